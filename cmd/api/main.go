@@ -3,40 +3,61 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	_ "github.com/lib/pq" // postgres driver
+	"github.com/lib/pq"
 
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/api"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/captcha"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/config"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/dbhealth"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/dbretry"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/embed"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/errorreport"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fraud"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/geoip"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/lockout"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/logging"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/prefill"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/querywatch"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/redact"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/retention"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/settings"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
 	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
 	// ── Logger ────────────────────────────────────────────────────────────────
-	// JSON in production, pretty text in development.
-	var logger *slog.Logger
-	if os.Getenv("ENV") == "production" {
-		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		}))
-	} else {
-		logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		}))
-	}
+	// JSON in production, pretty text in development. This bootstrap logger is
+	// only used until the config is loaded; run replaces it with one built
+	// from LOG_LEVEL and LOG_DEBUG_SAMPLE_RATE.
+	logger := logging.New(os.Stdout, logging.Options{
+		JSON:            os.Getenv("ENV") == "production",
+		Level:           slog.LevelInfo,
+		DebugSampleRate: 1,
+		Redact:          redact.String,
+	})
 	slog.SetDefault(logger)
 
 	if err := run(logger); err != nil {
@@ -45,85 +66,426 @@ func main() {
 	}
 }
 
-func run(logger *slog.Logger) error {
+func run(logger *slog.Logger) (err error) {
 	// ── Config ────────────────────────────────────────────────────────────────
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("config: %w", err)
 	}
-	logger.Info("config loaded", "env", cfg.Env, "port", cfg.Port)
+
+	logger, err = newLogger(cfg)
+	if err != nil {
+		return fmt.Errorf("logger: %w", err)
+	}
+	slog.SetDefault(logger)
+	logger.Info("config loaded", "env", cfg.Env, "port", cfg.Port, "strict", cfg.Strict)
+	logger.Info("config report", "config", cfg.Redacted())
+	for _, w := range cfg.Warnings {
+		logger.Warn("config warning", "var", w.Var, "warning", w.Msg)
+	}
+
+	// ── Error reporting ───────────────────────────────────────────────────────
+	// From here on a fatal error is reported before run returns. Config
+	// errors above are not: without config there is nowhere to send them.
+	reporter, err := errorreport.New(errorreport.Config{
+		DSN:         cfg.SentryDSN,
+		Environment: cfg.SentryEnvironment,
+		Release:     cfg.Release,
+	}, logger)
+	if err != nil {
+		return fmt.Errorf("error reporting: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			reporter.CaptureError(context.Background(), err, map[string]string{"component": "main"})
+		}
+		reporter.Flush(5 * time.Second)
+	}()
 
 	// ── Database ──────────────────────────────────────────────────────────────
-	pool, queries, err := openDB(cfg.DatabaseURL)
+	// Every statement, on the pool, the replica and in transactions, is
+	// timed out, logged when slow and counted for GET /api/admin/queries.
+	// Reads outside transactions are also retried when a failover drops
+	// their connection; each attempt is timed on its own.
+	watch := querywatch.New(querywatch.Config{
+		Timeout:       cfg.DBQueryTimeout,
+		SlowThreshold: cfg.DBSlowQueryThreshold,
+	}, logger)
+	wrapPool := func(h db.DBTX) db.DBTX {
+		return dbretry.Wrap(watch.Wrap(h), dbretry.Config{Attempts: cfg.DBReadMaxAttempts}, logger)
+	}
+	pool, queries, err := openDB(cfg.DatabaseURL, cfg, wrapPool)
 	if err != nil {
 		return fmt.Errorf("database: %w", err)
 	}
 	defer pool.Close()
-	logger.Info("database connected")
+	logger.Info("database connected",
+		"max_open_conns", pool.Stats().MaxOpenConnections,
+		"max_idle_conns", cfg.DBMaxIdleConns,
+		"conn_max_lifetime", cfg.DBConnMaxLifetime,
+		"conn_max_idle_time", cfg.DBConnMaxIdleTime,
+	)
+
+	// Probed in the background; during an outage the API serves degraded
+	// instead of every request waiting on the pool.
+	dbMonitor := dbhealth.New(pool, dbhealth.Config{
+		Interval: cfg.DBProbeInterval,
+		Failures: cfg.DBProbeFailures,
+	}, logger)
+
+	// ── Scoring configs ───────────────────────────────────────────────────────
+	// An invalid scoring_config fails every report that answers the question,
+	// so find out now rather than after a customer has paid.
+	if err := checkScoringConfigs(context.Background(), queries, cfg.Strict, logger); err != nil {
+		return fmt.Errorf("scoring configs: %w", err)
+	}
 
 	// ── Store (atomic multi-step writes) ──────────────────────────────────────
 	st := store.New(pool, queries)
+	st.SetTxAttempts(cfg.DBTxMaxAttempts)
+	st.SetTxWrapper(watch.Wrap)
+	if cfg.DatabaseReadURL != "" {
+		readPool, readQueries, err := openDB(cfg.DatabaseReadURL, cfg, wrapPool)
+		if err != nil {
+			// Not fatal: report reads stay on the primary.
+			logger.Error("database: read replica unavailable, reading reports from the primary", "error", err)
+		} else {
+			defer readPool.Close()
+			st.SetReplica(readQueries)
+			logger.Info("database: read replica connected")
+		}
+	}
+	st.SetDuplicatePolicy(store.DuplicatePolicy{
+		Window:            cfg.DuplicatePurchaseWindow,
+		MaxChangedAnswers: cfg.DuplicateMaxChangedAnswers,
+	})
+
+	// Email addresses and Stripe payloads are encrypted at rest when
+	// FIELD_ENCRYPTION_KEYS is set. Everything below uses st.Q(), which
+	// decrypts them; queries itself would return ciphertext.
+	codec, err := fieldcrypt.New(cfg.FieldEncryptionKeys, cfg.FieldIndexKey)
+	if err != nil {
+		return fmt.Errorf("field encryption: %w", err)
+	}
+	st.SetCodec(codec)
+	q := st.Q()
+	logger.Info("field encryption", "enabled", codec.Enabled(), "primary_key", codec.PrimaryKeyID())
+
+	// ── GeoIP (optional) ──────────────────────────────────────────────────────
+	// Request logs carry the client's country; the lookup never leaves the
+	// process.
+	var geo *geoip.DB
+	if cfg.GeoIPDB != "" {
+		geo, err = geoip.Open(cfg.GeoIPDB)
+		if err != nil {
+			return err
+		}
+		logger.Info("geoip loaded", "ranges", geo.Len())
+	}
 
 	// ── Stripe ────────────────────────────────────────────────────────────────
 	stripeClient := stripeinternal.NewClient(cfg.StripeSecretKey)
 
+	// ── Runtime settings (hot-reloadable) ─────────────────────────────────────
+	// Values in the runtime_settings table override these defaults and are
+	// re-read every SETTINGS_RELOAD_INTERVAL, or immediately on SIGHUP.
+	defaults := settings.Defaults()
+	defaults.PollInterval = cfg.PollInterval
+	watcher := settings.NewWatcher(q, defaults, cfg.SettingsReloadInterval, logger)
+	if err := watcher.Reload(context.Background()); err != nil {
+		// Not fatal: the defaults are safe and the watcher retries on its tick.
+		logger.Error("settings: initial load failed, using defaults", "error", err)
+	}
+
+	// ── Feature flags ─────────────────────────────────────────────────────────
+	// FEATURE_FLAGS sets the defaults; feature_flags rows override them and
+	// are reloaded on the same interval and signal as the runtime settings.
+	flagRules, err := flags.ParseRules(cfg.FeatureFlags)
+	if err != nil {
+		return fmt.Errorf("FEATURE_FLAGS: %w", err)
+	}
+	flagWatcher := flags.NewWatcher(q, cfg.Env, flagRules, cfg.SettingsReloadInterval, logger)
+	if err := flagWatcher.Reload(context.Background()); err != nil {
+		logger.Error("flags: initial load failed, using FEATURE_FLAGS", "error", err)
+	}
+	logger.Info("flags: feature flags loaded", "environment", cfg.Env, "flags", flagWatcher.Current().String())
+
+	// ── A/B experiments ───────────────────────────────────────────────────────
+	experimentSet, err := experiments.New(cfg.Experiments, cfg.ExperimentPrices)
+	if err != nil {
+		return fmt.Errorf("EXPERIMENTS: %w", err)
+	}
+	if names := experimentSet.Names(); len(names) > 0 {
+		logger.Info("experiments: running", "experiments", strings.Join(names, ","))
+	}
+
+	// ── API versions ──────────────────────────────────────────────────────────
+	apiDeprecations, err := api.ParseDeprecations(cfg.APIDeprecations)
+	if err != nil {
+		return fmt.Errorf("API_DEPRECATIONS: %w", err)
+	}
+
 	// ── AI ────────────────────────────────────────────────────────────────────
-	// DeepSeek is primary. Anthropic is the fallback when ANTHROPIC_API_KEY is
-	// also set. In production, set both keys for maximum resilience.
-	var hedger ai.Hedger
-	switch {
-	case cfg.DeepSeekAPIKey != "" && cfg.AnthropicAPIKey != "":
-		primary := ai.NewDeepSeekClient(cfg.DeepSeekAPIKey, cfg.DeepSeekModel)
-		secondary := ai.NewAnthropicClient(cfg.AnthropicAPIKey, cfg.AnthropicModel)
-		hedger = ai.NewFallbackHedger(primary, secondary, logger)
-		logger.Info("ai: using DeepSeek with Anthropic fallback")
-	case cfg.DeepSeekAPIKey != "":
-		hedger = ai.NewDeepSeekClient(cfg.DeepSeekAPIKey, cfg.DeepSeekModel)
-		logger.Info("ai: using DeepSeek only")
-	default:
-		hedger = ai.NewAnthropicClient(cfg.AnthropicAPIKey, cfg.AnthropicModel)
-		logger.Info("ai: using Anthropic only")
+	// Every configured provider joins the chain. The order defaults to DeepSeek
+	// then Anthropic and can be changed at runtime via ai_provider_order. In
+	// production, set both keys for maximum resilience.
+	providers := map[string]ai.Hedger{}
+	if cfg.DeepSeekAPIKey != "" {
+		providers[settings.ProviderDeepSeek] = ai.NewDeepSeekClient(cfg.DeepSeekAPIKey, cfg.DeepSeekModel, cfg.DeepSeekAnalysisModel, cfg.DeepSeekTimeout, ai.Tuning{
+			MaxTokens:   cfg.DeepSeekMaxTokens,
+			Temperature: cfg.DeepSeekTemperature,
+		})
+	}
+	if cfg.AnthropicAPIKey != "" {
+		providers[settings.ProviderAnthropic] = ai.NewAnthropicClient(cfg.AnthropicAPIKey, cfg.AnthropicModel, cfg.AnthropicAnalysisModel, cfg.AnthropicTimeout, ai.Tuning{
+			MaxTokens:   cfg.AnthropicMaxTokens,
+			Temperature: cfg.AnthropicTemperature,
+		}, logger)
 	}
 
+	// Ping every provider now and every AI_HEALTH_INTERVAL. Providers that
+	// failed their last check are moved to the back of the chain.
+	aiHealth := ai.NewHealthChecker(providers, cfg.AIHealthInterval, logger)
+	aiHealth.CheckAll(context.Background())
+
+	// The quality gate sits inside the guard so it sees hallucinated question
+	// IDs before the guard quietly drops them.
+	chain := ai.NewProviderChain(providers, func() []string {
+		return aiHealth.Prioritise(watcher.Current().AIProviderOrder)
+	}, logger)
+	hedger := ai.NewGuardedHedger(ai.NewQualityGate(chain, cfg.AIBannedPhrases, logger), logger)
+	logger.Info("ai: providers configured",
+		"providers", len(providers),
+		"order", strings.Join(watcher.Current().AIProviderOrder, ","),
+		"health", aiHealth.Status(),
+	)
+
 	// ── Email (Resend) ────────────────────────────────────────────────────────
 	mailer := email.NewResendClient(
 		cfg.ResendAPIKey,
 		cfg.EmailFromAddr,
 		cfg.EmailFromName,
 		cfg.BaseURL,
+		cfg.ConsultationURL,
 	)
 
+	// ── Object storage ────────────────────────────────────────────────────────
+	// Holds CSV exports and AI transcripts when STORAGE_BUCKET is set.
+	var artifacts storage.Store
+	if cfg.StorageBucket != "" {
+		artifacts, err = storage.New(storage.Config{
+			Endpoint:        cfg.StorageEndpoint,
+			Region:          cfg.StorageRegion,
+			Bucket:          cfg.StorageBucket,
+			AccessKeyID:     cfg.StorageAccessKeyID,
+			SecretAccessKey: cfg.StorageSecretAccessKey,
+			PathStyle:       cfg.StoragePathStyle,
+			URLTTL:          cfg.StorageURLTTL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// ── Worker ────────────────────────────────────────────────────────────────
-	job := worker.NewJob(queries, st, hedger, mailer, logger)
-	runner := worker.NewRunner(job, st, queries, worker.RunnerConfig{
-		Workers:      cfg.WorkerCount,
-		PollInterval: cfg.PollInterval,
-		JobTimeout:   cfg.JobTimeout,
-		MaxRetries:   cfg.MaxRetries,
+	// Reminds customers who never opened their report email. Opens come from
+	// the Resend webhook, so without its secret nothing can be detected.
+	resendAfter := cfg.EmailResendAfter
+	if cfg.ResendWebhookSecret == "" {
+		resendAfter = 0
+	}
+
+	job := worker.NewJob(q, st, hedger, mailer, worker.JobConfig{
+		AIChunkSize:       cfg.AIChunkSize,
+		AICacheTTL:        cfg.AICacheTTL,
+		PlaybookSnippets:  cfg.AIPlaybookSnippets,
+		AIMaxReportTokens: cfg.AIMaxReportTokens,
+		Settings:          watcher,
+		Flags:             flagWatcher,
+		Experiments:       experimentSet,
+		Transcripts:       cfg.AITranscripts,
+		Storage:           artifacts,
+		ReminderAfter:     resendAfter,
+		FeedbackAfter:     cfg.FeedbackRequestAfter,
+	}, logger)
+	runner := worker.NewRunner(job, st, q, worker.RunnerConfig{
+		Workers:       cfg.WorkerCount,
+		PollInterval:  cfg.PollInterval,
+		JobTimeout:    cfg.JobTimeout,
+		MaxRetries:    cfg.MaxRetries,
+		Settings:      watcher,
+		ErrorReporter: reporter,
+		InstanceID:    cfg.WorkerID,
 	}, logger)
 
+	// Each delivered report schedules a reminder, should its email go
+	// unopened, and a request for a rating and testimonial; these run them.
+	resender := worker.NewResender(q, mailer, worker.ResendConfig{After: resendAfter}, logger)
+	runner.Register(resender.Spec())
+	feedback := worker.NewFeedbackRequester(q, mailer, worker.FeedbackConfig{After: cfg.FeedbackRequestAfter}, logger)
+	runner.Register(feedback.Spec())
+
+	// ── Retention ─────────────────────────────────────────────────────────────
+	// Deletes data past its RETENTION_* window every RETENTION_INTERVAL, as a
+	// cleanup_sessions job that also prunes finished jobs. With no window set
+	// only the jobs are pruned.
+	enforcer := retention.NewEnforcer(q, retention.Config{
+		Answers:       cfg.RetentionAnswers,
+		StripeEvents:  cfg.RetentionStripeEvents,
+		EmailLog:      cfg.RetentionEmailLog,
+		AICache:       cfg.RetentionAICache,
+		AITranscripts: cfg.RetentionAITranscripts,
+		Storage:       artifacts,
+		Interval:      cfg.RetentionInterval,
+		DryRun:        cfg.RetentionDryRun,
+	}, logger)
+	runner.Register(worker.CleanupSessionsSpec(enforcer, q, logger))
+
+	// ── Fraud checks ──────────────────────────────────────────────────────────
+	fraudChecker := fraud.NewChecker(fraud.Config{
+		Mode:                 fraud.Mode(cfg.FraudMode),
+		MaxSessionsPerIPHour: cfg.FraudIPSessionsPerHour,
+		MaxFailedPayments:    cfg.FraudMaxFailedPayments,
+		DisposableDomains:    cfg.FraudDisposableDomains,
+	}, q, logger)
+
+	// ── Shared state ──────────────────────────────────────────────────────────
+	// With REDIS_URL the replicas share lockout counts and cached reports, and
+	// hear each other's admin setting changes. Without it each keeps its own.
+	var redisClient *redis.Client
+	if cfg.RedisURL != "" {
+		redisClient, err = cluster.Connect(context.Background(), cfg.RedisURL)
+		if err != nil {
+			return fmt.Errorf("REDIS_URL: %w", err)
+		}
+		defer redisClient.Close()
+		logger.Info("redis connected, sharing state between replicas")
+	}
+	reloads := cluster.NewNotifier(redisClient, logger)
+
+	// ── Report link lockout ───────────────────────────────────────────────────
+	reportIPLockout := lockout.New(lockout.Config{
+		MaxFailures: cfg.ReportLockoutIPFailures,
+		Window:      cfg.ReportLockoutWindow,
+		Duration:    cfg.ReportLockoutDuration,
+		Redis:       redisClient,
+		Logger:      logger,
+		Name:        "report_ip",
+	})
+	reportTokenLockout := lockout.New(lockout.Config{
+		MaxFailures: cfg.ReportLockoutTokenFailures,
+		Window:      cfg.ReportLockoutWindow,
+		Duration:    cfg.ReportLockoutDuration,
+		Redis:       redisClient,
+		Logger:      logger,
+		Name:        "report_token",
+	})
+
+	// ── Report link resend limits ─────────────────────────────────────────────
+	reportResendIPLimit := lockout.New(lockout.Config{
+		MaxFailures: cfg.ReportResendIPLimit,
+		Window:      cfg.ReportResendWindow,
+		Duration:    cfg.ReportResendWindow,
+		Redis:       redisClient,
+		Logger:      logger,
+		Name:        "resend_ip",
+	})
+	reportResendEmailLimit := lockout.New(lockout.Config{
+		MaxFailures: cfg.ReportResendEmailLimit,
+		Window:      cfg.ReportResendWindow,
+		Duration:    cfg.ReportResendWindow,
+		Redis:       redisClient,
+		Logger:      logger,
+		Name:        "resend_email",
+	})
+
+	// ── Partner pre-fill ──────────────────────────────────────────────────────
+	partnerKeys, err := prefill.ParseKeys(cfg.PartnerKeys)
+	if err != nil {
+		return fmt.Errorf("partner keys: %w", err)
+	}
+	embedIssuer, err := embed.New(embed.Config{
+		Keys:    partnerKeys,
+		Origins: cfg.EmbedOrigins,
+		TTL:     cfg.EmbedTokenTTL,
+	})
+	if err != nil {
+		return err
+	}
+
+	// ── Bot protection ────────────────────────────────────────────────────────
+	var captchaVerifier captcha.Verifier
+	if verifyURL, ok := captcha.VerifyURL(cfg.CaptchaProvider); ok {
+		captchaVerifier = captcha.New(verifyURL, cfg.CaptchaSecret)
+	}
+
 	// ── HTTP server ───────────────────────────────────────────────────────────
+	conns := &api.ConnTracker{}
 	handler := api.NewServer(
-		queries,
+		st.ReplicaQ(),
 		st,
 		stripeClient,
 		runner, // *Runner satisfies worker.Enqueuer
 		mailer,
 		api.Config{
-			BaseURL:             cfg.BaseURL,
-			StripeWebhookSecret: cfg.StripeWebhookSecret,
-			Env:                 cfg.Env,
+			BaseURL:                cfg.BaseURL,
+			StripeWebhookSecrets:   cfg.StripeWebhookSecrets,
+			ResendWebhookSecret:    cfg.ResendWebhookSecret,
+			Env:                    cfg.Env,
+			CompressionLevel:       cfg.CompressionLevel,
+			AdminAPIKey:            cfg.AdminAPIKey,
+			ConfigReport:           cfg.Redacted(),
+			Settings:               watcher,
+			Flags:                  flagWatcher,
+			Experiments:            experimentSet,
+			ConsultationURL:        cfg.ConsultationURL,
+			APIDeprecations:        apiDeprecations,
+			StripeTax:              cfg.StripeTaxEnabled,
+			DuplicateAutoRefund:    cfg.DuplicateAutoRefund,
+			InvoiceIssuer:          cfg.InvoiceIssuer,
+			StrictAnswers:          cfg.StrictAnswers,
+			SectionGating:          cfg.SectionGating,
+			AnswerBatchHeadroom:    cfg.AnswerBatchHeadroom,
+			CohortReportsPerMinute: cfg.CohortReportsPerMinute,
+			CohortMaxRows:          cfg.CohortMaxRows,
+			Partners:               partnerKeys,
+			Embed:                  embedIssuer,
+			Fraud:                  fraudChecker,
+			Captcha:                captchaVerifier,
+			ReportIPLockout:        reportIPLockout,
+			ReportTokenLockout:     reportTokenLockout,
+			ReportResendIPLimit:    reportResendIPLimit,
+			ReportResendEmailLimit: reportResendEmailLimit,
+			IPHashSalt:             cfg.IPHashSalt,
+			IPPrivacyMode:          cfg.IPPrivacyMode,
+			GeoIP:                  geo,
+			TrustedProxies:         cfg.TrustedProxies,
+			ErrorReporter:          reporter,
+			ReadinessChecks:        readinessChecks(pool, aiHealth, providers),
+			DBHealth:               dbMonitor,
+			ReportCacheSize:        cfg.ReportCacheSize,
+			ReportCacheTTL:         cfg.ReportCacheTTL,
+			Redis:                  redisClient,
+			Storage:                artifacts,
+			Reloads:                reloads,
+			Queries:                watch,
+			Conns:                  conns,
+			StripeEvents:           runner,
 		},
 		logger,
 	)
 
 	srv := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 60 * time.Second, // generous — report endpoint can be slow on first hit
-		IdleTimeout:  120 * time.Second,
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout, // generous — report endpoint can be slow on first hit
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		ConnState:         conns.ConnState,
 	}
+	srv.SetKeepAlivesEnabled(cfg.HTTPKeepAlives)
 
 	// ── Graceful shutdown ─────────────────────────────────────────────────────
 	// Root context cancelled by OS signal. Worker and HTTP server both respect it.
@@ -132,12 +494,32 @@ func run(logger *slog.Logger) error {
 
 	// Start the worker pool in a background goroutine. It blocks until ctx is done.
 	go runner.Start(ctx)
+	go runner.Every(ctx, worker.JobCleanupSessions, enforcer.Interval())
+
+	// Keep runtime settings fresh: periodically, and on demand via SIGHUP.
+	go watcher.Start(ctx)
+	go flagWatcher.Start(ctx)
+	go aiHealth.Start(ctx)
+	go dbMonitor.Start(ctx)
+	go reloadOnSIGHUP(ctx, watcher, flagWatcher, logger)
+	go drainOnSIGUSR1(ctx, runner, logger)
+	go reloads.Listen(ctx, cluster.TopicReload, func(ctx context.Context) {
+		logger.Info("settings: change announced by another replica, reloading")
+		reloadSettings(ctx, watcher, flagWatcher, logger)
+	})
 
 	// Start the HTTP server in a background goroutine.
 	serverErr := make(chan error, 1)
 	go func() {
-		logger.Info("server listening", "addr", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Info("server listening", "addr", srv.Addr, "tls", cfg.TLSCertFile != "")
+		var err error
+		if cfg.TLSCertFile != "" {
+			// With TLS the standard library negotiates HTTP/2 via ALPN.
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			serverErr <- err
 		}
 	}()
@@ -164,21 +546,138 @@ func run(logger *slog.Logger) error {
 	return nil
 }
 
+// readinessChecks builds the /readyz probes. The database is critical; AI is
+// critical only when every provider is down, since one healthy provider is
+// enough to generate reports.
+func readinessChecks(pool *sql.DB, aiHealth *ai.HealthChecker, providers map[string]ai.Hedger) []api.ReadinessCheck {
+	checks := []api.ReadinessCheck{
+		{Name: "database", Critical: true, Check: pool.PingContext},
+		{Name: "ai", Critical: true, Check: func(context.Context) error { return aiHealth.AnyHealthy() }},
+	}
+	for name := range providers {
+		checks = append(checks, api.ReadinessCheck{
+			Name:  "ai:" + name,
+			Check: func(context.Context) error { return aiHealth.Err(name) },
+		})
+	}
+	return checks
+}
+
+// checkScoringConfigs validates the scoring_config of every scoring question
+// and logs each invalid one. In strict mode (CONFIG_STRICT) any invalid config
+// is returned as an error so the process refuses to start.
+func checkScoringConfigs(ctx context.Context, q db.Querier, strict bool, logger *slog.Logger) error {
+	questions, err := q.GetScoringQuestions(ctx)
+	if err != nil {
+		return fmt.Errorf("list questions: %w", err)
+	}
+	configs := make(map[string]json.RawMessage, len(questions))
+	for _, qd := range questions {
+		configs[qd.ID] = qd.ScoringConfig
+	}
+
+	invalid := scoring.ValidateConfigs(configs)
+	for _, e := range invalid {
+		logger.Error("scoring: invalid scoring_config; reports answering this question will fail",
+			"question_id", e.QuestionID,
+			"error", e.Err,
+		)
+	}
+	if len(invalid) > 0 && strict {
+		return fmt.Errorf("%d of %d scoring configs are invalid (see armctl validate-scoring-configs)", len(invalid), len(questions))
+	}
+	if len(invalid) == 0 {
+		logger.Info("scoring: configs valid", "questions", len(questions))
+	}
+	return nil
+}
+
+// reloadOnSIGHUP reloads runtime settings and feature flags each time the
+// process receives SIGHUP, so operators can apply a table change without
+// waiting for the next reload tick: `kill -HUP <pid>`.
+func reloadOnSIGHUP(ctx context.Context, watcher *settings.Watcher, flagWatcher *flags.Watcher, logger *slog.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Info("settings: SIGHUP received, reloading")
+			reloadSettings(ctx, watcher, flagWatcher, logger)
+		}
+	}
+}
+
+// drainOnSIGUSR1 drains the replica when the process receives SIGUSR1, for
+// deploys that signal rather than call POST /internal/drain: `kill -USR1 <pid>`.
+func drainOnSIGUSR1(ctx context.Context, runner *worker.Runner, logger *slog.Logger) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	defer signal.Stop(usr1)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-usr1:
+			logger.Info("drain: SIGUSR1 received")
+			runner.Drain()
+		}
+	}
+}
+
+// reloadSettings reloads runtime settings and feature flags, logging failures.
+func reloadSettings(ctx context.Context, watcher *settings.Watcher, flagWatcher *flags.Watcher, logger *slog.Logger) {
+	if err := watcher.Reload(ctx); err != nil {
+		logger.Error("settings: reload failed", "error", err)
+	}
+	if err := flagWatcher.Reload(ctx); err != nil {
+		logger.Error("flags: reload failed", "error", err)
+	}
+}
+
+// newLogger builds the process logger from config: JSON in production, text
+// elsewhere, with debug lines sampled at LogDebugSampleRate and per-job
+// context attributes (see logging.With) attached automatically. Emails and
+// tokens are redacted unless LOG_REDACT is off.
+func newLogger(cfg *config.Config) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, err
+	}
+	opts := logging.Options{
+		JSON:            cfg.Env == "production",
+		Level:           level,
+		DebugSampleRate: cfg.LogDebugSampleRate,
+	}
+	if cfg.LogRedact {
+		opts.Redact = redact.String
+	}
+	return logging.New(os.Stdout, opts), nil
+}
+
 // openDB opens the connection pool and verifies connectivity.
 // Uses db.New (unprepared queries) instead of db.Prepare so the app works
 // with PgBouncer in transaction-pooling mode (e.g. Supabase port 6543).
-// Prepared statements are incompatible with transaction-mode pooling.
-func openDB(dsn string) (*sql.DB, *db.Queries, error) {
-	pool, err := sql.Open("postgres", dsn)
+// Prepared statements are incompatible with transaction-mode pooling. The
+// pool is sized by cfg's DB_* settings and the queries run on wrap(pool).
+// Connections go through querywatch.Connector so each query's timeout is
+// released once its rows are read.
+func openDB(dsn string, cfg *config.Config, wrap func(db.DBTX) db.DBTX) (*sql.DB, *db.Queries, error) {
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, nil, fmt.Errorf("open: %w", err)
 	}
+	pool := sql.OpenDB(querywatch.Connector(connector))
 
 	// Tune the connection pool.
-	pool.SetMaxOpenConns(25)
-	pool.SetMaxIdleConns(10)
-	pool.SetConnMaxLifetime(5 * time.Minute)
-	pool.SetConnMaxIdleTime(2 * time.Minute)
+	pool.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	pool.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	pool.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	pool.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
 	// Verify the connection is reachable before proceeding.
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -192,7 +691,7 @@ func openDB(dsn string) (*sql.DB, *db.Queries, error) {
 	// db.New uses unprepared queries — compatible with PgBouncer transaction
 	// pooling mode. If you ever switch to a direct connection you can swap this
 	// back to db.Prepare for startup-time schema validation.
-	queries := db.New(pool)
+	queries := db.New(wrap(pool))
 
 	return pool, queries, nil
 }
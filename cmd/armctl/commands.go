@@ -0,0 +1,685 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/retention"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/seed"
+)
+
+// ─── REPORTS ──────────────────────────────────────────────────────────────────
+
+func requeueReport(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("requeue-report", flag.ContinueOnError)
+	narrative := fs.Bool("narrative", false, "keep the stored AI analysis and regenerate only the narrative")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	reportID, err := parseID(rest[0], "report")
+	if err != nil {
+		return err
+	}
+	st, err := env.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	requeue := st.RequeueReport
+	if *narrative {
+		requeue = st.RequeueReportNarrative
+	}
+	report, err := requeue(ctx, reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("report %s not found", reportID)
+	}
+	if err != nil {
+		return err
+	}
+	env.logger.Info("armctl: report requeued", "report_id", report.ID, "narrative_only", *narrative, "audit", true)
+	if *narrative && !report.AiAnalysis.Valid {
+		fmt.Printf("report %s has no stored AI analysis; both AI stages will run again\n", report.ID)
+	}
+	fmt.Printf("report %s requeued; the worker will pick it up on its next poll\n", report.ID)
+	return nil
+}
+
+func markReportFailed(ctx context.Context, env *env, args []string) error {
+	rest, err := parseArgs(flag.NewFlagSet("mark-report-failed", flag.ContinueOnError), args, -2)
+	if err != nil {
+		return err
+	}
+	reportID, err := parseID(rest[0], "report")
+	if err != nil {
+		return err
+	}
+	reason := joinArgs(rest[1:])
+	if reason == "" {
+		return errUsage
+	}
+	st, err := env.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, err := st.MarkReportFailed(ctx, reportID, reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("report %s not found", reportID)
+	}
+	if err != nil {
+		return err
+	}
+	env.logger.Info("armctl: report marked failed", "report_id", report.ID, "reason", reason, "audit", true)
+	fmt.Printf("report %s marked failed\n", report.ID)
+	return nil
+}
+
+func resendReportEmail(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("resend-report-email", flag.ContinueOnError)
+	to := fs.String("to", "", "send to this address instead of the session's email")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	reportID, err := parseID(rest[0], "report")
+	if err != nil {
+		return err
+	}
+	q, err := env.queries(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, err := q.GetReportByID(ctx, reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("report %s not found", reportID)
+	}
+	if err != nil {
+		return fmt.Errorf("get report: %w", err)
+	}
+	if report.Status != db.ReportStatusReady {
+		return fmt.Errorf("report %s is %s; only ready reports can be emailed", reportID, report.Status)
+	}
+	session, err := q.GetSessionByID(ctx, report.SessionID)
+	if err != nil {
+		return fmt.Errorf("get session: %w", err)
+	}
+
+	recipient := *to
+	if recipient == "" {
+		if !session.Email.Valid || session.Email.String == "" {
+			return fmt.Errorf("session %s has no email address; pass -to", session.ID)
+		}
+		recipient = session.Email.String
+	}
+
+	cfg := env.cfg
+	mailer := email.NewResendClient(cfg.ResendAPIKey, cfg.EmailFromAddr, cfg.EmailFromName, cfg.BaseURL, cfg.ConsultationURL)
+	sent, err := mailer.SendReportReady(ctx, email.ReportReadyParams{
+		To:          recipient,
+		BizName:     session.BizName.String,
+		AccessToken: report.AccessToken,
+	})
+	if err := email.Record(ctx, q, email.LogEntry{
+		SessionID: session.ID,
+		ReportID:  report.ID,
+		To:        recipient,
+		Template:  email.TemplateReportReady,
+	}, sent, err); err != nil {
+		env.logger.Warn("armctl: could not record report email", "report_id", report.ID, "error", err)
+	}
+	if err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	env.logger.Info("armctl: report email resent", "report_id", report.ID, "to", recipient, "audit", true)
+	fmt.Printf("report email for %s sent\n", report.ID)
+	return nil
+}
+
+// ─── SESSIONS ─────────────────────────────────────────────────────────────────
+
+// sessionInspection is what inspect-session prints. The anon token is left
+// out: it is a live credential for the session. Emails shows whether the
+// customer opened their report email, for "I never got it" tickets, and
+// Notes what support has already recorded about the case.
+type sessionInspection struct {
+	Session  db.Session       `json:"session"`
+	Answered int64            `json:"answered"`
+	Report   *reportSummary   `json:"report"`
+	Emails   []db.EmailLog    `json:"emails"`
+	Notes    []db.SupportNote `json:"notes"`
+}
+
+type reportSummary struct {
+	ID              uuid.UUID          `json:"id"`
+	Status          db.ReportStatus    `json:"status"`
+	ErrorMessage    string             `json:"error_message,omitempty"`
+	OverallScore    *int16             `json:"overall_score,omitempty"`
+	CriticalCount   *int16             `json:"critical_count,omitempty"`
+	GeneratedAt     *time.Time         `json:"generated_at,omitempty"`
+	AIAnalysis      json.RawMessage    `json:"ai_analysis,omitempty"`
+	AINarrative     json.RawMessage    `json:"ai_narrative,omitempty"`
+	AIQualityIssues string             `json:"ai_quality_issues,omitempty"`
+	AIBudgetNote    string             `json:"ai_budget_note,omitempty"`
+	FailedAttempts  []db.ReportAttempt `json:"failed_attempts,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+func inspectSession(ctx context.Context, env *env, args []string) error {
+	rest, err := parseArgs(flag.NewFlagSet("inspect-session", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	sessionID, err := parseID(rest[0], "session")
+	if err != nil {
+		return err
+	}
+	q, err := env.queries(ctx)
+	if err != nil {
+		return err
+	}
+
+	session, err := q.GetSessionByID(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	if err != nil {
+		return fmt.Errorf("get session: %w", err)
+	}
+	session.AnonToken = ""
+
+	answered, err := q.CountAnsweredBySession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("count answers: %w", err)
+	}
+	out := sessionInspection{Session: session, Answered: answered}
+
+	report, err := q.GetReportBySessionID(ctx, sessionID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("get report: %w", err)
+	default:
+		out.Report = summariseReport(report)
+		out.Report.FailedAttempts, err = q.ListReportAttempts(ctx, report.ID)
+		if err != nil {
+			return fmt.Errorf("list report attempts: %w", err)
+		}
+	}
+
+	out.Emails, err = q.ListEmailLogBySession(ctx, uuid.NullUUID{UUID: sessionID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("list emails: %w", err)
+	}
+	out.Notes, err = q.ListSupportNotesBySession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("list support notes: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func summariseReport(r db.Report) *reportSummary {
+	s := &reportSummary{
+		ID:              r.ID,
+		Status:          r.Status,
+		ErrorMessage:    r.ErrorMessage.String,
+		AIQualityIssues: r.AiQualityIssues.String,
+		AIBudgetNote:    r.AiBudgetNote.String,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+	if r.OverallScore.Valid {
+		s.OverallScore = &r.OverallScore.Int16
+	}
+	if r.CriticalCount.Valid {
+		s.CriticalCount = &r.CriticalCount.Int16
+	}
+	if r.GeneratedAt.Valid {
+		s.GeneratedAt = &r.GeneratedAt.Time
+	}
+	if r.AiAnalysis.Valid {
+		s.AIAnalysis = r.AiAnalysis.RawMessage
+	}
+	if r.AiNarrative.Valid {
+		s.AINarrative = r.AiNarrative.RawMessage
+	}
+	return s
+}
+
+// reportFailures summarises report_attempts, so a run of failures can be put
+// down to one provider and error rather than read one report at a time.
+func reportFailures(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("report-failures", flag.ContinueOnError)
+	since := fs.Duration("since", 24*time.Hour, "how far back to look")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	q, err := env.queries(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.SummariseReportAttempts(ctx, time.Now().Add(-*since))
+	if err != nil {
+		return fmt.Errorf("summarise report attempts: %w", err)
+	}
+	if len(rows) == 0 {
+		fmt.Printf("no failed report attempts in the last %s\n", *since)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tATTEMPTS\tREPORTS\tLAST SEEN\tPROVIDER ERROR")
+	for _, r := range rows {
+		provider := r.Provider
+		if provider == "" {
+			provider = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", provider, r.Attempts, r.Reports, r.LastSeen.UTC().Format(time.RFC3339), r.ProviderError)
+	}
+	return tw.Flush()
+}
+
+// ─── STRIPE ───────────────────────────────────────────────────────────────────
+
+// replayStripeEvent asks the running API to replay the event rather than
+// dispatching it here: the handlers live in the API process, which also owns
+// the worker queue a replayed payment is enqueued on.
+func replayStripeEvent(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("replay-stripe-event", flag.ContinueOnError)
+	apiURL := fs.String("api", "", "base URL of a running API (default http://localhost:$PORT)")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	eventID := rest[0]
+	cfg, err := env.config()
+	if err != nil {
+		return err
+	}
+	if cfg.AdminAPIKey == "" {
+		return errors.New("ADMIN_API_KEY is not set; the API has no admin routes to call")
+	}
+	base := *apiURL
+	if base == "" {
+		base = "http://localhost:" + cfg.Port
+	}
+
+	endpoint := strings.TrimRight(base, "/") + "/api/admin/stripe-events/" + url.PathEscape(eventID) + "/replay"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.AdminAPIKey)
+
+	resp, err := (&http.Client{Timeout: 60 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("call API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		EventID   string `json:"event_id"`
+		Type      string `json:"type"`
+		Processed bool   `json:"processed"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("decode API response: %w", err)
+	}
+	env.logger.Info("armctl: stripe event replayed", "event_id", result.EventID, "processed", result.Processed, "audit", true)
+	if !result.Processed {
+		return fmt.Errorf("%s (%s) failed again: %s", result.EventID, result.Type, result.Error)
+	}
+	fmt.Printf("%s (%s) processed\n", result.EventID, result.Type)
+	return nil
+}
+
+// ─── SCORING ──────────────────────────────────────────────────────────────────
+
+func validateScoringConfigs(ctx context.Context, env *env, args []string) error {
+	if _, err := parseArgs(flag.NewFlagSet("validate-scoring-configs", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+	q, err := env.queries(ctx)
+	if err != nil {
+		return err
+	}
+
+	questions, err := q.GetScoringQuestions(ctx)
+	if err != nil {
+		return fmt.Errorf("list questions: %w", err)
+	}
+	configs := make(map[string]json.RawMessage, len(questions))
+	for _, qd := range questions {
+		configs[qd.ID] = qd.ScoringConfig
+	}
+	invalid := scoring.ValidateConfigs(configs)
+	for _, e := range invalid {
+		fmt.Printf("%s\t%v\n", e.QuestionID, e.Err)
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%d of %d scoring configs are invalid", len(invalid), len(questions))
+	}
+	fmt.Printf("all %d scoring configs are valid\n", len(questions))
+	return nil
+}
+
+// scoreResult is what score -json prints.
+type scoreResult struct {
+	OverallScore  int                 `json:"overall_score"`
+	ScoreProfile  string              `json:"score_profile"`
+	Band          scoring.ScoreBand   `json:"band"`
+	CriticalCount int                 `json:"critical_count"`
+	Risks         []scoredRiskSummary `json:"risks"`
+}
+
+type scoredRiskSummary struct {
+	Rank       int              `json:"rank"`
+	QuestionID string           `json:"question_id"`
+	RiskName   string           `json:"risk_name"`
+	Section    string           `json:"section"`
+	P          int              `json:"p"`
+	I          int              `json:"i"`
+	Score      int              `json:"score"`
+	Tier       scoring.RiskTier `json:"tier"`
+}
+
+// scoreAnswers runs the worker's scoring over a seed file and an answers file
+// without touching config or the database, so scoring changes can be checked
+// before they are seeded.
+func scoreAnswers(_ context.Context, _ *env, args []string) error {
+	fs := flag.NewFlagSet("score", flag.ContinueOnError)
+	questionsPath := fs.String("questions", "", "seed file with the question definitions (required)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	profileFlag := fs.String("profile", "mean", "overall score profile: mean, weighted, top_n[:N] or max_dominant")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *questionsPath == "" {
+		return errUsage
+	}
+	profile, err := scoring.ParseProfile(*profileFlag)
+	if err != nil {
+		return err
+	}
+	file, err := seed.LoadFile(*questionsPath)
+	if err != nil {
+		return err
+	}
+	answers, err := loadAnswers(rest[0])
+	if err != nil {
+		return err
+	}
+	rows, err := file.AnswerRows(answers)
+	if err != nil {
+		return err
+	}
+	risks, err := scoring.ComputeRisks(rows)
+	if err != nil {
+		return err
+	}
+
+	overall := profile.OverallScore(risks)
+	out := scoreResult{
+		OverallScore:  overall,
+		ScoreProfile:  profile.String(),
+		Band:          scoring.Band(overall),
+		CriticalCount: scoring.CriticalCount(risks),
+		Risks:         make([]scoredRiskSummary, len(risks)),
+	}
+	for i, r := range risks {
+		out.Risks[i] = scoredRiskSummary{
+			Rank: r.Rank, QuestionID: r.QuestionID, RiskName: r.RiskName, Section: r.Section,
+			P: r.P, I: r.I, Score: r.Score, Tier: r.Tier,
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tQUESTION\tRISK\tTIER\tP\tI\tSCORE")
+	for _, r := range out.Risks {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%d\n", r.Rank, r.QuestionID, r.RiskName, r.Tier, r.P, r.I, r.Score)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\noverall score %d (%s, %s), %d critical, %d of %d answers scored\n",
+		out.OverallScore, out.Band, out.ScoreProfile, out.CriticalCount, len(risks), len(answers))
+	return nil
+}
+
+// loadAnswers reads an answers file: either the body of PUT
+// /api/session/{id}/answers, {"answers": [{"question_id", "answer_text"}]},
+// or a plain object of question ID to answer text.
+func loadAnswers(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Answers []struct {
+			QuestionID string `json:"question_id"`
+			AnswerText string `json:"answer_text"`
+		} `json:"answers"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Answers != nil {
+		answers := make(map[string]string, len(body.Answers))
+		for _, a := range body.Answers {
+			answers[a.QuestionID] = a.AnswerText
+		}
+		return answers, nil
+	}
+	var answers map[string]string
+	if err := json.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("answers file: expected {\"answers\": [...]} or an object of question id to answer: %w", err)
+	}
+	return answers, nil
+}
+
+// ─── QUESTIONS ────────────────────────────────────────────────────────────────
+
+func seedQuestions(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("seed-questions", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "write the changes (default: print them only)")
+	rest, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	file, err := seed.LoadFile(rest[0])
+	if err != nil {
+		return err
+	}
+	st, err := env.store(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := st.Q().GetAllQuestionDefinitions(ctx)
+	if err != nil {
+		return fmt.Errorf("list questions: %w", err)
+	}
+
+	plan := seed.Diff(file, existing)
+	fmt.Printf("seed file version %d: %d to insert, %d to update, %d unchanged\n",
+		file.Version, len(plan.Inserts), len(plan.Updates), plan.Unchanged)
+	for _, row := range plan.Inserts {
+		fmt.Printf("  + %s\n", row.ID)
+	}
+	for _, u := range plan.Updates {
+		fmt.Printf("  ~ %s (%s)\n", u.Row.ID, strings.Join(u.Fields, ", "))
+	}
+	for _, id := range plan.Missing {
+		fmt.Printf("  ? %s is in the database but not the file; left unchanged\n", id)
+	}
+
+	if plan.Empty() || !*apply {
+		if !plan.Empty() {
+			fmt.Println("dry run; pass -apply to write these changes")
+		}
+		return nil
+	}
+	if err := st.UpsertQuestionDefinitions(ctx, plan.Changes()); err != nil {
+		return err
+	}
+	env.logger.Info("armctl: question definitions seeded",
+		"version", file.Version,
+		"inserted", len(plan.Inserts),
+		"updated", len(plan.Updates),
+		"audit", true,
+	)
+	fmt.Println("applied")
+	return nil
+}
+
+func exportQuestions(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("export-questions", flag.ContinueOnError)
+	version := fs.Int("version", 1, "version number to write into the file")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	q, err := env.queries(ctx)
+	if err != nil {
+		return err
+	}
+	defs, err := q.GetAllQuestionDefinitions(ctx)
+	if err != nil {
+		return fmt.Errorf("list questions: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(seed.Export(defs, *version))
+}
+
+// ─── RETENTION ────────────────────────────────────────────────────────────────
+
+func applyRetention(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("retention", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "delete the expired rows (default: count them only)")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, err := env.config()
+	if err != nil {
+		return err
+	}
+	q, err := env.queries(ctx)
+	if err != nil {
+		return err
+	}
+	artifacts, err := env.artifacts()
+	if err != nil {
+		return err
+	}
+	enforcer := retention.NewEnforcer(q, retention.Config{
+		Answers:       cfg.RetentionAnswers,
+		StripeEvents:  cfg.RetentionStripeEvents,
+		EmailLog:      cfg.RetentionEmailLog,
+		AICache:       cfg.RetentionAICache,
+		AITranscripts: cfg.RetentionAITranscripts,
+		Storage:       artifacts,
+	}, env.logger)
+	if !enforcer.Enabled() {
+		fmt.Println("no RETENTION_* windows are set; everything is kept")
+		return nil
+	}
+
+	results, runErr := enforcer.Run(ctx, !*apply)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLASS\tWINDOW\tCUTOFF\tEXPIRED\tDELETED")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", r.Class, r.Window, r.Cutoff.UTC().Format(time.RFC3339), r.Expired, r.Deleted)
+		if *apply && r.Deleted > 0 {
+			env.logger.Info("armctl: expired data deleted", "class", r.Class, "deleted", r.Deleted, "audit", true)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if !*apply {
+		fmt.Println("dry run; pass -apply to delete these rows")
+	}
+	return nil
+}
+
+// ─── ENCRYPTION ───────────────────────────────────────────────────────────────
+
+func reencrypt(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	apply := fs.Bool("apply", false, "rewrite the stale values (default: count them only)")
+	decrypt := fs.Bool("decrypt", false, "write plaintext instead, before removing FIELD_ENCRYPTION_KEYS")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	st, err := env.store(ctx)
+	if err != nil {
+		return err
+	}
+
+	results, runErr := st.Reencrypt(ctx, *apply, *decrypt)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLUMN\tSCANNED\tSTALE\tREWRITTEN")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", r.Column, r.Scanned, r.Stale, r.Rewritten)
+		if r.Rewritten > 0 {
+			env.logger.Info("armctl: column reencrypted",
+				"column", r.Column,
+				"rewritten", r.Rewritten,
+				"decrypt", *decrypt,
+				"audit", true,
+			)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return runErr
+	}
+	if !*apply {
+		fmt.Println("dry run; pass -apply to rewrite these values")
+	}
+	return nil
+}
+
+// parseID parses a UUID argument, naming what it identifies in the error.
+func parseID(s, what string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s id %q", what, s)
+	}
+	return id, nil
+}
@@ -0,0 +1,280 @@
+// Command armctl performs one-off operator tasks against the same database and
+// configuration as the API, so nobody has to hand-write SQL in production:
+//
+//	armctl requeue-report [-narrative] <report-id>
+//	armctl mark-report-failed <report-id> <reason>
+//	armctl resend-report-email [-to <address>] <report-id>
+//	armctl inspect-session <session-id>
+//	armctl replay-stripe-event [-api <url>] <event-id>
+//	armctl validate-scoring-configs
+//	armctl seed-questions [-apply] <file>
+//	armctl export-questions [-version <n>]
+//	armctl score -questions <file> [-profile <p>] [-json] <answers-file>
+//	armctl retention [-apply]
+//	armctl reencrypt [-decrypt] [-apply]
+//
+// It reads configuration exactly as cmd/api does (environment, .env, _FILE
+// secrets, Vault), so run it inside the API's container or with its env. score
+// is the exception: it needs neither config nor a database.
+// Every command that changes state logs an audit line to stderr.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/config"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/logging"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/redact"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+)
+
+// command is one armctl subcommand.
+type command struct {
+	usage   string // arguments, shown after the command name
+	summary string
+	run     func(ctx context.Context, env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"requeue-report": {
+		usage:   "[-narrative] <report-id>",
+		summary: "discard a report's results and let the worker generate it again; -narrative reuses its AI analysis",
+		run:     requeueReport,
+	},
+	"mark-report-failed": {
+		usage:   "<report-id> <reason>",
+		summary: "stop the worker retrying a report and record why",
+		run:     markReportFailed,
+	},
+	"resend-report-email": {
+		usage:   "[-to <address>] <report-id>",
+		summary: "send the report-ready email again, optionally to another address",
+		run:     resendReportEmail,
+	},
+	"inspect-session": {
+		usage:   "<session-id>",
+		summary: "print a session with its report, answer count, emails and support notes as JSON",
+		run:     inspectSession,
+	},
+	"report-failures": {
+		usage:   "[-since <duration>]",
+		summary: "count failed report attempts by AI provider and error, over the last day by default",
+		run:     reportFailures,
+	},
+	"replay-stripe-event": {
+		usage:   "[-api <url>] <event-id>",
+		summary: "run a stored Stripe event through the running API's webhook handlers again",
+		run:     replayStripeEvent,
+	},
+	"seed-questions": {
+		usage:   "[-apply] <file>",
+		summary: "diff question_definitions against a seed file; -apply writes the changes",
+		run:     seedQuestions,
+	},
+	"export-questions": {
+		usage:   "[-version <n>]",
+		summary: "print question_definitions as a seed file",
+		run:     exportQuestions,
+	},
+	"score": {
+		usage:   "-questions <file> [-profile <p>] [-json] <answers-file>",
+		summary: "score an answers file against a seed file and print ranks, tiers and the overall score",
+		run:     scoreAnswers,
+	},
+	"reencrypt": {
+		usage:   "[-decrypt] [-apply]",
+		summary: "count values not under the primary FIELD_ENCRYPTION_KEYS key; -apply rewrites them",
+		run:     reencrypt,
+	},
+	"retention": {
+		usage:   "[-apply]",
+		summary: "count data past its RETENTION_* window; -apply deletes it",
+		run:     applyRetention,
+	},
+	"validate-scoring-configs": {
+		usage:   "",
+		summary: "check every question's scoring_config and list the invalid ones",
+		run:     validateScoringConfigs,
+	},
+}
+
+// errUsage makes main print the command's usage and exit 2.
+var errUsage = errors.New("usage")
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "armctl: unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	env := &env{}
+	defer env.close()
+
+	err := cmd.run(ctx, env, os.Args[2:])
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "usage: armctl %s %s\n", name, cmd.usage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "armctl %s: %v\n", name, err)
+		env.close()
+		os.Exit(1)
+	}
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: armctl <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-26s %s\n", name, commands[name].summary)
+	}
+}
+
+// ─── ENVIRONMENT ──────────────────────────────────────────────────────────────
+
+// env loads config and opens the database on first use, so usage errors are
+// reported without needing either.
+type env struct {
+	cfg    *config.Config
+	pool   *sql.DB
+	st     *store.Store
+	logger *slog.Logger
+}
+
+func (e *env) config() (*config.Config, error) {
+	if e.cfg != nil {
+		return e.cfg, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	opts := logging.Options{Level: slog.LevelInfo, DebugSampleRate: 1}
+	if cfg.LogRedact {
+		opts.Redact = redact.String
+	}
+	e.cfg, e.logger = cfg, logging.New(os.Stderr, opts)
+	return cfg, nil
+}
+
+// queries returns the store's Querier, which decrypts encrypted columns.
+func (e *env) queries(ctx context.Context) (db.Querier, error) {
+	st, err := e.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return st.Q(), nil
+}
+
+func (e *env) store(ctx context.Context) (*store.Store, error) {
+	if e.st != nil {
+		return e.st, nil
+	}
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	codec, err := fieldcrypt.New(cfg.FieldEncryptionKeys, cfg.FieldIndexKey)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("database: open: %w", err)
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := pool.PingContext(pingCtx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("database: ping: %w", err)
+	}
+	st := store.New(pool, db.New(pool))
+	st.SetTxAttempts(cfg.DBTxMaxAttempts)
+	st.SetCodec(codec)
+	e.pool, e.st = pool, st
+	return st, nil
+}
+
+// artifacts returns the object storage client, or nil when STORAGE_BUCKET is
+// not set.
+func (e *env) artifacts() (storage.Store, error) {
+	cfg, err := e.config()
+	if err != nil || cfg.StorageBucket == "" {
+		return nil, err
+	}
+	return storage.New(storage.Config{
+		Endpoint:        cfg.StorageEndpoint,
+		Region:          cfg.StorageRegion,
+		Bucket:          cfg.StorageBucket,
+		AccessKeyID:     cfg.StorageAccessKeyID,
+		SecretAccessKey: cfg.StorageSecretAccessKey,
+		PathStyle:       cfg.StoragePathStyle,
+		URLTTL:          cfg.StorageURLTTL,
+	})
+}
+
+func (e *env) close() {
+	if e.pool != nil {
+		e.pool.Close()
+		e.pool = nil
+	}
+}
+
+// ─── ARGUMENTS ────────────────────────────────────────────────────────────────
+
+// parseArgs parses flags declared on fs and returns exactly n positional
+// arguments, or errUsage. n < 0 accepts n or more.
+func parseArgs(fs *flag.FlagSet, args []string, n int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	rest := fs.Args()
+	if n >= 0 && len(rest) != n || n < 0 && len(rest) < -n {
+		return nil, errUsage
+	}
+	return rest, nil
+}
+
+// joinArgs joins free-text arguments so reasons need not be quoted.
+func joinArgs(args []string) string {
+	return strings.TrimSpace(strings.Join(args, " "))
+}
@@ -0,0 +1,289 @@
+// Command loadgen drives synthetic customer traffic at a running API so launch
+// capacity can be measured before the spike rather than during it:
+//
+//	loadgen -target https://staging.asymmetricrisk.com -rate 20 -duration 5m
+//	loadgen -target http://localhost:8080 -rate 5 -pay 0.2 -webhook-secret whsec_...
+//
+// Each simulated customer creates a session, loads the questionnaire, saves
+// answers one section at a time as the frontend does, and, for the -pay
+// fraction, checks out. With -webhook-secret the checkout is followed by a
+// signed payment_intent.succeeded delivery, so report generation is loaded
+// too; the secret must be one of the target's STRIPE_WEBHOOK_SECRET values.
+//
+// Customers arrive at -rate per second regardless of how fast the API answers
+// (an open workload), up to -concurrency in flight; arrivals beyond that are
+// counted as dropped. At the end it prints per-endpoint latency percentiles
+// and errors, or JSON with -json.
+//
+// Never point it at production. It creates real sessions, and checkouts create
+// PaymentIntents in whichever Stripe mode the target runs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/pkg/client"
+)
+
+type options struct {
+	target        string
+	rate          float64
+	duration      time.Duration
+	concurrency   int
+	pay           float64
+	webhookSecret string
+	captchaToken  string
+	think         time.Duration
+	timeout       time.Duration
+	jsonOut       bool
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.target, "target", "", "API origin to load, e.g. http://localhost:8080 (required)")
+	flag.Float64Var(&o.rate, "rate", 5, "new customers per second")
+	flag.DurationVar(&o.duration, "duration", time.Minute, "how long to generate arrivals")
+	flag.IntVar(&o.concurrency, "concurrency", 200, "maximum customers in flight")
+	flag.Float64Var(&o.pay, "pay", 0, "fraction of customers who check out, 0 to 1")
+	flag.StringVar(&o.webhookSecret, "webhook-secret", "", "sign and post payment_intent.succeeded after each checkout")
+	flag.StringVar(&o.captchaToken, "captcha-token", "", "captcha token for POST /api/session, e.g. the provider's test token")
+	flag.DurationVar(&o.think, "think", 0, "maximum random pause between a customer's steps")
+	flag.DurationVar(&o.timeout, "timeout", 30*time.Second, "per-request timeout")
+	flag.BoolVar(&o.jsonOut, "json", false, "print the summary as JSON")
+	flag.Parse()
+
+	if err := o.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	summary := run(ctx, o)
+	var err error
+	if o.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(summary)
+	} else {
+		err = summary.print(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "loadgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (o options) validate() error {
+	u, err := url.Parse(o.target)
+	switch {
+	case o.target == "" || err != nil || u.Host == "":
+		return errors.New("-target must be an absolute URL")
+	case o.rate <= 0 || o.rate > 10000:
+		return errors.New("-rate must be between 0 and 10000")
+	case o.duration <= 0:
+		return errors.New("-duration must be positive")
+	case o.concurrency < 1:
+		return errors.New("-concurrency must be at least 1")
+	case o.pay < 0 || o.pay > 1:
+		return errors.New("-pay must be between 0 and 1")
+	}
+	return nil
+}
+
+// ─── RUN ──────────────────────────────────────────────────────────────────────
+
+// run starts customers at o.rate until o.duration has passed or ctx is
+// cancelled, then waits for the ones in flight.
+func run(ctx context.Context, o options) *summary {
+	httpClient := &http.Client{
+		Timeout: o.timeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        o.concurrency,
+			MaxIdleConnsPerHost: o.concurrency,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	g := &generator{
+		opts: o,
+		// Retries would hide the failures this tool exists to find.
+		api:  client.New(client.Config{BaseURL: o.target, HTTPClient: httpClient, MaxRetries: -1}),
+		http: httpClient,
+		rec:  newRecorder(),
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, o.duration)
+	defer cancel()
+
+	slots := make(chan struct{}, o.concurrency)
+	var wg sync.WaitGroup
+	var started, dropped atomic.Int64
+	begin := time.Now()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / o.rate))
+	defer ticker.Stop()
+	progress := time.NewTicker(10 * time.Second)
+	defer progress.Stop()
+
+loop:
+	for {
+		select {
+		case <-runCtx.Done():
+			break loop
+		case <-progress.C:
+			fmt.Fprintf(os.Stderr, "loadgen: %s elapsed, %d customers started, %d in flight, %d dropped\n",
+				time.Since(begin).Round(time.Second), started.Load(), len(slots), dropped.Load())
+		case <-ticker.C:
+			select {
+			case slots <- struct{}{}:
+			default:
+				dropped.Add(1)
+				continue
+			}
+			n := started.Add(1)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				// In-flight customers finish after -duration; only Ctrl-C
+				// abandons them.
+				g.customer(ctx, n)
+			}()
+		}
+	}
+	wg.Wait()
+
+	return g.rec.summarise(time.Since(begin), started.Load(), dropped.Load())
+}
+
+// ─── CUSTOMER ─────────────────────────────────────────────────────────────────
+
+type generator struct {
+	opts options
+	api  *client.Client
+	http *http.Client
+	rec  *recorder
+}
+
+// sampleText answers free-text questions with something a customer might type.
+var sampleText = []string{
+	"Acme Trading",
+	"We sell refurbished laptops to schools",
+	"Two founders and six staff",
+	"Mostly online, some wholesale",
+}
+
+// customer walks one customer through the flow, stopping at the first error.
+func (g *generator) customer(ctx context.Context, n int64) {
+	var sess client.Session
+	err := g.rec.time("create_session", func() (err error) {
+		sess, err = g.api.CreateSession(ctx, client.CreateSessionRequest{
+			BizName:      fmt.Sprintf("Loadgen %d", n),
+			Industry:     "Retail",
+			Stage:        "growth",
+			CaptchaToken: g.opts.captchaToken,
+		})
+		return err
+	})
+	if err != nil || !g.pause(ctx) {
+		return
+	}
+
+	var questions []client.Question
+	if err := g.rec.time("get_questions", func() (err error) {
+		questions, err = g.api.GetQuestions(ctx, sess)
+		return err
+	}); err != nil {
+		return
+	}
+
+	for _, batch := range answerBatches(questions) {
+		if !g.pause(ctx) {
+			return
+		}
+		if err := g.rec.time("upsert_answers", func() error {
+			_, err := g.api.UpsertAnswers(ctx, sess, batch)
+			return err
+		}); err != nil {
+			return
+		}
+	}
+
+	if rand.Float64() >= g.opts.pay || !g.pause(ctx) {
+		return
+	}
+	var checkout client.Checkout
+	if err := g.rec.time("checkout", func() (err error) {
+		checkout, err = g.api.CreateCheckout(ctx, sess, client.CheckoutRequest{
+			Email:             fmt.Sprintf("loadgen+%d@example.com", n),
+			BillingCountry:    "US",
+			BillingPostalCode: "94103",
+		})
+		return err
+	}); err != nil {
+		return
+	}
+
+	if g.opts.webhookSecret == "" || checkout.CoveredBySubscription {
+		return
+	}
+	_ = g.rec.time("webhook", func() error {
+		return g.postPaymentSucceeded(ctx, checkout)
+	})
+}
+
+// answerBatches answers every question with a random option, or sample text,
+// and groups the answers by section. Questions the API sends no options for
+// are left unanswered.
+func answerBatches(questions []client.Question) [][]client.Answer {
+	var batches [][]client.Answer
+	section := ""
+	for _, q := range questions {
+		var text string
+		switch {
+		case len(q.Options) > 0:
+			text = q.Options[rand.IntN(len(q.Options))].Label
+		case q.Type == "text":
+			text = sampleText[rand.IntN(len(sampleText))]
+		default:
+			continue
+		}
+		if q.SectionID != section || len(batches) == 0 {
+			batches = append(batches, nil)
+			section = q.SectionID
+		}
+		last := len(batches) - 1
+		batches[last] = append(batches[last], client.Answer{QuestionID: q.ID, AnswerText: text})
+	}
+	return batches
+}
+
+// pause waits a random time up to -think and reports whether ctx is still
+// live.
+func (g *generator) pause(ctx context.Context) bool {
+	if g.opts.think <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(rand.N(g.opts.think)):
+		return true
+	}
+}
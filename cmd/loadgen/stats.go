@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/pkg/client"
+)
+
+// operations is the order endpoints appear in the summary.
+var operations = []string{"create_session", "get_questions", "upsert_answers", "checkout", "webhook"}
+
+// recorder collects the latency and outcome of every request.
+type recorder struct {
+	mu  sync.Mutex
+	ops map[string]*opSamples
+}
+
+type opSamples struct {
+	latencies []time.Duration // successful requests only
+	errors    map[string]int  // by errorKind
+}
+
+func newRecorder() *recorder {
+	return &recorder{ops: make(map[string]*opSamples)}
+}
+
+// time runs fn, records its latency under op and returns its error.
+func (r *recorder) time(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.ops[op]
+	if s == nil {
+		s = &opSamples{errors: make(map[string]int)}
+		r.ops[op] = s
+	}
+	if err != nil {
+		s.errors[errorKind(err)]++
+	} else {
+		s.latencies = append(s.latencies, elapsed)
+	}
+	return err
+}
+
+// errorKind groups errors for the summary: "HTTP 503", "timeout", "network".
+func errorKind(err error) string {
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("HTTP %d", apiErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	default:
+		return "network"
+	}
+}
+
+// ─── SUMMARY ──────────────────────────────────────────────────────────────────
+
+type summary struct {
+	Duration         string      `json:"duration"`
+	CustomersStarted int64       `json:"customers_started"`
+	CustomersDropped int64       `json:"customers_dropped"`
+	Operations       []opSummary `json:"operations"`
+}
+
+type opSummary struct {
+	Name       string         `json:"name"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	PerSecond  float64        `json:"per_second"`
+	P50Millis  float64        `json:"p50_ms"`
+	P95Millis  float64        `json:"p95_ms"`
+	P99Millis  float64        `json:"p99_ms"`
+	MaxMillis  float64        `json:"max_ms"`
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"`
+}
+
+func (r *recorder) summarise(elapsed time.Duration, started, dropped int64) *summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := &summary{
+		Duration:         elapsed.Round(time.Millisecond).String(),
+		CustomersStarted: started,
+		CustomersDropped: dropped,
+	}
+	for _, name := range operations {
+		s := r.ops[name]
+		if s == nil {
+			continue
+		}
+		slices.Sort(s.latencies)
+		op := opSummary{
+			Name:      name,
+			P50Millis: percentile(s.latencies, 50),
+			P95Millis: percentile(s.latencies, 95),
+			P99Millis: percentile(s.latencies, 99),
+			MaxMillis: percentile(s.latencies, 100),
+		}
+		for _, n := range s.errors {
+			op.Errors += n
+		}
+		op.Requests = len(s.latencies) + op.Errors
+		op.PerSecond = float64(op.Requests) / elapsed.Seconds()
+		if op.Errors > 0 {
+			op.ErrorKinds = s.errors
+		}
+		out.Operations = append(out.Operations, op)
+	}
+	return out
+}
+
+// percentile returns the p-th percentile of sorted in milliseconds, by the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1].Microseconds()) / 1000
+}
+
+func (s *summary) print(w io.Writer) error {
+	fmt.Fprintf(w, "duration %s, %d customers started, %d dropped at the concurrency limit\n\n",
+		s.Duration, s.CustomersStarted, s.CustomersDropped)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tREQ/S\tERRORS\tP50 MS\tP95 MS\tP99 MS\tMAX MS\t")
+	for _, op := range s.Operations {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			op.Name, op.Requests, op.PerSecond, op.Errors, op.P50Millis, op.P95Millis, op.P99Millis, op.MaxMillis)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, op := range s.Operations {
+		kinds := make([]string, 0, len(op.ErrorKinds))
+		for k := range op.ErrorKinds {
+			kinds = append(kinds, k)
+		}
+		slices.Sort(kinds)
+		for _, k := range kinds {
+			fmt.Fprintf(w, "%s: %d × %s\n", op.Name, op.ErrorKinds[k], k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v82/webhook"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/pkg/client"
+)
+
+// postPaymentSucceeded simulates Stripe confirming the checkout's payment by
+// delivering a signed payment_intent.succeeded event. The event carries no
+// charge, so the API sends the receipt without card details.
+func (g *generator) postPaymentSucceeded(ctx context.Context, checkout client.Checkout) error {
+	// A client secret is the PaymentIntent ID followed by "_secret_…".
+	piID, _, ok := strings.Cut(checkout.ClientSecret, "_secret_")
+	if !ok {
+		return fmt.Errorf("unexpected client secret format")
+	}
+	payload, err := json.Marshal(map[string]any{
+		"id":          "evt_loadgen_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		"object":      "event",
+		"type":        "payment_intent.succeeded",
+		"api_version": "2025-03-31.basil",
+		"created":     time.Now().Unix(),
+		"data": map[string]any{"object": map[string]any{
+			"id":              piID,
+			"object":          "payment_intent",
+			"amount_received": checkout.AmountCents,
+			"currency":        checkout.Currency,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: g.opts.webhookSecret})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(g.opts.target, "/")+"/api/webhooks/stripe", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", signed.Header)
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return &client.APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return nil
+}
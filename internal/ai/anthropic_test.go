@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// anthropicReplies answers each Messages API request with the next reply and
+// records whether the request forced a tool.
+type anthropicReplies struct {
+	replies   []string
+	withTools []bool
+}
+
+func (a *anthropicReplies) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body anthropicRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	a.withTools = append(a.withTools, body.ToolChoice != nil)
+	reply := a.replies[0]
+	a.replies = a.replies[1:]
+	status := http.StatusOK
+	if strings.Contains(reply, `"error"`) {
+		status = http.StatusBadRequest
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(reply)), Header: http.Header{}}, nil
+}
+
+func newTestAnthropicClient(rt http.RoundTripper) *anthropicClient {
+	c := NewAnthropicClient("key", "claude-test", "", time.Second, Tuning{}, slog.New(slog.NewTextHandler(io.Discard, nil))).(*anthropicClient)
+	c.httpClient.Transport = rt
+	return c
+}
+
+const plainJSONReply = `{"content":[{"type":"text","text":"{\"ok\":true}"}]}`
+
+func TestAnthropicToolsUnsupported_MatchesOnlyTheModelRefusal(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"claude-2.1 does not support tool use":                      true,
+		"Tool use is not supported for this model":                  true,
+		"tools.0.input_schema: JSON schema is invalid":              false,
+		"messages.1.content.0.tool_result: content is too long":     false,
+		"tool_choice: tool 'submit_hedges' not found in tools list": false,
+	} {
+		err := &anthropicAPIError{Type: "invalid_request_error", Message: msg}
+		if got := err.toolsUnsupported(); got != want {
+			t.Errorf("%q: toolsUnsupported = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+func TestAnthropicCallStructured_FallsBackThenRetriesToolsLater(t *testing.T) {
+	rt := &anthropicReplies{replies: []string{
+		`{"type":"error","error":{"type":"invalid_request_error","message":"claude-test does not support tool use"}}`,
+		plainJSONReply,
+		plainJSONReply,
+		plainJSONReply,
+	}}
+	c := newTestAnthropicClient(rt)
+	req := anthropicRequest{Model: "claude-test", MaxTokens: 100}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.callStructured(context.Background(), req, hedgesTool); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if len(rt.withTools) != 3 || !rt.withTools[0] || rt.withTools[1] || rt.withTools[2] {
+		t.Fatalf("expected tools, then plain JSON twice; got %v", rt.withTools)
+	}
+
+	// Once the fallback expires, tools are tried again.
+	c.noTools.Store("claude-test", time.Now().Add(-time.Second))
+	if _, err := c.callStructured(context.Background(), req, hedgesTool); err != nil {
+		t.Fatal(err)
+	}
+	if !rt.withTools[3] {
+		t.Errorf("expected tools to be tried again after the fallback expired")
+	}
+}
+
+func TestAnthropicCallStructured_SchemaErrorIsNotAFallback(t *testing.T) {
+	rt := &anthropicReplies{replies: []string{
+		`{"type":"error","error":{"type":"invalid_request_error","message":"tools.0.input_schema: JSON schema is invalid"}}`,
+	}}
+	c := newTestAnthropicClient(rt)
+
+	if _, err := c.callStructured(context.Background(), anthropicRequest{Model: "claude-test"}, hedgesTool); err == nil {
+		t.Fatal("expected the schema error to be returned")
+	}
+	if c.toolsDisabled("claude-test") || len(rt.withTools) != 1 {
+		t.Errorf("a schema error must not disable tools (requests: %v)", rt.withTools)
+	}
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── PER-REPORT TOKEN BUDGET ──────────────────────────────────────────────────
+//
+// A giant questionnaire, or a provider that keeps failing the quality gate or
+// timing out into the next one in the chain, can run up the AI bill for a
+// single report. The worker caps it: before generating it projects the
+// report's token use and sends fewer risks if the projection is over budget,
+// and every provider call spends from a Budget on the context and is refused
+// once the budget is gone, which leaves the remaining risks on static hedges.
+//
+// Token counts are estimates — prompt length over charsPerToken, plus the
+// full max_tokens allowance for the reply — so the cap errs on the safe side.
+
+// charsPerToken is a rough average for English prose and JSON.
+const charsPerToken = 4
+
+// ErrBudgetExceeded is returned by a provider call that would take the report
+// over its token budget.
+var ErrBudgetExceeded = errors.New("ai: report token budget exceeded")
+
+// Budget is a report's token allowance. It is safe for concurrent use by the
+// chunks of one report. A nil *Budget allows everything.
+type Budget struct {
+	mu       sync.Mutex
+	limit    int
+	spent    int
+	exceeded bool
+}
+
+// NewBudget returns a budget of limit tokens.
+func NewBudget(limit int) *Budget {
+	return &Budget{limit: limit}
+}
+
+// Spend takes tokens from the budget, or returns ErrBudgetExceeded and takes
+// nothing if there are not enough left.
+func (b *Budget) Spend(tokens int) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.spent+tokens > b.limit {
+		b.exceeded = true
+		return ErrBudgetExceeded
+	}
+	b.spent += tokens
+	return nil
+}
+
+// Spent returns the tokens spent so far.
+func (b *Budget) Spent() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Exceeded reports whether any call has been refused.
+func (b *Budget) Exceeded() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a context whose provider calls spend from b. Like the
+// report type, it travels on the context so wrappers pass it through.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// BudgetFrom returns the budget on ctx, or nil.
+func BudgetFrom(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetKey{}).(*Budget)
+	return b
+}
+
+// EstimateTokens returns the projected cost of one narrative call for risks
+// with the options on ctx.
+func EstimateTokens(ctx context.Context, risks []scoring.ScoredRisk) int {
+	system, maxTokens := promptFor(ctx)
+	return callTokens(system, buildPrompt(ctx, risks), maxTokens)
+}
+
+// EstimateAnalysisTokens returns the projected cost of the analysis call for
+// risks.
+func EstimateAnalysisTokens(ctx context.Context, risks []scoring.ScoredRisk) int {
+	return callTokens(analysisSystemPrompt, buildPrompt(ctx, risks), analysisMaxTokens)
+}
+
+// spend charges a provider call, body being its encoded request, to the
+// budget on ctx if there is one.
+func spend(ctx context.Context, body []byte, maxTokens int) error {
+	return BudgetFrom(ctx).Spend(len(body)/charsPerToken + maxTokens)
+}
+
+func callTokens(system, user string, maxTokens int) int {
+	return (len(system)+len(user))/charsPerToken + maxTokens
+}
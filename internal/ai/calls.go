@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"sync"
+)
+
+// ─── CALL LOG ─────────────────────────────────────────────────────────────────
+//
+// A CallLog on the context notes which provider each call went to and how the
+// last failed one failed. The worker puts one on every report attempt and
+// stores what it saw with a failed attempt (see report_attempts), so failures
+// can be traced to a provider — e.g. a night of DeepSeek 429s — even though a
+// failed AI call does not itself fail the report. Unlike a Transcript it
+// keeps no prompts, so it is always on.
+
+// CallLog records the providers called during one attempt. It is safe for
+// concurrent use by the chunks of one report. A nil *CallLog records nothing.
+type CallLog struct {
+	mu             sync.Mutex
+	provider       string
+	failedProvider string
+	failure        string
+}
+
+// NewCallLog returns an empty call log.
+func NewCallLog() *CallLog {
+	return &CallLog{}
+}
+
+// note records a finished call to provider whose outcome is *err. Providers
+// defer it next to the transcript's record.
+func (l *CallLog) note(provider string, err *error) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.provider = provider
+	if *err != nil {
+		l.failedProvider = provider
+		l.failure = (*err).Error()
+	}
+}
+
+// Provider returns the provider of the last call to finish, or "" if none
+// was made.
+func (l *CallLog) Provider() string {
+	if l == nil {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.provider
+}
+
+// LastFailure returns the provider and error of the last call that failed,
+// or empty strings if none did.
+func (l *CallLog) LastFailure() (provider, message string) {
+	if l == nil {
+		return "", ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.failedProvider, l.failure
+}
+
+type callLogKey struct{}
+
+// WithCallLog returns a context whose provider calls are noted in l.
+func WithCallLog(ctx context.Context, l *CallLog) context.Context {
+	return context.WithValue(ctx, callLogKey{}, l)
+}
+
+// CallLogFrom returns the call log on ctx, or nil.
+func CallLogFrom(ctx context.Context) *CallLog {
+	l, _ := ctx.Value(callLogKey{}).(*CallLog)
+	return l
+}
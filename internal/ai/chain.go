@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// providerChain tries a set of named Hedgers in an order that is looked up on
+// every call, so the provider order can be changed at runtime (see
+// settings.KeyAIProviderOrder) without rebuilding the chain.
+type providerChain struct {
+	providers map[string]Hedger
+	order     func() []string
+	logger    *slog.Logger
+}
+
+// NewProviderChain returns a Hedger that calls providers in the order returned
+// by order, moving on to the next one whenever a call fails.
+//
+// Names returned by order that are not in providers (e.g. a provider with no
+// API key) are skipped. Configured providers missing from order are tried
+// last, in name order, so a bad ordering can never disable a working provider.
+func NewProviderChain(providers map[string]Hedger, order func() []string, logger *slog.Logger) Hedger {
+	return &providerChain{
+		providers: providers,
+		order:     order,
+		logger:    logger,
+	}
+}
+
+// GenerateHedges returns the first successful provider's result, or every
+// provider's error joined together if all of them fail.
+func (c *providerChain) GenerateHedges(ctx context.Context, risks []scoring.ScoredRisk) (HedgeResult, error) {
+	names := c.resolveOrder(ctx)
+	if len(names) == 0 {
+		if len(c.providers) > 0 {
+			return HedgeResult{}, errors.New("ai: every configured provider is disabled for this report")
+		}
+		return HedgeResult{}, errors.New("ai: no providers configured")
+	}
+
+	var errs []error
+	for i, name := range names {
+		result, err := c.providers[name].GenerateHedges(ctx, risks)
+		if err == nil {
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+
+		if i < len(names)-1 {
+			c.logger.WarnContext(ctx, "ai: provider failed, trying next",
+				"provider", name,
+				"next", names[i+1],
+				"error", err,
+				"risks", len(risks),
+			)
+		}
+	}
+
+	return HedgeResult{}, fmt.Errorf("ai: all providers failed: %w", errors.Join(errs...))
+}
+
+// Analyse runs the analysis stage on the first provider that supports it and
+// succeeds, in the same order as GenerateHedges.
+func (c *providerChain) Analyse(ctx context.Context, risks []scoring.ScoredRisk) (Analysis, error) {
+	var errs []error
+	for _, name := range c.resolveOrder(ctx) {
+		analyser, ok := c.providers[name].(Analyser)
+		if !ok {
+			continue
+		}
+		a, err := analyser.Analyse(ctx, risks)
+		if err == nil {
+			return a, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		c.logger.WarnContext(ctx, "ai: provider analysis failed", "provider", name, "error", err)
+	}
+	if len(errs) == 0 {
+		return Analysis{}, errors.New("ai: no provider supports analysis")
+	}
+	return Analysis{}, fmt.Errorf("ai: analysis failed on every provider: %w", errors.Join(errs...))
+}
+
+// resolveOrder returns the configured providers in order, leaving out those
+// disabled on ctx.
+func (c *providerChain) resolveOrder(ctx context.Context) []string {
+	disabled := disabledFrom(ctx)
+
+	var names []string
+	for _, name := range c.order() {
+		if _, ok := c.providers[name]; ok && !slices.Contains(names, name) && !slices.Contains(disabled, name) {
+			names = append(names, name)
+		}
+	}
+
+	var rest []string
+	for name := range c.providers {
+		if !slices.Contains(names, name) && !slices.Contains(disabled, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+type disabledKey struct{}
+
+// WithoutProviders returns a context on which the provider chain skips the
+// named providers, e.g. one a feature flag is off for this report.
+func WithoutProviders(ctx context.Context, names ...string) context.Context {
+	return context.WithValue(ctx, disabledKey{}, names)
+}
+
+func disabledFrom(ctx context.Context) []string {
+	names, _ := ctx.Value(disabledKey{}).([]string)
+	return names
+}
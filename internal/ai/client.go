@@ -4,10 +4,35 @@ package ai
 
 import (
 	"context"
+	"time"
 
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
 )
 
+// defaultTimeout is the HTTP timeout used by provider clients when the caller
+// passes zero. Generating hedges for a full report routinely takes 30–60s.
+const defaultTimeout = 90 * time.Second
+
+// Tuning adjusts a provider client's requests. The zero value changes
+// nothing.
+type Tuning struct {
+	// MaxTokens caps every request's max_tokens. Zero keeps each call's own
+	// budget (2048 for standard narratives, 6144 for premium).
+	MaxTokens int
+
+	// Temperature is sent with every request. Nil leaves the provider's
+	// default.
+	Temperature *float64
+}
+
+// capTokens returns n, lowered to MaxTokens if that is set and smaller.
+func (t Tuning) capTokens(n int) int {
+	if t.MaxTokens > 0 && t.MaxTokens < n {
+		return t.MaxTokens
+	}
+	return n
+}
+
 // HedgeResult is the structured output from a successful GenerateHedges call.
 type HedgeResult struct {
 	// Hedges maps question_id → AI-generated hedge narrative. May be nil if
@@ -22,6 +47,20 @@ type HedgeResult struct {
 	// single most urgent action the business owner should take. Rendered
 	// directly in the report view.
 	TopPriorityHTML string
+
+	// Relationships are links between the risks (see relationships.go),
+	// keyed by question_id. May be empty.
+	Relationships []Relationship
+
+	// QualityIssues are why part of the output was rejected by the quality
+	// gate (see quality.go), for chunks that fell back to static hedges.
+	// Providers leave it unset; the worker fills it in.
+	QualityIssues []string
+
+	// Analysis is the analysis stage's output the narrative was written from
+	// (see analysis.go). Nil if that stage did not run or failed. Providers
+	// leave it unset; the worker fills it in.
+	Analysis *Analysis
 }
 
 // Hedger is the interface the worker uses to generate AI narratives.
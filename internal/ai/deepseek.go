@@ -7,9 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
 )
 
@@ -17,20 +17,33 @@ import (
 // DeepSeek exposes an OpenAI-compatible /v1/chat/completions endpoint, so the
 // request/response shapes are standard OpenAI chat format — not Anthropic's.
 type deepseekClient struct {
-	apiKey     string
-	model      string
-	httpClient *http.Client
+	apiKey        string
+	model         string
+	analysisModel string
+	tuning        Tuning
+	httpClient    *http.Client
 }
 
 // NewDeepSeekClient returns a Hedger that calls the DeepSeek API.
 //   - apiKey: your DEEPSEEK_API_KEY
 //   - model:  e.g. "deepseek-chat" or "deepseek-reasoner"
-func NewDeepSeekClient(apiKey, model string) Hedger {
+//   - analysisModel: model for the analysis stage; empty means model
+//   - timeout: per-request HTTP timeout; zero means defaultTimeout
+//   - tuning: max_tokens cap and temperature for every request
+func NewDeepSeekClient(apiKey, model, analysisModel string, timeout time.Duration, tuning Tuning) Hedger {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if analysisModel == "" {
+		analysisModel = model
+	}
 	return &deepseekClient{
-		apiKey: apiKey,
-		model:  model,
+		apiKey:        apiKey,
+		model:         model,
+		analysisModel: analysisModel,
+		tuning:        tuning,
 		httpClient: &http.Client{
-			Timeout: 90 * time.Second,
+			Timeout: timeout,
 		},
 	}
 }
@@ -41,6 +54,7 @@ type openAIRequest struct {
 	Model          string          `json:"model"`
 	Messages       []openAIMessage `json:"messages"`
 	MaxTokens      int             `json:"max_tokens"`
+	Temperature    *float64        `json:"temperature,omitempty"`
 	ResponseFormat *responseFormat `json:"response_format,omitempty"`
 }
 
@@ -78,14 +92,16 @@ func (c *deepseekClient) GenerateHedges(ctx context.Context, risks []scoring.Sco
 		return HedgeResult{}, nil
 	}
 
+	system, maxTokens := promptFor(ctx)
+
 	reqBody := openAIRequest{
 		Model:     c.model,
-		MaxTokens: 2048,
+		MaxTokens: maxTokens,
 		// json_object mode guarantees the response is valid JSON — no fence stripping needed.
 		ResponseFormat: &responseFormat{Type: "json_object"},
 		Messages: []openAIMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: buildPrompt(risks)},
+			{Role: "system", Content: system},
+			{Role: "user", Content: buildPrompt(ctx, risks)},
 		},
 	}
 
@@ -95,11 +111,7 @@ func (c *deepseekClient) GenerateHedges(ctx context.Context, risks []scoring.Sco
 	}
 
 	// json_object mode should give us clean JSON, but strip fences defensively.
-	raw = strings.TrimSpace(raw)
-	raw = strings.TrimPrefix(raw, "```json")
-	raw = strings.TrimPrefix(raw, "```")
-	raw = strings.TrimSuffix(raw, "```")
-	raw = strings.TrimSpace(raw)
+	raw = trimFences(raw)
 
 	var parsed hedgeJSON
 	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
@@ -110,16 +122,52 @@ func (c *deepseekClient) GenerateHedges(ctx context.Context, risks []scoring.Sco
 		Hedges:           parsed.Hedges,
 		ExecutiveSummary: parsed.ExecutiveSummary,
 		TopPriorityHTML:  parsed.TopPriority,
+		Relationships:    parsed.Relationships,
 	}, nil
 }
 
+// Analyse runs the analysis stage (see analysis.go) on the analysis model.
+func (c *deepseekClient) Analyse(ctx context.Context, risks []scoring.ScoredRisk) (Analysis, error) {
+	if len(risks) == 0 {
+		return Analysis{}, nil
+	}
+
+	raw, err := c.call(ctx, openAIRequest{
+		Model:          c.analysisModel,
+		MaxTokens:      analysisMaxTokens,
+		ResponseFormat: &responseFormat{Type: "json_object"},
+		Messages: []openAIMessage{
+			{Role: "system", Content: analysisSystemPrompt},
+			{Role: "user", Content: buildPrompt(ctx, risks)},
+		},
+	})
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	a, err := parseAnalysis(raw)
+	if err != nil {
+		return Analysis{}, fmt.Errorf("deepseek: %w", err)
+	}
+	return a, nil
+}
+
 // call sends one request to the DeepSeek chat completions endpoint and returns
 // the text content of the first choice.
-func (c *deepseekClient) call(ctx context.Context, reqBody openAIRequest) (string, error) {
+func (c *deepseekClient) call(ctx context.Context, reqBody openAIRequest) (_ string, err error) {
+	reqBody.MaxTokens = c.tuning.capTokens(reqBody.MaxTokens)
+	reqBody.Temperature = c.tuning.Temperature
+
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", fmt.Errorf("deepseek: marshal request: %w", err)
 	}
+	if err := spend(ctx, bodyBytes, reqBody.MaxTokens); err != nil {
+		return "", err
+	}
+	ex := Exchange{Provider: "deepseek", Model: reqBody.Model, Request: bodyBytes}
+	defer TranscriptFrom(ctx).record(&ex, time.Now(), &err)
+	defer CallLogFrom(ctx).note(ex.Provider, &err)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
 		"https://api.deepseek.com/v1/chat/completions",
@@ -131,6 +179,7 @@ func (c *deepseekClient) call(ctx context.Context, reqBody openAIRequest) (strin
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	requestid.SetHeader(ctx, req.Header)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -142,6 +191,8 @@ func (c *deepseekClient) call(ctx context.Context, reqBody openAIRequest) (strin
 	if err != nil {
 		return "", fmt.Errorf("deepseek: read response: %w", err)
 	}
+	ex.Status = resp.StatusCode
+	ex.Response = string(respBytes)
 
 	var parsed openAIResponse
 	if err := json.Unmarshal(respBytes, &parsed); err != nil {
@@ -161,4 +212,26 @@ func (c *deepseekClient) call(ctx context.Context, reqBody openAIRequest) (strin
 	}
 
 	return parsed.Choices[0].Message.Content, nil
+}
+
+// Ping lists the available models — an authenticated request that costs no
+// tokens — to confirm the API is reachable and the key is accepted.
+func (c *deepseekClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.deepseek.com/models", nil)
+	if err != nil {
+		return fmt.Errorf("deepseek: build ping request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("deepseek: ping: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("deepseek: ping: unexpected status %d", resp.StatusCode)
+	}
+	return nil
 }
\ No newline at end of file
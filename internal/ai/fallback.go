@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -38,7 +39,7 @@ func (f *fallbackHedger) GenerateHedges(ctx context.Context, risks []scoring.Sco
 		if err == nil {
 			return result, nil
 		}
-		f.logger.Warn("ai: primary hedger failed, trying secondary",
+		f.logger.WarnContext(ctx, "ai: primary hedger failed, trying secondary",
 			"error", err,
 			"risks", len(risks),
 		)
@@ -48,4 +49,21 @@ func (f *fallbackHedger) GenerateHedges(ctx context.Context, risks []scoring.Sco
 	}
 
 	return f.secondary.GenerateHedges(ctx, risks)
+}
+
+// Analyse runs the analysis stage on the primary Hedger and, if that fails or
+// cannot analyse, on the secondary.
+func (f *fallbackHedger) Analyse(ctx context.Context, risks []scoring.ScoredRisk) (Analysis, error) {
+	err := errors.New("ai: no hedger supports analysis")
+	for _, h := range []Hedger{f.primary, f.secondary} {
+		analyser, ok := h.(Analyser)
+		if !ok {
+			continue
+		}
+		var a Analysis
+		if a, err = analyser.Analyse(ctx, risks); err == nil {
+			return a, nil
+		}
+	}
+	return Analysis{}, err
 }
\ No newline at end of file
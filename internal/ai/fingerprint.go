@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// fingerprintVersion is mixed into every fingerprint. Bump it whenever the
+// prompt or the HedgeResult shape changes so stale cache entries stop matching.
+const fingerprintVersion = 4
+
+// Fingerprint returns a stable hex SHA-256 of everything that determines a
+// GenerateHedges result: each risk's identity, wording, P/I and tier, the
+// business's industry and stage, the report type (see WithReportType), the
+// prompt variant (see WithPromptVariant) and the playbook snippets sent with
+// them (see WithSnippets).
+// Two sessions with the same fingerprint would send the AI an identical
+// prompt, so the earlier result can be reused.
+//
+// Risk order does not matter; risks are sorted by question ID before hashing.
+// Question wording is included so editing question_definitions invalidates
+// entries for that question without a manual cache flush; snippets are
+// included for the same reason, in the order they are sent.
+func Fingerprint(risks []scoring.ScoredRisk, industry, stage, reportType, promptVariant string, snippets ...Snippet) string {
+	sorted := make([]scoring.ScoredRisk, len(risks))
+	copy(sorted, risks)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].QuestionID < sorted[b].QuestionID })
+
+	h := sha256.New()
+	// %q keeps field boundaries unambiguous whatever the text contains.
+	fmt.Fprintf(h, "v%d\nindustry=%q\nstage=%q\nreport_type=%q\n", fingerprintVersion, industry, stage, reportType)
+	// Only a variant is hashed, so the default prompt keeps its entries.
+	if promptVariant != "" {
+		fmt.Fprintf(h, "prompt=%q\n", promptVariant)
+	}
+	for _, r := range sorted {
+		fmt.Fprintf(h, "%q %q %q %q p=%d i=%d tier=%s\n",
+			r.QuestionID, r.RiskName, r.RiskDesc, r.Hedge, r.P, r.I, r.Tier)
+	}
+	for _, s := range snippets {
+		fmt.Fprintf(h, "snippet %q %q\n", s.Title, s.Body)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
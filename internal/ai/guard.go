@@ -0,0 +1,202 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── PROMPT-INJECTION GUARD ───────────────────────────────────────────────────
+//
+// Free text interpolated into the prompt (risk wording today; answer text and
+// business names as soon as they are sent) is treated as untrusted. Three
+// layers keep it from steering the model:
+//
+//  1. sanitise strips control characters and our own delimiters and caps the
+//     length of every field before buildPrompt writes it.
+//  2. buildPrompt wraps the data in riskDataOpen/riskDataClose markers and the
+//     system prompt tells the model everything inside them is data.
+//  3. guardedHedger checks the model's output and drops any field that echoes
+//     injection-style instructions, so the worker falls back to static hedges.
+//
+// Suspicious inputs and dropped outputs are logged with audit=true so they can
+// be pulled out of the log stream for review.
+
+const (
+	riskDataOpen  = "<risk_data>"
+	riskDataClose = "</risk_data>"
+
+	// maxFieldRunes caps any single interpolated field. The longest static
+	// question text is well under this.
+	maxFieldRunes = 1000
+)
+
+// riskDataMarker matches our delimiters, including spaced or re-cased variants.
+var riskDataMarker = regexp.MustCompile(`(?i)</?\s*risk_data\s*>`)
+
+// injectionPatterns match phrasing typical of attempts to override the system
+// prompt. They are deliberately narrow — a risk description that mentions
+// "instructions" in passing must not trip them.
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,30}\b(previous|prior|above|earlier|all|system)\b.{0,20}\b(instructions?|prompts?|rules?|messages?)\b`),
+	regexp.MustCompile(`(?i)\b(you are now|pretend to be|new instructions?)\b`),
+	regexp.MustCompile(`(?i)\b(system prompt|developer message|jailbreak)\b`),
+	regexp.MustCompile(`(?i)^\s*(system|assistant|user)\s*:`),
+	riskDataMarker,
+}
+
+// sanitise makes s safe to place on a single line inside the risk data block.
+func sanitise(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1 // includes zero-width and bidi override characters
+		}
+		return r
+	}, s)
+
+	s = riskDataMarker.ReplaceAllString(s, "")
+
+	if r := []rune(s); len(r) > maxFieldRunes {
+		s = string(r[:maxFieldRunes]) + "…"
+	}
+	return strings.TrimSpace(s)
+}
+
+// injectionMatches returns the fragments of s that match an injection pattern.
+func injectionMatches(s string) []string {
+	var out []string
+	for _, re := range injectionPatterns {
+		if m := re.FindString(s); m != "" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// guardedHedger wraps another Hedger with input auditing and output checks.
+type guardedHedger struct {
+	next   Hedger
+	logger *slog.Logger
+}
+
+// NewGuardedHedger returns a Hedger that flags risks whose text looks like a
+// prompt-injection attempt and removes any part of the result that echoes
+// such instructions back. It never fails a call on its own.
+func NewGuardedHedger(next Hedger, logger *slog.Logger) Hedger {
+	return &guardedHedger{next: next, logger: logger}
+}
+
+// GenerateHedges audits the input, calls the wrapped Hedger and filters its
+// result. Hedges and relationships naming unknown question IDs are dropped too
+// — the model has no legitimate reason to invent them.
+func (g *guardedHedger) GenerateHedges(ctx context.Context, risks []scoring.ScoredRisk) (HedgeResult, error) {
+	known := make(map[string]bool, len(risks))
+	for _, r := range risks {
+		known[r.QuestionID] = true
+		for _, field := range []string{r.RiskName, r.RiskDesc, r.Hedge} {
+			if m := injectionMatches(field); len(m) > 0 {
+				g.logger.WarnContext(ctx, "ai: suspicious text in prompt input",
+					"audit", true,
+					"question_id", r.QuestionID,
+					"matches", m,
+				)
+			}
+		}
+	}
+
+	result, err := g.next.GenerateHedges(ctx, risks)
+	if err != nil {
+		return result, err
+	}
+
+	for id, hedge := range result.Hedges {
+		reason := ""
+		switch {
+		case !known[id]:
+			reason = "unknown question_id"
+		case len(injectionMatches(hedge)) > 0:
+			reason = "echoes injected instructions"
+		default:
+			continue
+		}
+		g.logger.WarnContext(ctx, "ai: dropping hedge", "audit", true, "question_id", id, "reason", reason)
+		delete(result.Hedges, id)
+	}
+	if m := injectionMatches(result.ExecutiveSummary); len(m) > 0 {
+		g.logger.WarnContext(ctx, "ai: dropping executive summary", "audit", true, "matches", m)
+		result.ExecutiveSummary = ""
+	}
+	if m := injectionMatches(result.TopPriorityHTML); len(m) > 0 {
+		g.logger.WarnContext(ctx, "ai: dropping top priority block", "audit", true, "matches", m)
+		result.TopPriorityHTML = ""
+	}
+	relationships := make([]Relationship, 0, len(result.Relationships))
+	for _, rel := range result.Relationships {
+		reason := ""
+		switch {
+		case !known[rel.From] || !known[rel.To]:
+			reason = "unknown question_id"
+		case rel.From == rel.To:
+			reason = "links a risk to itself"
+		case len(injectionMatches(rel.Description)) > 0:
+			reason = "echoes injected instructions"
+		default:
+			relationships = append(relationships, rel)
+			continue
+		}
+		g.logger.WarnContext(ctx, "ai: dropping relationship", "audit", true, "from", rel.From, "to", rel.To, "reason", reason)
+	}
+	result.Relationships = relationships
+
+	return result, nil
+}
+
+// Analyse calls the wrapped Hedger's analysis stage and drops every part of
+// the analysis that names an unknown question ID or echoes injected
+// instructions. The input is audited by GenerateHedges, which always follows.
+func (g *guardedHedger) Analyse(ctx context.Context, risks []scoring.ScoredRisk) (Analysis, error) {
+	analyser, ok := g.next.(Analyser)
+	if !ok {
+		return Analysis{}, errors.New("ai: hedger does not support analysis")
+	}
+	a, err := analyser.Analyse(ctx, risks)
+	if err != nil {
+		return a, err
+	}
+
+	known := make(map[string]bool, len(risks))
+	for _, r := range risks {
+		known[r.QuestionID] = true
+	}
+	out := Analysis{Priorities: []string{}, Dependencies: []Dependency{}}
+	for _, id := range a.Priorities {
+		if known[id] {
+			out.Priorities = append(out.Priorities, id)
+		}
+	}
+	if known[a.TopPriority] {
+		out.TopPriority = a.TopPriority
+	}
+	for _, d := range a.Dependencies {
+		if !known[d.From] || !known[d.To] || d.From == d.To {
+			continue
+		}
+		if m := injectionMatches(d.Note); len(m) > 0 {
+			g.logger.WarnContext(ctx, "ai: dropping analysis dependency", "audit", true, "from", d.From, "to", d.To, "matches", m)
+			continue
+		}
+		out.Dependencies = append(out.Dependencies, d)
+	}
+	if dropped := len(a.Priorities) - len(out.Priorities); dropped > 0 {
+		g.logger.WarnContext(ctx, "ai: dropping unknown question IDs from analysis", "audit", true, "dropped", dropped)
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Pinger is implemented by providers that support a cheap liveness probe.
+// Both concrete clients implement it; test stubs usually do not.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ProviderHealth is the last known state of one provider.
+type ProviderHealth struct {
+	Healthy   bool      `json:"healthy"`
+	CheckedAt time.Time `json:"checked_at"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// HealthChecker pings every provider at startup and on an interval so an
+// outage is noticed before a paying customer's report depends on it. Results
+// feed /readyz and the provider order used by the chain (see Prioritise).
+type HealthChecker struct {
+	providers map[string]Pinger
+	interval  time.Duration
+	timeout   time.Duration
+	logger    *slog.Logger
+
+	mu     sync.RWMutex
+	status map[string]ProviderHealth
+}
+
+// NewHealthChecker builds a checker for every provider that implements
+// Pinger. Providers without Ping support are assumed healthy.
+func NewHealthChecker(providers map[string]Hedger, interval time.Duration, logger *slog.Logger) *HealthChecker {
+	h := &HealthChecker{
+		providers: make(map[string]Pinger, len(providers)),
+		interval:  interval,
+		timeout:   10 * time.Second,
+		logger:    logger,
+		status:    make(map[string]ProviderHealth, len(providers)),
+	}
+	for name, p := range providers {
+		if pinger, ok := p.(Pinger); ok {
+			h.providers[name] = pinger
+		} else {
+			h.status[name] = ProviderHealth{Healthy: true}
+		}
+	}
+	return h
+}
+
+// CheckAll pings every provider concurrently and records the results.
+func (h *HealthChecker) CheckAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for name, p := range h.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.check(ctx, name, p)
+		}()
+	}
+	wg.Wait()
+}
+
+func (h *HealthChecker) check(ctx context.Context, name string, p Pinger) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := p.Ping(ctx)
+	result := ProviderHealth{
+		Healthy:   err == nil,
+		CheckedAt: time.Now(),
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	h.mu.Lock()
+	prev, seen := h.status[name]
+	h.status[name] = result
+	h.mu.Unlock()
+
+	switch {
+	case err != nil && (!seen || prev.Healthy):
+		h.logger.Warn("ai: provider health check failed", "provider", name, "error", err)
+	case err == nil && seen && !prev.Healthy:
+		h.logger.Info("ai: provider recovered", "provider", name)
+	}
+}
+
+// Start runs CheckAll on every interval tick until ctx is cancelled. Call
+// CheckAll once at startup first so the initial state is known.
+func (h *HealthChecker) Start(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.CheckAll(ctx)
+		}
+	}
+}
+
+// Status returns a copy of the latest result per provider.
+func (h *HealthChecker) Status() map[string]ProviderHealth {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	out := make(map[string]ProviderHealth, len(h.status))
+	for name, st := range h.status {
+		out[name] = st
+	}
+	return out
+}
+
+// Err returns nil when the named provider passed its last check (or has not
+// been checked yet), and an error describing the failure otherwise.
+func (h *HealthChecker) Err(name string) error {
+	h.mu.RLock()
+	st, ok := h.status[name]
+	h.mu.RUnlock()
+
+	if !ok || st.Healthy {
+		return nil
+	}
+	return errors.New(st.Error)
+}
+
+// AnyHealthy returns nil if at least one provider is usable.
+func (h *HealthChecker) AnyHealthy() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.status) == 0 {
+		return nil // nothing checked yet
+	}
+	for _, st := range h.status {
+		if st.Healthy {
+			return nil
+		}
+	}
+	return errors.New("ai: every provider failed its last health check")
+}
+
+// Prioritise returns order with providers that failed their last check moved
+// to the end, preserving relative order otherwise. Unhealthy providers are
+// still tried as a last resort — a failed ping is a strong hint, not proof.
+func (h *HealthChecker) Prioritise(order []string) []string {
+	out := make([]string, len(order))
+	copy(out, order)
+	sort.SliceStable(out, func(i, j int) bool {
+		return h.Err(out[i]) == nil && h.Err(out[j]) != nil
+	})
+	return out
+}
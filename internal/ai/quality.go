@@ -0,0 +1,271 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── QUALITY GATE ─────────────────────────────────────────────────────────────
+//
+// A model reply can be valid JSON and still not be fit to show a customer:
+// one-line or runaway hedges, boilerplate like "as an AI language model",
+// hedges for questions that were never asked, or a reply in another language.
+// qualityGate checks every result and, when it finds issues, asks once more
+// with the issues spelled out. If the second reply fails too the call fails
+// with a *QualityError and the worker keeps the static hedges, recording the
+// issues on the report.
+//
+// Reports are written in English only, so the language check always applies.
+
+// Length bounds, in runes. The upper bounds leave room for premium reports.
+const (
+	minHedgeRunes           = 60
+	maxHedgeRunes           = 1200
+	maxPremiumHedgeRunes    = 3600
+	maxSummaryRunes         = 1200
+	maxTopPriorityHTMLRunes = 600
+
+	// minLanguageWords is the least text the language check judges; shorter
+	// replies are too small to tell.
+	minLanguageWords = 20
+)
+
+// bannedPhrases never belong in a customer's report: refusals, model
+// self-references, template leftovers and our own prompt vocabulary.
+// Matching is case-insensitive.
+var bannedPhrases = []string{
+	"as an ai",
+	"language model",
+	"i cannot provide",
+	"i'm unable to",
+	"i am unable to",
+	"i'm sorry",
+	"lorem ipsum",
+	"[insert",
+	"question_id",
+	"static hedge",
+	"risk_data",
+}
+
+// englishStopwords are common enough that any English prose is full of them
+// and other languages written in Latin script rarely use them.
+var englishStopwords = map[string]bool{
+	"the": true, "and": true, "to": true, "of": true, "a": true, "an": true,
+	"in": true, "for": true, "with": true, "your": true, "is": true, "on": true,
+	"this": true, "that": true, "by": true, "it": true, "be": true, "or": true,
+	"are": true, "as": true, "if": true, "at": true, "from": true, "you": true,
+}
+
+// QualityError is returned when a result still fails the quality checks after
+// the corrective retry.
+type QualityError struct {
+	Issues []string
+}
+
+func (e *QualityError) Error() string {
+	return "ai: output failed quality checks: " + strings.Join(e.Issues, "; ")
+}
+
+// QualityIssues returns the issues of every *QualityError in err's tree, so
+// the reasons survive errors.Join across chunks and providers.
+func QualityIssues(err error) []string {
+	var out []string
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *QualityError:
+		return append(out, e.Issues...)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			out = append(out, QualityIssues(inner)...)
+		}
+		return out
+	}
+	return QualityIssues(errors.Unwrap(err))
+}
+
+type correctionKey struct{}
+
+// withCorrection returns a context that asks providers to fix issues found in
+// their previous reply.
+func withCorrection(ctx context.Context, issues []string) context.Context {
+	return context.WithValue(ctx, correctionKey{}, issues)
+}
+
+func correctionFrom(ctx context.Context) []string {
+	issues, _ := ctx.Value(correctionKey{}).([]string)
+	return issues
+}
+
+// correctionPrompt is appended to the system prompt, followed by the issues,
+// when a reply is retried.
+const correctionPrompt = `
+
+Your previous reply to this request was rejected. Write a new reply that fixes these problems, in English, keyed only by the question_ids in the data:`
+
+// qualityGate wraps another Hedger with the quality checks.
+type qualityGate struct {
+	next   Hedger
+	banned []string
+	logger *slog.Logger
+}
+
+// NewQualityGate returns a Hedger that rejects low-quality output from next,
+// retrying once with a corrective prompt first. banned adds to the built-in
+// list of phrases a reply may not contain.
+func NewQualityGate(next Hedger, banned []string, logger *slog.Logger) Hedger {
+	g := &qualityGate{next: next, logger: logger}
+	for _, p := range append(append([]string{}, bannedPhrases...), banned...) {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			g.banned = append(g.banned, p)
+		}
+	}
+	return g
+}
+
+// GenerateHedges calls the wrapped Hedger and checks its result, retrying
+// once if the checks fail. A provider error is returned as is.
+func (g *qualityGate) GenerateHedges(ctx context.Context, risks []scoring.ScoredRisk) (HedgeResult, error) {
+	result, err := g.next.GenerateHedges(ctx, risks)
+	if err != nil {
+		return result, err
+	}
+	issues := g.check(ctx, risks, result)
+	if len(issues) == 0 {
+		return result, nil
+	}
+	g.logger.WarnContext(ctx, "ai: output failed quality checks, retrying", "audit", true, "issues", issues)
+
+	result, err = g.next.GenerateHedges(withCorrection(ctx, issues), risks)
+	if err != nil {
+		return result, err
+	}
+	if issues = g.check(ctx, risks, result); len(issues) > 0 {
+		g.logger.WarnContext(ctx, "ai: rejecting output after retry", "audit", true, "issues", issues)
+		return HedgeResult{}, &QualityError{Issues: issues}
+	}
+	return result, nil
+}
+
+// Analyse passes the analysis stage through. Its output is IDs and short
+// notes, which the guard already filters.
+func (g *qualityGate) Analyse(ctx context.Context, risks []scoring.ScoredRisk) (Analysis, error) {
+	analyser, ok := g.next.(Analyser)
+	if !ok {
+		return Analysis{}, errors.New("ai: hedger does not support analysis")
+	}
+	return analyser.Analyse(ctx, risks)
+}
+
+// check returns a description of every issue in result, or nil.
+func (g *qualityGate) check(ctx context.Context, risks []scoring.ScoredRisk, result HedgeResult) []string {
+	known := make(map[string]bool, len(risks))
+	for _, r := range risks {
+		known[r.QuestionID] = true
+	}
+	maxHedge := maxHedgeRunes
+	if ReportTypeFrom(ctx) == ReportPremium {
+		maxHedge = maxPremiumHedgeRunes
+	}
+
+	ids := make([]string, 0, len(result.Hedges))
+	for id := range result.Hedges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var issues []string
+	var text strings.Builder
+	for _, id := range ids {
+		hedge := result.Hedges[id]
+		text.WriteString(hedge + "\n")
+		switch n := utf8.RuneCountInString(strings.TrimSpace(hedge)); {
+		case !known[id]:
+			issues = append(issues, fmt.Sprintf("hedge for unknown question_id %q", sanitise(id)))
+			continue
+		case n < minHedgeRunes:
+			issues = append(issues, fmt.Sprintf("hedge for %s is too short (%d characters, at least %d)", id, n, minHedgeRunes))
+		case n > maxHedge:
+			issues = append(issues, fmt.Sprintf("hedge for %s is too long (%d characters, at most %d)", id, n, maxHedge))
+		}
+		if p := g.bannedIn(hedge); p != "" {
+			issues = append(issues, fmt.Sprintf("hedge for %s contains %q", id, p))
+		}
+	}
+	for _, rel := range result.Relationships {
+		if !known[rel.From] || !known[rel.To] {
+			issues = append(issues, fmt.Sprintf("relationship between unknown question_ids %q and %q", sanitise(rel.From), sanitise(rel.To)))
+		}
+	}
+
+	for _, field := range []struct {
+		name, value string
+		max         int
+	}{
+		{"executive_summary", result.ExecutiveSummary, maxSummaryRunes},
+		{"top_priority_html", result.TopPriorityHTML, maxTopPriorityHTMLRunes},
+	} {
+		text.WriteString(field.value + "\n")
+		if n := utf8.RuneCountInString(field.value); n > field.max {
+			issues = append(issues, fmt.Sprintf("%s is too long (%d characters, at most %d)", field.name, n, field.max))
+		}
+		if p := g.bannedIn(field.value); p != "" {
+			issues = append(issues, fmt.Sprintf("%s contains %q", field.name, p))
+		}
+	}
+
+	if !looksEnglish(text.String()) {
+		issues = append(issues, "reply is not in English")
+	}
+	return issues
+}
+
+// bannedIn returns the first banned phrase in s, or "".
+func (g *qualityGate) bannedIn(s string) string {
+	s = strings.ToLower(s)
+	for _, p := range g.banned {
+		if strings.Contains(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// looksEnglish reports whether s reads as English prose: mostly Latin script
+// and with a share of common English words. Text too short to judge passes.
+func looksEnglish(s string) bool {
+	letters, latin := 0, 0
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.Is(unicode.Latin, r) {
+				latin++
+			}
+		}
+	}
+	if letters > 0 && float64(latin)/float64(letters) < 0.8 {
+		return false
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < minLanguageWords {
+		return true
+	}
+	common := 0
+	for _, w := range words {
+		if englishStopwords[w] {
+			common++
+		}
+	}
+	return float64(common)/float64(len(words)) >= 0.1
+}
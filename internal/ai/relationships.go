@@ -0,0 +1,41 @@
+package ai
+
+// Relationship records that one risk makes another more likely or more
+// damaging — "losing the founder would also cut the cash runway". The model
+// writes them with the hedges; the report shows them as a section of their
+// own.
+type Relationship struct {
+	From        string `json:"from"`        // question_id of the risk that drives
+	To          string `json:"to"`          // question_id of the risk it makes worse
+	Description string `json:"description"` // one sentence on how
+}
+
+// MergeRelationships concatenates lists, keeping the first relationship for
+// each from/to pair. It never returns nil, so the result marshals as [].
+func MergeRelationships(lists ...[]Relationship) []Relationship {
+	type pair struct{ from, to string }
+	seen := map[pair]bool{}
+	out := []Relationship{}
+	for _, list := range lists {
+		for _, rel := range list {
+			if p := (pair{rel.From, rel.To}); !seen[p] {
+				seen[p] = true
+				out = append(out, rel)
+			}
+		}
+	}
+	return out
+}
+
+// Relationships returns a's dependencies as relationships, for links the
+// narrative did not write — typically between risks sent in different chunks.
+func (a *Analysis) Relationships() []Relationship {
+	if a == nil {
+		return nil
+	}
+	out := make([]Relationship, 0, len(a.Dependencies))
+	for _, d := range a.Dependencies {
+		out = append(out, Relationship{From: d.From, To: d.To, Description: d.Note})
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"context"
+	"strings"
+)
+
+// Report types, matching the products.report_type enum. Premium reports get
+// longer, more detailed narratives.
+const (
+	ReportStandard = "standard"
+	ReportPremium  = "premium"
+)
+
+type reportTypeKey struct{}
+
+// WithReportType returns a context that asks providers for the given report
+// type. It travels on the context rather than through the Hedger interface so
+// wrappers (chain, guard, chunking) pass it through untouched.
+func WithReportType(ctx context.Context, reportType string) context.Context {
+	return context.WithValue(ctx, reportTypeKey{}, reportType)
+}
+
+// ReportTypeFrom returns the report type on ctx, defaulting to standard.
+func ReportTypeFrom(ctx context.Context) string {
+	if t, ok := ctx.Value(reportTypeKey{}).(string); ok && t != "" {
+		return t
+	}
+	return ReportStandard
+}
+
+// PromptAlternative names the alternative prompt template of the prompt
+// experiment (see package experiments). The empty variant is the default.
+const PromptAlternative = "b"
+
+type promptVariantKey struct{}
+
+// WithPromptVariant returns a context that asks providers for a prompt
+// template variant.
+func WithPromptVariant(ctx context.Context, variant string) context.Context {
+	return context.WithValue(ctx, promptVariantKey{}, variant)
+}
+
+// PromptVariantFrom returns the prompt variant on ctx, or "" for the default.
+func PromptVariantFrom(ctx context.Context) string {
+	v, _ := ctx.Value(promptVariantKey{}).(string)
+	return v
+}
+
+// alternativePrompt is appended to systemPrompt for the PromptAlternative
+// variant.
+const alternativePrompt = `
+
+Open every hedge with the single most effective action as one imperative sentence, then explain it. Prefer concrete amounts, deadlines and thresholds to general advice.`
+
+// premiumPrompt is appended to systemPrompt for premium reports.
+const premiumPrompt = `
+
+This is a premium deep-dive report. For each hedge write 5-8 sentences instead of 2-4: explain why the risk matters for this kind of business, give a phased plan (first 30 days, 90 days, 12 months) with rough costs, and name the early-warning signals to monitor. The executive_summary may be up to 5 sentences.`
+
+// promptFor returns the system prompt and max_tokens budget for the report
+// type and prompt variant requested on ctx, and explains the playbook snippets and analysis if
+// there are any. A retry after failed quality checks lists the issues.
+func promptFor(ctx context.Context) (string, int) {
+	system, maxTokens := systemPrompt, 2048
+	if ReportTypeFrom(ctx) == ReportPremium {
+		system, maxTokens = systemPrompt+premiumPrompt, 6144
+	}
+	if PromptVariantFrom(ctx) == PromptAlternative {
+		system += alternativePrompt
+	}
+	if len(SnippetsFrom(ctx)) > 0 {
+		system += playbookPrompt
+	}
+	if AnalysisFrom(ctx) != nil {
+		system += narrativeAnalysisPrompt
+	}
+	if issues := correctionFrom(ctx); len(issues) > 0 {
+		system += correctionPrompt + "\n- " + strings.Join(issues, "\n- ")
+	}
+	return system, maxTokens
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ─── TRANSCRIPTS ──────────────────────────────────────────────────────────────
+//
+// A Transcript on the context records every request a provider sends and the
+// raw response it gets back, failed calls included, so that "why did the AI
+// say this?" can be answered from the exact prompt and reply rather than
+// guessed at. The worker stores one per generation attempt (see
+// ai_transcripts). Like the budget it travels on the context, so every
+// wrapper in the chain passes it through.
+
+// Exchange is one provider call.
+type Exchange struct {
+	Provider   string          `json:"provider"`
+	Model      string          `json:"model"`
+	At         time.Time       `json:"at"`
+	DurationMS int64           `json:"duration_ms"`
+	Status     int             `json:"status,omitempty"` // HTTP status; 0 when no response arrived
+	Request    json.RawMessage `json:"request"`          // the body sent, prompts included
+	Response   string          `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// Transcript collects a report's exchanges. It is safe for concurrent use by
+// the chunks of one report. A nil *Transcript records nothing.
+type Transcript struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewTranscript returns an empty transcript.
+func NewTranscript() *Transcript {
+	return &Transcript{}
+}
+
+// Exchanges returns the calls recorded so far, in the order they finished.
+func (t *Transcript) Exchanges() []Exchange {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Exchange(nil), t.exchanges...)
+}
+
+// record finishes ex, a call started at start whose outcome is *err, and
+// appends it. Providers defer it right before sending a request.
+func (t *Transcript) record(ex *Exchange, start time.Time, err *error) {
+	if t == nil {
+		return
+	}
+	ex.At = start.UTC()
+	ex.DurationMS = time.Since(start).Milliseconds()
+	if *err != nil {
+		ex.Error = (*err).Error()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = append(t.exchanges, *ex)
+}
+
+type transcriptKey struct{}
+
+// WithTranscript returns a context whose provider calls are recorded in t.
+func WithTranscript(ctx context.Context, t *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, t)
+}
+
+// TranscriptFrom returns the transcript on ctx, or nil.
+func TranscriptFrom(ctx context.Context) *Transcript {
+	t, _ := ctx.Value(transcriptKey{}).(*Transcript)
+	return t
+}
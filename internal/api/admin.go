@@ -0,0 +1,280 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/settings"
+)
+
+// ─── GET /api/admin/config ────────────────────────────────────────────────────
+//
+// Returns the configuration this process loaded at startup, keyed by
+// environment variable name, with secrets masked. Lets operators confirm what
+// a deploy actually picked up without shelling into the container.
+//
+// Requires Authorization: Bearer <ADMIN_API_KEY>.
+
+func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, map[string]any{
+		"config": s.cfg.ConfigReport,
+	})
+}
+
+// ─── GET /api/admin/settings ──────────────────────────────────────────────────
+//
+// Returns the raw runtime_settings rows alongside the effective values this
+// instance is currently using. The two can differ briefly after a write until
+// every instance's watcher has reloaded, or permanently if a row is invalid.
+
+type effectiveSettingsResponse struct {
+	PollInterval       string   `json:"poll_interval"`
+	AIProviderOrder    []string `json:"ai_provider_order"`
+	ScoreProfile       string   `json:"score_profile"`
+	ShadowScoreProfile string   `json:"shadow_score_profile"` // "" when none runs in shadow
+}
+
+func (s *Server) handleAdminListSettings(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.q.ListRuntimeSettings(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list runtime settings: %w", err))
+		return
+	}
+
+	cur := s.cfg.Settings.Current()
+	respond(w, http.StatusOK, map[string]any{
+		"settings": rows,
+		"effective": effectiveSettingsResponse{
+			PollInterval:       cur.PollInterval.String(),
+			AIProviderOrder:    cur.AIProviderOrder,
+			ScoreProfile:       cur.ScoreProfile.String(),
+			ShadowScoreProfile: cur.ShadowString(),
+		},
+	})
+}
+
+// ─── PUT /api/admin/settings/:key ─────────────────────────────────────────────
+//
+// Validates and stores a runtime setting, then reloads this instance's watcher
+// so the change is visible immediately. Other instances pick it up on their
+// next reload interval (or on SIGHUP).
+
+type putSettingRequest struct {
+	Value string `json:"value"`
+}
+
+func (s *Server) handleAdminPutSetting(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+
+	var req putSettingRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if err := settings.Validate(key, req.Value); err != nil {
+		respondErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	row, err := s.q.UpsertRuntimeSetting(r.Context(), db.UpsertRuntimeSettingParams{
+		Key:   key,
+		Value: strings.TrimSpace(req.Value),
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("upsert runtime setting: %w", err))
+		return
+	}
+
+	s.reloadSettings(r)
+	respond(w, http.StatusOK, row)
+}
+
+// ─── DELETE /api/admin/settings/:key ──────────────────────────────────────────
+//
+// Removes a runtime setting so the built-in default applies again.
+
+func (s *Server) handleAdminDeleteSetting(w http.ResponseWriter, r *http.Request) {
+	n, err := s.q.DeleteRuntimeSetting(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("delete runtime setting: %w", err))
+		return
+	}
+	if n == 0 {
+		respondErr(w, http.StatusNotFound, "setting not found")
+		return
+	}
+
+	s.reloadSettings(r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadSettings refreshes the local watcher after a write and tells the
+// other replicas to do the same. Failure is logged only — the periodic reload
+// will catch up.
+func (s *Server) reloadSettings(r *http.Request) {
+	if s.cfg.Settings == nil {
+		return
+	}
+	if err := s.cfg.Settings.Reload(r.Context()); err != nil {
+		s.logger.Error("admin: settings reload failed", "error", err, logField(r))
+	}
+	s.cfg.Reloads.Notify(r.Context(), cluster.TopicReload)
+}
+
+// ─── DELETE /api/admin/reports/:reportID ──────────────────────────────────────
+//
+// Revokes a report, for a customer's deletion request or a report bought with
+// a fraudulent payment. It is a soft delete: the row, its results and the
+// payment stay for accounting, but the report, consultation and invoice links
+// answer 410 and the worker no longer generates it. A reason is required for
+// the audit trail. Revoking a revoked report returns it unchanged.
+
+type revokeReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+type revokeReportResponse struct {
+	ReportID  string `json:"report_id"`
+	RevokedAt string `json:"revoked_at"`
+	Reason    string `json:"reason"`
+}
+
+func (s *Server) handleAdminRevokeReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := parseUUID(chi.URLParam(r, "reportID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid report_id")
+		return
+	}
+	var req revokeReportRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		respondErr(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	report, err := s.q.RevokeReport(r.Context(), db.RevokeReportParams{ID: reportID, RevokedReason: req.Reason})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Unknown, or revoked already — the latter is a success.
+		report, err = s.q.GetReportByID(r.Context(), reportID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondErr(w, http.StatusNotFound, "report not found")
+			return
+		}
+	} else if err == nil {
+		s.reports.forget(r.Context(), report.AccessToken)
+		s.logger.Info("admin: report revoked",
+			"report_id", report.ID,
+			"reason", req.Reason,
+			"audit", true,
+			logField(r),
+		)
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("revoke report: %w", err))
+		return
+	}
+
+	respond(w, http.StatusOK, revokeReportResponse{
+		ReportID:  report.ID.String(),
+		RevokedAt: report.RevokedAt.Time.UTC().Format(time.RFC3339),
+		Reason:    report.RevokedReason.String,
+	})
+}
+
+// ─── GET /api/admin/stats ─────────────────────────────────────────────────────
+//
+// Returns the sales funnel, the report → consultation conversion rate, per
+// settlement currency what Stripe kept in fees (margin is net / gross), and
+// the conversion and report quality of each A/B experiment variant.
+
+type consultationStatsResponse struct {
+	Requests          int64   `json:"requests"`
+	ConversionRate    float64 `json:"conversion_rate"`
+	Requests30d       int64   `json:"requests_30d"`
+	ConversionRate30d float64 `json:"conversion_rate_30d"`
+}
+
+type paymentMarginResponse struct {
+	Currency      string  `json:"currency"`
+	Payments      int64   `json:"payments"`
+	GrossCents    int64   `json:"gross_cents"`
+	FeeCents      int64   `json:"fee_cents"`
+	NetCents      int64   `json:"net_cents"`
+	Margin        float64 `json:"margin"`
+	Payments30d   int64   `json:"payments_30d"`
+	GrossCents30d int64   `json:"gross_cents_30d"`
+	FeeCents30d   int64   `json:"fee_cents_30d"`
+	NetCents30d   int64   `json:"net_cents_30d"`
+	Margin30d     float64 `json:"margin_30d"`
+}
+
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	funnel, err := s.q.GetCompletionFunnelStats(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get funnel stats: %w", err))
+		return
+	}
+	consult, err := s.q.GetConsultationStats(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get consultation stats: %w", err))
+		return
+	}
+
+	margins, err := s.q.GetPaymentMarginStats(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get payment margin stats: %w", err))
+		return
+	}
+	payments := make([]paymentMarginResponse, len(margins))
+	for i, m := range margins {
+		payments[i] = paymentMarginResponse{
+			Currency:      m.Currency,
+			Payments:      m.Payments,
+			GrossCents:    m.GrossCents,
+			FeeCents:      m.FeeCents,
+			NetCents:      m.NetCents,
+			Margin:        ratio(m.NetCents, m.GrossCents),
+			Payments30d:   m.Payments30d,
+			GrossCents30d: m.GrossCents30d,
+			FeeCents30d:   m.FeeCents30d,
+			NetCents30d:   m.NetCents30d,
+			Margin30d:     ratio(m.NetCents30d, m.GrossCents30d),
+		}
+	}
+
+	experimentStats, err := s.experimentStats(r)
+	if err != nil {
+		s.respondInternalErr(w, r, err)
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]any{
+		"funnel": funnel,
+		"payments": payments,
+		"experiments": experimentStats,
+		"consultations": consultationStatsResponse{
+			Requests:          consult.Requests,
+			ConversionRate:    ratio(consult.Requests, consult.ReportsDelivered),
+			Requests30d:       consult.Requests30d,
+			ConversionRate30d: ratio(consult.Requests30d, consult.ReportsDelivered30d),
+		},
+	})
+}
+
+// ratio returns n/d, or 0 when d is 0.
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
@@ -4,9 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
 )
 
 // ─── PUT /api/session/:sessionID/answers ─────────────────────────────────────
@@ -14,6 +18,29 @@ import (
 // Accepts a batch of answers and upserts them. The browser sends the full
 // current answer set on every navigation (or a partial batch on debounce).
 // Using upsert means it is safe to replay the same payload multiple times.
+//
+// Every answer is checked against its question definition before anything is
+// written: the question must exist, text answers are capped at
+// maxAnswerTextLen characters, and radio answers must be one of the options
+// (or empty, for a skipped question). With Config.StrictAnswers off, an
+// unrecognised radio answer is stored and logged instead — it scores (1,1).
+//
+// A request may carry at most maxAnswers answers, derived from the number of
+// question definitions so the questionnaire can grow without breaking saves.
+// The questions endpoint reports the limit under limits.
+//
+// With Config.SectionGating on, answers to a gated section are rejected with
+// 409 while a section before it is incomplete, counting the answers in the
+// same request (see sections.go).
+//
+// An answer with skipped set marks the question as not applicable. Its text
+// must be blank. Unlike a blank answer, which scores (1,1), a skipped scoring
+// question is left out of the report's risks and overall score, and the
+// report lists it. A skip counts as a response for progress and gating.
+
+// maxAnswerTextLen caps a single answer. Long enough for a considered free-text
+// reply; far beyond any radio option label.
+const maxAnswerTextLen = 2000
 
 type answerInput struct {
 	QuestionID string `json:"question_id"`
@@ -23,6 +50,8 @@ type answerInput struct {
 	// recomputes its own scores from scoring_config during report generation.
 	ClientP *int16 `json:"client_p,omitempty"`
 	ClientI *int16 `json:"client_i,omitempty"`
+	// Skipped marks the question as not applicable; answer_text must be blank.
+	Skipped bool `json:"skipped,omitempty"`
 }
 
 type upsertAnswersRequest struct {
@@ -55,22 +84,73 @@ func (s *Server) handleUpsertAnswers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Answers) > 100 {
-		respondErr(w, http.StatusBadRequest, "too many answers in a single request (max 100)")
+	questions, err := s.q.GetAllQuestionDefinitions(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get questions: %w", err))
+		return
+	}
+	if limit := s.maxAnswers(len(questions)); len(req.Answers) > limit {
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("too many answers in a single request (max %d)", limit))
 		return
 	}
+	byID := make(map[string]db.QuestionDefinition, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
 
-	upserted := 0
 	for _, a := range req.Answers {
 		if a.QuestionID == "" {
 			respondErr(w, http.StatusBadRequest, "each answer must have a non-empty question_id")
 			return
 		}
+		q, ok := byID[a.QuestionID]
+		if !ok {
+			respondErr(w, http.StatusBadRequest, fmt.Sprintf("unknown question_id %q", a.QuestionID))
+			return
+		}
+		if a.Skipped && strings.TrimSpace(a.AnswerText) != "" {
+			respondErr(w, http.StatusBadRequest, fmt.Sprintf("answer for %q is skipped but has answer_text", a.QuestionID))
+			return
+		}
+		if msg := s.checkAnswer(r, sessionID, q, a.AnswerText); msg != "" {
+			respondErr(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	if s.cfg.SectionGating {
+		saved, err := s.q.GetAnswersBySession(r.Context(), sessionID)
+		if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("get answers: %w", err))
+			return
+		}
+		answered := make(map[string]bool, len(saved)+len(req.Answers))
+		for _, a := range saved {
+			answered[a.QuestionID] = responded(a.AnswerText, a.Skipped)
+		}
+		for _, a := range req.Answers {
+			answered[a.QuestionID] = responded(a.AnswerText, a.Skipped)
+		}
+		locked, err := s.lockedSections(r, questions, answered)
+		if err != nil {
+			s.respondInternalErr(w, r, err)
+			return
+		}
+		for _, a := range req.Answers {
+			if section := byID[a.QuestionID].SectionID; locked[string(section)] {
+				respondErr(w, http.StatusConflict, fmt.Sprintf("section %q is locked until the sections before it are complete", section))
+				return
+			}
+		}
+	}
 
+	upserted := 0
+	for _, a := range req.Answers {
 		params := db.UpsertAnswerParams{
 			SessionID:  sessionID,
 			QuestionID: a.QuestionID,
 			AnswerText: a.AnswerText,
+			Skipped:    a.Skipped,
 		}
 
 		if a.ClientP != nil {
@@ -88,4 +168,48 @@ func (s *Server) handleUpsertAnswers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respond(w, http.StatusOK, upsertAnswersResponse{Upserted: upserted})
+}
+
+// responded reports whether an answer counts towards progress: it has
+// non-blank text or the question was skipped.
+func responded(text string, skipped bool) bool {
+	return skipped || strings.TrimSpace(text) != ""
+}
+
+// maxAnswers is the most answers one request may carry when there are
+// questions question definitions: one each, plus Config.AnswerBatchHeadroom.
+func (s *Server) maxAnswers(questions int) int {
+	return questions + s.cfg.AnswerBatchHeadroom
+}
+
+// checkAnswer validates answer against its question definition and returns a
+// message for the client when it must be rejected.
+func (s *Server) checkAnswer(r *http.Request, sessionID uuid.UUID, q db.QuestionDefinition, answer string) string {
+	if utf8.RuneCountInString(answer) > maxAnswerTextLen {
+		return fmt.Sprintf("answer for %q must be at most %d characters", q.ID, maxAnswerTextLen)
+	}
+	if q.Type != db.QuestionTypeRadio || strings.TrimSpace(answer) == "" {
+		return ""
+	}
+
+	cfg, err := scoring.ParseScoringConfig(q.ScoringConfig)
+	if err != nil || !cfg.IsRadio() {
+		// A broken definition is an operator problem; the worker reports it
+		// when scoring. Don't block the customer over it.
+		s.logger.Warn("answers: cannot check radio answer, bad scoring config",
+			"question_id", q.ID, "error", err, logField(r))
+		return ""
+	}
+	if cfg.Radio().HasOption(answer) {
+		return ""
+	}
+	if s.cfg.StrictAnswers {
+		return fmt.Sprintf("answer for %q is not one of its options", q.ID)
+	}
+	s.logger.Warn("answers: storing answer that is not an option",
+		"session_id", sessionID,
+		"question_id", q.ID,
+		logField(r),
+	)
+	return ""
 }
\ No newline at end of file
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ─── STORED ARTIFACTS ─────────────────────────────────────────────────────────
+//
+// Generated files that are large or slow to build can be uploaded to
+// Config.Storage and handed out as a signed URL, which the client downloads
+// straight from the bucket. Each upload gets a fresh key, so links to an
+// earlier export keep working until they expire; set a lifecycle rule on the
+// bucket to delete old artifacts.
+
+type artifactLinkResponse struct {
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// respondArtifactLink uploads body under prefix and answers with a signed URL
+// that downloads it as filename. Callers check that Config.Storage is set.
+func (s *Server) respondArtifactLink(w http.ResponseWriter, r *http.Request, prefix, filename, contentType string, body []byte) {
+	key := prefix + "/" + uuid.NewString() + "/" + filename
+	if err := s.cfg.Storage.Put(r.Context(), key, contentType, body); err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("upload %s: %w", prefix, err))
+		return
+	}
+	url, expires := s.cfg.Storage.SignedURL(key, filename)
+
+	s.logger.Info("artifact uploaded",
+		"key", key,
+		"bytes", len(body),
+		logField(r),
+	)
+	respond(w, http.StatusOK, artifactLinkResponse{URL: url, ExpiresAt: expires.UTC().Format(time.RFC3339)})
+}
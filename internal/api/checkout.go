@@ -1,21 +1,50 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fraud"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
 	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
 )
 
 // ─── POST /api/session/:sessionID/checkout ────────────────────────────────────
 
+// countryCode matches an ISO 3166-1 alpha-2 code after upper-casing.
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
 type createCheckoutRequest struct {
 	Email string `json:"email"`
+	// SKU selects the product from GET /api/products. Defaults to the
+	// standard report.
+	SKU string `json:"sku"`
+	// BillingCountry is the ISO 3166-1 alpha-2 code of the customer's billing
+	// address. Required when Stripe Tax is enabled, since it decides the tax.
+	BillingCountry string `json:"billing_country"`
+	// BillingPostalCode is needed by Stripe Tax for US and Canadian addresses.
+	BillingPostalCode string `json:"billing_postal_code"`
+
+	// Optional details printed on the invoice (GET /api/report/:token/invoice).
+	// BillingName defaults to the session's business name.
+	BillingName         string `json:"billing_name"`
+	BillingAddressLine1 string `json:"billing_address_line1"`
+	BillingAddressLine2 string `json:"billing_address_line2"`
+	BillingCity         string `json:"billing_city"`
+	BillingTaxID        string `json:"billing_tax_id"`
 }
 
+// maxBillingFieldLen caps each free-text billing field.
+const maxBillingFieldLen = 200
+
 type createCheckoutResponse struct {
 	// ClientSecret is the Stripe PaymentIntent client_secret. The browser
 	// passes this to Stripe.js to render the payment UI and confirm the charge.
@@ -24,6 +53,26 @@ type createCheckoutResponse struct {
 	// user opened checkout twice). The browser should use the returned secret
 	// normally — the PI is still valid and confirmable.
 	IsExisting bool `json:"is_existing,omitempty"`
+	// SubtotalCents, TaxCents and AmountCents break down what the
+	// PaymentIntent charges so the payment form can show the tax line. Omitted
+	// on the IsExisting path.
+	SubtotalCents int64  `json:"subtotal_cents,omitempty"`
+	TaxCents      int64  `json:"tax_cents,omitempty"`
+	AmountCents   int64  `json:"amount_cents,omitempty"`
+	Currency      string `json:"currency,omitempty"`
+	// CoveredBySubscription is true when the report was paid for with the
+	// customer's quarterly re-assessment instead. There is no client_secret —
+	// the report is already being generated and will be emailed as usual.
+	CoveredBySubscription bool `json:"covered_by_subscription,omitempty"`
+	// CoveredByCredit is true when the report was paid for by an earlier
+	// duplicate purchase the customer kept as credit. As with
+	// CoveredBySubscription there is no client_secret.
+	CoveredByCredit bool `json:"covered_by_credit,omitempty"`
+	// EmailSuggestion is the address with its domain corrected when the one
+	// given looks mistyped (gmial.com, acme.con). It is only a warning: the
+	// checkout used the address as given, so the form should confirm it with
+	// the customer, and call again with the corrected one, before payment.
+	EmailSuggestion string `json:"email_suggestion,omitempty"`
 }
 
 // handleCreateCheckout creates a Stripe PaymentIntent for the session and
@@ -33,6 +82,10 @@ type createCheckoutResponse struct {
 // store.AttachPaymentIntent using a serializable transaction. The second call
 // receives ErrPaymentIntentAlreadyAttached and returns the existing
 // client_secret rather than creating a second PI.
+//
+// Subscribers with an unused re-assessment this quarter skip payment entirely
+// when buying the standard report; see redeemSubscription. So do customers
+// with a duplicate purchase kept as credit for the product; see redeemCredit.
 func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 	sessionID, err := parseUUID(chi.URLParam(r, "sessionID"))
 	if err != nil {
@@ -49,6 +102,40 @@ func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 		respondErr(w, http.StatusBadRequest, "email is required")
 		return
 	}
+	if req.SKU == "" {
+		req.SKU = defaultProductSKU
+	}
+	req.BillingCountry = strings.ToUpper(strings.TrimSpace(req.BillingCountry))
+	if req.BillingCountry == "" && s.cfg.StripeTax {
+		respondErr(w, http.StatusBadRequest, "billing_country is required")
+		return
+	}
+	if req.BillingCountry != "" && !countryCode.MatchString(req.BillingCountry) {
+		respondErr(w, http.StatusBadRequest, "billing_country must be a two-letter ISO country code")
+		return
+	}
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{"billing_postal_code", &req.BillingPostalCode},
+		{"billing_name", &req.BillingName},
+		{"billing_address_line1", &req.BillingAddressLine1},
+		{"billing_address_line2", &req.BillingAddressLine2},
+		{"billing_city", &req.BillingCity},
+		{"billing_tax_id", &req.BillingTaxID},
+	} {
+		*f.value = strings.TrimSpace(*f.value)
+		if len(*f.value) > maxBillingFieldLen {
+			respondErr(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", f.name, maxBillingFieldLen))
+			return
+		}
+	}
+
+	product, ok := s.lookupProduct(w, r, req.SKU)
+	if !ok {
+		return
+	}
 
 	// ── Fast path: session already has a PI ───────────────────────────────────
 	// Check before calling Stripe to avoid creating an unnecessary PI object.
@@ -60,7 +147,45 @@ func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if existingSession.StripePaymentIntent.Valid && existingSession.StripePaymentIntent.String != "" {
+	hasPI := existingSession.StripePaymentIntent.Valid && existingSession.StripePaymentIntent.String != ""
+
+	// ── Subscription path: no charge for an entitled subscriber ──────────────
+	// Only before card checkout has started — redeeming a session whose PI the
+	// customer might still confirm could charge them for a covered report.
+	if !hasPI && product.Sku == defaultProductSKU {
+		if s.redeemSubscription(w, r, store.RedeemSubscriptionParams{
+			SessionID:  sessionID,
+			Email:      req.Email,
+			ProductSKU: product.Sku,
+		}) {
+			return
+		}
+	}
+	if !hasPI && s.redeemCredit(w, r, store.RedeemSubscriptionParams{
+		SessionID:  sessionID,
+		Email:      req.Email,
+		ProductSKU: product.Sku,
+	}) {
+		return
+	}
+
+	if hasPI {
+		// The existing PI was created for a fixed amount. Switching products
+		// after checkout has started is not supported.
+		attachedSKU := existingSession.ProductSku.String
+		if attachedSKU == "" {
+			attachedSKU = defaultProductSKU
+		}
+		if attachedSKU != product.Sku {
+			respondErr(w, http.StatusConflict, "checkout already started for a different product")
+			return
+		}
+		// Likewise the tax was calculated for the original billing country.
+		if s.cfg.StripeTax && existingSession.BillingCountry.String != req.BillingCountry {
+			respondErr(w, http.StatusConflict, "checkout already started for a different billing country")
+			return
+		}
+
 		clientSecret, err := s.stripe.GetClientSecret(r.Context(), existingSession.StripePaymentIntent.String)
 		if err != nil {
 			// PI exists in our DB but Stripe can't find it — unusual.
@@ -72,21 +197,69 @@ func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 			)
 		} else {
 			respond(w, http.StatusOK, createCheckoutResponse{
-				ClientSecret: clientSecret,
-				IsExisting:   true,
+				ClientSecret:    clientSecret,
+				IsExisting:      true,
+				EmailSuggestion: email.Suggest(req.Email),
 			})
 			return
 		}
 	}
 
+	// ── Anti-abuse checks before a new PaymentIntent is created ──────────────
+	// Resuming an existing PI and redeeming a subscription are not checked:
+	// neither lets a card tester try a new card against us.
+	if s.cfg.Fraud != nil {
+		decision := s.cfg.Fraud.Check(r.Context(), fraud.Input{
+			SessionID: sessionID,
+			Email:     req.Email,
+			IPHash:    existingSession.IpHash.String,
+		})
+		if decision.Action == fraud.ActionBlock {
+			respondErr(w, http.StatusForbidden, "checkout is not available; contact support if this is unexpected")
+			return
+		}
+	}
+
+	// ── Price the purchase, adding tax when Stripe Tax is on ──────────────────
+	// Sessions in the price experiment's alternative pay its price instead.
+	priceVariant := s.sessionVariant(r, sessionID, experiments.Price)
+	product.PriceCents = s.cfg.Experiments.PriceCents(priceVariant, product.Sku, product.PriceCents)
+	tax := stripeinternal.TaxCalculation{AmountTotalCents: int64(product.PriceCents)}
+	if s.cfg.StripeTax {
+		tax, err = s.stripe.CalculateTax(r.Context(), stripeinternal.TaxParams{
+			AmountCents: int64(product.PriceCents),
+			Currency:    product.Currency,
+			Country:     req.BillingCountry,
+			PostalCode:  req.BillingPostalCode,
+			Reference:   product.Sku,
+		})
+		if errors.Is(err, stripeinternal.ErrInvalidTaxLocation) {
+			respondErr(w, http.StatusBadRequest, "billing address is not valid for tax purposes; check billing_postal_code")
+			return
+		}
+		if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("calculate tax: %w", err))
+			return
+		}
+	}
+
+	metadata := map[string]string{
+		"session_id": sessionID.String(),
+		"sku":        product.Sku,
+	}
+	if tax.ID != "" {
+		metadata["tax_calculation"] = tax.ID
+	}
+	if s.cfg.Experiments.Running(experiments.Price) {
+		metadata["price_variant"] = priceVariant
+	}
+
 	// ── Create a new Stripe PaymentIntent ─────────────────────────────────────
 	pi, err := s.stripe.CreatePaymentIntent(r.Context(), stripeinternal.CreatePaymentIntentParams{
-		AmountCents: 5900, // $59.00 — fixed price
-		Currency:    "usd",
+		AmountCents: tax.AmountTotalCents,
+		Currency:    product.Currency,
 		Email:       req.Email,
-		Metadata: map[string]string{
-			"session_id": sessionID.String(),
-		},
+		Metadata:    metadata,
 	})
 	if err != nil {
 		s.respondInternalErr(w, r, fmt.Errorf("create payment intent: %w", err))
@@ -99,6 +272,17 @@ func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 		StripeCustomerID:    pi.CustomerID,
 		StripePaymentIntent: pi.ID,
 		Email:               req.Email,
+		ProductSKU:          product.Sku,
+		BillingCountry:      req.BillingCountry,
+		BillingPostalCode:   req.BillingPostalCode,
+		SubtotalCents:       product.PriceCents,
+		TaxCents:            int32(tax.TaxCents),
+		TaxCalculationID:    tax.ID,
+		BillingName:         req.BillingName,
+		BillingAddressLine1: req.BillingAddressLine1,
+		BillingAddressLine2: req.BillingAddressLine2,
+		BillingCity:         req.BillingCity,
+		BillingTaxID:        req.BillingTaxID,
 	})
 
 	if errors.Is(err, store.ErrPaymentIntentAlreadyAttached) {
@@ -120,8 +304,9 @@ func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		respond(w, http.StatusOK, createCheckoutResponse{
-			ClientSecret: clientSecret,
-			IsExisting:   true,
+			ClientSecret:    clientSecret,
+			IsExisting:      true,
+			EmailSuggestion: email.Suggest(req.Email),
 		})
 		return
 	}
@@ -132,6 +317,102 @@ func (s *Server) handleCreateCheckout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respond(w, http.StatusOK, createCheckoutResponse{
-		ClientSecret: pi.ClientSecret,
+		ClientSecret:    pi.ClientSecret,
+		SubtotalCents:   int64(product.PriceCents),
+		TaxCents:        tax.TaxCents,
+		AmountCents:     tax.AmountTotalCents,
+		Currency:        product.Currency,
+		EmailSuggestion: email.Suggest(req.Email),
 	})
+}
+
+// redeemSubscription pays for the session with the email's subscription
+// entitlement if it has one. It returns true once it has written a response;
+// false means the customer is not entitled and checkout should carry on.
+func (s *Server) redeemSubscription(w http.ResponseWriter, r *http.Request, p store.RedeemSubscriptionParams) bool {
+	// Cheap read outside the transaction so non-subscribers — nearly every
+	// checkout — never open one.
+	_, err := s.q.GetEntitledSubscription(r.Context(), p.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get entitled subscription: %w", err))
+		return true
+	}
+
+	report, err := s.store.RedeemSubscription(r.Context(), p)
+	if errors.Is(err, store.ErrNotEntitled) {
+		// Used up by a concurrent checkout since the read above.
+		return false
+	}
+	if errors.Is(err, store.ErrSessionAlreadyPaid) {
+		respondErr(w, http.StatusConflict, "session is already paid")
+		return true
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("redeem subscription: %w", err))
+		return true
+	}
+
+	s.logger.Info("checkout: covered by subscription",
+		"session_id", p.SessionID,
+		"report_id", report.ID,
+		logField(r),
+	)
+	if err := s.worker.Enqueue(r.Context(), report.ID); err != nil {
+		s.logger.Warn("checkout: enqueue failed, will be picked up by poller",
+			"report_id", report.ID,
+			"error", err,
+			logField(r),
+		)
+	}
+
+	respond(w, http.StatusOK, createCheckoutResponse{CoveredBySubscription: true})
+	return true
+}
+
+// redeemCredit pays for the session with a duplicate purchase the email kept
+// as credit, if it has one for this product. Like redeemSubscription it
+// returns true once it has written a response.
+func (s *Server) redeemCredit(w http.ResponseWriter, r *http.Request, p store.RedeemSubscriptionParams) bool {
+	// Cheap read outside the transaction, as for subscriptions.
+	_, err := s.q.GetDuplicateCredit(r.Context(), db.GetDuplicateCreditParams{Email: p.Email, ProductSku: p.ProductSKU})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get duplicate credit: %w", err))
+		return true
+	}
+
+	report, err := s.store.RedeemDuplicateCredit(r.Context(), p)
+	if errors.Is(err, store.ErrNotEntitled) {
+		return false
+	}
+	if errors.Is(err, store.ErrSessionAlreadyPaid) {
+		respondErr(w, http.StatusConflict, "session is already paid")
+		return true
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("redeem duplicate credit: %w", err))
+		return true
+	}
+
+	s.logger.Info("checkout: covered by duplicate purchase credit",
+		"session_id", p.SessionID,
+		"report_id", report.ID,
+		"audit", true,
+		logField(r),
+	)
+	if err := s.worker.Enqueue(r.Context(), report.ID); err != nil {
+		s.logger.Warn("checkout: enqueue failed, will be picked up by poller",
+			"report_id", report.ID,
+			"error", err,
+			logField(r),
+		)
+	}
+
+	respond(w, http.StatusOK, createCheckoutResponse{CoveredByCredit: true})
+	return true
 }
\ No newline at end of file
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+)
+
+// ─── POST /api/admin/cohorts/:cohort ──────────────────────────────────────────
+//
+// Imports a cohort of pre-answered assessments — a paper survey, a partner's
+// data — for a B2B deal. The body is a CSV with a header row: email is
+// required, biz_name, industry, stage and product_sku are optional, and every
+// other column is a question ID. Empty cells are left unanswered.
+//
+// Every row is validated first, answers as PUT .../answers validates them,
+// and the whole import is rejected if any row is invalid. Then each row
+// becomes a paid session with its answers and a draft report, in one
+// transaction. The reports go through the normal pipeline, report-ready
+// email included, but are scheduled COHORT_REPORTS_PER_MINUTE apart after any
+// earlier import still waiting, so a cohort never crowds out paying
+// customers. No receipt is sent, and cohort sessions are left out of sales
+// and funnel stats.
+//
+// ?dry_run=true validates without writing anything.
+
+// maxCohortCSVBytes bounds the request body; a thousand fully answered rows
+// are well under it.
+const maxCohortCSVBytes = 8 << 20
+
+// cohortPattern is what a cohort label may look like, e.g. "acme-2026-q4".
+var cohortPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// cohortContextColumns are the CSV columns that are not question IDs.
+var cohortContextColumns = map[string]bool{
+	"email": true, "biz_name": true, "industry": true, "stage": true, "product_sku": true,
+}
+
+type cohortImportResponse struct {
+	Cohort  string         `json:"cohort"`
+	DryRun  bool           `json:"dry_run"`
+	Rows    int            `json:"rows"`
+	Reports []cohortReport `json:"reports"` // empty for a dry run
+}
+
+type cohortReport struct {
+	Row       int    `json:"row"` // 1 is the first row after the header
+	Email     string `json:"email"`
+	ReportID  string `json:"report_id"`
+	NotBefore string `json:"not_before"` // when the worker may generate it
+}
+
+func (s *Server) handleAdminImportCohort(w http.ResponseWriter, r *http.Request) {
+	cohort := chi.URLParam(r, "cohort")
+	if !cohortPattern.MatchString(cohort) {
+		respondErr(w, http.StatusBadRequest, "cohort must be 1-64 lowercase letters, digits, '.', '_' or '-'")
+		return
+	}
+
+	questions, err := s.q.GetAllQuestionDefinitions(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get questions: %w", err))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxCohortCSVBytes)
+	sessions, msg, err := s.parseCohortCSV(r, questions)
+	if err != nil {
+		s.respondInternalErr(w, r, err)
+		return
+	}
+	if msg != "" {
+		respondErr(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	resp := cohortImportResponse{
+		Cohort:  cohort,
+		DryRun:  r.URL.Query().Get("dry_run") == "true",
+		Rows:    len(sessions),
+		Reports: []cohortReport{},
+	}
+	if resp.DryRun {
+		respond(w, http.StatusOK, resp)
+		return
+	}
+
+	for i := range sessions {
+		if sessions[i].AnonToken, err = newAnonToken(); err != nil {
+			s.respondInternalErr(w, r, err)
+			return
+		}
+	}
+	var spacing time.Duration
+	if s.cfg.CohortReportsPerMinute > 0 {
+		spacing = time.Minute / time.Duration(s.cfg.CohortReportsPerMinute)
+	}
+	reports, err := s.store.ImportCohort(r.Context(), store.ImportCohortParams{
+		Cohort:   cohort,
+		Sessions: sessions,
+		Spacing:  spacing,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("import cohort: %w", err))
+		return
+	}
+
+	for i, rep := range reports {
+		resp.Reports = append(resp.Reports, cohortReport{
+			Row:       i + 1,
+			Email:     sessions[i].Email,
+			ReportID:  rep.ID.String(),
+			NotBefore: rep.NotBefore.Time.UTC().Format(time.RFC3339),
+		})
+	}
+	s.logger.Info("admin: cohort imported",
+		"cohort", cohort,
+		"rows", len(sessions),
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusCreated, resp)
+}
+
+// parseCohortCSV reads and validates an import. It returns a message for the
+// client when the import must be rejected, and an error only for a failed
+// lookup.
+func (s *Server) parseCohortCSV(r *http.Request, questions []db.QuestionDefinition) ([]store.CohortSession, string, error) {
+	byID := make(map[string]db.QuestionDefinition, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+
+	cr := csv.NewReader(r.Body)
+	cr.FieldsPerRecord = 0 // every row as long as the header
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, "the CSV is empty", nil
+	}
+	if err != nil {
+		return nil, "invalid CSV: " + err.Error(), nil
+	}
+	seen := make(map[string]bool, len(header))
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(col))
+		header[i] = col
+		if seen[col] {
+			return nil, fmt.Sprintf("column %q appears twice", col), nil
+		}
+		seen[col] = true
+		if _, ok := byID[col]; !ok && !cohortContextColumns[col] {
+			return nil, fmt.Sprintf("unknown column %q: expected email, biz_name, industry, stage, product_sku or a question_id", col), nil
+		}
+	}
+	if !seen["email"] {
+		return nil, "the CSV needs an email column", nil
+	}
+
+	knownSKU := map[string]bool{}
+	var sessions []store.CohortSession
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, "invalid CSV: " + err.Error(), nil
+		}
+		if s.cfg.CohortMaxRows > 0 && row > s.cfg.CohortMaxRows {
+			return nil, fmt.Sprintf("too many rows (max %d)", s.cfg.CohortMaxRows), nil
+		}
+
+		cs := store.CohortSession{Answers: map[string]string{}}
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			switch col := header[i]; col {
+			case "email":
+				cs.Email = cell
+			case "biz_name":
+				cs.BizName = cell
+			case "industry":
+				cs.Industry = cell
+			case "stage":
+				cs.Stage = cell
+			case "product_sku":
+				cs.ProductSKU = cell
+			default:
+				if cell == "" {
+					continue
+				}
+				if msg := s.checkAnswer(r, uuid.Nil, byID[col], cell); msg != "" {
+					return nil, fmt.Sprintf("row %d: %s", row, msg), nil
+				}
+				cs.Answers[col] = cell
+			}
+		}
+
+		if len(cs.Email) > 254 || !strings.Contains(cs.Email, "@") {
+			return nil, fmt.Sprintf("row %d: a valid email is required", row), nil
+		}
+		if len(cs.Answers) == 0 {
+			return nil, fmt.Sprintf("row %d: no answers", row), nil
+		}
+		if cs.ProductSKU != "" && !knownSKU[cs.ProductSKU] {
+			_, err := s.q.GetProductBySKU(r.Context(), cs.ProductSKU)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Sprintf("row %d: unknown product_sku %q", row, cs.ProductSKU), nil
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("get product %q: %w", cs.ProductSKU, err)
+			}
+			knownSKU[cs.ProductSKU] = true
+		}
+		sessions = append(sessions, cs)
+	}
+
+	if len(sessions) == 0 {
+		return nil, "the CSV has no rows after the header", nil
+	}
+	return sessions, "", nil
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── GET /api/reports/compare?a=:accessToken&b=:accessToken ───────────────────
+//
+// Compares two reports question by question, for a before/after view across a
+// consulting engagement or a re-assessment. a is the earlier report and b the
+// later one; every delta is b minus a.
+//
+// Holding both links is not enough: both reports must belong to the same
+// customer (the same email on their sessions), so a consultant cannot line up
+// two clients' reports. Returns 400 without two different tokens, 404 if
+// either is unknown, 410 if either is revoked, 409 if either is not ready,
+// and 403 for reports of different customers.
+
+type compareReportSummary struct {
+	ReportID      string `json:"report_id"`
+	GeneratedAt   string `json:"generated_at"`
+	OverallScore  int16  `json:"overall_score"`
+	CriticalCount int16  `json:"critical_count"`
+}
+
+type compareRiskScore struct {
+	Probability int16  `json:"probability"`
+	Impact      int16  `json:"impact"`
+	Score       int16  `json:"score"`
+	Tier        string `json:"tier"`
+}
+
+type compareQuestion struct {
+	QuestionID string `json:"question_id"`
+	RiskName   string `json:"risk_name"`
+	// A and B are nil for a question scored in only one of the reports; the
+	// deltas then treat the missing side as zero.
+	A                *compareRiskScore `json:"a"`
+	B                *compareRiskScore `json:"b"`
+	ProbabilityDelta int16             `json:"probability_delta"`
+	ImpactDelta      int16             `json:"impact_delta"`
+	ScoreDelta       int16             `json:"score_delta"`
+	TierChanged      bool              `json:"tier_changed"`
+}
+
+type compareReportsResponse struct {
+	A                  compareReportSummary `json:"a"`
+	B                  compareReportSummary `json:"b"`
+	OverallScoreDelta  int16                `json:"overall_score_delta"`
+	CriticalCountDelta int16                `json:"critical_count_delta"`
+	Questions          []compareQuestion    `json:"questions"`
+}
+
+func (s *Server) handleCompareReports(w http.ResponseWriter, r *http.Request) {
+	tokenA, tokenB := strings.TrimSpace(r.URL.Query().Get("a")), strings.TrimSpace(r.URL.Query().Get("b"))
+	if tokenA == "" || tokenB == "" {
+		respondErr(w, http.StatusBadRequest, "a and b access tokens are required")
+		return
+	}
+	if tokenA == tokenB {
+		respondErr(w, http.StatusBadRequest, "a and b must be different reports")
+		return
+	}
+
+	var rows [2]db.GetReportByAccessTokenRow
+	for i, token := range []string{tokenA, tokenB} {
+		row, err := s.q.GetReportByAccessToken(r.Context(), token)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.reportTokenMissFor(r, token)
+			respondErr(w, http.StatusNotFound, "report not found")
+			return
+		}
+		if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
+			return
+		}
+		if row.RevokedAt.Valid {
+			respondErr(w, http.StatusGone, errReportRevoked)
+			return
+		}
+		if row.HeldAt.Valid || row.Status != db.ReportStatusReady {
+			respondErr(w, http.StatusConflict, "both reports must be ready to compare")
+			return
+		}
+		rows[i] = row
+	}
+	a, b := rows[0], rows[1]
+
+	emailA, emailB := strings.TrimSpace(a.Email.String), strings.TrimSpace(b.Email.String)
+	if emailA == "" || !strings.EqualFold(emailA, emailB) {
+		s.logger.Info("report compare: refused reports of different customers",
+			"report_a", a.ID,
+			"report_b", b.ID,
+			logField(r),
+		)
+		respondErr(w, http.StatusForbidden, "reports belong to different customers")
+		return
+	}
+
+	resultsA, err := s.q.GetRiskResultsByReport(r.Context(), a.ID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get risk results: %w", err))
+		return
+	}
+	resultsB, err := s.q.GetRiskResultsByReport(r.Context(), b.ID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get risk results: %w", err))
+		return
+	}
+
+	respond(w, http.StatusOK, compareReportsResponse{
+		A:                  compareSummary(a),
+		B:                  compareSummary(b),
+		OverallScoreDelta:  b.OverallScore.Int16 - a.OverallScore.Int16,
+		CriticalCountDelta: b.CriticalCount.Int16 - a.CriticalCount.Int16,
+		Questions:          compareRisks(resultsA, resultsB),
+	})
+}
+
+func compareSummary(row db.GetReportByAccessTokenRow) compareReportSummary {
+	generatedAt := ""
+	if row.GeneratedAt.Valid {
+		generatedAt = row.GeneratedAt.Time.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return compareReportSummary{
+		ReportID:      row.ID.String(),
+		GeneratedAt:   generatedAt,
+		OverallScore:  row.OverallScore.Int16,
+		CriticalCount: row.CriticalCount.Int16,
+	}
+}
+
+// compareRisks pairs the two reports' risks by question, ordered by question
+// ID so the rows line up however the ranks moved.
+func compareRisks(a, b []db.RiskResult) []compareQuestion {
+	byID := map[string]*compareQuestion{}
+	question := func(rr db.RiskResult) *compareQuestion {
+		q, ok := byID[rr.QuestionID]
+		if !ok {
+			q = &compareQuestion{QuestionID: rr.QuestionID}
+			byID[rr.QuestionID] = q
+		}
+		q.RiskName = rr.RiskName // the later report's name wins
+		return q
+	}
+	for _, rr := range a {
+		question(rr).A = riskScore(rr)
+	}
+	for _, rr := range b {
+		question(rr).B = riskScore(rr)
+	}
+
+	out := make([]compareQuestion, 0, len(byID))
+	for _, q := range byID {
+		var before, after compareRiskScore
+		if q.A != nil {
+			before = *q.A
+		}
+		if q.B != nil {
+			after = *q.B
+		}
+		q.ProbabilityDelta = after.Probability - before.Probability
+		q.ImpactDelta = after.Impact - before.Impact
+		q.ScoreDelta = after.Score - before.Score
+		q.TierChanged = before.Tier != after.Tier
+		out = append(out, *q)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].QuestionID < out[j].QuestionID })
+	return out
+}
+
+func riskScore(rr db.RiskResult) *compareRiskScore {
+	return &compareRiskScore{
+		Probability: rr.Probability,
+		Impact:      rr.Impact,
+		Score:       rr.Score,
+		Tier:        string(rr.Tier),
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── POST /api/report/:accessToken/consultation ───────────────────────────────
+//
+// Records that the customer wants a paid consultation and returns the
+// scheduling link to send them to. Calling it again for the same report is
+// harmless — the request is stored once and the note updated. The frontend
+// should call this rather than linking to the scheduling page directly so the
+// conversion is counted in GET /api/admin/stats.
+//
+// Returns 404 when CONSULTATION_URL is not configured and 410 for a revoked
+// report.
+
+// maxConsultationNoteLen caps the optional free-text note.
+const maxConsultationNoteLen = 2000
+
+type consultationRequest struct {
+	Note string `json:"note"`
+}
+
+type consultationResponse struct {
+	BookingURL string `json:"booking_url"`
+}
+
+func (s *Server) handleRequestConsultation(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.ConsultationURL == "" {
+		respondErr(w, http.StatusNotFound, "consultations are not offered")
+		return
+	}
+
+	var req consultationRequest
+	if r.ContentLength != 0 && !decode(w, r, &req) {
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if len(req.Note) > maxConsultationNoteLen {
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("note must be at most %d characters", maxConsultationNoteLen))
+		return
+	}
+
+	report, err := s.q.GetReportByAccessToken(r.Context(), chi.URLParam(r, "accessToken"))
+	if errors.Is(err, sql.ErrNoRows) {
+		s.reportTokenMiss(r)
+		respondErr(w, http.StatusNotFound, "report not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
+		return
+	}
+	if report.RevokedAt.Valid {
+		respondErr(w, http.StatusGone, errReportRevoked)
+		return
+	}
+	if report.Status != db.ReportStatusReady {
+		respondErr(w, http.StatusConflict, "report is not ready yet")
+		return
+	}
+
+	if _, err := s.q.UpsertConsultationRequest(r.Context(), db.UpsertConsultationRequestParams{
+		ReportID:  report.ID,
+		SessionID: report.SessionID,
+		Note:      sql.NullString{String: req.Note, Valid: req.Note != ""},
+	}); err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("upsert consultation request: %w", err))
+		return
+	}
+
+	s.logger.Info("consultation: requested", "report_id", report.ID, logField(r))
+	respond(w, http.StatusOK, consultationResponse{BookingURL: s.cfg.ConsultationURL})
+}
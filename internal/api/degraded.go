@@ -0,0 +1,185 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
+	"github.com/redis/go-redis/v9"
+)
+
+// ─── DEGRADED MODE ────────────────────────────────────────────────────────────
+//
+// While Config.DBHealth reports the database down, requireDatabase answers
+// every /api request without touching it: report links that were served
+// recently come from reportCache, everything else gets 503 with Retry-After.
+// Without this each request would hold a connection attempt until its own
+// timeout, piling up behind the outage.
+
+// requireDatabase short-circuits /api requests while the database is down.
+// The API description needs no database and is always served.
+func (s *Server) requireDatabase(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.DBHealth.Up() || r.URL.Path == "/api/openapi.json" || r.URL.Path == "/api/docs" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			if token, ok := strings.CutPrefix(r.URL.Path, "/api/report/"); ok && !strings.Contains(token, "/") {
+				if resp, ok := s.reports.get(r.Context(), token); ok {
+					w.Header().Set("X-Served-From", "cache")
+					respond(w, http.StatusOK, resp)
+					return
+				}
+			}
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(s.cfg.DBHealth.RetryAfter().Seconds()))))
+		respondErr(w, http.StatusServiceUnavailable, "temporarily unavailable, please retry shortly")
+	})
+}
+
+// reportCache keeps the most recently served ready reports by access token,
+// so their links keep working through a database outage. It is only read
+// while the database is down; entries older than ttl are not served. A nil
+// *reportCache caches nothing.
+//
+// With Config.Redis every report is also stored there for ttl, so a replica
+// can serve a report another one cached, and a revocation on one replica
+// reaches them all. Redis is then the source of truth; the in-memory copy is
+// only read while Redis cannot be reached.
+type reportCache struct {
+	size   int
+	ttl    time.Duration
+	now    func() time.Time
+	redis  *redis.Client
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	order   *list.List // of *cachedReport, most recently used first
+	byToken map[string]*list.Element
+}
+
+type cachedReport struct {
+	token    string
+	resp     reportResponse
+	cachedAt time.Time
+}
+
+// newReportCache returns a cache of up to size reports in memory, shared
+// through client when it is not nil, or nil when size is not positive.
+func newReportCache(size int, ttl time.Duration, client *redis.Client, logger *slog.Logger) *reportCache {
+	if size <= 0 {
+		return nil
+	}
+	return &reportCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		redis:   client,
+		logger:  logger,
+		order:   list.New(),
+		byToken: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *reportCache) put(ctx context.Context, token string, resp reportResponse) {
+	if c == nil {
+		return
+	}
+	if c.redis != nil {
+		if b, err := json.Marshal(resp); err != nil {
+			c.redisFailed("put", err)
+		} else if err := c.redis.Set(ctx, redisReportKey(token), b, c.ttl).Err(); err != nil {
+			c.redisFailed("put", err)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.byToken[token]; ok {
+		el.Value = &cachedReport{token: token, resp: resp, cachedAt: c.now()}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.byToken[token] = c.order.PushFront(&cachedReport{token: token, resp: resp, cachedAt: c.now()})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.byToken, oldest.Value.(*cachedReport).token)
+	}
+}
+
+func (c *reportCache) get(ctx context.Context, token string) (reportResponse, bool) {
+	if c == nil {
+		return reportResponse{}, false
+	}
+	if c.redis != nil {
+		b, err := c.redis.Get(ctx, redisReportKey(token)).Bytes()
+		switch {
+		case errors.Is(err, redis.Nil):
+			return reportResponse{}, false
+		case err == nil:
+			var resp reportResponse
+			if err := json.Unmarshal(b, &resp); err != nil {
+				c.redisFailed("get", err)
+				return reportResponse{}, false
+			}
+			return resp, true
+		}
+		c.redisFailed("get", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.byToken[token]
+	if !ok {
+		return reportResponse{}, false
+	}
+	entry := el.Value.(*cachedReport)
+	if c.ttl > 0 && c.now().Sub(entry.cachedAt) > c.ttl {
+		return reportResponse{}, false
+	}
+	return entry.resp, true
+}
+
+// forget drops a report, e.g. once it has been revoked.
+func (c *reportCache) forget(ctx context.Context, token string) {
+	if c == nil {
+		return
+	}
+	if c.redis != nil {
+		if err := c.redis.Del(ctx, redisReportKey(token)).Err(); err != nil {
+			c.redisFailed("forget", err)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.byToken[token]; ok {
+		c.order.Remove(el)
+		delete(c.byToken, token)
+	}
+}
+
+func (c *reportCache) redisFailed(op string, err error) {
+	c.logger.Warn("report cache: redis unavailable, using memory", "op", op, "error", err)
+}
+
+// redisReportKey keys a report by a hash of its access token, so the tokens
+// themselves are not readable from Redis.
+func redisReportKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return cluster.KeyPrefix + "report:" + hex.EncodeToString(sum[:])
+}
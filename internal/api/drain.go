@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
+)
+
+// ─── DRAINING ─────────────────────────────────────────────────────────────────
+//
+// A rolling deploy drains a replica before stopping it: POST /internal/drain
+// (or SIGUSR1) stops the worker taking reports, /readyz starts failing so the
+// load balancer sends no new traffic, and responses ask keep-alive clients to
+// reconnect elsewhere. The deploy then polls GET /internal/drain until no
+// jobs are running and few connections are left, and sends SIGTERM. Reports
+// queued on the replica are handed back to the database for the others'
+// pollers. Draining cannot be undone; the process is meant to exit next.
+
+// ConnTracker counts the HTTP server's open connections. Set its ConnState
+// as the http.Server's ConnState hook. A nil *ConnTracker counts nothing.
+type ConnTracker struct {
+	open atomic.Int64
+}
+
+// ConnState implements the http.Server ConnState hook.
+func (t *ConnTracker) ConnState(_ net.Conn, state http.ConnState) {
+	if t == nil {
+		return
+	}
+	switch state {
+	case http.StateNew:
+		t.open.Add(1)
+	case http.StateClosed, http.StateHijacked:
+		t.open.Add(-1)
+	}
+}
+
+// Open returns the number of connections not yet closed.
+func (t *ConnTracker) Open() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.open.Load()
+}
+
+// draining reports whether the worker has been told to drain. An Enqueuer
+// that cannot drain never is.
+func (s *Server) draining() bool {
+	d, ok := s.worker.(worker.Drainer)
+	return ok && d.Draining()
+}
+
+// closeWhenDraining asks clients to close their connection after each
+// response once draining, so keep-alive connections move to other replicas.
+func (s *Server) closeWhenDraining(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.draining() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type drainResponse struct {
+	Draining    bool  `json:"draining"`
+	JobsRunning int   `json:"jobs_running"`
+	JobsQueued  int   `json:"jobs_queued"` // handed back when the worker stops
+	Connections int64 `json:"connections"` // this request's included; 0 when untracked
+}
+
+func (s *Server) drainStatus() drainResponse {
+	out := drainResponse{Draining: s.draining(), Connections: s.cfg.Conns.Open()}
+	if backlog, ok := s.worker.(worker.Backlog); ok {
+		stats := backlog.QueueStats()
+		out.JobsRunning, out.JobsQueued = stats.Running, stats.Queued
+	}
+	return out
+}
+
+// ─── GET /internal/drain ──────────────────────────────────────────────────────
+//
+// Reports whether this replica is draining and what it is still doing.
+
+func (s *Server) handleDrainStatus(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, s.drainStatus())
+}
+
+// ─── POST /internal/drain ─────────────────────────────────────────────────────
+//
+// Starts draining this replica; repeating it is harmless. 501 when the
+// worker cannot drain.
+
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.worker.(worker.Drainer)
+	if !ok {
+		respondErr(w, http.StatusNotImplemented, "worker does not support draining")
+		return
+	}
+	already := d.Draining()
+	d.Drain()
+	status := s.drainStatus()
+	if !already {
+		s.logger.Info("drain: requested",
+			"jobs_running", status.JobsRunning,
+			"jobs_queued", status.JobsQueued,
+			"connections", status.Connections,
+			"audit", true,
+			logField(r),
+		)
+	}
+	respond(w, http.StatusAccepted, status)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+)
+
+// ─── DUPLICATE PURCHASES ──────────────────────────────────────────────────────
+//
+// A second card payment from an email within DUPLICATE_PURCHASE_WINDOW of an
+// earlier one, with (nearly) the same answers, has its report held by
+// store.InitialiseReport instead of generated. An operator then refunds it,
+// keeps it as credit for the customer's next re-assessment, or releases it
+// to be generated after all. With DuplicateAutoRefund the webhook refunds it
+// straight away.
+
+// duplicateActions maps the action names the API accepts to resolutions.
+var duplicateActions = map[string]string{
+	"refund":  store.DuplicateRefunded,
+	"credit":  store.DuplicateCredit,
+	"release": store.DuplicateReleased,
+}
+
+// errDuplicateNotFound is returned by resolveDuplicate for a session that was
+// never held as a duplicate.
+var errDuplicateNotFound = errors.New("duplicate purchase not found")
+
+// resolveDuplicate refunds the payment through Stripe when resolution is
+// store.DuplicateRefunded, then records the resolution. A release is
+// enqueued for generation. A failure after the refund is safe to retry:
+// Stripe returns the refund already made.
+func (s *Server) resolveDuplicate(ctx context.Context, sessionID uuid.UUID, resolution string) (db.DuplicatePurchase, db.Report, error) {
+	dup, err := s.q.GetDuplicatePurchase(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.DuplicatePurchase{}, db.Report{}, errDuplicateNotFound
+	}
+	if err != nil {
+		return db.DuplicatePurchase{}, db.Report{}, fmt.Errorf("get duplicate purchase: %w", err)
+	}
+	if dup.Resolution.Valid {
+		return db.DuplicatePurchase{}, db.Report{}, store.ErrDuplicateResolved
+	}
+
+	var refundID string
+	if resolution == store.DuplicateRefunded {
+		if !dup.StripePaymentIntent.Valid {
+			return db.DuplicatePurchase{}, db.Report{}, fmt.Errorf("session %s has no payment intent to refund", sessionID)
+		}
+		if refundID, err = s.stripe.RefundPaymentIntent(ctx, dup.StripePaymentIntent.String); err != nil {
+			return db.DuplicatePurchase{}, db.Report{}, err
+		}
+	}
+
+	resolved, report, err := s.store.ResolveDuplicate(ctx, store.ResolveDuplicateParams{
+		SessionID:      sessionID,
+		Resolution:     resolution,
+		StripeRefundID: refundID,
+	})
+	if err != nil {
+		return db.DuplicatePurchase{}, db.Report{}, err
+	}
+	if resolution == store.DuplicateReleased {
+		if err := s.worker.Enqueue(ctx, report.ID); err != nil {
+			s.logger.Warn("duplicates: enqueue failed, will be picked up by poller",
+				"report_id", report.ID,
+				"error", err,
+			)
+		}
+	}
+	return resolved, report, nil
+}
+
+// ─── GET /api/admin/duplicates ────────────────────────────────────────────────
+//
+// Lists the held duplicate purchases awaiting a decision, oldest first.
+
+type adminDuplicatesResponse struct {
+	Duplicates []db.ListUnresolvedDuplicatePurchasesRow `json:"duplicates"`
+}
+
+func (s *Server) handleAdminListDuplicates(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.q.ListUnresolvedDuplicatePurchases(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list duplicate purchases: %w", err))
+		return
+	}
+	respond(w, http.StatusOK, adminDuplicatesResponse{Duplicates: rows})
+}
+
+// ─── POST /api/admin/duplicates/:sessionID/resolve ────────────────────────────
+//
+// Decides a held duplicate purchase: "refund" refunds the payment in full and
+// revokes the held report, "credit" keeps the payment so the email's next
+// checkout of the same product is free and revokes the held report, and
+// "release" generates the report after all. 409 once it has been decided.
+
+type resolveDuplicateRequest struct {
+	Action string `json:"action"`
+}
+
+type resolveDuplicateResponse struct {
+	Duplicate db.DuplicatePurchase `json:"duplicate"`
+	ReportID  string               `json:"report_id"`
+}
+
+func (s *Server) handleAdminResolveDuplicate(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUID(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid session_id")
+		return
+	}
+	var req resolveDuplicateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	resolution, ok := duplicateActions[req.Action]
+	if !ok {
+		respondErr(w, http.StatusBadRequest, "action must be one of refund, credit, release")
+		return
+	}
+
+	dup, report, err := s.resolveDuplicate(r.Context(), sessionID, resolution)
+	switch {
+	case errors.Is(err, errDuplicateNotFound):
+		respondErr(w, http.StatusNotFound, "duplicate purchase not found")
+		return
+	case errors.Is(err, store.ErrDuplicateResolved):
+		respondErr(w, http.StatusConflict, "duplicate purchase already resolved")
+		return
+	case err != nil:
+		s.respondInternalErr(w, r, fmt.Errorf("resolve duplicate purchase: %w", err))
+		return
+	}
+
+	s.logger.Info("admin: duplicate purchase resolved",
+		"session_id", sessionID,
+		"report_id", report.ID,
+		"resolution", resolution,
+		"stripe_refund_id", dup.StripeRefundID.String,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusOK, resolveDuplicateResponse{Duplicate: dup, ReportID: report.ID.String()})
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+)
+
+// ─── AI TEXT CORRECTIONS ──────────────────────────────────────────────────────
+//
+// An admin can replace an obviously wrong executive summary or AI hedge on a
+// generated report. The value replaced, the editor and the reason are kept
+// in ai_edits, and the report API marks the field as human-edited. The admin
+// key is shared, so the editor names themselves in the request. Regenerating
+// the report replaces the corrections.
+
+// maxEditRunes bounds a corrected text; generated ones are far shorter.
+const maxEditRunes = 4000
+
+type aiEditResponse struct {
+	ID         string `json:"id"`
+	Field      string `json:"field"`
+	QuestionID string `json:"question_id,omitempty"`
+	Original   string `json:"original"`
+	Edited     string `json:"edited"`
+	Editor     string `json:"editor"`
+	Reason     string `json:"reason"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func newAIEditResponse(e db.AiEdit) aiEditResponse {
+	return aiEditResponse{
+		ID:         e.ID.String(),
+		Field:      e.Field,
+		QuestionID: e.QuestionID.String,
+		Original:   e.Original.String,
+		Edited:     e.Edited,
+		Editor:     e.Editor,
+		Reason:     e.Reason,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// ─── GET /api/admin/reports/:reportID/edits ───────────────────────────────────
+//
+// Lists a report's corrections, oldest first.
+
+type adminEditsResponse struct {
+	ReportID string           `json:"report_id"`
+	Edits    []aiEditResponse `json:"edits"`
+}
+
+func (s *Server) handleAdminListEdits(w http.ResponseWriter, r *http.Request) {
+	reportID, err := parseUUID(chi.URLParam(r, "reportID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid report_id")
+		return
+	}
+	rows, err := s.q.ListAIEditsByReport(r.Context(), reportID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list AI edits: %w", err))
+		return
+	}
+	out := adminEditsResponse{ReportID: reportID.String(), Edits: make([]aiEditResponse, len(rows))}
+	for i, row := range rows {
+		out.Edits[i] = newAIEditResponse(row)
+	}
+	respond(w, http.StatusOK, out)
+}
+
+// ─── POST /api/admin/reports/:reportID/edits ──────────────────────────────────
+//
+// Replaces the executive summary, or the AI hedge of the risk for
+// question_id, with text. 404 for an unknown report or a question the report
+// has no risk for; 409 for a report that is not ready or has been revoked.
+
+type postEditRequest struct {
+	Field      string `json:"field"` // "executive_summary" or "ai_hedge"
+	QuestionID string `json:"question_id"`
+	Text       string `json:"text"`
+	Editor     string `json:"editor"`
+	Reason     string `json:"reason"`
+}
+
+func (s *Server) handleAdminPostEdit(w http.ResponseWriter, r *http.Request) {
+	reportID, err := parseUUID(chi.URLParam(r, "reportID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid report_id")
+		return
+	}
+	var req postEditRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	req.Editor = strings.TrimSpace(req.Editor)
+	req.Reason = strings.TrimSpace(req.Reason)
+	switch {
+	case req.Field != store.FieldExecutiveSummary && req.Field != store.FieldAIHedge:
+		respondErr(w, http.StatusBadRequest, "field must be one of executive_summary, ai_hedge")
+		return
+	case (req.Field == store.FieldAIHedge) != (req.QuestionID != ""):
+		respondErr(w, http.StatusBadRequest, "question_id is required for ai_hedge and only allowed there")
+		return
+	case req.Text == "":
+		respondErr(w, http.StatusBadRequest, "text is required")
+		return
+	case utf8.RuneCountInString(req.Text) > maxEditRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("text must be at most %d characters", maxEditRunes))
+		return
+	case req.Editor == "":
+		respondErr(w, http.StatusBadRequest, "editor is required")
+		return
+	case req.Reason == "":
+		respondErr(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	edit, err := s.store.EditAIText(r.Context(), store.EditAITextParams{
+		ReportID:   reportID,
+		Field:      req.Field,
+		QuestionID: req.QuestionID,
+		Text:       req.Text,
+		Editor:     req.Editor,
+		Reason:     req.Reason,
+	})
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		respondErr(w, http.StatusNotFound, "report or risk not found")
+		return
+	case errors.Is(err, store.ErrReportNotReady):
+		respondErr(w, http.StatusConflict, "report is not ready or has been revoked")
+		return
+	case err != nil:
+		s.respondInternalErr(w, r, fmt.Errorf("edit AI text: %w", err))
+		return
+	}
+
+	// The cached copy still has the old text.
+	if report, err := s.q.GetReportByID(r.Context(), reportID); err == nil {
+		s.reports.forget(r.Context(), report.AccessToken)
+	} else {
+		s.logger.Warn("admin: could not evict edited report from cache", "report_id", reportID, "error", err, logField(r))
+	}
+
+	s.logger.Info("admin: AI text edited",
+		"report_id", reportID,
+		"field", edit.Field,
+		"question_id", edit.QuestionID.String,
+		"editor", edit.Editor,
+		"reason", edit.Reason,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusCreated, newAIEditResponse(edit))
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── GET /api/admin/emails ────────────────────────────────────────────────────
+//
+// Lists the email log newest first, so a customer's "I never got it" can be
+// checked without SQL. Filtered by template (e.g. report_ready; empty for
+// any) and paged by limit and cursor (see pagination.go). Sends that failed
+// carry their error; claims still in flight have no sent_at.
+
+type emailLogSummary struct {
+	ID          uuid.UUID  `json:"id"`
+	SessionID   *uuid.UUID `json:"session_id,omitempty"`
+	ReportID    *uuid.UUID `json:"report_id,omitempty"`
+	To          string     `json:"to"`
+	Subject     string     `json:"subject"`
+	Template    string     `json:"template"`
+	ProviderID  string     `json:"provider_id,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	ClickedAt   *time.Time `json:"clicked_at,omitempty"`
+	BouncedAt   *time.Time `json:"bounced_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type listEmailLogResponse struct {
+	Emails     []emailLogSummary `json:"emails"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) handleAdminListEmails(w http.ResponseWriter, r *http.Request) {
+	page, msg := parsePageRequest(r)
+	if msg != "" {
+		respondErr(w, http.StatusBadRequest, msg)
+		return
+	}
+	cursorID, ok := cursorUUID(page)
+	if !ok {
+		respondErr(w, http.StatusBadRequest, errBadCursor.Error())
+		return
+	}
+
+	rows, err := s.q.ListEmailLogPage(r.Context(), db.ListEmailLogPageParams{
+		Template:  r.URL.Query().Get("template"),
+		HasCursor: page.HasCursor,
+		CursorAt:  page.Cursor.At,
+		CursorID:  cursorID,
+		MaxRows:   page.fetchRows(),
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list email log: %w", err))
+		return
+	}
+
+	rows, next := trimPage(page, rows, func(e db.EmailLog) pageCursor {
+		return pageCursor{At: e.CreatedAt, ID: e.ID.String()}
+	})
+	resp := listEmailLogResponse{Emails: make([]emailLogSummary, len(rows)), NextCursor: next}
+	for i, e := range rows {
+		resp.Emails[i] = summariseEmailLog(e)
+	}
+	setNextLink(w, r, next)
+	respond(w, http.StatusOK, resp)
+}
+
+func summariseEmailLog(e db.EmailLog) emailLogSummary {
+	out := emailLogSummary{
+		ID:         e.ID,
+		To:         e.ToAddress,
+		Subject:    e.Subject,
+		Template:   e.Template,
+		ProviderID: e.ProviderID.String,
+		Error:      e.Error.String,
+		CreatedAt:  e.CreatedAt,
+	}
+	if e.SessionID.Valid {
+		out.SessionID = &e.SessionID.UUID
+	}
+	if e.ReportID.Valid {
+		out.ReportID = &e.ReportID.UUID
+	}
+	if e.DuplicateOf.Valid {
+		out.DuplicateOf = &e.DuplicateOf.UUID
+	}
+	if e.SentAt.Valid {
+		out.SentAt = &e.SentAt.Time
+	}
+	if e.OpenedAt.Valid {
+		out.OpenedAt = &e.OpenedAt.Time
+	}
+	if e.ClickedAt.Valid {
+		out.ClickedAt = &e.ClickedAt.Time
+	}
+	if e.BouncedAt.Valid {
+		out.BouncedAt = &e.BouncedAt.Time
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// ─── POST /api/webhooks/resend ────────────────────────────────────────────────
+
+// handleResendWebhook records opens, clicks and bounces of the emails in
+// email_log, matched on the Resend message ID. Only mounted when
+// RESEND_WEBHOOK_SECRET is set; the signature is checked with it. A bounce
+// also suppresses the address (email_suppressions).
+//
+// Every update keeps the first timestamp, so redeliveries are harmless.
+// Events for messages not in email_log (sent before logging existed, or by
+// another application on the same Resend account) are acknowledged and
+// ignored. A database error returns 500 so Resend retries.
+func (s *Server) handleResendWebhook(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 65536)
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "could not read request body")
+		return
+	}
+
+	event, err := email.VerifyWebhook(s.cfg.ResendWebhookSecret, r.Header, payload, time.Now())
+	if err != nil {
+		s.logger.Warn("email webhook: rejected", "error", err, logField(r))
+		respondErr(w, http.StatusBadRequest, "invalid webhook signature")
+		return
+	}
+
+	providerID := sql.NullString{String: event.Data.EmailID, Valid: event.Data.EmailID != ""}
+	switch event.Type {
+	case email.EventOpened:
+		_, err = s.q.MarkEmailOpened(r.Context(), providerID)
+	case email.EventClicked:
+		_, err = s.q.MarkEmailClicked(r.Context(), providerID)
+	case email.EventBounced:
+		reason := "bounced"
+		if b := event.Data.Bounce; b != nil && b.Message != "" {
+			reason = "bounced: " + b.Message
+		}
+		var bounced db.EmailLog
+		bounced, err = s.q.MarkEmailBounced(r.Context(), db.MarkEmailBouncedParams{
+			Reason:     sql.NullString{String: reason, Valid: true},
+			ProviderID: providerID,
+		})
+		if err == nil && bounced.SessionID.Valid {
+			// No optional email is sent to an address that bounced.
+			err = s.q.SuppressSessionEmail(r.Context(), db.SuppressSessionEmailParams{
+				Reason:    "bounced",
+				SessionID: bounced.SessionID.UUID,
+			})
+		}
+	default:
+		s.logger.Debug("email webhook: unhandled event type", "type", event.Type, logField(r))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		s.logger.Debug("email webhook: message not in email_log", "type", event.Type, "email_id", event.Data.EmailID, logField(r))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("record %s: %w", event.Type, err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// recordEmail writes a send to email_log. A failure is logged and ignored:
+// the email itself has already gone, or failed and been logged.
+func (s *Server) recordEmail(r *http.Request, e email.LogEntry, sent email.Sent, sendErr error) {
+	if err := email.Record(r.Context(), s.q, e, sent, sendErr); err != nil {
+		s.logger.Warn("email log write failed", "template", e.Template, "error", err, logField(r))
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/embed"
+)
+
+// ─── POST /api/embed/session ──────────────────────────────────────────────────
+//
+// Issues an embed token to a partner's page, which passes it to the iframed
+// assessment; the widget sends it as embed_token to POST /api/session and the
+// session is attributed to the partner. The page calls this from the
+// browser, so the Origin header names the embedding site and must be on the
+// partner's EMBED_ORIGINS allow-list. Tokens live for EMBED_TOKEN_TTL.
+
+type createEmbedTokenRequest struct {
+	Partner string `json:"partner"`
+}
+
+type createEmbedTokenResponse struct {
+	EmbedToken string    `json:"embed_token"`
+	Partner    string    `json:"partner"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (s *Server) handleCreateEmbedToken(w http.ResponseWriter, r *http.Request) {
+	var req createEmbedTokenRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Partner == "" {
+		respondErr(w, http.StatusBadRequest, "partner is required")
+		return
+	}
+
+	origin := r.Header.Get("Origin")
+	token, claims, err := s.cfg.Embed.Issue(req.Partner, origin, time.Now())
+	if errors.Is(err, embed.ErrOriginNotAllowed) {
+		s.logger.Warn("embed: origin not allowed", "partner", req.Partner, "origin", origin, logField(r))
+		respondErr(w, http.StatusForbidden, "origin not allowed for this partner")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("issue embed token: %w", err))
+		return
+	}
+	respond(w, http.StatusCreated, createEmbedTokenResponse{
+		EmbedToken: token,
+		Partner:    claims.Partner,
+		ExpiresAt:  claims.ExpiresAt,
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+)
+
+// ─── A/B EXPERIMENTS ──────────────────────────────────────────────────────────
+//
+// New sessions are assigned to a variant of every running experiment (see
+// package experiments). The price experiment changes what checkout charges
+// and what GET /api/products?session_id= lists; the prompt experiment is
+// applied by the worker. Per-variant results are part of GET /api/admin/stats.
+
+type experimentStatsResponse struct {
+	Experiment           string  `json:"experiment"`
+	Variant              string  `json:"variant"`
+	Running              bool    `json:"running"`
+	Sessions             int64   `json:"sessions"`
+	Paid                 int64   `json:"paid"`
+	ConversionRate       float64 `json:"conversion_rate"` // paid / sessions
+	Reports              int64   `json:"reports"`
+	QualityRejectionRate float64 `json:"quality_rejection_rate"` // AI narratives rejected / reports
+	MeanOverallScore     float64 `json:"mean_overall_score"`
+	Consultations        int64   `json:"consultations"`
+	ConsultationRate     float64 `json:"consultation_rate"` // consultations / reports
+}
+
+// assignExperiments puts a new session in every running experiment and
+// returns its variants. A failed write is logged and leaves the session out
+// of that experiment, with the control.
+func (s *Server) assignExperiments(r *http.Request, sessionID uuid.UUID) map[string]string {
+	var variants map[string]string
+	for _, name := range s.cfg.Experiments.Names() {
+		variant := experiments.Assign(name, sessionID)
+		err := s.q.AssignExperiment(r.Context(), db.AssignExperimentParams{
+			SessionID:  sessionID,
+			Experiment: name,
+			Variant:    variant,
+		})
+		if err != nil {
+			s.logger.Warn("create session: could not assign experiment",
+				"session_id", sessionID,
+				"experiment", name,
+				"error", err,
+				logField(r),
+			)
+			continue
+		}
+		if variants == nil {
+			variants = map[string]string{}
+		}
+		variants[name] = variant
+	}
+	return variants
+}
+
+// sessionVariant returns the session's variant of a running experiment. If
+// the assignment cannot be read the session gets the control.
+func (s *Server) sessionVariant(r *http.Request, sessionID uuid.UUID, name string) string {
+	if !s.cfg.Experiments.Running(name) {
+		return experiments.Control
+	}
+	assignments, err := s.q.GetExperimentAssignments(r.Context(), sessionID)
+	if err != nil {
+		s.logger.Warn("experiments: could not load assignments, using the control",
+			"session_id", sessionID,
+			"experiment", name,
+			"error", err,
+			logField(r),
+		)
+		return experiments.Control
+	}
+	return s.cfg.Experiments.Variant(name, assignments)
+}
+
+// experimentStats returns the per-variant results of every experiment that
+// has assigned a session, running or not.
+func (s *Server) experimentStats(r *http.Request) ([]experimentStatsResponse, error) {
+	rows, err := s.q.GetExperimentStats(r.Context())
+	if err != nil {
+		return nil, fmt.Errorf("get experiment stats: %w", err)
+	}
+	out := make([]experimentStatsResponse, len(rows))
+	for i, row := range rows {
+		out[i] = experimentStatsResponse{
+			Experiment:           row.Experiment,
+			Variant:              row.Variant,
+			Running:              s.cfg.Experiments.Running(row.Experiment),
+			Sessions:             row.Sessions,
+			Paid:                 row.Paid,
+			ConversionRate:       ratio(row.Paid, row.Sessions),
+			Reports:              row.Reports,
+			QualityRejectionRate: ratio(row.QualityRejected, row.Reports),
+			MeanOverallScore:     row.MeanOverallScore,
+			Consultations:        row.Consultations,
+			ConsultationRate:     ratio(row.Consultations, row.Reports),
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/invoice"
+	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+)
+
+// ─── GET /api/admin/exports/payments?from=YYYY-MM-DD&to=YYYY-MM-DD ────────────
+//
+// Streams a CSV of every payment, refund and dispute Stripe told us about
+// between from and to (both inclusive, UTC), one row per money movement, for
+// monthly reconciliation. Rows are built from the stored webhook events rather
+// than the Stripe API, so the export matches what this service acted on.
+//
+// Amounts are signed decimals in major units: refunds and dispute withdrawals
+// are negative. Refund rows carry the amount refunded by that event, not the
+// charge's running total. Payment fees come from the payments table (filled
+// from charge.succeeded); dispute fees from the dispute's balance
+// transactions. Fee and net are blank where neither is available, and for
+// payments settled in a different currency than they were charged in.
+// Disputes appear only if the webhook endpoint is subscribed to
+// charge.dispute.created and charge.dispute.closed.
+//
+// ?link=true uploads the CSV to object storage and returns a signed download
+// URL instead (see respondArtifactLink), for exports too large to wait on.
+
+const (
+	exportDateLayout = "2006-01-02"
+	// maxExportDays bounds a single export so a typo cannot scan every event.
+	maxExportDays = 366
+)
+
+// exportEventTypes are the stored event types that move money.
+var exportEventTypes = []string{
+	"payment_intent.succeeded",
+	"charge.refunded",
+	"charge.dispute.created",
+	"charge.dispute.closed",
+}
+
+var exportHeader = []string{
+	"date", "type", "stripe_event_id", "payment_intent", "reference",
+	"email", "sku", "currency", "amount", "tax", "fee", "net", "status",
+}
+
+// exportRow is one line of the payments export. Fee and tax are nil when
+// unknown so they can be left blank rather than reported as zero.
+type exportRow struct {
+	at            time.Time
+	kind          string
+	eventID       string
+	paymentIntent string
+	reference     string
+	currency      string
+	amount        int64
+	tax           *int64
+	fee           *int64
+	status        string
+}
+
+func (s *Server) handleAdminExportPayments(w http.ResponseWriter, r *http.Request) {
+	from, to, msg := parseExportRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if msg != "" {
+		respondErr(w, http.StatusBadRequest, msg)
+		return
+	}
+	end := to.AddDate(0, 0, 1)
+	link := r.URL.Query().Get("link") == "true"
+	if link && s.cfg.Storage == nil {
+		respondErr(w, http.StatusBadRequest, "link=true needs object storage, which is not configured")
+		return
+	}
+
+	events, err := s.q.ListStripeEventsForExport(r.Context(), db.ListStripeEventsForExportParams{
+		Types:        exportEventTypes,
+		ReceivedFrom: from,
+		ReceivedTo:   end,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list stripe events: %w", err))
+		return
+	}
+
+	// charge.refunded reports the cumulative amount refunded, so the refunds
+	// seen before the period are needed to work out what each event added.
+	earlier, err := s.q.ListStripeEventsForExport(r.Context(), db.ListStripeEventsForExportParams{
+		Types:        []string{"charge.refunded"},
+		ReceivedFrom: time.Unix(0, 0).UTC(),
+		ReceivedTo:   from,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list earlier refunds: %w", err))
+		return
+	}
+	refunded := make(map[string]int64)
+	for _, e := range earlier {
+		if refund, err := parseRefundEvent(e); err == nil {
+			refunded[refund.ChargeID] = max(refunded[refund.ChargeID], refund.AmountRefunded)
+		}
+	}
+
+	var rows []exportRow
+	var pis []string
+	for _, e := range events {
+		row, ok, err := exportRowFor(e, refunded)
+		if err != nil {
+			// A malformed payload should not sink the whole export; it is
+			// still in stripe_events for manual follow-up.
+			s.logger.Warn("export: skipping unreadable event",
+				"event_id", e.StripeEventID, "type", e.Type, "error", err, logField(r))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		rows = append(rows, row)
+		if row.paymentIntent != "" {
+			pis = append(pis, row.paymentIntent)
+		}
+	}
+
+	sessions, err := s.q.ListSessionsByStripePIs(r.Context(), pis)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list sessions: %w", err))
+		return
+	}
+	byPI := make(map[string]db.Session, len(sessions))
+	for _, sess := range sessions {
+		byPI[sess.StripePaymentIntent.String] = sess
+	}
+	payments, err := s.q.ListPaymentsByStripePIs(r.Context(), pis)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list payments: %w", err))
+		return
+	}
+	feesByCharge := make(map[string]db.Payment, len(payments))
+	for _, p := range payments {
+		feesByCharge[p.StripeChargeID] = p
+	}
+
+	filename := fmt.Sprintf("payments_%s_%s.csv", from.Format(exportDateLayout), to.Format(exportDateLayout))
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	if !link {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		out = w
+	}
+
+	cw := csv.NewWriter(out)
+	_ = cw.Write(exportHeader)
+	for _, row := range rows {
+		sess := byPI[row.paymentIntent]
+		if row.kind == "payment" && sess.TaxCents.Valid {
+			tax := int64(sess.TaxCents.Int32)
+			row.tax = &tax
+		}
+		if p, ok := feesByCharge[row.reference]; ok && row.kind == "payment" && p.Currency == row.currency {
+			fee := p.FeeCents
+			row.fee = &fee
+		}
+		_ = cw.Write([]string{
+			row.at.UTC().Format(time.RFC3339),
+			row.kind,
+			row.eventID,
+			row.paymentIntent,
+			row.reference,
+			sess.Email.String,
+			sess.ProductSku.String,
+			row.currency,
+			invoice.Decimal(row.amount, row.currency),
+			optionalAmount(row.tax, row.currency),
+			optionalAmount(row.fee, row.currency),
+			exportNet(row),
+			row.status,
+		})
+	}
+	cw.Flush()
+	if link {
+		s.respondArtifactLink(w, r, "exports", filename, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+	if err := cw.Error(); err != nil {
+		// Headers are already sent; all that is left is to record it.
+		s.logger.Error("export: write failed", "error", err, logField(r))
+	}
+}
+
+// parseExportRange validates the from/to query parameters, returning a
+// message for the client when they are missing or out of bounds.
+func parseExportRange(fromStr, toStr string) (from, to time.Time, msg string) {
+	if fromStr == "" || toStr == "" {
+		return from, to, "from and to are required (YYYY-MM-DD)"
+	}
+	from, err := time.Parse(exportDateLayout, fromStr)
+	if err != nil {
+		return from, to, "from must be a date (YYYY-MM-DD)"
+	}
+	to, err = time.Parse(exportDateLayout, toStr)
+	if err != nil {
+		return from, to, "to must be a date (YYYY-MM-DD)"
+	}
+	if to.Before(from) {
+		return from, to, "to must not be before from"
+	}
+	if to.Sub(from) >= maxExportDays*24*time.Hour {
+		return from, to, fmt.Sprintf("range must be at most %d days", maxExportDays)
+	}
+	return from, to, ""
+}
+
+// exportRowFor converts a stored event into an export row. ok is false for
+// events that moved no money, such as a dispute closed as lost (the funds
+// already left with charge.dispute.created). refunded tracks the highest
+// amount_refunded seen per charge and is updated as events are consumed.
+func exportRowFor(e db.StripeEvent, refunded map[string]int64) (row exportRow, ok bool, err error) {
+	event, err := stripeinternal.ParseStoredEvent(e.Payload)
+	if err != nil {
+		return row, false, err
+	}
+	row = exportRow{at: e.ReceivedAt, eventID: e.StripeEventID}
+
+	switch e.Type {
+	case "payment_intent.succeeded":
+		pi, err := stripeinternal.ExtractPaymentIntentID(event)
+		if err != nil {
+			return row, false, err
+		}
+		details, err := stripeinternal.ExtractPaymentDetails(event)
+		if err != nil {
+			return row, false, err
+		}
+		row.kind, row.status = "payment", "succeeded"
+		row.paymentIntent, row.reference = pi, details.Charge.ID
+		row.amount, row.currency = details.AmountCents, details.Currency
+
+	case "charge.refunded":
+		refund, err := stripeinternal.ExtractRefund(event)
+		if err != nil {
+			return row, false, err
+		}
+		delta := refund.AmountRefunded - refunded[refund.ChargeID]
+		if delta <= 0 {
+			return row, false, nil // redelivered or out-of-order event
+		}
+		refunded[refund.ChargeID] = refund.AmountRefunded
+		row.kind, row.status = "refund", "refunded"
+		row.paymentIntent, row.reference = refund.PaymentIntentID, refund.ChargeID
+		row.amount, row.currency = -delta, refund.Currency
+
+	case "charge.dispute.created", "charge.dispute.closed":
+		dispute, err := stripeinternal.ExtractDispute(event)
+		if err != nil {
+			return row, false, err
+		}
+		// Stripe lists the withdrawal first and the reinstatement, if the
+		// dispute was won, second.
+		idx, amount := 0, -dispute.AmountCents
+		if e.Type == "charge.dispute.closed" {
+			if dispute.Status != "won" {
+				return row, false, nil
+			}
+			idx, amount = 1, dispute.AmountCents
+		}
+		row.kind, row.status = "dispute", dispute.Status
+		row.paymentIntent, row.reference = dispute.PaymentIntentID, dispute.ID
+		row.amount, row.currency = amount, dispute.Currency
+		if idx < len(dispute.BalanceTransactions) {
+			bt := dispute.BalanceTransactions[idx]
+			row.amount = bt.AmountCents
+			row.fee = &bt.FeeCents
+		}
+
+	default:
+		return row, false, nil
+	}
+	return row, true, nil
+}
+
+func parseRefundEvent(e db.StripeEvent) (stripeinternal.Refund, error) {
+	event, err := stripeinternal.ParseStoredEvent(e.Payload)
+	if err != nil {
+		return stripeinternal.Refund{}, err
+	}
+	return stripeinternal.ExtractRefund(event)
+}
+
+// optionalAmount formats v, or returns "" when it is unknown.
+func optionalAmount(v *int64, currency string) string {
+	if v == nil {
+		return ""
+	}
+	return invoice.Decimal(*v, currency)
+}
+
+// exportNet is amount less fee, left blank when the fee is unknown so a
+// spreadsheet sum does not silently treat it as zero.
+func exportNet(row exportRow) string {
+	if row.fee == nil {
+		return ""
+	}
+	return invoice.Decimal(row.amount-*row.fee, row.currency)
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── FEEDBACK ─────────────────────────────────────────────────────────────────
+//
+// A week after delivery the worker emails each customer once, asking for a
+// 0–10 rating and a testimonial (see worker.FeedbackRequester). The email
+// links to the frontend's /feedback/{token} page, which reads and answers
+// through the routes below. The token is 24 random bytes, so unlike report
+// tokens it is not worth throttling guesses.
+//
+// A testimonial is only exported once its author agreed to have it quoted
+// and an admin approved it; changing it or withdrawing consent withdraws
+// the approval.
+
+// maxTestimonialRunes bounds a testimonial.
+const maxTestimonialRunes = 2000
+
+type feedbackResponse struct {
+	BizName      string `json:"biz_name,omitempty"`
+	Rating       *int16 `json:"rating"` // null until answered
+	Testimonial  string `json:"testimonial,omitempty"`
+	ConsentQuote bool   `json:"consent_quote"`
+	ConsentName  bool   `json:"consent_name"`
+	RespondedAt  string `json:"responded_at,omitempty"`
+}
+
+func newFeedbackResponse(f db.Feedback, bizName string) feedbackResponse {
+	resp := feedbackResponse{
+		BizName:      bizName,
+		Testimonial:  f.Testimonial.String,
+		ConsentQuote: f.ConsentQuote,
+		ConsentName:  f.ConsentName,
+	}
+	if f.Rating.Valid {
+		resp.Rating = &f.Rating.Int16
+	}
+	if f.RespondedAt.Valid {
+		resp.RespondedAt = f.RespondedAt.Time.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// feedbackByToken loads the route's feedback row, answering 404 for an
+// unknown token. ok is false when a response has been written.
+func (s *Server) feedbackByToken(w http.ResponseWriter, r *http.Request) (db.GetFeedbackByTokenRow, bool) {
+	fb, err := s.q.GetFeedbackByToken(r.Context(), chi.URLParam(r, "token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "feedback request not found")
+		return fb, false
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get feedback: %w", err))
+		return fb, false
+	}
+	return fb, true
+}
+
+// ─── GET /api/feedback/:token ─────────────────────────────────────────────────
+//
+// The current answer, so the page can show it for editing.
+
+func (s *Server) handleGetFeedback(w http.ResponseWriter, r *http.Request) {
+	fb, ok := s.feedbackByToken(w, r)
+	if !ok {
+		return
+	}
+	respond(w, http.StatusOK, newFeedbackResponse(db.Feedback{
+		Rating:       fb.Rating,
+		Testimonial:  fb.Testimonial,
+		ConsentQuote: fb.ConsentQuote,
+		ConsentName:  fb.ConsentName,
+		RespondedAt:  fb.RespondedAt,
+	}, fb.BizName.String))
+}
+
+// ─── POST /api/feedback/:token ────────────────────────────────────────────────
+//
+// Stores the rating, the optional testimonial and the consent to quote it,
+// replacing any earlier answer. consent_name (quote it under the business
+// name) needs consent_quote, which needs a testimonial.
+
+type postFeedbackRequest struct {
+	Rating       *int16 `json:"rating"`
+	Testimonial  string `json:"testimonial"`
+	ConsentQuote bool   `json:"consent_quote"`
+	ConsentName  bool   `json:"consent_name"`
+}
+
+func (s *Server) handlePostFeedback(w http.ResponseWriter, r *http.Request) {
+	var req postFeedbackRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Testimonial = strings.TrimSpace(req.Testimonial)
+	switch {
+	case req.Rating == nil || *req.Rating < 0 || *req.Rating > 10:
+		respondErr(w, http.StatusBadRequest, "rating must be between 0 and 10")
+		return
+	case utf8.RuneCountInString(req.Testimonial) > maxTestimonialRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("testimonial must be at most %d characters", maxTestimonialRunes))
+		return
+	case req.ConsentQuote && req.Testimonial == "":
+		respondErr(w, http.StatusBadRequest, "consent_quote needs a testimonial")
+		return
+	case req.ConsentName && !req.ConsentQuote:
+		respondErr(w, http.StatusBadRequest, "consent_name needs consent_quote")
+		return
+	}
+
+	fb, ok := s.feedbackByToken(w, r)
+	if !ok {
+		return
+	}
+	updated, err := s.q.RecordFeedback(r.Context(), db.RecordFeedbackParams{
+		Rating:       sql.NullInt16{Int16: *req.Rating, Valid: true},
+		Testimonial:  sql.NullString{String: req.Testimonial, Valid: req.Testimonial != ""},
+		ConsentQuote: req.ConsentQuote,
+		ConsentName:  req.ConsentName,
+		Token:        fb.Token,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("record feedback: %w", err))
+		return
+	}
+
+	s.logger.Info("feedback: received",
+		"report_id", fb.ReportID,
+		"rating", *req.Rating,
+		"testimonial", req.Testimonial != "",
+		"consent_quote", req.ConsentQuote,
+		logField(r),
+	)
+	respond(w, http.StatusOK, newFeedbackResponse(updated, fb.BizName.String))
+}
+
+// ─── POST /api/feedback/:token/unsubscribe ────────────────────────────────────
+//
+// Adds the customer's address to email_suppressions, so no optional email
+// reaches it again. Report and receipt emails are still sent.
+
+func (s *Server) handleFeedbackUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	fb, ok := s.feedbackByToken(w, r)
+	if !ok {
+		return
+	}
+	if fb.EmailHash.Valid {
+		if err := s.q.SuppressEmail(r.Context(), db.SuppressEmailParams{EmailHash: fb.EmailHash.String, Reason: "unsubscribed"}); err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("suppress email: %w", err))
+			return
+		}
+	}
+	s.logger.Info("feedback: unsubscribed", "report_id", fb.ReportID, logField(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ─── GET /api/admin/testimonials?status=pending|approved ──────────────────────
+//
+// Testimonials their authors agreed to have quoted, awaiting approval
+// (the default) or approved, oldest first. attribution is the business name
+// when its author agreed to be named.
+
+type testimonialResponse struct {
+	ReportID    string `json:"report_id"`
+	Rating      *int16 `json:"rating"`
+	Testimonial string `json:"testimonial"`
+	Attribution string `json:"attribution,omitempty"`
+	RespondedAt string `json:"responded_at"`
+	ApprovedAt  string `json:"approved_at,omitempty"`
+}
+
+func newTestimonialResponse(row db.ListTestimonialsRow) testimonialResponse {
+	t := testimonialResponse{
+		ReportID:    row.ReportID.String(),
+		Testimonial: row.Testimonial.String,
+		RespondedAt: row.RespondedAt.Time.UTC().Format(time.RFC3339),
+	}
+	if row.Rating.Valid {
+		t.Rating = &row.Rating.Int16
+	}
+	if row.ConsentName {
+		t.Attribution = row.BizName.String
+	}
+	if row.ApprovedAt.Valid {
+		t.ApprovedAt = row.ApprovedAt.Time.UTC().Format(time.RFC3339)
+	}
+	return t
+}
+
+type adminTestimonialsResponse struct {
+	Testimonials []testimonialResponse `json:"testimonials"`
+}
+
+func (s *Server) handleAdminListTestimonials(w http.ResponseWriter, r *http.Request) {
+	var approved bool
+	switch r.URL.Query().Get("status") {
+	case "", "pending":
+	case "approved":
+		approved = true
+	default:
+		respondErr(w, http.StatusBadRequest, "status must be one of pending, approved")
+		return
+	}
+	rows, err := s.q.ListTestimonials(r.Context(), approved)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list testimonials: %w", err))
+		return
+	}
+	out := adminTestimonialsResponse{Testimonials: make([]testimonialResponse, len(rows))}
+	for i, row := range rows {
+		out.Testimonials[i] = newTestimonialResponse(row)
+	}
+	respond(w, http.StatusOK, out)
+}
+
+// ─── POST /api/admin/testimonials/:reportID/approve ───────────────────────────
+//
+// Approves a report's testimonial for publication. 404 when the report has
+// no testimonial its author agreed to have quoted.
+
+type approveTestimonialResponse struct {
+	ReportID   string `json:"report_id"`
+	ApprovedAt string `json:"approved_at"`
+}
+
+func (s *Server) handleAdminApproveTestimonial(w http.ResponseWriter, r *http.Request) {
+	reportID, err := parseUUID(chi.URLParam(r, "reportID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid report_id")
+		return
+	}
+	fb, err := s.q.ApproveTestimonial(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "no quotable testimonial for this report")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("approve testimonial: %w", err))
+		return
+	}
+
+	s.logger.Info("admin: testimonial approved", "report_id", reportID, "audit", true, logField(r))
+	respond(w, http.StatusOK, approveTestimonialResponse{
+		ReportID:   reportID.String(),
+		ApprovedAt: fb.ApprovedAt.Time.UTC().Format(time.RFC3339),
+	})
+}
+
+// ─── GET /api/admin/exports/testimonials ──────────────────────────────────────
+//
+// CSV of the approved testimonials, for the marketing site.
+
+var testimonialExportHeader = []string{"report_id", "rating", "testimonial", "attribution", "responded_at", "approved_at"}
+
+func (s *Server) handleAdminExportTestimonials(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.q.ListTestimonials(r.Context(), true)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list testimonials: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "testimonials.csv"))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(testimonialExportHeader)
+	for _, row := range rows {
+		t := newTestimonialResponse(row)
+		rating := ""
+		if t.Rating != nil {
+			rating = strconv.Itoa(int(*t.Rating))
+		}
+		_ = cw.Write([]string{t.ReportID, rating, t.Testimonial, t.Attribution, t.RespondedAt, t.ApprovedAt})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		// Headers are already sent; all that is left is to record it.
+		s.logger.Error("export: write failed", "error", err, logField(r))
+	}
+}
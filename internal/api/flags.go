@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+)
+
+// ─── GET /api/admin/flags ─────────────────────────────────────────────────────
+//
+// Returns every known feature flag with the percentage of sessions it is on
+// for in this instance's environment, alongside the raw feature_flags rows.
+// FEATURE_FLAGS rules show up only in the effective percentages.
+
+type flagResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Percent     int    `json:"percent"`
+}
+
+type adminFlagsResponse struct {
+	Environment string           `json:"environment"`
+	Flags       []flagResponse   `json:"flags"`
+	Rows        []db.FeatureFlag `json:"rows"`
+}
+
+func (s *Server) handleAdminListFlags(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.q.ListFeatureFlags(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list feature flags: %w", err))
+		return
+	}
+
+	cur := s.cfg.Flags.Current()
+	resp := adminFlagsResponse{Environment: s.cfg.Env, Rows: rows}
+	for name, description := range flags.Known {
+		resp.Flags = append(resp.Flags, flagResponse{Name: name, Description: description, Percent: cur.Percent(name)})
+	}
+	sort.Slice(resp.Flags, func(i, j int) bool { return resp.Flags[i].Name < resp.Flags[j].Name })
+	respond(w, http.StatusOK, resp)
+}
+
+// ─── PUT /api/admin/flags/:name ───────────────────────────────────────────────
+//
+// Stores a flag's rule for one environment, or for all of them when
+// environment is empty, then reloads this instance's flags. value is on, off
+// or a percentage such as "10%", as in FEATURE_FLAGS. Other instances pick the
+// change up at once when REDIS_URL is set, else on their next reload (or on
+// SIGHUP).
+
+type putFlagRequest struct {
+	Environment string `json:"environment"`
+	Value       string `json:"value"`
+}
+
+func (s *Server) handleAdminPutFlag(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var req putFlagRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if err := flags.Validate(name); err != nil {
+		respondErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	percent, err := flags.ParsePercent(req.Value)
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	row, err := s.q.UpsertFeatureFlag(r.Context(), db.UpsertFeatureFlagParams{
+		Name:        name,
+		Environment: strings.TrimSpace(req.Environment),
+		Percent:     int16(percent),
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("upsert feature flag: %w", err))
+		return
+	}
+
+	s.logger.Info("admin: feature flag set",
+		"flag", row.Name,
+		"environment", row.Environment,
+		"percent", row.Percent,
+		"audit", true,
+		logField(r),
+	)
+	s.reloadFlags(r)
+	respond(w, http.StatusOK, row)
+}
+
+// ─── DELETE /api/admin/flags/:name?environment= ───────────────────────────────
+//
+// Removes a flag's rule for the environment (all environments when omitted),
+// so the FEATURE_FLAGS rule or the built-in default applies again.
+
+func (s *Server) handleAdminDeleteFlag(w http.ResponseWriter, r *http.Request) {
+	name, env := chi.URLParam(r, "name"), r.URL.Query().Get("environment")
+	n, err := s.q.DeleteFeatureFlag(r.Context(), db.DeleteFeatureFlagParams{Name: name, Environment: env})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("delete feature flag: %w", err))
+		return
+	}
+	if n == 0 {
+		respondErr(w, http.StatusNotFound, "flag rule not found")
+		return
+	}
+
+	s.logger.Info("admin: feature flag rule deleted",
+		"flag", name,
+		"environment", env,
+		"audit", true,
+		logField(r),
+	)
+	s.reloadFlags(r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadFlags refreshes the local flags after a write and tells the other
+// replicas to do the same. Failure is logged only — the periodic reload will
+// catch up.
+func (s *Server) reloadFlags(r *http.Request) {
+	if s.cfg.Flags == nil {
+		return
+	}
+	if err := s.cfg.Flags.Reload(r.Context()); err != nil {
+		s.logger.Error("admin: feature flags reload failed", "error", err, logField(r))
+	}
+	s.cfg.Reloads.Notify(r.Context(), cluster.TopicReload)
+}
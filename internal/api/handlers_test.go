@@ -2,8 +2,13 @@ package api_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,314 +16,93 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/api"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
-	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
-	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/dbhealth"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/embed"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fraud"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/lockout"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/prefill"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/querywatch"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/testutil"
 	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/pkg/client"
+	"github.com/redis/go-redis/v9"
+	"github.com/sqlc-dev/pqtype"
 )
 
-// ─── STUBS ────────────────────────────────────────────────────────────────────
-
-// stubQuerier satisfies db.Querier with in-memory state.
-// Fields may be set per-test to control behaviour.
-type stubQuerier struct {
-	db.Querier                          // embedded to panic on unimplemented methods
-	sessions       map[string]db.Session // keyed by anon_token
-	sessionsByID   map[uuid.UUID]db.Session
-	reports        map[string]db.GetReportByAccessTokenRow // keyed by access_token
-	riskResults    map[uuid.UUID][]db.RiskResult
-	createSessionErr error
-	upsertAnswerErr  error
-}
-
-func newStubQuerier() *stubQuerier {
-	return &stubQuerier{
-		sessions:     make(map[string]db.Session),
-		sessionsByID: make(map[uuid.UUID]db.Session),
-		reports:      make(map[string]db.GetReportByAccessTokenRow),
-		riskResults:  make(map[uuid.UUID][]db.RiskResult),
-	}
-}
-
-func (q *stubQuerier) addSession(token string, s db.Session) {
-	q.sessions[token] = s
-	q.sessionsByID[s.ID] = s
-}
+// ─── GET /healthz ─────────────────────────────────────────────────────────────
 
-func (q *stubQuerier) CreateSession(_ context.Context, p db.CreateSessionParams) (db.Session, error) {
-	if q.createSessionErr != nil {
-		return db.Session{}, q.createSessionErr
-	}
-	s := db.Session{
-		ID:        uuid.New(),
-		AnonToken: p.AnonToken,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+func TestHealthz(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/healthz", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
 	}
-	q.addSession(p.AnonToken, s)
-	return s, nil
 }
 
-func (q *stubQuerier) GetSessionByAnonToken(_ context.Context, token string) (db.Session, error) {
-	s, ok := q.sessions[token]
-	if !ok {
-		return db.Session{}, sql.ErrNoRows
-	}
-	return s, nil
-}
+// ─── GET /readyz ──────────────────────────────────────────────────────────────
 
-func (q *stubQuerier) GetSessionByID(_ context.Context, id uuid.UUID) (db.Session, error) {
-	s, ok := q.sessionsByID[id]
-	if !ok {
-		return db.Session{}, sql.ErrNoRows
+func TestReadyz_NoChecksIsReady(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/readyz", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
 	}
-	return s, nil
 }
 
-func (q *stubQuerier) UpdateSessionContext(_ context.Context, p db.UpdateSessionContextParams) (db.Session, error) {
-	s, ok := q.sessionsByID[p.ID]
-	if !ok {
-		return db.Session{}, sql.ErrNoRows
-	}
-	s.BizName = p.BizName
-	s.Industry = p.Industry
-	s.Stage = p.Stage
-	q.sessionsByID[p.ID] = s
-	for tok, sess := range q.sessions {
-		if sess.ID == p.ID {
-			q.sessions[tok] = s
+func TestReadyz_FailingCriticalCheckReturns503(t *testing.T) {
+	deps := testutil.NewServer(t, func(cfg *api.Config) {
+		cfg.ReadinessChecks = []api.ReadinessCheck{
+			{Name: "database", Critical: true, Check: func(context.Context) error { return errors.New("down") }},
 		}
-	}
-	return s, nil
-}
-
-func (q *stubQuerier) UpsertAnswer(_ context.Context, p db.UpsertAnswerParams) (db.Answer, error) {
-	if q.upsertAnswerErr != nil {
-		return db.Answer{}, q.upsertAnswerErr
-	}
-	return db.Answer{
-		ID:         uuid.New(),
-		SessionID:  p.SessionID,
-		QuestionID: p.QuestionID,
-		AnswerText: p.AnswerText,
-	}, nil
-}
-
-func (q *stubQuerier) GetReportByAccessToken(_ context.Context, token string) (db.GetReportByAccessTokenRow, error) {
-	r, ok := q.reports[token]
-	if !ok {
-		return db.GetReportByAccessTokenRow{}, sql.ErrNoRows
-	}
-	return r, nil
-}
-
-func (q *stubQuerier) GetRiskResultsByReport(_ context.Context, id uuid.UUID) ([]db.RiskResult, error) {
-	return q.riskResults[id], nil
-}
-
-func (q *stubQuerier) UpsertStripeEvent(_ context.Context, _ db.UpsertStripeEventParams) (db.StripeEvent, error) {
-	return db.StripeEvent{}, nil
-}
-
-func (q *stubQuerier) MarkStripeEventProcessed(_ context.Context, _ string) (db.StripeEvent, error) {
-	return db.StripeEvent{}, nil
-}
-
-func (q *stubQuerier) MarkStripeEventFailed(_ context.Context, _ db.MarkStripeEventFailedParams) (db.StripeEvent, error) {
-	return db.StripeEvent{}, nil
-}
-
-func (q *stubQuerier) MarkSessionPaymentFailed(_ context.Context, _ sql.NullString) (db.Session, error) {
-	return db.Session{}, nil
-}
-
-func (q *stubQuerier) AttachStripeCustomer(_ context.Context, p db.AttachStripeCustomerParams) (db.Session, error) {
-	s, ok := q.sessionsByID[p.ID]
-	if !ok {
-		return db.Session{}, sql.ErrNoRows
-	}
-	s.StripePaymentIntent = p.StripePaymentIntent
-	s.Email = p.Email
-	q.sessionsByID[p.ID] = s
-	return s, nil
-}
-
-// stubStore satisfies the subset of store.Store the API uses.
-type stubStore struct {
-	attachErr         error
-	initialiseReport  db.Report
-	initialiseErr     error
-}
-
-func (s *stubStore) AttachPaymentIntent(_ context.Context, _ store.AttachPaymentIntentParams) (db.Session, error) {
-	return db.Session{}, s.attachErr
-}
-
-func (s *stubStore) InitialiseReport(_ context.Context, _ string) (db.Report, error) {
-	return s.initialiseReport, s.initialiseErr
-}
-
-func (s *stubStore) MarkReportFailed(_ context.Context, _ uuid.UUID, _ string) (db.Report, error) {
-	return db.Report{}, nil
-}
-
-// stubStripe is a controllable Stripe client.
-type stubStripe struct {
-	pi             stripeinternal.PaymentIntent
-	clientSecret   string
-	createErr      error
-	getSecretErr   error
-	verifyEvent    stripeinternal.Event
-	verifyErr      error
-}
-
-func (s *stubStripe) CreatePaymentIntent(_ context.Context, _ stripeinternal.CreatePaymentIntentParams) (stripeinternal.PaymentIntent, error) {
-	return s.pi, s.createErr
-}
-
-func (s *stubStripe) GetClientSecret(_ context.Context, _ string) (string, error) {
-	return s.clientSecret, s.getSecretErr
-}
-
-func (s *stubStripe) VerifyWebhook(_ []byte, _ string, _ string) (stripeinternal.Event, error) {
-	return s.verifyEvent, s.verifyErr
-}
-
-// stubWorker records enqueued jobs.
-type stubWorker struct {
-	enqueued []uuid.UUID
-	err      error
-}
-
-func (w *stubWorker) Enqueue(_ context.Context, id uuid.UUID) error {
-	w.enqueued = append(w.enqueued, id)
-	return w.err
-}
-
-// stubMailer captures sent emails.
-type stubMailer struct {
-	receipts     []email.ReceiptParams
-	reportReadys []email.ReportReadyParams
-	err          error
-}
-
-func (m *stubMailer) SendReceipt(_ context.Context, p email.ReceiptParams) error {
-	m.receipts = append(m.receipts, p)
-	return m.err
-}
-
-func (m *stubMailer) SendReportReady(_ context.Context, p email.ReportReadyParams) error {
-	m.reportReadys = append(m.reportReadys, p)
-	return m.err
-}
-
-// ─── HELPERS ─────────────────────────────────────────────────────────────────
-
-type testDeps struct {
-	q       *stubQuerier
-	stripe  *stubStripe
-	worker  *stubWorker
-	mailer  *stubMailer
-	handler http.Handler
-}
-
-func newTestServer(t *testing.T, cfgOverrides ...func(*api.Config)) *testDeps {
-	t.Helper()
-
-	q := newStubQuerier()
-	st := &stubStore{}
-	fmt.Println(st)
-	strp := &stubStripe{
-		pi:           stripeinternal.PaymentIntent{ID: "pi_test", ClientSecret: "cs_test"},
-		clientSecret: "cs_test",
-	}
-	wk := &stubWorker{}
-	ml := &stubMailer{}
-
-	cfg := api.Config{
-		Env:                 "development",
-		BaseURL:             "http://localhost:8080",
-		StripeWebhookSecret: "whsec_test",
-	}
-	for _, fn := range cfgOverrides {
-		fn(&cfg)
-	}
-
-	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-
-	handler := api.NewServer(q, nil, strp, wk, ml, cfg, logger)
-
-	return &testDeps{
-		q:       q,
-		stripe:  strp,
-		worker:  wk,
-		mailer:  ml,
-		handler: handler,
+	})
+	rr := deps.Do(t, http.MethodGet, "/readyz", nil, nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
 	}
 }
 
-func doRequest(t *testing.T, handler http.Handler, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
-	t.Helper()
-	var bodyReader io.Reader
-	if body != nil {
-		b, err := json.Marshal(body)
-		if err != nil {
-			t.Fatalf("marshal body: %v", err)
+func TestReadyz_FailingNonCriticalCheckStillReady(t *testing.T) {
+	deps := testutil.NewServer(t, func(cfg *api.Config) {
+		cfg.ReadinessChecks = []api.ReadinessCheck{
+			{Name: "ai:deepseek", Check: func(context.Context) error { return errors.New("down") }},
 		}
-		bodyReader = bytes.NewReader(b)
-	}
-	req := httptest.NewRequest(method, path, bodyReader)
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-	rr := httptest.NewRecorder()
-	handler.ServeHTTP(rr, req)
-	return rr
-}
-
-func decodeJSON(t *testing.T, rr *httptest.ResponseRecorder, dst any) {
-	t.Helper()
-	if err := json.NewDecoder(rr.Body).Decode(dst); err != nil {
-		t.Fatalf("decode response body: %v (raw: %s)", err, rr.Body.String())
-	}
-}
-
-// sessionWithToken seeds a session in the stub querier and returns its ID and token.
-func sessionWithToken(deps *testDeps) (uuid.UUID, string) {
-	id := uuid.New()
-	token := "test_tok_" + id.String()
-	deps.q.addSession(token, db.Session{
-		ID:        id,
-		AnonToken: token,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
 	})
-	return id, token
-}
-
-// ─── GET /healthz ─────────────────────────────────────────────────────────────
-
-func TestHealthz(t *testing.T) {
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler, http.MethodGet, "/healthz", nil, nil)
+	rr := deps.Do(t, http.MethodGet, "/readyz", nil, nil)
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rr.Code)
 	}
+
+	var resp struct {
+		Checks map[string]struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		} `json:"checks"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if c := resp.Checks["ai:deepseek"]; c.OK || c.Error != "down" {
+		t.Errorf("expected ai:deepseek to be reported failing, got %+v", c)
+	}
 }
 
 // ─── POST /api/session ────────────────────────────────────────────────────────
 
 func TestCreateSession_ReturnsSessionIDAndToken(t *testing.T) {
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler, http.MethodPost, "/api/session",
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodPost, "/api/session",
 		map[string]string{"biz_name": "Acme", "industry": "SaaS", "stage": "growth"}, nil)
 
 	if rr.Code != http.StatusCreated {
@@ -329,7 +113,7 @@ func TestCreateSession_ReturnsSessionIDAndToken(t *testing.T) {
 		SessionID string `json:"session_id"`
 		AnonToken string `json:"anon_token"`
 	}
-	decodeJSON(t, rr, &resp)
+	testutil.DecodeJSON(t, rr, &resp)
 
 	if resp.SessionID == "" {
 		t.Error("session_id should not be empty")
@@ -341,19 +125,19 @@ func TestCreateSession_ReturnsSessionIDAndToken(t *testing.T) {
 
 func TestCreateSession_OptionalContextFields(t *testing.T) {
 	// Empty body is valid — all context fields are optional.
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler, http.MethodPost, "/api/session", map[string]string{}, nil)
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodPost, "/api/session", map[string]string{}, nil)
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
 	}
 }
 
 func TestCreateSession_InvalidJSONReturns400(t *testing.T) {
-	deps := newTestServer(t)
+	deps := testutil.NewServer(t)
 	req := httptest.NewRequest(http.MethodPost, "/api/session", bytes.NewBufferString(`{bad json`))
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
-	deps.handler.ServeHTTP(rr, req)
+	deps.Handler.ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", rr.Code)
@@ -362,19 +146,159 @@ func TestCreateSession_InvalidJSONReturns400(t *testing.T) {
 
 func TestCreateSession_UnknownFieldsReturns400(t *testing.T) {
 	// DisallowUnknownFields is set on the decoder.
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler, http.MethodPost, "/api/session",
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodPost, "/api/session",
 		map[string]string{"unknown_field": "value"}, nil)
 	if rr.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400 for unknown field, got %d: %s", rr.Code, rr.Body.String())
 	}
 }
 
+func TestCreateSession_ReportsReassessmentAvailable(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Q.Entitled["sub@example.com"] = db.Subscription{ID: uuid.New(), Status: "active"}
+
+	for email, want := range map[string]bool{"sub@example.com": true, "new@example.com": false} {
+		rr := deps.Do(t, http.MethodPost, "/api/session",
+			map[string]string{"email": email}, nil)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		var resp struct {
+			ReassessmentAvailable bool `json:"reassessment_available"`
+		}
+		testutil.DecodeJSON(t, rr, &resp)
+		if resp.ReassessmentAvailable != want {
+			t.Errorf("%s: expected reassessment_available=%v", email, want)
+		}
+	}
+}
+
+func TestCreateSession_Captcha(t *testing.T) {
+	cases := []struct {
+		name  string
+		token string
+		err   error
+		want  int
+	}{
+		{"missing token", "", nil, http.StatusBadRequest},
+		{"rejected token", "bad", nil, http.StatusForbidden},
+		{"valid token", "ok", nil, http.StatusCreated},
+		{"provider down", "ok", errors.New("siteverify: timeout"), http.StatusCreated},
+	}
+	for _, tc := range cases {
+		cv := &testutil.Captcha{Err: tc.err}
+		deps := testutil.NewServer(t, func(c *api.Config) { c.Captcha = cv })
+
+		rr := deps.Do(t, http.MethodPost, "/api/session",
+			map[string]string{"captcha_token": tc.token}, nil)
+
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", tc.name, tc.want, rr.Code, rr.Body.String())
+		}
+		if created := len(deps.Q.Sessions) > 0; created != (tc.want == http.StatusCreated) {
+			t.Errorf("%s: session created = %v", tc.name, created)
+		}
+	}
+}
+
+func TestCreateSession_IPHash(t *testing.T) {
+	ipHash := func(deps *testutil.Server, ip string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/session", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Real-IP", ip)
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			SessionID string `json:"session_id"`
+		}
+		testutil.DecodeJSON(t, rr, &resp)
+		return deps.Q.SessionsByID[uuid.MustParse(resp.SessionID)].IpHash.String
+	}
+
+	salted := testutil.NewServer(t, func(c *api.Config) { c.IPHashSalt = "pepper" })
+	other := testutil.NewServer(t, func(c *api.Config) { c.IPHashSalt = "paprika" })
+	if a, b := ipHash(salted, "203.0.113.7"), ipHash(other, "203.0.113.7"); a == "" || a == b {
+		t.Errorf("expected different salts to give different hashes, got %q and %q", a, b)
+	}
+	if strings.Contains(ipHash(salted, "203.0.113.7"), "203.0.113") {
+		t.Error("ip_hash must not contain the IP")
+	}
+	if ipHash(salted, "203.0.113.7") == ipHash(salted, "203.0.113.9") {
+		t.Error("expected hosts to hash differently without privacy mode")
+	}
+
+	private := testutil.NewServer(t, func(c *api.Config) {
+		c.IPHashSalt = "pepper"
+		c.IPPrivacyMode = true
+	})
+	for _, pair := range [][2]string{
+		{"203.0.113.7", "203.0.113.9"},
+		{"2001:db8:1:2::1", "2001:db8:1:ffff::2"},
+		{"::ffff:203.0.113.7", "203.0.113.200"},
+	} {
+		if ipHash(private, pair[0]) != ipHash(private, pair[1]) {
+			t.Errorf("privacy mode: expected %s and %s to share a hash", pair[0], pair[1])
+		}
+	}
+	if ipHash(private, "203.0.113.7") == ipHash(private, "203.0.114.7") {
+		t.Error("privacy mode: expected different networks to hash differently")
+	}
+}
+
+func TestCreateSession_ClientIPBehindTrustedProxies(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("10.0.0.0/8")}
+	})
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"forwarded by trusted proxy", "192.0.2.1:4000", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "203.0.113.7"},
+		{"spoofed leftmost entry", "192.0.2.1:4000", map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.7, 10.0.0.5"}, "203.0.113.7"},
+		{"malformed leftmost entry", "192.0.2.1:4000", map[string]string{"X-Forwarded-For": "unknown, 203.0.113.7"}, "203.0.113.7"},
+		{"untrusted peer", "198.51.100.1:4000", map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.7"}, "198.51.100.1"},
+		{"x-real-ip from trusted proxy", "192.0.2.1:4000", map[string]string{"X-Real-IP": "203.0.113.8"}, "203.0.113.8"},
+		{"ipv6 peer", "[2001:db8::1]:443", nil, "2001:db8::1"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/session", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = tc.remoteAddr
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("%s: expected 201, got %d: %s", tc.name, rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			SessionID string `json:"session_id"`
+		}
+		testutil.DecodeJSON(t, rr, &resp)
+
+		// Without IP_HASH_SALT the hash is plain SHA-256 of the address.
+		want := sha256.Sum256([]byte(tc.want))
+		if got := deps.Q.SessionsByID[uuid.MustParse(resp.SessionID)].IpHash.String; got != hex.EncodeToString(want[:]) {
+			t.Errorf("%s: expected the hash of %s", tc.name, tc.want)
+		}
+	}
+}
+
 // ─── PATCH /api/session/:sessionID/context ────────────────────────────────────
 
 func TestUpdateContext_MissingTokenReturns401(t *testing.T) {
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler,
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t,
 		http.MethodPatch, "/api/session/"+uuid.New().String()+"/context",
 		map[string]string{"biz_name": "Test"}, nil)
 
@@ -384,8 +308,8 @@ func TestUpdateContext_MissingTokenReturns401(t *testing.T) {
 }
 
 func TestUpdateContext_InvalidTokenReturns401(t *testing.T) {
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler,
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t,
 		http.MethodPatch, "/api/session/"+uuid.New().String()+"/context",
 		map[string]string{"biz_name": "Test"},
 		map[string]string{"X-Anon-Token": "totally_fake"})
@@ -396,10 +320,10 @@ func TestUpdateContext_InvalidTokenReturns401(t *testing.T) {
 }
 
 func TestUpdateContext_WrongSessionIDReturns403(t *testing.T) {
-	deps := newTestServer(t)
-	_, token := sessionWithToken(deps)
+	deps := testutil.NewServer(t)
+	_, token := deps.NewSession()
 
-	rr := doRequest(t, deps.handler,
+	rr := deps.Do(t,
 		http.MethodPatch, "/api/session/"+uuid.New().String()+"/context", // different UUID
 		map[string]string{"biz_name": "Test"},
 		map[string]string{"X-Anon-Token": token})
@@ -410,10 +334,10 @@ func TestUpdateContext_WrongSessionIDReturns403(t *testing.T) {
 }
 
 func TestUpdateContext_ValidRequestUpdatesContext(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
 
-	rr := doRequest(t, deps.handler,
+	rr := deps.Do(t,
 		http.MethodPatch, "/api/session/"+sessionID.String()+"/context",
 		map[string]string{"biz_name": "Acme Co", "industry": "SaaS", "stage": "growth"},
 		map[string]string{"X-Anon-Token": token})
@@ -425,7 +349,7 @@ func TestUpdateContext_ValidRequestUpdatesContext(t *testing.T) {
 	var resp struct {
 		BizName string `json:"biz_name"`
 	}
-	decodeJSON(t, rr, &resp)
+	testutil.DecodeJSON(t, rr, &resp)
 	if resp.BizName != "Acme Co" {
 		t.Errorf("biz_name: got %q", resp.BizName)
 	}
@@ -434,10 +358,10 @@ func TestUpdateContext_ValidRequestUpdatesContext(t *testing.T) {
 // ─── PUT /api/session/:sessionID/answers ─────────────────────────────────────
 
 func TestUpsertAnswers_EmptyBatchReturns400(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
 
-	rr := doRequest(t, deps.handler,
+	rr := deps.Do(t,
 		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
 		map[string]any{"answers": []any{}},
 		map[string]string{"X-Anon-Token": token})
@@ -447,30 +371,152 @@ func TestUpsertAnswers_EmptyBatchReturns400(t *testing.T) {
 	}
 }
 
-func TestUpsertAnswers_Over100ItemsReturns400(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
+func TestUpsertAnswers_LimitFollowsQuestionCount(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) { c.AnswerBatchHeadroom = 2 })
+	sessionID, token := deps.NewSession()
+	auth := map[string]string{"X-Anon-Token": token}
 
-	answers := make([]map[string]string, 101)
-	for i := range answers {
-		answers[i] = map[string]string{"question_id": "q_x", "answer_text": "yes"}
+	rr := deps.Do(t, http.MethodGet, "/api/session/"+sessionID.String()+"/questions", nil, auth)
+	var resp struct {
+		Limits struct {
+			QuestionCount        int `json:"question_count"`
+			MaxAnswersPerRequest int `json:"max_answers_per_request"`
+			MaxAnswerLength      int `json:"max_answer_length"`
+		} `json:"limits"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Limits.QuestionCount != 3 || resp.Limits.MaxAnswersPerRequest != 5 || resp.Limits.MaxAnswerLength != 2000 {
+		t.Fatalf("unexpected limits: %+v", resp.Limits)
 	}
 
-	rr := doRequest(t, deps.handler,
-		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
-		map[string]any{"answers": answers},
-		map[string]string{"X-Anon-Token": token})
+	for n, want := range map[int]int{5: http.StatusOK, 6: http.StatusBadRequest} {
+		answers := make([]map[string]string, n)
+		for i := range answers {
+			answers[i] = map[string]string{"question_id": "q_x", "answer_text": "yes"}
+		}
+		rr := deps.Do(t,
+			http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+			map[string]any{"answers": answers}, auth)
+		if rr.Code != want {
+			t.Errorf("%d answers: expected %d, got %d", n, want, rr.Code)
+		}
+	}
+}
+
+func TestUpsertAnswers_SectionGating(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) { c.SectionGating = true })
+	deps.Q.Questions = append(deps.Q.Questions, db.QuestionDefinition{
+		ID: "q_market", SectionID: db.SectionIDMarket, Type: db.QuestionTypeText, Required: true,
+		ScoringConfig: json.RawMessage(`{"type":"text","threshold":10,"p_short":2,"p_long":6,"i_short":2,"i_long":8}`),
+	})
+	deps.Q.Sections = []db.QuestionSection{
+		{ID: db.SectionIDSnapshot, Title: "Snapshot", DisplayOrder: 1},
+		{ID: db.SectionIDDependency, Title: "Dependency", DisplayOrder: 2},
+		{ID: db.SectionIDMarket, Title: "Market", DisplayOrder: 3, Gated: true},
+	}
+	sessionID, token := deps.NewSession()
+	auth := map[string]string{"X-Anon-Token": token}
+	path := "/api/session/" + sessionID.String()
+
+	type section struct {
+		ID       string `json:"id"`
+		Answered int    `json:"answered"`
+		Complete bool   `json:"complete"`
+		Locked   bool   `json:"locked"`
+	}
+	sections := func() map[string]section {
+		var resp struct {
+			Sections []section `json:"sections"`
+		}
+		testutil.DecodeJSON(t, deps.Do(t, http.MethodGet, path+"/questions", nil, auth), &resp)
+		out := make(map[string]section, len(resp.Sections))
+		for _, sec := range resp.Sections {
+			out[sec.ID] = sec
+		}
+		return out
+	}
+	if got := sections(); !got["snapshot"].Complete || got["dependency"].Complete || !got["market"].Locked {
+		t.Fatalf("unexpected progress before answering: %+v", got)
+	}
+
+	rr := deps.Do(t, http.MethodPut, path+"/answers",
+		map[string]any{"answers": []map[string]string{{"question_id": "q_market", "answer_text": "Crowded"}}}, auth)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a locked section, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Completing the section before it in the same request unlocks it.
+	rr = deps.Do(t, http.MethodPut, path+"/answers",
+		map[string]any{"answers": []map[string]string{
+			{"question_id": "q_cash_runway", "answer_text": "< 3 months"},
+			{"question_id": "q_key_person", "answer_text": "Yes"},
+			{"question_id": "q_market", "answer_text": "Crowded"},
+		}}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := sections(); !got["dependency"].Complete || got["market"].Locked || got["market"].Answered != 1 {
+		t.Errorf("unexpected progress after answering: %+v", got)
+	}
+}
+
+func TestUpsertAnswers_SkippedQuestions(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	auth := map[string]string{"X-Anon-Token": token}
+	path := "/api/session/" + sessionID.String()
 
+	rr := deps.Do(t, http.MethodPut, path+"/answers",
+		map[string]any{"answers": []map[string]any{{"question_id": "q_key_person", "answer_text": "Yes", "skipped": true}}}, auth)
 	if rr.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d", rr.Code)
+		t.Fatalf("expected 400 for a skipped answer with text, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = deps.Do(t, http.MethodPut, path+"/answers",
+		map[string]any{"answers": []map[string]any{{"question_id": "q_key_person", "skipped": true}}}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if saved := deps.Q.Answers[sessionID]; len(saved) != 1 || !saved[0].Skipped {
+		t.Fatalf("expected the skip to be stored, got %+v", saved)
+	}
+
+	var resp struct {
+		Questions []struct {
+			ID      string `json:"id"`
+			Skipped bool   `json:"skipped"`
+		} `json:"questions"`
+		TotalAnswered int `json:"total_answered"`
+	}
+	testutil.DecodeJSON(t, deps.Do(t, http.MethodGet, path+"/questions", nil, auth), &resp)
+	if resp.TotalAnswered != 1 {
+		t.Errorf("expected the skip to count as answered, got total_answered %d", resp.TotalAnswered)
+	}
+	for _, q := range resp.Questions {
+		if q.Skipped != (q.ID == "q_key_person") {
+			t.Errorf("question %s: skipped = %v", q.ID, q.Skipped)
+		}
+	}
+
+	// The report lists what scoring left out.
+	reportToken := seedPaidReport(deps, "", db.PaymentStatusPaid)
+	report := deps.Q.Reports[reportToken]
+	report.SkippedQuestions = []string{"q_key_person"}
+	deps.Q.Reports[reportToken] = report
+	var got struct {
+		SkippedQuestions []string `json:"skipped_questions"`
+	}
+	testutil.DecodeJSON(t, deps.Do(t, http.MethodGet, "/api/report/"+reportToken, nil, nil), &got)
+	if len(got.SkippedQuestions) != 1 || got.SkippedQuestions[0] != "q_key_person" {
+		t.Errorf("expected skipped_questions [q_key_person], got %v", got.SkippedQuestions)
 	}
 }
 
 func TestUpsertAnswers_MissingQuestionIDReturns400(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
 
-	rr := doRequest(t, deps.handler,
+	rr := deps.Do(t,
 		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
 		map[string]any{"answers": []map[string]string{{"question_id": "", "answer_text": "yes"}}},
 		map[string]string{"X-Anon-Token": token})
@@ -481,10 +527,10 @@ func TestUpsertAnswers_MissingQuestionIDReturns400(t *testing.T) {
 }
 
 func TestUpsertAnswers_ValidBatchReturnsUpsertedCount(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
 
-	rr := doRequest(t, deps.handler,
+	rr := deps.Do(t,
 		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
 		map[string]any{
 			"answers": []map[string]any{
@@ -501,18 +547,18 @@ func TestUpsertAnswers_ValidBatchReturnsUpsertedCount(t *testing.T) {
 	var resp struct {
 		Upserted int `json:"upserted"`
 	}
-	decodeJSON(t, rr, &resp)
+	testutil.DecodeJSON(t, rr, &resp)
 	if resp.Upserted != 2 {
 		t.Errorf("expected upserted=2, got %d", resp.Upserted)
 	}
 }
 
 func TestUpsertAnswers_UpsertErrorReturns500(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
-	deps.q.upsertAnswerErr = errors.New("db connection lost")
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	deps.Q.UpsertAnswerErr = errors.New("db connection lost")
 
-	rr := doRequest(t, deps.handler,
+	rr := deps.Do(t,
 		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
 		map[string]any{"answers": []map[string]string{{"question_id": "q_x", "answer_text": "yes"}}},
 		map[string]string{"X-Anon-Token": token})
@@ -522,243 +568,3343 @@ func TestUpsertAnswers_UpsertErrorReturns500(t *testing.T) {
 	}
 }
 
-// ─── GET /api/report/:accessToken ────────────────────────────────────────────
+func TestUpsertAnswers_UnknownQuestionIDReturns400(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
 
-func TestGetReport_UnknownTokenReturns404(t *testing.T) {
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler, http.MethodGet, "/api/report/nonexistent", nil, nil)
-	if rr.Code != http.StatusNotFound {
-		t.Fatalf("expected 404, got %d", rr.Code)
+	rr := deps.Do(t,
+		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		map[string]any{"answers": []map[string]string{{"question_id": "q_nope", "answer_text": "yes"}}},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
 	}
 }
 
-func TestGetReport_DraftStatusReturns202(t *testing.T) {
-	deps := newTestServer(t)
-	token := "draft_token_abc"
-	reportID := uuid.New()
-	deps.q.reports[token] = db.GetReportByAccessTokenRow{
-		ID:     reportID,
-		Status: db.ReportStatusDraft,
-	}
+func TestUpsertAnswers_TextTooLongReturns400(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
 
-	rr := doRequest(t, deps.handler, http.MethodGet, "/api/report/"+token, nil, nil)
-	if rr.Code != http.StatusAccepted {
-		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
-	}
+	rr := deps.Do(t,
+		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		map[string]any{"answers": []map[string]string{{"question_id": "q_x", "answer_text": strings.Repeat("a", 2001)}}},
+		map[string]string{"X-Anon-Token": token})
 
-	var resp map[string]string
-	decodeJSON(t, rr, &resp)
-	if resp["status"] != "draft" {
-		t.Errorf("expected status=draft, got %q", resp["status"])
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
 	}
 }
 
-func TestGetReport_ProcessingStatusReturns202(t *testing.T) {
-	deps := newTestServer(t)
-	token := "processing_token_abc"
-	reportID := uuid.New()
-	deps.q.reports[token] = db.GetReportByAccessTokenRow{
-		ID:     reportID,
-		Status: db.ReportStatusProcessing,
+func TestUpsertAnswers_RadioValueNotInOptions(t *testing.T) {
+	body := map[string]any{"answers": []map[string]string{
+		{"question_id": "q_key_person", "answer_text": "Yes"},
+		{"question_id": "q_cash_runway", "answer_text": "forever"},
+	}}
+
+	// Strict: the whole batch is rejected before anything is written.
+	deps := testutil.NewServer(t, func(cfg *api.Config) { cfg.StrictAnswers = true })
+	sessionID, token := deps.NewSession()
+	deps.Q.UpsertAnswerErr = errors.New("must not be called")
+	rr := deps.Do(t, http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		body, map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("strict: expected 400, got %d: %s", rr.Code, rr.Body.String())
 	}
 
-	rr := doRequest(t, deps.handler, http.MethodGet, "/api/report/"+token, nil, nil)
-	if rr.Code != http.StatusAccepted {
-		t.Fatalf("expected 202 for processing, got %d", rr.Code)
+	// Lenient: stored anyway.
+	deps = testutil.NewServer(t)
+	sessionID, token = deps.NewSession()
+	rr = deps.Do(t, http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		body, map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("lenient: expected 200, got %d: %s", rr.Code, rr.Body.String())
 	}
 }
 
-func TestGetReport_ReadyStatusReturns200WithBody(t *testing.T) {
-	deps := newTestServer(t)
-	token := "ready_token_abc"
-	reportID := uuid.New()
-	deps.q.reports[token] = db.GetReportByAccessTokenRow{
-		ID:            reportID,
-		Status:        db.ReportStatusReady,
-		BizName:       sql.NullString{String: "Acme Co", Valid: true},
-		OverallScore:  sql.NullInt16{Int16: 77, Valid: true},
-		CriticalCount: sql.NullInt16{Int16: 2, Valid: true},
-		ExecutiveSummary: sql.NullString{String: "High risk posture.", Valid: true},
-	}
-	deps.q.riskResults[reportID] = []db.RiskResult{
-		{
-			ID:          uuid.New(),
-			Rank:        1,
-			QuestionID:  "q_cash_runway",
-			RiskName:    "Cash Runway Risk",
-			Probability: 9,
-			Impact:      9,
-			Score:       81,
-			Tier:        db.RiskTierWatch,
-			Hedge:       "Maintain 6+ months runway",
-			Section:     "snapshot",
-		},
-	}
+func TestUpsertAnswers_EmptyRadioAnswerAllowed(t *testing.T) {
+	deps := testutil.NewServer(t, func(cfg *api.Config) { cfg.StrictAnswers = true })
+	sessionID, token := deps.NewSession()
+
+	rr := deps.Do(t,
+		http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		map[string]any{"answers": []map[string]string{{"question_id": "q_key_person", "answer_text": ""}}},
+		map[string]string{"X-Anon-Token": token})
 
-	rr := doRequest(t, deps.handler, http.MethodGet, "/api/report/"+token, nil, nil)
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
 	}
+}
 
-	var resp struct {
-		Status        string `json:"status"`
-		BizName       string `json:"biz_name"`
-		OverallScore  int16  `json:"overall_score"`
-		CriticalCount int16  `json:"critical_count"`
-		Risks         []struct {
-			QuestionID string `json:"question_id"`
-			Score      int16  `json:"score"`
-		} `json:"risks"`
+// ─── POST /api/session/:sessionID/import ──────────────────────────────────────
+
+var partnerKey = []byte(strings.Repeat("p", 32))
+
+func withPartner(cfg *api.Config) {
+	cfg.Partners = prefill.Keys{"acme": partnerKey}
+}
+
+func TestImportPrefill_FillsOnlyWhatTheClientLeftBlank(t *testing.T) {
+	deps := testutil.NewServer(t, withPartner)
+	sessionID, token := deps.NewSession()
+	auth := map[string]string{"X-Anon-Token": token}
+	deps.Q.UpdateSessionContext(context.Background(), db.UpdateSessionContextParams{
+		ID:      sessionID,
+		BizName: sql.NullString{String: "Client's Own Name", Valid: true},
+	})
+	deps.Q.UpsertAnswer(context.Background(), db.UpsertAnswerParams{SessionID: sessionID, QuestionID: "q_key_person", AnswerText: "No"})
+
+	prefillToken, err := prefill.Sign(prefill.Payload{
+		Partner:   "acme",
+		ExpiresAt: time.Now().Add(time.Hour),
+		BizName:   "Partner's Name",
+		Industry:  "Retail",
+		Answers:   map[string]string{"q_cash_runway": "> 6 months", "q_key_person": "Yes"},
+	}, partnerKey)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
 	}
-	decodeJSON(t, rr, &resp)
 
-	if resp.Status != "ready" {
+	rr := deps.Do(t, http.MethodPost, "/api/session/"+sessionID.String()+"/import", map[string]string{"token": prefillToken}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var resp struct {
+		Partner  string   `json:"partner"`
+		Imported int      `json:"imported"`
+		Skipped  []string `json:"skipped"`
+		Context  struct {
+			BizName  string `json:"biz_name"`
+			Industry string `json:"industry"`
+		} `json:"context"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Partner != "acme" || resp.Imported != 1 || len(resp.Skipped) != 1 || resp.Skipped[0] != "q_key_person" {
+		t.Errorf("unexpected import result: %+v", resp)
+	}
+	if resp.Context.BizName != "Client's Own Name" || resp.Context.Industry != "Retail" {
+		t.Errorf("expected the client's name kept and the industry filled in, got %+v", resp.Context)
+	}
+}
+
+func TestImportPrefill_RejectsBadTokensAndAnswers(t *testing.T) {
+	deps := testutil.NewServer(t, withPartner, func(c *api.Config) { c.StrictAnswers = true })
+	sessionID, token := deps.NewSession()
+	auth := map[string]string{"X-Anon-Token": token}
+
+	expired, _ := prefill.Sign(prefill.Payload{Partner: "acme", ExpiresAt: time.Now().Add(-time.Minute)}, partnerKey)
+	notAnOption, _ := prefill.Sign(prefill.Payload{Partner: "acme", ExpiresAt: time.Now().Add(time.Hour),
+		Answers: map[string]string{"q_key_person": "Maybe"}}, partnerKey)
+	unknownQuestion, _ := prefill.Sign(prefill.Payload{Partner: "acme", ExpiresAt: time.Now().Add(time.Hour),
+		Answers: map[string]string{"q_nope": "Yes"}}, partnerKey)
+
+	for name, tok := range map[string]string{"expired": expired, "not an option": notAnOption, "unknown question": unknownQuestion, "garbage": "abc.def"} {
+		rr := deps.Do(t, http.MethodPost, "/api/session/"+sessionID.String()+"/import", map[string]string{"token": tok}, auth)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rr.Code)
+		}
+	}
+	if len(deps.Q.Answers[sessionID]) != 0 {
+		t.Errorf("a rejected import must write nothing, got %+v", deps.Q.Answers[sessionID])
+	}
+}
+
+func TestImportPrefill_NotMountedWithoutPartnerKeys(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	rr := deps.Do(t, http.MethodPost, "/api/session/"+sessionID.String()+"/import",
+		map[string]string{"token": "x"}, map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected the route to be missing, got %d", rr.Code)
+	}
+}
+
+// ─── POST /api/embed/session ──────────────────────────────────────────────────
+
+func withEmbed(t *testing.T) func(*api.Config) {
+	return func(cfg *api.Config) {
+		withPartner(cfg)
+		issuer, err := embed.New(embed.Config{Keys: cfg.Partners, Origins: []string{"acme:https://www.acme.example"}})
+		if err != nil {
+			t.Fatalf("embed.New: %v", err)
+		}
+		cfg.Embed = issuer
+	}
+}
+
+func TestEmbed_AttributesSessionsToTheIssuingPartner(t *testing.T) {
+	deps := testutil.NewServer(t, withEmbed(t))
+
+	rr := deps.Do(t, http.MethodPost, "/api/embed/session", map[string]string{"partner": "acme"},
+		map[string]string{"Origin": "https://evil.example"})
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 from an origin off the allow-list, got %d", rr.Code)
+	}
+
+	rr = deps.Do(t, http.MethodPost, "/api/embed/session", map[string]string{"partner": "acme"},
+		map[string]string{"Origin": "https://www.acme.example"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var issued struct {
+		EmbedToken string `json:"embed_token"`
+	}
+	testutil.DecodeJSON(t, rr, &issued)
+
+	rr = deps.Do(t, http.MethodPost, "/api/session", map[string]string{"embed_token": issued.EmbedToken}, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var created struct {
+		SessionID string `json:"session_id"`
+		Partner   string `json:"partner"`
+	}
+	testutil.DecodeJSON(t, rr, &created)
+	id, _ := uuid.Parse(created.SessionID)
+	if created.Partner != "acme" || deps.Q.SessionsByID[id].Partner.String != "acme" {
+		t.Errorf("expected the session attributed to acme, got %q / %+v", created.Partner, deps.Q.SessionsByID[id].Partner)
+	}
+
+	rr = deps.Do(t, http.MethodPost, "/api/session", map[string]string{"embed_token": issued.EmbedToken + "x"}, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a tampered embed token, got %d", rr.Code)
+	}
+}
+
+// ─── GET /api/session/:sessionID/progress ─────────────────────────────────────
+
+func TestGetProgress_CountsPerSection(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+
+	deps.Do(t, http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		map[string]any{"answers": []map[string]string{
+			{"question_id": "q_key_person", "answer_text": "No"},
+			{"question_id": "q_x", "answer_text": "  "},
+		}},
+		map[string]string{"X-Anon-Token": token})
+
+	rr := deps.Do(t, http.MethodGet, "/api/session/"+sessionID.String()+"/progress", nil,
+		map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Sections []struct {
+			SectionID string `json:"section_id"`
+			Answered  int    `json:"answered"`
+			Total     int    `json:"total"`
+		} `json:"sections"`
+		Answered          int `json:"answered"`
+		Total             int `json:"total"`
+		PercentComplete   int `json:"percent_complete"`
+		RequiredRemaining int `json:"required_remaining"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Answered != 1 || resp.Total != 3 || resp.PercentComplete != 33 || resp.RequiredRemaining != 1 {
+		t.Errorf("unexpected totals: %+v", resp)
+	}
+	if len(resp.Sections) != 2 || resp.Sections[1].SectionID != "dependency" || resp.Sections[1].Answered != 1 || resp.Sections[1].Total != 2 {
+		t.Errorf("unexpected sections: %+v", resp.Sections)
+	}
+}
+
+// ─── GET /api/session/:sessionID/teaser ───────────────────────────────────────
+
+func TestGetTeaser_NoAnswersReturns409(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+
+	rr := deps.Do(t, http.MethodGet, "/api/session/"+sessionID.String()+"/teaser", nil,
+		map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGetTeaser_ReturnsOnlyTopRiskAndBand(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	radio := json.RawMessage(`{"type":"radio","opts":["Yes","No"],"p_scores":[8,2],"i_scores":[9,2]}`)
+	deps.Q.Answers[sessionID] = []db.GetAnswersBySessionRow{
+		{QuestionID: "q_key_person", AnswerText: "Yes", RiskName: "Key person", Hedge: "Document everything", IsScoring: true, ScoringConfig: radio},
+		{QuestionID: "q_supplier", AnswerText: "No", RiskName: "Supplier", IsScoring: true, ScoringConfig: radio},
+		{QuestionID: "q_blank", AnswerText: "", RiskName: "Blank", IsScoring: true, ScoringConfig: radio},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/session/"+sessionID.String()+"/teaser", nil,
+		map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// (72 + 4) / 2 = 38 → moderate. The blank answer is ignored.
+	want := `{"top_risk":{"name":"Key person","tier":"watch"},"overall_band":"moderate"}`
+	if got := strings.TrimSpace(rr.Body.String()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// ─── GET /api/report/:accessToken ────────────────────────────────────────────
+
+func TestGetReport_UnknownTokenReturns404(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/api/report/nonexistent", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestGetReport_RepeatedUnknownTokensLockOutTheIP(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.ReportIPLockout = lockout.New(lockout.Config{MaxFailures: 3})
+	})
+	deps.Q.Reports["real_token"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusDraft}
+
+	for i := 0; i < 3; i++ {
+		rr := deps.Do(t, http.MethodGet, fmt.Sprintf("/api/report/guess%d", i), nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Fatalf("guess %d: expected 404, got %d", i, rr.Code)
+		}
+	}
+	rr := deps.Do(t, http.MethodGet, "/api/report/real_token", nil, nil)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After even for a real token, got %d", rr.Code)
+	}
+	rr = deps.Do(t, http.MethodGet, "/api/report/real_token/invoice", nil, nil)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the invoice route locked out too, got %d", rr.Code)
+	}
+
+	other := httptest.NewRequest(http.MethodGet, "/api/report/real_token", nil)
+	other.RemoteAddr = "198.51.100.7:4321"
+	rec := httptest.NewRecorder()
+	deps.Handler.ServeHTTP(rec, other)
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("another IP should not be locked out, got %d", rec.Code)
+	}
+}
+
+func TestGetReport_RepeatedLookupsOfOneUnknownTokenLockOutTheToken(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.ReportTokenLockout = lockout.New(lockout.Config{MaxFailures: 2})
+	})
+
+	for i, ip := range []string{"198.51.100.1:1", "198.51.100.2:1", "198.51.100.3:1"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/report/guessed", nil)
+		req.RemoteAddr = ip
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, req)
+		want := http.StatusNotFound
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if rr.Code != want {
+			t.Errorf("request %d from %s: expected %d, got %d", i, ip, want, rr.Code)
+		}
+	}
+}
+
+// seedPaidReport adds a ready report for a session with the given payment
+// status and email, and returns its access token.
+func seedPaidReport(deps *testutil.Server, addr string, status db.PaymentStatus) string {
+	sess := testutil.Session(func(s *db.Session) { s.PaymentStatus = status })
+	deps.Q.AddSession(sess.AnonToken, sess)
+	r := testutil.Report(func(r *db.GetReportByAccessTokenRow) {
+		r.SessionID = sess.ID
+		r.Email = sql.NullString{String: addr, Valid: addr != ""}
+	})
+	deps.Q.Reports[r.AccessToken] = r
+	return r.AccessToken
+}
+
+func TestResendReportLinks_EmailsPaidReportsToTheStoredAddress(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := seedPaidReport(deps, "Owner@Example.com", db.PaymentStatusPaid)
+	seedPaidReport(deps, "owner@example.com", db.PaymentStatusRefunded)
+	revoked := seedPaidReport(deps, "owner@example.com", db.PaymentStatusPaid)
+	r := deps.Q.Reports[revoked]
+	r.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	deps.Q.Reports[revoked] = r
+
+	rr := deps.Do(t, http.MethodPost, "/api/report/resend", map[string]string{"email": " owner@EXAMPLE.com "}, nil)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	sent := deps.Mailer.WaitReportReadys(t, 1)
+	if len(sent) != 1 || sent[0].AccessToken != token {
+		t.Fatalf("expected one email for the paid report, got %+v", sent)
+	}
+	if sent[0].To != "Owner@Example.com" {
+		t.Errorf("expected the session's address, got %q", sent[0].To)
+	}
+}
+
+func TestRotateReportToken_EmailsNewLinkToTheOwner(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := seedPaidReport(deps, "Owner@Example.com", db.PaymentStatusPaid)
+	path := "/api/report/" + token + "/rotate"
+
+	if rr := deps.Do(t, http.MethodPost, path, map[string]string{"email": "finder@example.com"}, nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for another address, got %d", rr.Code)
+	}
+	if _, ok := deps.Q.Reports[token]; !ok {
+		t.Fatal("a refused rotation replaced the token")
+	}
+
+	rr := deps.Do(t, http.MethodPost, path, map[string]string{"email": "owner@example.com"}, nil)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "tok_") {
+		t.Errorf("response leaks a token: %s", rr.Body.String())
+	}
+	if rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected the old link to 404, got %d", rr.Code)
+	}
+	sent := deps.Mailer.WaitReportReadys(t, 1)
+	if sent[0].To != "Owner@Example.com" || sent[0].AccessToken == token {
+		t.Fatalf("expected the new link emailed to the stored address, got %+v", sent[0])
+	}
+	if rr := deps.Do(t, http.MethodGet, "/api/report/"+sent[0].AccessToken, nil, nil); rr.Code != http.StatusOK {
+		t.Errorf("expected the new link to work, got %d", rr.Code)
+	}
+}
+
+func TestResendReportLinks_UnknownEmailGetsTheSameAnswer(t *testing.T) {
+	deps := testutil.NewServer(t)
+	seedPaidReport(deps, "owner@example.com", db.PaymentStatusPaid)
+
+	known := deps.Do(t, http.MethodPost, "/api/report/resend", map[string]string{"email": "owner@example.com"}, nil)
+	unknown := deps.Do(t, http.MethodPost, "/api/report/resend", map[string]string{"email": "stranger@example.com"}, nil)
+	if known.Code != unknown.Code || known.Body.String() != unknown.Body.String() {
+		t.Errorf("answers differ: %d %q vs %d %q", known.Code, known.Body.String(), unknown.Code, unknown.Body.String())
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/api/report/resend", map[string]string{"email": "not-an-address"}, nil)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed address, got %d", rr.Code)
+	}
+}
+
+func TestResendReportLinks_RateLimitedPerAddress(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.ReportResendEmailLimit = lockout.New(lockout.Config{MaxFailures: 2, Window: time.Hour, Duration: time.Hour})
+	})
+
+	for i, ip := range []string{"198.51.100.1:1", "198.51.100.2:1", "198.51.100.3:1"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/report/resend", strings.NewReader(`{"email":"victim@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, req)
+		want := http.StatusAccepted
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if rr.Code != want {
+			t.Errorf("request %d from %s: expected %d, got %d", i, ip, want, rr.Code)
+		}
+		if i == 2 && rr.Header().Get("Retry-After") == "" {
+			t.Error("expected Retry-After on 429")
+		}
+	}
+}
+
+func TestGetReport_DraftStatusReturns202(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := "draft_token_abc"
+	reportID := uuid.New()
+	deps.Q.Reports[token] = db.GetReportByAccessTokenRow{
+		ID:     reportID,
+		Status: db.ReportStatusDraft,
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp map[string]any
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp["status"] != "draft" {
+		t.Errorf("expected status=draft, got %v", resp["status"])
+	}
+}
+
+func TestGetReport_ProcessingStatusReturns202(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := "processing_token_abc"
+	reportID := uuid.New()
+	deps.Q.Reports[token] = db.GetReportByAccessTokenRow{
+		ID:     reportID,
+		Status: db.ReportStatusProcessing,
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 for processing, got %d", rr.Code)
+	}
+}
+
+func TestGetReport_ProcessingDuringBacklogSaysWhen(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Worker.Stats = worker.QueueStats{Queued: 6, Running: 3, Workers: 3, AvgJobDuration: 90 * time.Second}
+	deps.Q.ReportsAhead = 9
+	deps.Q.Reports["busy_token"] = db.GetReportByAccessTokenRow{
+		ID:     uuid.New(),
+		Status: db.ReportStatusProcessing,
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/busy_token", nil, nil)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Status         string `json:"status"`
+		QueuePosition  int    `json:"queue_position"`
+		ETASeconds     int    `json:"eta_seconds"`
+		ReadyInMinutes int    `json:"ready_in_minutes"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	// Nine reports ahead across three workers: four rounds of 90s.
+	if resp.QueuePosition != 10 || resp.ETASeconds != 360 || resp.ReadyInMinutes != 6 {
+		t.Errorf("expected position 10, eta 360s, ready in 6 minutes; got %+v", resp)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After capped at 60, got %q", got)
+	}
+
+	deps.Worker.Stats.Queued = 1
+	deps.Q.ReportsAhead = 0
+	rr = deps.Do(t, http.MethodGet, "/api/report/busy_token", nil, nil)
+	if strings.Contains(rr.Body.String(), "ready_in_minutes") {
+		t.Errorf("expected no delay message without a backlog, got %s", rr.Body.String())
+	}
+	// Next in line: a quarter of one 90s job.
+	if got := rr.Header().Get("Retry-After"); got != "22" {
+		t.Errorf("expected Retry-After 22, got %q", got)
+	}
+}
+
+func TestGetReport_MatchesGolden(t *testing.T) {
+	deps := testutil.NewServer(t)
+	reportID := uuid.MustParse("4f1d2c3b-0000-4000-8000-000000000001")
+	deps.Q.Reports["golden"] = testutil.Report(func(r *db.GetReportByAccessTokenRow) {
+		r.ID = reportID
+		r.SessionID = uuid.MustParse("4f1d2c3b-0000-4000-8000-000000000002")
+		r.AccessToken = "golden"
+		r.Industry = sql.NullString{String: "Retail", Valid: true}
+		r.OverallScore = sql.NullInt16{Int16: 64, Valid: true}
+		r.CriticalCount = sql.NullInt16{Int16: 1, Valid: true}
+		r.ExecutiveSummary = sql.NullString{String: "Cash is the exposure to hedge first.", Valid: true}
+		r.GeneratedAt = sql.NullTime{Time: testutil.Epoch, Valid: true}
+		r.CreatedAt = testutil.Epoch
+	})
+	deps.Q.RiskResults[reportID] = []db.RiskResult{
+		testutil.Risk(1, "q_cash_runway", 9, 9, func(r *db.RiskResult) {
+			r.ID = uuid.MustParse("4f1d2c3b-0000-4000-8000-000000000003")
+		}),
+		testutil.Risk(2, "q_key_person", 3, 8, func(r *db.RiskResult) {
+			r.ID = uuid.MustParse("4f1d2c3b-0000-4000-8000-000000000004")
+		}),
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/golden", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	testutil.AssertGoldenJSON(t, "report_ready", rr.Body.Bytes())
+}
+
+func TestGetReport_ReadyStatusReturns200WithBody(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := "ready_token_abc"
+	reportID := uuid.New()
+	deps.Q.Reports[token] = db.GetReportByAccessTokenRow{
+		ID:            reportID,
+		Status:        db.ReportStatusReady,
+		BizName:       sql.NullString{String: "Acme Co", Valid: true},
+		OverallScore:  sql.NullInt16{Int16: 77, Valid: true},
+		CriticalCount: sql.NullInt16{Int16: 2, Valid: true},
+		ExecutiveSummary: sql.NullString{String: "High risk posture.", Valid: true},
+	}
+	deps.Q.RiskResults[reportID] = []db.RiskResult{
+		{
+			ID:          uuid.New(),
+			Rank:        1,
+			QuestionID:  "q_cash_runway",
+			RiskName:    "Cash Runway Risk",
+			Probability: 9,
+			Impact:      9,
+			Score:       81,
+			Tier:        db.RiskTierWatch,
+			Hedge:       "Maintain 6+ months runway",
+			Section:     "snapshot",
+			Explanation: pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"radio":{"selected":"< 3 months","matched":true},"tier_rule":"probability 9 >= 6 and impact 9 >= 7: watch"}`), Valid: true},
+		},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Status        string `json:"status"`
+		BizName       string `json:"biz_name"`
+		OverallScore  int16  `json:"overall_score"`
+		CriticalCount int16  `json:"critical_count"`
+		Risks         []struct {
+			QuestionID  string `json:"question_id"`
+			Score       int16  `json:"score"`
+			Explanation struct {
+				Radio struct {
+					Selected string `json:"selected"`
+				} `json:"radio"`
+				TierRule string `json:"tier_rule"`
+			} `json:"explanation"`
+		} `json:"risks"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if resp.Status != "ready" {
 		t.Errorf("status: got %q", resp.Status)
 	}
-	if resp.OverallScore != 77 {
-		t.Errorf("overall_score: got %d", resp.OverallScore)
+	if resp.OverallScore != 77 {
+		t.Errorf("overall_score: got %d", resp.OverallScore)
+	}
+	if resp.CriticalCount != 2 {
+		t.Errorf("critical_count: got %d", resp.CriticalCount)
+	}
+	if len(resp.Risks) != 1 {
+		t.Fatalf("expected 1 risk, got %d", len(resp.Risks))
+	}
+	if resp.Risks[0].QuestionID != "q_cash_runway" {
+		t.Errorf("risk question_id: got %q", resp.Risks[0].QuestionID)
+	}
+	if resp.Risks[0].Score != 81 {
+		t.Errorf("risk score: got %d", resp.Risks[0].Score)
+	}
+	if ex := resp.Risks[0].Explanation; ex.Radio.Selected != "< 3 months" || ex.TierRule == "" {
+		t.Errorf("risk explanation: got %+v", ex)
+	}
+}
+
+func TestGetReportMatrix_PlacesRisksOnTheGrid(t *testing.T) {
+	deps := testutil.NewServer(t)
+	reportID := uuid.New()
+	deps.Q.Reports["tok"] = db.GetReportByAccessTokenRow{ID: reportID, Status: db.ReportStatusReady}
+	deps.Q.RiskResults[reportID] = []db.RiskResult{
+		{Rank: 1, QuestionID: "q_cash", Probability: 9, Impact: 10, Tier: db.RiskTierWatch},
+		{Rank: 2, QuestionID: "q_key_person", Probability: 2, Impact: 7, Tier: db.RiskTierRed},
+		{Rank: 3, QuestionID: "q_supplier", Probability: 9, Impact: 10, Tier: db.RiskTierWatch},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok/matrix", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Size       int `json:"size"`
+		Thresholds struct {
+			HighProbabilityFrom int `json:"high_probability_from"`
+			HighImpactFrom      int `json:"high_impact_from"`
+		} `json:"thresholds"`
+		Tiers []struct {
+			Tier string `json:"tier"`
+		} `json:"tiers"`
+		Rows [][]struct {
+			Probability int      `json:"probability"`
+			Impact      int      `json:"impact"`
+			Tier        string   `json:"tier"`
+			RiskIDs     []string `json:"risk_ids"`
+		} `json:"rows"`
+		Risks []struct {
+			QuestionID string `json:"question_id"`
+		} `json:"risks"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if resp.Size != 10 || len(resp.Rows) != 10 || len(resp.Rows[0]) != 10 {
+		t.Fatalf("expected a 10x10 grid, got size %d with %d rows", resp.Size, len(resp.Rows))
+	}
+	if resp.Thresholds.HighProbabilityFrom != 6 || resp.Thresholds.HighImpactFrom != 7 || len(resp.Tiers) != 4 {
+		t.Errorf("unexpected boundaries: %+v, %d tiers", resp.Thresholds, len(resp.Tiers))
+	}
+	top := resp.Rows[0][8]
+	if top.Impact != 10 || top.Probability != 9 || top.Tier != "watch" || strings.Join(top.RiskIDs, ",") != "q_cash,q_supplier" {
+		t.Errorf("unexpected cell (p9, i10): %+v", top)
+	}
+	red := resp.Rows[3][1]
+	if red.Impact != 7 || red.Probability != 2 || red.Tier != "red" || len(red.RiskIDs) != 1 {
+		t.Errorf("unexpected cell (p2, i7): %+v", red)
+	}
+	if corner := resp.Rows[9][5]; corner.Impact != 1 || corner.Probability != 6 || corner.Tier != "manage" || corner.RiskIDs == nil {
+		t.Errorf("unexpected cell (p6, i1): %+v", corner)
+	}
+	if len(resp.Risks) != 3 {
+		t.Errorf("expected 3 risks, got %d", len(resp.Risks))
+	}
+}
+
+func TestGetReportMatrix_PendingAndUnknown(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Q.Reports["tok"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusProcessing}
+
+	if rr := deps.Do(t, http.MethodGet, "/api/report/tok/matrix", nil, nil); rr.Code != http.StatusAccepted {
+		t.Errorf("expected 202 while generating, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodGet, "/api/report/nope/matrix", nil, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown token, got %d", rr.Code)
+	}
+}
+
+func TestGetScoringMeta(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/api/scoring/meta", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Thresholds struct {
+			HighProbabilityFrom int `json:"high_probability_from"`
+			HighImpactFrom      int `json:"high_impact_from"`
+		} `json:"thresholds"`
+		Tiers []struct {
+			Tier        string `json:"tier"`
+			Label       string `json:"label"`
+			Probability struct {
+				From int `json:"from"`
+				To   int `json:"to"`
+			} `json:"probability"`
+		} `json:"tiers"`
+		Bands []struct {
+			Band string `json:"band"`
+		} `json:"bands"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if resp.Thresholds.HighProbabilityFrom != 6 || resp.Thresholds.HighImpactFrom != 7 {
+		t.Errorf("unexpected thresholds: %+v", resp.Thresholds)
+	}
+	if len(resp.Tiers) != 4 || resp.Tiers[0].Tier != "watch" || resp.Tiers[0].Label == "" || resp.Tiers[0].Probability.From != 6 {
+		t.Errorf("unexpected tiers: %+v", resp.Tiers)
+	}
+	if len(resp.Bands) != 4 {
+		t.Errorf("expected 4 bands, got %d", len(resp.Bands))
+	}
+}
+
+func TestAdminPutTier_RenamesTheTierEverywhere(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	admin := map[string]string{"Authorization": "Bearer admin_test_key"}
+
+	rr := deps.Do(t, http.MethodPut, "/api/admin/tiers/red",
+		map[string]string{"label": "Hedge Now", "color": "red", "description": "Unlikely but existential."}, admin)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad colour, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = deps.Do(t, http.MethodPut, "/api/admin/tiers/crimson",
+		map[string]string{"label": "Hedge Now", "color": "#EA580C", "description": "Unlikely but existential."}, admin)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown tier, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = deps.Do(t, http.MethodPut, "/api/admin/tiers/red",
+		map[string]string{"label": "Hedge Now", "color": "#EA580C", "description": "Unlikely but existential.", "cadence": "Review monthly"}, admin)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	type tier struct {
+		Tier    string `json:"tier"`
+		Label   string `json:"label"`
+		Color   string `json:"color"`
+		Cadence string `json:"cadence"`
+	}
+	check := func(where string, tiers []tier) {
+		t.Helper()
+		for _, got := range tiers {
+			switch {
+			case got.Tier == "red" && (got.Label != "Hedge Now" || got.Color != "#ea580c" || got.Cadence != "Review monthly"):
+				t.Errorf("%s: red tier not themed: %+v", where, got)
+			case got.Tier == "watch" && got.Label != "Watch":
+				t.Errorf("%s: unthemed tier should keep its default label: %+v", where, got)
+			}
+		}
+		if len(tiers) != 4 {
+			t.Errorf("%s: expected 4 tiers, got %d", where, len(tiers))
+		}
+	}
+
+	var meta struct {
+		Tiers []tier `json:"tiers"`
+	}
+	testutil.DecodeJSON(t, deps.Do(t, http.MethodGet, "/api/scoring/meta", nil, nil), &meta)
+	check("meta", meta.Tiers)
+
+	token := seedPaidReport(deps, "", db.PaymentStatusPaid)
+	var report struct {
+		Tiers []tier `json:"tiers"`
+	}
+	testutil.DecodeJSON(t, deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil), &report)
+	check("report", report.Tiers)
+}
+
+func TestCompareReports_DeltasPerQuestion(t *testing.T) {
+	deps := testutil.NewServer(t)
+	email := sql.NullString{String: "owner@example.com", Valid: true}
+	before, after := uuid.New(), uuid.New()
+	deps.Q.Reports["before"] = db.GetReportByAccessTokenRow{ID: before, Status: db.ReportStatusReady, Email: email, OverallScore: sql.NullInt16{Int16: 60, Valid: true}}
+	deps.Q.Reports["after"] = db.GetReportByAccessTokenRow{ID: after, Status: db.ReportStatusReady, Email: sql.NullString{String: "Owner@Example.com", Valid: true}, OverallScore: sql.NullInt16{Int16: 45, Valid: true}}
+	deps.Q.RiskResults[before] = []db.RiskResult{
+		{QuestionID: "q_cash", Probability: 8, Impact: 9, Score: 72, Tier: db.RiskTierWatch},
+		{QuestionID: "q_old", Probability: 3, Impact: 3, Score: 9, Tier: db.RiskTierIgnore},
+	}
+	deps.Q.RiskResults[after] = []db.RiskResult{
+		{QuestionID: "q_cash", Probability: 4, Impact: 9, Score: 36, Tier: db.RiskTierRed},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/reports/compare?a=before&b=after", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		OverallScoreDelta int16 `json:"overall_score_delta"`
+		Questions         []struct {
+			QuestionID       string          `json:"question_id"`
+			B                json.RawMessage `json:"b"`
+			ProbabilityDelta int16           `json:"probability_delta"`
+			ScoreDelta       int16           `json:"score_delta"`
+			TierChanged      bool            `json:"tier_changed"`
+		} `json:"questions"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if resp.OverallScoreDelta != -15 || len(resp.Questions) != 2 {
+		t.Fatalf("unexpected comparison: %s", rr.Body.String())
+	}
+	cash, old := resp.Questions[0], resp.Questions[1]
+	if cash.QuestionID != "q_cash" || cash.ProbabilityDelta != -4 || cash.ScoreDelta != -36 || !cash.TierChanged {
+		t.Errorf("unexpected q_cash row: %+v", cash)
+	}
+	if old.QuestionID != "q_old" || string(old.B) != "null" || old.ScoreDelta != -9 {
+		t.Errorf("unexpected q_old row: %+v", old)
+	}
+}
+
+func TestCompareReports_RefusesOtherCustomersAndBadInput(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Q.Reports["mine"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusReady, Email: sql.NullString{String: "a@example.com", Valid: true}}
+	deps.Q.Reports["theirs"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusReady, Email: sql.NullString{String: "b@example.com", Valid: true}}
+	deps.Q.Reports["pending"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusProcessing, Email: sql.NullString{String: "a@example.com", Valid: true}}
+
+	for query, want := range map[string]int{
+		"a=mine&b=theirs":  http.StatusForbidden,
+		"a=mine&b=pending": http.StatusConflict,
+		"a=mine&b=nope":    http.StatusNotFound,
+		"a=mine&b=mine":    http.StatusBadRequest,
+		"a=mine":           http.StatusBadRequest,
+	} {
+		if rr := deps.Do(t, http.MethodGet, "/api/reports/compare?"+query, nil, nil); rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", query, want, rr.Code)
+		}
+	}
+}
+
+func TestGetReport_ReadyUsesAIHedgeWhenAvailable(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := "ready_ai_hedge_token"
+	reportID := uuid.New()
+	deps.Q.Reports[token] = db.GetReportByAccessTokenRow{
+		ID:     reportID,
+		Status: db.ReportStatusReady,
+	}
+	deps.Q.RiskResults[reportID] = []db.RiskResult{
+		{
+			Rank:       1,
+			QuestionID: "q_cash_runway",
+			RiskName:   "Cash Runway Risk",
+			Hedge:      "Static hedge",
+			AiHedge:    sql.NullString{String: "AI-generated hedge", Valid: true},
+			Tier:       db.RiskTierWatch,
+		},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var resp struct {
+		Risks []struct {
+			Hedge string `json:"hedge"`
+		} `json:"risks"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if len(resp.Risks) == 0 {
+		t.Fatal("expected at least one risk")
+	}
+	if resp.Risks[0].Hedge != "AI-generated hedge" {
+		t.Errorf("expected AI hedge, got %q", resp.Risks[0].Hedge)
+	}
+}
+
+func TestGetReport_MarksHumanEditedText(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := "ready_edited_token"
+	reportID := uuid.New()
+	edited := sql.NullTime{Time: time.Now(), Valid: true}
+	deps.Q.Reports[token] = db.GetReportByAccessTokenRow{
+		ID:                       reportID,
+		Status:                   db.ReportStatusReady,
+		ExecutiveSummary:         sql.NullString{String: "Corrected summary.", Valid: true},
+		ExecutiveSummaryEditedAt: edited,
+	}
+	deps.Q.RiskResults[reportID] = []db.RiskResult{
+		{Rank: 1, QuestionID: "q_cash_runway", Hedge: "Static", AiHedge: sql.NullString{String: "Corrected hedge", Valid: true}, AiHedgeEditedAt: edited, Tier: db.RiskTierWatch},
+		{Rank: 2, QuestionID: "q_key_person", Hedge: "Static", AiHedge: sql.NullString{String: "AI hedge", Valid: true}, Tier: db.RiskTierWatch},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp struct {
+		ExecutiveSummaryEdited bool `json:"executive_summary_edited"`
+		Risks                  []struct {
+			HedgeEdited bool `json:"hedge_edited"`
+		} `json:"risks"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if !resp.ExecutiveSummaryEdited {
+		t.Error("expected the summary marked as edited")
+	}
+	if len(resp.Risks) != 2 || !resp.Risks[0].HedgeEdited || resp.Risks[1].HedgeEdited {
+		t.Errorf("expected only the first hedge marked as edited, got %+v", resp.Risks)
+	}
+}
+
+func TestAdminEdits_ValidatesAndLists(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	reportID := uuid.New()
+	path := "/api/admin/reports/" + reportID.String() + "/edits"
+
+	for _, body := range []map[string]any{
+		{"field": "top_priority_html", "text": "x", "editor": "ops", "reason": "r"},
+		{"field": "ai_hedge", "text": "x", "editor": "ops", "reason": "r"},
+		{"field": "executive_summary", "question_id": "q_cash_runway", "text": "x", "editor": "ops", "reason": "r"},
+		{"field": "executive_summary", "text": " ", "editor": "ops", "reason": "r"},
+		{"field": "executive_summary", "text": strings.Repeat("x", 4001), "editor": "ops", "reason": "r"},
+		{"field": "executive_summary", "text": "x", "reason": "r"},
+		{"field": "executive_summary", "text": "x", "editor": "ops"},
+	} {
+		if rr := deps.Do(t, http.MethodPost, path, body, auth); rr.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", body, rr.Code)
+		}
+	}
+
+	deps.Q.AIEdits = []db.AiEdit{{
+		ID:         uuid.New(),
+		ReportID:   reportID,
+		Field:      "ai_hedge",
+		QuestionID: sql.NullString{String: "q_cash_runway", Valid: true},
+		Original:   sql.NullString{String: "AI hedge", Valid: true},
+		Edited:     "Corrected hedge",
+		Editor:     "ops@example.com",
+		Reason:     "wrong industry",
+	}}
+	rr := deps.Do(t, http.MethodGet, path, nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var resp struct {
+		Edits []struct {
+			QuestionID string `json:"question_id"`
+			Original   string `json:"original"`
+			Editor     string `json:"editor"`
+		} `json:"edits"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Edits) != 1 || resp.Edits[0].Original != "AI hedge" || resp.Edits[0].Editor != "ops@example.com" || resp.Edits[0].QuestionID != "q_cash_runway" {
+		t.Errorf("unexpected edits %+v", resp.Edits)
+	}
+}
+
+func TestAdminShadowScores_SummarizesPerProfile(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	deps.Q.ShadowScores = []db.SummarizeShadowScoresRow{
+		{Profile: "max_dominant", ProductionBand: "low", ShadowBand: "high", Reports: 1, DeltaSum: 40, AbsDeltaSum: 40},
+		{Profile: "max_dominant", ProductionBand: "low", ShadowBand: "low", Reports: 3, DeltaSum: -2, AbsDeltaSum: 6},
+		{Profile: "top_n:5", ProductionBand: "medium", ShadowBand: "medium", Reports: 2, DeltaSum: 3, AbsDeltaSum: 3},
+	}
+
+	if rr := deps.Do(t, http.MethodGet, "/api/admin/shadow-scores?since=last-week", nil, auth); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed since, got %d", rr.Code)
+	}
+	rr := deps.Do(t, http.MethodGet, "/api/admin/shadow-scores?since=2026-09-01", nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := deps.Q.ShadowSince.Format("2006-01-02"); got != "2026-09-01" {
+		t.Errorf("expected the window to start on 2026-09-01, got %s", got)
+	}
+	var resp struct {
+		Profiles []struct {
+			Profile      string  `json:"profile"`
+			Reports      int64   `json:"reports"`
+			MeanDelta    float64 `json:"mean_delta"`
+			MeanAbsDelta float64 `json:"mean_abs_delta"`
+			BandChanges  int64   `json:"band_changes"`
+			Bands        []any   `json:"bands"`
+		} `json:"profiles"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Profiles) != 2 {
+		t.Fatalf("expected two profiles, got %+v", resp.Profiles)
+	}
+	if p := resp.Profiles[0]; p.Profile != "max_dominant" || p.Reports != 4 || p.MeanDelta != 9.5 || p.MeanAbsDelta != 11.5 || p.BandChanges != 1 || len(p.Bands) != 2 {
+		t.Errorf("unexpected max_dominant summary %+v", p)
+	}
+	if p := resp.Profiles[1]; p.Profile != "top_n:5" || p.Reports != 2 || p.MeanDelta != 1.5 || p.BandChanges != 0 {
+		t.Errorf("unexpected top_n summary %+v", p)
+	}
+}
+
+// execOnly is a db.DBTX whose Exec succeeds at once.
+type execOnly struct{ db.DBTX }
+
+func (execOnly) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+
+func TestAdminNotes_SessionAndReportShareNotes(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	report := deps.Q.Reports[seedPaidReport(deps, "owner@example.com", db.PaymentStatusPaid)]
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	sessionPath := "/api/admin/sessions/" + report.SessionID.String() + "/notes"
+	reportPath := "/api/admin/reports/" + report.ID.String() + "/notes"
+
+	rr := deps.Do(t, http.MethodPost, sessionPath, map[string]string{"author": "sam", "body": "  Asked for the link again.  "}, auth)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = deps.Do(t, http.MethodPost, reportPath, map[string]string{"author": "alex", "body": "Refunded: duplicate purchase."}, auth)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := deps.Do(t, http.MethodPost, reportPath, map[string]string{"author": "alex"}, auth); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an empty body, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodGet, "/api/admin/sessions/"+uuid.NewString()+"/notes", nil, auth); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown session, got %d", rr.Code)
+	}
+
+	var resp struct {
+		SessionID string `json:"session_id"`
+		Notes     []struct {
+			ReportID string `json:"report_id"`
+			Author   string `json:"author"`
+			Body     string `json:"body"`
+		} `json:"notes"`
+	}
+	rr = deps.Do(t, http.MethodGet, reportPath, nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.SessionID != report.SessionID.String() || len(resp.Notes) != 2 {
+		t.Fatalf("expected both notes on the session, got %+v", resp)
+	}
+	if n := resp.Notes[0]; n.Author != "sam" || n.Body != "Asked for the link again." || n.ReportID != "" {
+		t.Errorf("unexpected session note %+v", n)
+	}
+	if n := resp.Notes[1]; n.Author != "alex" || n.ReportID != report.ID.String() {
+		t.Errorf("unexpected report note %+v", n)
+	}
+}
+
+func TestDrain_FailsReadinessAndReportsJobs(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	deps.Worker.Stats = worker.QueueStats{Running: 2, Queued: 1}
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+
+	if rr := deps.Do(t, http.MethodPost, "/internal/drain", nil, nil); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the admin key, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodGet, "/readyz", nil, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected ready before draining, got %d", rr.Code)
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/internal/drain", nil, auth)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Draining    bool `json:"draining"`
+		JobsRunning int  `json:"jobs_running"`
+		JobsQueued  int  `json:"jobs_queued"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if !resp.Draining || resp.JobsRunning != 2 || resp.JobsQueued != 1 {
+		t.Errorf("unexpected drain status %+v", resp)
+	}
+	if !deps.Worker.Draining() {
+		t.Error("expected the worker to be told to drain")
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/readyz", nil, nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Connection"); got != "close" {
+		t.Errorf("expected Connection: close while draining, got %q", got)
+	}
+	var ready struct {
+		Status string `json:"status"`
+	}
+	testutil.DecodeJSON(t, rr, &ready)
+	if ready.Status != "draining" {
+		t.Errorf("expected status draining, got %q", ready.Status)
+	}
+}
+
+func TestAdminQueries_ListsStatementStats(t *testing.T) {
+	watch := querywatch.New(querywatch.Config{Timeout: 30 * time.Second}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	deps := testutil.NewServer(t, withAdminKey, func(cfg *api.Config) { cfg.Queries = watch })
+	h := watch.Wrap(execOnly{})
+	for _, query := range []string{"-- name: TouchSession :exec\nUPDATE", "-- name: TouchSession :exec\nUPDATE", "-- name: MarkAnswered :exec\nUPDATE"} {
+		if _, err := h.ExecContext(context.Background(), query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/queries", nil, map[string]string{"Authorization": "Bearer admin_test_key"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		TimeoutMS  int64 `json:"timeout_ms"`
+		Statements []struct {
+			Name  string `json:"name"`
+			Calls int64  `json:"calls"`
+		} `json:"statements"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.TimeoutMS != 30000 {
+		t.Errorf("expected timeout_ms 30000, got %d", resp.TimeoutMS)
+	}
+	calls := map[string]int64{}
+	for _, st := range resp.Statements {
+		calls[st.Name] = st.Calls
+	}
+	if len(calls) != 2 || calls["TouchSession"] != 2 || calls["MarkAnswered"] != 1 {
+		t.Errorf("unexpected statements %+v", resp.Statements)
+	}
+}
+
+func TestFeedback_RecordsAnswerAndUnsubscribes(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Q.Feedback["fb_token"] = &db.GetFeedbackByTokenRow{
+		ReportID:     uuid.New(),
+		Token:        "fb_token",
+		Testimonial:  sql.NullString{String: "Eye-opening.", Valid: true},
+		ConsentQuote: true,
+		ApprovedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		BizName:      sql.NullString{String: "Acme", Valid: true},
+		EmailHash:    sql.NullString{String: "hash_acme", Valid: true},
+	}
+
+	if rr := deps.Do(t, http.MethodGet, "/api/feedback/fb_unknown", nil, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown token, got %d", rr.Code)
+	}
+	for _, body := range []map[string]any{
+		{"testimonial": "Great"},
+		{"rating": 11},
+		{"rating": 9, "consent_quote": true},
+		{"rating": 9, "testimonial": "Great", "consent_name": true},
+		{"rating": 9, "testimonial": strings.Repeat("x", 2001)},
+	} {
+		if rr := deps.Do(t, http.MethodPost, "/api/feedback/fb_token", body, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %d", body, rr.Code)
+		}
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/api/feedback/fb_token",
+		map[string]any{"rating": 9, "testimonial": "  Changed how we plan.  ", "consent_quote": true, "consent_name": true}, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		BizName     string `json:"biz_name"`
+		Rating      int    `json:"rating"`
+		Testimonial string `json:"testimonial"`
+		RespondedAt string `json:"responded_at"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.BizName != "Acme" || resp.Rating != 9 || resp.Testimonial != "Changed how we plan." || resp.RespondedAt == "" {
+		t.Errorf("unexpected feedback %+v", resp)
+	}
+	if deps.Q.Feedback["fb_token"].ApprovedAt.Valid {
+		t.Error("expected a changed testimonial to lose its approval")
+	}
+
+	if rr := deps.Do(t, http.MethodPost, "/api/feedback/fb_token/unsubscribe", nil, nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	if reason := deps.Q.Suppressed["hash_acme"]; reason != "unsubscribed" {
+		t.Errorf("expected the address suppressed, got %q", reason)
+	}
+}
+
+func TestGetReport_ReadyIncludesRelationships(t *testing.T) {
+	deps := testutil.NewServer(t)
+	token := "ready_relationships_token"
+	reportID := uuid.New()
+	deps.Q.Reports[token] = db.GetReportByAccessTokenRow{
+		ID:     reportID,
+		Status: db.ReportStatusReady,
+		Relationships: pqtype.NullRawMessage{
+			RawMessage: []byte(`[{"from":"q_key_person","to":"q_cash_runway","description":"Losing the founder stalls sales."},{"from":"q_key_person","to":"q_gone","description":"No longer scored."}]`),
+			Valid:      true,
+		},
+	}
+	deps.Q.RiskResults[reportID] = []db.RiskResult{
+		{Rank: 1, QuestionID: "q_cash_runway", RiskName: "Cash Runway Risk", Tier: db.RiskTierWatch},
+		{Rank: 2, QuestionID: "q_key_person", RiskName: "Key Person Risk", Tier: db.RiskTierRed},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/"+token, nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var resp struct {
+		Relationships []struct {
+			FromRiskName string `json:"from_risk_name"`
+			ToRiskName   string `json:"to_risk_name"`
+			Description  string `json:"description"`
+		} `json:"relationships"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if len(resp.Relationships) != 1 {
+		t.Fatalf("expected the one relationship between scored risks, got %+v", resp.Relationships)
+	}
+	if got := resp.Relationships[0]; got.FromRiskName != "Key Person Risk" || got.ToRiskName != "Cash Runway Risk" {
+		t.Errorf("expected the risks named, got %+v", got)
+	}
+}
+
+// ─── CORS ─────────────────────────────────────────────────────────────────────
+
+func TestCORS_PreflightReturns204(t *testing.T) {
+	deps := testutil.NewServer(t)
+	req := httptest.NewRequest(http.MethodOptions, "/api/session", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rr := httptest.NewRecorder()
+	deps.Handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	if rr.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Error("missing Access-Control-Allow-Origin header")
+	}
+	if rr.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Error("missing Access-Control-Allow-Methods header")
+	}
+}
+
+func TestCORS_NoOriginHeader_SkipsCORSHeaders(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/healthz", nil, nil)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("should not set CORS headers when no Origin present")
+	}
+}
+
+func TestCORS_MachineRoutesGetNoCORSHeaders(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	for _, path := range []string{"/api/admin/config", "/api/webhooks/stripe", "/api/v1/admin/config"} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://evil.example")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("%s: expected preflight 403, got %d", path, rr.Code)
+		}
+		if rr.Header().Get("Access-Control-Allow-Origin") != "" || rr.Header().Get("Access-Control-Allow-Headers") != "" {
+			t.Errorf("%s: preflight got CORS headers %v", path, rr.Header())
+		}
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/config", nil, map[string]string{
+		"Authorization": "Bearer admin_test_key",
+		"Origin":        "https://evil.example",
+	})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected a server-side admin call with an Origin to work, got %d", rr.Code)
+	}
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("admin response has Access-Control-Allow-Origin")
+	}
+}
+
+// ─── API versions ─────────────────────────────────────────────────────────────
+
+func TestAPIVersion_VersionedPathServesTheSameRoute(t *testing.T) {
+	deps := testutil.NewServer(t)
+
+	unversioned := deps.Do(t, http.MethodGet, "/api/products", nil, nil)
+	versioned := deps.Do(t, http.MethodGet, "/api/v1/products", nil, nil)
+	if versioned.Code != http.StatusOK || versioned.Body.String() != unversioned.Body.String() {
+		t.Fatalf("expected /api/v1/products to match /api/products, got %d: %s", versioned.Code, versioned.Body.String())
+	}
+	for _, rr := range []*httptest.ResponseRecorder{unversioned, versioned} {
+		if got := rr.Header().Get("API-Version"); got != "1" {
+			t.Errorf("expected API-Version 1, got %q", got)
+		}
+		if rr.Header().Get("Deprecation") != "" {
+			t.Errorf("expected no Deprecation header, got %q", rr.Header().Get("Deprecation"))
+		}
+	}
+
+	// Only the unprefixed path answers differently per API-Version header.
+	headerPicked := deps.Do(t, http.MethodGet, "/api/products", nil, map[string]string{"API-Version": "2"})
+	for _, rr := range []*httptest.ResponseRecorder{unversioned, headerPicked} {
+		if !variesOnAPIVersion(rr) {
+			t.Errorf("expected Vary: API-Version on /api/products, got %q", rr.Header().Values("Vary"))
+		}
+	}
+	if variesOnAPIVersion(versioned) {
+		t.Errorf("expected no Vary: API-Version on /api/v1/products, got %q", versioned.Header().Values("Vary"))
+	}
+
+	// Path parameters and guards work the same under the version.
+	_, token := deps.NewSession()
+	id := deps.Q.Sessions[token].ID
+	rr := deps.Do(t, http.MethodGet, "/api/v1/session/"+id.String()+"/progress", nil, map[string]string{"X-Anon-Token": "wrong"})
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected the anon token check under /api/v1, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodGet, "/healthz", nil, nil); rr.Header().Get("API-Version") != "" {
+		t.Errorf("expected no API-Version outside /api, got %q", rr.Header().Get("API-Version"))
+	}
+}
+
+// variesOnAPIVersion reports whether rr's Vary header names API-Version.
+func variesOnAPIVersion(rr *httptest.ResponseRecorder) bool {
+	for _, v := range rr.Header().Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "API-Version") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestAPIVersion_RejectsUnsupportedVersions(t *testing.T) {
+	deps := testutil.NewServer(t)
+
+	for _, tc := range []struct {
+		path    string
+		headers map[string]string
+	}{
+		{"/api/v9/products", nil},
+		{"/api/v0/products", nil},
+		{"/api/products", map[string]string{"API-Version": "3"}},
+		{"/api/products", map[string]string{"API-Version": "latest"}},
+	} {
+		rr := deps.Do(t, http.MethodGet, tc.path, nil, tc.headers)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "supported: v1, v2") {
+			t.Errorf("%s %v: expected 400 naming the supported versions, got %d: %s", tc.path, tc.headers, rr.Code, rr.Body.String())
+		}
+		if tc.headers != nil && !variesOnAPIVersion(rr) {
+			t.Errorf("%s %v: expected the 400 to carry Vary: API-Version", tc.path, tc.headers)
+		}
+	}
+
+	// A header naming a supported version is honoured.
+	if rr := deps.Do(t, http.MethodGet, "/api/products", nil, map[string]string{"API-Version": "v1"}); rr.Code != http.StatusOK {
+		t.Errorf("expected API-Version v1 to be served, got %d", rr.Code)
+	}
+}
+
+func TestAPIVersion_DeprecatedVersionAnnouncesItsSunset(t *testing.T) {
+	deprecations, err := api.ParseDeprecations([]string{"v1=2026-11-01/2027-05-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := testutil.NewServer(t, func(cfg *api.Config) { cfg.APIDeprecations = deprecations })
+
+	for _, path := range []string{"/api/products", "/api/v1/products"} {
+		rr := deps.Do(t, http.MethodGet, path, nil, nil)
+		if got, want := rr.Header().Get("Deprecation"), fmt.Sprintf("@%d", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC).Unix()); got != want {
+			t.Errorf("%s: Deprecation = %q, want %q", path, got, want)
+		}
+		if got := rr.Header().Get("Sunset"); got != "Sat, 01 May 2027 00:00:00 GMT" {
+			t.Errorf("%s: Sunset = %q", path, got)
+		}
+		if got := rr.Header().Get("Link"); got != `</api/v2>; rel="successor-version"` {
+			t.Errorf("%s: Link = %q, want the v2 successor", path, got)
+		}
+	}
+	if rr := deps.Do(t, http.MethodGet, "/api/v2/products", nil, nil); rr.Header().Get("Deprecation") != "" || rr.Header().Get("Link") != "" {
+		t.Errorf("v2 is not deprecated, got Deprecation %q, Link %q", rr.Header().Get("Deprecation"), rr.Header().Get("Link"))
+	}
+}
+
+func TestParseDeprecations(t *testing.T) {
+	got, err := api.ParseDeprecations([]string{"v1=2026-11-01"})
+	if err != nil || !got[1].Since.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) || !got[1].Sunset.IsZero() {
+		t.Errorf("ParseDeprecations = %+v, %v", got, err)
+	}
+
+	for _, entries := range [][]string{
+		{"v1"},
+		{"v9=2026-11-01"},
+		{"v1=November"},
+		{"v1=2026-11-01/2026-10-01"},
+		{"v1=2026-11-01", "1=2026-12-01"},
+	} {
+		if _, err := api.ParseDeprecations(entries); err == nil {
+			t.Errorf("%q: expected an error", entries)
+		}
+	}
+}
+
+// ─── Compression ──────────────────────────────────────────────────────────────
+
+func TestCompression_GzipsJSONForClientsThatAcceptIt(t *testing.T) {
+	for _, level := range []int{0, 5} {
+		deps := testutil.NewServer(t, func(c *api.Config) { c.CompressionLevel = level })
+
+		plain := deps.Do(t, http.MethodGet, "/api/openapi.json", nil, nil)
+		if plain.Header().Get("Content-Encoding") != "" {
+			t.Errorf("level %d: compressed for a client that did not ask", level)
+		}
+
+		rr := deps.Do(t, http.MethodGet, "/api/openapi.json", nil,
+			map[string]string{"Accept-Encoding": "gzip"})
+		if level == 0 {
+			if rr.Header().Get("Content-Encoding") != "" {
+				t.Errorf("level 0: expected no compression, got %q", rr.Header().Get("Content-Encoding"))
+			}
+			continue
+		}
+		if rr.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("level %d: expected gzip, got %q", level, rr.Header().Get("Content-Encoding"))
+		}
+		zr, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil || !bytes.Equal(body, plain.Body.Bytes()) {
+			t.Errorf("decompressed body differs from the plain one (%v)", err)
+		}
+	}
+}
+
+// ─── Degraded mode ────────────────────────────────────────────────────────────
+
+type stubPinger struct{ err error }
+
+func (p *stubPinger) PingContext(context.Context) error { return p.err }
+
+func TestDegraded_ServesCachedReportsAnd503sTheRest(t *testing.T) {
+	pinger := &stubPinger{}
+	monitor := dbhealth.New(pinger, dbhealth.Config{Failures: 1}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.DBHealth = monitor
+		c.ReportCacheSize = 10
+	})
+	addReadyReport(deps, "tok_cached")
+	sessionID, token := deps.NewSession()
+
+	if rr := deps.Do(t, http.MethodGet, "/api/report/tok_cached", nil, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 while up, got %d", rr.Code)
+	}
+
+	pinger.err = errors.New("connection refused")
+	_ = monitor.Check(context.Background())
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok_cached", nil, nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Served-From") != "cache" {
+		t.Errorf("expected the cached report, got %d %q", rr.Code, rr.Header().Get("X-Served-From"))
+	}
+
+	addReadyReport(deps, "tok_uncached")
+	rr = deps.Do(t, http.MethodGet, "/api/report/tok_uncached", nil, nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for a report never cached, got %d", rr.Code)
+	}
+	rr = deps.Do(t, http.MethodPut, "/api/session/"+sessionID.String()+"/answers",
+		map[string]any{"answers": []map[string]string{{"question_id": "q_x", "answer_text": "yes"}}},
+		map[string]string{"X-Anon-Token": token})
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 503 with Retry-After for a write, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodGet, "/healthz", nil, nil); rr.Code != http.StatusOK {
+		t.Errorf("healthz should not depend on the database, got %d", rr.Code)
+	}
+
+	pinger.err = nil
+	_ = monitor.Check(context.Background())
+	if rr := deps.Do(t, http.MethodGet, "/api/report/tok_uncached", nil, nil); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 after recovery, got %d", rr.Code)
+	}
+}
+
+func TestDegraded_FallsBackToMemoryWhenRedisIsDown(t *testing.T) {
+	pinger := &stubPinger{}
+	monitor := dbhealth.New(pinger, dbhealth.Config{Failures: 1}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	down := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer down.Close()
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.DBHealth = monitor
+		c.ReportCacheSize = 10
+		c.Redis = down
+	})
+	addReadyReport(deps, "tok_cached")
+
+	if rr := deps.Do(t, http.MethodGet, "/api/report/tok_cached", nil, nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 while up, got %d", rr.Code)
+	}
+
+	pinger.err = errors.New("connection refused")
+	_ = monitor.Check(context.Background())
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok_cached", nil, nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Served-From") != "cache" {
+		t.Errorf("expected the report from this replica's memory, got %d %q", rr.Code, rr.Header().Get("X-Served-From"))
+	}
+}
+
+// ─── POST /api/session/:sessionID/checkout ────────────────────────────────────
+
+func TestCreateCheckout_MissingEmailReturns400(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": ""},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateCheckout_StripeErrorReturns500(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	deps.Stripe.CreateErr = errors.New("stripe unavailable")
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateCheckout_UnknownProductReturns400(t *testing.T) {
+	for _, sku := range []string{"nope", "retired"} {
+		deps := testutil.NewServer(t)
+		sessionID, token := deps.NewSession()
+
+		rr := deps.Do(t,
+			http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+			map[string]string{"email": "test@example.com", "sku": sku},
+			map[string]string{"X-Anon-Token": token})
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("sku %q: expected 400, got %d: %s", sku, rr.Code, rr.Body.String())
+		}
+		if len(deps.Stripe.Created) != 0 {
+			t.Errorf("sku %q: expected no PaymentIntent to be created", sku)
+		}
+	}
+}
+
+func TestCreateCheckout_ChargesSelectedProduct(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	deps.Stripe.CreateErr = errors.New("stop after create") // store is nil in tests
+
+	deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com", "sku": "premium"},
+		map[string]string{"X-Anon-Token": token})
+
+	if len(deps.Stripe.Created) != 1 {
+		t.Fatalf("expected one PaymentIntent, got %d", len(deps.Stripe.Created))
+	}
+	if got := deps.Stripe.Created[0]; got.AmountCents != 14900 || got.Metadata["sku"] != "premium" {
+		t.Errorf("expected premium price and sku metadata, got %+v", got)
+	}
+}
+
+func TestPriceExperiment_ChargesAndListsTheSessionsVariant(t *testing.T) {
+	set, err := experiments.New([]string{experiments.Price}, []string{"standard:4900"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := testutil.NewServer(t, func(c *api.Config) { c.Experiments = set })
+	deps.Stripe.CreateErr = errors.New("stop after create") // store is nil in tests
+
+	rr := deps.Do(t, http.MethodPost, "/api/session", map[string]string{}, nil)
+	var created struct {
+		Experiments map[string]string `json:"experiments"`
+	}
+	testutil.DecodeJSON(t, rr, &created)
+	if v := created.Experiments[experiments.Price]; v == "" || len(deps.Q.Assignments) != 1 || deps.Q.Assignments[0].Variant != v {
+		t.Fatalf("expected the new session to be assigned and told its variant, got %v and %+v", created.Experiments, deps.Q.Assignments)
+	}
+
+	for variant, want := range map[string]int32{experiments.Control: 5900, experiments.Alternative: 4900} {
+		sessionID, token := deps.NewSession()
+		deps.Q.Assignments = append(deps.Q.Assignments, db.ExperimentAssignment{SessionID: sessionID, Experiment: experiments.Price, Variant: variant})
+		deps.Stripe.Created = nil
+
+		deps.Do(t,
+			http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+			map[string]string{"email": "test@example.com"},
+			map[string]string{"X-Anon-Token": token})
+		if len(deps.Stripe.Created) != 1 || deps.Stripe.Created[0].AmountCents != int64(want) || deps.Stripe.Created[0].Metadata["price_variant"] != variant {
+			t.Errorf("variant %s: expected a %d charge, got %+v", variant, want, deps.Stripe.Created)
+		}
+
+		rr := deps.Do(t, http.MethodGet, "/api/products?session_id="+sessionID.String(), nil, nil)
+		var list struct {
+			Products []struct {
+				SKU        string `json:"sku"`
+				PriceCents int32  `json:"price_cents"`
+			} `json:"products"`
+		}
+		testutil.DecodeJSON(t, rr, &list)
+		for _, p := range list.Products {
+			if p.SKU == "standard" && p.PriceCents != want {
+				t.Errorf("variant %s: expected standard listed at %d, got %d", variant, want, p.PriceCents)
+			}
+		}
+	}
+}
+
+func TestCreateCheckout_ForwardsRequestIDToStripe(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	deps.Stripe.CreateErr = errors.New("stop after create") // store is nil in tests
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com"},
+		map[string]string{"X-Anon-Token": token, "X-Request-ID": "req-checkout-1"})
+
+	if got := rr.Header().Get("X-Request-ID"); got != "req-checkout-1" {
+		t.Errorf("expected the request ID to be echoed, got %q", got)
+	}
+	if len(deps.Stripe.RequestIDs) != 1 || deps.Stripe.RequestIDs[0] != "req-checkout-1" {
+		t.Errorf("expected Stripe to be called with the request ID, got %v", deps.Stripe.RequestIDs)
+	}
+}
+
+func TestCreateCheckout_FraudBlockReturns403(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		// Thresholds of zero leave only the disposable-email check, which
+		// needs no queries.
+		c.Fraud = fraud.NewChecker(fraud.Config{Mode: fraud.ModeBlock}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	})
+	sessionID, token := deps.NewSession()
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@mailinator.com"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deps.Stripe.Created) != 0 {
+		t.Errorf("expected no PaymentIntent to be created, got %d", len(deps.Stripe.Created))
+	}
+}
+
+func TestCreateCheckout_FraudFlagStillCharges(t *testing.T) {
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.Fraud = fraud.NewChecker(fraud.Config{Mode: fraud.ModeFlag}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	})
+	sessionID, token := deps.NewSession()
+	deps.Stripe.CreateErr = errors.New("stop after create") // store is nil in tests
+
+	deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@mailinator.com"},
+		map[string]string{"X-Anon-Token": token})
+
+	if len(deps.Stripe.Created) != 1 {
+		t.Errorf("expected a flagged checkout to proceed, got %d PaymentIntents", len(deps.Stripe.Created))
+	}
+}
+
+func TestCreateCheckout_DifferentProductAfterPIReturns409(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+
+	sess := deps.Q.SessionsByID[sessionID]
+	sess.StripePaymentIntent = sql.NullString{String: "pi_existing", Valid: true}
+	deps.Q.AddSession(token, sess) // no product_sku: bought before the catalog → standard
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com", "sku": "premium"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateCheckout_SuggestsCorrectedEmailDomain(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+
+	sess := deps.Q.SessionsByID[sessionID]
+	sess.StripePaymentIntent = sql.NullString{String: "pi_existing", Valid: true}
+	deps.Q.AddSession(token, sess)
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "owner@gmial.com"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		ClientSecret    string `json:"client_secret"`
+		EmailSuggestion string `json:"email_suggestion"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.ClientSecret == "" || resp.EmailSuggestion != "owner@gmail.com" {
+		t.Errorf("expected checkout to go ahead with a suggestion, got %+v", resp)
+	}
+}
+
+func TestCreateCheckout_SubscriptionDoesNotCoverPremium(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+	deps.Q.Entitled["sub@example.com"] = db.Subscription{ID: uuid.New(), Status: "active"}
+	deps.Stripe.CreateErr = errors.New("stop after create") // store is nil in tests
+
+	deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "sub@example.com", "sku": "premium"},
+		map[string]string{"X-Anon-Token": token})
+
+	if len(deps.Stripe.Created) != 1 {
+		t.Fatalf("expected premium to be charged, got %d PaymentIntents", len(deps.Stripe.Created))
+	}
+}
+
+func withStripeTax(cfg *api.Config) {
+	cfg.StripeTax = true
+}
+
+func TestCreateCheckout_StripeTaxRequiresBillingCountry(t *testing.T) {
+	deps := testutil.NewServer(t, withStripeTax)
+	sessionID, token := deps.NewSession()
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateCheckout_InvalidBillingCountryReturns400(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID, token := deps.NewSession()
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com", "billing_country": "Germany"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestCreateCheckout_ChargesPricePlusTax(t *testing.T) {
+	deps := testutil.NewServer(t, withStripeTax)
+	sessionID, token := deps.NewSession()
+	deps.Stripe.TaxCalc = stripeinternal.TaxCalculation{ID: "taxcalc_1", AmountTotalCents: 7021, TaxCents: 1121}
+	deps.Stripe.CreateErr = errors.New("stop after create") // store is nil in tests
+
+	deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com", "billing_country": "de"},
+		map[string]string{"X-Anon-Token": token})
+
+	if len(deps.Stripe.TaxRequests) != 1 || deps.Stripe.TaxRequests[0].Country != "DE" {
+		t.Fatalf("expected one tax calculation for DE, got %+v", deps.Stripe.TaxRequests)
+	}
+	if len(deps.Stripe.Created) != 1 {
+		t.Fatalf("expected one PaymentIntent, got %d", len(deps.Stripe.Created))
+	}
+	if got := deps.Stripe.Created[0]; got.AmountCents != 7021 || got.Metadata["tax_calculation"] != "taxcalc_1" {
+		t.Errorf("expected taxed amount and calculation metadata, got %+v", got)
+	}
+}
+
+func TestCreateCheckout_InvalidTaxLocationReturns400(t *testing.T) {
+	deps := testutil.NewServer(t, withStripeTax)
+	sessionID, token := deps.NewSession()
+	deps.Stripe.TaxErr = stripeinternal.ErrInvalidTaxLocation
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com", "billing_country": "US", "billing_postal_code": "00000"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deps.Stripe.Created) != 0 {
+		t.Error("expected no PaymentIntent to be created")
+	}
+}
+
+func TestCreateCheckout_DifferentCountryAfterPIReturns409(t *testing.T) {
+	deps := testutil.NewServer(t, withStripeTax)
+	sessionID, token := deps.NewSession()
+
+	sess := deps.Q.SessionsByID[sessionID]
+	sess.StripePaymentIntent = sql.NullString{String: "pi_existing", Valid: true}
+	sess.BillingCountry = sql.NullString{String: "DE", Valid: true}
+	deps.Q.AddSession(token, sess)
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
+		map[string]string{"email": "test@example.com", "billing_country": "FR"},
+		map[string]string{"X-Anon-Token": token})
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+// ─── GET /api/products ────────────────────────────────────────────────────────
+
+func TestListProducts_ReturnsActiveOnly(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/api/products", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var resp struct {
+		Products []struct {
+			SKU string `json:"sku"`
+		} `json:"products"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Products) != 2 {
+		t.Errorf("expected 2 active products, got %+v", resp.Products)
+	}
+	for _, p := range resp.Products {
+		if p.SKU == "retired" {
+			t.Error("inactive product should not be listed")
+		}
+	}
+}
+
+func TestListProducts_V2PricesAreMoneyObjects(t *testing.T) {
+	deps := testutil.NewServer(t)
+
+	type product struct {
+		SKU        string `json:"sku"`
+		PriceCents *int32 `json:"price_cents"`
+		Price      *struct {
+			AmountCents int32  `json:"amount_cents"`
+			Currency    string `json:"currency"`
+		} `json:"price"`
+	}
+	decode := func(rr *httptest.ResponseRecorder) map[string]product {
+		var resp struct {
+			Products []product `json:"products"`
+		}
+		testutil.DecodeJSON(t, rr, &resp)
+		out := make(map[string]product, len(resp.Products))
+		for _, p := range resp.Products {
+			out[p.SKU] = p
+		}
+		return out
+	}
+
+	v1 := decode(deps.Do(t, http.MethodGet, "/api/v1/products", nil, nil))
+	for _, rr := range []*httptest.ResponseRecorder{
+		deps.Do(t, http.MethodGet, "/api/v2/products", nil, nil),
+		deps.Do(t, http.MethodGet, "/api/products", nil, map[string]string{"API-Version": "2"}),
+	} {
+		if got := rr.Header().Get("API-Version"); got != "2" {
+			t.Errorf("expected API-Version 2, got %q", got)
+		}
+		v2 := decode(rr)
+		if len(v2) != len(v1) || len(v2) == 0 {
+			t.Fatalf("expected the same products in v1 and v2, got %d and %d", len(v1), len(v2))
+		}
+		for sku, p := range v2 {
+			old := v1[sku]
+			if p.PriceCents != nil || p.Price == nil {
+				t.Fatalf("%s: expected a price object and no price_cents in v2, got %+v", sku, p)
+			}
+			if old.PriceCents == nil || p.Price.AmountCents != *old.PriceCents || p.Price.Currency == "" {
+				t.Errorf("%s: v2 price %+v does not match v1 price_cents %v", sku, *p.Price, old.PriceCents)
+			}
+		}
+	}
+}
+
+// ─── POST /api/webhooks/resend ────────────────────────────────────────────────
+
+// resendWebhookRequest signs body the way Resend (Svix) does.
+func resendWebhookRequest(secretKey []byte, body string) *http.Request {
+	ts := fmt.Sprint(time.Now().Unix())
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte("msg_1." + ts + "." + body))
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks/resend", strings.NewReader(body))
+	req.Header.Set("svix-id", "msg_1")
+	req.Header.Set("svix-timestamp", ts)
+	req.Header.Set("svix-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestResendWebhook_RecordsOpensClicksAndBounces(t *testing.T) {
+	key := []byte("resend-key")
+	deps := testutil.NewServer(t, func(c *api.Config) {
+		c.ResendWebhookSecret = "whsec_" + base64.StdEncoding.EncodeToString(key)
+	})
+	deps.Q.EmailLog["re_open"] = &db.EmailLog{}
+	deps.Q.EmailLog["re_click"] = &db.EmailLog{}
+	bouncedSession := uuid.New()
+	deps.Q.SessionsByID[bouncedSession] = db.Session{ID: bouncedSession, EmailHash: sql.NullString{String: "hash_bounced", Valid: true}}
+	deps.Q.EmailLog["re_bounce"] = &db.EmailLog{SessionID: uuid.NullUUID{UUID: bouncedSession, Valid: true}}
+
+	for _, body := range []string{
+		`{"type":"email.opened","data":{"email_id":"re_open"}}`,
+		`{"type":"email.clicked","data":{"email_id":"re_click"}}`,
+		`{"type":"email.bounced","data":{"email_id":"re_bounce","bounce":{"message":"mailbox full"}}}`,
+		`{"type":"email.opened","data":{"email_id":"re_unknown"}}`,
+		`{"type":"email.delivered","data":{"email_id":"re_open"}}`,
+	} {
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, resendWebhookRequest(key, body))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+
+	if !deps.Q.EmailLog["re_open"].OpenedAt.Valid {
+		t.Error("expected the open recorded")
+	}
+	if e := deps.Q.EmailLog["re_click"]; !e.ClickedAt.Valid || !e.OpenedAt.Valid {
+		t.Error("expected the click recorded as an open too")
+	}
+	if e := deps.Q.EmailLog["re_bounce"]; !e.BouncedAt.Valid || e.Error.String != "bounced: mailbox full" {
+		t.Errorf("expected the bounce recorded, got %+v", e)
+	}
+	if reason := deps.Q.Suppressed["hash_bounced"]; reason != "bounced" {
+		t.Errorf("expected the bounced address suppressed, got %q", reason)
+	}
+
+	rr := httptest.NewRecorder()
+	deps.Handler.ServeHTTP(rr, resendWebhookRequest([]byte("forged"), `{"type":"email.opened","data":{"email_id":"re_bounce"}}`))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad signature, got %d", rr.Code)
+	}
+}
+
+func TestResendWebhook_NotMountedWithoutSecret(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := httptest.NewRecorder()
+	deps.Handler.ServeHTTP(rr, resendWebhookRequest([]byte("k"), `{}`))
+	if rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected the route to be absent, got %d", rr.Code)
+	}
+}
+
+// ─── POST /api/webhooks/stripe ────────────────────────────────────────────────
+
+func TestStripeWebhook_InvalidSignatureReturns400(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Stripe.VerifyErr = errors.New("invalid signature")
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/webhooks/stripe",
+		map[string]string{"type": "payment_intent.succeeded"}, nil)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestStripeWebhook_UnknownEventTypeReturns200(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Stripe.VerifyErr = nil
+	deps.Stripe.VerifyEvent = stripeinternal.Event{
+		ID:   "evt_test_unknown",
+		Type: "customer.created", // not handled
+	}
+
+	rr := deps.Do(t,
+		http.MethodPost, "/api/webhooks/stripe",
+		[]byte(`{}`), nil)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for unknown event type, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestStripeWebhook_SubscriptionUpdatedIsMirrored(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Stripe.VerifyEvent = stripeinternal.Event{
+		ID:   "evt_sub",
+		Type: "customer.subscription.updated",
+		DataRaw: json.RawMessage(`{"id":"sub_1","customer":"cus_1","status":"past_due",` +
+			`"items":{"data":[{"current_period_start":1700000000,"current_period_end":1707776000}]}}`),
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/api/webhooks/stripe", []byte(`{}`), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deps.Q.Subscriptions) != 1 {
+		t.Fatalf("expected one subscription upsert, got %d", len(deps.Q.Subscriptions))
+	}
+	got := deps.Q.Subscriptions[0]
+	if got.StripeSubscriptionID != "sub_1" || got.Status.String != "past_due" || !got.CurrentPeriodEnd.Valid {
+		t.Errorf("unexpected upsert: %+v", got)
+	}
+}
+
+// stubEventQueue records the events the webhook hands off and the handler
+// the server registers.
+type stubEventQueue struct {
+	handler  worker.StripeEventHandler
+	enqueued []string
+}
+
+func (q *stubEventQueue) SetHandler(h worker.StripeEventHandler) { q.handler = h }
+
+func (q *stubEventQueue) EnqueueStripeEvent(_ context.Context, id string) error {
+	q.enqueued = append(q.enqueued, id)
+	return nil
+}
+
+func TestStripeWebhook_HandsOffToWorkerWhenQueued(t *testing.T) {
+	queue := &stubEventQueue{}
+	deps := testutil.NewServer(t, func(c *api.Config) { c.StripeEvents = queue })
+	object := json.RawMessage(`{"id":"sub_1","customer":"cus_1","status":"active",` +
+		`"items":{"data":[{"current_period_start":1700000000,"current_period_end":1707776000}]}}`)
+	deps.Stripe.VerifyEvent = stripeinternal.Event{ID: "evt_sub", Type: "customer.subscription.updated", DataRaw: object}
+
+	rr := deps.Do(t, http.MethodPost, "/api/webhooks/stripe", []byte(`{}`), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(queue.enqueued) != 1 || queue.enqueued[0] != "evt_sub" {
+		t.Fatalf("enqueued = %v, want [evt_sub]", queue.enqueued)
+	}
+	if len(deps.Q.Subscriptions) != 0 {
+		t.Fatalf("webhook dispatched the event itself: %d subscription upserts", len(deps.Q.Subscriptions))
+	}
+
+	// The worker later runs the stored event through the server.
+	if queue.handler == nil {
+		t.Fatal("server did not register itself as the queue's handler")
+	}
+	payload, _ := json.Marshal(map[string]any{"id": "evt_sub", "type": "customer.subscription.updated",
+		"data": map[string]any{"object": object}})
+	stored := db.StripeEvent{StripeEventID: "evt_sub", Type: "customer.subscription.updated", Payload: payload}
+	if err := queue.handler.HandleStripeEvent(context.Background(), stored); err != nil {
+		t.Fatalf("HandleStripeEvent: %v", err)
+	}
+	if len(deps.Q.Subscriptions) != 1 || deps.Q.Subscriptions[0].StripeSubscriptionID != "sub_1" {
+		t.Errorf("subscription upserts = %+v, want one for sub_1", deps.Q.Subscriptions)
+	}
+}
+
+func TestStripeWebhook_InvoicePaidWithoutSubscriptionIgnored(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Stripe.VerifyEvent = stripeinternal.Event{
+		ID:      "evt_inv",
+		Type:    "invoice.paid",
+		DataRaw: json.RawMessage(`{"id":"in_1","customer":"cus_1","customer_email":"a@example.com"}`),
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/api/webhooks/stripe", []byte(`{}`), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deps.Q.Subscriptions) != 0 {
+		t.Errorf("one-off invoice should not create a subscription")
+	}
+}
+
+func TestStripeWebhook_ChargeSucceededRecordsFees(t *testing.T) {
+	deps := testutil.NewServer(t)
+	sessionID := uuid.New()
+	deps.Q.AddSession("tok", db.Session{
+		ID:                  sessionID,
+		AnonToken:           "tok",
+		StripePaymentIntent: sql.NullString{String: "pi_1", Valid: true},
+	})
+	deps.Stripe.VerifyEvent = stripeinternal.Event{
+		ID:      "evt_ch",
+		Type:    "charge.succeeded",
+		DataRaw: json.RawMessage(`{"id":"ch_1","payment_intent":"pi_1","status":"succeeded","balance_transaction":"txn_1"}`),
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/api/webhooks/stripe", []byte(`{}`), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deps.Q.Payments) != 1 {
+		t.Fatalf("expected one payment upsert, got %d", len(deps.Q.Payments))
+	}
+	got := deps.Q.Payments[0]
+	if got.StripeChargeID != "ch_1" || got.StripeBalanceTransactionID != "txn_1" || got.FeeCents != 201 || got.NetCents != 5699 {
+		t.Errorf("unexpected upsert: %+v", got)
+	}
+	if got.SessionID.UUID != sessionID {
+		t.Errorf("expected payment linked to session %s, got %+v", sessionID, got.SessionID)
+	}
+}
+
+func TestStripeWebhook_ChargeWithoutBalanceTransactionWaits(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Stripe.VerifyEvent = stripeinternal.Event{
+		ID:      "evt_ch",
+		Type:    "charge.succeeded",
+		DataRaw: json.RawMessage(`{"id":"ch_1","payment_intent":"pi_1","status":"succeeded","balance_transaction":null}`),
+	}
+
+	rr := deps.Do(t, http.MethodPost, "/api/webhooks/stripe", []byte(`{}`), nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deps.Q.Payments) != 0 {
+		t.Errorf("expected no payment until the balance transaction exists, got %+v", deps.Q.Payments)
+	}
+}
+
+// ─── GET /api/report/:accessToken/invoice ─────────────────────────────────────
+
+func paidInvoiceRow() db.GetInvoiceByAccessTokenRow {
+	return db.GetInvoiceByAccessTokenRow{
+		ReportID:            uuid.New(),
+		SessionID:           uuid.New(),
+		PaymentStatus:       db.PaymentStatusPaid,
+		PaidAt:              sql.NullTime{Time: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		StripePaymentIntent: sql.NullString{String: "pi_123", Valid: true},
+		BizName:             sql.NullString{String: "Acme", Valid: true},
+		BillingCountry:      sql.NullString{String: "DE", Valid: true},
+		BillingTaxID:        sql.NullString{String: "DE123456789", Valid: true},
+		SubtotalCents:       5900,
+		TaxCents:            1121,
+		ProductName:         "Risk Report",
+		Currency:            "eur",
+	}
+}
+
+func TestInvoice_UnknownTokenReturns404(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/api/report/nope/invoice", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestInvoice_SubscriptionReportHasNoInvoice(t *testing.T) {
+	deps := testutil.NewServer(t)
+	row := paidInvoiceRow()
+	row.SubscriptionID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	deps.Q.Invoices["tok"] = row
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok/invoice", nil, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestInvoice_ReturnsPDF(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Q.Invoices["tok"] = paidInvoiceRow()
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok/invoice", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("expected application/pdf, got %q", ct)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); !strings.Contains(cd, "INV-001000.pdf") {
+		t.Errorf("expected assigned invoice number in filename, got %q", cd)
+	}
+	for _, want := range []string{"%PDF-", "Tax ID: DE123456789", "EUR 70.21"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("invoice does not contain %q", want)
+		}
+	}
+}
+
+// ─── GET /api/admin/config ────────────────────────────────────────────────────
+
+func withAdminKey(cfg *api.Config) {
+	cfg.AdminAPIKey = "admin_test_key"
+	cfg.ConfigReport = map[string]string{"ENV": "development", "STRIPE_SECRET_KEY": "****1234"}
+}
+
+func TestAdminConfig_NotMountedWithoutKey(t *testing.T) {
+	deps := testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/api/admin/config", nil,
+		map[string]string{"Authorization": "Bearer "})
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestAdminConfig_WrongKeyReturns401(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	rr := deps.Do(t, http.MethodGet, "/api/admin/config", nil,
+		map[string]string{"Authorization": "Bearer nope"})
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestAdminConfig_ReturnsRedactedReport(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	rr := deps.Do(t, http.MethodGet, "/api/admin/config", nil,
+		map[string]string{"Authorization": "Bearer admin_test_key"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Config map[string]string `json:"config"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Config["STRIPE_SECRET_KEY"] != "****1234" {
+		t.Errorf("unexpected config report: %v", resp.Config)
+	}
+}
+
+func TestAdminRevokeReport_ClosesReportLinks(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey, withConsultation)
+	id := addReadyReport(deps, "tok_revoke")
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	path := "/api/admin/reports/" + id.String()
+
+	rr := deps.Do(t, http.MethodDelete, path, map[string]string{"reason": " "}, auth)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a reason, got %d", rr.Code)
+	}
+
+	rr = deps.Do(t, http.MethodDelete, path, map[string]string{"reason": "customer deletion request"}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var first map[string]string
+	testutil.DecodeJSON(t, rr, &first)
+	if first["report_id"] != id.String() || first["reason"] != "customer deletion request" || first["revoked_at"] == "" {
+		t.Errorf("unexpected response: %v", first)
+	}
+
+	for _, link := range []struct{ method, path string }{
+		{http.MethodGet, "/api/report/tok_revoke"},
+		{http.MethodPost, "/api/report/tok_revoke/consultation"},
+	} {
+		if rr := deps.Do(t, link.method, link.path, nil, nil); rr.Code != http.StatusGone {
+			t.Errorf("%s %s: expected 410, got %d", link.method, link.path, rr.Code)
+		}
+	}
+
+	// Revoking again is a no-op that returns the original revocation.
+	rr = deps.Do(t, http.MethodDelete, path, map[string]string{"reason": "fraud"}, auth)
+	var again map[string]string
+	testutil.DecodeJSON(t, rr, &again)
+	if rr.Code != http.StatusOK || again["reason"] != "customer deletion request" || again["revoked_at"] != first["revoked_at"] {
+		t.Errorf("expected the original revocation, got %d %v", rr.Code, again)
+	}
+
+	rr = deps.Do(t, http.MethodDelete, "/api/admin/reports/"+uuid.NewString(), map[string]string{"reason": "fraud"}, auth)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown report, got %d", rr.Code)
+	}
+}
+
+func TestAdminResolveDuplicate_RejectsUnknownActionsAndSessions(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	path := "/api/admin/duplicates/" + uuid.NewString() + "/resolve"
+
+	rr := deps.Do(t, http.MethodPost, path, map[string]string{"action": "delete"}, auth)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown action, got %d", rr.Code)
+	}
+	rr = deps.Do(t, http.MethodPost, path, map[string]string{"action": "refund"}, auth)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a session never held, got %d", rr.Code)
+	}
+	if len(deps.Stripe.Refunds) != 0 {
+		t.Errorf("expected no refund, got %v", deps.Stripe.Refunds)
+	}
+}
+
+func TestAdminImportCohort_ValidatesEveryRowBeforeWriting(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey, func(c *api.Config) { c.StrictAnswers = true })
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Authorization", "Bearer admin_test_key")
+		rr := httptest.NewRecorder()
+		deps.Handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, tc := range []struct{ name, path, body, want string }{
+		{"bad cohort", "/api/admin/cohorts/Acme%20Q4", "email,q_x\na@example.com,x\n", "cohort must be"},
+		{"unknown column", "/api/admin/cohorts/acme", "email,q_nope\na@example.com,x\n", "unknown column"},
+		{"no email column", "/api/admin/cohorts/acme", "biz_name,q_x\nAcme,x\n", "email column"},
+		{"bad email", "/api/admin/cohorts/acme", "email,q_x\na@example.com,x\nnobody,x\n", "row 2: a valid email"},
+		{"bad option", "/api/admin/cohorts/acme", "email,q_key_person\na@example.com,Maybe\n", "is not one of its options"},
+		{"unknown product", "/api/admin/cohorts/acme", "email,product_sku,q_x\na@example.com,gold,x\n", "row 1: unknown product_sku"},
+		{"no rows", "/api/admin/cohorts/acme", "email,q_x\n", "no rows"},
+	} {
+		rr := post(tc.path, tc.body)
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), tc.want) {
+			t.Errorf("%s: expected 400 mentioning %q, got %d: %s", tc.name, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+
+	// A valid import with dry_run writes nothing; the store is not even set.
+	rr := post("/api/admin/cohorts/acme?dry_run=true",
+		"Email,Biz_Name,product_sku,q_x,q_key_person\na@example.com,Acme,premium,some text,Yes\nb@example.com,,,,No\n")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a dry run, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Cohort string `json:"cohort"`
+		DryRun bool   `json:"dry_run"`
+		Rows   int    `json:"rows"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Cohort != "acme" || !resp.DryRun || resp.Rows != 2 {
+		t.Errorf("unexpected dry run response: %+v", resp)
+	}
+}
+
+func TestGetReport_HeldDuplicateReturns202Held(t *testing.T) {
+	deps := testutil.NewServer(t)
+	deps.Q.Reports["tok_held"] = db.GetReportByAccessTokenRow{
+		ID:     uuid.New(),
+		Status: db.ReportStatusDraft,
+		HeldAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok_held", nil, nil)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+	var resp map[string]string
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp["status"] != "held" {
+		t.Errorf("expected status held, got %v", resp)
+	}
+}
+
+// ─── POST /api/report/:accessToken/consultation ───────────────────────────────
+
+func withConsultation(cfg *api.Config) {
+	cfg.ConsultationURL = "https://cal.example.com/advisor"
+}
+
+func addReadyReport(deps *testutil.Server, token string) uuid.UUID {
+	r := testutil.Report(func(r *db.GetReportByAccessTokenRow) { r.AccessToken = token })
+	deps.Q.Reports[token] = r
+	return r.ID
+}
+
+func TestConsultation_DisabledReturns404(t *testing.T) {
+	deps := testutil.NewServer(t)
+	addReadyReport(deps, "tok")
+
+	rr := deps.Do(t, http.MethodPost, "/api/report/tok/consultation", map[string]string{}, nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestConsultation_RecordsInterestAndReturnsLink(t *testing.T) {
+	deps := testutil.NewServer(t, withConsultation)
+	reportID := addReadyReport(deps, "tok")
+
+	rr := deps.Do(t, http.MethodPost, "/api/report/tok/consultation",
+		map[string]string{"note": "Mostly worried about supplier risk"}, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		BookingURL string `json:"booking_url"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.BookingURL != "https://cal.example.com/advisor" {
+		t.Errorf("unexpected booking_url %q", resp.BookingURL)
+	}
+	if got := deps.Q.Consultations[reportID]; got.Note.String != "Mostly worried about supplier risk" {
+		t.Errorf("expected consultation request to be recorded, got %+v", got)
+	}
+}
+
+func TestConsultation_ReportNotReadyReturns409(t *testing.T) {
+	deps := testutil.NewServer(t, withConsultation)
+	deps.Q.Reports["tok"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusProcessing}
+
+	rr := deps.Do(t, http.MethodPost, "/api/report/tok/consultation", nil, nil)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rr.Code)
+	}
+}
+
+func TestGetReport_IncludesConsultationURLWhenEnabled(t *testing.T) {
+	deps := testutil.NewServer(t, withConsultation)
+	addReadyReport(deps, "tok")
+
+	rr := deps.Do(t, http.MethodGet, "/api/report/tok", nil, nil)
+	var resp struct {
+		ConsultationURL string `json:"consultation_url"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.ConsultationURL != "https://cal.example.com/advisor" {
+		t.Errorf("expected consultation_url in report payload, got %q", resp.ConsultationURL)
+	}
+}
+
+func TestAdminStats_ReportsConsultationConversion(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey, withConsultation)
+	addReadyReport(deps, "tok")
+	deps.Do(t, http.MethodPost, "/api/report/tok/consultation", nil, nil)
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/stats", nil,
+		map[string]string{"Authorization": "Bearer admin_test_key"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Consultations struct {
+			Requests       int64   `json:"requests"`
+			ConversionRate float64 `json:"conversion_rate"`
+		} `json:"consultations"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Consultations.Requests != 1 || resp.Consultations.ConversionRate != 0.25 {
+		t.Errorf("expected 1 request at 25%% conversion, got %+v", resp.Consultations)
+	}
+}
+
+// ─── GET /api/admin/exports/payments ──────────────────────────────────────────
+
+func addStripeEvent(deps *testutil.Server, id, typ string, at time.Time, object map[string]any) {
+	payload, _ := json.Marshal(map[string]any{"id": id, "type": typ, "data": map[string]any{"object": object}})
+	deps.Q.StripeEvents = append(deps.Q.StripeEvents, db.StripeEvent{
+		StripeEventID: id,
+		Type:          typ,
+		Payload:       payload,
+		ReceivedAt:    at,
+	})
+}
+
+func TestExportPayments_RequiresValidRange(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+
+	for _, query := range []string{"", "?from=2026-03-01", "?from=2026-03-01&to=March", "?from=2026-03-31&to=2026-03-01", "?from=2024-01-01&to=2026-01-01"} {
+		rr := deps.Do(t, http.MethodGet, "/api/admin/exports/payments"+query, nil, auth)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestExportPayments_WritesPaymentsRefundsAndDisputes(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	deps.Q.AddSession("tok", db.Session{
+		ID:                  uuid.New(),
+		AnonToken:           "tok",
+		Email:               sql.NullString{String: "buyer@example.com", Valid: true},
+		ProductSku:          sql.NullString{String: "standard", Valid: true},
+		StripePaymentIntent: sql.NullString{String: "pi_1", Valid: true},
+		TaxCents:            sql.NullInt32{Int32: 590, Valid: true},
+	})
+
+	deps.Q.Payments = append(deps.Q.Payments, db.UpsertPaymentParams{
+		StripeChargeID:      "ch_1",
+		StripePaymentIntent: sql.NullString{String: "pi_1", Valid: true},
+		AmountCents:         6490,
+		FeeCents:            218,
+		NetCents:            6272,
+		Currency:            "usd",
+	})
+
+	day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	// Refunded 1000 in February; the March event's running total of 2500
+	// should be exported as a 15.00 refund.
+	addStripeEvent(deps, "evt_old", "charge.refunded", day.AddDate(0, -1, 0),
+		map[string]any{"id": "ch_1", "payment_intent": "pi_1", "amount_refunded": 1000, "currency": "usd"})
+	addStripeEvent(deps, "evt_pay", "payment_intent.succeeded", day,
+		map[string]any{"id": "pi_1", "amount_received": 6490, "currency": "usd", "latest_charge": "ch_1"})
+	addStripeEvent(deps, "evt_ref", "charge.refunded", day.Add(time.Hour),
+		map[string]any{"id": "ch_1", "payment_intent": "pi_1", "amount_refunded": 2500, "currency": "usd"})
+	addStripeEvent(deps, "evt_dsp", "charge.dispute.created", day.Add(2*time.Hour),
+		map[string]any{"id": "dp_1", "charge": "ch_1", "payment_intent": "pi_1", "amount": 3990, "currency": "usd", "status": "needs_response",
+			"balance_transactions": []map[string]any{{"amount": -3990, "fee": 1500, "net": -5490}}})
+	addStripeEvent(deps, "evt_lost", "charge.dispute.closed", day.Add(3*time.Hour),
+		map[string]any{"id": "dp_1", "payment_intent": "pi_1", "amount": 3990, "currency": "usd", "status": "lost"})
+	addStripeEvent(deps, "evt_april", "payment_intent.succeeded", day.AddDate(0, 1, 0),
+		map[string]any{"id": "pi_2", "amount_received": 5900, "currency": "usd"})
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/exports/payments?from=2026-03-01&to=2026-03-31", nil,
+		map[string]string{"Authorization": "Bearer admin_test_key"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv, got %q", ct)
+	}
+
+	want := "date,type,stripe_event_id,payment_intent,reference,email,sku,currency,amount,tax,fee,net,status\n" +
+		"2026-03-10T12:00:00Z,payment,evt_pay,pi_1,ch_1,buyer@example.com,standard,usd,64.90,5.90,2.18,62.72,succeeded\n" +
+		"2026-03-10T13:00:00Z,refund,evt_ref,pi_1,ch_1,buyer@example.com,standard,usd,-15.00,,,,refunded\n" +
+		"2026-03-10T14:00:00Z,dispute,evt_dsp,pi_1,dp_1,buyer@example.com,standard,usd,-39.90,,15.00,-54.90,needs_response\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// stubStorage keeps uploaded artifacts in memory.
+type stubStorage struct {
+	objects map[string][]byte
+}
+
+func (s *stubStorage) Put(_ context.Context, key, _ string, body []byte) error {
+	if s.objects == nil {
+		s.objects = map[string][]byte{}
+	}
+	s.objects[key] = body
+	return nil
+}
+
+func (s *stubStorage) Get(_ context.Context, key string) ([]byte, error) {
+	if body, ok := s.objects[key]; ok {
+		return body, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (s *stubStorage) Delete(_ context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *stubStorage) SignedURL(key, _ string) (string, time.Time) {
+	return "https://bucket.example/" + key + "?sig", time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+}
+
+func TestExportPayments_LinkUploadsTheCSV(t *testing.T) {
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	const path = "/api/admin/exports/payments?from=2026-03-01&to=2026-03-31&link=true"
+
+	deps := testutil.NewServer(t, withAdminKey)
+	if rr := deps.Do(t, http.MethodGet, path, nil, auth); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without object storage, got %d", rr.Code)
+	}
+
+	bucket := &stubStorage{}
+	deps = testutil.NewServer(t, withAdminKey, func(c *api.Config) { c.Storage = bucket })
+	rr := deps.Do(t, http.MethodGet, path, nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		URL       string `json:"url"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(bucket.objects) != 1 {
+		t.Fatalf("expected one upload, got %d", len(bucket.objects))
+	}
+	for key, body := range bucket.objects {
+		if !strings.HasPrefix(key, "exports/") || !strings.HasSuffix(key, "/payments_2026-03-01_2026-03-31.csv") {
+			t.Errorf("unexpected key %q", key)
+		}
+		if !strings.HasPrefix(string(body), "date,type,stripe_event_id,") {
+			t.Errorf("unexpected upload %q", body)
+		}
+		if resp.URL != "https://bucket.example/"+key+"?sig" || resp.ExpiresAt != "2026-03-31T12:00:00Z" {
+			t.Errorf("unexpected link %+v", resp)
+		}
+	}
+}
+
+func TestAdminTranscripts_InlineAndSignedURL(t *testing.T) {
+	bucket := &stubStorage{}
+	deps := testutil.NewServer(t, withAdminKey, func(c *api.Config) { c.Storage = bucket })
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	reportID := uuid.New()
+	deps.Q.Reports["tok"] = db.GetReportByAccessTokenRow{ID: reportID, AccessToken: "tok"}
+	at := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	deps.Q.Transcripts = []db.AiTranscript{
+		{ID: uuid.New(), ReportID: reportID, Exchanges: 2, StorageKey: sql.NullString{String: "transcripts/r/1.json", Valid: true}, CreatedAt: at},
+		{ID: uuid.New(), ReportID: reportID, Exchanges: 1, Body: pqtype.NullRawMessage{RawMessage: json.RawMessage(`[{"provider":"anthropic"}]`), Valid: true}, CreatedAt: at},
+		{ID: uuid.New(), ReportID: uuid.New(), Exchanges: 1, CreatedAt: at},
 	}
-	if resp.CriticalCount != 2 {
-		t.Errorf("critical_count: got %d", resp.CriticalCount)
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/reports/"+reportID.String()+"/transcripts", nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
 	}
-	if len(resp.Risks) != 1 {
-		t.Fatalf("expected 1 risk, got %d", len(resp.Risks))
+	var resp struct {
+		Transcripts []struct {
+			Exchanges int32           `json:"exchanges"`
+			Body      json.RawMessage `json:"body"`
+			URL       string          `json:"url"`
+		} `json:"transcripts"`
 	}
-	if resp.Risks[0].QuestionID != "q_cash_runway" {
-		t.Errorf("risk question_id: got %q", resp.Risks[0].QuestionID)
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Transcripts) != 2 {
+		t.Fatalf("expected the report's 2 transcripts, got %+v", resp.Transcripts)
 	}
-	if resp.Risks[0].Score != 81 {
-		t.Errorf("risk score: got %d", resp.Risks[0].Score)
+	if got := resp.Transcripts[0]; got.URL != "https://bucket.example/transcripts/r/1.json?sig" || got.Body != nil {
+		t.Errorf("expected a stored transcript as a signed URL, got %+v", got)
+	}
+	if got := resp.Transcripts[1]; string(got.Body) != `[{"provider":"anthropic"}]` || got.URL != "" {
+		t.Errorf("expected an inline transcript as its body, got %+v", got)
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/api/admin/reports/"+uuid.NewString()+"/transcripts", nil, auth)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown report, got %d", rr.Code)
 	}
 }
 
-func TestGetReport_ReadyUsesAIHedgeWhenAvailable(t *testing.T) {
-	deps := newTestServer(t)
-	token := "ready_ai_hedge_token"
-	reportID := uuid.New()
-	deps.q.reports[token] = db.GetReportByAccessTokenRow{
-		ID:     reportID,
-		Status: db.ReportStatusReady,
+func TestExportResearch_SuppressesSmallCells(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	at := sql.NullTime{Time: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), Valid: true}
+	add := func(industry string, score int16, tier db.RiskTier) {
+		id := uuid.New()
+		deps.Q.Reports[id.String()] = db.GetReportByAccessTokenRow{
+			ID:           id,
+			Status:       db.ReportStatusReady,
+			GeneratedAt:  at,
+			Industry:     sql.NullString{String: industry, Valid: true},
+			Stage:        sql.NullString{String: "Established", Valid: true},
+			BizName:      sql.NullString{String: "Identifiable Ltd", Valid: true},
+			Email:        sql.NullString{String: "owner@example.com", Valid: true},
+			OverallScore: sql.NullInt16{Int16: score, Valid: true},
+		}
+		deps.Q.RiskResults[id] = []db.RiskResult{{QuestionID: "q_cash_runway", Tier: tier}}
 	}
-	deps.q.riskResults[reportID] = []db.RiskResult{
-		{
-			Rank:       1,
-			QuestionID: "q_cash_runway",
-			RiskName:   "Cash Runway Risk",
-			Hedge:      "Static hedge",
-			AiHedge:    sql.NullString{String: "AI-generated hedge", Valid: true},
-			Tier:       db.RiskTierWatch,
-		},
+	for i := 0; i < 5; i++ {
+		add("Retail", int16(40+i), db.RiskTierWatch)
 	}
+	add("Mining", 90, db.RiskTierRed) // a segment of one
 
-	rr := doRequest(t, deps.handler, http.MethodGet, "/api/report/"+token, nil, nil)
+	rr := deps.Do(t, http.MethodGet, "/api/admin/exports/research?from=2026-03-01&to=2026-03-31", nil, auth)
 	if rr.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rr.Code)
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for _, leak := range []string{"Mining", "Identifiable", "owner@example.com"} {
+		if strings.Contains(rr.Body.String(), leak) {
+			t.Errorf("export contains %q: %s", leak, rr.Body.String())
+		}
+	}
+	var resp struct {
+		Reports           int `json:"reports"`
+		SuppressedReports int `json:"suppressed_reports"`
+		Segments          []struct {
+			Industry         string  `json:"industry"`
+			Reports          int     `json:"reports"`
+			MeanOverallScore float64 `json:"mean_overall_score"`
+		} `json:"segments"`
+		Questions []struct {
+			QuestionID string          `json:"question_id"`
+			Tiers      map[string]*int `json:"tiers"`
+		} `json:"questions"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+
+	if resp.Reports != 6 || resp.SuppressedReports != 1 || len(resp.Segments) != 1 || resp.Segments[0].MeanOverallScore != 42 {
+		t.Errorf("unexpected segments: %+v", resp)
+	}
+	if len(resp.Questions) != 1 {
+		t.Fatalf("expected one question, got %+v", resp.Questions)
+	}
+	if tiers := resp.Questions[0].Tiers; tiers["watch"] == nil || *tiers["watch"] != 5 || tiers["red"] != nil {
+		t.Errorf("expected watch=5 and red suppressed, got %+v", tiers)
+	}
+
+	if rr := deps.Do(t, http.MethodGet, "/api/admin/exports/research?from=2026-03-01&to=2026-03-31&k=2", nil, auth); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for k below the minimum, got %d", rr.Code)
+	}
+}
+
+func TestAdminPlaybooks_PutListDelete(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+
+	rr := deps.Do(t, http.MethodPut, "/api/admin/playbooks/retail-pci", map[string]any{
+		"industry": "Retail",
+		"title":    "Card data",
+		"body":     "Merchants taking cards must meet PCI DSS; a breach brings fines per record.",
+		"keywords": []string{" Payment ", "", "BREACH"},
+	}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	got := deps.Q.Playbooks["retail-pci"]
+	if !got.Active || strings.Join(got.Keywords, ",") != "payment,breach" {
+		t.Errorf("expected an active snippet with normalised keywords, got %+v", got)
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/api/admin/playbooks", nil, auth)
+	var list struct {
+		Snippets []db.PlaybookSnippet `json:"snippets"`
+	}
+	testutil.DecodeJSON(t, rr, &list)
+	if len(list.Snippets) != 1 || list.Snippets[0].Slug != "retail-pci" {
+		t.Errorf("expected the stored snippet, got %+v", list.Snippets)
+	}
+
+	if rr := deps.Do(t, http.MethodDelete, "/api/admin/playbooks/retail-pci", nil, auth); rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodDelete, "/api/admin/playbooks/retail-pci", nil, auth); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a deleted snippet, got %d", rr.Code)
+	}
+}
+
+func TestAdminPlaybooks_Validation(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+
+	cases := map[string]map[string]any{
+		"no industry": {"title": "t", "body": "b"},
+		"no title":    {"industry": "Retail", "body": "b"},
+		"no body":     {"industry": "Retail", "title": "t", "body": "  "},
+		"long body":   {"industry": "Retail", "title": "t", "body": strings.Repeat("x", 1001)},
+	}
+	for name, body := range cases {
+		if rr := deps.Do(t, http.MethodPut, "/api/admin/playbooks/s", body, auth); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rr.Code)
+		}
+	}
+	if len(deps.Q.Playbooks) != 0 {
+		t.Errorf("expected nothing stored, got %+v", deps.Q.Playbooks)
+	}
+}
+
+func TestAdminFlags_PutListDelete(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+
+	for name, body := range map[string]struct{ flag, value string }{
+		"unknown flag":  {"new_checkout", "on"},
+		"bare number":   {"score_profile", "10"},
+		"over 100":      {"score_profile", "120%"},
+		"missing value": {"score_profile", ""},
+	} {
+		rr := deps.Do(t, http.MethodPut, "/api/admin/flags/"+body.flag, map[string]any{"value": body.value}, auth)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rr.Code)
+		}
+	}
+	if len(deps.Q.FeatureFlags) != 0 {
+		t.Fatalf("expected nothing stored, got %+v", deps.Q.FeatureFlags)
+	}
+
+	rr := deps.Do(t, http.MethodPut, "/api/admin/flags/score_profile", map[string]any{"environment": "production", "value": "10%"}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := deps.Q.FeatureFlags; len(got) != 1 || got[0].Environment != "production" || got[0].Percent != 10 {
+		t.Errorf("expected a 10%% production rule, got %+v", got)
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/api/admin/flags", nil, auth)
+	var list struct {
+		Flags []struct {
+			Name    string `json:"name"`
+			Percent int    `json:"percent"`
+		} `json:"flags"`
+		Rows []db.FeatureFlag `json:"rows"`
+	}
+	testutil.DecodeJSON(t, rr, &list)
+	if len(list.Flags) != len(flags.Known) || len(list.Rows) != 1 {
+		t.Errorf("expected every known flag and the stored row, got %+v", list)
+	}
+
+	if rr := deps.Do(t, http.MethodDelete, "/api/admin/flags/score_profile", nil, auth); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for the all-environments rule, got %d", rr.Code)
+	}
+	if rr := deps.Do(t, http.MethodDelete, "/api/admin/flags/score_profile?environment=production", nil, auth); rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+}
+
+func TestAdminStats_ReportsPaymentMargin(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	deps.Q.Payments = append(deps.Q.Payments, db.UpsertPaymentParams{
+		StripeChargeID: "ch_1", AmountCents: 10000, FeeCents: 320, NetCents: 9680, Currency: "usd",
+	})
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/stats", nil,
+		map[string]string{"Authorization": "Bearer admin_test_key"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
 	}
 
 	var resp struct {
-		Risks []struct {
-			Hedge string `json:"hedge"`
-		} `json:"risks"`
+		Payments []struct {
+			Currency string  `json:"currency"`
+			FeeCents int64   `json:"fee_cents"`
+			Margin   float64 `json:"margin"`
+		} `json:"payments"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Payments) != 1 || resp.Payments[0].FeeCents != 320 || resp.Payments[0].Margin != 0.968 {
+		t.Errorf("unexpected payments stats: %+v", resp.Payments)
 	}
-	decodeJSON(t, rr, &resp)
+}
 
-	if len(resp.Risks) == 0 {
-		t.Fatal("expected at least one risk")
+// ─── POST /api/admin/stripe-events/:eventID/replay ────────────────────────────
+
+func TestReplayStripeEvent(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	addStripeEvent(deps, "evt_other", "customer.created", time.Now(), map[string]any{"id": "cus_1"})
+	addStripeEvent(deps, "evt_bad_pi", "payment_intent.succeeded", time.Now(), map[string]any{})
+
+	cases := []struct {
+		eventID       string
+		wantStatus    int
+		wantProcessed bool
+	}{
+		{"evt_other", http.StatusOK, true},
+		{"evt_bad_pi", http.StatusOK, false},
+		{"evt_missing", http.StatusNotFound, false},
+	}
+	for _, tc := range cases {
+		rr := deps.Do(t, http.MethodPost, "/api/admin/stripe-events/"+tc.eventID+"/replay", nil, auth)
+		if rr.Code != tc.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tc.eventID, tc.wantStatus, rr.Code, rr.Body)
+			continue
+		}
+		if rr.Code != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Processed bool   `json:"processed"`
+			Error     string `json:"error"`
+		}
+		testutil.DecodeJSON(t, rr, &body)
+		if body.Processed != tc.wantProcessed || (body.Error == "") != tc.wantProcessed {
+			t.Errorf("%s: unexpected result %+v", tc.eventID, body)
+		}
 	}
-	if resp.Risks[0].Hedge != "AI-generated hedge" {
-		t.Errorf("expected AI hedge, got %q", resp.Risks[0].Hedge)
+}
+
+// ─── GET /api/admin/sessions ──────────────────────────────────────────────────
+
+func TestListSessions_PagesNewestFirstWithCursor(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	// Three sessions share a timestamp, so only the ID tie-break keeps the
+	// order stable across pages.
+	var want []uuid.UUID
+	for _, at := range []time.Time{testutil.Epoch, testutil.Epoch, testutil.Epoch, testutil.Epoch.Add(time.Minute), testutil.Epoch.Add(-time.Minute)} {
+		sess := testutil.Session(func(s *db.Session) {
+			s.CreatedAt = at
+			s.PaymentStatus = db.PaymentStatusPaid
+		})
+		deps.Q.AddSession(sess.AnonToken, sess)
+	}
+	unpaid := testutil.Session(func(s *db.Session) { s.PaymentStatus = db.PaymentStatusPending })
+	deps.Q.AddSession(unpaid.AnonToken, unpaid)
+	var paid []db.Session
+	for _, sess := range deps.Q.SessionsByID {
+		if sess.PaymentStatus == db.PaymentStatusPaid {
+			paid = append(paid, sess)
+		}
+	}
+	sort.Slice(paid, func(i, j int) bool {
+		if !paid[i].CreatedAt.Equal(paid[j].CreatedAt) {
+			return paid[i].CreatedAt.After(paid[j].CreatedAt)
+		}
+		return paid[i].ID.String() > paid[j].ID.String()
+	})
+	for _, sess := range paid {
+		want = append(want, sess.ID)
+	}
+
+	var got []uuid.UUID
+	path := "/api/admin/sessions?payment_status=paid&limit=2"
+	for pages := 0; path != ""; pages++ {
+		if pages == 3 {
+			t.Fatalf("expected 3 pages, still paging at %s", path)
+		}
+		rr := deps.Do(t, http.MethodGet, path, nil, auth)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, rr.Code, rr.Body)
+		}
+		var page struct {
+			Sessions []struct {
+				ID            uuid.UUID `json:"id"`
+				PaymentStatus string    `json:"payment_status"`
+			} `json:"sessions"`
+			NextCursor string `json:"next_cursor"`
+		}
+		testutil.DecodeJSON(t, rr, &page)
+		for _, sess := range page.Sessions {
+			got = append(got, sess.ID)
+		}
+		link := rr.Header().Get("Link")
+		if page.NextCursor == "" {
+			if link != "" {
+				t.Errorf("last page: Link = %q, want none", link)
+			}
+			path = ""
+			continue
+		}
+		path = "/api/admin/sessions?cursor=" + page.NextCursor + "&limit=2&payment_status=paid"
+		if wantLink := "<" + path + `>; rel="next"`; link != wantLink {
+			t.Errorf("Link = %q, want %q", link, wantLink)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged sessions = %v, want %v", got, want)
 	}
 }
 
-// ─── CORS ─────────────────────────────────────────────────────────────────────
+func TestListSessions_RejectsBadQueries(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	for _, query := range []string{"payment_status=gone", "limit=0", "limit=201", "cursor=not-a-cursor", "cursor=" + base64.RawURLEncoding.EncodeToString([]byte("2026-01-02T15:04:05Z evt_1"))} {
+		rr := deps.Do(t, http.MethodGet, "/api/admin/sessions?"+query, nil, auth)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
 
-func TestCORS_PreflightReturns204(t *testing.T) {
-	deps := newTestServer(t)
-	req := httptest.NewRequest(http.MethodOptions, "/api/session", nil)
-	req.Header.Set("Origin", "http://localhost:3000")
-	req.Header.Set("Access-Control-Request-Method", "POST")
-	rr := httptest.NewRecorder()
-	deps.handler.ServeHTTP(rr, req)
+func TestListSessions_LinkKeepsTheVersionedPath(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	deps.NewSession()
+	deps.NewSession()
 
-	if rr.Code != http.StatusNoContent {
-		t.Fatalf("expected 204, got %d", rr.Code)
+	rr := deps.Do(t, http.MethodGet, "/api/v1/admin/sessions?limit=1", nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
 	}
-	if rr.Header().Get("Access-Control-Allow-Origin") == "" {
-		t.Error("missing Access-Control-Allow-Origin header")
+	if link := rr.Header().Get("Link"); !strings.HasPrefix(link, "</api/v1/admin/sessions?cursor=") {
+		t.Errorf("Link = %q, want the /api/v1 path", link)
 	}
-	if rr.Header().Get("Access-Control-Allow-Methods") == "" {
-		t.Error("missing Access-Control-Allow-Methods header")
+}
+
+// ─── GET /api/admin/emails ────────────────────────────────────────────────────
+
+func TestListEmails_FiltersAndPages(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	for i, template := range []string{"report_ready", "receipt", "report_ready", "report_ready"} {
+		deps.Q.EmailLog[fmt.Sprintf("re_%d", i)] = &db.EmailLog{
+			ID:         uuid.New(),
+			ToAddress:  fmt.Sprintf("owner%d@example.com", i),
+			Subject:    "Your report",
+			Template:   template,
+			ProviderID: sql.NullString{String: fmt.Sprintf("re_%d", i), Valid: true},
+			CreatedAt:  testutil.Epoch.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	deps.Q.EmailLog["re_0"].Error = sql.NullString{String: "mailbox full", Valid: true}
+
+	type page struct {
+		Emails []struct {
+			To         string `json:"to"`
+			ProviderID string `json:"provider_id"`
+			Error      string `json:"error"`
+		} `json:"emails"`
+		NextCursor string `json:"next_cursor"`
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/emails?template=report_ready&limit=2", nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var first page
+	testutil.DecodeJSON(t, rr, &first)
+	if len(first.Emails) != 2 || first.Emails[0].ProviderID != "re_3" || first.Emails[1].ProviderID != "re_2" || first.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	if first.Emails[0].To != "owner3@example.com" {
+		t.Errorf("expected the recipient, got %+v", first.Emails[0])
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/api/admin/emails?template=report_ready&limit=2&cursor="+first.NextCursor, nil, auth)
+	var second page
+	testutil.DecodeJSON(t, rr, &second)
+	if len(second.Emails) != 1 || second.Emails[0].ProviderID != "re_0" || second.Emails[0].Error != "mailbox full" || second.NextCursor != "" {
+		t.Errorf("unexpected second page: %+v", second)
+	}
+	if link := rr.Header().Get("Link"); link != "" {
+		t.Errorf("last page: Link = %q, want none", link)
 	}
 }
 
-func TestCORS_NoOriginHeader_SkipsCORSHeaders(t *testing.T) {
-	deps := newTestServer(t)
-	rr := doRequest(t, deps.handler, http.MethodGet, "/healthz", nil, nil)
-	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
-		t.Error("should not set CORS headers when no Origin present")
+// ─── GET /api/admin/stripe-events ─────────────────────────────────────────────
+
+func TestListStripeEvents_FiltersAndPages(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	start := time.Now().Add(-time.Hour)
+	for i, typ := range []string{"checkout.session.completed", "customer.created", "checkout.session.completed", "checkout.session.completed"} {
+		addStripeEvent(deps, fmt.Sprintf("evt_%d", i), typ, start.Add(time.Duration(i)*time.Minute), map[string]any{"note": strings.Repeat("x", 600)})
+	}
+	deps.Q.StripeEvents[0].Error = sql.NullString{String: "boom", Valid: true}
+	deps.Q.StripeEvents[3].Error = sql.NullString{String: "boom", Valid: true}
+
+	type page struct {
+		Events []struct {
+			EventID        string `json:"event_id"`
+			Error          string `json:"error"`
+			PayloadPreview string `json:"payload_preview"`
+		} `json:"events"`
+		NextCursor string `json:"next_cursor"`
+		NextBefore string `json:"next_before"`
+	}
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/stripe-events?status=failed&type=checkout.session.completed&limit=1", nil, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var first page
+	testutil.DecodeJSON(t, rr, &first)
+	if len(first.Events) != 1 || first.Events[0].EventID != "evt_3" || first.NextBefore != "evt_3" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	if first.NextCursor == "" || !strings.Contains(rr.Header().Get("Link"), "cursor="+first.NextCursor) {
+		t.Errorf("expected a next cursor and Link, got %+v / %q", first, rr.Header().Get("Link"))
+	}
+	if first.Events[0].Error != "boom" || !strings.HasSuffix(first.Events[0].PayloadPreview, "…") || len([]rune(first.Events[0].PayloadPreview)) != 501 {
+		t.Errorf("expected the error and a truncated payload preview, got %+v", first.Events[0])
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/api/admin/stripe-events?status=failed&type=checkout.session.completed&limit=1&before="+first.NextBefore, nil, auth)
+	var second page
+	testutil.DecodeJSON(t, rr, &second)
+	if len(second.Events) != 1 || second.Events[0].EventID != "evt_0" {
+		t.Errorf("unexpected second page: %+v", second)
+	}
+
+	rr = deps.Do(t, http.MethodGet, "/api/admin/stripe-events?status=failed&type=checkout.session.completed&limit=1&cursor="+first.NextCursor, nil, auth)
+	var byCursor page
+	testutil.DecodeJSON(t, rr, &byCursor)
+	if len(byCursor.Events) != 1 || byCursor.Events[0].EventID != "evt_0" || byCursor.NextCursor != "" || byCursor.NextBefore != "" {
+		t.Errorf("unexpected page by cursor: %+v", byCursor)
+	}
+
+	for _, query := range []string{"status=broken", "limit=0", "limit=201", "limit=x", "cursor=!!!"} {
+		rr := deps.Do(t, http.MethodGet, "/api/admin/stripe-events?"+query, nil, auth)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
 	}
 }
 
-// ─── POST /api/session/:sessionID/checkout ────────────────────────────────────
+func TestListStripeEvents_CursorSurvivesTheAnchorBeingDeleted(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		addStripeEvent(deps, fmt.Sprintf("evt_%d", i), "customer.created", start.Add(time.Duration(i)*time.Minute), map[string]any{})
+	}
 
-func TestCreateCheckout_MissingEmailReturns400(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
+	var first struct {
+		Events []struct {
+			EventID string `json:"event_id"`
+		} `json:"events"`
+		NextCursor string `json:"next_cursor"`
+		NextBefore string `json:"next_before"`
+	}
+	testutil.DecodeJSON(t, deps.Do(t, http.MethodGet, "/api/admin/stripe-events?limit=1", nil, auth), &first)
+	if len(first.Events) != 1 || first.Events[0].EventID != "evt_2" || first.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
 
-	rr := doRequest(t, deps.handler,
-		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
-		map[string]string{"email": ""},
-		map[string]string{"X-Anon-Token": token})
+	// Retention deletes the last event served before the next page is asked for.
+	deps.Q.StripeEvents = deps.Q.StripeEvents[:2]
+
+	rr := deps.Do(t, http.MethodGet, "/api/admin/stripe-events?limit=1&cursor="+first.NextCursor, nil, auth)
+	var second struct {
+		Events []struct {
+			EventID string `json:"event_id"`
+		} `json:"events"`
+	}
+	testutil.DecodeJSON(t, rr, &second)
+	if len(second.Events) != 1 || second.Events[0].EventID != "evt_1" {
+		t.Errorf("expected the cursor to continue at evt_1, got %+v", second)
+	}
 
+	// The deprecated before alias cannot continue from a deleted event.
+	rr = deps.Do(t, http.MethodGet, "/api/admin/stripe-events?limit=1&before="+first.NextBefore, nil, auth)
 	if rr.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+		t.Errorf("expected 400 for a deleted before event, got %d: %s", rr.Code, rr.Body)
 	}
 }
 
-func TestCreateCheckout_StripeErrorReturns500(t *testing.T) {
-	deps := newTestServer(t)
-	sessionID, token := sessionWithToken(deps)
-	deps.stripe.createErr = errors.New("stripe unavailable")
+// ─── POST /api/admin/stripe-events/reprocess ──────────────────────────────────
 
-	rr := doRequest(t, deps.handler,
-		http.MethodPost, "/api/session/"+sessionID.String()+"/checkout",
-		map[string]string{"email": "test@example.com"},
-		map[string]string{"X-Anon-Token": token})
+func TestReprocessStripeEvents_ReplaysFailedEventsOldestFirst(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	start := time.Now().Add(-time.Hour)
+	addStripeEvent(deps, "evt_old", "customer.created", start, map[string]any{"id": "cus_1"})
+	addStripeEvent(deps, "evt_new", "payment_intent.succeeded", start.Add(time.Minute), map[string]any{})
+	addStripeEvent(deps, "evt_ok", "customer.created", start.Add(2*time.Minute), map[string]any{"id": "cus_2"})
+	deps.Q.StripeEvents[0].Error = sql.NullString{String: "boom", Valid: true}
+	deps.Q.StripeEvents[1].Error = sql.NullString{String: "boom", Valid: true}
+	deps.Q.StripeEvents[2].Processed = true
 
-	if rr.Code != http.StatusInternalServerError {
-		t.Fatalf("expected 500, got %d: %s", rr.Code, rr.Body.String())
+	rr := deps.Do(t, http.MethodPost, "/api/admin/stripe-events/reprocess", map[string]any{}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var resp struct {
+		Results []struct {
+			EventID   string `json:"event_id"`
+			Processed bool   `json:"processed"`
+		} `json:"results"`
+		Processed int `json:"processed"`
+		Failed    int `json:"failed"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if resp.Processed != 1 || resp.Failed != 1 || len(resp.Results) != 2 ||
+		resp.Results[0].EventID != "evt_old" || !resp.Results[0].Processed || resp.Results[1].EventID != "evt_new" {
+		t.Errorf("unexpected reprocess result: %+v", resp)
+	}
+
+	rr = deps.Do(t, http.MethodPost, "/api/admin/stripe-events/reprocess", map[string]any{"limit": 51}, auth)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an oversized batch, got %d", rr.Code)
 	}
 }
 
-// ─── POST /api/webhooks/stripe ────────────────────────────────────────────────
+func TestReprocessStripeEvents_StartsFromTheOldestFailure(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		addStripeEvent(deps, fmt.Sprintf("evt_%d", i), "payment_intent.succeeded", start.Add(time.Duration(i)*time.Minute), map[string]any{})
+		deps.Q.StripeEvents[i].Error = sql.NullString{String: "boom", Valid: true}
+	}
 
-func TestStripeWebhook_InvalidSignatureReturns400(t *testing.T) {
-	deps := newTestServer(t)
-	deps.stripe.verifyErr = errors.New("invalid signature")
+	rr := deps.Do(t, http.MethodPost, "/api/admin/stripe-events/reprocess", map[string]any{"limit": 1}, auth)
+	var resp struct {
+		Results []struct {
+			EventID string `json:"event_id"`
+		} `json:"results"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Results) != 1 || resp.Results[0].EventID != "evt_0" {
+		t.Errorf("expected the oldest failure first, got %+v", resp.Results)
+	}
+}
 
-	rr := doRequest(t, deps.handler,
-		http.MethodPost, "/api/webhooks/stripe",
-		map[string]string{"type": "payment_intent.succeeded"}, nil)
+func TestReprocessStripeEvents_SkipsEventsWithAQueuedJob(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	start := time.Now().Add(-time.Hour)
+	addStripeEvent(deps, "evt_queued", "customer.created", start, map[string]any{"id": "cus_1"})
+	addStripeEvent(deps, "evt_stuck", "customer.created", start.Add(time.Minute), map[string]any{"id": "cus_2"})
+	for i := range deps.Q.StripeEvents {
+		deps.Q.StripeEvents[i].Error = sql.NullString{String: "boom", Valid: true}
+	}
+	// The worker will retry evt_queued; replaying it now would run it twice.
+	deps.Q.Jobs = []db.Job{
+		{ID: uuid.New(), Type: "process_stripe_event", Status: "pending", DedupeKey: sql.NullString{String: "stripe_event:evt_queued", Valid: true}},
+		{ID: uuid.New(), Type: "process_stripe_event", Status: "failed", DedupeKey: sql.NullString{String: "stripe_event:evt_stuck", Valid: true}},
+	}
 
-	if rr.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	rr := deps.Do(t, http.MethodPost, "/api/admin/stripe-events/reprocess", map[string]any{}, auth)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var resp struct {
+		Results []struct {
+			EventID string `json:"event_id"`
+		} `json:"results"`
+	}
+	testutil.DecodeJSON(t, rr, &resp)
+	if len(resp.Results) != 1 || resp.Results[0].EventID != "evt_stuck" {
+		t.Errorf("expected only the event the worker gave up on, got %+v", resp.Results)
 	}
 }
 
-func TestStripeWebhook_UnknownEventTypeReturns200(t *testing.T) {
-	deps := newTestServer(t)
-	deps.stripe.verifyErr = nil
-	deps.stripe.verifyEvent = stripeinternal.Event{
-		ID:   "evt_test_unknown",
-		Type: "customer.created", // not handled
+func TestReprocessStripeEvents_RefusesPendingAndProcessedEvents(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	auth := map[string]string{"Authorization": "Bearer admin_test_key"}
+	addStripeEvent(deps, "evt_pending", "customer.created", time.Now(), map[string]any{"id": "cus_1"})
+
+	for _, status := range []string{"pending", "processed"} {
+		rr := deps.Do(t, http.MethodPost, "/api/admin/stripe-events/reprocess", map[string]any{"status": status}, auth)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", status, rr.Code)
+		}
 	}
+}
 
-	rr := doRequest(t, deps.handler,
-		http.MethodPost, "/api/webhooks/stripe",
-		[]byte(`{}`), nil)
+// ─── GET /api/openapi.json ────────────────────────────────────────────────────
 
+func getOpenAPIDocument(t *testing.T, deps *testutil.Server) map[string]any {
+	t.Helper()
+	rr := deps.Do(t, http.MethodGet, "/api/openapi.json", nil, nil)
 	if rr.Code != http.StatusOK {
-		t.Fatalf("expected 200 for unknown event type, got %d: %s", rr.Code, rr.Body.String())
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var doc map[string]any
+	testutil.DecodeJSON(t, rr, &doc)
+	return doc
+}
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	deps := testutil.NewServer(t, withAdminKey)
+	paths, _ := getOpenAPIDocument(t, deps)["paths"].(map[string]any)
+
+	routes, ok := deps.Handler.(chi.Routes)
+	if !ok {
+		t.Fatalf("handler is %T, not a chi router", deps.Handler)
+	}
+	mounted := map[string]bool{}
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := strings.ToLower(method) + " " + route
+		mounted[key] = true
+		if ops, _ := paths[route].(map[string]any); ops[strings.ToLower(method)] == nil {
+			t.Errorf("%s %s is not described in the OpenAPI document", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	for path, ops := range paths {
+		for method := range ops.(map[string]any) {
+			if !mounted[method+" "+path] {
+				t.Errorf("the OpenAPI document describes %s %s, which is not mounted", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPI_SchemasFollowJSONTags(t *testing.T) {
+	deps := testutil.NewServer(t)
+	doc := getOpenAPIDocument(t, deps)
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	req, ok := schemas["CreateSessionRequest"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a CreateSessionRequest schema, got %v", schemas)
+	}
+	props := req["properties"].(map[string]any)
+	for _, field := range []string{"biz_name", "industry", "stage", "email", "captcha_token"} {
+		if props[field] == nil {
+			t.Errorf("expected property %q, got %v", field, props)
+		}
+	}
+	resp := schemas["CreateCheckoutResponse"].(map[string]any)
+	if required, _ := resp["required"].([]any); len(required) != 1 || required[0] != "client_secret" {
+		t.Errorf("expected only client_secret to be required, got %v", resp["required"])
+	}
+}
+
+func TestOpenAPI_ProductionHidesDocsAndUnmountedAdminRoutes(t *testing.T) {
+	deps := testutil.NewServer(t, func(cfg *api.Config) { cfg.Env = "production" })
+
+	if rr := deps.Do(t, http.MethodGet, "/api/docs", nil, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected Swagger UI to be unmounted in production, got %d", rr.Code)
+	}
+	paths := getOpenAPIDocument(t, deps)["paths"].(map[string]any)
+	for path := range paths {
+		if path == "/api/docs" || strings.HasPrefix(path, "/api/admin/") {
+			t.Errorf("expected %s to be left out", path)
+		}
+	}
+
+	deps = testutil.NewServer(t)
+	rr := deps.Do(t, http.MethodGet, "/api/docs", nil, nil)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "/api/openapi.json") {
+		t.Errorf("expected Swagger UI outside production, got %d", rr.Code)
+	}
+}
+
+// ─── pkg/client ───────────────────────────────────────────────────────────────
+
+// TestClient_MatchesServer runs the public client against the real router so a
+// field renamed on either side is caught here rather than by integrators.
+func TestClient_MatchesServer(t *testing.T) {
+	deps := testutil.NewServer(t)
+	srv := httptest.NewServer(deps.Handler)
+	defer srv.Close()
+	c := client.New(client.Config{BaseURL: srv.URL, MaxRetries: -1})
+	ctx := context.Background()
+
+	sess, err := c.CreateSession(ctx, client.CreateSessionRequest{BizName: "Acme", Industry: "SaaS"})
+	if err != nil || sess.SessionID == "" || sess.AnonToken == "" {
+		t.Fatalf("CreateSession: %+v, %v", sess, err)
+	}
+
+	questions, err := c.GetQuestions(ctx, sess)
+	if err != nil || len(questions) != 3 || questions[1].ID != "q_cash_runway" || len(questions[1].Options) != 3 {
+		t.Fatalf("GetQuestions: %+v, %v", questions, err)
 	}
-}
\ No newline at end of file
+
+	deps.Q.Reports["draft_token"] = db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusDraft}
+	report, err := c.GetReport(ctx, "draft_token")
+	if err != nil || report.Ready() || report.Status != "draft" {
+		t.Errorf("GetReport: %+v, %v", report, err)
+	}
+	if _, err := c.GetReport(ctx, "missing"); !client.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/invoice"
+)
+
+// ─── GET /api/report/:accessToken/invoice ─────────────────────────────────────
+//
+// Returns a PDF invoice for the payment behind a report, so business customers
+// can expense it. Available as soon as the payment succeeds — the report does
+// not need to be ready. The invoice number is assigned on first download and
+// reused afterwards.
+//
+// Returns 404 for an unknown token and for reports that were not paid by card
+// (unpaid, covered by a subscription, whose invoices come from Stripe, or by a
+// duplicate payment kept as credit), and 410 for a revoked report.
+
+func (s *Server) handleGetInvoice(w http.ResponseWriter, r *http.Request) {
+	row, err := s.q.GetInvoiceByAccessToken(r.Context(), chi.URLParam(r, "accessToken"))
+	if errors.Is(err, sql.ErrNoRows) {
+		s.reportTokenMiss(r)
+		respondErr(w, http.StatusNotFound, "report not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get invoice: %w", err))
+		return
+	}
+	if row.RevokedAt.Valid {
+		respondErr(w, http.StatusGone, errReportRevoked)
+		return
+	}
+	if row.PaymentStatus != db.PaymentStatusPaid || row.SubscriptionID.Valid || row.CreditFromSessionID.Valid || !row.PaidAt.Valid {
+		respondErr(w, http.StatusNotFound, "no invoice for this report")
+		return
+	}
+
+	number := row.InvoiceNumber.Int64
+	if !row.InvoiceNumber.Valid {
+		number, err = s.q.AssignInvoiceNumber(r.Context(), row.SessionID)
+		if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("assign invoice number: %w", err))
+			return
+		}
+	}
+
+	inv := invoice.Invoice{
+		Number:        fmt.Sprintf("INV-%06d", number),
+		IssuedAt:      row.PaidAt.Time,
+		Issuer:        s.cfg.InvoiceIssuer,
+		BillTo:        billTo(row),
+		Description:   "Asymmetric Risk assessment — " + row.ProductName,
+		SubtotalCents: int64(row.SubtotalCents),
+		TaxCents:      int64(row.TaxCents),
+		Currency:      row.Currency,
+	}
+	if row.StripePaymentIntent.Valid {
+		inv.PaymentNote = "Paid by card. Stripe payment reference " + row.StripePaymentIntent.String + "."
+	}
+
+	pdf := invoice.Render(inv)
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+inv.Number+`.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pdf)
+}
+
+// billTo builds the customer block from the billing details given at
+// checkout, falling back to the business name and email when they are absent.
+func billTo(row db.GetInvoiceByAccessTokenRow) []string {
+	var lines []string
+	add := func(parts ...string) {
+		var kept []string
+		for _, p := range parts {
+			if p != "" {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) > 0 {
+			lines = append(lines, strings.Join(kept, " "))
+		}
+	}
+
+	name := row.BillingName.String
+	if name == "" {
+		name = row.BizName.String
+	}
+	add(name)
+	add(row.BillingAddressLine1.String)
+	add(row.BillingAddressLine2.String)
+	add(row.BillingPostalCode.String, row.BillingCity.String)
+	add(row.BillingCountry.String)
+	if row.BillingTaxID.Valid && row.BillingTaxID.String != "" {
+		add("Tax ID:", row.BillingTaxID.String)
+	}
+	add(row.Email.String)
+	return lines
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── GET /api/report/:accessToken/matrix ──────────────────────────────────────
+//
+// Returns a report's risks laid out on the probability × impact grid the
+// frontend draws as a heat-map, with the tier of every cell and the tier
+// boundaries. The thresholds belong to the scorer; serving them here keeps
+// the frontend from carrying its own copy that can drift.
+//
+// Rows run from the highest impact down, so rows[0] is the top of the chart,
+// and each row runs from the lowest probability up. Risks are identified by
+// their question_id, as in GET /api/report/:accessToken. Same 202/404/410
+// responses as that endpoint.
+
+type matrixCell struct {
+	Probability int      `json:"probability"`
+	Impact      int      `json:"impact"`
+	Tier        string   `json:"tier"`
+	RiskIDs     []string `json:"risk_ids"`
+}
+
+type matrixRisk struct {
+	QuestionID  string `json:"question_id"`
+	RiskName    string `json:"risk_name"`
+	Rank        int16  `json:"rank"`
+	Probability int16  `json:"probability"`
+	Impact      int16  `json:"impact"`
+	Tier        string `json:"tier"`
+}
+
+type reportMatrixResponse struct {
+	ReportID   string          `json:"report_id"`
+	Size       int             `json:"size"`
+	Thresholds scoreThresholds `json:"thresholds"`
+	Tiers      []tierMeta      `json:"tiers"` // as in GET /api/scoring/meta
+	Rows       [][]matrixCell  `json:"rows"`
+	Risks      []matrixRisk    `json:"risks"`
+}
+
+func (s *Server) handleGetReportMatrix(w http.ResponseWriter, r *http.Request) {
+	row, err := s.q.GetReportByAccessToken(r.Context(), chi.URLParam(r, "accessToken"))
+	if errors.Is(err, sql.ErrNoRows) {
+		s.reportTokenMiss(r)
+		respondErr(w, http.StatusNotFound, "report not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
+		return
+	}
+	if row.RevokedAt.Valid {
+		respondErr(w, http.StatusGone, errReportRevoked)
+		return
+	}
+	if row.HeldAt.Valid {
+		respond(w, http.StatusAccepted, map[string]string{
+			"status":  "held",
+			"message": "this purchase looks like a repeat of an earlier one and is being reviewed",
+		})
+		return
+	}
+	if row.Status != db.ReportStatusReady {
+		respond(w, http.StatusAccepted, map[string]string{
+			"status":  string(row.Status),
+			"message": "report is being generated, please check back shortly",
+		})
+		return
+	}
+
+	results, err := s.q.GetRiskResultsByReport(r.Context(), row.ID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get risk results: %w", err))
+		return
+	}
+
+	resp := buildRiskMatrix(results, s.tierMetas(r))
+	resp.ReportID = row.ID.String()
+	respond(w, http.StatusOK, resp)
+}
+
+// buildRiskMatrix places results on the grid, described by tiers. Scores
+// outside 1–10 cannot come from the scorer, but are pinned to the edge rather
+// than dropped.
+func buildRiskMatrix(results []db.RiskResult, tiers []tierMeta) reportMatrixResponse {
+	const lo, hi = scoring.MinScore, scoring.MaxScore
+
+	resp := reportMatrixResponse{
+		Size:       hi - lo + 1,
+		Thresholds: tierThresholds(),
+		Tiers:      tiers,
+		Rows:       make([][]matrixCell, 0, hi-lo+1),
+		Risks:      make([]matrixRisk, len(results)),
+	}
+	for impact := hi; impact >= lo; impact-- {
+		cells := make([]matrixCell, 0, hi-lo+1)
+		for p := lo; p <= hi; p++ {
+			cells = append(cells, matrixCell{
+				Probability: p,
+				Impact:      impact,
+				Tier:        string(scoring.GetTier(p, impact)),
+				RiskIDs:     []string{},
+			})
+		}
+		resp.Rows = append(resp.Rows, cells)
+	}
+
+	pin := func(v int16) int { return min(max(int(v), lo), hi) }
+	for i, rr := range results {
+		p, impact := pin(rr.Probability), pin(rr.Impact)
+		cell := &resp.Rows[hi-impact][p-lo]
+		cell.RiskIDs = append(cell.RiskIDs, rr.QuestionID)
+		resp.Risks[i] = matrixRisk{
+			QuestionID:  rr.QuestionID,
+			RiskName:    rr.RiskName,
+			Rank:        rr.Rank,
+			Probability: rr.Probability,
+			Impact:      rr.Impact,
+			Tier:        string(rr.Tier),
+		}
+	}
+	return resp
+}
@@ -2,14 +2,21 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/useragent"
 )
 
 // ─── CONTEXT KEYS ─────────────────────────────────────────────────────────────
@@ -62,14 +69,193 @@ func (s *Server) requireAnonToken(next http.Handler) http.Handler {
 	})
 }
 
+// ─── ADMIN AUTH ───────────────────────────────────────────────────────────────
+
+// requireAdmin is chi middleware that checks the Authorization header for
+// "Bearer <ADMIN_API_KEY>". The comparison is constant-time so the key cannot
+// be recovered by timing the response.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.cfg.AdminAPIKey)) != 1 {
+			respondErr(w, http.StatusUnauthorized, "invalid admin credentials")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ─── REPORT TOKEN LOCKOUT ─────────────────────────────────────────────────────
+
+// guardReportToken refuses report-route requests from a client IP, or for a
+// token, that ReportIPLockout or ReportTokenLockout has locked out after
+// too many unknown tokens. Handlers record those with reportTokenMiss. The
+// check runs before the lookup, so a locked-out IP cannot confirm a guess
+// even when it is right.
+func (s *Server) guardReportToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		left, locked := s.cfg.ReportIPLockout.Locked(r.Context(), realIP(r))
+		for _, token := range reportTokens(r) {
+			if locked {
+				break
+			}
+			left, locked = s.cfg.ReportTokenLockout.Locked(r.Context(), tokenKey(token))
+		}
+		if locked {
+			w.Header().Set("Retry-After", strconv.Itoa(int(left.Seconds())+1))
+			respondErr(w, http.StatusTooManyRequests, "too many requests for unknown reports, try again later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reportTokenMiss records a request for an access token that matches no
+// report, and logs an audit event when it locks the client IP or the token
+// out. Call it only for unknown tokens — not for revoked or unpaid reports,
+// whose links are real.
+func (s *Server) reportTokenMiss(r *http.Request) {
+	s.reportTokenMissFor(r, chi.URLParam(r, "accessToken"))
+}
+
+// reportTokenMissFor is reportTokenMiss for a token that is not the route's
+// {accessToken}, such as one of GET /api/reports/compare's.
+func (s *Server) reportTokenMissFor(r *http.Request, accessToken string) {
+	ip := realIP(r)
+	token := tokenKey(accessToken)
+	if d, locked := s.cfg.ReportIPLockout.Fail(r.Context(), ip); locked {
+		s.logger.Warn("report access: ip locked out after repeated unknown tokens",
+			"ip_hash", s.hashIP(ip),
+			"lockout", d,
+			"audit", true,
+			logField(r),
+		)
+	}
+	if d, locked := s.cfg.ReportTokenLockout.Fail(r.Context(), token); locked {
+		s.logger.Warn("report access: token locked out after repeated lookups",
+			"token_hash", token,
+			"ip_hash", s.hashIP(ip),
+			"lockout", d,
+			"audit", true,
+			logField(r),
+		)
+	}
+}
+
+// reportTokens returns the access tokens a report-route request names: the
+// {accessToken} path segment, or the a and b query parameters of
+// GET /api/reports/compare.
+func reportTokens(r *http.Request) []string {
+	if token := chi.URLParam(r, "accessToken"); token != "" {
+		return []string{token}
+	}
+	return []string{r.URL.Query().Get("a"), r.URL.Query().Get("b")}
+}
+
+// tokenKey is the lockout key for an access token: a truncated SHA-256, so
+// neither memory nor logs hold guessed tokens.
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
 // chi_URLParam wraps chi.URLParam to avoid importing chi in every file.
 // Defined here once; handlers call this helper.
 func chi_URLParam(r *http.Request, key string) string {
 	return chi.URLParam(r, key)
 }
 
+// ─── CLIENT IP ────────────────────────────────────────────────────────────────
+
+// clientIPMiddleware rewrites r.RemoteAddr to the client's address so every
+// later reader — realIP, the request logger — sees the same value.
+//
+// With TrustedProxies configured, forwarding headers are only believed when
+// the direct peer is a trusted proxy. X-Forwarded-For is walked from the
+// right, skipping trusted hops; the first untrusted address is the client.
+// Entries further left were supplied by the client and may be forged.
+// X-Real-IP is used when there is no X-Forwarded-For.
+//
+// Without TrustedProxies chi's RealIP behaviour is kept: the headers are
+// trusted from any peer, which is only safe behind a proxy that overwrites them.
+func (s *Server) clientIPMiddleware(next http.Handler) http.Handler {
+	if len(s.cfg.TrustedProxies) == 0 {
+		return middleware.RealIP(next)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := s.forwardedClientIP(r); ip.IsValid() {
+			r.RemoteAddr = ip.String()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedClientIP resolves the client address as described on
+// clientIPMiddleware. It returns the zero Addr when RemoteAddr is unparseable.
+func (s *Server) forwardedClientIP(r *http.Request) netip.Addr {
+	peer := parseIP(r.RemoteAddr)
+	if !peer.IsValid() || !s.trustedProxy(peer) {
+		return peer
+	}
+
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	if len(hops) == 0 {
+		if ip := parseIP(r.Header.Get("X-Real-IP")); ip.IsValid() {
+			return ip
+		}
+		return peer
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(hops[i])
+		if !ip.IsValid() {
+			break // nothing left of a malformed hop can be trusted
+		}
+		client = ip
+		if !s.trustedProxy(ip) {
+			break
+		}
+	}
+	return client
+}
+
+func (s *Server) trustedProxy(ip netip.Addr) bool {
+	for _, p := range s.cfg.TrustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP parses an address with or without a port ("1.2.3.4:5678",
+// "[2001:db8::1]:443"), unmapping IPv4-in-IPv6. It returns the zero Addr
+// for anything else.
+func parseIP(s string) netip.Addr {
+	s = strings.TrimSpace(s)
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap()
+	}
+	if ip, err := netip.ParseAddr(s); err == nil {
+		return ip.Unmap()
+	}
+	return netip.Addr{}
+}
+
 // ─── CORS ─────────────────────────────────────────────────────────────────────
 
+// machineOnlyPrefixes are the routes called by servers, never by browsers:
+// Stripe and Resend post the webhooks, and operators script the admin API
+// and deploys drain replicas.
+// They get no CORS headers and their preflights are refused, so no web page
+// can call them through a visitor's browser and their responses do not
+// advertise the browser API's methods and headers.
+var machineOnlyPrefixes = []string{"/api/webhooks/", "/api/admin/", "/internal/"}
+
 // corsMiddleware handles preflight OPTIONS requests and sets CORS headers.
 // In production, tighten AllowedOrigins to your actual frontend domain.
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
@@ -79,6 +265,17 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		for _, prefix := range machineOnlyPrefixes {
+			if !strings.HasPrefix(r.URL.Path, prefix) {
+				continue
+			}
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
 
 		// TODO: replace "*" with your frontend URL in production.
 		allowed := "*"
@@ -88,7 +285,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 
 		w.Header().Set("Access-Control-Allow-Origin", allowed)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Anon-Token, X-Request-ID")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Anon-Token, X-Request-ID, "+apiVersionHeader)
+		w.Header().Set("Access-Control-Expose-Headers", requestid.Header+", "+apiVersionHeader+", Deprecation, Sunset, Link")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
 		if r.Method == http.MethodOptions {
@@ -100,23 +298,52 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// ─── REQUEST ID ───────────────────────────────────────────────────────────────
+
+// propagateRequestID copies the chi request ID onto the context with
+// requestid.With, so store, Stripe, AI and email calls made while serving the
+// request forward it, and echoes it in the X-Request-ID response header so a
+// customer or the frontend can quote it. Runs after middleware.RequestID.
+func propagateRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := middleware.GetReqID(r.Context())
+		if id != "" {
+			w.Header().Set(requestid.Header, id)
+		}
+		next.ServeHTTP(w, r.WithContext(requestid.With(r.Context(), id)))
+	})
+}
+
 // ─── LOGGER MIDDLEWARE ────────────────────────────────────────────────────────
 
-// loggerMiddleware logs each request with method, path, status, and duration.
+// loggerMiddleware logs each request with method, path, status, and duration,
+// plus who sent it for abuse detection and attribution: the class of its user
+// agent (see useragent), the client IP's hash as stored in sessions.ip_hash,
+// and its country when a GeoIP database is configured. The IP itself is
+// never logged.
 func (s *Server) loggerMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 		defer func() {
-			s.logger.Info("http",
+			ip := realIP(r)
+			attrs := []any{
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", ww.Status(),
 				"bytes", ww.BytesWritten(),
 				"duration_ms", time.Since(start).Milliseconds(),
 				"request_id", middleware.GetReqID(r.Context()),
-			)
+				"ua_class", useragent.Class(r.UserAgent()),
+				"ip_hash", s.hashIP(ip),
+			}
+			if s.cfg.GeoIP != nil {
+				if country := s.cfg.GeoIP.Country(parseIP(ip)); country != "" {
+					attrs = append(attrs, "country", country)
+				}
+			}
+			s.logger.Info("http", attrs...)
 		}()
 
 		next.ServeHTTP(ww, r)
@@ -147,9 +374,21 @@ func (s *Server) respondInternalErr(w http.ResponseWriter, r *http.Request, err
 		"path", r.URL.Path,
 		"request_id", middleware.GetReqID(r.Context()),
 	)
+	s.cfg.ErrorReporter.CaptureError(r.Context(), err, map[string]string{"route": r.Method + " " + routePattern(r)})
 	respondErr(w, http.StatusInternalServerError, "internal server error")
 }
 
+// routePattern returns the matched chi route, e.g. "/api/report/{accessToken}",
+// so error reports group by endpoint and never carry a token from the path.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if p := rctx.RoutePattern(); p != "" {
+			return p
+		}
+	}
+	return "unmatched"
+}
+
 // logAndIgnoreEmailErr logs an email send error without surfacing it to the
 // caller. Used where email failure must not fail the HTTP response.
 func (s *Server) logAndIgnoreEmailErr(r *http.Request, err error, context string) {
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── SUPPORT NOTES ────────────────────────────────────────────────────────────
+//
+// Support staff keep notes on a session or its report — a resend request, a
+// refund reason — so a customer's case history sits next to their data
+// rather than in a separate ticketing tool. A report belongs to one session,
+// so both routes list the same notes; one written against the report carries
+// its report_id. The admin key is shared, so the author names themselves.
+// Notes may quote the customer, so their text is never logged.
+
+// maxNoteRunes bounds a note's text.
+const maxNoteRunes = 4000
+
+type supportNoteResponse struct {
+	ID        string `json:"id"`
+	ReportID  string `json:"report_id,omitempty"` // set when written against the report
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newSupportNoteResponse(n db.SupportNote) supportNoteResponse {
+	out := supportNoteResponse{
+		ID:        n.ID.String(),
+		Author:    n.Author,
+		Body:      n.Body,
+		CreatedAt: n.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if n.ReportID.Valid {
+		out.ReportID = n.ReportID.UUID.String()
+	}
+	return out
+}
+
+// noteTarget resolves the session, and the report if the route names one,
+// that a notes request is about. On failure it has responded and ok is false.
+func (s *Server) noteTarget(w http.ResponseWriter, r *http.Request) (sessionID uuid.UUID, reportID uuid.NullUUID, ok bool) {
+	if param := chi.URLParam(r, "reportID"); param != "" {
+		id, err := parseUUID(param)
+		if err != nil {
+			respondErr(w, http.StatusBadRequest, "invalid report_id")
+			return uuid.Nil, uuid.NullUUID{}, false
+		}
+		report, err := s.q.GetReportByID(r.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondErr(w, http.StatusNotFound, "report not found")
+			return uuid.Nil, uuid.NullUUID{}, false
+		} else if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
+			return uuid.Nil, uuid.NullUUID{}, false
+		}
+		return report.SessionID, uuid.NullUUID{UUID: report.ID, Valid: true}, true
+	}
+
+	id, err := parseUUID(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid session_id")
+		return uuid.Nil, uuid.NullUUID{}, false
+	}
+	if _, err := s.q.GetSessionByID(r.Context(), id); errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "session not found")
+		return uuid.Nil, uuid.NullUUID{}, false
+	} else if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get session: %w", err))
+		return uuid.Nil, uuid.NullUUID{}, false
+	}
+	return id, uuid.NullUUID{}, true
+}
+
+// ─── GET /api/admin/sessions/:sessionID/notes ─────────────────────────────────
+// ─── GET /api/admin/reports/:reportID/notes ───────────────────────────────────
+//
+// Lists the session's notes, oldest first.
+
+type adminNotesResponse struct {
+	SessionID string                `json:"session_id"`
+	Notes     []supportNoteResponse `json:"notes"`
+}
+
+func (s *Server) handleAdminListNotes(w http.ResponseWriter, r *http.Request) {
+	sessionID, _, ok := s.noteTarget(w, r)
+	if !ok {
+		return
+	}
+	rows, err := s.q.ListSupportNotesBySession(r.Context(), sessionID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list support notes: %w", err))
+		return
+	}
+	out := adminNotesResponse{SessionID: sessionID.String(), Notes: make([]supportNoteResponse, len(rows))}
+	for i, row := range rows {
+		out.Notes[i] = newSupportNoteResponse(row)
+	}
+	respond(w, http.StatusOK, out)
+}
+
+// ─── POST /api/admin/sessions/:sessionID/notes ────────────────────────────────
+// ─── POST /api/admin/reports/:reportID/notes ──────────────────────────────────
+//
+// Adds a note. 404 for an unknown session or report.
+
+type postNoteRequest struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+}
+
+func (s *Server) handleAdminPostNote(w http.ResponseWriter, r *http.Request) {
+	sessionID, reportID, ok := s.noteTarget(w, r)
+	if !ok {
+		return
+	}
+	var req postNoteRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Author = strings.TrimSpace(req.Author)
+	req.Body = strings.TrimSpace(req.Body)
+	switch {
+	case req.Author == "":
+		respondErr(w, http.StatusBadRequest, "author is required")
+		return
+	case req.Body == "":
+		respondErr(w, http.StatusBadRequest, "body is required")
+		return
+	case utf8.RuneCountInString(req.Body) > maxNoteRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("body must be at most %d characters", maxNoteRunes))
+		return
+	}
+
+	note, err := s.q.InsertSupportNote(r.Context(), db.InsertSupportNoteParams{
+		SessionID: sessionID,
+		ReportID:  reportID,
+		Author:    req.Author,
+		Body:      req.Body,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("insert support note: %w", err))
+		return
+	}
+
+	s.logger.Info("admin: support note added",
+		"session_id", sessionID,
+		"report_id", reportID.UUID,
+		"note_id", note.ID,
+		"author", note.Author,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusCreated, newSupportNoteResponse(note))
+}
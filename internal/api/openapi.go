@@ -0,0 +1,541 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── GET /api/openapi.json ────────────────────────────────────────────────────
+//
+// Serves an OpenAPI 3 description of every route, for the frontend and
+// partners to generate clients from. The operation table below is maintained
+// by hand next to the routes; request and response schemas are derived from
+// the handler structs by reflection, so a renamed JSON field cannot drift.
+// TestOpenAPI_CoversEveryRoute fails when a route is added without an entry.
+//
+// GET /api/docs renders the document with Swagger UI outside production.
+
+const openAPIVersion = "1.0.0"
+
+// apiOperation describes one route. Request and response values are only
+// inspected for their type.
+type apiOperation struct {
+	method, path string
+	summary      string
+	auth         apiAuth
+	admin        bool // mounted only when ADMIN_API_KEY is set
+	emailHook    bool // mounted only when RESEND_WEBHOOK_SECRET is set
+	partners     bool // mounted only when PARTNER_KEYS is set
+	embeds       bool // mounted only when EMBED_ORIGINS is set
+	devOnly      bool // not mounted in production
+	query        []apiParam
+	request      any
+	// responses maps a status code to a body: a Go value whose type becomes
+	// the JSON schema, one of the raw body markers, or nil for no body.
+	responses map[int]any
+}
+
+type apiAuth int
+
+const (
+	authNone apiAuth = iota
+	authAnonToken
+	authAdmin
+)
+
+type apiParam struct {
+	name, description string
+	required          bool
+}
+
+// Markers for bodies that are not JSON.
+type (
+	csvBody  struct{}
+	pdfBody  struct{}
+	htmlBody struct{}
+)
+
+// errorResponse is the respondErr envelope.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+var errBody = errorResponse{}
+
+// Named shapes for handlers that respond with a map.
+type (
+	productsList struct {
+		Products []productResponse `json:"products"`
+	}
+	adminProductsList struct {
+		Products []db.Product `json:"products"`
+	}
+	adminPlaybooksList struct {
+		Snippets []db.PlaybookSnippet `json:"snippets"`
+	}
+	readinessResponse struct {
+		Status string                     `json:"status"`
+		Checks map[string]readinessResult `json:"checks"`
+	}
+	adminConfigResponse struct {
+		Config map[string]string `json:"config"`
+	}
+	adminSettingsResponse struct {
+		Settings  []db.RuntimeSetting       `json:"settings"`
+		Effective effectiveSettingsResponse `json:"effective"`
+	}
+	adminStatsResponse struct {
+		Funnel        db.GetCompletionFunnelStatsRow `json:"funnel"`
+		Payments      []paymentMarginResponse        `json:"payments"`
+		Consultations consultationStatsResponse      `json:"consultations"`
+		Experiments   []experimentStatsResponse      `json:"experiments"`
+	}
+)
+
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/healthz", summary: "Liveness probe", responses: map[int]any{200: nil}},
+	{method: "GET", path: "/readyz", summary: "Readiness probe; 503 when a critical dependency is down or the replica is draining",
+		responses: map[int]any{200: readinessResponse{}, 503: readinessResponse{}}},
+	{method: "GET", path: "/internal/drain", summary: "Whether this replica is draining, and the jobs and connections it still has", auth: authAdmin, admin: true,
+		responses: map[int]any{200: drainResponse{}}},
+	{method: "POST", path: "/internal/drain", summary: "Drain this replica for a rolling deploy: stop taking reports and fail /readyz", auth: authAdmin, admin: true,
+		responses: map[int]any{202: drainResponse{}, 501: errBody}},
+	{method: "GET", path: "/api/openapi.json", summary: "This document", responses: map[int]any{200: map[string]any(nil)}},
+	{method: "GET", path: "/api/docs", summary: "Swagger UI for this document", devOnly: true, responses: map[int]any{200: htmlBody{}}},
+
+	{method: "POST", path: "/api/session", summary: "Create an anonymous assessment session",
+		request:   createSessionRequest{},
+		responses: map[int]any{201: createSessionResponse{}, 400: errBody, 403: errBody}},
+	{method: "POST", path: "/api/embed/session", summary: "Issue an embed token to an allow-listed partner site", embeds: true,
+		request:   createEmbedTokenRequest{},
+		responses: map[int]any{201: createEmbedTokenResponse{}, 400: errBody, 403: errBody}},
+	{method: "GET", path: "/api/products", summary: "List the products on sale",
+		query:     []apiParam{{name: "session_id", description: "price the products for this session's price experiment variant"}},
+		responses: map[int]any{200: productsList{}}},
+	{method: "GET", path: "/api/scoring/meta", summary: "Score ranges, tier thresholds and labels, and score bands",
+		responses: map[int]any{200: scoringMetaResponse{}}},
+	{method: "PATCH", path: "/api/session/{sessionID}/context", summary: "Update the business context",
+		auth: authAnonToken, request: updateContextRequest{},
+		responses: map[int]any{200: updateContextResponse{}, 400: errBody, 401: errBody}},
+	{method: "GET", path: "/api/session/{sessionID}/questions", summary: "List questions with saved answers, and sections with the session's progress",
+		auth:      authAnonToken,
+		responses: map[int]any{200: getQuestionsResponse{}, 401: errBody}},
+	{method: "GET", path: "/api/session/{sessionID}/progress", summary: "Per-section completion",
+		auth:      authAnonToken,
+		responses: map[int]any{200: progressResponse{}, 401: errBody}},
+	{method: "GET", path: "/api/session/{sessionID}/teaser", summary: "Top risk and score band shown before payment",
+		auth:      authAnonToken,
+		responses: map[int]any{200: teaserResponse{}, 401: errBody, 409: errBody}},
+	{method: "PUT", path: "/api/session/{sessionID}/answers", summary: "Save a batch of answers; 409 for a locked section when SECTION_GATING is on",
+		auth: authAnonToken, request: upsertAnswersRequest{},
+		responses: map[int]any{200: upsertAnswersResponse{}, 400: errBody, 401: errBody, 409: errBody}},
+	{method: "POST", path: "/api/session/{sessionID}/import", summary: "Pre-fill context and answers from a partner's signed token",
+		auth: authAnonToken, request: importPrefillRequest{}, partners: true,
+		responses: map[int]any{200: importPrefillResponse{}, 400: errBody, 401: errBody}},
+	{method: "POST", path: "/api/session/{sessionID}/checkout", summary: "Create or reuse the PaymentIntent for the report",
+		auth: authAnonToken, request: createCheckoutRequest{},
+		responses: map[int]any{200: createCheckoutResponse{}, 400: errBody, 401: errBody, 403: errBody, 409: errBody}},
+
+	{method: "POST", path: "/api/webhooks/stripe", summary: "Stripe webhook receiver (Stripe-Signature verified)",
+		responses: map[int]any{200: nil, 400: errBody}},
+	{method: "POST", path: "/api/webhooks/resend", summary: "Resend email tracking webhook (Svix signature verified)", emailHook: true,
+		responses: map[int]any{200: nil, 400: errBody}},
+
+	{method: "POST", path: "/api/report/resend", summary: "Email the links to the paid reports of an address again",
+		request:   reportResendRequest{},
+		responses: map[int]any{202: reportResendResponse{}, 400: errBody, 429: errBody}},
+	{method: "GET", path: "/api/feedback/{token}", summary: "The customer's rating and testimonial, for editing",
+		responses: map[int]any{200: feedbackResponse{}, 404: errBody}},
+	{method: "POST", path: "/api/feedback/{token}", summary: "Rate a delivered report and optionally leave a testimonial",
+		request:   postFeedbackRequest{},
+		responses: map[int]any{200: feedbackResponse{}, 400: errBody, 404: errBody}},
+	{method: "POST", path: "/api/feedback/{token}/unsubscribe", summary: "Stop optional emails to the customer's address",
+		responses: map[int]any{204: nil, 404: errBody}},
+	{method: "GET", path: "/api/report/{accessToken}", summary: "Fetch a report; 202 while it is being generated",
+		responses: map[int]any{200: reportResponse{}, 202: reportPending{}, 404: errBody, 410: errBody, 429: errBody}},
+	{method: "POST", path: "/api/report/{accessToken}/consultation", summary: "Request a consultation and get the booking link",
+		request:   consultationRequest{},
+		responses: map[int]any{200: consultationResponse{}, 400: errBody, 404: errBody, 409: errBody, 410: errBody, 429: errBody}},
+	{method: "POST", path: "/api/report/{accessToken}/rotate", summary: "Replace the report link and email the new one to the report's address",
+		request:   rotateReportTokenRequest{},
+		responses: map[int]any{202: rotateReportTokenResponse{}, 400: errBody, 403: errBody, 404: errBody, 410: errBody, 429: errBody}},
+	{method: "GET", path: "/api/report/{accessToken}/invoice", summary: "Download the PDF invoice",
+		responses: map[int]any{200: pdfBody{}, 404: errBody, 410: errBody, 429: errBody}},
+	{method: "GET", path: "/api/report/{accessToken}/matrix", summary: "Risks on the probability/impact grid with tier boundaries",
+		responses: map[int]any{200: reportMatrixResponse{}, 202: reportPending{}, 404: errBody, 410: errBody, 429: errBody}},
+	{method: "GET", path: "/api/reports/compare", summary: "Per-question deltas between two reports of the same customer",
+		query: []apiParam{
+			{name: "a", description: "access token of the earlier report", required: true},
+			{name: "b", description: "access token of the later report", required: true},
+		},
+		responses: map[int]any{200: compareReportsResponse{}, 400: errBody, 403: errBody, 404: errBody, 409: errBody, 410: errBody, 429: errBody}},
+
+	{method: "GET", path: "/api/admin/config", summary: "Redacted configuration", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminConfigResponse{}}},
+	{method: "GET", path: "/api/admin/settings", summary: "Runtime settings and their effective values", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminSettingsResponse{}}},
+	{method: "PUT", path: "/api/admin/settings/{key}", summary: "Store a runtime setting", auth: authAdmin, admin: true,
+		request:   putSettingRequest{},
+		responses: map[int]any{200: db.RuntimeSetting{}, 400: errBody}},
+	{method: "DELETE", path: "/api/admin/settings/{key}", summary: "Revert a runtime setting to its default", auth: authAdmin, admin: true,
+		responses: map[int]any{204: nil, 404: errBody}},
+	{method: "GET", path: "/api/admin/flags", summary: "Feature flags and their effective percentages", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminFlagsResponse{}}},
+	{method: "PUT", path: "/api/admin/flags/{name}", summary: "Set a feature flag for an environment", auth: authAdmin, admin: true,
+		request:   putFlagRequest{},
+		responses: map[int]any{200: db.FeatureFlag{}, 400: errBody}},
+	{method: "DELETE", path: "/api/admin/flags/{name}", summary: "Remove a feature flag rule", auth: authAdmin, admin: true,
+		query:     []apiParam{{name: "environment", description: "the rule's environment; omit for the all-environments rule"}},
+		responses: map[int]any{204: nil, 404: errBody}},
+	{method: "GET", path: "/api/admin/products", summary: "List every product, including inactive ones", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminProductsList{}}},
+	{method: "PUT", path: "/api/admin/products/{sku}", summary: "Create or replace a product", auth: authAdmin, admin: true,
+		request:   putProductRequest{},
+		responses: map[int]any{200: db.Product{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/playbooks", summary: "List every industry playbook snippet, including inactive ones", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminPlaybooksList{}}},
+	{method: "PUT", path: "/api/admin/playbooks/{slug}", summary: "Create or replace an industry playbook snippet", auth: authAdmin, admin: true,
+		request:   putPlaybookRequest{},
+		responses: map[int]any{200: db.PlaybookSnippet{}, 400: errBody}},
+	{method: "DELETE", path: "/api/admin/playbooks/{slug}", summary: "Delete an industry playbook snippet", auth: authAdmin, admin: true,
+		responses: map[int]any{204: nil, 404: errBody}},
+	{method: "PUT", path: "/api/admin/sections/{sectionID}", summary: "Replace a questionnaire section's title, description, order and gating", auth: authAdmin, admin: true,
+		request:   putSectionRequest{},
+		responses: map[int]any{200: adminSectionResponse{}, 400: errBody, 404: errBody}},
+	{method: "PUT", path: "/api/admin/tiers/{tier}", summary: "Replace a risk tier's label, colour, description and review cadence", auth: authAdmin, admin: true,
+		request:   putTierRequest{},
+		responses: map[int]any{200: db.TierTheme{}, 400: errBody, 404: errBody}},
+	{method: "DELETE", path: "/api/admin/reports/{reportID}", summary: "Revoke a report so its links stop working", auth: authAdmin, admin: true,
+		request:   revokeReportRequest{},
+		responses: map[int]any{200: revokeReportResponse{}, 400: errBody, 404: errBody}},
+	{method: "GET", path: "/api/admin/reports/{reportID}/transcripts", summary: "Raw AI requests and responses recorded while generating a report", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminTranscriptsResponse{}, 400: errBody, 404: errBody}},
+	{method: "GET", path: "/api/admin/reports/{reportID}/edits", summary: "Corrections made to a report's AI text, with the values they replaced", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminEditsResponse{}, 400: errBody}},
+	{method: "POST", path: "/api/admin/reports/{reportID}/edits", summary: "Correct a report's executive summary or one risk's AI hedge", auth: authAdmin, admin: true,
+		request:   postEditRequest{},
+		responses: map[int]any{201: aiEditResponse{}, 400: errBody, 404: errBody, 409: errBody}},
+	{method: "GET", path: "/api/admin/reports/{reportID}/notes", summary: "Support notes on a report's session, oldest first", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminNotesResponse{}, 400: errBody, 404: errBody}},
+	{method: "POST", path: "/api/admin/reports/{reportID}/notes", summary: "Add a support note about a report", auth: authAdmin, admin: true,
+		request:   postNoteRequest{},
+		responses: map[int]any{201: supportNoteResponse{}, 400: errBody, 404: errBody}},
+	{method: "GET", path: "/api/admin/sessions/{sessionID}/notes", summary: "Support notes on a session, those about its report included, oldest first", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminNotesResponse{}, 400: errBody, 404: errBody}},
+	{method: "POST", path: "/api/admin/sessions/{sessionID}/notes", summary: "Add a support note about a session", auth: authAdmin, admin: true,
+		request:   postNoteRequest{},
+		responses: map[int]any{201: supportNoteResponse{}, 400: errBody, 404: errBody}},
+	{method: "GET", path: "/api/admin/testimonials", summary: "Quotable testimonials awaiting approval, or approved", auth: authAdmin, admin: true,
+		query:     []apiParam{{name: "status", description: "pending (default) or approved"}},
+		responses: map[int]any{200: adminTestimonialsResponse{}, 400: errBody}},
+	{method: "POST", path: "/api/admin/testimonials/{reportID}/approve", summary: "Approve a testimonial for publication", auth: authAdmin, admin: true,
+		responses: map[int]any{200: approveTestimonialResponse{}, 400: errBody, 404: errBody}},
+	{method: "POST", path: "/api/admin/cohorts/{cohort}", summary: "Import a CSV of pre-answered assessments and schedule their reports", auth: authAdmin, admin: true,
+		query:     []apiParam{{name: "dry_run", description: "true validates without writing"}},
+		request:   csvBody{},
+		responses: map[int]any{200: cohortImportResponse{}, 201: cohortImportResponse{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/duplicates", summary: "Held duplicate purchases awaiting a decision", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminDuplicatesResponse{}}},
+	{method: "POST", path: "/api/admin/duplicates/{sessionID}/resolve", summary: "Refund, keep as credit or release a duplicate purchase", auth: authAdmin, admin: true,
+		request:   resolveDuplicateRequest{},
+		responses: map[int]any{200: resolveDuplicateResponse{}, 400: errBody, 404: errBody, 409: errBody}},
+	{method: "GET", path: "/api/admin/stats", summary: "Funnel, consultation and payment margin statistics", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminStatsResponse{}}},
+	{method: "GET", path: "/api/admin/shadow-scores", summary: "Candidate score profiles run in shadow, compared with production", auth: authAdmin, admin: true,
+		query:     []apiParam{{name: "since", description: "first day, YYYY-MM-DD (UTC); default 30 days ago"}},
+		responses: map[int]any{200: adminShadowScoresResponse{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/queries", summary: "Per-statement query counts and timings of this replica", auth: authAdmin, admin: true,
+		responses: map[int]any{200: adminQueriesResponse{}}},
+	{method: "GET", path: "/api/admin/exports/payments", summary: "CSV of money movements for reconciliation", auth: authAdmin, admin: true,
+		query: []apiParam{
+			{name: "from", description: "first day, YYYY-MM-DD (UTC)", required: true},
+			{name: "to", description: "last day, YYYY-MM-DD (UTC)", required: true},
+			{name: "link", description: "true uploads the CSV to object storage and answers {url, expires_at} instead"},
+		},
+		responses: map[int]any{200: csvBody{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/exports/research", summary: "Anonymised aggregate dataset with small cells suppressed", auth: authAdmin, admin: true,
+		query: []apiParam{
+			{name: "from", description: "first day, YYYY-MM-DD (UTC)", required: true},
+			{name: "to", description: "last day, YYYY-MM-DD (UTC)", required: true},
+			{name: "k", description: "smallest cell published (default and minimum 5)"},
+		},
+		responses: map[int]any{200: researchExportResponse{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/exports/testimonials", summary: "CSV of approved testimonials", auth: authAdmin, admin: true,
+		responses: map[int]any{200: csvBody{}}},
+	{method: "GET", path: "/api/admin/sessions", summary: "Sessions, newest first", auth: authAdmin, admin: true,
+		query: []apiParam{
+			{name: "payment_status", description: "pending, paid, failed or refunded"},
+			{name: "cursor", description: "next_cursor from the previous page"},
+			{name: "limit", description: "page size, 1–200 (default 50)"},
+		},
+		responses: map[int]any{200: listSessionsResponse{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/emails", summary: "Email log, newest first", auth: authAdmin, admin: true,
+		query: []apiParam{
+			{name: "template", description: "exact template name, e.g. report_ready"},
+			{name: "cursor", description: "next_cursor from the previous page"},
+			{name: "limit", description: "page size, 1–200 (default 50)"},
+		},
+		responses: map[int]any{200: listEmailLogResponse{}, 400: errBody}},
+	{method: "GET", path: "/api/admin/stripe-events", summary: "Stored Stripe events, newest first, with a payload preview", auth: authAdmin, admin: true,
+		query: []apiParam{
+			{name: "status", description: "failed, pending or processed"},
+			{name: "type", description: "exact Stripe event type"},
+			{name: "cursor", description: "next_cursor from the previous page"},
+			{name: "before", description: "deprecated: next_before from the previous page; 400 once that event is deleted. Use cursor"},
+			{name: "limit", description: "page size, 1–200 (default 50)"},
+		},
+		responses: map[int]any{200: listStripeEventsResponse{}, 400: errBody}},
+	{method: "POST", path: "/api/admin/stripe-events/reprocess", summary: "Replay the oldest failed Stripe events the worker has stopped retrying", auth: authAdmin, admin: true,
+		request:   reprocessStripeEventsRequest{},
+		responses: map[int]any{200: reprocessStripeEventsResponse{}, 400: errBody}},
+	{method: "POST", path: "/api/admin/stripe-events/{eventID}/replay", summary: "Run a stored Stripe event through its handler again", auth: authAdmin, admin: true,
+		responses: map[int]any{200: replayStripeEventResponse{}, 404: errBody}},
+}
+
+// openAPIDocument builds the document for the routes this server mounts.
+func (s *Server) openAPIDocument() map[string]any {
+	b := &schemaBuilder{components: map[string]any{}}
+	paths := map[string]map[string]any{}
+
+	for _, op := range apiOperations {
+		if op.admin && s.cfg.AdminAPIKey == "" || op.devOnly && s.cfg.Env == "production" ||
+			op.emailHook && s.cfg.ResendWebhookSecret == "" ||
+			op.partners && len(s.cfg.Partners) == 0 || op.embeds && s.cfg.Embed == nil {
+			continue
+		}
+		doc := map[string]any{
+			"summary":     op.summary,
+			"operationId": operationID(op),
+			"responses":   b.responses(op.responses),
+		}
+		var params []any
+		for _, name := range pathParams(op.path) {
+			params = append(params, map[string]any{
+				"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, p := range op.query {
+			params = append(params, map[string]any{
+				"name": p.name, "in": "query", "required": p.required, "description": p.description,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+		if params != nil {
+			doc["parameters"] = params
+		}
+		switch op.request.(type) {
+		case nil:
+		case csvBody:
+			doc["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"text/csv": map[string]any{"schema": map[string]any{"type": "string"}}},
+			}
+		default:
+			doc["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(op.request))}},
+			}
+		}
+		switch op.auth {
+		case authAnonToken:
+			doc["security"] = []any{map[string]any{"anonToken": []string{}}}
+		case authAdmin:
+			doc["security"] = []any{map[string]any{"adminKey": []string{}}}
+		}
+		if paths[op.path] == nil {
+			paths[op.path] = map[string]any{}
+		}
+		paths[op.path][strings.ToLower(op.method)] = doc
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Asymmetric Risk Mapper API",
+			"version":     openAPIVersion,
+			"description": "Paths are listed unversioned, as served to clients that name no version (v1). Each is also served under /api/v{N}, e.g. /api/v1/session; the API-Version response header names the version served. v2 differs only in GET /api/products, where each price is an object {amount_cents, currency}.",
+		},
+		"servers": []any{map[string]any{"url": s.cfg.BaseURL}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": b.components,
+			"securitySchemes": map[string]any{
+				"anonToken": map[string]any{"type": "apiKey", "in": "header", "name": "X-Anon-Token"},
+				"adminKey":  map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operationID derives a stable identifier, e.g. "PUT /api/session/{sessionID}/answers"
+// becomes "putSessionAnswers".
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.method)
+	for _, seg := range strings.Split(strings.TrimPrefix(op.path, "/api"), "/") {
+		if seg == "" || strings.HasPrefix(seg, "{") {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '.' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, seg[1:len(seg)-1])
+		}
+	}
+	return names
+}
+
+// ─── SCHEMAS ──────────────────────────────────────────────────────────────────
+
+// schemaBuilder turns Go types into JSON schemas, registering named structs
+// under components/schemas.
+type schemaBuilder struct {
+	components map[string]any
+}
+
+// knownSchemas covers types whose JSON encoding is not their struct layout.
+var knownSchemas = map[reflect.Type]map[string]any{
+	reflect.TypeOf(time.Time{}):       {"type": "string", "format": "date-time"},
+	reflect.TypeOf(uuid.UUID{}):       {"type": "string", "format": "uuid"},
+	reflect.TypeOf(json.RawMessage{}): {},
+	reflect.TypeOf(sql.NullString{}):  {"type": "string", "nullable": true},
+	reflect.TypeOf(sql.NullTime{}):    {"type": "string", "format": "date-time", "nullable": true},
+	reflect.TypeOf(sql.NullInt16{}):   {"type": "integer", "nullable": true},
+	reflect.TypeOf(sql.NullInt32{}):   {"type": "integer", "nullable": true},
+	reflect.TypeOf(sql.NullInt64{}):   {"type": "integer", "nullable": true},
+	reflect.TypeOf(sql.NullBool{}):    {"type": "boolean", "nullable": true},
+}
+
+func (b *schemaBuilder) responses(bodies map[int]any) map[string]any {
+	out := make(map[string]any, len(bodies))
+	for code, body := range bodies {
+		resp := map[string]any{"description": http.StatusText(code)}
+		switch body.(type) {
+		case nil:
+		case csvBody:
+			resp["content"] = map[string]any{"text/csv": map[string]any{"schema": map[string]any{"type": "string"}}}
+		case htmlBody:
+			resp["content"] = map[string]any{"text/html": map[string]any{"schema": map[string]any{"type": "string"}}}
+		case pdfBody:
+			resp["content"] = map[string]any{"application/pdf": map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}}
+		default:
+			resp["content"] = map[string]any{"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(body))}}
+		}
+		out[strconv.Itoa(code)] = resp
+	}
+	return out
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	if known, ok := knownSchemas[t]; ok {
+		return maps.Clone(known)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := b.schema(t.Elem())
+		s["nullable"] = true
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		name := componentName(t)
+		if _, ok := b.components[name]; !ok {
+			b.components[name] = nil // reserve first, in case of recursion
+			b.components[name] = b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	// Interfaces and anything else: any JSON value.
+	return map[string]any{}
+}
+
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+	obj := map[string]any{"type": "object", "properties": props}
+	if required != nil {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// componentName exports the Go type name, so createSessionRequest becomes
+// CreateSessionRequest.
+func componentName(t reflect.Type) string {
+	name := t.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// ─── HANDLERS ─────────────────────────────────────────────────────────────────
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respond(w, http.StatusOK, s.openAPIDocument())
+}
+
+// swaggerUIPage loads Swagger UI from a CDN so no assets are vendored. It is
+// mounted outside production only.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Asymmetric Risk Mapper API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>window.ui = SwaggerUIBundle({url: "/api/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(swaggerUIPage))
+}
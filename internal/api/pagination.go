@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ─── CURSOR PAGINATION ────────────────────────────────────────────────────────
+//
+// The admin listings (sessions, emails, stripe-events) page newest first by
+// keyset rather than offset, so a row written while an operator pages does
+// not shift the rest of the listing onto the wrong page. Each orders by a
+// timestamp and then a unique ID, so rows sharing a timestamp still have one
+// fixed order and land on exactly one page.
+//
+// The contract is the same for every listing:
+//
+//   - limit (default 50, max 200) caps the page; cursor continues a listing.
+//   - When there is another page the response has next_cursor and a
+//     Link: <...>; rel="next" header with the request URL plus that cursor.
+//     The last page has neither.
+//   - A cursor is opaque: the sort key of the page's last row. It is only
+//     valid for the listing that returned it, with the same filters.
+//
+// A handler fetches page.fetchRows() rows, one more than it serves, so
+// whether there is a next page is known without a count query.
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// pageCursor is the sort key of the last row served.
+type pageCursor struct {
+	At time.Time
+	ID string
+}
+
+// encode renders c as base64url("<RFC 3339 time> <id>").
+func (c pageCursor) encode() string {
+	raw := c.At.UTC().Format(time.RFC3339Nano) + " " + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+var errBadCursor = errors.New("cursor is not one this API returned")
+
+func decodeCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errBadCursor
+	}
+	at, id, ok := strings.Cut(string(raw), " ")
+	if !ok || id == "" {
+		return pageCursor{}, errBadCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return pageCursor{}, errBadCursor
+	}
+	return pageCursor{At: t, ID: id}, nil
+}
+
+// pageRequest is a listing's limit and, when continuing, its cursor.
+type pageRequest struct {
+	Limit     int
+	Cursor    pageCursor
+	HasCursor bool
+}
+
+// parsePageRequest reads limit and cursor from the query string. msg is the
+// 400 message when either is invalid.
+func parsePageRequest(r *http.Request) (p pageRequest, msg string) {
+	p.Limit = defaultPageLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return p, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)
+		}
+		p.Limit = n
+	}
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := decodeCursor(v)
+		if err != nil {
+			return p, err.Error()
+		}
+		p.Cursor, p.HasCursor = c, true
+	}
+	return p, ""
+}
+
+// fetchRows is the row limit for the page's query: one past the page, to
+// learn whether another follows.
+func (p pageRequest) fetchRows() int32 {
+	return int32(p.Limit + 1)
+}
+
+// trimPage cuts rows, fetched with fetchRows, to the page and returns the
+// cursor of the next page, or "" on the last page.
+func trimPage[T any](p pageRequest, rows []T, key func(T) pageCursor) ([]T, string) {
+	if len(rows) <= p.Limit {
+		return rows, ""
+	}
+	rows = rows[:p.Limit]
+	return rows, key(rows[len(rows)-1]).encode()
+}
+
+// setNextLink adds a rel="next" Link to the request's own URL with cursor
+// set to next. The path is the one the client asked for, with any /v{N}
+// segment that negotiateAPIVersion stripped before routing.
+func setNextLink(w http.ResponseWriter, r *http.Request, next string) {
+	if next == "" {
+		return
+	}
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = r.URL
+	}
+	q := u.Query()
+	q.Set("cursor", next)
+	link := url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: q.Encode()}
+	w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, link.String()))
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// maxPlaybookBodyRunes matches the AI prompt's per-field cap; a longer body
+// would be cut off mid-sentence when sent.
+const maxPlaybookBodyRunes = 1000
+
+// ─── GET /api/admin/playbooks ─────────────────────────────────────────────────
+//
+// Lists every industry playbook snippet, including inactive ones, grouped by
+// industry.
+
+func (s *Server) handleAdminListPlaybooks(w http.ResponseWriter, r *http.Request) {
+	snippets, err := s.q.ListPlaybookSnippets(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list playbook snippets: %w", err))
+		return
+	}
+	respond(w, http.StatusOK, map[string]any{"snippets": snippets})
+}
+
+// ─── PUT /api/admin/playbooks/:slug ───────────────────────────────────────────
+//
+// Creates or replaces a playbook snippet. The worker sends up to
+// AI_PLAYBOOK_SNIPPETS active snippets for the session's industry with the
+// risks, those whose keywords appear in the risks' wording first. Changes
+// apply to the next report.
+
+type putPlaybookRequest struct {
+	Industry string   `json:"industry"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Keywords []string `json:"keywords"`
+	Active   *bool    `json:"active"`
+}
+
+func (s *Server) handleAdminPutPlaybook(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	var req putPlaybookRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	req.Industry = strings.TrimSpace(req.Industry)
+	req.Title = strings.TrimSpace(req.Title)
+	req.Body = strings.TrimSpace(req.Body)
+	switch {
+	case req.Industry == "":
+		respondErr(w, http.StatusBadRequest, "industry is required")
+		return
+	case req.Title == "":
+		respondErr(w, http.StatusBadRequest, "title is required")
+		return
+	case req.Body == "":
+		respondErr(w, http.StatusBadRequest, "body is required")
+		return
+	case utf8.RuneCountInString(req.Body) > maxPlaybookBodyRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("body must be at most %d characters", maxPlaybookBodyRunes))
+		return
+	}
+	keywords := []string{}
+	for _, kw := range req.Keywords {
+		if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+	active := req.Active == nil || *req.Active
+
+	snippet, err := s.q.UpsertPlaybookSnippet(r.Context(), db.UpsertPlaybookSnippetParams{
+		Slug:     slug,
+		Industry: req.Industry,
+		Title:    req.Title,
+		Body:     req.Body,
+		Keywords: keywords,
+		Active:   active,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("upsert playbook snippet: %w", err))
+		return
+	}
+
+	s.logger.Info("admin: playbook snippet updated",
+		"slug", snippet.Slug,
+		"industry", snippet.Industry,
+		"active", snippet.Active,
+		logField(r),
+	)
+	respond(w, http.StatusOK, snippet)
+}
+
+// ─── DELETE /api/admin/playbooks/:slug ────────────────────────────────────────
+//
+// Removes a playbook snippet. Reports already generated with it are
+// unaffected.
+
+func (s *Server) handleAdminDeletePlaybook(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	n, err := s.q.DeletePlaybookSnippet(r.Context(), slug)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("delete playbook snippet: %w", err))
+		return
+	}
+	if n == 0 {
+		respondErr(w, http.StatusNotFound, "playbook snippet not found")
+		return
+	}
+
+	s.logger.Info("admin: playbook snippet deleted", "slug", slug, logField(r))
+	w.WriteHeader(http.StatusNoContent)
+}
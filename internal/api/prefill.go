@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/prefill"
+)
+
+// ─── POST /api/session/:sessionID/import ──────────────────────────────────────
+//
+// Pre-fills a session from a partner's signed token (see package prefill):
+// the business context and any answers the partner already knows, so an
+// accountant's or consultant's client starts with part of the assessment
+// done. The frontend posts the token it found in the partner's link.
+//
+// Nothing the client has entered is overwritten: context fields that are
+// already set and questions already answered are skipped. Answers are
+// validated as PUT .../answers validates them, and the whole import is
+// rejected if any is invalid. Only mounted when PARTNER_KEYS is set.
+
+type importPrefillRequest struct {
+	Token string `json:"token"`
+}
+
+type importPrefillResponse struct {
+	Partner  string                `json:"partner"`
+	Imported int                   `json:"imported"`
+	Skipped  []string              `json:"skipped"` // question IDs already answered
+	Context  updateContextResponse `json:"context"`
+}
+
+func (s *Server) handleImportPrefill(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUID(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid session_id")
+		return
+	}
+
+	var req importPrefillRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	p, err := s.cfg.Partners.Verify(strings.TrimSpace(req.Token), time.Now())
+	if errors.Is(err, prefill.ErrInvalidToken) {
+		s.logger.Info("prefill: rejected token", "session_id", sessionID, "error", err, logField(r))
+		respondErr(w, http.StatusBadRequest, "invalid or expired pre-fill token")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("verify pre-fill token: %w", err))
+		return
+	}
+
+	questions, err := s.q.GetAllQuestionDefinitions(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get questions: %w", err))
+		return
+	}
+	if limit := s.maxAnswers(len(questions)); len(p.Answers) > limit {
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("too many answers in pre-fill (max %d)", limit))
+		return
+	}
+	byID := make(map[string]db.QuestionDefinition, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+	// Sorted so errors and writes do not depend on map order.
+	ids := make([]string, 0, len(p.Answers))
+	for id := range p.Answers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		q, ok := byID[id]
+		if !ok {
+			respondErr(w, http.StatusBadRequest, fmt.Sprintf("unknown question_id %q in pre-fill", id))
+			return
+		}
+		if msg := s.checkAnswer(r, sessionID, q, p.Answers[id]); msg != "" {
+			respondErr(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	session, err := s.q.GetSessionByID(r.Context(), sessionID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get session: %w", err))
+		return
+	}
+	bizName, industry, stage := keepSet(session.BizName.String, p.BizName), keepSet(session.Industry.String, p.Industry), keepSet(session.Stage.String, p.Stage)
+	if bizName != session.BizName.String || industry != session.Industry.String || stage != session.Stage.String {
+		session, err = s.q.UpdateSessionContext(r.Context(), db.UpdateSessionContextParams{
+			ID:       sessionID,
+			BizName:  nullString(bizName),
+			Industry: nullString(industry),
+			Stage:    nullString(stage),
+		})
+		if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("update context: %w", err))
+			return
+		}
+	}
+
+	existing, err := s.q.GetAnswersBySession(r.Context(), sessionID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get answers: %w", err))
+		return
+	}
+	answered := make(map[string]bool, len(existing))
+	for _, a := range existing {
+		answered[a.QuestionID] = responded(a.AnswerText, a.Skipped)
+	}
+
+	resp := importPrefillResponse{Partner: p.Partner, Skipped: []string{}}
+	for _, id := range ids {
+		if answered[id] {
+			resp.Skipped = append(resp.Skipped, id)
+			continue
+		}
+		if _, err := s.q.UpsertAnswer(r.Context(), db.UpsertAnswerParams{
+			SessionID:  sessionID,
+			QuestionID: id,
+			AnswerText: p.Answers[id],
+		}); err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("upsert answer %q: %w", id, err))
+			return
+		}
+		resp.Imported++
+	}
+	resp.Context = updateContextResponse{
+		SessionID: session.ID.String(),
+		BizName:   session.BizName.String,
+		Industry:  session.Industry.String,
+		Stage:     session.Stage.String,
+	}
+
+	s.logger.Info("prefill: imported",
+		"session_id", sessionID,
+		"partner", p.Partner,
+		"imported", resp.Imported,
+		"skipped", len(resp.Skipped),
+		logField(r),
+	)
+	respond(w, http.StatusOK, resp)
+}
+
+// keepSet returns current unless it is blank, and imported otherwise.
+func keepSet(current, imported string) string {
+	if strings.TrimSpace(current) != "" {
+		return current
+	}
+	return strings.TrimSpace(imported)
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+)
+
+// defaultProductSKU is charged when a checkout request names no product, which
+// keeps clients written before the catalog existed working unchanged.
+const defaultProductSKU = "standard"
+
+// ─── GET /api/products ────────────────────────────────────────────────────────
+//
+// Lists the products a customer can buy, cheapest first. No auth — the
+// frontend renders the pricing options from this before a session exists.
+// With ?session_id= the prices are those the session will be charged, which
+// differ from the catalog's in the price experiment.
+//
+// From API v2 each price is a money object, so an amount is never read
+// without its currency.
+
+type productResponse struct {
+	SKU        string `json:"sku"`
+	Name       string `json:"name"`
+	PriceCents int32  `json:"price_cents"`
+	Currency   string `json:"currency"`
+	ReportType string `json:"report_type"`
+}
+
+type productV2Response struct {
+	SKU        string `json:"sku"`
+	Name       string `json:"name"`
+	Price      money  `json:"price"`
+	ReportType string `json:"report_type"`
+}
+
+type money struct {
+	AmountCents int32  `json:"amount_cents"`
+	Currency    string `json:"currency"`
+}
+
+func (s *Server) handleListProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := s.q.ListActiveProducts(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list products: %w", err))
+		return
+	}
+
+	variant := experiments.Control
+	if id, err := parseUUID(r.URL.Query().Get("session_id")); err == nil {
+		variant = s.sessionVariant(r, id, experiments.Price)
+	}
+
+	if apiVersion(r.Context()) >= 2 {
+		out := make([]productV2Response, len(products))
+		for i, p := range products {
+			out[i] = productV2Response{
+				SKU:        p.Sku,
+				Name:       p.Name,
+				Price:      money{AmountCents: s.cfg.Experiments.PriceCents(variant, p.Sku, p.PriceCents), Currency: p.Currency},
+				ReportType: string(p.ReportType),
+			}
+		}
+		respond(w, http.StatusOK, map[string]any{"products": out})
+		return
+	}
+
+	out := make([]productResponse, len(products))
+	for i, p := range products {
+		out[i] = productResponse{
+			SKU:        p.Sku,
+			Name:       p.Name,
+			PriceCents: s.cfg.Experiments.PriceCents(variant, p.Sku, p.PriceCents),
+			Currency:   p.Currency,
+			ReportType: string(p.ReportType),
+		}
+	}
+	respond(w, http.StatusOK, map[string]any{"products": out})
+}
+
+// lookupProduct returns the active product for sku, or ok=false after writing
+// a 400 if it does not exist or has been retired.
+func (s *Server) lookupProduct(w http.ResponseWriter, r *http.Request, sku string) (db.Product, bool) {
+	product, err := s.q.GetProductBySKU(r.Context(), sku)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !product.Active) {
+		respondErr(w, http.StatusBadRequest, "unknown product")
+		return db.Product{}, false
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get product: %w", err))
+		return db.Product{}, false
+	}
+	return product, true
+}
+
+// ─── GET /api/admin/products ──────────────────────────────────────────────────
+//
+// Lists every product, including inactive ones.
+
+func (s *Server) handleAdminListProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := s.q.ListProducts(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list products: %w", err))
+		return
+	}
+	respond(w, http.StatusOK, map[string]any{"products": products})
+}
+
+// ─── PUT /api/admin/products/:sku ─────────────────────────────────────────────
+//
+// Creates or replaces a product. Price changes apply to the next checkout;
+// PaymentIntents that already exist keep the amount they were created with.
+// Retire a product with "active": false rather than deleting it — sessions
+// that bought it still reference the SKU.
+
+type putProductRequest struct {
+	Name       string `json:"name"`
+	PriceCents int32  `json:"price_cents"`
+	Currency   string `json:"currency"`
+	ReportType string `json:"report_type"`
+	Active     *bool  `json:"active"`
+}
+
+func (s *Server) handleAdminPutProduct(w http.ResponseWriter, r *http.Request) {
+	sku := chi.URLParam(r, "sku")
+
+	var req putProductRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if req.Name == "" {
+		respondErr(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	// Stripe's minimum charge for USD is $0.50.
+	if req.PriceCents < 50 {
+		respondErr(w, http.StatusBadRequest, "price_cents must be at least 50")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "usd"
+	}
+	reportType := db.ReportType(req.ReportType)
+	if reportType == "" {
+		reportType = db.ReportTypeStandard
+	}
+	if reportType != db.ReportTypeStandard && reportType != db.ReportTypePremium {
+		respondErr(w, http.StatusBadRequest, "report_type must be standard or premium")
+		return
+	}
+	active := req.Active == nil || *req.Active
+
+	product, err := s.q.UpsertProduct(r.Context(), db.UpsertProductParams{
+		Sku:        sku,
+		Name:       req.Name,
+		PriceCents: req.PriceCents,
+		Currency:   strings.ToLower(req.Currency),
+		ReportType: reportType,
+		Active:     active,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("upsert product: %w", err))
+		return
+	}
+
+	s.logger.Info("admin: product updated",
+		"sku", product.Sku,
+		"price_cents", product.PriceCents,
+		"active", product.Active,
+		logField(r),
+	)
+	respond(w, http.StatusOK, product)
+}
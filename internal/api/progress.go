@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ─── GET /api/session/:sessionID/progress ─────────────────────────────────────
+//
+// Returns how much of the questionnaire the session has answered, per section
+// and overall, counted against the current question definitions. A question
+// counts as answered once it has a non-blank answer — the same rule the
+// questions endpoint uses for total_answered. Answers to questions that have
+// since been removed are not counted.
+//
+// Requires X-Anon-Token — the requireAnonToken middleware runs first.
+
+type sectionProgress struct {
+	SectionID    string `json:"section_id"`
+	SectionTitle string `json:"section_title"`
+	Answered     int    `json:"answered"`
+	Total        int    `json:"total"`
+}
+
+type progressResponse struct {
+	Sections []sectionProgress `json:"sections"`
+	Answered int               `json:"answered"`
+	Total    int               `json:"total"`
+	// PercentComplete is Answered/Total as a whole percentage, rounded down so
+	// 100 is only reported when every question is answered.
+	PercentComplete int `json:"percent_complete"`
+	// RequiredRemaining is the number of required questions still unanswered.
+	RequiredRemaining int `json:"required_remaining"`
+}
+
+func (s *Server) handleGetProgress(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUID(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid session_id")
+		return
+	}
+
+	questions, err := s.q.GetAllQuestionDefinitions(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get questions: %w", err))
+		return
+	}
+	answerRows, err := s.q.GetAnswersBySession(r.Context(), sessionID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get answers: %w", err))
+		return
+	}
+
+	answered := make(map[string]bool, len(answerRows))
+	for _, a := range answerRows {
+		answered[a.QuestionID] = responded(a.AnswerText, a.Skipped)
+	}
+
+	// Questions arrive ordered by section, so sections keep questionnaire order.
+	resp := progressResponse{Sections: []sectionProgress{}}
+	index := make(map[string]int)
+	for _, q := range questions {
+		i, ok := index[string(q.SectionID)]
+		if !ok {
+			i = len(resp.Sections)
+			index[string(q.SectionID)] = i
+			resp.Sections = append(resp.Sections, sectionProgress{
+				SectionID:    string(q.SectionID),
+				SectionTitle: q.SectionTitle,
+			})
+		}
+
+		resp.Sections[i].Total++
+		resp.Total++
+		switch {
+		case answered[q.ID]:
+			resp.Sections[i].Answered++
+			resp.Answered++
+		case q.Required:
+			resp.RequiredRemaining++
+		}
+	}
+	if resp.Total > 0 {
+		resp.PercentComplete = resp.Answered * 100 / resp.Total
+	}
+
+	respond(w, http.StatusOK, resp)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// ─── GET /api/admin/queries ───────────────────────────────────────────────────
+//
+// Per-statement counts and timings from this replica's query watcher since
+// it started, the most total time first. A statement called far more often
+// than its callers suggests an N+1; a rising mean a missing index.
+
+type queryStatsResponse struct {
+	Name     string  `json:"name"`
+	Calls    int64   `json:"calls"`
+	Errors   int64   `json:"errors"`
+	Timeouts int64   `json:"timeouts"`
+	Canceled int64   `json:"canceled"`
+	Slow     int64   `json:"slow"`
+	TotalMS  float64 `json:"total_ms"`
+	MeanMS   float64 `json:"mean_ms"`
+	MaxMS    float64 `json:"max_ms"`
+}
+
+type adminQueriesResponse struct {
+	TimeoutMS       int64                `json:"timeout_ms"`        // 0: no timeout
+	SlowThresholdMS int64                `json:"slow_threshold_ms"` // 0: slow queries are not logged
+	Statements      []queryStatsResponse `json:"statements"`
+}
+
+func (s *Server) handleAdminQueries(w http.ResponseWriter, r *http.Request) {
+	cfg := s.cfg.Queries.Config()
+	stats := s.cfg.Queries.Snapshot()
+	out := adminQueriesResponse{
+		TimeoutMS:       cfg.Timeout.Milliseconds(),
+		SlowThresholdMS: cfg.SlowThreshold.Milliseconds(),
+		Statements:      make([]queryStatsResponse, len(stats)),
+	}
+	for i, st := range stats {
+		out.Statements[i] = queryStatsResponse{
+			Name:     st.Name,
+			Calls:    st.Calls,
+			Errors:   st.Errors,
+			Timeouts: st.Timeouts,
+			Canceled: st.Canceled,
+			Slow:     st.Slow,
+			TotalMS:  millis(st.Total),
+			MeanMS:   millis(st.Mean()),
+			MaxMS:    millis(st.Max),
+		}
+	}
+	respond(w, http.StatusOK, out)
+}
+
+// millis is d in milliseconds, to a tenth.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()/100) / 10
+}
@@ -47,13 +47,28 @@ type questionResponse struct {
 	// if the user hasn't answered it yet. Included so the client can restore
 	// state in a single round-trip without a separate GET /answers call.
 	SavedAnswer string `json:"saved_answer"`
+	// Skipped is true when the user marked the question as not applicable.
+	Skipped bool `json:"skipped,omitempty"`
 }
 
 type getQuestionsResponse struct {
 	Questions []questionResponse `json:"questions"`
-	// TotalAnswered is the count of non-empty answers — used by the frontend
+	// Sections are the questionnaire's sections in order, with the
+	// session's progress through each (see sections.go).
+	Sections []sectionResponse `json:"sections"`
+	// TotalAnswered is the count of non-empty or skipped answers — used by the frontend
 	// to render the progress bar without counting locally.
 	TotalAnswered int `json:"total_answered"`
+	// Limits are the bounds PUT /answers enforces, so the frontend can size
+	// its batches from the server rather than a hard-coded copy.
+	Limits answerLimits `json:"limits"`
+}
+
+// answerLimits describes what a single answers request may contain.
+type answerLimits struct {
+	QuestionCount        int `json:"question_count"`
+	MaxAnswersPerRequest int `json:"max_answers_per_request"`
+	MaxAnswerLength      int `json:"max_answer_length"`
 }
 
 // radioScoringConfig is used only for JSON unmarshalling inside this handler.
@@ -89,8 +104,18 @@ func (s *Server) handleGetQuestions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	savedAnswers := make(map[string]string, len(answerRows))
+	skipped := make(map[string]bool, len(answerRows))
+	answered := make(map[string]bool, len(answerRows))
 	for _, a := range answerRows {
 		savedAnswers[a.QuestionID] = a.AnswerText
+		skipped[a.QuestionID] = a.Skipped
+		answered[a.QuestionID] = responded(a.AnswerText, a.Skipped)
+	}
+
+	sections, err := s.q.ListQuestionSections(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list sections: %w", err))
+		return
 	}
 
 	totalAnswered := 0
@@ -98,7 +123,7 @@ func (s *Server) handleGetQuestions(w http.ResponseWriter, r *http.Request) {
 
 	for _, q := range questions {
 		saved := savedAnswers[q.ID]
-		if saved != "" {
+		if saved != "" || skipped[q.ID] {
 			totalAnswered++
 		}
 
@@ -116,6 +141,7 @@ func (s *Server) handleGetQuestions(w http.ResponseWriter, r *http.Request) {
 			RiskName:     q.RiskName,
 			RiskDesc:     q.RiskDesc,
 			SavedAnswer:  saved,
+			Skipped:      skipped[q.ID],
 		}
 
 		// For radio questions, unpack the scoring config into labelled options
@@ -144,6 +170,12 @@ func (s *Server) handleGetQuestions(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, http.StatusOK, getQuestionsResponse{
 		Questions:     out,
+		Sections:      sectionStates(sections, questions, answered, s.cfg.SectionGating),
 		TotalAnswered: totalAnswered,
+		Limits: answerLimits{
+			QuestionCount:        len(questions),
+			MaxAnswersPerRequest: s.maxAnswers(len(questions)),
+			MaxAnswerLength:      maxAnswerTextLen,
+		},
 	})
 }
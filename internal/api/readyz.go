@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ─── GET /readyz ──────────────────────────────────────────────────────────────
+//
+// Unlike /healthz (process is up), /readyz reports whether the dependencies
+// needed to serve traffic are reachable. Each ReadinessCheck runs in parallel
+// with a short timeout. The response is 200 when every critical check passes
+// and 503 otherwise; non-critical checks are reported but never fail the probe.
+// A draining replica (see drain.go) answers 503 with status "draining"
+// whatever its checks say, so the load balancer takes it out of rotation.
+
+// ReadinessCheck is one dependency probe registered by main.
+type ReadinessCheck struct {
+	// Name is the key used in the response body, e.g. "database" or "ai:deepseek".
+	Name string
+
+	// Critical checks make /readyz return 503 when they fail.
+	Critical bool
+
+	// Check returns nil when the dependency is usable.
+	Check func(ctx context.Context) error
+}
+
+type readinessResult struct {
+	OK       bool   `json:"ok"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	results := make(map[string]readinessResult, len(s.cfg.ReadinessChecks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, c := range s.cfg.ReadinessChecks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := readinessResult{OK: true, Critical: c.Critical}
+			if err := c.Check(ctx); err != nil {
+				res.OK = false
+				res.Error = err.Error()
+			}
+			mu.Lock()
+			results[c.Name] = res
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	for _, res := range results {
+		if res.Critical && !res.OK {
+			status, code = "not_ready", http.StatusServiceUnavailable
+			break
+		}
+	}
+	if s.draining() {
+		status, code = "draining", http.StatusServiceUnavailable
+	}
+
+	respond(w, code, map[string]any{
+		"status": status,
+		"checks": results,
+	})
+}
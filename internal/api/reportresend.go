@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// ─── POST /api/report/resend ──────────────────────────────────────────────────
+//
+// Sends the report-ready email again for the customer's paid reports, so a
+// lost email does not need a support ticket. The request names the purchase
+// email; the email goes to the address stored on the session, never to one
+// taken from the request.
+//
+// The answer is the same 202 whether or not the address has reports, and the
+// emails are sent after responding, so neither the body nor the timing says
+// which addresses are customers. ReportResendIPLimit and ReportResendEmailLimit
+// cap the requests per client IP and per address; over either, 429.
+
+// maxResendReports caps the reports emailed per request, newest first.
+const maxResendReports = 3
+
+// reportResendTimeout bounds the sends started by one request.
+const reportResendTimeout = 30 * time.Second
+
+type reportResendRequest struct {
+	Email string `json:"email"`
+}
+
+type reportResendResponse struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) handleResendReportLinks(w http.ResponseWriter, r *http.Request) {
+	var req reportResendRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	addr := strings.ToLower(strings.TrimSpace(req.Email))
+	if len(addr) > 254 || !strings.Contains(addr, "@") {
+		respondErr(w, http.StatusBadRequest, "a valid email is required")
+		return
+	}
+
+	ip, key := realIP(r), tokenKey(addr)
+	left, limited := s.cfg.ReportResendIPLimit.Locked(r.Context(), ip)
+	if !limited {
+		left, limited = s.cfg.ReportResendEmailLimit.Locked(r.Context(), key)
+	}
+	if limited {
+		w.Header().Set("Retry-After", strconv.Itoa(int(left.Seconds())+1))
+		respondErr(w, http.StatusTooManyRequests, "too many resend requests, try again later")
+		return
+	}
+	if d, locked := s.cfg.ReportResendIPLimit.Fail(r.Context(), ip); locked {
+		s.logger.Warn("report resend: ip rate limited",
+			"ip_hash", s.hashIP(ip),
+			"lockout", d,
+			"audit", true,
+			logField(r),
+		)
+	}
+	s.cfg.ReportResendEmailLimit.Fail(r.Context(), key)
+
+	rows, err := s.q.ListDeliverableReportsByEmail(r.Context(), db.ListDeliverableReportsByEmailParams{
+		Email:   addr,
+		MaxRows: maxResendReports,
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list reports by email: %w", err))
+		return
+	}
+	if len(rows) > 0 {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), reportResendTimeout)
+		reqField := logField(r)
+		go func() {
+			defer cancel()
+			s.resendReportLinks(ctx, rows, reqField)
+		}()
+	}
+
+	respond(w, http.StatusAccepted, reportResendResponse{
+		Message: "if that email has a paid report, a link to it is on its way",
+	})
+}
+
+// resendReportLinks sends and records one report-ready email per row. It runs
+// after the response has gone, so failures are only logged.
+func (s *Server) resendReportLinks(ctx context.Context, rows []db.ListDeliverableReportsByEmailRow, reqField slog.Attr) {
+	for _, row := range rows {
+		if !row.Email.Valid || row.Email.String == "" {
+			continue
+		}
+		sent, err := s.mailer.SendReportReady(ctx, email.ReportReadyParams{
+			To:          row.Email.String,
+			BizName:     row.BizName.String,
+			AccessToken: row.AccessToken,
+		})
+		if err := email.Record(ctx, s.q, email.LogEntry{
+			SessionID: row.SessionID,
+			ReportID:  row.ID,
+			To:        row.Email.String,
+			Template:  email.TemplateReportReady,
+		}, sent, err); err != nil {
+			s.logger.Warn("email log write failed", "template", email.TemplateReportReady, "error", err, reqField)
+		}
+		if err != nil {
+			s.logger.Error("report resend: email failed", "report_id", row.ID, "error", err, reqField)
+			continue
+		}
+		s.logger.Info("report resend: email sent", "report_id", row.ID, "audit", true, reqField)
+	}
+}
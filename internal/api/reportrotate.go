@@ -0,0 +1,126 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// ─── POST /api/report/:accessToken/rotate ─────────────────────────────────────
+//
+// Replaces a report's access token, for a customer who shared the link
+// somewhere public. The old link stops working at once. The request must name
+// the email on the report, and the new link is only ever emailed to that
+// address, never returned, so whoever found the shared link cannot take the
+// report over. 403 when the email does not match; 410 once revoked. The
+// ReportResend limits apply, since every rotation sends an email.
+
+type rotateReportTokenRequest struct {
+	Email string `json:"email"`
+}
+
+type rotateReportTokenResponse struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) handleRotateReportToken(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "accessToken")
+	var req rotateReportTokenRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	addr := strings.TrimSpace(req.Email)
+	if len(addr) > 254 || !strings.Contains(addr, "@") {
+		respondErr(w, http.StatusBadRequest, "a valid email is required")
+		return
+	}
+
+	ip := realIP(r)
+	left, limited := s.cfg.ReportResendIPLimit.Locked(r.Context(), ip)
+	if !limited {
+		left, limited = s.cfg.ReportResendEmailLimit.Locked(r.Context(), tokenKey(token))
+	}
+	if limited {
+		w.Header().Set("Retry-After", strconv.Itoa(int(left.Seconds())+1))
+		respondErr(w, http.StatusTooManyRequests, "too many requests, try again later")
+		return
+	}
+	s.cfg.ReportResendIPLimit.Fail(r.Context(), ip)
+	s.cfg.ReportResendEmailLimit.Fail(r.Context(), tokenKey(token))
+
+	report, err := s.q.GetReportByAccessToken(r.Context(), token)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.reportTokenMiss(r)
+		respondErr(w, http.StatusNotFound, "report not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
+		return
+	}
+	if report.RevokedAt.Valid {
+		respondErr(w, http.StatusGone, errReportRevoked)
+		return
+	}
+	owner := strings.TrimSpace(report.Email.String)
+	if owner == "" || !strings.EqualFold(owner, addr) {
+		s.logger.Info("report rotate: refused, email does not match",
+			"report_id", report.ID,
+			"ip_hash", s.hashIP(ip),
+			logField(r),
+		)
+		respondErr(w, http.StatusForbidden, "email does not match the report")
+		return
+	}
+
+	newToken, err := s.q.RotateReportAccessToken(r.Context(), db.RotateReportAccessTokenParams{
+		ID:          report.ID,
+		AccessToken: token,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Rotated by a concurrent request; its email carries the new link.
+		respondErr(w, http.StatusNotFound, "report not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("rotate access token: %w", err))
+		return
+	}
+	s.reports.forget(r.Context(), token)
+	s.logger.Info("report rotate: access token replaced",
+		"report_id", report.ID,
+		"ip_hash", s.hashIP(ip),
+		"audit", true,
+		logField(r),
+	)
+
+	// The old link is gone either way; if this email fails the customer
+	// can still get the new one through POST /api/report/resend.
+	sent, err := s.mailer.SendReportReady(r.Context(), email.ReportReadyParams{
+		To:          owner,
+		BizName:     report.BizName.String,
+		AccessToken: newToken,
+	})
+	if err := email.Record(r.Context(), s.q, email.LogEntry{
+		SessionID: report.SessionID,
+		ReportID:  report.ID,
+		To:        owner,
+		Template:  email.TemplateReportReady,
+	}, sent, err); err != nil {
+		s.logger.Warn("email log write failed", "template", email.TemplateReportReady, "error", err, logField(r))
+	}
+	if err != nil {
+		s.logger.Error("report rotate: email failed", "report_id", report.ID, "error", err, logField(r))
+	}
+
+	respond(w, http.StatusAccepted, rotateReportTokenResponse{
+		Message: "the old link no longer works; a new one is on its way to the email on the report",
+	})
+}
@@ -2,16 +2,48 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
 )
 
 // ─── GET /api/report/:accessToken ────────────────────────────────────────────
 
+// errReportRevoked is the message for every report link once an admin has
+// revoked the report.
+const errReportRevoked = "this report is no longer available"
+
+// Bounds on the Retry-After of a pending report. Between them the frontend
+// polls at about a quarter of the remaining estimate.
+const (
+	minPendingRetryAfter = 5 * time.Second
+	maxPendingRetryAfter = time.Minute
+)
+
+// reportPending is the 202 body while a report is still being generated.
+type reportPending struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+
+	// QueuePosition is 1 for the next report to be generated, counting
+	// reports already running, and ETASeconds the estimated time until this
+	// one is ready. Both are set when the worker can estimate them.
+	QueuePosition int `json:"queue_position,omitempty"`
+	ETASeconds    int `json:"eta_seconds,omitempty"`
+
+	// ReadyInMinutes is set while the worker is backed up.
+	ReadyInMinutes int `json:"ready_in_minutes,omitempty"`
+}
+
 // reportRiskResponse is the per-risk shape returned in the API response.
 // It flattens db.RiskResult into a clean JSON structure.
 type reportRiskResponse struct {
@@ -27,28 +59,64 @@ type reportRiskResponse struct {
 	// Hedge is the AI-generated narrative if available, otherwise the static
 	// hedge from question_definitions.
 	Hedge string `json:"hedge"`
+	// HedgeEdited is set when an admin has rewritten the AI hedge.
+	HedgeEdited bool `json:"hedge_edited,omitempty"`
+	// Explanation says why the risk scored and ranked as it did: the answer
+	// as scored, the question's P/I mapping, the tier rule and the risk's
+	// weight in overall_score (scoring.Explanation). Absent for reports
+	// scored before explanations were recorded.
+	Explanation json.RawMessage `json:"explanation,omitempty"`
+}
+
+// reportRelationship is one AI-identified link between two of the report's
+// risks, for the report's dependency section: the "from" risk makes the "to"
+// risk more likely or more damaging.
+type reportRelationship struct {
+	FromQuestionID string `json:"from_question_id"`
+	FromRiskName   string `json:"from_risk_name"`
+	ToQuestionID   string `json:"to_question_id"`
+	ToRiskName     string `json:"to_risk_name"`
+	Description    string `json:"description"`
 }
 
 type reportResponse struct {
-	ReportID         string               `json:"report_id"`
-	Status           string               `json:"status"`
-	BizName          string               `json:"biz_name,omitempty"`
-	Industry         string               `json:"industry,omitempty"`
-	Stage            string               `json:"stage,omitempty"`
-	OverallScore     int16                `json:"overall_score"`
-	CriticalCount    int16                `json:"critical_count"`
-	ExecutiveSummary string               `json:"executive_summary,omitempty"`
-	TopPriorityHTML  string               `json:"top_priority_html,omitempty"`
-	Risks            []reportRiskResponse `json:"risks"`
-	GeneratedAt      string               `json:"generated_at,omitempty"`
+	ReportID         string `json:"report_id"`
+	Status           string `json:"status"`
+	BizName          string `json:"biz_name,omitempty"`
+	Industry         string `json:"industry,omitempty"`
+	Stage            string `json:"stage,omitempty"`
+	OverallScore     int16  `json:"overall_score"`
+	CriticalCount    int16  `json:"critical_count"`
+	ExecutiveSummary string `json:"executive_summary,omitempty"`
+	// ExecutiveSummaryEdited is set when an admin has rewritten the summary.
+	ExecutiveSummaryEdited bool                 `json:"executive_summary_edited,omitempty"`
+	TopPriorityHTML        string               `json:"top_priority_html,omitempty"`
+	Risks                  []reportRiskResponse `json:"risks"`
+	Relationships          []reportRelationship `json:"relationships"`
+	GeneratedAt            string               `json:"generated_at,omitempty"`
+	// Tiers are the tiers' labels, colours, descriptions and cadences, as
+	// in GET /api/scoring/meta, for rendering each risk's tier.
+	Tiers []tierMeta `json:"tiers"`
+	// SkippedQuestions are the questions the customer marked as not
+	// applicable. They have no risk and are left out of overall_score.
+	SkippedQuestions []string `json:"skipped_questions,omitempty"`
+	// ConsultationURL is set when the consultation upsell is enabled. The
+	// frontend should register interest via POST .../consultation, which
+	// returns the same link, rather than linking here directly.
+	ConsultationURL string `json:"consultation_url,omitempty"`
 }
 
 // handleGetReport serves the completed risk report. The access token is an
 // opaque 24-byte base64url string stored on the report row — no session
 // authentication is needed. The user receives this link in their email.
 //
-// Returns 404 for an unknown token. Returns 202 Accepted while the report is
-// still being generated (status != ready) so the frontend can poll.
+// Returns 404 for an unknown token, 410 for a revoked report and 429 once
+// the client or token is locked out for guessing (see guardReportToken).
+// Returns 202 Accepted while the report is still being generated
+// (status != ready) so the frontend can poll, with a queue position, an ETA
+// and a Retry-After that says when, and with status "held" while a
+// duplicate purchase waits for a decision. Ready reports are cached for
+// database outages; see degraded.go.
 func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
 	accessToken := chi.URLParam(r, "accessToken")
 	if accessToken == "" {
@@ -59,6 +127,7 @@ func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
 	// Load the report and its session context in one query.
 	row, err := s.q.GetReportByAccessToken(r.Context(), accessToken)
 	if errors.Is(err, sql.ErrNoRows) {
+		s.reportTokenMiss(r)
 		respondErr(w, http.StatusNotFound, "report not found")
 		return
 	}
@@ -66,16 +135,25 @@ func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
 		s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
 		return
 	}
+	if row.RevokedAt.Valid {
+		respondErr(w, http.StatusGone, errReportRevoked)
+		return
+	}
 
-	// Report is still being generated — tell the client to poll.
-	if row.Status != db.ReportStatusReady {
+	if row.HeldAt.Valid {
 		respond(w, http.StatusAccepted, map[string]string{
-			"status":  string(row.Status),
-			"message": "report is being generated, please check back shortly",
+			"status":  "held",
+			"message": "this purchase looks like a repeat of an earlier one and is being reviewed",
 		})
 		return
 	}
 
+	// Report is still being generated — tell the client to poll.
+	if row.Status != db.ReportStatusReady {
+		s.respondPending(w, r, row)
+		return
+	}
+
 	// Load individual risk rows for the full detail view.
 	// We use risk_results rather than the risks_json snapshot so the response
 	// always reflects AI hedges written after initial generation.
@@ -102,6 +180,8 @@ func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
 			Tier:        string(rr.Tier),
 			Section:     rr.Section,
 			Hedge:       hedge,
+			HedgeEdited: rr.AiHedgeEditedAt.Valid,
+			Explanation: rr.Explanation.RawMessage,
 		}
 	}
 
@@ -110,17 +190,90 @@ func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
 		generatedAt = row.GeneratedAt.Time.UTC().Format("2006-01-02T15:04:05Z")
 	}
 
-	respond(w, http.StatusOK, reportResponse{
-		ReportID:         row.ID.String(),
-		Status:           string(row.Status),
-		BizName:          row.BizName.String,
-		Industry:         row.Industry.String,
-		Stage:            row.Stage.String,
-		OverallScore:     row.OverallScore.Int16,
-		CriticalCount:    row.CriticalCount.Int16,
-		ExecutiveSummary: row.ExecutiveSummary.String,
-		TopPriorityHTML:  row.TopPriorityHtml.String,
-		Risks:            risks,
-		GeneratedAt:      generatedAt,
-	})
-}
\ No newline at end of file
+	resp := reportResponse{
+		ReportID:               row.ID.String(),
+		Status:                 string(row.Status),
+		BizName:                row.BizName.String,
+		Industry:               row.Industry.String,
+		Stage:                  row.Stage.String,
+		OverallScore:           row.OverallScore.Int16,
+		CriticalCount:          row.CriticalCount.Int16,
+		ExecutiveSummary:       row.ExecutiveSummary.String,
+		ExecutiveSummaryEdited: row.ExecutiveSummaryEditedAt.Valid,
+		TopPriorityHTML:        row.TopPriorityHtml.String,
+		Risks:                  risks,
+		Relationships:          reportRelationships(row.Relationships.RawMessage, results),
+		GeneratedAt:            generatedAt,
+		Tiers:                  s.tierMetas(r),
+		SkippedQuestions:       row.SkippedQuestions,
+		ConsultationURL:        s.cfg.ConsultationURL,
+	}
+	s.reports.put(r.Context(), accessToken, resp)
+	respond(w, http.StatusOK, resp)
+}
+
+// respondPending answers 202 for a report still being generated, with its
+// place in the queue, an estimate of when it will be ready and a Retry-After
+// that polls often near the estimate and less often while it is far off.
+// During a spike the message says how long it will be.
+func (s *Server) respondPending(w http.ResponseWriter, r *http.Request, row db.GetReportByAccessTokenRow) {
+	resp := reportPending{
+		Status:  string(row.Status),
+		Message: "report is being generated, please check back shortly",
+	}
+	backlog, ok := s.worker.(worker.Backlog)
+	if !ok {
+		respond(w, http.StatusAccepted, resp)
+		return
+	}
+
+	stats := backlog.QueueStats()
+	ahead, err := s.q.CountReportsAhead(r.Context(), row.ID)
+	if err != nil {
+		// The estimate is a courtesy; fall back to this replica's queue.
+		s.logger.Warn("report: count reports ahead failed", "report_id", row.ID, "error", err, logField(r))
+		ahead = int64(stats.Queued + stats.Running)
+	}
+	eta := stats.ETA(int(ahead))
+	resp.QueuePosition = int(ahead) + 1
+	resp.ETASeconds = int(math.Ceil(eta.Seconds()))
+	if stats.Busy() {
+		resp.ReadyInMinutes = int(math.Ceil(eta.Minutes()))
+		resp.Message = fmt.Sprintf("we are busier than usual; your report should be ready in about %d minutes", resp.ReadyInMinutes)
+	}
+
+	retryAfter := min(max(eta/4, minPendingRetryAfter), maxPendingRetryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	respond(w, http.StatusAccepted, resp)
+}
+
+// reportRelationships decodes the report's stored relationships and names
+// their risks. A relationship whose risks are not both in results is left out,
+// as is everything if the column is empty or unreadable — the section is
+// optional and the report is complete without it.
+func reportRelationships(raw json.RawMessage, results []db.RiskResult) []reportRelationship {
+	out := []reportRelationship{}
+	var stored []ai.Relationship
+	if len(raw) == 0 || json.Unmarshal(raw, &stored) != nil {
+		return out
+	}
+	names := make(map[string]string, len(results))
+	for _, rr := range results {
+		names[rr.QuestionID] = rr.RiskName
+	}
+	for _, rel := range stored {
+		from, okFrom := names[rel.From]
+		to, okTo := names[rel.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		out = append(out, reportRelationship{
+			FromQuestionID: rel.From,
+			FromRiskName:   from,
+			ToQuestionID:   rel.To,
+			ToRiskName:     to,
+			Description:    rel.Description,
+		})
+	}
+	return out
+}
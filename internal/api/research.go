@@ -0,0 +1,165 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── GET /api/admin/exports/research?from=YYYY-MM-DD&to=YYYY-MM-DD[&k=N] ──────
+//
+// Returns an anonymised dataset of the reports generated between from and to
+// (both inclusive, UTC) for research and marketing content: overall scores by
+// industry and stage, the overall score band distribution, and how often each
+// question landed in each tier. Nothing identifying leaves the database — no
+// IDs, names, emails or dates finer than the requested range.
+//
+// Small cells are suppressed (k-anonymity): an industry/stage segment with
+// fewer than k reports is left out and counted in suppressed_reports, and any
+// band or tier count below k is returned as null. k defaults to
+// minResearchCell and may only be raised. Revoked and held reports are
+// excluded.
+
+// minResearchCell is the smallest k the research export accepts.
+const minResearchCell = 5
+
+type researchSegment struct {
+	Industry         string  `json:"industry"`
+	Stage            string  `json:"stage"`
+	Reports          int     `json:"reports"`
+	MeanOverallScore float64 `json:"mean_overall_score"`
+}
+
+type researchBand struct {
+	Band    string `json:"band"`
+	Reports *int   `json:"reports"` // nil when suppressed
+}
+
+type researchQuestion struct {
+	QuestionID string          `json:"question_id"`
+	Tiers      map[string]*int `json:"tiers"` // tier → reports; nil when suppressed
+}
+
+type researchExportResponse struct {
+	From              string             `json:"from"`
+	To                string             `json:"to"`
+	K                 int                `json:"k"`
+	Reports           int                `json:"reports"`
+	SuppressedReports int                `json:"suppressed_reports"`
+	Segments          []researchSegment  `json:"segments"`
+	Bands             []researchBand     `json:"bands"`
+	Questions         []researchQuestion `json:"questions"`
+}
+
+func (s *Server) handleAdminExportResearch(w http.ResponseWriter, r *http.Request) {
+	from, to, msg := parseExportRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if msg != "" {
+		respondErr(w, http.StatusBadRequest, msg)
+		return
+	}
+	k := minResearchCell
+	if v := r.URL.Query().Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minResearchCell {
+			respondErr(w, http.StatusBadRequest, fmt.Sprintf("k must be an integer of at least %d", minResearchCell))
+			return
+		}
+		k = n
+	}
+	params := db.ListResearchReportsParams{GeneratedFrom: from, GeneratedTo: to.AddDate(0, 0, 1)}
+
+	reports, err := s.q.ListResearchReports(r.Context(), params)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list research reports: %w", err))
+		return
+	}
+	tiers, err := s.q.CountResearchRiskTiers(r.Context(), db.CountResearchRiskTiersParams(params))
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("count research risk tiers: %w", err))
+		return
+	}
+
+	resp := buildResearchExport(reports, tiers, k)
+	resp.From, resp.To = from.Format(exportDateLayout), to.Format(exportDateLayout)
+
+	s.logger.Info("admin: research export",
+		"from", resp.From,
+		"to", resp.To,
+		"k", k,
+		"reports", resp.Reports,
+		"suppressed_reports", resp.SuppressedReports,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusOK, resp)
+}
+
+// buildResearchExport aggregates the rows and applies the suppression rules.
+func buildResearchExport(reports []db.ListResearchReportsRow, tiers []db.CountResearchRiskTiersRow, k int) researchExportResponse {
+	resp := researchExportResponse{
+		K:         k,
+		Reports:   len(reports),
+		Segments:  []researchSegment{},
+		Questions: []researchQuestion{},
+	}
+	// suppress hides counts that could single out a handful of customers.
+	suppress := func(n int) *int {
+		if n < k {
+			return nil
+		}
+		return &n
+	}
+
+	type segmentKey struct{ industry, stage string }
+	type segmentTotal struct{ reports, scores int }
+	totals := map[segmentKey]segmentTotal{}
+	bands := map[scoring.ScoreBand]int{}
+	for _, rep := range reports {
+		key := segmentKey{rep.Industry, rep.Stage}
+		t := totals[key]
+		totals[key] = segmentTotal{t.reports + 1, t.scores + int(rep.OverallScore)}
+		bands[scoring.Band(int(rep.OverallScore))]++
+	}
+	for key, t := range totals {
+		if t.reports < k {
+			resp.SuppressedReports += t.reports
+			continue
+		}
+		resp.Segments = append(resp.Segments, researchSegment{
+			Industry:         key.industry,
+			Stage:            key.stage,
+			Reports:          t.reports,
+			MeanOverallScore: math.Round(float64(t.scores)/float64(t.reports)*10) / 10,
+		})
+	}
+	sort.Slice(resp.Segments, func(i, j int) bool {
+		a, b := resp.Segments[i], resp.Segments[j]
+		if a.Industry != b.Industry {
+			return a.Industry < b.Industry
+		}
+		return a.Stage < b.Stage
+	})
+
+	for _, b := range scoring.Bands() {
+		resp.Bands = append(resp.Bands, researchBand{Band: string(b.Band), Reports: suppress(bands[b.Band])})
+	}
+
+	for _, row := range tiers {
+		n := len(resp.Questions)
+		if n == 0 || resp.Questions[n-1].QuestionID != row.QuestionID {
+			q := researchQuestion{QuestionID: row.QuestionID, Tiers: map[string]*int{}}
+			for _, t := range scoring.Tiers() {
+				q.Tiers[string(t.Tier)] = suppress(0)
+			}
+			resp.Questions = append(resp.Questions, q)
+			n++
+		}
+		resp.Questions[n-1].Tiers[string(row.Tier)] = suppress(int(row.Reports))
+	}
+	return resp
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── GET /api/scoring/meta ────────────────────────────────────────────────────
+//
+// Returns the scoring model's constants: the score ranges, the tier
+// thresholds, each tier's label, colour, description, review cadence and
+// region of the grid, and the overall score bands. The frontend and the PDF
+// renderer read these instead of hard-coding the thresholds in
+// internal/scoring. No auth, and cacheable — the ranges only change with a
+// deploy, and a tier theme edit (see tiers.go) reaches clients within the
+// cache lifetime.
+
+type scoreRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+type scoreThresholds struct {
+	HighProbabilityFrom int `json:"high_probability_from"`
+	HighImpactFrom      int `json:"high_impact_from"`
+}
+
+type tierMeta struct {
+	Tier        string     `json:"tier"`
+	Label       string     `json:"label"`
+	Color       string     `json:"color,omitempty"` // "#rrggbb"
+	Description string     `json:"description"`
+	Cadence     string     `json:"cadence,omitempty"` // how often to review a risk in the tier
+	Probability scoreRange `json:"probability"`
+	Impact      scoreRange `json:"impact"`
+}
+
+type bandMeta struct {
+	Band  string     `json:"band"`
+	Score scoreRange `json:"score"`
+}
+
+type scoringMetaResponse struct {
+	Probability  scoreRange      `json:"probability"`
+	Impact       scoreRange      `json:"impact"`
+	RiskScore    scoreRange      `json:"risk_score"` // probability × impact
+	OverallScore scoreRange      `json:"overall_score"`
+	Thresholds   scoreThresholds `json:"thresholds"`
+	Tiers        []tierMeta      `json:"tiers"`
+	Bands        []bandMeta      `json:"bands"`
+}
+
+func (s *Server) handleGetScoringMeta(w http.ResponseWriter, r *http.Request) {
+	bands := scoring.Bands()
+	resp := scoringMetaResponse{
+		Probability:  scoreRange{scoring.MinScore, scoring.MaxScore},
+		Impact:       scoreRange{scoring.MinScore, scoring.MaxScore},
+		RiskScore:    scoreRange{scoring.MinScore * scoring.MinScore, scoring.MaxRiskScore},
+		OverallScore: scoreRange{scoring.MinOverallScore, scoring.MaxRiskScore},
+		Thresholds:   tierThresholds(),
+		Tiers:        s.tierMetas(r),
+		Bands:        make([]bandMeta, len(bands)),
+	}
+	for i, b := range bands {
+		resp.Bands[i] = bandMeta{Band: string(b.Band), Score: scoreRange{b.Min, b.Max}}
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respond(w, http.StatusOK, resp)
+}
+
+func tierThresholds() scoreThresholds {
+	highProb, highImpact := scoring.Thresholds()
+	return scoreThresholds{HighProbabilityFrom: highProb, HighImpactFrom: highImpact}
+}
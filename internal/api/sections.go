@@ -0,0 +1,189 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── QUESTIONNAIRE SECTIONS ───────────────────────────────────────────────────
+//
+// question_sections gives each section its title, description and place in
+// the questionnaire. A section is complete once every required question in
+// it is answered. A gated section is locked while a section before it is
+// incomplete; with Config.SectionGating on, PUT /answers rejects answers to a
+// locked section, so the flow no longer rests on the client alone. GET
+// /questions lists the sections with the session's progress through them.
+
+// maxSectionDescriptionRunes bounds a section description.
+const maxSectionDescriptionRunes = 1000
+
+type sectionResponse struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description,omitempty"`
+	DisplayOrder int16  `json:"display_order"`
+	Gated        bool   `json:"gated"`
+	Questions    int    `json:"questions"`
+	Required     int    `json:"required"`
+	Answered     int    `json:"answered"` // required or not
+	Complete     bool   `json:"complete"`
+	// Locked is true for a gated section while one before it is incomplete.
+	// Only set when answers to it would be rejected (SECTION_GATING).
+	Locked bool `json:"locked"`
+}
+
+// sectionStates lists sections, in order, with the progress answered (the
+// IDs of questions with a non-blank answer, as in progress.go) makes through
+// questions. Locked
+// is only set when enforce is true.
+func sectionStates(sections []db.QuestionSection, questions []db.QuestionDefinition, answered map[string]bool, enforce bool) []sectionResponse {
+	out := make([]sectionResponse, len(sections))
+	missing := make([]int, len(sections)) // required questions unanswered
+	index := make(map[db.SectionID]int, len(sections))
+	for i, sec := range sections {
+		index[sec.ID] = i
+		out[i] = sectionResponse{
+			ID:           string(sec.ID),
+			Title:        sec.Title,
+			Description:  sec.Description,
+			DisplayOrder: sec.DisplayOrder,
+			Gated:        sec.Gated,
+		}
+	}
+	for _, q := range questions {
+		i, ok := index[q.SectionID]
+		if !ok {
+			continue
+		}
+		out[i].Questions++
+		if answered[q.ID] {
+			out[i].Answered++
+		}
+		if q.Required {
+			out[i].Required++
+			if !answered[q.ID] {
+				missing[i]++
+			}
+		}
+	}
+
+	incompleteBefore := false
+	for i := range out {
+		out[i].Complete = missing[i] == 0
+		out[i].Locked = enforce && out[i].Gated && incompleteBefore
+		incompleteBefore = incompleteBefore || !out[i].Complete
+	}
+	return out
+}
+
+// lockedSections returns the sections of questions that answers may not be
+// saved to, given that after the save the questions in answered have a
+// non-blank answer. Nil when gating is off.
+func (s *Server) lockedSections(r *http.Request, questions []db.QuestionDefinition, answered map[string]bool) (map[string]bool, error) {
+	if !s.cfg.SectionGating {
+		return nil, nil
+	}
+	sections, err := s.q.ListQuestionSections(r.Context())
+	if err != nil {
+		return nil, fmt.Errorf("list sections: %w", err)
+	}
+	locked := make(map[string]bool)
+	for _, sec := range sectionStates(sections, questions, answered, true) {
+		if sec.Locked {
+			locked[sec.ID] = true
+		}
+	}
+	return locked, nil
+}
+
+// ─── PUT /api/admin/sections/:sectionID ───────────────────────────────────────
+//
+// Replaces a section's title, description, order and gating. Sections are
+// the values of the section_id enum; new ones come with a migration.
+
+type putSectionRequest struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	DisplayOrder int16  `json:"display_order"`
+	Gated        bool   `json:"gated"`
+}
+
+type adminSectionResponse struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	DisplayOrder int16  `json:"display_order"`
+	Gated        bool   `json:"gated"`
+}
+
+func (s *Server) handleAdminPutSection(w http.ResponseWriter, r *http.Request) {
+	id := db.SectionID(chi.URLParam(r, "sectionID"))
+	// An ID outside the enum would fail the update with a type error, so it
+	// is checked against the rows first.
+	sections, err := s.q.ListQuestionSections(r.Context())
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list sections: %w", err))
+		return
+	}
+	if !slices.ContainsFunc(sections, func(sec db.QuestionSection) bool { return sec.ID == id }) {
+		respondErr(w, http.StatusNotFound, "section not found")
+		return
+	}
+
+	var req putSectionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Description = strings.TrimSpace(req.Description)
+	switch {
+	case req.Title == "":
+		respondErr(w, http.StatusBadRequest, "title is required")
+		return
+	case utf8.RuneCountInString(req.Description) > maxSectionDescriptionRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("description must be at most %d characters", maxSectionDescriptionRunes))
+		return
+	case req.DisplayOrder < 1:
+		respondErr(w, http.StatusBadRequest, "display_order must be at least 1")
+		return
+	}
+
+	sec, err := s.q.UpdateQuestionSection(r.Context(), db.UpdateQuestionSectionParams{
+		ID:           id,
+		Title:        req.Title,
+		Description:  req.Description,
+		DisplayOrder: req.DisplayOrder,
+		Gated:        req.Gated,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "section not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("update section %q: %w", id, err))
+		return
+	}
+
+	s.logger.Info("admin: section updated",
+		"section_id", sec.ID,
+		"display_order", sec.DisplayOrder,
+		"gated", sec.Gated,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusOK, adminSectionResponse{
+		ID:           string(sec.ID),
+		Title:        sec.Title,
+		Description:  sec.Description,
+		DisplayOrder: sec.DisplayOrder,
+		Gated:        sec.Gated,
+	})
+}
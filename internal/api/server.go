@@ -6,15 +6,31 @@ package api
 import (
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/captcha"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/dbhealth"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/embed"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/errorreport"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fraud"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/geoip"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/lockout"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/prefill"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/querywatch"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/settings"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
 	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
+	"github.com/redis/go-redis/v9"
 )
 
 // Config holds values read from environment variables at startup.
@@ -23,11 +39,164 @@ type Config struct {
 	// e.g. "https://app.asymmetricrisk.com"
 	BaseURL string
 
-	// StripeWebhookSecret is the signing secret from the Stripe dashboard.
-	StripeWebhookSecret string
+	// StripeWebhookSecrets are the accepted signing secrets from the Stripe
+	// dashboard. More than one is configured only while rotating.
+	StripeWebhookSecrets []string
+
+	// ResendWebhookSecret verifies Resend's open, click and bounce webhooks.
+	// When empty POST /api/webhooks/resend is not mounted.
+	ResendWebhookSecret string
 
 	// Env is "production", "staging", or "development".
 	Env string
+
+	// CompressionLevel is the gzip/deflate level for JSON responses; 0 sends
+	// them uncompressed.
+	CompressionLevel int
+
+	// APIDeprecations marks API versions deprecated, by version number; their
+	// responses carry Deprecation and Sunset headers. See ParseDeprecations.
+	APIDeprecations map[int]Deprecation
+
+	// AdminAPIKey is the bearer token required on /api/admin/* routes. When
+	// empty the admin routes are not mounted.
+	AdminAPIKey string
+
+	// ConfigReport is the redacted configuration served by
+	// GET /api/admin/config (see config.Config.Redacted).
+	ConfigReport map[string]string
+
+	// Settings supplies hot-reloadable values such as the poll interval. May
+	// be nil, in which case settings.Defaults() apply.
+	Settings *settings.Watcher
+
+	// Flags gates risky behaviour per session and is managed through
+	// /api/admin/flags. May be nil, in which case every flag is on.
+	Flags *flags.Watcher
+
+	// Experiments are the running A/B experiments. New sessions are assigned
+	// to their variants. May be nil, in which case none run.
+	Experiments *experiments.Set
+
+	// ConsultationURL is the scheduling link for the post-report consultation
+	// upsell. Empty disables the upsell endpoint and hides the link.
+	ConsultationURL string
+
+	// InvoiceIssuer is printed as the seller on customer invoices, one entry
+	// per line.
+	InvoiceIssuer []string
+
+	// StripeTax enables Stripe Tax at checkout: billing_country becomes
+	// required and tax is added to the product price.
+	StripeTax bool
+
+	// StrictAnswers rejects radio answers that are not one of the question's
+	// options instead of storing them and logging a warning.
+	StrictAnswers bool
+
+	// SectionGating rejects answers to a gated section until the sections
+	// before it are complete.
+	SectionGating bool
+
+	// AnswerBatchHeadroom is how many answers a save may carry beyond one per
+	// question definition.
+	AnswerBatchHeadroom int
+
+	// CohortReportsPerMinute spaces out the reports of an admin bulk import;
+	// zero schedules them all at once. CohortMaxRows bounds one import; zero
+	// leaves it unbounded.
+	CohortReportsPerMinute int
+	CohortMaxRows          int
+
+	// Partners verify pre-fill tokens. When empty
+	// POST /api/session/{sessionID}/import is not mounted.
+	Partners prefill.Keys
+
+	// Embed issues and verifies the tokens of partner sites that iframe the
+	// assessment. When nil POST /api/embed/session is not mounted.
+	Embed *embed.Issuer
+
+	// Fraud screens checkouts before a PaymentIntent is created. Nil skips
+	// the checks entirely.
+	Fraud *fraud.Checker
+
+	// Captcha verifies the bot-protection token on POST /api/session. Nil
+	// disables the check.
+	Captcha captcha.Verifier
+
+	// ReportIPLockout and ReportTokenLockout count report-route requests for
+	// unknown access tokens per client IP and per token, and refuse locked
+	// out ones with 429. Nil disables either.
+	ReportIPLockout    *lockout.Tracker
+	ReportTokenLockout *lockout.Tracker
+
+	// DuplicateAutoRefund refunds a duplicate purchase as soon as its report
+	// is held instead of leaving it for GET /api/admin/duplicates.
+	DuplicateAutoRefund bool
+
+	// ReportResendIPLimit and ReportResendEmailLimit count POST
+	// /api/report/resend requests per client IP and per address, and refuse
+	// the ones over their limit with 429. Nil leaves either unlimited.
+	ReportResendIPLimit    *lockout.Tracker
+	ReportResendEmailLimit *lockout.Tracker
+
+	// IPHashSalt keys the HMAC used for sessions.ip_hash. Empty falls back to
+	// unsalted SHA-256.
+	IPHashSalt string
+
+	// IPPrivacyMode truncates IPs to their network before hashing.
+	IPPrivacyMode bool
+
+	// GeoIP resolves the client's country for request logs. Nil logs none.
+	GeoIP *geoip.DB
+
+	// TrustedProxies are the proxies whose X-Forwarded-For and X-Real-IP
+	// headers are believed. Empty trusts the headers from any peer.
+	TrustedProxies []netip.Prefix
+
+	// ErrorReporter receives handler panics and 500s. Nil disables reporting.
+	ErrorReporter *errorreport.Reporter
+
+	// ReadinessChecks are run by GET /readyz. With none registered the probe
+	// always reports ready.
+	ReadinessChecks []ReadinessCheck
+
+	// DBHealth reports database outages, during which /api requests are
+	// answered from the report cache or with 503 (see degraded.go). Nil
+	// treats the database as always up.
+	DBHealth *dbhealth.Monitor
+
+	// ReportCacheSize is how many recently served reports are kept for
+	// serving during a database outage, for at most ReportCacheTTL. Zero
+	// disables the cache.
+	ReportCacheSize int
+	ReportCacheTTL  time.Duration
+
+	// Redis, when set, shares the report cache between replicas. Nil keeps it
+	// in this process's memory.
+	Redis *redis.Client
+
+	// Storage holds generated artifacts handed out as signed URLs, such as
+	// payment exports requested with ?link=true. Nil disables those links.
+	Storage storage.Store
+
+	// Queries is the query watcher whose per-statement stats GET
+	// /api/admin/queries serves. Nil serves none.
+	Queries *querywatch.Watcher
+
+	// Conns counts the HTTP server's open connections for GET /internal/drain.
+	// Nil reports none.
+	Conns *ConnTracker
+
+	// StripeEvents, when set, takes the Stripe webhook's events: the webhook
+	// stores each one and answers 200 at once, and the worker dispatches it.
+	// Nil dispatches within the webhook request.
+	StripeEvents worker.StripeEventQueue
+
+	// Reloads tells the other replicas to reload runtime settings and feature
+	// flags after an admin changes them. Nil leaves them to their periodic
+	// reload.
+	Reloads *cluster.Notifier
 }
 
 // Server holds all shared dependencies. Each handler file attaches methods to
@@ -48,6 +217,9 @@ type Server struct {
 	// mailer sends transactional emails (receipt + report delivery).
 	mailer email.Sender
 
+	// reports caches ready reports for database outages.
+	reports *reportCache
+
 	cfg    Config
 	logger *slog.Logger
 }
@@ -64,13 +236,17 @@ func NewServer(
 	logger *slog.Logger,
 ) http.Handler {
 	s := &Server{
-		q:      q,
-		store:  st,
-		stripe: stripeClient,
-		worker: enqueuer,
-		mailer: mailer,
-		cfg:    cfg,
-		logger: logger,
+		q:       q,
+		store:   st,
+		stripe:  stripeClient,
+		worker:  enqueuer,
+		mailer:  mailer,
+		reports: newReportCache(cfg.ReportCacheSize, cfg.ReportCacheTTL, cfg.Redis, logger),
+		cfg:     cfg,
+		logger:  logger,
+	}
+	if cfg.StripeEvents != nil {
+		cfg.StripeEvents.SetHandler(s)
 	}
 
 	return s.routes()
@@ -81,36 +257,149 @@ func (s *Server) routes() http.Handler {
 
 	// ── Global middleware ─────────────────────────────────────────────────────
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(propagateRequestID)
+	r.Use(s.clientIPMiddleware)
 	r.Use(s.loggerMiddleware)
+	r.Use(s.negotiateAPIVersion)
 	r.Use(middleware.Recoverer)
+	r.Use(s.cfg.ErrorReporter.Middleware)
 	r.Use(s.corsMiddleware)
+	if s.cfg.CompressionLevel > 0 {
+		// Reports with AI narratives run to several KB and are polled; PDFs
+		// are already compressed, so only JSON is.
+		r.Use(middleware.Compress(s.cfg.CompressionLevel, "application/json"))
+	}
 	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(s.closeWhenDraining)
 
 	// ── Health ────────────────────────────────────────────────────────────────
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
+	r.Get("/readyz", s.handleReadyz)
 
-	// ── API v1 ────────────────────────────────────────────────────────────────
+	// ── Deploys ───────────────────────────────────────────────────────────────
+	// Outside /api so draining works while the database is down. Not mounted
+	// without an admin key.
+	if s.cfg.AdminAPIKey != "" {
+		r.Route("/internal", func(r chi.Router) {
+			r.Use(s.requireAdmin)
+			r.Get("/drain", s.handleDrainStatus)
+			r.Post("/drain", s.handleDrain)
+		})
+	}
+
+	// ── API ───────────────────────────────────────────────────────────────────
+	// Served for every version at /api/v{N} as well as here; see
+	// negotiateAPIVersion.
 	r.Route("/api", func(r chi.Router) {
+		// Fail fast while the database is down.
+		r.Use(s.requireDatabase)
+
 		// Sessions — no auth required (anonymous creation).
 		r.Post("/session", s.handleCreateSession)
 
+		// Embed tokens — public; the Origin header is checked per partner.
+		if s.cfg.Embed != nil {
+			r.Post("/embed/session", s.handleCreateEmbedToken)
+		}
+
+		// API description — public. Swagger UI is for local and staging use.
+		r.Get("/openapi.json", s.handleOpenAPI)
+		if s.cfg.Env != "production" {
+			r.Get("/docs", s.handleSwaggerUI)
+		}
+
+		// Product catalog — public, used to render pricing.
+		r.Get("/products", s.handleListProducts)
+		r.Get("/scoring/meta", s.handleGetScoringMeta)
+
 		// Session-scoped routes — require valid anon_token cookie/header.
 		r.Route("/session/{sessionID}", func(r chi.Router) {
 			r.Use(s.requireAnonToken)
 			r.Patch("/context", s.handleUpdateContext)
 			r.Get("/questions", s.handleGetQuestions)
+			r.Get("/progress", s.handleGetProgress)
+			r.Get("/teaser", s.handleGetTeaser)
 			r.Put("/answers", s.handleUpsertAnswers)
+			if len(s.cfg.Partners) > 0 {
+				r.Post("/import", s.handleImportPrefill)
+			}
 			r.Post("/checkout", s.handleCreateCheckout)
 		})
 
 		// Stripe webhook — no auth (signature verification inside handler).
 		r.Post("/webhooks/stripe", s.handleStripeWebhook)
 
-		// Report access — no auth (opaque access token in URL).
-		r.Get("/report/{accessToken}", s.handleGetReport)
+		// Resend tracking webhook — no auth (Svix signature verified inside).
+		if s.cfg.ResendWebhookSecret != "" {
+			r.Post("/webhooks/resend", s.handleResendWebhook)
+		}
+
+		// Lost report links — no auth, rate limited per IP and per address.
+		r.Post("/report/resend", s.handleResendReportLinks)
+
+		// Feedback on a delivered report — no auth (random token in URL).
+		r.Get("/feedback/{token}", s.handleGetFeedback)
+		r.Post("/feedback/{token}", s.handlePostFeedback)
+		r.Post("/feedback/{token}/unsubscribe", s.handleFeedbackUnsubscribe)
+
+		// Report access — no auth (opaque access token in URL), so guessing
+		// tokens is throttled by guardReportToken.
+		r.Group(func(r chi.Router) {
+			r.Use(s.guardReportToken)
+			r.Get("/report/{accessToken}", s.handleGetReport)
+			r.Post("/report/{accessToken}/consultation", s.handleRequestConsultation)
+			r.Post("/report/{accessToken}/rotate", s.handleRotateReportToken)
+			r.Get("/report/{accessToken}/invoice", s.handleGetInvoice)
+			r.Get("/report/{accessToken}/matrix", s.handleGetReportMatrix)
+			r.Get("/reports/compare", s.handleCompareReports)
+		})
+
+		// Operator routes — bearer ADMIN_API_KEY. Not mounted without a key.
+		if s.cfg.AdminAPIKey != "" {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(s.requireAdmin)
+				r.Get("/config", s.handleAdminConfig)
+				r.Get("/settings", s.handleAdminListSettings)
+				r.Put("/settings/{key}", s.handleAdminPutSetting)
+				r.Delete("/settings/{key}", s.handleAdminDeleteSetting)
+				r.Get("/flags", s.handleAdminListFlags)
+				r.Put("/flags/{name}", s.handleAdminPutFlag)
+				r.Delete("/flags/{name}", s.handleAdminDeleteFlag)
+				r.Get("/products", s.handleAdminListProducts)
+				r.Put("/products/{sku}", s.handleAdminPutProduct)
+				r.Get("/playbooks", s.handleAdminListPlaybooks)
+				r.Put("/playbooks/{slug}", s.handleAdminPutPlaybook)
+				r.Delete("/playbooks/{slug}", s.handleAdminDeletePlaybook)
+				r.Put("/sections/{sectionID}", s.handleAdminPutSection)
+				r.Put("/tiers/{tier}", s.handleAdminPutTier)
+				r.Get("/stats", s.handleAdminStats)
+				r.Get("/shadow-scores", s.handleAdminShadowScores)
+				r.Get("/queries", s.handleAdminQueries)
+				r.Delete("/reports/{reportID}", s.handleAdminRevokeReport)
+				r.Get("/reports/{reportID}/transcripts", s.handleAdminListTranscripts)
+				r.Get("/reports/{reportID}/edits", s.handleAdminListEdits)
+				r.Post("/reports/{reportID}/edits", s.handleAdminPostEdit)
+				r.Get("/reports/{reportID}/notes", s.handleAdminListNotes)
+				r.Post("/reports/{reportID}/notes", s.handleAdminPostNote)
+				r.Get("/sessions/{sessionID}/notes", s.handleAdminListNotes)
+				r.Post("/sessions/{sessionID}/notes", s.handleAdminPostNote)
+				r.Get("/testimonials", s.handleAdminListTestimonials)
+				r.Post("/testimonials/{reportID}/approve", s.handleAdminApproveTestimonial)
+				r.Post("/cohorts/{cohort}", s.handleAdminImportCohort)
+				r.Get("/duplicates", s.handleAdminListDuplicates)
+				r.Post("/duplicates/{sessionID}/resolve", s.handleAdminResolveDuplicate)
+				r.Get("/exports/payments", s.handleAdminExportPayments)
+				r.Get("/exports/research", s.handleAdminExportResearch)
+				r.Get("/exports/testimonials", s.handleAdminExportTestimonials)
+				r.Get("/sessions", s.handleAdminListSessions)
+				r.Get("/emails", s.handleAdminListEmails)
+				r.Get("/stripe-events", s.handleAdminListStripeEvents)
+				r.Post("/stripe-events/reprocess", s.handleAdminReprocessStripeEvents)
+				r.Post("/stripe-events/{eventID}/replay", s.handleAdminReplayStripeEvent)
+			})
+		}
 	})
 
 	return r
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── GET /api/admin/sessions ──────────────────────────────────────────────────
+//
+// Lists sessions newest first, filtered by payment_status (pending, paid,
+// failed, refunded; empty for any) and paged by limit and cursor (see
+// pagination.go). Emails are left out, so the listing decrypts nothing.
+
+// sessionPaymentStatuses are the accepted payment_status filters.
+var sessionPaymentStatuses = map[string]bool{
+	"":                               true,
+	string(db.PaymentStatusPending):  true,
+	string(db.PaymentStatusPaid):     true,
+	string(db.PaymentStatusFailed):   true,
+	string(db.PaymentStatusRefunded): true,
+}
+
+type sessionSummary struct {
+	ID            uuid.UUID  `json:"id"`
+	BizName       string     `json:"biz_name,omitempty"`
+	Industry      string     `json:"industry,omitempty"`
+	Stage         string     `json:"stage,omitempty"`
+	PaymentStatus string     `json:"payment_status"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	Partner       string     `json:"partner,omitempty"`
+	Cohort        string     `json:"cohort,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type listSessionsResponse struct {
+	Sessions   []sessionSummary `json:"sessions"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) handleAdminListSessions(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("payment_status")
+	if !sessionPaymentStatuses[status] {
+		respondErr(w, http.StatusBadRequest, "payment_status must be one of pending, paid, failed, refunded")
+		return
+	}
+	page, msg := parsePageRequest(r)
+	if msg != "" {
+		respondErr(w, http.StatusBadRequest, msg)
+		return
+	}
+	cursorID, ok := cursorUUID(page)
+	if !ok {
+		respondErr(w, http.StatusBadRequest, errBadCursor.Error())
+		return
+	}
+
+	rows, err := s.q.ListSessionsPage(r.Context(), db.ListSessionsPageParams{
+		PaymentStatus: status,
+		HasCursor:     page.HasCursor,
+		CursorAt:      page.Cursor.At,
+		CursorID:      cursorID,
+		MaxRows:       page.fetchRows(),
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list sessions: %w", err))
+		return
+	}
+
+	rows, next := trimPage(page, rows, func(row db.ListSessionsPageRow) pageCursor {
+		return pageCursor{At: row.CreatedAt, ID: row.ID.String()}
+	})
+	resp := listSessionsResponse{Sessions: make([]sessionSummary, len(rows)), NextCursor: next}
+	for i, row := range rows {
+		resp.Sessions[i] = sessionSummary{
+			ID:            row.ID,
+			BizName:       row.BizName.String,
+			Industry:      row.Industry.String,
+			Stage:         row.Stage.String,
+			PaymentStatus: string(row.PaymentStatus),
+			Partner:       row.Partner.String,
+			Cohort:        row.Cohort.String,
+			CreatedAt:     row.CreatedAt,
+		}
+		if row.PaidAt.Valid {
+			resp.Sessions[i].PaidAt = &row.PaidAt.Time
+		}
+	}
+	setNextLink(w, r, next)
+	respond(w, http.StatusOK, resp)
+}
+
+// cursorUUID parses the ID of a cursor from a listing keyed by UUID. ok is
+// true, with uuid.Nil, when there is no cursor.
+func cursorUUID(p pageRequest) (uuid.UUID, bool) {
+	if !p.HasCursor {
+		return uuid.Nil, true
+	}
+	id, err := uuid.Parse(p.Cursor.ID)
+	return id, err == nil
+}
@@ -1,15 +1,20 @@
 package api
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/captcha"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
 )
 
@@ -20,11 +25,29 @@ type createSessionRequest struct {
 	BizName  string `json:"biz_name"`
 	Industry string `json:"industry"`
 	Stage    string `json:"stage"`
+	// Email is optional. A returning subscriber can send it to learn whether
+	// this assessment is covered by their subscription before reaching
+	// checkout.
+	Email string `json:"email"`
+	// CaptchaToken is the token from the hCaptcha or Turnstile widget.
+	// Required when CAPTCHA_PROVIDER is configured.
+	CaptchaToken string `json:"captcha_token"`
+	// EmbedToken is the token a partner's page passed to the embedded
+	// widget. The session is attributed to that partner.
+	EmbedToken string `json:"embed_token"`
 }
 
 type createSessionResponse struct {
 	SessionID  string `json:"session_id"`
 	AnonToken  string `json:"anon_token"`
+	// ReassessmentAvailable is true when Email has an unused quarterly
+	// re-assessment. Checkout with the same email will then not charge.
+	ReassessmentAvailable bool `json:"reassessment_available,omitempty"`
+	// Partner is set for sessions started in a partner's embedded widget.
+	Partner string `json:"partner,omitempty"`
+	// Experiments maps each running A/B experiment to the session's variant,
+	// "a" or "b".
+	Experiments map[string]string `json:"experiments,omitempty"`
 }
 
 // handleCreateSession creates an anonymous session for a new visitor.
@@ -32,22 +55,39 @@ type createSessionResponse struct {
 //
 // The anon_token is returned to the browser and stored in sessionStorage.
 // It is sent as X-Anon-Token on all subsequent session-scoped requests.
+//
+// The endpoint is unauthenticated and writes a row per call, so when a captcha
+// provider is configured the widget's token is verified first. A provider
+// outage lets the request through rather than taking the funnel down with it.
 func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 	var req createSessionRequest
 	if !decode(w, r, &req) {
 		return
 	}
 
-	// Generate a cryptographically random token. 32 bytes → 64 hex chars.
-	tokenBytes := make([]byte, 32)
-	if _, err := rand.Read(tokenBytes); err != nil {
-		s.respondInternalErr(w, r, fmt.Errorf("generate anon token: %w", err))
+	if s.cfg.Captcha != nil && !s.verifyCaptcha(w, r, req.CaptchaToken) {
+		return
+	}
+
+	var partner string
+	if req.EmbedToken != "" {
+		claims, err := s.cfg.Embed.Verify(req.EmbedToken, time.Now())
+		if err != nil {
+			s.logger.Info("create session: rejected embed token", "error", err, logField(r))
+			respondErr(w, http.StatusBadRequest, "invalid or expired embed_token")
+			return
+		}
+		partner = claims.Partner
+	}
+
+	anonToken, err := newAnonToken()
+	if err != nil {
+		s.respondInternalErr(w, r, err)
 		return
 	}
-	anonToken := hex.EncodeToString(tokenBytes)
 
 	// Hash the real IP for fraud logging — never store the raw IP.
-	ipHash := hashIP(realIP(r))
+	ipHash := s.hashIP(realIP(r))
 
 	session, err := s.q.CreateSession(r.Context(), db.CreateSessionParams{
 		AnonToken:   anonToken,
@@ -57,6 +97,7 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		Referrer:    nullString(r.Referer()),
 		IpHash:      nullString(ipHash),
 		UserAgent:   nullString(r.UserAgent()),
+		Partner:     nullString(partner),
 	})
 	if err != nil {
 		s.respondInternalErr(w, r, fmt.Errorf("create session: %w", err))
@@ -81,10 +122,59 @@ func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	respond(w, http.StatusCreated, createSessionResponse{
-		SessionID: session.ID.String(),
-		AnonToken: anonToken,
-	})
+	resp := createSessionResponse{
+		SessionID:   session.ID.String(),
+		AnonToken:   anonToken,
+		Partner:     partner,
+		Experiments: s.assignExperiments(r, session.ID),
+	}
+	if req.Email != "" {
+		_, err := s.q.GetEntitledSubscription(r.Context(), req.Email)
+		switch {
+		case err == nil:
+			resp.ReassessmentAvailable = true
+		case !errors.Is(err, sql.ErrNoRows):
+			// Non-fatal — checkout repeats the check authoritatively.
+			s.logger.Warn("create session: entitlement check failed",
+				"session_id", session.ID,
+				"error", err,
+				logField(r),
+			)
+		}
+	}
+
+	respond(w, http.StatusCreated, resp)
+}
+
+// newAnonToken generates a session's anon_token: 32 cryptographically random
+// bytes, hex-encoded to 64 characters.
+func newAnonToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("generate anon token: %w", err)
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+// verifyCaptcha checks token with the configured provider. It returns false
+// once it has written a 4xx response.
+func (s *Server) verifyCaptcha(w http.ResponseWriter, r *http.Request, token string) bool {
+	if token == "" {
+		respondErr(w, http.StatusBadRequest, "captcha_token is required")
+		return false
+	}
+	err := s.cfg.Captcha.Verify(r.Context(), token, realIP(r))
+	if errors.Is(err, captcha.ErrRejected) {
+		respondErr(w, http.StatusForbidden, "captcha verification failed")
+		return false
+	}
+	if err != nil {
+		s.logger.Warn("create session: captcha provider unavailable, allowing",
+			"error", err,
+			logField(r),
+		)
+	}
+	return true
 }
 
 // ─── PATCH /api/session/:sessionID/context ────────────────────────────────────
@@ -144,20 +234,48 @@ func nullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: s != ""}
 }
 
-// hashIP returns the hex-encoded SHA-256 of the IP string.
-func hashIP(ip string) string {
-	h := sha256.Sum256([]byte(ip))
-	return hex.EncodeToString(h[:])
+// hashIP returns the hex-encoded HMAC-SHA256 of the IP keyed with
+// IP_HASH_SALT, so ip_hash cannot be reversed by hashing all 2^32 IPv4
+// addresses. In privacy mode the IP is truncated first (see truncateIP).
+// Without a salt it falls back to plain SHA-256.
+func (s *Server) hashIP(ip string) string {
+	if s.cfg.IPPrivacyMode {
+		ip = truncateIP(ip)
+	}
+	if s.cfg.IPHashSalt == "" {
+		h := sha256.Sum256([]byte(ip))
+		return hex.EncodeToString(h[:])
+	}
+	mac := hmac.New(sha256.New, []byte(s.cfg.IPHashSalt))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// realIP extracts the client IP, honouring X-Real-IP set by a reverse proxy.
-func realIP(r *http.Request) string {
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+// truncateIP zeroes the host part of ip: the last octet of an IPv4 address,
+// everything after the first 48 bits of an IPv6 one. Input that does not
+// parse as an IP is returned unchanged.
+func truncateIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
 		return ip
 	}
-	// RemoteAddr is "ip:port".
-	if idx := strings.LastIndex(r.RemoteAddr, ":"); idx >= 0 {
-		return r.RemoteAddr[:idx]
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.Addr().String()
+}
+
+// realIP returns the client IP as resolved by clientIPMiddleware, without
+// the port.
+func realIP(r *http.Request) string {
+	if ip := parseIP(r.RemoteAddr); ip.IsValid() {
+		return ip.String()
 	}
 	return r.RemoteAddr
 }
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// ─── GET /api/admin/shadow-scores?since=YYYY-MM-DD ────────────────────────────
+//
+// Compares each candidate score profile that has run in shadow (the
+// shadow_score_profile runtime setting) against production over the reports
+// scored since the given day (UTC; default 30 days ago): how far the overall
+// score moves on average and how many reports would land in another band.
+
+// defaultShadowDays is the window compared when since is not given.
+const defaultShadowDays = 30
+
+type shadowBandsResponse struct {
+	ProductionBand string `json:"production_band"`
+	ShadowBand     string `json:"shadow_band"`
+	Reports        int64  `json:"reports"`
+}
+
+type shadowProfileResponse struct {
+	Profile      string                `json:"profile"`
+	Reports      int64                 `json:"reports"`
+	MeanDelta    float64               `json:"mean_delta"`     // shadow − production
+	MeanAbsDelta float64               `json:"mean_abs_delta"` // average size of the move
+	BandChanges  int64                 `json:"band_changes"`   // reports in another band
+	Bands        []shadowBandsResponse `json:"bands"`
+}
+
+type adminShadowScoresResponse struct {
+	Since    string                  `json:"since"`
+	Current  string                  `json:"current"` // the candidate now set; "" for none
+	Profiles []shadowProfileResponse `json:"profiles"`
+}
+
+func (s *Server) handleAdminShadowScores(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().UTC().AddDate(0, 0, -defaultShadowDays).Truncate(24 * time.Hour)
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(exportDateLayout, v)
+		if err != nil {
+			respondErr(w, http.StatusBadRequest, "since must be a date, YYYY-MM-DD")
+			return
+		}
+		since = t
+	}
+
+	rows, err := s.q.SummarizeShadowScores(r.Context(), since)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("summarize shadow scores: %w", err))
+		return
+	}
+
+	out := adminShadowScoresResponse{
+		Since:    since.Format(exportDateLayout),
+		Current:  s.cfg.Settings.Current().ShadowString(),
+		Profiles: []shadowProfileResponse{},
+	}
+	var deltaSum, absDeltaSum int64
+	for _, row := range rows {
+		if n := len(out.Profiles); n == 0 || out.Profiles[n-1].Profile != row.Profile {
+			deltaSum, absDeltaSum = 0, 0
+			out.Profiles = append(out.Profiles, shadowProfileResponse{Profile: row.Profile, Bands: []shadowBandsResponse{}})
+		}
+		p := &out.Profiles[len(out.Profiles)-1]
+		p.Reports += row.Reports
+		if row.ProductionBand != row.ShadowBand {
+			p.BandChanges += row.Reports
+		}
+		p.Bands = append(p.Bands, shadowBandsResponse{ProductionBand: row.ProductionBand, ShadowBand: row.ShadowBand, Reports: row.Reports})
+		deltaSum += row.DeltaSum
+		absDeltaSum += row.AbsDeltaSum
+		p.MeanDelta = math.Round(float64(deltaSum)/float64(p.Reports)*10) / 10
+		p.MeanAbsDelta = math.Round(float64(absDeltaSum)/float64(p.Reports)*10) / 10
+	}
+	respond(w, http.StatusOK, out)
+}
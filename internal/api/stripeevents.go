@@ -0,0 +1,198 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── GET /api/admin/stripe-events ─────────────────────────────────────────────
+//
+// Lists stored Stripe webhook events newest first, so a failed webhook can be
+// investigated without SQL. Filters: status (failed, pending, processed),
+// type (exact event type). Paged by limit and cursor like every admin
+// listing (see pagination.go).
+//
+// before/next_before, the listing's original paging, is deprecated but still
+// works: next_before is the last event's ID, and before is turned into that
+// event's cursor. Unlike a cursor it needs the event to still be stored, so
+// it fails with 400 once retention or a purge has deleted it.
+//
+// Each event carries the start of its payload; the full payload is in the
+// database, or replayed through POST .../replay.
+
+// stripePayloadPreviewLen caps payload_preview, in characters.
+const stripePayloadPreviewLen = 500
+
+// stripeEventStatuses are the accepted status filters.
+var stripeEventStatuses = map[string]bool{"": true, "failed": true, "pending": true, "processed": true}
+
+type stripeEventSummary struct {
+	EventID        string     `json:"event_id"`
+	Type           string     `json:"type"`
+	Processed      bool       `json:"processed"`
+	ProcessedAt    *time.Time `json:"processed_at,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	ReceivedAt     time.Time  `json:"received_at"`
+	PayloadPreview string     `json:"payload_preview"`
+}
+
+type listStripeEventsResponse struct {
+	Events     []stripeEventSummary `json:"events"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+	NextBefore string               `json:"next_before,omitempty"`
+}
+
+func (s *Server) handleAdminListStripeEvents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if !stripeEventStatuses[status] {
+		respondErr(w, http.StatusBadRequest, "status must be one of failed, pending, processed")
+		return
+	}
+	page, msg := parsePageRequest(r)
+	if msg != "" {
+		respondErr(w, http.StatusBadRequest, msg)
+		return
+	}
+	if before := r.URL.Query().Get("before"); before != "" && !page.HasCursor {
+		anchor, err := s.q.GetStripeEvent(r.Context(), before)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondErr(w, http.StatusBadRequest, "before is not a stored event; page with cursor instead")
+			return
+		}
+		if err != nil {
+			s.respondInternalErr(w, r, fmt.Errorf("get stripe event: %w", err))
+			return
+		}
+		page.Cursor, page.HasCursor = pageCursor{At: anchor.ReceivedAt, ID: anchor.StripeEventID}, true
+	}
+
+	events, err := s.q.ListStripeEvents(r.Context(), db.ListStripeEventsParams{
+		Status:    status,
+		EventType: r.URL.Query().Get("type"),
+		HasCursor: page.HasCursor,
+		CursorAt:  page.Cursor.At,
+		CursorID:  page.Cursor.ID,
+		MaxRows:   page.fetchRows(),
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list stripe events: %w", err))
+		return
+	}
+
+	events, next := trimPage(page, events, func(e db.StripeEvent) pageCursor {
+		return pageCursor{At: e.ReceivedAt, ID: e.StripeEventID}
+	})
+	resp := listStripeEventsResponse{Events: make([]stripeEventSummary, len(events)), NextCursor: next}
+	for i, e := range events {
+		resp.Events[i] = summariseStripeEvent(e)
+	}
+	if next != "" {
+		resp.NextBefore = events[len(events)-1].StripeEventID
+	}
+	setNextLink(w, r, next)
+	respond(w, http.StatusOK, resp)
+}
+
+func summariseStripeEvent(e db.StripeEvent) stripeEventSummary {
+	out := stripeEventSummary{
+		EventID:        e.StripeEventID,
+		Type:           e.Type,
+		Processed:      e.Processed,
+		Error:          e.Error.String,
+		ReceivedAt:     e.ReceivedAt,
+		PayloadPreview: string(e.Payload),
+	}
+	if e.ProcessedAt.Valid {
+		out.ProcessedAt = &e.ProcessedAt.Time
+	}
+	if utf8.RuneCountInString(out.PayloadPreview) > stripePayloadPreviewLen {
+		out.PayloadPreview = string([]rune(out.PayloadPreview)[:stripePayloadPreviewLen]) + "…"
+	}
+	return out
+}
+
+// ─── POST /api/admin/stripe-events/reprocess ──────────────────────────────────
+//
+// Replays the oldest failed events, optionally of one type, as POST
+// .../replay does for one. limit defaults to 20 and is capped at 50 so the
+// batch finishes within the request timeout — call again for the next batch.
+// Failed replays are reported per event.
+//
+// Only failed events are replayed in bulk, and only those the worker has
+// stopped retrying: a pending event may be running in its
+// process_stripe_event job right now, and a processed one has had its side
+// effects. Replay those one at a time, deliberately, with POST .../replay.
+// status is accepted for compatibility and must be failed when set.
+
+const (
+	defaultReprocessLimit = 20
+	maxReprocessLimit     = 50
+)
+
+type reprocessStripeEventsRequest struct {
+	Status string `json:"status"`
+	Type   string `json:"type"`
+	Limit  int    `json:"limit"`
+}
+
+type reprocessStripeEventsResponse struct {
+	Results   []replayStripeEventResponse `json:"results"`
+	Processed int                         `json:"processed"`
+	Failed    int                         `json:"failed"`
+}
+
+func (s *Server) handleAdminReprocessStripeEvents(w http.ResponseWriter, r *http.Request) {
+	var req reprocessStripeEventsRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Status != "" && req.Status != "failed" {
+		respondErr(w, http.StatusBadRequest, "only failed events are reprocessed in bulk; replay others one at a time")
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultReprocessLimit
+	}
+	if req.Limit < 1 || req.Limit > maxReprocessLimit {
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxReprocessLimit))
+		return
+	}
+
+	events, err := s.q.ListReprocessableStripeEvents(r.Context(), db.ListReprocessableStripeEventsParams{
+		EventType: req.Type,
+		MaxRows:   int32(req.Limit),
+	})
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list stripe events: %w", err))
+		return
+	}
+
+	resp := reprocessStripeEventsResponse{Results: make([]replayStripeEventResponse, 0, len(events))}
+	for _, e := range events {
+		result, err := s.replayStripeEvent(r, e)
+		if err != nil {
+			result = replayStripeEventResponse{EventID: e.StripeEventID, Type: e.Type, Error: err.Error()}
+		}
+		if result.Processed {
+			resp.Processed++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	s.logger.Info("admin: stripe events reprocessed",
+		"type", req.Type,
+		"processed", resp.Processed,
+		"failed", resp.Failed,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusOK, resp)
+}
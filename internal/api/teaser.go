@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── GET /api/session/:sessionID/teaser ───────────────────────────────────────
+//
+// Scores the session's current answers on the fly and returns a deliberately
+// thin preview for the paywall: the name and tier of the top-ranked risk and
+// a coarse band for the overall score. Nothing else from the scored results —
+// not the score, the other risks or any hedge — is exposed before payment.
+// Nothing is stored; the paid report is scored again by the worker.
+//
+// Returns 409 until at least one scoring question has been answered.
+//
+// Requires X-Anon-Token — the requireAnonToken middleware runs first.
+
+type teaserRisk struct {
+	Name string `json:"name"`
+	Tier string `json:"tier"`
+}
+
+type teaserResponse struct {
+	TopRisk     teaserRisk `json:"top_risk"`
+	OverallBand string     `json:"overall_band"`
+}
+
+func (s *Server) handleGetTeaser(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUID(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid session_id")
+		return
+	}
+
+	rows, err := s.q.GetAnswersBySession(r.Context(), sessionID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get answers: %w", err))
+		return
+	}
+
+	// Only answered scoring questions count: a blank answer scores (1,1) and
+	// would drag the band down before the customer has reached that section.
+	answerRows := make([]scoring.AnswerRow, 0, len(rows))
+	for _, row := range rows {
+		if !row.IsScoring || strings.TrimSpace(row.AnswerText) == "" {
+			continue
+		}
+		answerRows = append(answerRows, scoring.AnswerRow{
+			QuestionID:    row.QuestionID,
+			AnswerText:    row.AnswerText,
+			SectionTitle:  string(row.SectionID),
+			RiskName:      row.RiskName,
+			ScoringConfig: row.ScoringConfig,
+			IsScoring:     row.IsScoring,
+		})
+	}
+	if len(answerRows) == 0 {
+		respondErr(w, http.StatusConflict, "answer at least one question to see a preview")
+		return
+	}
+
+	risks, err := scoring.ComputeRisks(answerRows)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("compute risks: %w", err))
+		return
+	}
+
+	// The band uses the profile the report will be scored with.
+	profile := s.cfg.Settings.Current().ScoreProfile
+	if !s.cfg.Flags.Current().Enabled(flags.ScoreProfile, sessionID) {
+		profile = scoring.Profile{Mode: scoring.ModeMean}
+	}
+
+	top := risks[0]
+	respond(w, http.StatusOK, teaserResponse{
+		TopRisk:     teaserRisk{Name: top.RiskName, Tier: string(top.Tier)},
+		OverallBand: string(scoring.Band(profile.OverallScore(risks))),
+	})
+}
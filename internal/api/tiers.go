@@ -0,0 +1,141 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── TIER THEMES ──────────────────────────────────────────────────────────────
+//
+// tier_themes holds how each tier is presented — label, colour, description
+// and review cadence — so renaming "red" to "Hedge now" is a data change that
+// reaches the report, its matrix and GET /api/scoring/meta (which the PDF
+// renderer reads) at once. The tier keys and their regions of the grid stay
+// with the scorer. A tier without a row, or a failed read, falls back to the
+// scorer's built-in label and description: presentation never fails a report.
+
+// tierColorPattern is the form colours are stored in, as the schema checks.
+var tierColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// Bounds on the editable text of a tier theme.
+const (
+	maxTierLabelRunes       = 40
+	maxTierDescriptionRunes = 500
+	maxTierCadenceRunes     = 100
+)
+
+// tierMetas returns the tiers, most urgent first, themed from tier_themes.
+func (s *Server) tierMetas(r *http.Request) []tierMeta {
+	tiers := scoring.Tiers()
+	out := make([]tierMeta, len(tiers))
+	for i, t := range tiers {
+		out[i] = tierMeta{
+			Tier:        string(t.Tier),
+			Label:       t.Label,
+			Description: t.Description,
+			Probability: scoreRange{t.MinP, t.MaxP},
+			Impact:      scoreRange{t.MinI, t.MaxI},
+		}
+	}
+
+	themes, err := s.q.ListTierThemes(r.Context())
+	if err != nil {
+		s.logger.Warn("tiers: cannot load themes, using defaults", "error", err, logField(r))
+		return out
+	}
+	for _, theme := range themes {
+		i := slices.IndexFunc(out, func(m tierMeta) bool { return m.Tier == string(theme.Tier) })
+		if i < 0 {
+			continue
+		}
+		out[i].Label = theme.Label
+		out[i].Color = theme.Color
+		out[i].Description = theme.Description
+		out[i].Cadence = theme.Cadence
+	}
+	return out
+}
+
+// ─── PUT /api/admin/tiers/:tier ───────────────────────────────────────────────
+//
+// Replaces a tier's label, colour, description and cadence. Colours are
+// "#rrggbb"; upper case is folded to the stored lower case.
+
+type putTierRequest struct {
+	Label       string `json:"label"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	Cadence     string `json:"cadence"`
+}
+
+func (s *Server) handleAdminPutTier(w http.ResponseWriter, r *http.Request) {
+	tier := db.RiskTier(chi.URLParam(r, "tier"))
+	if !slices.ContainsFunc(scoring.Tiers(), func(t scoring.TierInfo) bool { return string(t.Tier) == string(tier) }) {
+		respondErr(w, http.StatusNotFound, "tier not found")
+		return
+	}
+
+	var req putTierRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	req.Label = strings.TrimSpace(req.Label)
+	req.Color = strings.ToLower(strings.TrimSpace(req.Color))
+	req.Description = strings.TrimSpace(req.Description)
+	req.Cadence = strings.TrimSpace(req.Cadence)
+	switch {
+	case req.Label == "":
+		respondErr(w, http.StatusBadRequest, "label is required")
+		return
+	case utf8.RuneCountInString(req.Label) > maxTierLabelRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("label must be at most %d characters", maxTierLabelRunes))
+		return
+	case !tierColorPattern.MatchString(req.Color):
+		respondErr(w, http.StatusBadRequest, `color must be a hex colour such as "#b91c1c"`)
+		return
+	case req.Description == "":
+		respondErr(w, http.StatusBadRequest, "description is required")
+		return
+	case utf8.RuneCountInString(req.Description) > maxTierDescriptionRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("description must be at most %d characters", maxTierDescriptionRunes))
+		return
+	case utf8.RuneCountInString(req.Cadence) > maxTierCadenceRunes:
+		respondErr(w, http.StatusBadRequest, fmt.Sprintf("cadence must be at most %d characters", maxTierCadenceRunes))
+		return
+	}
+
+	theme, err := s.q.UpdateTierTheme(r.Context(), db.UpdateTierThemeParams{
+		Tier:        tier,
+		Label:       req.Label,
+		Color:       req.Color,
+		Description: req.Description,
+		Cadence:     req.Cadence,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "tier not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("update tier theme %q: %w", tier, err))
+		return
+	}
+
+	s.logger.Info("admin: tier theme updated",
+		"tier", theme.Tier,
+		"label", theme.Label,
+		"color", theme.Color,
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusOK, theme)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ─── GET /api/admin/reports/:reportID/transcripts ─────────────────────────────
+//
+// The exact requests the worker sent to the AI providers for a report and
+// the raw responses they returned, one transcript per generation attempt,
+// oldest first, so "why did the AI say this?" can be answered. A transcript
+// kept in object storage is returned as a signed URL instead of inline.
+// Transcripts quote the customer's answers, so every read is audited.
+
+type transcriptResponse struct {
+	ID        string          `json:"id"`
+	CreatedAt string          `json:"created_at"`
+	Exchanges int32           `json:"exchanges"` // provider calls recorded
+	Body      json.RawMessage `json:"body,omitempty"`
+	URL       string          `json:"url,omitempty"`
+	ExpiresAt string          `json:"expires_at,omitempty"` // of URL
+}
+
+type adminTranscriptsResponse struct {
+	ReportID    string               `json:"report_id"`
+	Transcripts []transcriptResponse `json:"transcripts"`
+}
+
+func (s *Server) handleAdminListTranscripts(w http.ResponseWriter, r *http.Request) {
+	reportID, err := parseUUID(chi.URLParam(r, "reportID"))
+	if err != nil {
+		respondErr(w, http.StatusBadRequest, "invalid report_id")
+		return
+	}
+	if _, err := s.q.GetReportByID(r.Context(), reportID); errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "report not found")
+		return
+	} else if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get report: %w", err))
+		return
+	}
+
+	rows, err := s.q.ListAITranscriptsByReport(r.Context(), reportID)
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("list AI transcripts: %w", err))
+		return
+	}
+
+	out := adminTranscriptsResponse{ReportID: reportID.String(), Transcripts: make([]transcriptResponse, len(rows))}
+	for i, row := range rows {
+		t := transcriptResponse{
+			ID:        row.ID.String(),
+			CreatedAt: row.CreatedAt.UTC().Format(time.RFC3339),
+			Exchanges: row.Exchanges,
+			Body:      row.Body.RawMessage,
+		}
+		if row.StorageKey.Valid {
+			if s.cfg.Storage == nil {
+				s.respondInternalErr(w, r, fmt.Errorf("transcript %s is in object storage, which is not configured", row.ID))
+				return
+			}
+			url, expires := s.cfg.Storage.SignedURL(row.StorageKey.String, "")
+			t.URL, t.ExpiresAt = url, expires.UTC().Format(time.RFC3339)
+		}
+		out.Transcripts[i] = t
+	}
+
+	s.logger.Info("admin: AI transcripts read",
+		"report_id", reportID,
+		"transcripts", len(rows),
+		"audit", true,
+		logField(r),
+	)
+	respond(w, http.StatusOK, out)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ─── API VERSIONS ─────────────────────────────────────────────────────────────
+//
+// Every /api route is served at /api/v{N}/... for each version in apiVersions,
+// and at /api/... for clients that predate versioning. A version is picked:
+//
+//  1. from the path, when it has a /v{N} segment;
+//  2. otherwise from the API-Version request header ("2" or "v2");
+//  3. otherwise it is unversionedAPIVersion.
+//
+// The unversioned default is pinned rather than following the latest version,
+// so a frontend deployed before a breaking change keeps the responses it was
+// built against until it asks for the new version. An unknown version gets
+// 400 rather than a silent fallback.
+//
+// negotiateAPIVersion strips the version segment before routing, so every
+// version shares one route tree and path-based checks (CORS, degraded mode)
+// see one path per route. A handler whose response changes in a later
+// version branches on apiVersion(r.Context()).
+//
+// Responses name the version served in API-Version. Responses at an
+// unprefixed /api path also carry Vary: API-Version, since the same URL
+// answers differently per header. A version deprecated in
+// Config.APIDeprecations also gets Deprecation (RFC 9745), Sunset (RFC 8594)
+// and, when there is one, a Link to its successor.
+
+// apiVersions are the versions served, oldest first; the last is the latest.
+//
+// v2 changes:
+//   - GET /api/products prices each product as a money object (see
+//     productV2Response) rather than flat price_cents and currency.
+var apiVersions = []int{1, 2}
+
+// unversionedAPIVersion is served to /api/... requests that name no version.
+const unversionedAPIVersion = 1
+
+// apiVersionHeader names the API version asked for and served.
+const apiVersionHeader = "API-Version"
+
+// Deprecation marks an API version deprecated.
+type Deprecation struct {
+	// Since is when the version was deprecated.
+	Since time.Time
+	// Sunset is when it stops being served. Zero when not yet decided.
+	Sunset time.Time
+}
+
+// ParseDeprecations parses API_DEPRECATIONS entries, each
+// v<N>=<since>[/<sunset>] with dates as YYYY-MM-DD, e.g.
+// "v1=2026-11-01/2027-05-01".
+func ParseDeprecations(entries []string) (map[int]Deprecation, error) {
+	out := make(map[int]Deprecation, len(entries))
+	for _, entry := range entries {
+		name, dates, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want v<N>=<since>[/<sunset>]", entry)
+		}
+		version, ok := parseAPIVersion(name)
+		if !ok || !supportedAPIVersion(version) {
+			return nil, fmt.Errorf("%q: unknown API version %q", entry, name)
+		}
+		sinceStr, sunsetStr, hasSunset := strings.Cut(dates, "/")
+		var d Deprecation
+		var err error
+		if d.Since, err = time.Parse(time.DateOnly, sinceStr); err != nil {
+			return nil, fmt.Errorf("%q: deprecation date must be YYYY-MM-DD", entry)
+		}
+		if hasSunset {
+			if d.Sunset, err = time.Parse(time.DateOnly, sunsetStr); err != nil {
+				return nil, fmt.Errorf("%q: sunset date must be YYYY-MM-DD", entry)
+			}
+			if !d.Sunset.After(d.Since) {
+				return nil, fmt.Errorf("%q: sunset must be after the deprecation date", entry)
+			}
+		}
+		if _, dup := out[version]; dup {
+			return nil, fmt.Errorf("%q: v%d is listed twice", entry, version)
+		}
+		out[version] = d
+	}
+	return out, nil
+}
+
+// parseAPIVersion parses "2" or "v2".
+func parseAPIVersion(s string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(s), "v"))
+	return n, err == nil && n > 0
+}
+
+func supportedAPIVersion(n int) bool {
+	for _, v := range apiVersions {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+type apiVersionKey struct{}
+
+// apiVersion returns the API version a request is served as, or
+// unversionedAPIVersion outside /api.
+func apiVersion(ctx context.Context) int {
+	if v, ok := ctx.Value(apiVersionKey{}).(int); ok {
+		return v
+	}
+	return unversionedAPIVersion
+}
+
+// versionedPath matches the version segment of /api/v{N} and /api/v{N}/...
+var versionedPath = regexp.MustCompile(`^/api/v([0-9]+)(/|$)`)
+
+// negotiateAPIVersion picks the version of an /api request, strips it from
+// the path and sets the version headers. See the section comment.
+func (s *Server) negotiateAPIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" && !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version, asked := unversionedAPIVersion, ""
+		var unversioned *url.URL
+		if m := versionedPath.FindStringSubmatch(r.URL.Path); m != nil {
+			asked = "v" + m[1]
+			u := *r.URL
+			u.Path = "/api" + strings.TrimPrefix(u.Path, "/api/"+asked)
+			if u.RawPath != "" {
+				u.RawPath = "/api" + strings.TrimPrefix(u.RawPath, "/api/"+asked)
+			}
+			unversioned = &u
+		} else {
+			// The header picks the version, so a cache must key on it too.
+			w.Header().Add("Vary", apiVersionHeader)
+			if h := r.Header.Get(apiVersionHeader); h != "" {
+				asked = h
+			}
+		}
+		if asked != "" {
+			n, ok := parseAPIVersion(asked)
+			if !ok || !supportedAPIVersion(n) {
+				respondErr(w, http.StatusBadRequest, fmt.Sprintf("unsupported API version %q; supported: %s", asked, supportedAPIVersions()))
+				return
+			}
+			version = n
+		}
+
+		h := w.Header()
+		h.Set(apiVersionHeader, strconv.Itoa(version))
+		if d, ok := s.cfg.APIDeprecations[version]; ok {
+			h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+			if !d.Sunset.IsZero() {
+				h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if latest := apiVersions[len(apiVersions)-1]; latest > version {
+				h.Add("Link", fmt.Sprintf(`</api/v%d>; rel="successor-version"`, latest))
+			}
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version))
+		if unversioned != nil {
+			r.URL = unversioned
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// supportedAPIVersions lists apiVersions for error messages, e.g. "v1, v2".
+func supportedAPIVersions() string {
+	names := make([]string, len(apiVersions))
+	for i, v := range apiVersions {
+		names[i] = "v" + strconv.Itoa(v)
+	}
+	return strings.Join(names, ", ")
+}
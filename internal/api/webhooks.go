@@ -1,16 +1,24 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
 	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
 )
 
 // ─── POST /api/webhooks/stripe ────────────────────────────────────────────────
@@ -25,6 +33,14 @@ import (
 //   - payment_intent.succeeded  → initialise report + enqueue scoring job
 //   - payment_intent.payment_failed → mark session failed (informational)
 //   - charge.refunded           → update payment_status (for analytics)
+//   - charge.succeeded/updated  → record Stripe's fee and the net amount
+//   - customer.subscription.*   → mirror subscription status and period
+//   - invoice.paid              → record the subscriber's email and new period
+//
+// With Config.StripeEvents set, the event is only stored and queued here and
+// the worker dispatches it (see HandleStripeEvent): Stripe gets its 200 as
+// soon as the event is safe in stripe_events, so slow side effects never
+// cause a retry. An event that cannot be queued is dispatched here instead.
 func (s *Server) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 	// ── 1. Read and size-limit the body ───────────────────────────────────────
 	// Stripe recommends reading the raw body before any other processing so
@@ -38,7 +54,7 @@ func (s *Server) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 
 	// ── 2. Verify the Stripe-Signature header ─────────────────────────────────
 	sig := r.Header.Get("Stripe-Signature")
-	event, err := s.stripe.VerifyWebhook(payload, sig, s.cfg.StripeWebhookSecret)
+	event, err := s.stripe.VerifyWebhook(payload, sig, s.cfg.StripeWebhookSecrets)
 	if err != nil {
 		s.logger.Warn("webhook: invalid signature", "error", err, logField(r))
 		respondErr(w, http.StatusBadRequest, "invalid webhook signature")
@@ -61,25 +77,23 @@ func (s *Server) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// ── 4. Dispatch by event type ─────────────────────────────────────────────
-	var handlerErr error
-
-	switch event.Type {
-	case "payment_intent.succeeded":
-		handlerErr = s.onPaymentSucceeded(r, event)
-
-	case "payment_intent.payment_failed":
-		handlerErr = s.onPaymentFailed(r, event)
-
-	case "charge.refunded":
-		handlerErr = s.onChargeRefunded(r, event)
-
-	default:
-		// Unknown event type — ack immediately so Stripe stops retrying.
-		s.logger.Debug("webhook: unhandled event type", "type", event.Type, logField(r))
+	// ── 4. Hand off to the worker, when there is one ──────────────────────────
+	// A redelivery would be skipped as a duplicate in step 3, so an event
+	// that cannot be queued is dispatched now rather than lost.
+	if s.cfg.StripeEvents != nil {
+		err := s.cfg.StripeEvents.EnqueueStripeEvent(r.Context(), event.ID)
+		if err == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		s.logger.Warn("webhook: could not enqueue event, dispatching it now",
+			"event_id", event.ID, "error", err, logField(r))
 	}
 
-	// ── 5. Mark event processed (or failed) ───────────────────────────────────
+	// ── 5. Dispatch by event type ─────────────────────────────────────────────
+	handlerErr := s.dispatchStripeEvent(r, event)
+
+	// ── 6. Mark event processed (or failed) ───────────────────────────────────
 	if handlerErr != nil {
 		s.logger.Error("webhook: handler error",
 			"event_id", event.ID,
@@ -98,6 +112,123 @@ func (s *Server) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// ─── POST /api/admin/stripe-events/:eventID/replay ────────────────────────────
+//
+// Runs a stored Stripe event through its handler again, whether or not it was
+// processed before. Every handler is idempotent, so this is safe; it exists
+// for events that failed while a bug or outage was being fixed and that Stripe
+// has stopped retrying. The event comes from stripe_events, not from Stripe.
+//
+// Responds 200 with processed=false and the handler error when it fails
+// again, so the caller can tell a failed replay from a failed request.
+
+type replayStripeEventResponse struct {
+	EventID   string `json:"event_id"`
+	Type      string `json:"type"`
+	Processed bool   `json:"processed"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (s *Server) handleAdminReplayStripeEvent(w http.ResponseWriter, r *http.Request) {
+	stored, err := s.q.GetStripeEvent(r.Context(), chi.URLParam(r, "eventID"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondErr(w, http.StatusNotFound, "stripe event not found")
+		return
+	}
+	if err != nil {
+		s.respondInternalErr(w, r, fmt.Errorf("get stripe event: %w", err))
+		return
+	}
+	resp, err := s.replayStripeEvent(r, stored)
+	if err != nil {
+		s.respondInternalErr(w, r, err)
+		return
+	}
+	respond(w, http.StatusOK, resp)
+}
+
+// replayStripeEvent dispatches a stored event and records the outcome on its
+// row. A handler failure is reported in the response; the error is only for
+// a payload that cannot be parsed.
+func (s *Server) replayStripeEvent(r *http.Request, stored db.StripeEvent) (replayStripeEventResponse, error) {
+	event, err := stripeinternal.ParseStoredEvent(stored.Payload)
+	if err != nil {
+		return replayStripeEventResponse{}, err
+	}
+
+	s.logger.Info("admin: replaying stripe event",
+		"event_id", event.ID,
+		"type", event.Type,
+		"previously_processed", stored.Processed,
+		"audit", true,
+		logField(r),
+	)
+	resp := replayStripeEventResponse{EventID: event.ID, Type: event.Type}
+	if err := s.dispatchStripeEvent(r, event); err != nil {
+		s.logger.Error("admin: stripe event replay failed", "event_id", event.ID, "error", err, logField(r))
+		_, _ = s.q.MarkStripeEventFailed(r.Context(), stripeinternal.ToMarkFailedParams(event.ID, err))
+		resp.Error = err.Error()
+		return resp, nil
+	}
+
+	_, _ = s.q.MarkStripeEventProcessed(r.Context(), event.ID)
+	resp.Processed = true
+	return resp, nil
+}
+
+// HandleStripeEvent dispatches a stored event for the worker's
+// process_stripe_event job, which records the outcome. It satisfies
+// worker.StripeEventHandler. The handlers take the webhook request for its
+// context and request ID, so the event runs under a request carrying ctx
+// and the worker's trace ID.
+func (s *Server) HandleStripeEvent(ctx context.Context, stored db.StripeEvent) error {
+	event, err := stripeinternal.ParseStoredEvent(stored.Payload)
+	if err != nil {
+		return err
+	}
+	reqID := requestid.From(ctx)
+	if reqID == "" {
+		reqID = event.ID
+	}
+	r, err := http.NewRequestWithContext(context.WithValue(ctx, middleware.RequestIDKey, reqID),
+		http.MethodPost, "/api/webhooks/stripe", http.NoBody)
+	if err != nil {
+		return err
+	}
+	return s.dispatchStripeEvent(r, event)
+}
+
+// dispatchStripeEvent runs the handler for event.Type. Unknown types are a
+// no-op so Stripe stops retrying them. Shared by the webhook, the worker and
+// the admin replay endpoint.
+func (s *Server) dispatchStripeEvent(r *http.Request, event stripeinternal.Event) error {
+	switch event.Type {
+	case "payment_intent.succeeded":
+		return s.onPaymentSucceeded(r, event)
+
+	case "payment_intent.payment_failed":
+		return s.onPaymentFailed(r, event)
+
+	case "charge.refunded":
+		return s.onChargeRefunded(r, event)
+
+	case "charge.succeeded", "charge.updated":
+		return s.onChargeSettled(r, event)
+
+	case "customer.subscription.created",
+		"customer.subscription.updated",
+		"customer.subscription.deleted":
+		return s.onSubscriptionChanged(r, event)
+
+	case "invoice.paid":
+		return s.onInvoicePaid(r, event)
+
+	default:
+		s.logger.Debug("webhook: unhandled event type", "type", event.Type, logField(r))
+		return nil
+	}
+}
+
 // ─── EVENT HANDLERS ───────────────────────────────────────────────────────────
 
 func (s *Server) onPaymentSucceeded(r *http.Request, event stripeinternal.Event) error {
@@ -125,16 +256,58 @@ func (s *Server) onPaymentSucceeded(r *http.Request, event stripeinternal.Event)
 		return fmt.Errorf("onPaymentSucceeded: initialise report: %w", err)
 	}
 
-	// Send the receipt email immediately — don't wait for the report.
 	session, dbErr := s.q.GetSessionByID(r.Context(), report.SessionID)
+
+	// Report the sale to Stripe Tax. A failure here does not affect the
+	// customer; the calculation ID is on the session for manual follow-up.
+	if dbErr == nil && session.TaxCalculationID.Valid {
+		if err := s.stripe.RecordTaxTransaction(r.Context(), session.TaxCalculationID.String, piID); err != nil {
+			s.logger.Error("webhook: record tax transaction failed",
+				"session_id", session.ID,
+				"tax_calculation_id", session.TaxCalculationID.String,
+				"error", err,
+				logField(r),
+			)
+		}
+	}
+
+	// Send the receipt email immediately — don't wait for the report. During
+	// a spike it says when to expect the report.
 	if dbErr == nil && session.Email.Valid {
-		receiptErr := s.mailer.SendReceipt(r.Context(), email.ReceiptParams{
-			To:          session.Email.String,
-			BizName:     session.BizName.String,
-			AmountCents: 5900,
-			Currency:    "usd",
-		})
-		s.logAndIgnoreEmailErr(r, receiptErr, "send receipt")
+		readyIn := 0
+		if !report.HeldAt.Valid {
+			readyIn = s.readyInMinutes()
+		}
+		s.sendReceipt(r, event, session, report.ID, readyIn)
+	}
+
+	// A held duplicate purchase is not generated; see duplicates.go.
+	if report.HeldAt.Valid {
+		s.logger.Warn("webhook: duplicate purchase held",
+			"session_id", report.SessionID,
+			"report_id", report.ID,
+			"audit", true,
+			logField(r),
+		)
+		if s.cfg.DuplicateAutoRefund {
+			dup, _, err := s.resolveDuplicate(r.Context(), report.SessionID, store.DuplicateRefunded)
+			if err != nil {
+				// Left held for an operator; failing the webhook would not help.
+				s.logger.Error("webhook: automatic duplicate refund failed",
+					"session_id", report.SessionID,
+					"error", err,
+					logField(r),
+				)
+			} else {
+				s.logger.Info("webhook: duplicate purchase refunded",
+					"session_id", report.SessionID,
+					"stripe_refund_id", dup.StripeRefundID.String,
+					"audit", true,
+					logField(r),
+				)
+			}
+		}
+		return nil
 	}
 
 	// Enqueue the scoring job. The worker handles errors and retries.
@@ -150,6 +323,85 @@ func (s *Server) onPaymentSucceeded(r *http.Request, event stripeinternal.Event)
 	return nil
 }
 
+// sendReceipt emails the customer what Stripe actually charged: the amount
+// received, currency, tax, card and a link to Stripe's own receipt. Failures
+// are logged and swallowed — the receipt is a courtesy, the report is the
+// product. The send is recorded in email_log against the session and report.
+// readyIn is the expected wait in minutes, or 0 when the worker is not backed
+// up.
+func (s *Server) sendReceipt(r *http.Request, event stripeinternal.Event, session db.Session, reportID uuid.UUID, readyIn int) {
+	details, err := stripeinternal.ExtractPaymentDetails(event)
+	if err != nil {
+		s.logger.Warn("webhook: cannot read payment details, skipping receipt",
+			"event_id", event.ID,
+			"error", err,
+			logField(r),
+		)
+		return
+	}
+
+	// Webhook payloads normally reference the charge by ID only.
+	charge := details.Charge
+	if charge.ID != "" && charge.CardLast4 == "" && charge.ReceiptURL == "" {
+		fetched, err := s.stripe.GetCharge(r.Context(), charge.ID)
+		if err != nil {
+			s.logger.Warn("webhook: get charge failed, sending receipt without card details",
+				"charge_id", charge.ID,
+				"error", err,
+				logField(r),
+			)
+		} else {
+			charge = fetched
+		}
+	}
+
+	// Stripe retries webhooks and operators replay them; the receipt goes
+	// out once per report.
+	entry := email.LogEntry{
+		SessionID: session.ID,
+		ReportID:  reportID,
+		To:        session.Email.String,
+		Template:  email.TemplateReceipt,
+		DedupeKey: email.DedupeKey(reportID, email.TemplateReceipt),
+	}
+	if err := email.Claim(r.Context(), s.q, entry); errors.Is(err, email.ErrDuplicate) {
+		s.logger.Info("webhook: receipt already sent, skipping", "event_id", event.ID, "detail", err, logField(r))
+		return
+	} else if err != nil {
+		s.logger.Warn("webhook: could not claim receipt, sending anyway", "event_id", event.ID, "error", err, logField(r))
+	}
+
+	sent, err := s.mailer.SendReceipt(r.Context(), email.ReceiptParams{
+		To:          session.Email.String,
+		BizName:     session.BizName.String,
+		AmountCents: details.AmountCents,
+		Currency:    details.Currency,
+		TaxCents:    int64(session.TaxCents.Int32),
+		CardBrand:   charge.CardBrand,
+		CardLast4:   charge.CardLast4,
+		ReceiptURL:  charge.ReceiptURL,
+
+		ReadyInMinutes: readyIn,
+	})
+	s.logAndIgnoreEmailErr(r, err, "send receipt")
+	s.recordEmail(r, entry, sent, err)
+}
+
+// readyInMinutes estimates how long a report enqueued now takes, rounded up
+// to whole minutes, when the worker is backed up. It returns 0 when it is not,
+// or when the Enqueuer cannot say (see worker.Backlog).
+func (s *Server) readyInMinutes() int {
+	backlog, ok := s.worker.(worker.Backlog)
+	if !ok {
+		return 0
+	}
+	stats := backlog.QueueStats()
+	if !stats.Busy() {
+		return 0
+	}
+	return int(math.Ceil(stats.Wait().Minutes()))
+}
+
 func (s *Server) onPaymentFailed(r *http.Request, event stripeinternal.Event) error {
 	piID, err := stripeinternal.ExtractPaymentIntentID(event)
 	if err != nil {
@@ -193,3 +445,111 @@ func (s *Server) onChargeRefunded(r *http.Request, event stripeinternal.Event) e
 
 	return nil
 }
+
+// onChargeSettled records Stripe's fee and the net amount of a successful
+// charge. The balance transaction is often not ready at charge.succeeded for
+// non-card methods; the charge.updated that follows when it is fills the row
+// in instead.
+func (s *Server) onChargeSettled(r *http.Request, event stripeinternal.Event) error {
+	ch, err := stripeinternal.ExtractSettledCharge(event)
+	if err != nil {
+		return fmt.Errorf("onChargeSettled: extract charge: %w", err)
+	}
+	if ch.Status != "succeeded" || ch.BalanceTransactionID == "" {
+		s.logger.Debug("webhook: charge not settled yet",
+			"charge_id", ch.ID,
+			"status", ch.Status,
+			logField(r),
+		)
+		return nil
+	}
+
+	bt := ch.BalanceTransaction
+	if bt == nil {
+		fetched, err := s.stripe.GetBalanceTransaction(r.Context(), ch.BalanceTransactionID)
+		if err != nil {
+			return fmt.Errorf("onChargeSettled: get balance transaction: %w", err)
+		}
+		bt = &fetched
+	}
+
+	// Charges for subscription invoices have no session.
+	var sessionID uuid.NullUUID
+	pi := sql.NullString{String: ch.PaymentIntentID, Valid: ch.PaymentIntentID != ""}
+	if pi.Valid {
+		session, err := s.q.GetSessionByStripePI(r.Context(), pi)
+		switch {
+		case err == nil:
+			sessionID = uuid.NullUUID{UUID: session.ID, Valid: true}
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("onChargeSettled: get session: %w", err)
+		}
+	}
+
+	if _, err := s.q.UpsertPayment(r.Context(), db.UpsertPaymentParams{
+		StripeChargeID:             ch.ID,
+		StripePaymentIntent:        pi,
+		StripeBalanceTransactionID: bt.ID,
+		SessionID:                  sessionID,
+		AmountCents:                bt.AmountCents,
+		FeeCents:                   bt.FeeCents,
+		NetCents:                   bt.NetCents,
+		Currency:                   bt.Currency,
+	}); err != nil {
+		return fmt.Errorf("onChargeSettled: upsert payment: %w", err)
+	}
+
+	s.logger.Info("webhook: payment fees recorded",
+		"charge_id", ch.ID,
+		"fee_cents", bt.FeeCents,
+		"net_cents", bt.NetCents,
+		logField(r),
+	)
+	return nil
+}
+
+func (s *Server) onSubscriptionChanged(r *http.Request, event stripeinternal.Event) error {
+	sub, err := stripeinternal.ExtractSubscription(event)
+	if err != nil {
+		return fmt.Errorf("onSubscriptionChanged: extract subscription: %w", err)
+	}
+
+	// A deleted subscription arrives with status=canceled, which is enough to
+	// end the entitlement — the row is kept for history.
+	if _, err := s.q.UpsertSubscription(r.Context(), stripeinternal.ToUpsertSubscriptionParams(sub)); err != nil {
+		return fmt.Errorf("onSubscriptionChanged: upsert subscription: %w", err)
+	}
+
+	s.logger.Info("webhook: subscription updated",
+		"subscription_id", sub.ID,
+		"status", sub.Status,
+		"event_id", event.ID,
+		logField(r),
+	)
+	return nil
+}
+
+func (s *Server) onInvoicePaid(r *http.Request, event stripeinternal.Event) error {
+	sub, err := stripeinternal.ExtractPaidInvoice(event)
+	if errors.Is(err, stripeinternal.ErrNoSubscription) {
+		s.logger.Debug("webhook: invoice.paid without subscription", "event_id", event.ID, logField(r))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("onInvoicePaid: extract invoice: %w", err)
+	}
+
+	// Subscriptions are matched to sessions by email, which only the invoice
+	// carries. The new period also starts the next quarter's entitlement.
+	if _, err := s.q.UpsertSubscription(r.Context(), stripeinternal.ToUpsertSubscriptionParams(sub)); err != nil {
+		return fmt.Errorf("onInvoicePaid: upsert subscription: %w", err)
+	}
+
+	s.logger.Info("webhook: subscription invoice paid",
+		"subscription_id", sub.ID,
+		"period_end", sub.CurrentPeriodEnd,
+		"event_id", event.ID,
+		logField(r),
+	)
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package captcha_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/captcha"
+)
+
+func siteverify(t *testing.T, status int, body string) (*httptest.Server, *http.Request) {
+	t.Helper()
+	got := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		*got = *r
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestVerify_SendsSecretTokenAndIP(t *testing.T) {
+	srv, got := siteverify(t, http.StatusOK, `{"success": true}`)
+
+	err := captcha.New(srv.URL, "s3cret").Verify(context.Background(), "tok", "203.0.113.7")
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	for field, want := range map[string]string{"secret": "s3cret", "response": "tok", "remoteip": "203.0.113.7"} {
+		if v := got.PostForm.Get(field); v != want {
+			t.Errorf("%s = %q, want %q", field, v, want)
+		}
+	}
+}
+
+func TestVerify_UnsuccessfulIsRejected(t *testing.T) {
+	srv, _ := siteverify(t, http.StatusOK, `{"success": false, "error-codes": ["timeout-or-duplicate"]}`)
+
+	err := captcha.New(srv.URL, "s3cret").Verify(context.Background(), "tok", "")
+	if !errors.Is(err, captcha.ErrRejected) {
+		t.Errorf("expected ErrRejected, got %v", err)
+	}
+}
+
+func TestVerify_EmptyTokenIsRejectedWithoutCall(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	defer srv.Close()
+
+	err := captcha.New(srv.URL, "s3cret").Verify(context.Background(), "", "")
+	if !errors.Is(err, captcha.ErrRejected) || called {
+		t.Errorf("expected ErrRejected without a request, got %v (called=%v)", err, called)
+	}
+}
+
+func TestVerify_ProviderErrorIsNotRejection(t *testing.T) {
+	srv, _ := siteverify(t, http.StatusBadGateway, `upstream down`)
+
+	err := captcha.New(srv.URL, "s3cret").Verify(context.Background(), "tok", "")
+	if err == nil || errors.Is(err, captcha.ErrRejected) {
+		t.Errorf("expected a non-rejection error, got %v", err)
+	}
+}
+
+func TestVerifyURL(t *testing.T) {
+	if u, ok := captcha.VerifyURL("turnstile"); !ok || u != captcha.TurnstileVerifyURL {
+		t.Errorf("turnstile: got %q, %v", u, ok)
+	}
+	if u, ok := captcha.VerifyURL("hcaptcha"); !ok || u != captcha.HCaptchaVerifyURL {
+		t.Errorf("hcaptcha: got %q, %v", u, ok)
+	}
+	if _, ok := captcha.VerifyURL("recaptcha"); ok {
+		t.Error("expected recaptcha to be unsupported")
+	}
+}
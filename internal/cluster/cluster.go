@@ -0,0 +1,101 @@
+// Package cluster lets API replicas share state through Redis when REDIS_URL
+// is set: the lockout and resend counters (see lockout.Config.Redis), the
+// report cache kept for database outages, and notices that runtime settings
+// or feature flags were changed through the admin API.
+//
+// Without REDIS_URL each replica keeps that state in memory and picks up
+// admin changes on its next reload tick, which is how a single instance has
+// always run. Redis is never required for correctness: when it cannot be
+// reached, its users fall back to their in-memory state and log the error.
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix namespaces every key and channel this service uses, so the Redis
+// can be shared with other applications.
+const KeyPrefix = "arm:"
+
+// connectTimeout bounds the ping Connect makes at startup.
+const connectTimeout = 5 * time.Second
+
+// Connect returns a client for a redis:// or rediss:// URL once Redis has
+// answered a ping.
+func Connect(ctx context.Context, url string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: parse url: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("cluster: ping: %w", err)
+	}
+	return client, nil
+}
+
+// ─── NOTIFICATIONS ────────────────────────────────────────────────────────────
+
+// TopicReload is published after an admin write to runtime_settings or
+// feature_flags; every replica reloads both when it hears it.
+const TopicReload = "reload"
+
+// Notifier publishes and listens for notices on Redis pub/sub. A nil
+// *Notifier publishes nothing and never hears anything, so without Redis
+// every replica relies on its periodic reload.
+type Notifier struct {
+	client *redis.Client
+	logger *slog.Logger
+}
+
+// NewNotifier returns a Notifier on client, or nil when client is nil.
+func NewNotifier(client *redis.Client, logger *slog.Logger) *Notifier {
+	if client == nil {
+		return nil
+	}
+	return &Notifier{client: client, logger: logger}
+}
+
+// Notify publishes topic to every listening replica, this one included.
+// Failure is logged only — the periodic reloads will catch up.
+func (n *Notifier) Notify(ctx context.Context, topic string) {
+	if n == nil {
+		return
+	}
+	if err := n.client.Publish(ctx, KeyPrefix+topic, time.Now().UTC().Format(time.RFC3339)).Err(); err != nil {
+		n.logger.Error("cluster: publish failed", "topic", topic, "error", err)
+	}
+}
+
+// Listen calls fn each time topic is published until ctx is cancelled. The
+// subscription is re-established by the client after a lost connection;
+// notices published in between are missed.
+func (n *Notifier) Listen(ctx context.Context, topic string, fn func(context.Context)) {
+	if n == nil {
+		return
+	}
+	sub := n.client.Subscribe(ctx, KeyPrefix+topic)
+	defer sub.Close()
+
+	msgs := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-msgs:
+			if !ok {
+				return
+			}
+			fn(ctx)
+		}
+	}
+}
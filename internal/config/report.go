@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// ─── STARTUP REPORT ──────────────────────────────────────────────────────────
+
+// Redacted returns the loaded configuration keyed by environment variable
+// name, with secrets masked. It is logged at startup and served from
+// GET /api/admin/config so operators can confirm what the process actually
+// loaded without shelling into the container.
+//
+// Secrets keep their last four characters so two deploys can be compared
+// ("is this the rotated key?") without the value ever being printed.
+func (c *Config) Redacted() map[string]string {
+	return map[string]string{
+		"PORT":                          c.Port,
+		"ENV":                           c.Env,
+		"BASE_URL":                      c.BaseURL,
+		"TRUSTED_PROXIES":               joinPrefixes(c.TrustedProxies),
+		"COMPRESSION_LEVEL":             fmt.Sprint(c.CompressionLevel),
+		"HTTP_READ_HEADER_TIMEOUT":      c.HTTPReadHeaderTimeout.String(),
+		"HTTP_READ_TIMEOUT":             c.HTTPReadTimeout.String(),
+		"HTTP_WRITE_TIMEOUT":            c.HTTPWriteTimeout.String(),
+		"HTTP_IDLE_TIMEOUT":             c.HTTPIdleTimeout.String(),
+		"HTTP_MAX_HEADER_BYTES":         fmt.Sprint(c.HTTPMaxHeaderBytes),
+		"HTTP_KEEP_ALIVES":              fmt.Sprint(c.HTTPKeepAlives),
+		"TLS_CERT_FILE":                 c.TLSCertFile,
+		"TLS_KEY_FILE":                  c.TLSKeyFile,
+		"DATABASE_URL":                  redactURL(c.DatabaseURL),
+		"DATABASE_READ_URL":             redactURL(c.DatabaseReadURL),
+		"DB_MAX_OPEN_CONNS":             fmt.Sprint(c.DBMaxOpenConns),
+		"DB_MAX_IDLE_CONNS":             fmt.Sprint(c.DBMaxIdleConns),
+		"DB_CONN_MAX_LIFETIME":          c.DBConnMaxLifetime.String(),
+		"DB_CONN_MAX_IDLE_TIME":         c.DBConnMaxIdleTime.String(),
+		"DB_HTTP_CONNS":                 fmt.Sprint(c.DBHTTPConns),
+		"DB_TX_MAX_ATTEMPTS":            fmt.Sprint(c.DBTxMaxAttempts),
+		"DB_READ_MAX_ATTEMPTS":          fmt.Sprint(c.DBReadMaxAttempts),
+		"DB_PROBE_INTERVAL":             c.DBProbeInterval.String(),
+		"DB_PROBE_FAILURES":             fmt.Sprint(c.DBProbeFailures),
+		"DB_QUERY_TIMEOUT":              c.DBQueryTimeout.String(),
+		"DB_SLOW_QUERY_THRESHOLD":       c.DBSlowQueryThreshold.String(),
+		"REPORT_CACHE_SIZE":             fmt.Sprint(c.ReportCacheSize),
+		"REPORT_CACHE_TTL":              c.ReportCacheTTL.String(),
+		"REDIS_URL":                     redactURL(c.RedisURL),
+		"STRIPE_SECRET_KEY":             redactSecret(c.StripeSecretKey),
+		"STRIPE_WEBHOOK_SECRET":         redactList(c.StripeWebhookSecrets),
+		"STRIPE_TAX_ENABLED":            fmt.Sprint(c.StripeTaxEnabled),
+		"DUPLICATE_PURCHASE_WINDOW":     c.DuplicatePurchaseWindow.String(),
+		"DUPLICATE_MAX_CHANGED_ANSWERS": fmt.Sprint(c.DuplicateMaxChangedAnswers),
+		"DUPLICATE_AUTO_REFUND":         fmt.Sprint(c.DuplicateAutoRefund),
+		"ANTHROPIC_API_KEY":             redactSecret(c.AnthropicAPIKey),
+		"ANTHROPIC_MODEL":               c.AnthropicModel,
+		"ANTHROPIC_ANALYSIS_MODEL":      c.AnthropicAnalysisModel,
+		"DEEPSEEK_API_KEY":              redactSecret(c.DeepSeekAPIKey),
+		"DEEPSEEK_MODEL":                c.DeepSeekModel,
+		"DEEPSEEK_ANALYSIS_MODEL":       c.DeepSeekAnalysisModel,
+		"ANTHROPIC_TIMEOUT":             c.AnthropicTimeout.String(),
+		"ANTHROPIC_MAX_TOKENS":          fmt.Sprint(c.AnthropicMaxTokens),
+		"ANTHROPIC_TEMPERATURE":         optionalFloat(c.AnthropicTemperature),
+		"DEEPSEEK_TIMEOUT":              c.DeepSeekTimeout.String(),
+		"DEEPSEEK_MAX_TOKENS":           fmt.Sprint(c.DeepSeekMaxTokens),
+		"DEEPSEEK_TEMPERATURE":          optionalFloat(c.DeepSeekTemperature),
+		"AI_TIMEOUT":                    c.AITimeout.String(),
+		"AI_HEALTH_INTERVAL":            c.AIHealthInterval.String(),
+		"AI_CHUNK_SIZE":                 fmt.Sprint(c.AIChunkSize),
+		"AI_CACHE_TTL":                  c.AICacheTTL.String(),
+		"AI_TRANSCRIPTS":                fmt.Sprint(c.AITranscripts),
+		"AI_PLAYBOOK_SNIPPETS":          fmt.Sprint(c.AIPlaybookSnippets),
+		"AI_BANNED_PHRASES":             strings.Join(c.AIBannedPhrases, ","),
+		"AI_MAX_REPORT_TOKENS":          fmt.Sprint(c.AIMaxReportTokens),
+		"RESEND_API_KEY":                redactSecret(c.ResendAPIKey),
+		"RESEND_WEBHOOK_SECRET":         redactSecret(c.ResendWebhookSecret),
+		"EMAIL_RESEND_AFTER":            c.EmailResendAfter.String(),
+		"FEEDBACK_REQUEST_AFTER":        c.FeedbackRequestAfter.String(),
+		"EMAIL_FROM_ADDR":               c.EmailFromAddr,
+		"EMAIL_FROM_NAME":               c.EmailFromName,
+		"INVOICE_ISSUER":                strings.Join(c.InvoiceIssuer, "|"),
+		"CONSULTATION_URL":              c.ConsultationURL,
+		"API_DEPRECATIONS":              strings.Join(c.APIDeprecations, ","),
+		"STRICT_ANSWERS":                fmt.Sprint(c.StrictAnswers),
+		"SECTION_GATING":                fmt.Sprint(c.SectionGating),
+		"ANSWER_BATCH_HEADROOM":         fmt.Sprint(c.AnswerBatchHeadroom),
+		"PARTNER_KEYS":                  redactKeys(c.PartnerKeys),
+		"EMBED_ORIGINS":                 strings.Join(c.EmbedOrigins, ","),
+		"EMBED_TOKEN_TTL":               c.EmbedTokenTTL.String(),
+		"COHORT_REPORTS_PER_MINUTE":     fmt.Sprint(c.CohortReportsPerMinute),
+		"COHORT_MAX_ROWS":               fmt.Sprint(c.CohortMaxRows),
+		"FRAUD_MODE":                    c.FraudMode,
+		"FRAUD_IP_SESSIONS_PER_HOUR":    fmt.Sprint(c.FraudIPSessionsPerHour),
+		"FRAUD_MAX_FAILED_PAYMENTS":     fmt.Sprint(c.FraudMaxFailedPayments),
+		"FRAUD_DISPOSABLE_DOMAINS":      strings.Join(c.FraudDisposableDomains, ","),
+		"REPORT_LOCKOUT_IP_FAILURES":    fmt.Sprint(c.ReportLockoutIPFailures),
+		"REPORT_LOCKOUT_TOKEN_FAILURES": fmt.Sprint(c.ReportLockoutTokenFailures),
+		"REPORT_LOCKOUT_WINDOW":         c.ReportLockoutWindow.String(),
+		"REPORT_LOCKOUT_DURATION":       c.ReportLockoutDuration.String(),
+		"REPORT_RESEND_IP_LIMIT":        fmt.Sprint(c.ReportResendIPLimit),
+		"REPORT_RESEND_EMAIL_LIMIT":     fmt.Sprint(c.ReportResendEmailLimit),
+		"REPORT_RESEND_WINDOW":          c.ReportResendWindow.String(),
+		"CAPTCHA_PROVIDER":              c.CaptchaProvider,
+		"CAPTCHA_SECRET":                redactSecret(c.CaptchaSecret),
+		"IP_HASH_SALT":                  redactSecret(c.IPHashSalt),
+		"IP_PRIVACY_MODE":               fmt.Sprint(c.IPPrivacyMode),
+		"GEOIP_DB":                      c.GeoIPDB,
+		"FIELD_ENCRYPTION_KEYS":         redactKeys(c.FieldEncryptionKeys),
+		"FIELD_INDEX_KEY":               redactSecret(c.FieldIndexKey),
+		"WORKER_COUNT":                  fmt.Sprint(c.WorkerCount),
+		"POLL_INTERVAL":                 c.PollInterval.String(),
+		"JOB_TIMEOUT":                   c.JobTimeout.String(),
+		"MAX_RETRIES":                   fmt.Sprint(c.MaxRetries),
+		"WORKER_ID":                     c.WorkerID,
+		"SETTINGS_RELOAD_INTERVAL":      c.SettingsReloadInterval.String(),
+		"FEATURE_FLAGS":                 strings.Join(c.FeatureFlags, ","),
+		"EXPERIMENTS":                   strings.Join(c.Experiments, ","),
+		"EXPERIMENT_PRICES":             strings.Join(c.ExperimentPrices, ","),
+		"RETENTION_ANSWERS":             c.RetentionAnswers.String(),
+		"RETENTION_STRIPE_EVENTS":       c.RetentionStripeEvents.String(),
+		"RETENTION_EMAIL_LOG":           c.RetentionEmailLog.String(),
+		"RETENTION_AI_CACHE":            c.RetentionAICache.String(),
+		"RETENTION_AI_TRANSCRIPTS":      c.RetentionAITranscripts.String(),
+		"RETENTION_INTERVAL":            c.RetentionInterval.String(),
+		"RETENTION_DRY_RUN":             fmt.Sprint(c.RetentionDryRun),
+		"LOG_LEVEL":                     c.LogLevel,
+		"LOG_DEBUG_SAMPLE_RATE":         fmt.Sprint(c.LogDebugSampleRate),
+		"LOG_REDACT":                    fmt.Sprint(c.LogRedact),
+		"SENTRY_DSN":                    redactSecret(c.SentryDSN),
+		"SENTRY_ENVIRONMENT":            c.SentryEnvironment,
+		"RELEASE":                       c.Release,
+		"STORAGE_BUCKET":                c.StorageBucket,
+		"STORAGE_ENDPOINT":              c.StorageEndpoint,
+		"STORAGE_REGION":                c.StorageRegion,
+		"STORAGE_ACCESS_KEY_ID":         c.StorageAccessKeyID,
+		"STORAGE_SECRET_ACCESS_KEY":     redactSecret(c.StorageSecretAccessKey),
+		"STORAGE_PATH_STYLE":            fmt.Sprint(c.StoragePathStyle),
+		"STORAGE_URL_TTL":               c.StorageURLTTL.String(),
+		"ADMIN_API_KEY":                 redactSecret(c.AdminAPIKey),
+		"CONFIG_STRICT":                 fmt.Sprint(c.Strict),
+	}
+}
+
+// joinPrefixes renders TRUSTED_PROXIES back to its comma-separated form.
+func joinPrefixes(ps []netip.Prefix) string {
+	out := make([]string, len(ps))
+	for i, p := range ps {
+		out[i] = p.String()
+	}
+	return strings.Join(out, ",")
+}
+
+// optionalFloat renders an optional number, empty when unset.
+func optionalFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(*v)
+}
+
+// redactSecret masks everything but the last four characters. Short values
+// are masked completely; empty values stay empty so "unset" is visible.
+func redactSecret(v string) string {
+	switch {
+	case v == "":
+		return ""
+	case len(v) <= 8:
+		return "****"
+	default:
+		return "****" + v[len(v)-4:]
+	}
+}
+
+// redactList redacts each entry of a comma-separated secret list.
+func redactList(vs []string) string {
+	out := make([]string, len(vs))
+	for i, v := range vs {
+		out[i] = redactSecret(v)
+	}
+	return strings.Join(out, ",")
+}
+
+// redactKeys reduces "id:key" entries to their IDs, which say which key is
+// primary without revealing any of the key.
+func redactKeys(vs []string) string {
+	out := make([]string, len(vs))
+	for i, v := range vs {
+		id, _, _ := strings.Cut(v, ":")
+		out[i] = id + ":****"
+	}
+	return strings.Join(out, ",")
+}
+
+// redactURL hides the password component of a DSN.
+func redactURL(v string) string {
+	if v == "" {
+		return ""
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return "****"
+	}
+	return u.Redacted()
+}
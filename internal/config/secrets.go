@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ─── SECRETS ─────────────────────────────────────────────────────────────────
+
+// secretVars lists the variables that may be supplied indirectly instead of as
+// plain environment variables.
+var secretVars = []string{
+	"DATABASE_URL",
+	"DATABASE_READ_URL",
+	"REDIS_URL",
+	"STRIPE_SECRET_KEY",
+	"STRIPE_WEBHOOK_SECRET",
+	"ANTHROPIC_API_KEY",
+	"DEEPSEEK_API_KEY",
+	"RESEND_API_KEY",
+	"RESEND_WEBHOOK_SECRET",
+	"ADMIN_API_KEY",
+	"CAPTCHA_SECRET",
+	"IP_HASH_SALT",
+	"FIELD_ENCRYPTION_KEYS",
+	"FIELD_INDEX_KEY",
+	"PARTNER_KEYS",
+	"SENTRY_DSN",
+	"STORAGE_SECRET_ACCESS_KEY",
+}
+
+// secretResolver looks up secret values with a fixed precedence, highest first:
+//
+//  1. KEY set directly in the environment (or .env).
+//  2. KEY_FILE naming a file whose contents are the value — the Docker and
+//     Kubernetes secrets convention. One trailing newline is stripped.
+//  3. The Vault KV secret at VAULT_SECRET_PATH, when VAULT_ADDR is set. Keys
+//     inside the secret are the variable names, e.g. "STRIPE_SECRET_KEY".
+//
+// AWS Secrets Manager and GCP Secret Manager are supported through their CSI
+// drivers, which mount each secret as a file for KEY_FILE to read.
+//
+// Problems (unreadable file, unreachable Vault) are collected in errs rather
+// than aborting, so Load can report them alongside every other invalid var.
+type secretResolver struct {
+	vault map[string]string
+	errs  []error
+}
+
+func newSecretResolver() *secretResolver {
+	r := &secretResolver{}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return r
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if path := os.Getenv("VAULT_TOKEN_FILE"); path != "" {
+			token = r.readFile("VAULT_TOKEN_FILE", path)
+		}
+	}
+	path := os.Getenv("VAULT_SECRET_PATH")
+	if token == "" || path == "" {
+		r.errs = append(r.errs, &ValidationError{
+			Var: "VAULT_ADDR",
+			Msg: "VAULT_TOKEN (or VAULT_TOKEN_FILE) and VAULT_SECRET_PATH are required when VAULT_ADDR is set",
+		})
+		return r
+	}
+
+	vault, err := fetchVaultSecret(addr, token, path)
+	if err != nil {
+		r.errs = append(r.errs, &ValidationError{Var: "VAULT_ADDR", Msg: err.Error()})
+		return r
+	}
+	r.vault = vault
+	return r
+}
+
+// get returns the value for key following the precedence documented on
+// secretResolver. An unset secret resolves to "".
+func (r *secretResolver) get(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		return r.readFile(key+"_FILE", path)
+	}
+	return r.vault[key]
+}
+
+// shadowed warns about secrets defined more than one way, where the lower
+// precedence source is silently ignored.
+func (r *secretResolver) shadowed() []Warning {
+	var ws []Warning
+	for _, key := range secretVars {
+		if os.Getenv(key) != "" && os.Getenv(key+"_FILE") != "" {
+			ws = append(ws, Warning{key + "_FILE", fmt.Sprintf("ignored because %s is also set", key)})
+		}
+	}
+	return ws
+}
+
+func (r *secretResolver) readFile(name, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		r.errs = append(r.errs, &ValidationError{Var: name, Msg: fmt.Sprintf("cannot read secret file: %v", err)})
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+}
+
+// fetchVaultSecret reads a KV secret over Vault's HTTP API. Both KV v2
+// ({"data":{"data":{…}}}, path like "secret/data/app") and KV v1
+// ({"data":{…}}) responses are understood.
+func fetchVaultSecret(addr, token, path string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	url := strings.TrimRight(addr, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault: build request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault: %s returned status %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("vault: decode response: %w", err)
+	}
+
+	var v2 struct {
+		Data     map[string]string `json:"data"`
+		Metadata json.RawMessage   `json:"metadata"`
+	}
+	if err := json.Unmarshal(body.Data, &v2); err == nil && v2.Metadata != nil {
+		return v2.Data, nil
+	}
+
+	var v1 map[string]string
+	if err := json.Unmarshal(body.Data, &v1); err != nil {
+		return nil, fmt.Errorf("vault: secret values must be strings: %w", err)
+	}
+	return v1, nil
+}
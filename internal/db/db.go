@@ -24,33 +24,180 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.approveTestimonialStmt, err = db.PrepareContext(ctx, approveTestimonial); err != nil {
+		return nil, fmt.Errorf("error preparing query ApproveTestimonial: %w", err)
+	}
+	if q.assignExperimentStmt, err = db.PrepareContext(ctx, assignExperiment); err != nil {
+		return nil, fmt.Errorf("error preparing query AssignExperiment: %w", err)
+	}
+	if q.assignInvoiceNumberStmt, err = db.PrepareContext(ctx, assignInvoiceNumber); err != nil {
+		return nil, fmt.Errorf("error preparing query AssignInvoiceNumber: %w", err)
+	}
 	if q.attachStripeCustomerStmt, err = db.PrepareContext(ctx, attachStripeCustomer); err != nil {
 		return nil, fmt.Errorf("error preparing query AttachStripeCustomer: %w", err)
 	}
+	if q.claimEmailStmt, err = db.PrepareContext(ctx, claimEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimEmail: %w", err)
+	}
+	if q.claimJobStmt, err = db.PrepareContext(ctx, claimJob); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimJob: %w", err)
+	}
+	if q.claimPendingJobsStmt, err = db.PrepareContext(ctx, claimPendingJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimPendingJobs: %w", err)
+	}
+	if q.claimPendingReportsStmt, err = db.PrepareContext(ctx, claimPendingReports); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimPendingReports: %w", err)
+	}
+	if q.claimReportStmt, err = db.PrepareContext(ctx, claimReport); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimReport: %w", err)
+	}
+	if q.completeJobStmt, err = db.PrepareContext(ctx, completeJob); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteJob: %w", err)
+	}
 	if q.countAnsweredBySessionStmt, err = db.PrepareContext(ctx, countAnsweredBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query CountAnsweredBySession: %w", err)
 	}
+	if q.countExpiredAICacheStmt, err = db.PrepareContext(ctx, countExpiredAICache); err != nil {
+		return nil, fmt.Errorf("error preparing query CountExpiredAICache: %w", err)
+	}
+	if q.countExpiredAITranscriptsStmt, err = db.PrepareContext(ctx, countExpiredAITranscripts); err != nil {
+		return nil, fmt.Errorf("error preparing query CountExpiredAITranscripts: %w", err)
+	}
+	if q.countExpiredAnswersStmt, err = db.PrepareContext(ctx, countExpiredAnswers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountExpiredAnswers: %w", err)
+	}
+	if q.countExpiredEmailLogStmt, err = db.PrepareContext(ctx, countExpiredEmailLog); err != nil {
+		return nil, fmt.Errorf("error preparing query CountExpiredEmailLog: %w", err)
+	}
+	if q.countExpiredStripeEventsStmt, err = db.PrepareContext(ctx, countExpiredStripeEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query CountExpiredStripeEvents: %w", err)
+	}
+	if q.countFailedPaymentsByEmailSinceStmt, err = db.PrepareContext(ctx, countFailedPaymentsByEmailSince); err != nil {
+		return nil, fmt.Errorf("error preparing query CountFailedPaymentsByEmailSince: %w", err)
+	}
+	if q.countReportsAheadStmt, err = db.PrepareContext(ctx, countReportsAhead); err != nil {
+		return nil, fmt.Errorf("error preparing query CountReportsAhead: %w", err)
+	}
+	if q.countResearchRiskTiersStmt, err = db.PrepareContext(ctx, countResearchRiskTiers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountResearchRiskTiers: %w", err)
+	}
+	if q.countSessionsByIPHashSinceStmt, err = db.PrepareContext(ctx, countSessionsByIPHashSince); err != nil {
+		return nil, fmt.Errorf("error preparing query CountSessionsByIPHashSince: %w", err)
+	}
+	if q.createCohortSessionStmt, err = db.PrepareContext(ctx, createCohortSession); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCohortSession: %w", err)
+	}
+	if q.createDuplicatePurchaseStmt, err = db.PrepareContext(ctx, createDuplicatePurchase); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateDuplicatePurchase: %w", err)
+	}
+	if q.createFeedbackRequestStmt, err = db.PrepareContext(ctx, createFeedbackRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateFeedbackRequest: %w", err)
+	}
 	if q.createReportStmt, err = db.PrepareContext(ctx, createReport); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateReport: %w", err)
 	}
+	if q.createScheduledReportStmt, err = db.PrepareContext(ctx, createScheduledReport); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateScheduledReport: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.deleteExpiredAICacheStmt, err = db.PrepareContext(ctx, deleteExpiredAICache); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredAICache: %w", err)
+	}
+	if q.deleteExpiredAITranscriptsStmt, err = db.PrepareContext(ctx, deleteExpiredAITranscripts); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredAITranscripts: %w", err)
+	}
+	if q.deleteExpiredAnswersStmt, err = db.PrepareContext(ctx, deleteExpiredAnswers); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredAnswers: %w", err)
+	}
+	if q.deleteExpiredEmailLogStmt, err = db.PrepareContext(ctx, deleteExpiredEmailLog); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredEmailLog: %w", err)
+	}
+	if q.deleteExpiredStripeEventsStmt, err = db.PrepareContext(ctx, deleteExpiredStripeEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredStripeEvents: %w", err)
+	}
+	if q.deleteFeatureFlagStmt, err = db.PrepareContext(ctx, deleteFeatureFlag); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFeatureFlag: %w", err)
+	}
+	if q.deleteFinishedJobsStmt, err = db.PrepareContext(ctx, deleteFinishedJobs); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteFinishedJobs: %w", err)
+	}
+	if q.deletePlaybookSnippetStmt, err = db.PrepareContext(ctx, deletePlaybookSnippet); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePlaybookSnippet: %w", err)
+	}
+	if q.deleteRiskResultsByReportStmt, err = db.PrepareContext(ctx, deleteRiskResultsByReport); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRiskResultsByReport: %w", err)
+	}
+	if q.deleteRuntimeSettingStmt, err = db.PrepareContext(ctx, deleteRuntimeSetting); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRuntimeSetting: %w", err)
+	}
+	if q.editAIHedgeStmt, err = db.PrepareContext(ctx, editAIHedge); err != nil {
+		return nil, fmt.Errorf("error preparing query EditAIHedge: %w", err)
+	}
+	if q.editExecutiveSummaryStmt, err = db.PrepareContext(ctx, editExecutiveSummary); err != nil {
+		return nil, fmt.Errorf("error preparing query EditExecutiveSummary: %w", err)
+	}
+	if q.failJobStmt, err = db.PrepareContext(ctx, failJob); err != nil {
+		return nil, fmt.Errorf("error preparing query FailJob: %w", err)
+	}
 	if q.finalizeReportStmt, err = db.PrepareContext(ctx, finalizeReport); err != nil {
 		return nil, fmt.Errorf("error preparing query FinalizeReport: %w", err)
 	}
+	if q.getAICacheEntryStmt, err = db.PrepareContext(ctx, getAICacheEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAICacheEntry: %w", err)
+	}
 	if q.getAllQuestionDefinitionsStmt, err = db.PrepareContext(ctx, getAllQuestionDefinitions); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAllQuestionDefinitions: %w", err)
 	}
 	if q.getAnswersBySessionStmt, err = db.PrepareContext(ctx, getAnswersBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAnswersBySession: %w", err)
 	}
+	if q.getCohortScheduleEndStmt, err = db.PrepareContext(ctx, getCohortScheduleEnd); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCohortScheduleEnd: %w", err)
+	}
 	if q.getCompletionFunnelStatsStmt, err = db.PrepareContext(ctx, getCompletionFunnelStats); err != nil {
 		return nil, fmt.Errorf("error preparing query GetCompletionFunnelStats: %w", err)
 	}
+	if q.getConsultationStatsStmt, err = db.PrepareContext(ctx, getConsultationStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetConsultationStats: %w", err)
+	}
 	if q.getDailyRevenueStmt, err = db.PrepareContext(ctx, getDailyRevenue); err != nil {
 		return nil, fmt.Errorf("error preparing query GetDailyRevenue: %w", err)
 	}
+	if q.getDuplicateCreditStmt, err = db.PrepareContext(ctx, getDuplicateCredit); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDuplicateCredit: %w", err)
+	}
+	if q.getDuplicatePurchaseStmt, err = db.PrepareContext(ctx, getDuplicatePurchase); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDuplicatePurchase: %w", err)
+	}
+	if q.getEarlierCardPurchaseStmt, err = db.PrepareContext(ctx, getEarlierCardPurchase); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEarlierCardPurchase: %w", err)
+	}
+	if q.getEmailByDedupeKeyStmt, err = db.PrepareContext(ctx, getEmailByDedupeKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEmailByDedupeKey: %w", err)
+	}
+	if q.getEntitledSubscriptionStmt, err = db.PrepareContext(ctx, getEntitledSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEntitledSubscription: %w", err)
+	}
+	if q.getExperimentAssignmentsStmt, err = db.PrepareContext(ctx, getExperimentAssignments); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExperimentAssignments: %w", err)
+	}
+	if q.getExperimentStatsStmt, err = db.PrepareContext(ctx, getExperimentStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetExperimentStats: %w", err)
+	}
+	if q.getFeedbackByTokenStmt, err = db.PrepareContext(ctx, getFeedbackByToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFeedbackByToken: %w", err)
+	}
+	if q.getInvoiceByAccessTokenStmt, err = db.PrepareContext(ctx, getInvoiceByAccessToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetInvoiceByAccessToken: %w", err)
+	}
+	if q.getPaymentMarginStatsStmt, err = db.PrepareContext(ctx, getPaymentMarginStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPaymentMarginStats: %w", err)
+	}
+	if q.getProductBySKUStmt, err = db.PrepareContext(ctx, getProductBySKU); err != nil {
+		return nil, fmt.Errorf("error preparing query GetProductBySKU: %w", err)
+	}
 	if q.getQuestionByIDStmt, err = db.PrepareContext(ctx, getQuestionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetQuestionByID: %w", err)
 	}
@@ -63,6 +210,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getReportBySessionIDStmt, err = db.PrepareContext(ctx, getReportBySessionID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetReportBySessionID: %w", err)
 	}
+	if q.getRiskResultByQuestionStmt, err = db.PrepareContext(ctx, getRiskResultByQuestion); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRiskResultByQuestion: %w", err)
+	}
 	if q.getRiskResultsByReportStmt, err = db.PrepareContext(ctx, getRiskResultsByReport); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRiskResultsByReport: %w", err)
 	}
@@ -81,84 +231,507 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByStripePIStmt, err = db.PrepareContext(ctx, getSessionByStripePI); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByStripePI: %w", err)
 	}
+	if q.getStripeEventStmt, err = db.PrepareContext(ctx, getStripeEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStripeEvent: %w", err)
+	}
 	if q.getUnprocessedStripeEventsStmt, err = db.PrepareContext(ctx, getUnprocessedStripeEvents); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUnprocessedStripeEvents: %w", err)
 	}
 	if q.getWatchAndRedRisksStmt, err = db.PrepareContext(ctx, getWatchAndRedRisks); err != nil {
 		return nil, fmt.Errorf("error preparing query GetWatchAndRedRisks: %w", err)
 	}
+	if q.holdReportStmt, err = db.PrepareContext(ctx, holdReport); err != nil {
+		return nil, fmt.Errorf("error preparing query HoldReport: %w", err)
+	}
+	if q.insertAIEditStmt, err = db.PrepareContext(ctx, insertAIEdit); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertAIEdit: %w", err)
+	}
+	if q.insertAITranscriptStmt, err = db.PrepareContext(ctx, insertAITranscript); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertAITranscript: %w", err)
+	}
+	if q.insertJobStmt, err = db.PrepareContext(ctx, insertJob); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertJob: %w", err)
+	}
+	if q.insertReportAttemptStmt, err = db.PrepareContext(ctx, insertReportAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertReportAttempt: %w", err)
+	}
 	if q.insertRiskResultStmt, err = db.PrepareContext(ctx, insertRiskResult); err != nil {
 		return nil, fmt.Errorf("error preparing query InsertRiskResult: %w", err)
 	}
+	if q.insertSupportNoteStmt, err = db.PrepareContext(ctx, insertSupportNote); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertSupportNote: %w", err)
+	}
+	if q.listAIEditsByReportStmt, err = db.PrepareContext(ctx, listAIEditsByReport); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAIEditsByReport: %w", err)
+	}
+	if q.listAITranscriptsByReportStmt, err = db.PrepareContext(ctx, listAITranscriptsByReport); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAITranscriptsByReport: %w", err)
+	}
+	if q.listActivePlaybookSnippetsByIndustryStmt, err = db.PrepareContext(ctx, listActivePlaybookSnippetsByIndustry); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActivePlaybookSnippetsByIndustry: %w", err)
+	}
+	if q.listActiveProductsStmt, err = db.PrepareContext(ctx, listActiveProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveProducts: %w", err)
+	}
+	if q.listDeliverableReportsByEmailStmt, err = db.PrepareContext(ctx, listDeliverableReportsByEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDeliverableReportsByEmail: %w", err)
+	}
+	if q.listEmailLogAddressesStmt, err = db.PrepareContext(ctx, listEmailLogAddresses); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEmailLogAddresses: %w", err)
+	}
+	if q.listEmailLogBySessionStmt, err = db.PrepareContext(ctx, listEmailLogBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEmailLogBySession: %w", err)
+	}
+	if q.listEmailLogPageStmt, err = db.PrepareContext(ctx, listEmailLogPage); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEmailLogPage: %w", err)
+	}
+	if q.listFeatureFlagsStmt, err = db.PrepareContext(ctx, listFeatureFlags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeatureFlags: %w", err)
+	}
+	if q.listFeedbackCandidatesStmt, err = db.PrepareContext(ctx, listFeedbackCandidates); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeedbackCandidates: %w", err)
+	}
+	if q.listPaymentsByStripePIsStmt, err = db.PrepareContext(ctx, listPaymentsByStripePIs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPaymentsByStripePIs: %w", err)
+	}
 	if q.listPendingReportsStmt, err = db.PrepareContext(ctx, listPendingReports); err != nil {
 		return nil, fmt.Errorf("error preparing query ListPendingReports: %w", err)
 	}
+	if q.listPlaybookSnippetsStmt, err = db.PrepareContext(ctx, listPlaybookSnippets); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPlaybookSnippets: %w", err)
+	}
+	if q.listProductsStmt, err = db.PrepareContext(ctx, listProducts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListProducts: %w", err)
+	}
+	if q.listQuestionSectionsStmt, err = db.PrepareContext(ctx, listQuestionSections); err != nil {
+		return nil, fmt.Errorf("error preparing query ListQuestionSections: %w", err)
+	}
+	if q.listReportAttemptsStmt, err = db.PrepareContext(ctx, listReportAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReportAttempts: %w", err)
+	}
+	if q.listReprocessableStripeEventsStmt, err = db.PrepareContext(ctx, listReprocessableStripeEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReprocessableStripeEvents: %w", err)
+	}
+	if q.listResearchReportsStmt, err = db.PrepareContext(ctx, listResearchReports); err != nil {
+		return nil, fmt.Errorf("error preparing query ListResearchReports: %w", err)
+	}
+	if q.listRuntimeSettingsStmt, err = db.PrepareContext(ctx, listRuntimeSettings); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRuntimeSettings: %w", err)
+	}
+	if q.listSessionEmailsStmt, err = db.PrepareContext(ctx, listSessionEmails); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessionEmails: %w", err)
+	}
+	if q.listSessionsByStripePIsStmt, err = db.PrepareContext(ctx, listSessionsByStripePIs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessionsByStripePIs: %w", err)
+	}
+	if q.listSessionsPageStmt, err = db.PrepareContext(ctx, listSessionsPage); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessionsPage: %w", err)
+	}
+	if q.listStripeEventPayloadsStmt, err = db.PrepareContext(ctx, listStripeEventPayloads); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStripeEventPayloads: %w", err)
+	}
+	if q.listStripeEventsStmt, err = db.PrepareContext(ctx, listStripeEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStripeEvents: %w", err)
+	}
+	if q.listStripeEventsForExportStmt, err = db.PrepareContext(ctx, listStripeEventsForExport); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStripeEventsForExport: %w", err)
+	}
+	if q.listSubscriptionEmailsStmt, err = db.PrepareContext(ctx, listSubscriptionEmails); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSubscriptionEmails: %w", err)
+	}
+	if q.listSupportNotesBySessionStmt, err = db.PrepareContext(ctx, listSupportNotesBySession); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSupportNotesBySession: %w", err)
+	}
+	if q.listTestimonialsStmt, err = db.PrepareContext(ctx, listTestimonials); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTestimonials: %w", err)
+	}
+	if q.listTierThemesStmt, err = db.PrepareContext(ctx, listTierThemes); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTierThemes: %w", err)
+	}
+	if q.listUnopenedReportEmailsStmt, err = db.PrepareContext(ctx, listUnopenedReportEmails); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnopenedReportEmails: %w", err)
+	}
+	if q.listUnresolvedDuplicatePurchasesStmt, err = db.PrepareContext(ctx, listUnresolvedDuplicatePurchases); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnresolvedDuplicatePurchases: %w", err)
+	}
 	if q.logEmailStmt, err = db.PrepareContext(ctx, logEmail); err != nil {
 		return nil, fmt.Errorf("error preparing query LogEmail: %w", err)
 	}
+	if q.logEmailFailureStmt, err = db.PrepareContext(ctx, logEmailFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query LogEmailFailure: %w", err)
+	}
+	if q.logSkippedEmailStmt, err = db.PrepareContext(ctx, logSkippedEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query LogSkippedEmail: %w", err)
+	}
+	if q.markEmailBouncedStmt, err = db.PrepareContext(ctx, markEmailBounced); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkEmailBounced: %w", err)
+	}
+	if q.markEmailClaimSentStmt, err = db.PrepareContext(ctx, markEmailClaimSent); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkEmailClaimSent: %w", err)
+	}
+	if q.markEmailClickedStmt, err = db.PrepareContext(ctx, markEmailClicked); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkEmailClicked: %w", err)
+	}
 	if q.markEmailOpenedStmt, err = db.PrepareContext(ctx, markEmailOpened); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkEmailOpened: %w", err)
 	}
+	if q.markEmailResentStmt, err = db.PrepareContext(ctx, markEmailResent); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkEmailResent: %w", err)
+	}
 	if q.markSessionPaidStmt, err = db.PrepareContext(ctx, markSessionPaid); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkSessionPaid: %w", err)
 	}
+	if q.markSessionPaidByCreditStmt, err = db.PrepareContext(ctx, markSessionPaidByCredit); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkSessionPaidByCredit: %w", err)
+	}
+	if q.markSessionPaidBySubscriptionStmt, err = db.PrepareContext(ctx, markSessionPaidBySubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkSessionPaidBySubscription: %w", err)
+	}
 	if q.markSessionPaymentFailedStmt, err = db.PrepareContext(ctx, markSessionPaymentFailed); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkSessionPaymentFailed: %w", err)
 	}
+	if q.markSessionRefundedStmt, err = db.PrepareContext(ctx, markSessionRefunded); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkSessionRefunded: %w", err)
+	}
 	if q.markStripeEventFailedStmt, err = db.PrepareContext(ctx, markStripeEventFailed); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkStripeEventFailed: %w", err)
 	}
 	if q.markStripeEventProcessedStmt, err = db.PrepareContext(ctx, markStripeEventProcessed); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkStripeEventProcessed: %w", err)
 	}
+	if q.parkQuestionDisplayOrdersStmt, err = db.PrepareContext(ctx, parkQuestionDisplayOrders); err != nil {
+		return nil, fmt.Errorf("error preparing query ParkQuestionDisplayOrders: %w", err)
+	}
+	if q.recordFeedbackStmt, err = db.PrepareContext(ctx, recordFeedback); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordFeedback: %w", err)
+	}
+	if q.recordJobAttemptStmt, err = db.PrepareContext(ctx, recordJobAttempt); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordJobAttempt: %w", err)
+	}
+	if q.releaseEmailClaimStmt, err = db.PrepareContext(ctx, releaseEmailClaim); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseEmailClaim: %w", err)
+	}
+	if q.releaseJobClaimStmt, err = db.PrepareContext(ctx, releaseJobClaim); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseJobClaim: %w", err)
+	}
+	if q.releaseReportStmt, err = db.PrepareContext(ctx, releaseReport); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseReport: %w", err)
+	}
+	if q.releaseReportClaimStmt, err = db.PrepareContext(ctx, releaseReportClaim); err != nil {
+		return nil, fmt.Errorf("error preparing query ReleaseReportClaim: %w", err)
+	}
+	if q.requeueReportStmt, err = db.PrepareContext(ctx, requeueReport); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueReport: %w", err)
+	}
+	if q.requeueReportNarrativeStmt, err = db.PrepareContext(ctx, requeueReportNarrative); err != nil {
+		return nil, fmt.Errorf("error preparing query RequeueReportNarrative: %w", err)
+	}
+	if q.resolveDuplicatePurchaseStmt, err = db.PrepareContext(ctx, resolveDuplicatePurchase); err != nil {
+		return nil, fmt.Errorf("error preparing query ResolveDuplicatePurchase: %w", err)
+	}
+	if q.revokeReportStmt, err = db.PrepareContext(ctx, revokeReport); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeReport: %w", err)
+	}
+	if q.rotateReportAccessTokenStmt, err = db.PrepareContext(ctx, rotateReportAccessToken); err != nil {
+		return nil, fmt.Errorf("error preparing query RotateReportAccessToken: %w", err)
+	}
+	if q.saveReportJobStateStmt, err = db.PrepareContext(ctx, saveReportJobState); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveReportJobState: %w", err)
+	}
 	if q.setAIHedgeStmt, err = db.PrepareContext(ctx, setAIHedge); err != nil {
 		return nil, fmt.Errorf("error preparing query SetAIHedge: %w", err)
 	}
+	if q.setEmailLogAddressStmt, err = db.PrepareContext(ctx, setEmailLogAddress); err != nil {
+		return nil, fmt.Errorf("error preparing query SetEmailLogAddress: %w", err)
+	}
 	if q.setReportErrorStmt, err = db.PrepareContext(ctx, setReportError); err != nil {
 		return nil, fmt.Errorf("error preparing query SetReportError: %w", err)
 	}
 	if q.setReportProcessingStmt, err = db.PrepareContext(ctx, setReportProcessing); err != nil {
 		return nil, fmt.Errorf("error preparing query SetReportProcessing: %w", err)
 	}
+	if q.setSessionEmailStmt, err = db.PrepareContext(ctx, setSessionEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSessionEmail: %w", err)
+	}
+	if q.setStripeEventPayloadStmt, err = db.PrepareContext(ctx, setStripeEventPayload); err != nil {
+		return nil, fmt.Errorf("error preparing query SetStripeEventPayload: %w", err)
+	}
+	if q.setSubscriptionEmailStmt, err = db.PrepareContext(ctx, setSubscriptionEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSubscriptionEmail: %w", err)
+	}
+	if q.summariseReportAttemptsStmt, err = db.PrepareContext(ctx, summariseReportAttempts); err != nil {
+		return nil, fmt.Errorf("error preparing query SummariseReportAttempts: %w", err)
+	}
+	if q.summarizeShadowScoresStmt, err = db.PrepareContext(ctx, summarizeShadowScores); err != nil {
+		return nil, fmt.Errorf("error preparing query SummarizeShadowScores: %w", err)
+	}
+	if q.suppressEmailStmt, err = db.PrepareContext(ctx, suppressEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query SuppressEmail: %w", err)
+	}
+	if q.suppressSessionEmailStmt, err = db.PrepareContext(ctx, suppressSessionEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query SuppressSessionEmail: %w", err)
+	}
+	if q.tryLockSessionStmt, err = db.PrepareContext(ctx, tryLockSession); err != nil {
+		return nil, fmt.Errorf("error preparing query TryLockSession: %w", err)
+	}
+	if q.unlockSessionStmt, err = db.PrepareContext(ctx, unlockSession); err != nil {
+		return nil, fmt.Errorf("error preparing query UnlockSession: %w", err)
+	}
+	if q.updateQuestionSectionStmt, err = db.PrepareContext(ctx, updateQuestionSection); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateQuestionSection: %w", err)
+	}
 	if q.updateSessionContextStmt, err = db.PrepareContext(ctx, updateSessionContext); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSessionContext: %w", err)
 	}
+	if q.updateTierThemeStmt, err = db.PrepareContext(ctx, updateTierTheme); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateTierTheme: %w", err)
+	}
+	if q.upsertAICacheEntryStmt, err = db.PrepareContext(ctx, upsertAICacheEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertAICacheEntry: %w", err)
+	}
 	if q.upsertAnswerStmt, err = db.PrepareContext(ctx, upsertAnswer); err != nil {
 		return nil, fmt.Errorf("error preparing query UpsertAnswer: %w", err)
 	}
+	if q.upsertConsultationRequestStmt, err = db.PrepareContext(ctx, upsertConsultationRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertConsultationRequest: %w", err)
+	}
+	if q.upsertFeatureFlagStmt, err = db.PrepareContext(ctx, upsertFeatureFlag); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertFeatureFlag: %w", err)
+	}
+	if q.upsertPaymentStmt, err = db.PrepareContext(ctx, upsertPayment); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPayment: %w", err)
+	}
+	if q.upsertPlaybookSnippetStmt, err = db.PrepareContext(ctx, upsertPlaybookSnippet); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPlaybookSnippet: %w", err)
+	}
+	if q.upsertProductStmt, err = db.PrepareContext(ctx, upsertProduct); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertProduct: %w", err)
+	}
+	if q.upsertQuestionDefinitionStmt, err = db.PrepareContext(ctx, upsertQuestionDefinition); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertQuestionDefinition: %w", err)
+	}
+	if q.upsertRuntimeSettingStmt, err = db.PrepareContext(ctx, upsertRuntimeSetting); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRuntimeSetting: %w", err)
+	}
+	if q.upsertShadowScoreStmt, err = db.PrepareContext(ctx, upsertShadowScore); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertShadowScore: %w", err)
+	}
 	if q.upsertStripeEventStmt, err = db.PrepareContext(ctx, upsertStripeEvent); err != nil {
 		return nil, fmt.Errorf("error preparing query UpsertStripeEvent: %w", err)
 	}
+	if q.upsertSubscriptionStmt, err = db.PrepareContext(ctx, upsertSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertSubscription: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.approveTestimonialStmt != nil {
+		if cerr := q.approveTestimonialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing approveTestimonialStmt: %w", cerr)
+		}
+	}
+	if q.assignExperimentStmt != nil {
+		if cerr := q.assignExperimentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing assignExperimentStmt: %w", cerr)
+		}
+	}
+	if q.assignInvoiceNumberStmt != nil {
+		if cerr := q.assignInvoiceNumberStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing assignInvoiceNumberStmt: %w", cerr)
+		}
+	}
 	if q.attachStripeCustomerStmt != nil {
 		if cerr := q.attachStripeCustomerStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing attachStripeCustomerStmt: %w", cerr)
 		}
 	}
+	if q.claimEmailStmt != nil {
+		if cerr := q.claimEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimEmailStmt: %w", cerr)
+		}
+	}
+	if q.claimJobStmt != nil {
+		if cerr := q.claimJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimJobStmt: %w", cerr)
+		}
+	}
+	if q.claimPendingJobsStmt != nil {
+		if cerr := q.claimPendingJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimPendingJobsStmt: %w", cerr)
+		}
+	}
+	if q.claimPendingReportsStmt != nil {
+		if cerr := q.claimPendingReportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimPendingReportsStmt: %w", cerr)
+		}
+	}
+	if q.claimReportStmt != nil {
+		if cerr := q.claimReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimReportStmt: %w", cerr)
+		}
+	}
+	if q.completeJobStmt != nil {
+		if cerr := q.completeJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeJobStmt: %w", cerr)
+		}
+	}
 	if q.countAnsweredBySessionStmt != nil {
 		if cerr := q.countAnsweredBySessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing countAnsweredBySessionStmt: %w", cerr)
 		}
 	}
+	if q.countExpiredAICacheStmt != nil {
+		if cerr := q.countExpiredAICacheStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countExpiredAICacheStmt: %w", cerr)
+		}
+	}
+	if q.countExpiredAITranscriptsStmt != nil {
+		if cerr := q.countExpiredAITranscriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countExpiredAITranscriptsStmt: %w", cerr)
+		}
+	}
+	if q.countExpiredAnswersStmt != nil {
+		if cerr := q.countExpiredAnswersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countExpiredAnswersStmt: %w", cerr)
+		}
+	}
+	if q.countExpiredEmailLogStmt != nil {
+		if cerr := q.countExpiredEmailLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countExpiredEmailLogStmt: %w", cerr)
+		}
+	}
+	if q.countExpiredStripeEventsStmt != nil {
+		if cerr := q.countExpiredStripeEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countExpiredStripeEventsStmt: %w", cerr)
+		}
+	}
+	if q.countFailedPaymentsByEmailSinceStmt != nil {
+		if cerr := q.countFailedPaymentsByEmailSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countFailedPaymentsByEmailSinceStmt: %w", cerr)
+		}
+	}
+	if q.countReportsAheadStmt != nil {
+		if cerr := q.countReportsAheadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countReportsAheadStmt: %w", cerr)
+		}
+	}
+	if q.countResearchRiskTiersStmt != nil {
+		if cerr := q.countResearchRiskTiersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countResearchRiskTiersStmt: %w", cerr)
+		}
+	}
+	if q.countSessionsByIPHashSinceStmt != nil {
+		if cerr := q.countSessionsByIPHashSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countSessionsByIPHashSinceStmt: %w", cerr)
+		}
+	}
+	if q.createCohortSessionStmt != nil {
+		if cerr := q.createCohortSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCohortSessionStmt: %w", cerr)
+		}
+	}
+	if q.createDuplicatePurchaseStmt != nil {
+		if cerr := q.createDuplicatePurchaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createDuplicatePurchaseStmt: %w", cerr)
+		}
+	}
+	if q.createFeedbackRequestStmt != nil {
+		if cerr := q.createFeedbackRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createFeedbackRequestStmt: %w", cerr)
+		}
+	}
 	if q.createReportStmt != nil {
 		if cerr := q.createReportStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createReportStmt: %w", cerr)
 		}
 	}
+	if q.createScheduledReportStmt != nil {
+		if cerr := q.createScheduledReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createScheduledReportStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.deleteExpiredAICacheStmt != nil {
+		if cerr := q.deleteExpiredAICacheStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredAICacheStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredAITranscriptsStmt != nil {
+		if cerr := q.deleteExpiredAITranscriptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredAITranscriptsStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredAnswersStmt != nil {
+		if cerr := q.deleteExpiredAnswersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredAnswersStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredEmailLogStmt != nil {
+		if cerr := q.deleteExpiredEmailLogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredEmailLogStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredStripeEventsStmt != nil {
+		if cerr := q.deleteExpiredStripeEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredStripeEventsStmt: %w", cerr)
+		}
+	}
+	if q.deleteFeatureFlagStmt != nil {
+		if cerr := q.deleteFeatureFlagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFeatureFlagStmt: %w", cerr)
+		}
+	}
+	if q.deleteFinishedJobsStmt != nil {
+		if cerr := q.deleteFinishedJobsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteFinishedJobsStmt: %w", cerr)
+		}
+	}
+	if q.deletePlaybookSnippetStmt != nil {
+		if cerr := q.deletePlaybookSnippetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePlaybookSnippetStmt: %w", cerr)
+		}
+	}
+	if q.deleteRiskResultsByReportStmt != nil {
+		if cerr := q.deleteRiskResultsByReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRiskResultsByReportStmt: %w", cerr)
+		}
+	}
+	if q.deleteRuntimeSettingStmt != nil {
+		if cerr := q.deleteRuntimeSettingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRuntimeSettingStmt: %w", cerr)
+		}
+	}
+	if q.editAIHedgeStmt != nil {
+		if cerr := q.editAIHedgeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing editAIHedgeStmt: %w", cerr)
+		}
+	}
+	if q.editExecutiveSummaryStmt != nil {
+		if cerr := q.editExecutiveSummaryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing editExecutiveSummaryStmt: %w", cerr)
+		}
+	}
+	if q.failJobStmt != nil {
+		if cerr := q.failJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing failJobStmt: %w", cerr)
+		}
+	}
 	if q.finalizeReportStmt != nil {
 		if cerr := q.finalizeReportStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing finalizeReportStmt: %w", cerr)
 		}
 	}
+	if q.getAICacheEntryStmt != nil {
+		if cerr := q.getAICacheEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAICacheEntryStmt: %w", cerr)
+		}
+	}
 	if q.getAllQuestionDefinitionsStmt != nil {
 		if cerr := q.getAllQuestionDefinitionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAllQuestionDefinitionsStmt: %w", cerr)
@@ -169,16 +742,81 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getAnswersBySessionStmt: %w", cerr)
 		}
 	}
+	if q.getCohortScheduleEndStmt != nil {
+		if cerr := q.getCohortScheduleEndStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCohortScheduleEndStmt: %w", cerr)
+		}
+	}
 	if q.getCompletionFunnelStatsStmt != nil {
 		if cerr := q.getCompletionFunnelStatsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getCompletionFunnelStatsStmt: %w", cerr)
 		}
 	}
+	if q.getConsultationStatsStmt != nil {
+		if cerr := q.getConsultationStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getConsultationStatsStmt: %w", cerr)
+		}
+	}
 	if q.getDailyRevenueStmt != nil {
 		if cerr := q.getDailyRevenueStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getDailyRevenueStmt: %w", cerr)
 		}
 	}
+	if q.getDuplicateCreditStmt != nil {
+		if cerr := q.getDuplicateCreditStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDuplicateCreditStmt: %w", cerr)
+		}
+	}
+	if q.getDuplicatePurchaseStmt != nil {
+		if cerr := q.getDuplicatePurchaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDuplicatePurchaseStmt: %w", cerr)
+		}
+	}
+	if q.getEarlierCardPurchaseStmt != nil {
+		if cerr := q.getEarlierCardPurchaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEarlierCardPurchaseStmt: %w", cerr)
+		}
+	}
+	if q.getEmailByDedupeKeyStmt != nil {
+		if cerr := q.getEmailByDedupeKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEmailByDedupeKeyStmt: %w", cerr)
+		}
+	}
+	if q.getEntitledSubscriptionStmt != nil {
+		if cerr := q.getEntitledSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEntitledSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.getExperimentAssignmentsStmt != nil {
+		if cerr := q.getExperimentAssignmentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExperimentAssignmentsStmt: %w", cerr)
+		}
+	}
+	if q.getExperimentStatsStmt != nil {
+		if cerr := q.getExperimentStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getExperimentStatsStmt: %w", cerr)
+		}
+	}
+	if q.getFeedbackByTokenStmt != nil {
+		if cerr := q.getFeedbackByTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFeedbackByTokenStmt: %w", cerr)
+		}
+	}
+	if q.getInvoiceByAccessTokenStmt != nil {
+		if cerr := q.getInvoiceByAccessTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getInvoiceByAccessTokenStmt: %w", cerr)
+		}
+	}
+	if q.getPaymentMarginStatsStmt != nil {
+		if cerr := q.getPaymentMarginStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPaymentMarginStatsStmt: %w", cerr)
+		}
+	}
+	if q.getProductBySKUStmt != nil {
+		if cerr := q.getProductBySKUStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getProductBySKUStmt: %w", cerr)
+		}
+	}
 	if q.getQuestionByIDStmt != nil {
 		if cerr := q.getQuestionByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getQuestionByIDStmt: %w", cerr)
@@ -199,6 +837,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getReportBySessionIDStmt: %w", cerr)
 		}
 	}
+	if q.getRiskResultByQuestionStmt != nil {
+		if cerr := q.getRiskResultByQuestionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRiskResultByQuestionStmt: %w", cerr)
+		}
+	}
 	if q.getRiskResultsByReportStmt != nil {
 		if cerr := q.getRiskResultsByReportStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRiskResultsByReportStmt: %w", cerr)
@@ -229,6 +872,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByStripePIStmt: %w", cerr)
 		}
 	}
+	if q.getStripeEventStmt != nil {
+		if cerr := q.getStripeEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStripeEventStmt: %w", cerr)
+		}
+	}
 	if q.getUnprocessedStripeEventsStmt != nil {
 		if cerr := q.getUnprocessedStripeEventsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUnprocessedStripeEventsStmt: %w", cerr)
@@ -239,36 +887,261 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getWatchAndRedRisksStmt: %w", cerr)
 		}
 	}
+	if q.holdReportStmt != nil {
+		if cerr := q.holdReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing holdReportStmt: %w", cerr)
+		}
+	}
+	if q.insertAIEditStmt != nil {
+		if cerr := q.insertAIEditStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertAIEditStmt: %w", cerr)
+		}
+	}
+	if q.insertAITranscriptStmt != nil {
+		if cerr := q.insertAITranscriptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertAITranscriptStmt: %w", cerr)
+		}
+	}
+	if q.insertJobStmt != nil {
+		if cerr := q.insertJobStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertJobStmt: %w", cerr)
+		}
+	}
+	if q.insertReportAttemptStmt != nil {
+		if cerr := q.insertReportAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertReportAttemptStmt: %w", cerr)
+		}
+	}
 	if q.insertRiskResultStmt != nil {
 		if cerr := q.insertRiskResultStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing insertRiskResultStmt: %w", cerr)
 		}
 	}
+	if q.insertSupportNoteStmt != nil {
+		if cerr := q.insertSupportNoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertSupportNoteStmt: %w", cerr)
+		}
+	}
+	if q.listAIEditsByReportStmt != nil {
+		if cerr := q.listAIEditsByReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAIEditsByReportStmt: %w", cerr)
+		}
+	}
+	if q.listAITranscriptsByReportStmt != nil {
+		if cerr := q.listAITranscriptsByReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAITranscriptsByReportStmt: %w", cerr)
+		}
+	}
+	if q.listActivePlaybookSnippetsByIndustryStmt != nil {
+		if cerr := q.listActivePlaybookSnippetsByIndustryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActivePlaybookSnippetsByIndustryStmt: %w", cerr)
+		}
+	}
+	if q.listActiveProductsStmt != nil {
+		if cerr := q.listActiveProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveProductsStmt: %w", cerr)
+		}
+	}
+	if q.listDeliverableReportsByEmailStmt != nil {
+		if cerr := q.listDeliverableReportsByEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDeliverableReportsByEmailStmt: %w", cerr)
+		}
+	}
+	if q.listEmailLogAddressesStmt != nil {
+		if cerr := q.listEmailLogAddressesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEmailLogAddressesStmt: %w", cerr)
+		}
+	}
+	if q.listEmailLogBySessionStmt != nil {
+		if cerr := q.listEmailLogBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEmailLogBySessionStmt: %w", cerr)
+		}
+	}
+	if q.listEmailLogPageStmt != nil {
+		if cerr := q.listEmailLogPageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEmailLogPageStmt: %w", cerr)
+		}
+	}
+	if q.listFeatureFlagsStmt != nil {
+		if cerr := q.listFeatureFlagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeatureFlagsStmt: %w", cerr)
+		}
+	}
+	if q.listFeedbackCandidatesStmt != nil {
+		if cerr := q.listFeedbackCandidatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeedbackCandidatesStmt: %w", cerr)
+		}
+	}
+	if q.listPaymentsByStripePIsStmt != nil {
+		if cerr := q.listPaymentsByStripePIsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPaymentsByStripePIsStmt: %w", cerr)
+		}
+	}
 	if q.listPendingReportsStmt != nil {
 		if cerr := q.listPendingReportsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listPendingReportsStmt: %w", cerr)
 		}
 	}
+	if q.listPlaybookSnippetsStmt != nil {
+		if cerr := q.listPlaybookSnippetsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPlaybookSnippetsStmt: %w", cerr)
+		}
+	}
+	if q.listProductsStmt != nil {
+		if cerr := q.listProductsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listProductsStmt: %w", cerr)
+		}
+	}
+	if q.listQuestionSectionsStmt != nil {
+		if cerr := q.listQuestionSectionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listQuestionSectionsStmt: %w", cerr)
+		}
+	}
+	if q.listReportAttemptsStmt != nil {
+		if cerr := q.listReportAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReportAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.listReprocessableStripeEventsStmt != nil {
+		if cerr := q.listReprocessableStripeEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReprocessableStripeEventsStmt: %w", cerr)
+		}
+	}
+	if q.listResearchReportsStmt != nil {
+		if cerr := q.listResearchReportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listResearchReportsStmt: %w", cerr)
+		}
+	}
+	if q.listRuntimeSettingsStmt != nil {
+		if cerr := q.listRuntimeSettingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRuntimeSettingsStmt: %w", cerr)
+		}
+	}
+	if q.listSessionEmailsStmt != nil {
+		if cerr := q.listSessionEmailsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionEmailsStmt: %w", cerr)
+		}
+	}
+	if q.listSessionsByStripePIsStmt != nil {
+		if cerr := q.listSessionsByStripePIsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionsByStripePIsStmt: %w", cerr)
+		}
+	}
+	if q.listSessionsPageStmt != nil {
+		if cerr := q.listSessionsPageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionsPageStmt: %w", cerr)
+		}
+	}
+	if q.listStripeEventPayloadsStmt != nil {
+		if cerr := q.listStripeEventPayloadsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStripeEventPayloadsStmt: %w", cerr)
+		}
+	}
+	if q.listStripeEventsStmt != nil {
+		if cerr := q.listStripeEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStripeEventsStmt: %w", cerr)
+		}
+	}
+	if q.listStripeEventsForExportStmt != nil {
+		if cerr := q.listStripeEventsForExportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStripeEventsForExportStmt: %w", cerr)
+		}
+	}
+	if q.listSubscriptionEmailsStmt != nil {
+		if cerr := q.listSubscriptionEmailsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSubscriptionEmailsStmt: %w", cerr)
+		}
+	}
+	if q.listSupportNotesBySessionStmt != nil {
+		if cerr := q.listSupportNotesBySessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSupportNotesBySessionStmt: %w", cerr)
+		}
+	}
+	if q.listTestimonialsStmt != nil {
+		if cerr := q.listTestimonialsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTestimonialsStmt: %w", cerr)
+		}
+	}
+	if q.listTierThemesStmt != nil {
+		if cerr := q.listTierThemesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTierThemesStmt: %w", cerr)
+		}
+	}
+	if q.listUnopenedReportEmailsStmt != nil {
+		if cerr := q.listUnopenedReportEmailsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnopenedReportEmailsStmt: %w", cerr)
+		}
+	}
+	if q.listUnresolvedDuplicatePurchasesStmt != nil {
+		if cerr := q.listUnresolvedDuplicatePurchasesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnresolvedDuplicatePurchasesStmt: %w", cerr)
+		}
+	}
 	if q.logEmailStmt != nil {
 		if cerr := q.logEmailStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing logEmailStmt: %w", cerr)
 		}
 	}
+	if q.logEmailFailureStmt != nil {
+		if cerr := q.logEmailFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing logEmailFailureStmt: %w", cerr)
+		}
+	}
+	if q.logSkippedEmailStmt != nil {
+		if cerr := q.logSkippedEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing logSkippedEmailStmt: %w", cerr)
+		}
+	}
+	if q.markEmailBouncedStmt != nil {
+		if cerr := q.markEmailBouncedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markEmailBouncedStmt: %w", cerr)
+		}
+	}
+	if q.markEmailClaimSentStmt != nil {
+		if cerr := q.markEmailClaimSentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markEmailClaimSentStmt: %w", cerr)
+		}
+	}
+	if q.markEmailClickedStmt != nil {
+		if cerr := q.markEmailClickedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markEmailClickedStmt: %w", cerr)
+		}
+	}
 	if q.markEmailOpenedStmt != nil {
 		if cerr := q.markEmailOpenedStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing markEmailOpenedStmt: %w", cerr)
 		}
 	}
+	if q.markEmailResentStmt != nil {
+		if cerr := q.markEmailResentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markEmailResentStmt: %w", cerr)
+		}
+	}
 	if q.markSessionPaidStmt != nil {
 		if cerr := q.markSessionPaidStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing markSessionPaidStmt: %w", cerr)
 		}
 	}
+	if q.markSessionPaidByCreditStmt != nil {
+		if cerr := q.markSessionPaidByCreditStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markSessionPaidByCreditStmt: %w", cerr)
+		}
+	}
+	if q.markSessionPaidBySubscriptionStmt != nil {
+		if cerr := q.markSessionPaidBySubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markSessionPaidBySubscriptionStmt: %w", cerr)
+		}
+	}
 	if q.markSessionPaymentFailedStmt != nil {
 		if cerr := q.markSessionPaymentFailedStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing markSessionPaymentFailedStmt: %w", cerr)
 		}
 	}
+	if q.markSessionRefundedStmt != nil {
+		if cerr := q.markSessionRefundedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markSessionRefundedStmt: %w", cerr)
+		}
+	}
 	if q.markStripeEventFailedStmt != nil {
 		if cerr := q.markStripeEventFailedStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing markStripeEventFailedStmt: %w", cerr)
@@ -279,11 +1152,81 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing markStripeEventProcessedStmt: %w", cerr)
 		}
 	}
+	if q.parkQuestionDisplayOrdersStmt != nil {
+		if cerr := q.parkQuestionDisplayOrdersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing parkQuestionDisplayOrdersStmt: %w", cerr)
+		}
+	}
+	if q.recordFeedbackStmt != nil {
+		if cerr := q.recordFeedbackStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordFeedbackStmt: %w", cerr)
+		}
+	}
+	if q.recordJobAttemptStmt != nil {
+		if cerr := q.recordJobAttemptStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordJobAttemptStmt: %w", cerr)
+		}
+	}
+	if q.releaseEmailClaimStmt != nil {
+		if cerr := q.releaseEmailClaimStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseEmailClaimStmt: %w", cerr)
+		}
+	}
+	if q.releaseJobClaimStmt != nil {
+		if cerr := q.releaseJobClaimStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseJobClaimStmt: %w", cerr)
+		}
+	}
+	if q.releaseReportStmt != nil {
+		if cerr := q.releaseReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseReportStmt: %w", cerr)
+		}
+	}
+	if q.releaseReportClaimStmt != nil {
+		if cerr := q.releaseReportClaimStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing releaseReportClaimStmt: %w", cerr)
+		}
+	}
+	if q.requeueReportStmt != nil {
+		if cerr := q.requeueReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueReportStmt: %w", cerr)
+		}
+	}
+	if q.requeueReportNarrativeStmt != nil {
+		if cerr := q.requeueReportNarrativeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing requeueReportNarrativeStmt: %w", cerr)
+		}
+	}
+	if q.resolveDuplicatePurchaseStmt != nil {
+		if cerr := q.resolveDuplicatePurchaseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resolveDuplicatePurchaseStmt: %w", cerr)
+		}
+	}
+	if q.revokeReportStmt != nil {
+		if cerr := q.revokeReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeReportStmt: %w", cerr)
+		}
+	}
+	if q.rotateReportAccessTokenStmt != nil {
+		if cerr := q.rotateReportAccessTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rotateReportAccessTokenStmt: %w", cerr)
+		}
+	}
+	if q.saveReportJobStateStmt != nil {
+		if cerr := q.saveReportJobStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveReportJobStateStmt: %w", cerr)
+		}
+	}
 	if q.setAIHedgeStmt != nil {
 		if cerr := q.setAIHedgeStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing setAIHedgeStmt: %w", cerr)
 		}
 	}
+	if q.setEmailLogAddressStmt != nil {
+		if cerr := q.setEmailLogAddressStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setEmailLogAddressStmt: %w", cerr)
+		}
+	}
 	if q.setReportErrorStmt != nil {
 		if cerr := q.setReportErrorStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing setReportErrorStmt: %w", cerr)
@@ -294,21 +1237,126 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing setReportProcessingStmt: %w", cerr)
 		}
 	}
+	if q.setSessionEmailStmt != nil {
+		if cerr := q.setSessionEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSessionEmailStmt: %w", cerr)
+		}
+	}
+	if q.setStripeEventPayloadStmt != nil {
+		if cerr := q.setStripeEventPayloadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setStripeEventPayloadStmt: %w", cerr)
+		}
+	}
+	if q.setSubscriptionEmailStmt != nil {
+		if cerr := q.setSubscriptionEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSubscriptionEmailStmt: %w", cerr)
+		}
+	}
+	if q.summariseReportAttemptsStmt != nil {
+		if cerr := q.summariseReportAttemptsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing summariseReportAttemptsStmt: %w", cerr)
+		}
+	}
+	if q.summarizeShadowScoresStmt != nil {
+		if cerr := q.summarizeShadowScoresStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing summarizeShadowScoresStmt: %w", cerr)
+		}
+	}
+	if q.suppressEmailStmt != nil {
+		if cerr := q.suppressEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing suppressEmailStmt: %w", cerr)
+		}
+	}
+	if q.suppressSessionEmailStmt != nil {
+		if cerr := q.suppressSessionEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing suppressSessionEmailStmt: %w", cerr)
+		}
+	}
+	if q.tryLockSessionStmt != nil {
+		if cerr := q.tryLockSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing tryLockSessionStmt: %w", cerr)
+		}
+	}
+	if q.unlockSessionStmt != nil {
+		if cerr := q.unlockSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unlockSessionStmt: %w", cerr)
+		}
+	}
+	if q.updateQuestionSectionStmt != nil {
+		if cerr := q.updateQuestionSectionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateQuestionSectionStmt: %w", cerr)
+		}
+	}
 	if q.updateSessionContextStmt != nil {
 		if cerr := q.updateSessionContextStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateSessionContextStmt: %w", cerr)
 		}
 	}
+	if q.updateTierThemeStmt != nil {
+		if cerr := q.updateTierThemeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateTierThemeStmt: %w", cerr)
+		}
+	}
+	if q.upsertAICacheEntryStmt != nil {
+		if cerr := q.upsertAICacheEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertAICacheEntryStmt: %w", cerr)
+		}
+	}
 	if q.upsertAnswerStmt != nil {
 		if cerr := q.upsertAnswerStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing upsertAnswerStmt: %w", cerr)
 		}
 	}
+	if q.upsertConsultationRequestStmt != nil {
+		if cerr := q.upsertConsultationRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertConsultationRequestStmt: %w", cerr)
+		}
+	}
+	if q.upsertFeatureFlagStmt != nil {
+		if cerr := q.upsertFeatureFlagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertFeatureFlagStmt: %w", cerr)
+		}
+	}
+	if q.upsertPaymentStmt != nil {
+		if cerr := q.upsertPaymentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPaymentStmt: %w", cerr)
+		}
+	}
+	if q.upsertPlaybookSnippetStmt != nil {
+		if cerr := q.upsertPlaybookSnippetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPlaybookSnippetStmt: %w", cerr)
+		}
+	}
+	if q.upsertProductStmt != nil {
+		if cerr := q.upsertProductStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertProductStmt: %w", cerr)
+		}
+	}
+	if q.upsertQuestionDefinitionStmt != nil {
+		if cerr := q.upsertQuestionDefinitionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertQuestionDefinitionStmt: %w", cerr)
+		}
+	}
+	if q.upsertRuntimeSettingStmt != nil {
+		if cerr := q.upsertRuntimeSettingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRuntimeSettingStmt: %w", cerr)
+		}
+	}
+	if q.upsertShadowScoreStmt != nil {
+		if cerr := q.upsertShadowScoreStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertShadowScoreStmt: %w", cerr)
+		}
+	}
 	if q.upsertStripeEventStmt != nil {
 		if cerr := q.upsertStripeEventStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing upsertStripeEventStmt: %w", cerr)
 		}
 	}
+	if q.upsertSubscriptionStmt != nil {
+		if cerr := q.upsertSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertSubscriptionStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -346,83 +1394,345 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	attachStripeCustomerStmt       *sql.Stmt
-	countAnsweredBySessionStmt     *sql.Stmt
-	createReportStmt               *sql.Stmt
-	createSessionStmt              *sql.Stmt
-	finalizeReportStmt             *sql.Stmt
-	getAllQuestionDefinitionsStmt  *sql.Stmt
-	getAnswersBySessionStmt        *sql.Stmt
-	getCompletionFunnelStatsStmt   *sql.Stmt
-	getDailyRevenueStmt            *sql.Stmt
-	getQuestionByIDStmt            *sql.Stmt
-	getReportByAccessTokenStmt     *sql.Stmt
-	getReportByIDStmt              *sql.Stmt
-	getReportBySessionIDStmt       *sql.Stmt
-	getRiskResultsByReportStmt     *sql.Stmt
-	getRiskStatsStmt               *sql.Stmt
-	getScoringQuestionsStmt        *sql.Stmt
-	getSessionByAnonTokenStmt      *sql.Stmt
-	getSessionByIDStmt             *sql.Stmt
-	getSessionByStripePIStmt       *sql.Stmt
-	getUnprocessedStripeEventsStmt *sql.Stmt
-	getWatchAndRedRisksStmt        *sql.Stmt
-	insertRiskResultStmt           *sql.Stmt
-	listPendingReportsStmt         *sql.Stmt
-	logEmailStmt                   *sql.Stmt
-	markEmailOpenedStmt            *sql.Stmt
-	markSessionPaidStmt            *sql.Stmt
-	markSessionPaymentFailedStmt   *sql.Stmt
-	markStripeEventFailedStmt      *sql.Stmt
-	markStripeEventProcessedStmt   *sql.Stmt
-	setAIHedgeStmt                 *sql.Stmt
-	setReportErrorStmt             *sql.Stmt
-	setReportProcessingStmt        *sql.Stmt
-	updateSessionContextStmt       *sql.Stmt
-	upsertAnswerStmt               *sql.Stmt
-	upsertStripeEventStmt          *sql.Stmt
+	db                                       DBTX
+	tx                                       *sql.Tx
+	approveTestimonialStmt                   *sql.Stmt
+	assignExperimentStmt                     *sql.Stmt
+	assignInvoiceNumberStmt                  *sql.Stmt
+	attachStripeCustomerStmt                 *sql.Stmt
+	claimEmailStmt                           *sql.Stmt
+	claimJobStmt                             *sql.Stmt
+	claimPendingJobsStmt                     *sql.Stmt
+	claimPendingReportsStmt                  *sql.Stmt
+	claimReportStmt                          *sql.Stmt
+	completeJobStmt                          *sql.Stmt
+	countAnsweredBySessionStmt               *sql.Stmt
+	countExpiredAICacheStmt                  *sql.Stmt
+	countExpiredAITranscriptsStmt            *sql.Stmt
+	countExpiredAnswersStmt                  *sql.Stmt
+	countExpiredEmailLogStmt                 *sql.Stmt
+	countExpiredStripeEventsStmt             *sql.Stmt
+	countFailedPaymentsByEmailSinceStmt      *sql.Stmt
+	countReportsAheadStmt                    *sql.Stmt
+	countResearchRiskTiersStmt               *sql.Stmt
+	countSessionsByIPHashSinceStmt           *sql.Stmt
+	createCohortSessionStmt                  *sql.Stmt
+	createDuplicatePurchaseStmt              *sql.Stmt
+	createFeedbackRequestStmt                *sql.Stmt
+	createReportStmt                         *sql.Stmt
+	createScheduledReportStmt                *sql.Stmt
+	createSessionStmt                        *sql.Stmt
+	deleteExpiredAICacheStmt                 *sql.Stmt
+	deleteExpiredAITranscriptsStmt           *sql.Stmt
+	deleteExpiredAnswersStmt                 *sql.Stmt
+	deleteExpiredEmailLogStmt                *sql.Stmt
+	deleteExpiredStripeEventsStmt            *sql.Stmt
+	deleteFeatureFlagStmt                    *sql.Stmt
+	deleteFinishedJobsStmt                   *sql.Stmt
+	deletePlaybookSnippetStmt                *sql.Stmt
+	deleteRiskResultsByReportStmt            *sql.Stmt
+	deleteRuntimeSettingStmt                 *sql.Stmt
+	editAIHedgeStmt                          *sql.Stmt
+	editExecutiveSummaryStmt                 *sql.Stmt
+	failJobStmt                              *sql.Stmt
+	finalizeReportStmt                       *sql.Stmt
+	getAICacheEntryStmt                      *sql.Stmt
+	getAllQuestionDefinitionsStmt            *sql.Stmt
+	getAnswersBySessionStmt                  *sql.Stmt
+	getCohortScheduleEndStmt                 *sql.Stmt
+	getCompletionFunnelStatsStmt             *sql.Stmt
+	getConsultationStatsStmt                 *sql.Stmt
+	getDailyRevenueStmt                      *sql.Stmt
+	getDuplicateCreditStmt                   *sql.Stmt
+	getDuplicatePurchaseStmt                 *sql.Stmt
+	getEarlierCardPurchaseStmt               *sql.Stmt
+	getEmailByDedupeKeyStmt                  *sql.Stmt
+	getEntitledSubscriptionStmt              *sql.Stmt
+	getExperimentAssignmentsStmt             *sql.Stmt
+	getExperimentStatsStmt                   *sql.Stmt
+	getFeedbackByTokenStmt                   *sql.Stmt
+	getInvoiceByAccessTokenStmt              *sql.Stmt
+	getPaymentMarginStatsStmt                *sql.Stmt
+	getProductBySKUStmt                      *sql.Stmt
+	getQuestionByIDStmt                      *sql.Stmt
+	getReportByAccessTokenStmt               *sql.Stmt
+	getReportByIDStmt                        *sql.Stmt
+	getReportBySessionIDStmt                 *sql.Stmt
+	getRiskResultByQuestionStmt              *sql.Stmt
+	getRiskResultsByReportStmt               *sql.Stmt
+	getRiskStatsStmt                         *sql.Stmt
+	getScoringQuestionsStmt                  *sql.Stmt
+	getSessionByAnonTokenStmt                *sql.Stmt
+	getSessionByIDStmt                       *sql.Stmt
+	getSessionByStripePIStmt                 *sql.Stmt
+	getStripeEventStmt                       *sql.Stmt
+	getUnprocessedStripeEventsStmt           *sql.Stmt
+	getWatchAndRedRisksStmt                  *sql.Stmt
+	holdReportStmt                           *sql.Stmt
+	insertAIEditStmt                         *sql.Stmt
+	insertAITranscriptStmt                   *sql.Stmt
+	insertJobStmt                            *sql.Stmt
+	insertReportAttemptStmt                  *sql.Stmt
+	insertRiskResultStmt                     *sql.Stmt
+	insertSupportNoteStmt                    *sql.Stmt
+	listAIEditsByReportStmt                  *sql.Stmt
+	listAITranscriptsByReportStmt            *sql.Stmt
+	listActivePlaybookSnippetsByIndustryStmt *sql.Stmt
+	listActiveProductsStmt                   *sql.Stmt
+	listDeliverableReportsByEmailStmt        *sql.Stmt
+	listEmailLogAddressesStmt                *sql.Stmt
+	listEmailLogBySessionStmt                *sql.Stmt
+	listEmailLogPageStmt                     *sql.Stmt
+	listFeatureFlagsStmt                     *sql.Stmt
+	listFeedbackCandidatesStmt               *sql.Stmt
+	listPaymentsByStripePIsStmt              *sql.Stmt
+	listPendingReportsStmt                   *sql.Stmt
+	listPlaybookSnippetsStmt                 *sql.Stmt
+	listProductsStmt                         *sql.Stmt
+	listQuestionSectionsStmt                 *sql.Stmt
+	listReportAttemptsStmt                   *sql.Stmt
+	listReprocessableStripeEventsStmt        *sql.Stmt
+	listResearchReportsStmt                  *sql.Stmt
+	listRuntimeSettingsStmt                  *sql.Stmt
+	listSessionEmailsStmt                    *sql.Stmt
+	listSessionsByStripePIsStmt              *sql.Stmt
+	listSessionsPageStmt                     *sql.Stmt
+	listStripeEventPayloadsStmt              *sql.Stmt
+	listStripeEventsStmt                     *sql.Stmt
+	listStripeEventsForExportStmt            *sql.Stmt
+	listSubscriptionEmailsStmt               *sql.Stmt
+	listSupportNotesBySessionStmt            *sql.Stmt
+	listTestimonialsStmt                     *sql.Stmt
+	listTierThemesStmt                       *sql.Stmt
+	listUnopenedReportEmailsStmt             *sql.Stmt
+	listUnresolvedDuplicatePurchasesStmt     *sql.Stmt
+	logEmailStmt                             *sql.Stmt
+	logEmailFailureStmt                      *sql.Stmt
+	logSkippedEmailStmt                      *sql.Stmt
+	markEmailBouncedStmt                     *sql.Stmt
+	markEmailClaimSentStmt                   *sql.Stmt
+	markEmailClickedStmt                     *sql.Stmt
+	markEmailOpenedStmt                      *sql.Stmt
+	markEmailResentStmt                      *sql.Stmt
+	markSessionPaidStmt                      *sql.Stmt
+	markSessionPaidByCreditStmt              *sql.Stmt
+	markSessionPaidBySubscriptionStmt        *sql.Stmt
+	markSessionPaymentFailedStmt             *sql.Stmt
+	markSessionRefundedStmt                  *sql.Stmt
+	markStripeEventFailedStmt                *sql.Stmt
+	markStripeEventProcessedStmt             *sql.Stmt
+	parkQuestionDisplayOrdersStmt            *sql.Stmt
+	recordFeedbackStmt                       *sql.Stmt
+	recordJobAttemptStmt                     *sql.Stmt
+	releaseEmailClaimStmt                    *sql.Stmt
+	releaseJobClaimStmt                      *sql.Stmt
+	releaseReportStmt                        *sql.Stmt
+	releaseReportClaimStmt                   *sql.Stmt
+	requeueReportStmt                        *sql.Stmt
+	requeueReportNarrativeStmt               *sql.Stmt
+	resolveDuplicatePurchaseStmt             *sql.Stmt
+	revokeReportStmt                         *sql.Stmt
+	rotateReportAccessTokenStmt              *sql.Stmt
+	saveReportJobStateStmt                   *sql.Stmt
+	setAIHedgeStmt                           *sql.Stmt
+	setEmailLogAddressStmt                   *sql.Stmt
+	setReportErrorStmt                       *sql.Stmt
+	setReportProcessingStmt                  *sql.Stmt
+	setSessionEmailStmt                      *sql.Stmt
+	setStripeEventPayloadStmt                *sql.Stmt
+	setSubscriptionEmailStmt                 *sql.Stmt
+	summariseReportAttemptsStmt              *sql.Stmt
+	summarizeShadowScoresStmt                *sql.Stmt
+	suppressEmailStmt                        *sql.Stmt
+	suppressSessionEmailStmt                 *sql.Stmt
+	tryLockSessionStmt                       *sql.Stmt
+	unlockSessionStmt                        *sql.Stmt
+	updateQuestionSectionStmt                *sql.Stmt
+	updateSessionContextStmt                 *sql.Stmt
+	updateTierThemeStmt                      *sql.Stmt
+	upsertAICacheEntryStmt                   *sql.Stmt
+	upsertAnswerStmt                         *sql.Stmt
+	upsertConsultationRequestStmt            *sql.Stmt
+	upsertFeatureFlagStmt                    *sql.Stmt
+	upsertPaymentStmt                        *sql.Stmt
+	upsertPlaybookSnippetStmt                *sql.Stmt
+	upsertProductStmt                        *sql.Stmt
+	upsertQuestionDefinitionStmt             *sql.Stmt
+	upsertRuntimeSettingStmt                 *sql.Stmt
+	upsertShadowScoreStmt                    *sql.Stmt
+	upsertStripeEventStmt                    *sql.Stmt
+	upsertSubscriptionStmt                   *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		attachStripeCustomerStmt:       q.attachStripeCustomerStmt,
-		countAnsweredBySessionStmt:     q.countAnsweredBySessionStmt,
-		createReportStmt:               q.createReportStmt,
-		createSessionStmt:              q.createSessionStmt,
-		finalizeReportStmt:             q.finalizeReportStmt,
-		getAllQuestionDefinitionsStmt:  q.getAllQuestionDefinitionsStmt,
-		getAnswersBySessionStmt:        q.getAnswersBySessionStmt,
-		getCompletionFunnelStatsStmt:   q.getCompletionFunnelStatsStmt,
-		getDailyRevenueStmt:            q.getDailyRevenueStmt,
-		getQuestionByIDStmt:            q.getQuestionByIDStmt,
-		getReportByAccessTokenStmt:     q.getReportByAccessTokenStmt,
-		getReportByIDStmt:              q.getReportByIDStmt,
-		getReportBySessionIDStmt:       q.getReportBySessionIDStmt,
-		getRiskResultsByReportStmt:     q.getRiskResultsByReportStmt,
-		getRiskStatsStmt:               q.getRiskStatsStmt,
-		getScoringQuestionsStmt:        q.getScoringQuestionsStmt,
-		getSessionByAnonTokenStmt:      q.getSessionByAnonTokenStmt,
-		getSessionByIDStmt:             q.getSessionByIDStmt,
-		getSessionByStripePIStmt:       q.getSessionByStripePIStmt,
-		getUnprocessedStripeEventsStmt: q.getUnprocessedStripeEventsStmt,
-		getWatchAndRedRisksStmt:        q.getWatchAndRedRisksStmt,
-		insertRiskResultStmt:           q.insertRiskResultStmt,
-		listPendingReportsStmt:         q.listPendingReportsStmt,
-		logEmailStmt:                   q.logEmailStmt,
-		markEmailOpenedStmt:            q.markEmailOpenedStmt,
-		markSessionPaidStmt:            q.markSessionPaidStmt,
-		markSessionPaymentFailedStmt:   q.markSessionPaymentFailedStmt,
-		markStripeEventFailedStmt:      q.markStripeEventFailedStmt,
-		markStripeEventProcessedStmt:   q.markStripeEventProcessedStmt,
-		setAIHedgeStmt:                 q.setAIHedgeStmt,
-		setReportErrorStmt:             q.setReportErrorStmt,
-		setReportProcessingStmt:        q.setReportProcessingStmt,
-		updateSessionContextStmt:       q.updateSessionContextStmt,
-		upsertAnswerStmt:               q.upsertAnswerStmt,
-		upsertStripeEventStmt:          q.upsertStripeEventStmt,
+		db:                                       tx,
+		tx:                                       tx,
+		approveTestimonialStmt:                   q.approveTestimonialStmt,
+		assignExperimentStmt:                     q.assignExperimentStmt,
+		assignInvoiceNumberStmt:                  q.assignInvoiceNumberStmt,
+		attachStripeCustomerStmt:                 q.attachStripeCustomerStmt,
+		claimEmailStmt:                           q.claimEmailStmt,
+		claimJobStmt:                             q.claimJobStmt,
+		claimPendingJobsStmt:                     q.claimPendingJobsStmt,
+		claimPendingReportsStmt:                  q.claimPendingReportsStmt,
+		claimReportStmt:                          q.claimReportStmt,
+		completeJobStmt:                          q.completeJobStmt,
+		countAnsweredBySessionStmt:               q.countAnsweredBySessionStmt,
+		countExpiredAICacheStmt:                  q.countExpiredAICacheStmt,
+		countExpiredAITranscriptsStmt:            q.countExpiredAITranscriptsStmt,
+		countExpiredAnswersStmt:                  q.countExpiredAnswersStmt,
+		countExpiredEmailLogStmt:                 q.countExpiredEmailLogStmt,
+		countExpiredStripeEventsStmt:             q.countExpiredStripeEventsStmt,
+		countFailedPaymentsByEmailSinceStmt:      q.countFailedPaymentsByEmailSinceStmt,
+		countReportsAheadStmt:                    q.countReportsAheadStmt,
+		countResearchRiskTiersStmt:               q.countResearchRiskTiersStmt,
+		countSessionsByIPHashSinceStmt:           q.countSessionsByIPHashSinceStmt,
+		createCohortSessionStmt:                  q.createCohortSessionStmt,
+		createDuplicatePurchaseStmt:              q.createDuplicatePurchaseStmt,
+		createFeedbackRequestStmt:                q.createFeedbackRequestStmt,
+		createReportStmt:                         q.createReportStmt,
+		createScheduledReportStmt:                q.createScheduledReportStmt,
+		createSessionStmt:                        q.createSessionStmt,
+		deleteExpiredAICacheStmt:                 q.deleteExpiredAICacheStmt,
+		deleteExpiredAITranscriptsStmt:           q.deleteExpiredAITranscriptsStmt,
+		deleteExpiredAnswersStmt:                 q.deleteExpiredAnswersStmt,
+		deleteExpiredEmailLogStmt:                q.deleteExpiredEmailLogStmt,
+		deleteExpiredStripeEventsStmt:            q.deleteExpiredStripeEventsStmt,
+		deleteFeatureFlagStmt:                    q.deleteFeatureFlagStmt,
+		deleteFinishedJobsStmt:                   q.deleteFinishedJobsStmt,
+		deletePlaybookSnippetStmt:                q.deletePlaybookSnippetStmt,
+		deleteRiskResultsByReportStmt:            q.deleteRiskResultsByReportStmt,
+		deleteRuntimeSettingStmt:                 q.deleteRuntimeSettingStmt,
+		editAIHedgeStmt:                          q.editAIHedgeStmt,
+		editExecutiveSummaryStmt:                 q.editExecutiveSummaryStmt,
+		failJobStmt:                              q.failJobStmt,
+		finalizeReportStmt:                       q.finalizeReportStmt,
+		getAICacheEntryStmt:                      q.getAICacheEntryStmt,
+		getAllQuestionDefinitionsStmt:            q.getAllQuestionDefinitionsStmt,
+		getAnswersBySessionStmt:                  q.getAnswersBySessionStmt,
+		getCohortScheduleEndStmt:                 q.getCohortScheduleEndStmt,
+		getCompletionFunnelStatsStmt:             q.getCompletionFunnelStatsStmt,
+		getConsultationStatsStmt:                 q.getConsultationStatsStmt,
+		getDailyRevenueStmt:                      q.getDailyRevenueStmt,
+		getDuplicateCreditStmt:                   q.getDuplicateCreditStmt,
+		getDuplicatePurchaseStmt:                 q.getDuplicatePurchaseStmt,
+		getEarlierCardPurchaseStmt:               q.getEarlierCardPurchaseStmt,
+		getEmailByDedupeKeyStmt:                  q.getEmailByDedupeKeyStmt,
+		getEntitledSubscriptionStmt:              q.getEntitledSubscriptionStmt,
+		getExperimentAssignmentsStmt:             q.getExperimentAssignmentsStmt,
+		getExperimentStatsStmt:                   q.getExperimentStatsStmt,
+		getFeedbackByTokenStmt:                   q.getFeedbackByTokenStmt,
+		getInvoiceByAccessTokenStmt:              q.getInvoiceByAccessTokenStmt,
+		getPaymentMarginStatsStmt:                q.getPaymentMarginStatsStmt,
+		getProductBySKUStmt:                      q.getProductBySKUStmt,
+		getQuestionByIDStmt:                      q.getQuestionByIDStmt,
+		getReportByAccessTokenStmt:               q.getReportByAccessTokenStmt,
+		getReportByIDStmt:                        q.getReportByIDStmt,
+		getReportBySessionIDStmt:                 q.getReportBySessionIDStmt,
+		getRiskResultByQuestionStmt:              q.getRiskResultByQuestionStmt,
+		getRiskResultsByReportStmt:               q.getRiskResultsByReportStmt,
+		getRiskStatsStmt:                         q.getRiskStatsStmt,
+		getScoringQuestionsStmt:                  q.getScoringQuestionsStmt,
+		getSessionByAnonTokenStmt:                q.getSessionByAnonTokenStmt,
+		getSessionByIDStmt:                       q.getSessionByIDStmt,
+		getSessionByStripePIStmt:                 q.getSessionByStripePIStmt,
+		getStripeEventStmt:                       q.getStripeEventStmt,
+		getUnprocessedStripeEventsStmt:           q.getUnprocessedStripeEventsStmt,
+		getWatchAndRedRisksStmt:                  q.getWatchAndRedRisksStmt,
+		holdReportStmt:                           q.holdReportStmt,
+		insertAIEditStmt:                         q.insertAIEditStmt,
+		insertAITranscriptStmt:                   q.insertAITranscriptStmt,
+		insertJobStmt:                            q.insertJobStmt,
+		insertReportAttemptStmt:                  q.insertReportAttemptStmt,
+		insertRiskResultStmt:                     q.insertRiskResultStmt,
+		insertSupportNoteStmt:                    q.insertSupportNoteStmt,
+		listAIEditsByReportStmt:                  q.listAIEditsByReportStmt,
+		listAITranscriptsByReportStmt:            q.listAITranscriptsByReportStmt,
+		listActivePlaybookSnippetsByIndustryStmt: q.listActivePlaybookSnippetsByIndustryStmt,
+		listActiveProductsStmt:                   q.listActiveProductsStmt,
+		listDeliverableReportsByEmailStmt:        q.listDeliverableReportsByEmailStmt,
+		listEmailLogAddressesStmt:                q.listEmailLogAddressesStmt,
+		listEmailLogBySessionStmt:                q.listEmailLogBySessionStmt,
+		listEmailLogPageStmt:                     q.listEmailLogPageStmt,
+		listFeatureFlagsStmt:                     q.listFeatureFlagsStmt,
+		listFeedbackCandidatesStmt:               q.listFeedbackCandidatesStmt,
+		listPaymentsByStripePIsStmt:              q.listPaymentsByStripePIsStmt,
+		listPendingReportsStmt:                   q.listPendingReportsStmt,
+		listPlaybookSnippetsStmt:                 q.listPlaybookSnippetsStmt,
+		listProductsStmt:                         q.listProductsStmt,
+		listQuestionSectionsStmt:                 q.listQuestionSectionsStmt,
+		listReportAttemptsStmt:                   q.listReportAttemptsStmt,
+		listReprocessableStripeEventsStmt:        q.listReprocessableStripeEventsStmt,
+		listResearchReportsStmt:                  q.listResearchReportsStmt,
+		listRuntimeSettingsStmt:                  q.listRuntimeSettingsStmt,
+		listSessionEmailsStmt:                    q.listSessionEmailsStmt,
+		listSessionsByStripePIsStmt:              q.listSessionsByStripePIsStmt,
+		listSessionsPageStmt:                     q.listSessionsPageStmt,
+		listStripeEventPayloadsStmt:              q.listStripeEventPayloadsStmt,
+		listStripeEventsStmt:                     q.listStripeEventsStmt,
+		listStripeEventsForExportStmt:            q.listStripeEventsForExportStmt,
+		listSubscriptionEmailsStmt:               q.listSubscriptionEmailsStmt,
+		listSupportNotesBySessionStmt:            q.listSupportNotesBySessionStmt,
+		listTestimonialsStmt:                     q.listTestimonialsStmt,
+		listTierThemesStmt:                       q.listTierThemesStmt,
+		listUnopenedReportEmailsStmt:             q.listUnopenedReportEmailsStmt,
+		listUnresolvedDuplicatePurchasesStmt:     q.listUnresolvedDuplicatePurchasesStmt,
+		logEmailStmt:                             q.logEmailStmt,
+		logEmailFailureStmt:                      q.logEmailFailureStmt,
+		logSkippedEmailStmt:                      q.logSkippedEmailStmt,
+		markEmailBouncedStmt:                     q.markEmailBouncedStmt,
+		markEmailClaimSentStmt:                   q.markEmailClaimSentStmt,
+		markEmailClickedStmt:                     q.markEmailClickedStmt,
+		markEmailOpenedStmt:                      q.markEmailOpenedStmt,
+		markEmailResentStmt:                      q.markEmailResentStmt,
+		markSessionPaidStmt:                      q.markSessionPaidStmt,
+		markSessionPaidByCreditStmt:              q.markSessionPaidByCreditStmt,
+		markSessionPaidBySubscriptionStmt:        q.markSessionPaidBySubscriptionStmt,
+		markSessionPaymentFailedStmt:             q.markSessionPaymentFailedStmt,
+		markSessionRefundedStmt:                  q.markSessionRefundedStmt,
+		markStripeEventFailedStmt:                q.markStripeEventFailedStmt,
+		markStripeEventProcessedStmt:             q.markStripeEventProcessedStmt,
+		parkQuestionDisplayOrdersStmt:            q.parkQuestionDisplayOrdersStmt,
+		recordFeedbackStmt:                       q.recordFeedbackStmt,
+		recordJobAttemptStmt:                     q.recordJobAttemptStmt,
+		releaseEmailClaimStmt:                    q.releaseEmailClaimStmt,
+		releaseJobClaimStmt:                      q.releaseJobClaimStmt,
+		releaseReportStmt:                        q.releaseReportStmt,
+		releaseReportClaimStmt:                   q.releaseReportClaimStmt,
+		requeueReportStmt:                        q.requeueReportStmt,
+		requeueReportNarrativeStmt:               q.requeueReportNarrativeStmt,
+		resolveDuplicatePurchaseStmt:             q.resolveDuplicatePurchaseStmt,
+		revokeReportStmt:                         q.revokeReportStmt,
+		rotateReportAccessTokenStmt:              q.rotateReportAccessTokenStmt,
+		saveReportJobStateStmt:                   q.saveReportJobStateStmt,
+		setAIHedgeStmt:                           q.setAIHedgeStmt,
+		setEmailLogAddressStmt:                   q.setEmailLogAddressStmt,
+		setReportErrorStmt:                       q.setReportErrorStmt,
+		setReportProcessingStmt:                  q.setReportProcessingStmt,
+		setSessionEmailStmt:                      q.setSessionEmailStmt,
+		setStripeEventPayloadStmt:                q.setStripeEventPayloadStmt,
+		setSubscriptionEmailStmt:                 q.setSubscriptionEmailStmt,
+		summariseReportAttemptsStmt:              q.summariseReportAttemptsStmt,
+		summarizeShadowScoresStmt:                q.summarizeShadowScoresStmt,
+		suppressEmailStmt:                        q.suppressEmailStmt,
+		suppressSessionEmailStmt:                 q.suppressSessionEmailStmt,
+		tryLockSessionStmt:                       q.tryLockSessionStmt,
+		unlockSessionStmt:                        q.unlockSessionStmt,
+		updateQuestionSectionStmt:                q.updateQuestionSectionStmt,
+		updateSessionContextStmt:                 q.updateSessionContextStmt,
+		updateTierThemeStmt:                      q.updateTierThemeStmt,
+		upsertAICacheEntryStmt:                   q.upsertAICacheEntryStmt,
+		upsertAnswerStmt:                         q.upsertAnswerStmt,
+		upsertConsultationRequestStmt:            q.upsertConsultationRequestStmt,
+		upsertFeatureFlagStmt:                    q.upsertFeatureFlagStmt,
+		upsertPaymentStmt:                        q.upsertPaymentStmt,
+		upsertPlaybookSnippetStmt:                q.upsertPlaybookSnippetStmt,
+		upsertProductStmt:                        q.upsertProductStmt,
+		upsertQuestionDefinitionStmt:             q.upsertQuestionDefinitionStmt,
+		upsertRuntimeSettingStmt:                 q.upsertRuntimeSettingStmt,
+		upsertShadowScoreStmt:                    q.upsertShadowScoreStmt,
+		upsertStripeEventStmt:                    q.upsertStripeEventStmt,
+		upsertSubscriptionStmt:                   q.upsertSubscriptionStmt,
 	}
 }
@@ -0,0 +1,156 @@
+package db
+
+// This file is written by hand; sqlc leaves it alone.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ─── NARROW QUERIERS ──────────────────────────────────────────────────────────
+//
+// Querier has every query. A consumer that runs a handful takes one of the
+// interfaces below instead, so its test stubs implement only those methods
+// and the compiler rejects a query it was not meant to run. Each is a subset
+// of Querier, so *Queries and every Querier wrapper satisfy them all.
+//
+// The API server and the store still take the whole Querier: between them
+// they run nearly every query.
+
+// SessionReader reads a session by ID.
+type SessionReader interface {
+	GetSessionByID(ctx context.Context, id uuid.UUID) (Session, error)
+}
+
+// AnswerReader reads a session's answers.
+type AnswerReader interface {
+	GetAnswersBySession(ctx context.Context, sessionID uuid.UUID) ([]GetAnswersBySessionRow, error)
+}
+
+// ReportReader reads a report by ID.
+type ReportReader interface {
+	GetReportByID(ctx context.Context, id uuid.UUID) (Report, error)
+}
+
+// ReportWriter records what scoring a report produced along the way: its
+// retry checkpoint and its shadow-profile score.
+type ReportWriter interface {
+	SaveReportJobState(ctx context.Context, arg SaveReportJobStateParams) error
+	UpsertShadowScore(ctx context.Context, arg UpsertShadowScoreParams) error
+}
+
+// AIStore caches hedge results and keeps transcripts of the calls.
+type AIStore interface {
+	GetAICacheEntry(ctx context.Context, arg GetAICacheEntryParams) (AiCache, error)
+	UpsertAICacheEntry(ctx context.Context, arg UpsertAICacheEntryParams) error
+	InsertAITranscript(ctx context.Context, arg InsertAITranscriptParams) (AiTranscript, error)
+}
+
+// CatalogReader reads the products, playbook snippets and experiment
+// assignments a report is built from.
+type CatalogReader interface {
+	GetProductBySKU(ctx context.Context, sku string) (Product, error)
+	ListActivePlaybookSnippetsByIndustry(ctx context.Context, industry string) ([]PlaybookSnippet, error)
+	GetExperimentAssignments(ctx context.Context, sessionID uuid.UUID) ([]ExperimentAssignment, error)
+}
+
+// EmailLogStore writes the email log: a claim before a send and its outcome
+// after.
+type EmailLogStore interface {
+	LogEmail(ctx context.Context, arg LogEmailParams) (EmailLog, error)
+	LogEmailFailure(ctx context.Context, arg LogEmailFailureParams) (EmailLog, error)
+	ClaimEmail(ctx context.Context, arg ClaimEmailParams) (EmailLog, error)
+	MarkEmailClaimSent(ctx context.Context, arg MarkEmailClaimSentParams) (EmailLog, error)
+	ReleaseEmailClaim(ctx context.Context, arg ReleaseEmailClaimParams) (EmailLog, error)
+	GetEmailByDedupeKey(ctx context.Context, dedupeKey sql.NullString) (EmailLog, error)
+	LogSkippedEmail(ctx context.Context, arg LogSkippedEmailParams) (EmailLog, error)
+}
+
+// ReminderStore finds unopened report emails and marks them reminded.
+type ReminderStore interface {
+	EmailLogStore
+	ListUnopenedReportEmails(ctx context.Context, arg ListUnopenedReportEmailsParams) ([]ListUnopenedReportEmailsRow, error)
+	MarkEmailResent(ctx context.Context, id uuid.UUID) (int64, error)
+}
+
+// FeedbackRequestStore finds delivered reports to ask about and records the
+// request.
+type FeedbackRequestStore interface {
+	EmailLogStore
+	ListFeedbackCandidates(ctx context.Context, arg ListFeedbackCandidatesParams) ([]ListFeedbackCandidatesRow, error)
+	CreateFeedbackRequest(ctx context.Context, reportID uuid.UUID) (Feedback, error)
+}
+
+// ReportJobStore is everything the score_report job reads and writes outside
+// a store transaction.
+type ReportJobStore interface {
+	SessionReader
+	AnswerReader
+	ReportReader
+	ReportWriter
+	AIStore
+	CatalogReader
+	EmailLogStore
+}
+
+// JobQueue queues, claims and settles rows of the jobs table.
+type JobQueue interface {
+	InsertJob(ctx context.Context, arg InsertJobParams) (Job, error)
+	ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error)
+	ClaimPendingJobs(ctx context.Context, arg ClaimPendingJobsParams) ([]Job, error)
+	CompleteJob(ctx context.Context, id uuid.UUID) error
+	FailJob(ctx context.Context, id uuid.UUID) error
+	RecordJobAttempt(ctx context.Context, arg RecordJobAttemptParams) error
+	ReleaseJobClaim(ctx context.Context, arg ReleaseJobClaimParams) error
+}
+
+// JobPruner deletes finished jobs.
+type JobPruner interface {
+	DeleteFinishedJobs(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ReportQueue claims reports to score and records failed attempts.
+type ReportQueue interface {
+	ClaimPendingReports(ctx context.Context, arg ClaimPendingReportsParams) ([]Report, error)
+	ClaimReport(ctx context.Context, arg ClaimReportParams) (Report, error)
+	ReleaseReportClaim(ctx context.Context, arg ReleaseReportClaimParams) error
+	InsertReportAttempt(ctx context.Context, arg InsertReportAttemptParams) error
+}
+
+// StripeEventStore reads a stored Stripe event and records its outcome.
+type StripeEventStore interface {
+	GetStripeEvent(ctx context.Context, stripeEventID string) (StripeEvent, error)
+	MarkStripeEventProcessed(ctx context.Context, stripeEventID string) (StripeEvent, error)
+	MarkStripeEventFailed(ctx context.Context, arg MarkStripeEventFailedParams) (StripeEvent, error)
+}
+
+// RunnerStore is everything the worker's Runner queries.
+type RunnerStore interface {
+	JobQueue
+	ReportQueue
+	StripeEventStore
+}
+
+// SettingsReader reads the runtime_settings table.
+type SettingsReader interface {
+	ListRuntimeSettings(ctx context.Context) ([]RuntimeSetting, error)
+}
+
+// FeatureFlagReader reads the feature_flags table.
+type FeatureFlagReader interface {
+	ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error)
+}
+
+// Every narrow querier must stay a subset of Querier.
+var (
+	_ ReportJobStore       = Querier(nil)
+	_ ReminderStore        = Querier(nil)
+	_ FeedbackRequestStore = Querier(nil)
+	_ RunnerStore          = Querier(nil)
+	_ JobPruner            = Querier(nil)
+	_ SettingsReader       = Querier(nil)
+	_ FeatureFlagReader    = Querier(nil)
+)
@@ -146,6 +146,48 @@ func (ns NullReportStatus) Value() (driver.Value, error) {
 	return string(ns.ReportStatus), nil
 }
 
+type ReportType string
+
+const (
+	ReportTypeStandard ReportType = "standard"
+	ReportTypePremium  ReportType = "premium"
+)
+
+func (e *ReportType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReportType(s)
+	case string:
+		*e = ReportType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReportType: %T", src)
+	}
+	return nil
+}
+
+type NullReportType struct {
+	ReportType ReportType `json:"report_type"`
+	Valid      bool       `json:"valid"` // Valid is true if ReportType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReportType) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReportType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReportType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReportType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReportType), nil
+}
+
 type RiskTier string
 
 const (
@@ -236,6 +278,39 @@ func (ns NullSectionID) Value() (driver.Value, error) {
 	return string(ns.SectionID), nil
 }
 
+type AiCache struct {
+	Fingerprint      string                `db:"fingerprint" json:"fingerprint"`
+	Hedges           json.RawMessage       `db:"hedges" json:"hedges"`
+	ExecutiveSummary string                `db:"executive_summary" json:"executive_summary"`
+	TopPriorityHtml  string                `db:"top_priority_html" json:"top_priority_html"`
+	HitCount         int32                 `db:"hit_count" json:"hit_count"`
+	CreatedAt        time.Time             `db:"created_at" json:"created_at"`
+	LastHitAt        sql.NullTime          `db:"last_hit_at" json:"last_hit_at"`
+	Analysis         pqtype.NullRawMessage `db:"analysis" json:"analysis"`
+	Relationships    pqtype.NullRawMessage `db:"relationships" json:"relationships"`
+}
+
+type AiEdit struct {
+	ID         uuid.UUID      `db:"id" json:"id"`
+	ReportID   uuid.UUID      `db:"report_id" json:"report_id"`
+	Field      string         `db:"field" json:"field"`
+	QuestionID sql.NullString `db:"question_id" json:"question_id"`
+	Original   sql.NullString `db:"original" json:"original"`
+	Edited     string         `db:"edited" json:"edited"`
+	Editor     string         `db:"editor" json:"editor"`
+	Reason     string         `db:"reason" json:"reason"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+}
+
+type AiTranscript struct {
+	ID         uuid.UUID             `db:"id" json:"id"`
+	ReportID   uuid.UUID             `db:"report_id" json:"report_id"`
+	Exchanges  int32                 `db:"exchanges" json:"exchanges"`
+	StorageKey sql.NullString        `db:"storage_key" json:"storage_key"`
+	Body       pqtype.NullRawMessage `db:"body" json:"body"`
+	CreatedAt  time.Time             `db:"created_at" json:"created_at"`
+}
+
 type Answer struct {
 	ID         uuid.UUID     `db:"id" json:"id"`
 	SessionID  uuid.UUID     `db:"session_id" json:"session_id"`
@@ -245,20 +320,128 @@ type Answer struct {
 	ClientI    sql.NullInt16 `db:"client_i" json:"client_i"`
 	AnsweredAt time.Time     `db:"answered_at" json:"answered_at"`
 	UpdatedAt  time.Time     `db:"updated_at" json:"updated_at"`
+	Skipped    bool          `db:"skipped" json:"skipped"`
+}
+
+type ConsultationRequest struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	ReportID  uuid.UUID      `db:"report_id" json:"report_id"`
+	SessionID uuid.UUID      `db:"session_id" json:"session_id"`
+	Note      sql.NullString `db:"note" json:"note"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+type DuplicatePurchase struct {
+	SessionID         uuid.UUID      `db:"session_id" json:"session_id"`
+	OriginalSessionID uuid.UUID      `db:"original_session_id" json:"original_session_id"`
+	ChangedAnswers    int32          `db:"changed_answers" json:"changed_answers"`
+	Resolution        sql.NullString `db:"resolution" json:"resolution"`
+	StripeRefundID    sql.NullString `db:"stripe_refund_id" json:"stripe_refund_id"`
+	ResolvedAt        sql.NullTime   `db:"resolved_at" json:"resolved_at"`
+	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
 }
 
 type EmailLog struct {
-	ID         uuid.UUID      `db:"id" json:"id"`
-	SessionID  uuid.NullUUID  `db:"session_id" json:"session_id"`
-	ReportID   uuid.NullUUID  `db:"report_id" json:"report_id"`
-	ToAddress  string         `db:"to_address" json:"to_address"`
-	Subject    string         `db:"subject" json:"subject"`
-	Template   string         `db:"template" json:"template"`
-	ProviderID sql.NullString `db:"provider_id" json:"provider_id"`
-	SentAt     sql.NullTime   `db:"sent_at" json:"sent_at"`
-	OpenedAt   sql.NullTime   `db:"opened_at" json:"opened_at"`
-	Error      sql.NullString `db:"error" json:"error"`
-	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	ID          uuid.UUID      `db:"id" json:"id"`
+	SessionID   uuid.NullUUID  `db:"session_id" json:"session_id"`
+	ReportID    uuid.NullUUID  `db:"report_id" json:"report_id"`
+	ToAddress   string         `db:"to_address" json:"to_address"`
+	Subject     string         `db:"subject" json:"subject"`
+	Template    string         `db:"template" json:"template"`
+	ProviderID  sql.NullString `db:"provider_id" json:"provider_id"`
+	SentAt      sql.NullTime   `db:"sent_at" json:"sent_at"`
+	OpenedAt    sql.NullTime   `db:"opened_at" json:"opened_at"`
+	Error       sql.NullString `db:"error" json:"error"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	ClickedAt   sql.NullTime   `db:"clicked_at" json:"clicked_at"`
+	BouncedAt   sql.NullTime   `db:"bounced_at" json:"bounced_at"`
+	ResentAt    sql.NullTime   `db:"resent_at" json:"resent_at"`
+	DedupeKey   sql.NullString `db:"dedupe_key" json:"dedupe_key"`
+	DuplicateOf uuid.NullUUID  `db:"duplicate_of" json:"duplicate_of"`
+}
+
+type EmailSuppression struct {
+	EmailHash string    `db:"email_hash" json:"email_hash"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type ExperimentAssignment struct {
+	SessionID  uuid.UUID `db:"session_id" json:"session_id"`
+	Experiment string    `db:"experiment" json:"experiment"`
+	Variant    string    `db:"variant" json:"variant"`
+	AssignedAt time.Time `db:"assigned_at" json:"assigned_at"`
+}
+
+type FeatureFlag struct {
+	Name        string    `db:"name" json:"name"`
+	Environment string    `db:"environment" json:"environment"`
+	Percent     int16     `db:"percent" json:"percent"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type Feedback struct {
+	ReportID     uuid.UUID      `db:"report_id" json:"report_id"`
+	Token        string         `db:"token" json:"token"`
+	RequestedAt  time.Time      `db:"requested_at" json:"requested_at"`
+	Rating       sql.NullInt16  `db:"rating" json:"rating"`
+	Testimonial  sql.NullString `db:"testimonial" json:"testimonial"`
+	ConsentQuote bool           `db:"consent_quote" json:"consent_quote"`
+	ConsentName  bool           `db:"consent_name" json:"consent_name"`
+	RespondedAt  sql.NullTime   `db:"responded_at" json:"responded_at"`
+	ApprovedAt   sql.NullTime   `db:"approved_at" json:"approved_at"`
+}
+
+type Job struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	Type           string          `db:"type" json:"type"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	DedupeKey      sql.NullString  `db:"dedupe_key" json:"dedupe_key"`
+	RunAt          time.Time       `db:"run_at" json:"run_at"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int32           `db:"attempts" json:"attempts"`
+	Error          sql.NullString  `db:"error" json:"error"`
+	ClaimedBy      sql.NullString  `db:"claimed_by" json:"claimed_by"`
+	ClaimExpiresAt sql.NullTime    `db:"claim_expires_at" json:"claim_expires_at"`
+	FinishedAt     sql.NullTime    `db:"finished_at" json:"finished_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type Payment struct {
+	ID                         uuid.UUID      `db:"id" json:"id"`
+	StripeChargeID             string         `db:"stripe_charge_id" json:"stripe_charge_id"`
+	StripePaymentIntent        sql.NullString `db:"stripe_payment_intent" json:"stripe_payment_intent"`
+	StripeBalanceTransactionID string         `db:"stripe_balance_transaction_id" json:"stripe_balance_transaction_id"`
+	SessionID                  uuid.NullUUID  `db:"session_id" json:"session_id"`
+	AmountCents                int64          `db:"amount_cents" json:"amount_cents"`
+	FeeCents                   int64          `db:"fee_cents" json:"fee_cents"`
+	NetCents                   int64          `db:"net_cents" json:"net_cents"`
+	Currency                   string         `db:"currency" json:"currency"`
+	CreatedAt                  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt                  time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type PlaybookSnippet struct {
+	Slug      string    `db:"slug" json:"slug"`
+	Industry  string    `db:"industry" json:"industry"`
+	Title     string    `db:"title" json:"title"`
+	Body      string    `db:"body" json:"body"`
+	Keywords  []string  `db:"keywords" json:"keywords"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type Product struct {
+	Sku        string     `db:"sku" json:"sku"`
+	Name       string     `db:"name" json:"name"`
+	PriceCents int32      `db:"price_cents" json:"price_cents"`
+	Currency   string     `db:"currency" json:"currency"`
+	ReportType ReportType `db:"report_type" json:"report_type"`
+	Active     bool       `db:"active" json:"active"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 type PublicRiskStat struct {
@@ -291,36 +474,80 @@ type QuestionDefinition struct {
 	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
 }
 
+type QuestionSection struct {
+	ID           SectionID `db:"id" json:"id"`
+	Title        string    `db:"title" json:"title"`
+	Description  string    `db:"description" json:"description"`
+	DisplayOrder int16     `db:"display_order" json:"display_order"`
+	Gated        bool      `db:"gated" json:"gated"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
 type Report struct {
-	ID               uuid.UUID             `db:"id" json:"id"`
-	SessionID        uuid.UUID             `db:"session_id" json:"session_id"`
-	Status           ReportStatus          `db:"status" json:"status"`
-	ErrorMessage     sql.NullString        `db:"error_message" json:"error_message"`
-	OverallScore     sql.NullInt16         `db:"overall_score" json:"overall_score"`
-	CriticalCount    sql.NullInt16         `db:"critical_count" json:"critical_count"`
-	RisksJson        pqtype.NullRawMessage `db:"risks_json" json:"risks_json"`
-	ExecutiveSummary sql.NullString        `db:"executive_summary" json:"executive_summary"`
-	TopPriorityHtml  sql.NullString        `db:"top_priority_html" json:"top_priority_html"`
-	AccessToken      string                `db:"access_token" json:"access_token"`
-	GeneratedAt      sql.NullTime          `db:"generated_at" json:"generated_at"`
-	CreatedAt        time.Time             `db:"created_at" json:"created_at"`
-	UpdatedAt        time.Time             `db:"updated_at" json:"updated_at"`
+	ID                       uuid.UUID             `db:"id" json:"id"`
+	SessionID                uuid.UUID             `db:"session_id" json:"session_id"`
+	Status                   ReportStatus          `db:"status" json:"status"`
+	ErrorMessage             sql.NullString        `db:"error_message" json:"error_message"`
+	OverallScore             sql.NullInt16         `db:"overall_score" json:"overall_score"`
+	CriticalCount            sql.NullInt16         `db:"critical_count" json:"critical_count"`
+	RisksJson                pqtype.NullRawMessage `db:"risks_json" json:"risks_json"`
+	ExecutiveSummary         sql.NullString        `db:"executive_summary" json:"executive_summary"`
+	TopPriorityHtml          sql.NullString        `db:"top_priority_html" json:"top_priority_html"`
+	AccessToken              string                `db:"access_token" json:"access_token"`
+	GeneratedAt              sql.NullTime          `db:"generated_at" json:"generated_at"`
+	CreatedAt                time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt                time.Time             `db:"updated_at" json:"updated_at"`
+	ClaimedBy                sql.NullString        `db:"claimed_by" json:"claimed_by"`
+	ClaimExpiresAt           sql.NullTime          `db:"claim_expires_at" json:"claim_expires_at"`
+	RevokedAt                sql.NullTime          `db:"revoked_at" json:"revoked_at"`
+	RevokedReason            sql.NullString        `db:"revoked_reason" json:"revoked_reason"`
+	HeldAt                   sql.NullTime          `db:"held_at" json:"held_at"`
+	AiAnalysis               pqtype.NullRawMessage `db:"ai_analysis" json:"ai_analysis"`
+	AiNarrative              pqtype.NullRawMessage `db:"ai_narrative" json:"ai_narrative"`
+	Relationships            pqtype.NullRawMessage `db:"relationships" json:"relationships"`
+	AiQualityIssues          sql.NullString        `db:"ai_quality_issues" json:"ai_quality_issues"`
+	AiBudgetNote             sql.NullString        `db:"ai_budget_note" json:"ai_budget_note"`
+	NotBefore                sql.NullTime          `db:"not_before" json:"not_before"`
+	Flags                    pqtype.NullRawMessage `db:"flags" json:"flags"`
+	ExecutiveSummaryEditedAt sql.NullTime          `db:"executive_summary_edited_at" json:"executive_summary_edited_at"`
+	SkippedQuestions         []string              `db:"skipped_questions" json:"skipped_questions"`
+	JobState                 pqtype.NullRawMessage `db:"job_state" json:"job_state"`
+}
+
+type ReportAttempt struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	ReportID      uuid.UUID      `db:"report_id" json:"report_id"`
+	Attempt       int32          `db:"attempt" json:"attempt"`
+	Error         string         `db:"error" json:"error"`
+	Provider      sql.NullString `db:"provider" json:"provider"`
+	ProviderError sql.NullString `db:"provider_error" json:"provider_error"`
+	DurationMs    int32          `db:"duration_ms" json:"duration_ms"`
+	WorkerID      string         `db:"worker_id" json:"worker_id"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
 }
 
 type RiskResult struct {
-	ID          uuid.UUID      `db:"id" json:"id"`
-	ReportID    uuid.UUID      `db:"report_id" json:"report_id"`
-	QuestionID  string         `db:"question_id" json:"question_id"`
-	Rank        int16          `db:"rank" json:"rank"`
-	RiskName    string         `db:"risk_name" json:"risk_name"`
-	RiskDesc    string         `db:"risk_desc" json:"risk_desc"`
-	Probability int16          `db:"probability" json:"probability"`
-	Impact      int16          `db:"impact" json:"impact"`
-	Score       int16          `db:"score" json:"score"`
-	Tier        RiskTier       `db:"tier" json:"tier"`
-	Hedge       string         `db:"hedge" json:"hedge"`
-	AiHedge     sql.NullString `db:"ai_hedge" json:"ai_hedge"`
-	Section     string         `db:"section" json:"section"`
+	ID              uuid.UUID             `db:"id" json:"id"`
+	ReportID        uuid.UUID             `db:"report_id" json:"report_id"`
+	QuestionID      string                `db:"question_id" json:"question_id"`
+	Rank            int16                 `db:"rank" json:"rank"`
+	RiskName        string                `db:"risk_name" json:"risk_name"`
+	RiskDesc        string                `db:"risk_desc" json:"risk_desc"`
+	Probability     int16                 `db:"probability" json:"probability"`
+	Impact          int16                 `db:"impact" json:"impact"`
+	Score           int16                 `db:"score" json:"score"`
+	Tier            RiskTier              `db:"tier" json:"tier"`
+	Hedge           string                `db:"hedge" json:"hedge"`
+	AiHedge         sql.NullString        `db:"ai_hedge" json:"ai_hedge"`
+	Section         string                `db:"section" json:"section"`
+	AiHedgeEditedAt sql.NullTime          `db:"ai_hedge_edited_at" json:"ai_hedge_edited_at"`
+	Explanation     pqtype.NullRawMessage `db:"explanation" json:"explanation"`
+}
+
+type RuntimeSetting struct {
+	Key       string    `db:"key" json:"key"`
+	Value     string    `db:"value" json:"value"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
 type Session struct {
@@ -342,6 +569,34 @@ type Session struct {
 	UserAgent           sql.NullString `db:"user_agent" json:"user_agent"`
 	CreatedAt           time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt           time.Time      `db:"updated_at" json:"updated_at"`
+	ProductSku          sql.NullString `db:"product_sku" json:"product_sku"`
+	SubscriptionID      uuid.NullUUID  `db:"subscription_id" json:"subscription_id"`
+	BillingCountry      sql.NullString `db:"billing_country" json:"billing_country"`
+	BillingPostalCode   sql.NullString `db:"billing_postal_code" json:"billing_postal_code"`
+	SubtotalCents       sql.NullInt32  `db:"subtotal_cents" json:"subtotal_cents"`
+	TaxCents            sql.NullInt32  `db:"tax_cents" json:"tax_cents"`
+	TaxCalculationID    sql.NullString `db:"tax_calculation_id" json:"tax_calculation_id"`
+	BillingName         sql.NullString `db:"billing_name" json:"billing_name"`
+	BillingAddressLine1 sql.NullString `db:"billing_address_line1" json:"billing_address_line1"`
+	BillingAddressLine2 sql.NullString `db:"billing_address_line2" json:"billing_address_line2"`
+	BillingCity         sql.NullString `db:"billing_city" json:"billing_city"`
+	BillingTaxID        sql.NullString `db:"billing_tax_id" json:"billing_tax_id"`
+	InvoiceNumber       sql.NullInt64  `db:"invoice_number" json:"invoice_number"`
+	EmailHash           sql.NullString `db:"email_hash" json:"email_hash"`
+	CreditFromSessionID uuid.NullUUID  `db:"credit_from_session_id" json:"credit_from_session_id"`
+	Partner             sql.NullString `db:"partner" json:"partner"`
+	Cohort              sql.NullString `db:"cohort" json:"cohort"`
+}
+
+type ShadowScore struct {
+	ReportID          uuid.UUID `db:"report_id" json:"report_id"`
+	Profile           string    `db:"profile" json:"profile"`
+	ProductionProfile string    `db:"production_profile" json:"production_profile"`
+	ProductionScore   int16     `db:"production_score" json:"production_score"`
+	ShadowScore       int16     `db:"shadow_score" json:"shadow_score"`
+	ProductionBand    string    `db:"production_band" json:"production_band"`
+	ShadowBand        string    `db:"shadow_band" json:"shadow_band"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
 }
 
 type StripeEvent struct {
@@ -353,3 +608,34 @@ type StripeEvent struct {
 	Error         sql.NullString  `db:"error" json:"error"`
 	ReceivedAt    time.Time       `db:"received_at" json:"received_at"`
 }
+
+type Subscription struct {
+	ID                   uuid.UUID      `db:"id" json:"id"`
+	StripeSubscriptionID string         `db:"stripe_subscription_id" json:"stripe_subscription_id"`
+	StripeCustomerID     string         `db:"stripe_customer_id" json:"stripe_customer_id"`
+	Email                sql.NullString `db:"email" json:"email"`
+	Status               string         `db:"status" json:"status"`
+	CurrentPeriodStart   sql.NullTime   `db:"current_period_start" json:"current_period_start"`
+	CurrentPeriodEnd     sql.NullTime   `db:"current_period_end" json:"current_period_end"`
+	CreatedAt            time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time      `db:"updated_at" json:"updated_at"`
+	EmailHash            sql.NullString `db:"email_hash" json:"email_hash"`
+}
+
+type SupportNote struct {
+	ID        uuid.UUID     `db:"id" json:"id"`
+	SessionID uuid.UUID     `db:"session_id" json:"session_id"`
+	ReportID  uuid.NullUUID `db:"report_id" json:"report_id"`
+	Author    string        `db:"author" json:"author"`
+	Body      string        `db:"body" json:"body"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+}
+
+type TierTheme struct {
+	Tier        RiskTier  `db:"tier" json:"tier"`
+	Label       string    `db:"label" json:"label"`
+	Color       string    `db:"color" json:"color"`
+	Description string    `db:"description" json:"description"`
+	Cadence     string    `db:"cadence" json:"cadence"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
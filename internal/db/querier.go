@@ -7,17 +7,88 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
+	// sql.ErrNoRows when the report has no testimonial its author agreed to have
+	// quoted.
+	ApproveTestimonial(ctx context.Context, reportID uuid.UUID) (Feedback, error)
+	// ---------------------------------------------------------------------------
+	// EXPERIMENTS
+	// ---------------------------------------------------------------------------
+	// A session keeps its first assignment.
+	AssignExperiment(ctx context.Context, arg AssignExperimentParams) error
+	// Returns the session's invoice number, drawing the next one from the
+	// sequence on first use so re-downloads print the same number.
+	AssignInvoiceNumber(ctx context.Context, id uuid.UUID) (int64, error)
 	AttachStripeCustomer(ctx context.Context, arg AttachStripeCustomerParams) (Session, error)
+	// Claims dedupe_key before an email is sent. No row when the key is already
+	// claimed: the email was sent, or is being sent elsewhere. A claim that was
+	// never finished (the process died mid-send) is taken over once it was made
+	// before stale_before.
+	ClaimEmail(ctx context.Context, arg ClaimEmailParams) (EmailLog, error)
+	// Claims one pending job for claimed_by. Returns no rows when the job is
+	// finished, not due yet, or another worker holds an unexpired claim on it.
+	ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error)
+	// The poller's version of ClaimJob: claims up to max_jobs unclaimed due jobs
+	// of the given types, longest due first. SKIP LOCKED lets concurrent pollers
+	// take disjoint batches.
+	ClaimPendingJobs(ctx context.Context, arg ClaimPendingJobsParams) ([]Job, error)
+	// The poller's version of ListPendingReports for several replicas: marks up to
+	// max_reports unclaimed pending reports as owned by claimed_by until the lease
+	// expires and returns them. SKIP LOCKED lets concurrent pollers take disjoint
+	// batches instead of queueing behind each other for the same rows.
+	ClaimPendingReports(ctx context.Context, arg ClaimPendingReportsParams) ([]Report, error)
+	// Claims one pending report for claimed_by. Returns no rows when the report is
+	// finished, revoked or held, or another worker holds an unexpired claim on it.
+	ClaimReport(ctx context.Context, arg ClaimReportParams) (Report, error)
+	CompleteJob(ctx context.Context, id uuid.UUID) error
 	CountAnsweredBySession(ctx context.Context, sessionID uuid.UUID) (int64, error)
+	// AI output not created or reused since cutoff.
+	CountExpiredAICache(ctx context.Context, cutoff time.Time) (int64, error)
+	CountExpiredAITranscripts(ctx context.Context, cutoff time.Time) (int64, error)
+	// ---------------------------------------------------------------------------
+	// RETENTION
+	//   Each data class has a Count query for dry runs and a Delete query that
+	//   removes at most batch_size rows, so the enforcer can delete in short
+	//   transactions until a batch comes back short.
+	// ---------------------------------------------------------------------------
+	// Raw answers of sessions whose session and report are both untouched since
+	// cutoff, so a report being generated or requeued keeps its answers. Reports
+	// keep their scored risk_results, but can no longer be regenerated.
+	CountExpiredAnswers(ctx context.Context, cutoff time.Time) (int64, error)
+	CountExpiredEmailLog(ctx context.Context, cutoff time.Time) (int64, error)
+	// Processed Stripe events received before cutoff. Unprocessed events are kept
+	// for investigation whatever their age.
+	CountExpiredStripeEvents(ctx context.Context, cutoff time.Time) (int64, error)
+	// Sessions whose payment failed for this email, as a card-testing signal.
+	// The store's codec replaces email with its blind index.
+	CountFailedPaymentsByEmailSince(ctx context.Context, arg CountFailedPaymentsByEmailSinceParams) (int64, error)
+	// Pending reports paid for before this one, by the same filter as
+	// ListPendingReports: its position in the queue for GET /api/report.
+	CountReportsAhead(ctx context.Context, id uuid.UUID) (int64, error)
+	// How many of the reports ListResearchReports returns for the same range put
+	// each question in each tier.
+	CountResearchRiskTiers(ctx context.Context, arg CountResearchRiskTiersParams) ([]CountResearchRiskTiersRow, error)
+	// Velocity check at checkout: sessions started from the same (hashed) IP.
+	CountSessionsByIPHashSince(ctx context.Context, arg CountSessionsByIPHashSinceParams) (int64, error)
+	// A session from an admin bulk import: created paid, with its email and
+	// context, for the cohort. Its answers and report are written after it.
+	CreateCohortSession(ctx context.Context, arg CreateCohortSessionParams) (Session, error)
+	CreateDuplicatePurchase(ctx context.Context, arg CreateDuplicatePurchaseParams) (DuplicatePurchase, error)
+	// Claims a report for a feedback request; sql.ErrNoRows when another replica
+	// already has.
+	CreateFeedbackRequest(ctx context.Context, reportID uuid.UUID) (Feedback, error)
 	// ---------------------------------------------------------------------------
 	// REPORTS
 	// ---------------------------------------------------------------------------
 	CreateReport(ctx context.Context, sessionID uuid.UUID) (Report, error)
+	// A draft report the poller leaves alone until not_before; see
+	// GetCohortScheduleEnd.
+	CreateScheduledReport(ctx context.Context, arg CreateScheduledReportParams) (Report, error)
 	// =============================================================================
 	// sqlc QUERIES — Asymmetric Risk Mapper
 	// Run: sqlc generate  (sqlc.yaml points here)
@@ -26,18 +97,88 @@ type Querier interface {
 	// SESSIONS
 	// ---------------------------------------------------------------------------
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	DeleteExpiredAICache(ctx context.Context, arg DeleteExpiredAICacheParams) (int64, error)
+	// Returns the object storage keys of the deleted rows, for removal from the
+	// bucket.
+	DeleteExpiredAITranscripts(ctx context.Context, arg DeleteExpiredAITranscriptsParams) ([]sql.NullString, error)
+	DeleteExpiredAnswers(ctx context.Context, arg DeleteExpiredAnswersParams) (int64, error)
+	DeleteExpiredEmailLog(ctx context.Context, arg DeleteExpiredEmailLogParams) (int64, error)
+	DeleteExpiredStripeEvents(ctx context.Context, arg DeleteExpiredStripeEventsParams) (int64, error)
+	DeleteFeatureFlag(ctx context.Context, arg DeleteFeatureFlagParams) (int64, error)
+	DeleteFinishedJobs(ctx context.Context, cutoff time.Time) (int64, error)
+	DeletePlaybookSnippet(ctx context.Context, slug string) (int64, error)
+	DeleteRiskResultsByReport(ctx context.Context, reportID uuid.UUID) (int64, error)
+	DeleteRuntimeSetting(ctx context.Context, key string) (int64, error)
+	// ---------------------------------------------------------------------------
+	// AI EDITS
+	// ---------------------------------------------------------------------------
+	EditAIHedge(ctx context.Context, arg EditAIHedgeParams) (RiskResult, error)
+	EditExecutiveSummary(ctx context.Context, arg EditExecutiveSummaryParams) (Report, error)
+	// Gives up on a job after its last attempt; RecordJobAttempt has its error.
+	FailJob(ctx context.Context, id uuid.UUID) error
 	FinalizeReport(ctx context.Context, arg FinalizeReportParams) (Report, error)
 	// ---------------------------------------------------------------------------
+	// AI CACHE
+	// ---------------------------------------------------------------------------
+	// Returns the entry only if it was generated after not_before, and records
+	// the hit so cache effectiveness can be measured.
+	GetAICacheEntry(ctx context.Context, arg GetAICacheEntryParams) (AiCache, error)
+	// ---------------------------------------------------------------------------
 	// QUESTION DEFINITIONS
 	// ---------------------------------------------------------------------------
 	GetAllQuestionDefinitions(ctx context.Context) ([]QuestionDefinition, error)
 	GetAnswersBySession(ctx context.Context, sessionID uuid.UUID) ([]GetAnswersBySessionRow, error)
+	// The latest not_before of the imported reports still waiting, or now when
+	// none are, so a new import is scheduled after the ones before it.
+	GetCohortScheduleEnd(ctx context.Context) (time.Time, error)
+	// Imported cohorts never went through the funnel and are left out.
 	GetCompletionFunnelStats(ctx context.Context) (GetCompletionFunnelStatsRow, error)
+	// Conversion from delivered report to consultation request, overall and for
+	// the last 30 days.
+	GetConsultationStats(ctx context.Context) (GetConsultationStatsRow, error)
+	// Revenue excluding tax. Sessions checked out before subtotals were recorded
+	// count at the product's current catalog price, or the standard price if they
+	// predate the catalog too. Reports covered by a subscription are excluded; the
+	// subscription's invoices are in Stripe. So are imported cohorts, which are
+	// billed under their B2B deal.
 	GetDailyRevenue(ctx context.Context) ([]GetDailyRevenueRow, error)
+	// The oldest duplicate purchase kept as credit for this email and product
+	// that has not paid for a session yet. The store's codec replaces email with
+	// its blind index.
+	GetDuplicateCredit(ctx context.Context, arg GetDuplicateCreditParams) (uuid.UUID, error)
+	GetDuplicatePurchase(ctx context.Context, sessionID uuid.UUID) (GetDuplicatePurchaseRow, error)
+	// ---------------------------------------------------------------------------
+	// DUPLICATE PURCHASES
+	// ---------------------------------------------------------------------------
+	// The other card-paid session for email_hash paid since `since` whose answers
+	// are closest to session id's, with how many questions were answered
+	// differently or only in one of them. Sessions that are themselves
+	// duplicates are not candidates.
+	GetEarlierCardPurchase(ctx context.Context, arg GetEarlierCardPurchaseParams) (GetEarlierCardPurchaseRow, error)
+	GetEmailByDedupeKey(ctx context.Context, dedupeKey sql.NullString) (EmailLog, error)
+	// Returns a live subscription for the email — matched directly or through the
+	// Stripe customer on an earlier session — that has not yet covered a report
+	// in its current billing period. The store's codec replaces email with its
+	// blind index.
+	GetEntitledSubscription(ctx context.Context, email string) (Subscription, error)
+	GetExperimentAssignments(ctx context.Context, sessionID uuid.UUID) ([]ExperimentAssignment, error)
+	// Conversion and report quality per variant of every experiment, stopped ones
+	// included. A report counts as rejected when the AI quality gate threw its
+	// narrative out.
+	GetExperimentStats(ctx context.Context) ([]GetExperimentStatsRow, error)
+	GetFeedbackByToken(ctx context.Context, token string) (GetFeedbackByTokenRow, error)
+	// Everything printed on the invoice for a report. product_name and currency
+	// come from the catalog; sessions that predate it are the standard product.
+	GetInvoiceByAccessToken(ctx context.Context, accessToken string) (GetInvoiceByAccessTokenRow, error)
+	// Gross, Stripe fees and net per settlement currency, overall and for the
+	// last 30 days.
+	GetPaymentMarginStats(ctx context.Context) ([]GetPaymentMarginStatsRow, error)
+	GetProductBySKU(ctx context.Context, sku string) (Product, error)
 	GetQuestionByID(ctx context.Context, id string) (QuestionDefinition, error)
 	GetReportByAccessToken(ctx context.Context, accessToken string) (GetReportByAccessTokenRow, error)
 	GetReportByID(ctx context.Context, id uuid.UUID) (Report, error)
 	GetReportBySessionID(ctx context.Context, sessionID uuid.UUID) (Report, error)
+	GetRiskResultByQuestion(ctx context.Context, arg GetRiskResultByQuestionParams) (RiskResult, error)
 	GetRiskResultsByReport(ctx context.Context, reportID uuid.UUID) ([]RiskResult, error)
 	// ---------------------------------------------------------------------------
 	// ANALYTICS
@@ -47,35 +188,248 @@ type Querier interface {
 	GetSessionByAnonToken(ctx context.Context, anonToken string) (Session, error)
 	GetSessionByID(ctx context.Context, id uuid.UUID) (Session, error)
 	GetSessionByStripePI(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error)
+	GetStripeEvent(ctx context.Context, stripeEventID string) (StripeEvent, error)
 	GetUnprocessedStripeEvents(ctx context.Context) ([]StripeEvent, error)
 	GetWatchAndRedRisks(ctx context.Context, reportID uuid.UUID) ([]RiskResult, error)
+	HoldReport(ctx context.Context, id uuid.UUID) (Report, error)
+	InsertAIEdit(ctx context.Context, arg InsertAIEditParams) (AiEdit, error)
+	// ---------------------------------------------------------------------------
+	// AI TRANSCRIPTS
+	// ---------------------------------------------------------------------------
+	InsertAITranscript(ctx context.Context, arg InsertAITranscriptParams) (AiTranscript, error)
+	// ---------------------------------------------------------------------------
+	// JOBS
+	//   Claims work as the report claims do: a claim lasts lease_seconds, and an
+	//   expired claim, or claimed_by's own, can be taken again.
+	// ---------------------------------------------------------------------------
+	// Queues a job to run from run_at. Returns no rows when a job with the same
+	// dedupe_key was already queued.
+	InsertJob(ctx context.Context, arg InsertJobParams) (Job, error)
+	// ---------------------------------------------------------------------------
+	// REPORT ATTEMPTS
+	// ---------------------------------------------------------------------------
+	InsertReportAttempt(ctx context.Context, arg InsertReportAttemptParams) error
 	// ---------------------------------------------------------------------------
 	// RISK RESULTS
 	// ---------------------------------------------------------------------------
 	InsertRiskResult(ctx context.Context, arg InsertRiskResultParams) (RiskResult, error)
-	// Used by the background worker to pick up unprocessed reports.
+	// ---------------------------------------------------------------------------
+	// SUPPORT NOTES
+	// ---------------------------------------------------------------------------
+	InsertSupportNote(ctx context.Context, arg InsertSupportNoteParams) (SupportNote, error)
+	ListAIEditsByReport(ctx context.Context, reportID uuid.UUID) ([]AiEdit, error)
+	ListAITranscriptsByReport(ctx context.Context, reportID uuid.UUID) ([]AiTranscript, error)
+	ListActivePlaybookSnippetsByIndustry(ctx context.Context, industry string) ([]PlaybookSnippet, error)
+	// ---------------------------------------------------------------------------
+	// PRODUCTS
+	// ---------------------------------------------------------------------------
+	ListActiveProducts(ctx context.Context) ([]Product, error)
+	// Ready, unrevoked reports of paid sessions for this email, newest first, for
+	// POST /api/report/resend. The store's codec replaces email with its blind
+	// index.
+	ListDeliverableReportsByEmail(ctx context.Context, arg ListDeliverableReportsByEmailParams) ([]ListDeliverableReportsByEmailRow, error)
+	ListEmailLogAddresses(ctx context.Context, arg ListEmailLogAddressesParams) ([]ListEmailLogAddressesRow, error)
+	ListEmailLogBySession(ctx context.Context, sessionID uuid.NullUUID) ([]EmailLog, error)
+	// Logged emails newest first, for the admin listing, ordered and continued
+	// like ListSessionsPage. An empty template matches every template.
+	ListEmailLogPage(ctx context.Context, arg ListEmailLogPageParams) ([]EmailLog, error)
+	// ---------------------------------------------------------------------------
+	// FEATURE FLAGS
+	// ---------------------------------------------------------------------------
+	ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error)
+	// ---------------------------------------------------------------------------
+	// FEEDBACK
+	// ---------------------------------------------------------------------------
+	// Live reports whose report-ready email went out in [sent_after, sent_before)
+	// and never bounced, that have not been asked for feedback and whose address
+	// is not suppressed. Oldest first. Feeds the worker's feedback requester;
+	// with report_id, only that report.
+	ListFeedbackCandidates(ctx context.Context, arg ListFeedbackCandidatesParams) ([]ListFeedbackCandidatesRow, error)
+	ListPaymentsByStripePIs(ctx context.Context, paymentIntents []string) ([]Payment, error)
+	// Used by the background worker to pick up unprocessed reports. The window is
+	// on updated_at so a report requeued by an operator is picked up again however
+	// old it is, and on not_before so a cohort scheduled far ahead is not dropped.
+	// Imported reports wait for their not_before.
 	ListPendingReports(ctx context.Context) ([]Report, error)
 	// ---------------------------------------------------------------------------
+	// INDUSTRY PLAYBOOKS
+	// ---------------------------------------------------------------------------
+	ListPlaybookSnippets(ctx context.Context) ([]PlaybookSnippet, error)
+	ListProducts(ctx context.Context) ([]Product, error)
+	// ---------------------------------------------------------------------------
+	// QUESTION SECTIONS
+	// ---------------------------------------------------------------------------
+	ListQuestionSections(ctx context.Context) ([]QuestionSection, error)
+	// A report's failed attempts, oldest first.
+	ListReportAttempts(ctx context.Context, reportID uuid.UUID) ([]ReportAttempt, error)
+	// Failed events oldest first, for the admin bulk reprocess: not processed,
+	// with an error, and with no pending process_stripe_event job that will
+	// retry them, so a replay never runs beside the worker's. An empty
+	// event_type matches every type.
+	ListReprocessableStripeEvents(ctx context.Context, arg ListReprocessableStripeEventsParams) ([]StripeEvent, error)
+	// Delivered reports generated in [generated_from, generated_to), with only
+	// the columns the anonymised research export may publish.
+	ListResearchReports(ctx context.Context, arg ListResearchReportsParams) ([]ListResearchReportsRow, error)
+	// ---------------------------------------------------------------------------
+	// RUNTIME SETTINGS
+	// ---------------------------------------------------------------------------
+	ListRuntimeSettings(ctx context.Context) ([]RuntimeSetting, error)
+	// ---------------------------------------------------------------------------
+	// FIELD ENCRYPTION
+	//   Keyset-paginated scans and guarded rewrites for `armctl reencrypt`, which
+	//   moves encrypted columns to the primary key. Each Set query only writes if
+	//   the value is still the one that was read.
+	// ---------------------------------------------------------------------------
+	ListSessionEmails(ctx context.Context, arg ListSessionEmailsParams) ([]ListSessionEmailsRow, error)
+	ListSessionsByStripePIs(ctx context.Context, paymentIntents []string) ([]Session, error)
+	// Sessions newest first, for the admin listing; ties on created_at are broken
+	// by id so every session lands on exactly one page. An empty payment_status
+	// matches every status. With has_cursor, continues after the session at
+	// (cursor_at, cursor_id). Leaves out the email so no ciphertext is read.
+	ListSessionsPage(ctx context.Context, arg ListSessionsPageParams) ([]ListSessionsPageRow, error)
+	ListStripeEventPayloads(ctx context.Context, arg ListStripeEventPayloadsParams) ([]ListStripeEventPayloadsRow, error)
+	// Stored events newest first, for the admin listing, ordered and continued
+	// like ListSessionsPage. status is empty for any, 'failed' (handler errored,
+	// not processed since), 'pending' (not processed, no error) or 'processed';
+	// an empty event_type matches every type.
+	ListStripeEvents(ctx context.Context, arg ListStripeEventsParams) ([]StripeEvent, error)
+	// Stored events of the given types received in [received_from, received_to),
+	// oldest first. Used by the accounting export.
+	ListStripeEventsForExport(ctx context.Context, arg ListStripeEventsForExportParams) ([]StripeEvent, error)
+	ListSubscriptionEmails(ctx context.Context, arg ListSubscriptionEmailsParams) ([]ListSubscriptionEmailsRow, error)
+	// Notes written against the session's report included, oldest first.
+	ListSupportNotesBySession(ctx context.Context, sessionID uuid.UUID) ([]SupportNote, error)
+	// Testimonials their authors agreed to have quoted, approved or awaiting
+	// approval, oldest response first.
+	ListTestimonials(ctx context.Context, approved bool) ([]ListTestimonialsRow, error)
+	ListTierThemes(ctx context.Context) ([]TierTheme, error)
+	// Report-ready emails sent in [sent_after, sent_before) that were not opened,
+	// bounced or resent, for live reports with no other email that is later or
+	// was opened. Oldest first. Feeds the worker's resender; with report_id, only
+	// that report's.
+	ListUnopenedReportEmails(ctx context.Context, arg ListUnopenedReportEmailsParams) ([]ListUnopenedReportEmailsRow, error)
+	ListUnresolvedDuplicatePurchases(ctx context.Context) ([]ListUnresolvedDuplicatePurchasesRow, error)
+	// ---------------------------------------------------------------------------
 	// EMAIL LOG
 	// ---------------------------------------------------------------------------
 	LogEmail(ctx context.Context, arg LogEmailParams) (EmailLog, error)
+	// Records an email the provider refused. It has no sent_at.
+	LogEmailFailure(ctx context.Context, arg LogEmailFailureParams) (EmailLog, error)
+	// Records a send skipped as a duplicate of an earlier email. It has no
+	// sent_at.
+	LogSkippedEmail(ctx context.Context, arg LogSkippedEmailParams) (EmailLog, error)
+	MarkEmailBounced(ctx context.Context, arg MarkEmailBouncedParams) (EmailLog, error)
+	MarkEmailClaimSent(ctx context.Context, arg MarkEmailClaimSentParams) (EmailLog, error)
+	// A click implies an open, even when the client blocked the tracking pixel.
+	MarkEmailClicked(ctx context.Context, providerID sql.NullString) (EmailLog, error)
+	// Webhooks are delivered at least once; the first open is kept.
 	MarkEmailOpened(ctx context.Context, providerID sql.NullString) (EmailLog, error)
+	// Claims an email for resending; 0 when another replica already has.
+	MarkEmailResent(ctx context.Context, id uuid.UUID) (int64, error)
 	MarkSessionPaid(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error)
+	MarkSessionPaidByCredit(ctx context.Context, arg MarkSessionPaidByCreditParams) (Session, error)
+	MarkSessionPaidBySubscription(ctx context.Context, arg MarkSessionPaidBySubscriptionParams) (Session, error)
 	MarkSessionPaymentFailed(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error)
+	MarkSessionRefunded(ctx context.Context, id uuid.UUID) (Session, error)
 	MarkStripeEventFailed(ctx context.Context, arg MarkStripeEventFailedParams) (StripeEvent, error)
 	MarkStripeEventProcessed(ctx context.Context, stripeEventID string) (StripeEvent, error)
+	// Moves questions to unused negative display orders so a reordering can be
+	// written row by row without tripping idx_qdef_section_order.
+	ParkQuestionDisplayOrders(ctx context.Context, ids []string) error
+	// Stores the customer's answer; they may answer again. An approval only
+	// survives when the testimonial and consent to quote it are unchanged.
+	RecordFeedback(ctx context.Context, arg RecordFeedbackParams) (Feedback, error)
+	// Counts a failed attempt and keeps its error.
+	RecordJobAttempt(ctx context.Context, arg RecordJobAttemptParams) error
+	// Records a failed send and frees its key for the next attempt.
+	ReleaseEmailClaim(ctx context.Context, arg ReleaseEmailClaimParams) (EmailLog, error)
+	// Gives up claimed_by's claim so another worker can take the job at once.
+	ReleaseJobClaim(ctx context.Context, arg ReleaseJobClaimParams) error
+	// updated_at is bumped so the poller's one-day window starts again.
+	ReleaseReport(ctx context.Context, id uuid.UUID) (Report, error)
+	// Gives up claimed_by's claim so another worker can take the report at once.
+	ReleaseReportClaim(ctx context.Context, arg ReleaseReportClaimParams) error
+	// Returns a report to draft so the worker's poller generates it again.
+	RequeueReport(ctx context.Context, id uuid.UUID) (Report, error)
+	// Like RequeueReport, but keeps the stored AI analysis so the worker only
+	// runs the narrative stage again.
+	RequeueReportNarrative(ctx context.Context, id uuid.UUID) (Report, error)
+	// Returns no rows when the duplicate is unknown or already resolved.
+	ResolveDuplicatePurchase(ctx context.Context, arg ResolveDuplicatePurchaseParams) (DuplicatePurchase, error)
+	// Soft-deletes a report: the row and its results stay for accounting and
+	// audit, but the access token stops working and the worker skips it. Returns
+	// no rows when the report does not exist or is already revoked.
+	RevokeReport(ctx context.Context, arg RevokeReportParams) (Report, error)
+	// Gives a report a new access token, so links holding the old one stop
+	// working. Matching the old token makes concurrent rotations issue one new
+	// token; the loser gets no row.
+	RotateReportAccessToken(ctx context.Context, arg RotateReportAccessTokenParams) (string, error)
+	// Checkpoints the worker's progress on a report; see reports.job_state.
+	SaveReportJobState(ctx context.Context, arg SaveReportJobStateParams) error
 	SetAIHedge(ctx context.Context, arg SetAIHedgeParams) (RiskResult, error)
+	SetEmailLogAddress(ctx context.Context, arg SetEmailLogAddressParams) (int64, error)
 	SetReportError(ctx context.Context, arg SetReportErrorParams) (Report, error)
 	SetReportProcessing(ctx context.Context, id uuid.UUID) (Report, error)
+	SetSessionEmail(ctx context.Context, arg SetSessionEmailParams) (int64, error)
+	SetStripeEventPayload(ctx context.Context, arg SetStripeEventPayloadParams) (int64, error)
+	SetSubscriptionEmail(ctx context.Context, arg SetSubscriptionEmailParams) (int64, error)
+	// Failed attempts since a time, grouped by provider and its last error, most
+	// frequent first: "all failures last night were DeepSeek 429s" at a glance.
+	SummariseReportAttempts(ctx context.Context, since time.Time) ([]SummariseReportAttemptsRow, error)
+	// Shadow scores recorded since the given time, per candidate profile and
+	// pair of bands, with the sums of the score differences (shadow − production).
+	SummarizeShadowScores(ctx context.Context, since time.Time) ([]SummarizeShadowScoresRow, error)
+	// Stops optional emails (feedback requests) to an address, by its blind
+	// index. The first reason is kept.
+	SuppressEmail(ctx context.Context, arg SuppressEmailParams) error
+	SuppressSessionEmail(ctx context.Context, arg SuppressSessionEmailParams) error
+	// Takes the session's advisory lock on this connection without waiting, and
+	// returns whether it was taken. Held until UnlockSession or the connection
+	// closes; see store.LockSession.
+	TryLockSession(ctx context.Context, sessionID uuid.UUID) (bool, error)
+	UnlockSession(ctx context.Context, sessionID uuid.UUID) (bool, error)
+	UpdateQuestionSection(ctx context.Context, arg UpdateQuestionSectionParams) (QuestionSection, error)
 	UpdateSessionContext(ctx context.Context, arg UpdateSessionContextParams) (Session, error)
+	UpdateTierTheme(ctx context.Context, arg UpdateTierThemeParams) (TierTheme, error)
+	// Replaces an expired entry for the same fingerprint rather than failing.
+	UpsertAICacheEntry(ctx context.Context, arg UpsertAICacheEntryParams) error
 	// ---------------------------------------------------------------------------
 	// ANSWERS
 	// ---------------------------------------------------------------------------
 	UpsertAnswer(ctx context.Context, arg UpsertAnswerParams) (Answer, error)
 	// ---------------------------------------------------------------------------
+	// CONSULTATION REQUESTS
+	// ---------------------------------------------------------------------------
+	UpsertConsultationRequest(ctx context.Context, arg UpsertConsultationRequestParams) (ConsultationRequest, error)
+	UpsertFeatureFlag(ctx context.Context, arg UpsertFeatureFlagParams) (FeatureFlag, error)
+	// ---------------------------------------------------------------------------
+	// PAYMENTS
+	// ---------------------------------------------------------------------------
+	// Called for charge.succeeded and charge.updated. A charge's balance
+	// transaction does not change once it exists, so redelivery rewrites the
+	// same values.
+	UpsertPayment(ctx context.Context, arg UpsertPaymentParams) (Payment, error)
+	UpsertPlaybookSnippet(ctx context.Context, arg UpsertPlaybookSnippetParams) (PlaybookSnippet, error)
+	UpsertProduct(ctx context.Context, arg UpsertProductParams) (Product, error)
+	// Used by the question seed loader (internal/seed). created_at is kept on
+	// update.
+	UpsertQuestionDefinition(ctx context.Context, arg UpsertQuestionDefinitionParams) (QuestionDefinition, error)
+	UpsertRuntimeSetting(ctx context.Context, arg UpsertRuntimeSettingParams) (RuntimeSetting, error)
+	// ---------------------------------------------------------------------------
+	// SHADOW SCORES
+	// ---------------------------------------------------------------------------
+	// A regenerated report replaces its earlier shadow score for the profile.
+	UpsertShadowScore(ctx context.Context, arg UpsertShadowScoreParams) error
+	// ---------------------------------------------------------------------------
 	// STRIPE EVENTS
 	// ---------------------------------------------------------------------------
 	UpsertStripeEvent(ctx context.Context, arg UpsertStripeEventParams) (StripeEvent, error)
+	// ---------------------------------------------------------------------------
+	// SUBSCRIPTIONS
+	// ---------------------------------------------------------------------------
+	// Called for customer.subscription.* and invoice.paid. Null arguments leave
+	// the stored value alone, so each event only overwrites what it carries.
+	UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) (Subscription, error)
 }
 
 var _ Querier = (*Queries)(nil)
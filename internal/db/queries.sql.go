@@ -16,13 +16,89 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+const approveTestimonial = `-- name: ApproveTestimonial :one
+UPDATE feedback
+SET approved_at = COALESCE(approved_at, now())
+WHERE report_id = $1 AND consent_quote AND testimonial IS NOT NULL
+RETURNING report_id, token, requested_at, rating, testimonial, consent_quote, consent_name, responded_at, approved_at
+`
+
+// sql.ErrNoRows when the report has no testimonial its author agreed to have
+// quoted.
+func (q *Queries) ApproveTestimonial(ctx context.Context, reportID uuid.UUID) (Feedback, error) {
+	row := q.queryRow(ctx, q.approveTestimonialStmt, approveTestimonial, reportID)
+	var i Feedback
+	err := row.Scan(
+		&i.ReportID,
+		&i.Token,
+		&i.RequestedAt,
+		&i.Rating,
+		&i.Testimonial,
+		&i.ConsentQuote,
+		&i.ConsentName,
+		&i.RespondedAt,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const assignExperiment = `-- name: AssignExperiment :exec
+
+INSERT INTO experiment_assignments (session_id, experiment, variant)
+VALUES ($1, $2, $3)
+ON CONFLICT (session_id, experiment) DO NOTHING
+`
+
+type AssignExperimentParams struct {
+	SessionID  uuid.UUID `db:"session_id" json:"session_id"`
+	Experiment string    `db:"experiment" json:"experiment"`
+	Variant    string    `db:"variant" json:"variant"`
+}
+
+// ---------------------------------------------------------------------------
+// EXPERIMENTS
+// ---------------------------------------------------------------------------
+// A session keeps its first assignment.
+func (q *Queries) AssignExperiment(ctx context.Context, arg AssignExperimentParams) error {
+	_, err := q.exec(ctx, q.assignExperimentStmt, assignExperiment, arg.SessionID, arg.Experiment, arg.Variant)
+	return err
+}
+
+const assignInvoiceNumber = `-- name: AssignInvoiceNumber :one
+UPDATE sessions
+SET invoice_number = COALESCE(invoice_number, nextval('invoice_number_seq'))
+WHERE id = $1
+RETURNING invoice_number::bigint
+`
+
+// Returns the session's invoice number, drawing the next one from the
+// sequence on first use so re-downloads print the same number.
+func (q *Queries) AssignInvoiceNumber(ctx context.Context, id uuid.UUID) (int64, error) {
+	row := q.queryRow(ctx, q.assignInvoiceNumberStmt, assignInvoiceNumber, id)
+	var column1 int64
+	err := row.Scan(&column1)
+	return column1, err
+}
+
 const attachStripeCustomer = `-- name: AttachStripeCustomer :one
 UPDATE sessions
 SET stripe_customer_id    = $2,
     stripe_payment_intent = $3,
-    email                 = $4
+    email                 = $4,
+    product_sku           = $5,
+    billing_country       = $6,
+    billing_postal_code   = $7,
+    subtotal_cents        = $8,
+    tax_cents             = $9,
+    tax_calculation_id    = $10,
+    billing_name          = $11,
+    billing_address_line1 = $12,
+    billing_address_line2 = $13,
+    billing_city          = $14,
+    billing_tax_id        = $15,
+    email_hash            = $16
 WHERE id = $1
-RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
 `
 
 type AttachStripeCustomerParams struct {
@@ -30,6 +106,18 @@ type AttachStripeCustomerParams struct {
 	StripeCustomerID    sql.NullString `db:"stripe_customer_id" json:"stripe_customer_id"`
 	StripePaymentIntent sql.NullString `db:"stripe_payment_intent" json:"stripe_payment_intent"`
 	Email               sql.NullString `db:"email" json:"email"`
+	ProductSku          sql.NullString `db:"product_sku" json:"product_sku"`
+	BillingCountry      sql.NullString `db:"billing_country" json:"billing_country"`
+	BillingPostalCode   sql.NullString `db:"billing_postal_code" json:"billing_postal_code"`
+	SubtotalCents       sql.NullInt32  `db:"subtotal_cents" json:"subtotal_cents"`
+	TaxCents            sql.NullInt32  `db:"tax_cents" json:"tax_cents"`
+	TaxCalculationID    sql.NullString `db:"tax_calculation_id" json:"tax_calculation_id"`
+	BillingName         sql.NullString `db:"billing_name" json:"billing_name"`
+	BillingAddressLine1 sql.NullString `db:"billing_address_line1" json:"billing_address_line1"`
+	BillingAddressLine2 sql.NullString `db:"billing_address_line2" json:"billing_address_line2"`
+	BillingCity         sql.NullString `db:"billing_city" json:"billing_city"`
+	BillingTaxID        sql.NullString `db:"billing_tax_id" json:"billing_tax_id"`
+	EmailHash           sql.NullString `db:"email_hash" json:"email_hash"`
 }
 
 func (q *Queries) AttachStripeCustomer(ctx context.Context, arg AttachStripeCustomerParams) (Session, error) {
@@ -38,6 +126,18 @@ func (q *Queries) AttachStripeCustomer(ctx context.Context, arg AttachStripeCust
 		arg.StripeCustomerID,
 		arg.StripePaymentIntent,
 		arg.Email,
+		arg.ProductSku,
+		arg.BillingCountry,
+		arg.BillingPostalCode,
+		arg.SubtotalCents,
+		arg.TaxCents,
+		arg.TaxCalculationID,
+		arg.BillingName,
+		arg.BillingAddressLine1,
+		arg.BillingAddressLine2,
+		arg.BillingCity,
+		arg.BillingTaxID,
+		arg.EmailHash,
 	)
 	var i Session
 	err := row.Scan(
@@ -59,197 +159,177 @@ func (q *Queries) AttachStripeCustomer(ctx context.Context, arg AttachStripeCust
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
 	)
 	return i, err
 }
 
-const countAnsweredBySession = `-- name: CountAnsweredBySession :one
-SELECT COUNT(*) FROM answers WHERE session_id = $1 AND answer_text != ''
+const claimEmail = `-- name: ClaimEmail :one
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, dedupe_key)
+VALUES ($1, $2, $3, '', $4, $5)
+ON CONFLICT (dedupe_key) WHERE dedupe_key IS NOT NULL DO UPDATE
+SET created_at = now(),
+    to_address = EXCLUDED.to_address
+WHERE email_log.sent_at IS NULL
+  AND email_log.created_at < $6::timestamptz
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
 `
 
-func (q *Queries) CountAnsweredBySession(ctx context.Context, sessionID uuid.UUID) (int64, error) {
-	row := q.queryRow(ctx, q.countAnsweredBySessionStmt, countAnsweredBySession, sessionID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type ClaimEmailParams struct {
+	SessionID   uuid.NullUUID  `db:"session_id" json:"session_id"`
+	ReportID    uuid.NullUUID  `db:"report_id" json:"report_id"`
+	ToAddress   string         `db:"to_address" json:"to_address"`
+	Template    string         `db:"template" json:"template"`
+	DedupeKey   sql.NullString `db:"dedupe_key" json:"dedupe_key"`
+	StaleBefore time.Time      `db:"stale_before" json:"stale_before"`
 }
 
-const createReport = `-- name: CreateReport :one
-
-INSERT INTO reports (session_id)
-VALUES ($1)
-RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at
-`
-
-// ---------------------------------------------------------------------------
-// REPORTS
-// ---------------------------------------------------------------------------
-func (q *Queries) CreateReport(ctx context.Context, sessionID uuid.UUID) (Report, error) {
-	row := q.queryRow(ctx, q.createReportStmt, createReport, sessionID)
-	var i Report
+// Claims dedupe_key before an email is sent. No row when the key is already
+// claimed: the email was sent, or is being sent elsewhere. A claim that was
+// never finished (the process died mid-send) is taken over once it was made
+// before stale_before.
+func (q *Queries) ClaimEmail(ctx context.Context, arg ClaimEmailParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.claimEmailStmt, claimEmail,
+		arg.SessionID,
+		arg.ReportID,
+		arg.ToAddress,
+		arg.Template,
+		arg.DedupeKey,
+		arg.StaleBefore,
+	)
+	var i EmailLog
 	err := row.Scan(
 		&i.ID,
 		&i.SessionID,
-		&i.Status,
-		&i.ErrorMessage,
-		&i.OverallScore,
-		&i.CriticalCount,
-		&i.RisksJson,
-		&i.ExecutiveSummary,
-		&i.TopPriorityHtml,
-		&i.AccessToken,
-		&i.GeneratedAt,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
 	)
 	return i, err
 }
 
-const createSession = `-- name: CreateSession :one
-
-
-INSERT INTO sessions (anon_token, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at
+const claimJob = `-- name: ClaimJob :one
+UPDATE jobs
+SET claimed_by       = $1::text,
+    claim_expires_at = now() + make_interval(secs => $2::int)
+WHERE id = $3
+  AND status = 'pending'
+  AND run_at <= now()
+  AND (claim_expires_at IS NULL OR claim_expires_at < now() OR claimed_by = $1::text)
+RETURNING id, type, payload, dedupe_key, run_at, status, attempts, error, claimed_by, claim_expires_at, finished_at, created_at, updated_at
 `
 
-type CreateSessionParams struct {
-	AnonToken   string         `db:"anon_token" json:"anon_token"`
-	UtmSource   sql.NullString `db:"utm_source" json:"utm_source"`
-	UtmMedium   sql.NullString `db:"utm_medium" json:"utm_medium"`
-	UtmCampaign sql.NullString `db:"utm_campaign" json:"utm_campaign"`
-	Referrer    sql.NullString `db:"referrer" json:"referrer"`
-	IpHash      sql.NullString `db:"ip_hash" json:"ip_hash"`
-	UserAgent   sql.NullString `db:"user_agent" json:"user_agent"`
+type ClaimJobParams struct {
+	ClaimedBy    string    `db:"claimed_by" json:"claimed_by"`
+	LeaseSeconds int32     `db:"lease_seconds" json:"lease_seconds"`
+	ID           uuid.UUID `db:"id" json:"id"`
 }
 
-// =============================================================================
-// sqlc QUERIES — Asymmetric Risk Mapper
-// Run: sqlc generate  (sqlc.yaml points here)
-// =============================================================================
-// ---------------------------------------------------------------------------
-// SESSIONS
-// ---------------------------------------------------------------------------
-func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
-	row := q.queryRow(ctx, q.createSessionStmt, createSession,
-		arg.AnonToken,
-		arg.UtmSource,
-		arg.UtmMedium,
-		arg.UtmCampaign,
-		arg.Referrer,
-		arg.IpHash,
-		arg.UserAgent,
-	)
-	var i Session
+// Claims one pending job for claimed_by. Returns no rows when the job is
+// finished, not due yet, or another worker holds an unexpired claim on it.
+func (q *Queries) ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error) {
+	row := q.queryRow(ctx, q.claimJobStmt, claimJob, arg.ClaimedBy, arg.LeaseSeconds, arg.ID)
+	var i Job
 	err := row.Scan(
 		&i.ID,
-		&i.AnonToken,
-		&i.Email,
-		&i.BizName,
-		&i.Industry,
-		&i.Stage,
-		&i.StripeCustomerID,
-		&i.StripePaymentIntent,
-		&i.PaymentStatus,
-		&i.PaidAt,
-		&i.UtmSource,
-		&i.UtmMedium,
-		&i.UtmCampaign,
-		&i.Referrer,
-		&i.IpHash,
-		&i.UserAgent,
+		&i.Type,
+		&i.Payload,
+		&i.DedupeKey,
+		&i.RunAt,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FinishedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const finalizeReport = `-- name: FinalizeReport :one
-UPDATE reports
-SET status          = 'ready',
-    overall_score   = $2,
-    critical_count  = $3,
-    risks_json      = $4,
-    executive_summary = $5,
-    top_priority_html = $6,
-    generated_at    = now()
-WHERE id = $1
-RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at
+const claimPendingJobs = `-- name: ClaimPendingJobs :many
+UPDATE jobs
+SET claimed_by       = $1::text,
+    claim_expires_at = now() + make_interval(secs => $2::int)
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE status = 'pending'
+      AND run_at <= now()
+      AND type = ANY($3::text[])
+      AND (claim_expires_at IS NULL OR claim_expires_at < now())
+    ORDER BY run_at
+    LIMIT $4::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, type, payload, dedupe_key, run_at, status, attempts, error, claimed_by, claim_expires_at, finished_at, created_at, updated_at
 `
 
-type FinalizeReportParams struct {
-	ID               uuid.UUID             `db:"id" json:"id"`
-	OverallScore     sql.NullInt16         `db:"overall_score" json:"overall_score"`
-	CriticalCount    sql.NullInt16         `db:"critical_count" json:"critical_count"`
-	RisksJson        pqtype.NullRawMessage `db:"risks_json" json:"risks_json"`
-	ExecutiveSummary sql.NullString        `db:"executive_summary" json:"executive_summary"`
-	TopPriorityHtml  sql.NullString        `db:"top_priority_html" json:"top_priority_html"`
+type ClaimPendingJobsParams struct {
+	ClaimedBy    string   `db:"claimed_by" json:"claimed_by"`
+	LeaseSeconds int32    `db:"lease_seconds" json:"lease_seconds"`
+	Types        []string `db:"types" json:"types"`
+	MaxJobs      int32    `db:"max_jobs" json:"max_jobs"`
 }
 
-func (q *Queries) FinalizeReport(ctx context.Context, arg FinalizeReportParams) (Report, error) {
-	row := q.queryRow(ctx, q.finalizeReportStmt, finalizeReport,
-		arg.ID,
-		arg.OverallScore,
-		arg.CriticalCount,
-		arg.RisksJson,
-		arg.ExecutiveSummary,
-		arg.TopPriorityHtml,
-	)
-	var i Report
-	err := row.Scan(
-		&i.ID,
-		&i.SessionID,
-		&i.Status,
-		&i.ErrorMessage,
-		&i.OverallScore,
-		&i.CriticalCount,
-		&i.RisksJson,
-		&i.ExecutiveSummary,
-		&i.TopPriorityHtml,
-		&i.AccessToken,
-		&i.GeneratedAt,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+// The poller's version of ClaimJob: claims up to max_jobs unclaimed due jobs
+// of the given types, longest due first. SKIP LOCKED lets concurrent pollers
+// take disjoint batches.
+func (q *Queries) ClaimPendingJobs(ctx context.Context, arg ClaimPendingJobsParams) ([]Job, error) {
+	rows, err := q.query(ctx, q.claimPendingJobsStmt, claimPendingJobs,
+		arg.ClaimedBy,
+		arg.LeaseSeconds,
+		pq.Array(arg.Types),
+		arg.MaxJobs,
 	)
-	return i, err
-}
-
-const getAllQuestionDefinitions = `-- name: GetAllQuestionDefinitions :many
-
-SELECT id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at FROM question_definitions
-ORDER BY section_id, display_order
-`
-
-// ---------------------------------------------------------------------------
-// QUESTION DEFINITIONS
-// ---------------------------------------------------------------------------
-func (q *Queries) GetAllQuestionDefinitions(ctx context.Context) ([]QuestionDefinition, error) {
-	rows, err := q.query(ctx, q.getAllQuestionDefinitionsStmt, getAllQuestionDefinitions)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []QuestionDefinition{}
+	items := []Job{}
 	for rows.Next() {
-		var i QuestionDefinition
+		var i Job
 		if err := rows.Scan(
 			&i.ID,
-			&i.QuestionVersion,
-			&i.SectionID,
-			&i.SectionTitle,
-			&i.DisplayOrder,
-			&i.Text,
-			&i.Subtext,
 			&i.Type,
-			pq.Array(&i.Opts),
-			&i.Placeholder,
-			&i.Required,
-			&i.RiskName,
-			&i.RiskDesc,
-			&i.Hedge,
-			&i.ScoringConfig,
-			&i.IsScoring,
+			&i.Payload,
+			&i.DedupeKey,
+			&i.RunAt,
+			&i.Status,
+			&i.Attempts,
+			&i.Error,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.FinishedAt,
 			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -264,55 +344,73 @@ func (q *Queries) GetAllQuestionDefinitions(ctx context.Context) ([]QuestionDefi
 	return items, nil
 }
 
-const getAnswersBySession = `-- name: GetAnswersBySession :many
-SELECT a.id, a.session_id, a.question_id, a.answer_text, a.client_p, a.client_i, a.answered_at, a.updated_at, qd.section_id, qd.risk_name, qd.risk_desc, qd.hedge, qd.scoring_config, qd.is_scoring
-FROM answers a
-JOIN question_definitions qd ON qd.id = a.question_id
-WHERE a.session_id = $1
-ORDER BY qd.display_order
+const claimPendingReports = `-- name: ClaimPendingReports :many
+UPDATE reports
+SET claimed_by       = $1::text,
+    claim_expires_at = now() + make_interval(secs => $2::int)
+WHERE id IN (
+    SELECT id FROM reports
+    WHERE status IN ('draft', 'processing')
+      AND GREATEST(updated_at, not_before) > now() - INTERVAL '1 day'
+      AND (not_before IS NULL OR not_before <= now())
+      AND revoked_at IS NULL
+      AND held_at IS NULL
+      AND (claim_expires_at IS NULL OR claim_expires_at < now())
+    ORDER BY created_at
+    LIMIT $3::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
 `
 
-type GetAnswersBySessionRow struct {
-	ID            uuid.UUID       `db:"id" json:"id"`
-	SessionID     uuid.UUID       `db:"session_id" json:"session_id"`
-	QuestionID    string          `db:"question_id" json:"question_id"`
-	AnswerText    string          `db:"answer_text" json:"answer_text"`
-	ClientP       sql.NullInt16   `db:"client_p" json:"client_p"`
-	ClientI       sql.NullInt16   `db:"client_i" json:"client_i"`
-	AnsweredAt    time.Time       `db:"answered_at" json:"answered_at"`
-	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
-	SectionID     SectionID       `db:"section_id" json:"section_id"`
-	RiskName      string          `db:"risk_name" json:"risk_name"`
-	RiskDesc      string          `db:"risk_desc" json:"risk_desc"`
-	Hedge         string          `db:"hedge" json:"hedge"`
-	ScoringConfig json.RawMessage `db:"scoring_config" json:"scoring_config"`
-	IsScoring     bool            `db:"is_scoring" json:"is_scoring"`
+type ClaimPendingReportsParams struct {
+	ClaimedBy    string `db:"claimed_by" json:"claimed_by"`
+	LeaseSeconds int32  `db:"lease_seconds" json:"lease_seconds"`
+	MaxReports   int32  `db:"max_reports" json:"max_reports"`
 }
 
-func (q *Queries) GetAnswersBySession(ctx context.Context, sessionID uuid.UUID) ([]GetAnswersBySessionRow, error) {
-	rows, err := q.query(ctx, q.getAnswersBySessionStmt, getAnswersBySession, sessionID)
+// The poller's version of ListPendingReports for several replicas: marks up to
+// max_reports unclaimed pending reports as owned by claimed_by until the lease
+// expires and returns them. SKIP LOCKED lets concurrent pollers take disjoint
+// batches instead of queueing behind each other for the same rows.
+func (q *Queries) ClaimPendingReports(ctx context.Context, arg ClaimPendingReportsParams) ([]Report, error) {
+	rows, err := q.query(ctx, q.claimPendingReportsStmt, claimPendingReports, arg.ClaimedBy, arg.LeaseSeconds, arg.MaxReports)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []GetAnswersBySessionRow{}
+	items := []Report{}
 	for rows.Next() {
-		var i GetAnswersBySessionRow
+		var i Report
 		if err := rows.Scan(
 			&i.ID,
 			&i.SessionID,
-			&i.QuestionID,
-			&i.AnswerText,
-			&i.ClientP,
-			&i.ClientI,
-			&i.AnsweredAt,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.OverallScore,
+			&i.CriticalCount,
+			&i.RisksJson,
+			&i.ExecutiveSummary,
+			&i.TopPriorityHtml,
+			&i.AccessToken,
+			&i.GeneratedAt,
+			&i.CreatedAt,
 			&i.UpdatedAt,
-			&i.SectionID,
-			&i.RiskName,
-			&i.RiskDesc,
-			&i.Hedge,
-			&i.ScoringConfig,
-			&i.IsScoring,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.RevokedAt,
+			&i.RevokedReason,
+			&i.HeldAt,
+			&i.AiAnalysis,
+			&i.AiNarrative,
+			&i.Relationships,
+			&i.AiQualityIssues,
+			&i.AiBudgetNote,
+			&i.NotBefore,
+			&i.Flags,
+			&i.ExecutiveSummaryEditedAt,
+			pq.Array(&i.SkippedQuestions),
+			&i.JobState,
 		); err != nil {
 			return nil, err
 		}
@@ -327,64 +425,242 @@ func (q *Queries) GetAnswersBySession(ctx context.Context, sessionID uuid.UUID)
 	return items, nil
 }
 
-const getCompletionFunnelStats = `-- name: GetCompletionFunnelStats :one
-SELECT
-    COUNT(*)                                                        AS total_sessions,
-    COUNT(*) FILTER (WHERE (SELECT COUNT(*) FROM answers a WHERE a.session_id = s.id) > 0) AS started,
-    COUNT(*) FILTER (WHERE payment_status = 'paid')                AS paid,
-    COUNT(*) FILTER (WHERE payment_status = 'paid' AND EXISTS (
-        SELECT 1 FROM reports r WHERE r.session_id = s.id AND r.status = 'ready'
-    ))                                                              AS report_delivered
-FROM sessions s
+const claimReport = `-- name: ClaimReport :one
+UPDATE reports
+SET claimed_by       = $1::text,
+    claim_expires_at = now() + make_interval(secs => $2::int)
+WHERE id = $3
+  AND status IN ('draft', 'processing')
+  AND revoked_at IS NULL
+  AND held_at IS NULL
+  AND (claim_expires_at IS NULL OR claim_expires_at < now() OR claimed_by = $1::text)
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
 `
 
-type GetCompletionFunnelStatsRow struct {
-	TotalSessions   int64 `db:"total_sessions" json:"total_sessions"`
-	Started         int64 `db:"started" json:"started"`
-	Paid            int64 `db:"paid" json:"paid"`
-	ReportDelivered int64 `db:"report_delivered" json:"report_delivered"`
+type ClaimReportParams struct {
+	ClaimedBy    string    `db:"claimed_by" json:"claimed_by"`
+	LeaseSeconds int32     `db:"lease_seconds" json:"lease_seconds"`
+	ID           uuid.UUID `db:"id" json:"id"`
 }
 
-func (q *Queries) GetCompletionFunnelStats(ctx context.Context) (GetCompletionFunnelStatsRow, error) {
-	row := q.queryRow(ctx, q.getCompletionFunnelStatsStmt, getCompletionFunnelStats)
-	var i GetCompletionFunnelStatsRow
+// Claims one pending report for claimed_by. Returns no rows when the report is
+// finished, revoked or held, or another worker holds an unexpired claim on it.
+func (q *Queries) ClaimReport(ctx context.Context, arg ClaimReportParams) (Report, error) {
+	row := q.queryRow(ctx, q.claimReportStmt, claimReport, arg.ClaimedBy, arg.LeaseSeconds, arg.ID)
+	var i Report
 	err := row.Scan(
-		&i.TotalSessions,
-		&i.Started,
-		&i.Paid,
-		&i.ReportDelivered,
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
 	)
 	return i, err
 }
 
-const getDailyRevenue = `-- name: GetDailyRevenue :many
-SELECT
-    DATE(paid_at)       AS day,
-    COUNT(*)            AS sales,
-    COUNT(*) * 59       AS revenue_usd   -- $59 fixed price
-FROM sessions
-WHERE payment_status = 'paid'
-  AND paid_at >= now() - INTERVAL '30 days'
-GROUP BY DATE(paid_at)
-ORDER BY day DESC
+const completeJob = `-- name: CompleteJob :exec
+UPDATE jobs
+SET status           = 'done',
+    attempts         = attempts + 1,
+    error            = NULL,
+    finished_at      = now(),
+    claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = $1
 `
 
-type GetDailyRevenueRow struct {
-	Day        time.Time `db:"day" json:"day"`
-	Sales      int64     `db:"sales" json:"sales"`
-	RevenueUsd int32     `db:"revenue_usd" json:"revenue_usd"`
+func (q *Queries) CompleteJob(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, q.completeJobStmt, completeJob, id)
+	return err
 }
 
-func (q *Queries) GetDailyRevenue(ctx context.Context) ([]GetDailyRevenueRow, error) {
-	rows, err := q.query(ctx, q.getDailyRevenueStmt, getDailyRevenue)
+const countAnsweredBySession = `-- name: CountAnsweredBySession :one
+SELECT COUNT(*) FROM answers WHERE session_id = $1 AND (answer_text != '' OR skipped)
+`
+
+func (q *Queries) CountAnsweredBySession(ctx context.Context, sessionID uuid.UUID) (int64, error) {
+	row := q.queryRow(ctx, q.countAnsweredBySessionStmt, countAnsweredBySession, sessionID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countExpiredAICache = `-- name: CountExpiredAICache :one
+SELECT COUNT(*) FROM ai_cache
+WHERE COALESCE(last_hit_at, created_at) < $1::timestamptz
+`
+
+// AI output not created or reused since cutoff.
+func (q *Queries) CountExpiredAICache(ctx context.Context, cutoff time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countExpiredAICacheStmt, countExpiredAICache, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countExpiredAITranscripts = `-- name: CountExpiredAITranscripts :one
+SELECT COUNT(*) FROM ai_transcripts WHERE created_at < $1::timestamptz
+`
+
+func (q *Queries) CountExpiredAITranscripts(ctx context.Context, cutoff time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countExpiredAITranscriptsStmt, countExpiredAITranscripts, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countExpiredAnswers = `-- name: CountExpiredAnswers :one
+
+SELECT COUNT(*) FROM answers a
+JOIN sessions s ON s.id = a.session_id
+WHERE s.updated_at < $1::timestamptz
+  AND NOT EXISTS (
+      SELECT 1 FROM reports r
+      WHERE r.session_id = s.id AND r.updated_at >= $1::timestamptz
+  )
+`
+
+// ---------------------------------------------------------------------------
+// RETENTION
+//
+//	Each data class has a Count query for dry runs and a Delete query that
+//	removes at most batch_size rows, so the enforcer can delete in short
+//	transactions until a batch comes back short.
+//
+// ---------------------------------------------------------------------------
+// Raw answers of sessions whose session and report are both untouched since
+// cutoff, so a report being generated or requeued keeps its answers. Reports
+// keep their scored risk_results, but can no longer be regenerated.
+func (q *Queries) CountExpiredAnswers(ctx context.Context, cutoff time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countExpiredAnswersStmt, countExpiredAnswers, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countExpiredEmailLog = `-- name: CountExpiredEmailLog :one
+SELECT COUNT(*) FROM email_log
+WHERE created_at < $1::timestamptz
+`
+
+func (q *Queries) CountExpiredEmailLog(ctx context.Context, cutoff time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countExpiredEmailLogStmt, countExpiredEmailLog, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countExpiredStripeEvents = `-- name: CountExpiredStripeEvents :one
+SELECT COUNT(*) FROM stripe_events
+WHERE processed AND received_at < $1::timestamptz
+`
+
+// Processed Stripe events received before cutoff. Unprocessed events are kept
+// for investigation whatever their age.
+func (q *Queries) CountExpiredStripeEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.countExpiredStripeEventsStmt, countExpiredStripeEvents, cutoff)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFailedPaymentsByEmailSince = `-- name: CountFailedPaymentsByEmailSince :one
+SELECT COUNT(*) FROM sessions
+WHERE email_hash = $1 AND payment_status = 'failed' AND updated_at >= $2::timestamptz
+`
+
+type CountFailedPaymentsByEmailSinceParams struct {
+	Email sql.NullString `db:"email" json:"email"`
+	Since time.Time      `db:"since" json:"since"`
+}
+
+// Sessions whose payment failed for this email, as a card-testing signal.
+// The store's codec replaces email with its blind index.
+func (q *Queries) CountFailedPaymentsByEmailSince(ctx context.Context, arg CountFailedPaymentsByEmailSinceParams) (int64, error) {
+	row := q.queryRow(ctx, q.countFailedPaymentsByEmailSinceStmt, countFailedPaymentsByEmailSince, arg.Email, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countReportsAhead = `-- name: CountReportsAhead :one
+SELECT COUNT(*) FROM reports
+WHERE status IN ('draft', 'processing')
+  AND GREATEST(updated_at, not_before) > now() - INTERVAL '1 day'
+  AND (not_before IS NULL OR not_before <= now())
+  AND revoked_at IS NULL
+  AND held_at IS NULL
+  AND created_at < (SELECT r.created_at FROM reports r WHERE r.id = $1)
+`
+
+// Pending reports paid for before this one, by the same filter as
+// ListPendingReports: its position in the queue for GET /api/report.
+func (q *Queries) CountReportsAhead(ctx context.Context, id uuid.UUID) (int64, error) {
+	row := q.queryRow(ctx, q.countReportsAheadStmt, countReportsAhead, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countResearchRiskTiers = `-- name: CountResearchRiskTiers :many
+SELECT rr.question_id, rr.tier, COUNT(*)::int AS reports
+FROM risk_results rr
+JOIN reports r ON r.id = rr.report_id
+WHERE r.status = 'ready'
+  AND r.revoked_at IS NULL
+  AND r.held_at IS NULL
+  AND r.generated_at >= $1::timestamptz
+  AND r.generated_at <  $2::timestamptz
+GROUP BY rr.question_id, rr.tier
+ORDER BY rr.question_id, rr.tier
+`
+
+type CountResearchRiskTiersParams struct {
+	GeneratedFrom time.Time `db:"generated_from" json:"generated_from"`
+	GeneratedTo   time.Time `db:"generated_to" json:"generated_to"`
+}
+
+type CountResearchRiskTiersRow struct {
+	QuestionID string   `db:"question_id" json:"question_id"`
+	Tier       RiskTier `db:"tier" json:"tier"`
+	Reports    int32    `db:"reports" json:"reports"`
+}
+
+// How many of the reports ListResearchReports returns for the same range put
+// each question in each tier.
+func (q *Queries) CountResearchRiskTiers(ctx context.Context, arg CountResearchRiskTiersParams) ([]CountResearchRiskTiersRow, error) {
+	rows, err := q.query(ctx, q.countResearchRiskTiersStmt, countResearchRiskTiers, arg.GeneratedFrom, arg.GeneratedTo)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []GetDailyRevenueRow{}
+	items := []CountResearchRiskTiersRow{}
 	for rows.Next() {
-		var i GetDailyRevenueRow
-		if err := rows.Scan(&i.Day, &i.Sales, &i.RevenueUsd); err != nil {
+		var i CountResearchRiskTiersRow
+		if err := rows.Scan(&i.QuestionID, &i.Tier, &i.Reports); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -398,94 +674,159 @@ func (q *Queries) GetDailyRevenue(ctx context.Context) ([]GetDailyRevenueRow, er
 	return items, nil
 }
 
-const getQuestionByID = `-- name: GetQuestionByID :one
-SELECT id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at FROM question_definitions WHERE id = $1 LIMIT 1
+const countSessionsByIPHashSince = `-- name: CountSessionsByIPHashSince :one
+SELECT COUNT(*) FROM sessions
+WHERE ip_hash = $1 AND created_at >= $2::timestamptz
 `
 
-func (q *Queries) GetQuestionByID(ctx context.Context, id string) (QuestionDefinition, error) {
-	row := q.queryRow(ctx, q.getQuestionByIDStmt, getQuestionByID, id)
-	var i QuestionDefinition
+type CountSessionsByIPHashSinceParams struct {
+	IpHash sql.NullString `db:"ip_hash" json:"ip_hash"`
+	Since  time.Time      `db:"since" json:"since"`
+}
+
+// Velocity check at checkout: sessions started from the same (hashed) IP.
+func (q *Queries) CountSessionsByIPHashSince(ctx context.Context, arg CountSessionsByIPHashSinceParams) (int64, error) {
+	row := q.queryRow(ctx, q.countSessionsByIPHashSinceStmt, countSessionsByIPHashSince, arg.IpHash, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCohortSession = `-- name: CreateCohortSession :one
+INSERT INTO sessions (anon_token, email, email_hash, biz_name, industry, stage, product_sku, cohort, payment_status, paid_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'paid', now())
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
+`
+
+type CreateCohortSessionParams struct {
+	AnonToken  string         `db:"anon_token" json:"anon_token"`
+	Email      sql.NullString `db:"email" json:"email"`
+	EmailHash  sql.NullString `db:"email_hash" json:"email_hash"`
+	BizName    sql.NullString `db:"biz_name" json:"biz_name"`
+	Industry   sql.NullString `db:"industry" json:"industry"`
+	Stage      sql.NullString `db:"stage" json:"stage"`
+	ProductSku sql.NullString `db:"product_sku" json:"product_sku"`
+	Cohort     sql.NullString `db:"cohort" json:"cohort"`
+}
+
+// A session from an admin bulk import: created paid, with its email and
+// context, for the cohort. Its answers and report are written after it.
+func (q *Queries) CreateCohortSession(ctx context.Context, arg CreateCohortSessionParams) (Session, error) {
+	row := q.queryRow(ctx, q.createCohortSessionStmt, createCohortSession,
+		arg.AnonToken,
+		arg.Email,
+		arg.EmailHash,
+		arg.BizName,
+		arg.Industry,
+		arg.Stage,
+		arg.ProductSku,
+		arg.Cohort,
+	)
+	var i Session
 	err := row.Scan(
 		&i.ID,
-		&i.QuestionVersion,
-		&i.SectionID,
-		&i.SectionTitle,
-		&i.DisplayOrder,
-		&i.Text,
-		&i.Subtext,
-		&i.Type,
-		pq.Array(&i.Opts),
-		&i.Placeholder,
-		&i.Required,
-		&i.RiskName,
-		&i.RiskDesc,
-		&i.Hedge,
-		&i.ScoringConfig,
-		&i.IsScoring,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
 	)
 	return i, err
 }
 
-const getReportByAccessToken = `-- name: GetReportByAccessToken :one
-SELECT r.id, r.session_id, r.status, r.error_message, r.overall_score, r.critical_count, r.risks_json, r.executive_summary, r.top_priority_html, r.access_token, r.generated_at, r.created_at, r.updated_at, s.biz_name, s.industry, s.stage, s.email
-FROM reports r
-JOIN sessions s ON s.id = r.session_id
-WHERE r.access_token = $1
-LIMIT 1
+const createDuplicatePurchase = `-- name: CreateDuplicatePurchase :one
+INSERT INTO duplicate_purchases (session_id, original_session_id, changed_answers)
+VALUES ($1, $2, $3)
+RETURNING session_id, original_session_id, changed_answers, resolution, stripe_refund_id, resolved_at, created_at
 `
 
-type GetReportByAccessTokenRow struct {
-	ID               uuid.UUID             `db:"id" json:"id"`
-	SessionID        uuid.UUID             `db:"session_id" json:"session_id"`
-	Status           ReportStatus          `db:"status" json:"status"`
-	ErrorMessage     sql.NullString        `db:"error_message" json:"error_message"`
-	OverallScore     sql.NullInt16         `db:"overall_score" json:"overall_score"`
-	CriticalCount    sql.NullInt16         `db:"critical_count" json:"critical_count"`
-	RisksJson        pqtype.NullRawMessage `db:"risks_json" json:"risks_json"`
-	ExecutiveSummary sql.NullString        `db:"executive_summary" json:"executive_summary"`
-	TopPriorityHtml  sql.NullString        `db:"top_priority_html" json:"top_priority_html"`
-	AccessToken      string                `db:"access_token" json:"access_token"`
-	GeneratedAt      sql.NullTime          `db:"generated_at" json:"generated_at"`
-	CreatedAt        time.Time             `db:"created_at" json:"created_at"`
-	UpdatedAt        time.Time             `db:"updated_at" json:"updated_at"`
-	BizName          sql.NullString        `db:"biz_name" json:"biz_name"`
-	Industry         sql.NullString        `db:"industry" json:"industry"`
-	Stage            sql.NullString        `db:"stage" json:"stage"`
-	Email            sql.NullString        `db:"email" json:"email"`
+type CreateDuplicatePurchaseParams struct {
+	SessionID         uuid.UUID `db:"session_id" json:"session_id"`
+	OriginalSessionID uuid.UUID `db:"original_session_id" json:"original_session_id"`
+	ChangedAnswers    int32     `db:"changed_answers" json:"changed_answers"`
 }
 
-func (q *Queries) GetReportByAccessToken(ctx context.Context, accessToken string) (GetReportByAccessTokenRow, error) {
-	row := q.queryRow(ctx, q.getReportByAccessTokenStmt, getReportByAccessToken, accessToken)
-	var i GetReportByAccessTokenRow
+func (q *Queries) CreateDuplicatePurchase(ctx context.Context, arg CreateDuplicatePurchaseParams) (DuplicatePurchase, error) {
+	row := q.queryRow(ctx, q.createDuplicatePurchaseStmt, createDuplicatePurchase, arg.SessionID, arg.OriginalSessionID, arg.ChangedAnswers)
+	var i DuplicatePurchase
 	err := row.Scan(
-		&i.ID,
 		&i.SessionID,
-		&i.Status,
-		&i.ErrorMessage,
-		&i.OverallScore,
-		&i.CriticalCount,
-		&i.RisksJson,
-		&i.ExecutiveSummary,
-		&i.TopPriorityHtml,
-		&i.AccessToken,
-		&i.GeneratedAt,
+		&i.OriginalSessionID,
+		&i.ChangedAnswers,
+		&i.Resolution,
+		&i.StripeRefundID,
+		&i.ResolvedAt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.BizName,
-		&i.Industry,
-		&i.Stage,
-		&i.Email,
 	)
 	return i, err
 }
 
-const getReportByID = `-- name: GetReportByID :one
-SELECT id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at FROM reports WHERE id = $1 LIMIT 1
+const createFeedbackRequest = `-- name: CreateFeedbackRequest :one
+INSERT INTO feedback (report_id) VALUES ($1)
+ON CONFLICT (report_id) DO NOTHING
+RETURNING report_id, token, requested_at, rating, testimonial, consent_quote, consent_name, responded_at, approved_at
 `
 
-func (q *Queries) GetReportByID(ctx context.Context, id uuid.UUID) (Report, error) {
-	row := q.queryRow(ctx, q.getReportByIDStmt, getReportByID, id)
+// Claims a report for a feedback request; sql.ErrNoRows when another replica
+// already has.
+func (q *Queries) CreateFeedbackRequest(ctx context.Context, reportID uuid.UUID) (Feedback, error) {
+	row := q.queryRow(ctx, q.createFeedbackRequestStmt, createFeedbackRequest, reportID)
+	var i Feedback
+	err := row.Scan(
+		&i.ReportID,
+		&i.Token,
+		&i.RequestedAt,
+		&i.Rating,
+		&i.Testimonial,
+		&i.ConsentQuote,
+		&i.ConsentName,
+		&i.RespondedAt,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const createReport = `-- name: CreateReport :one
+
+INSERT INTO reports (session_id)
+VALUES ($1)
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+// ---------------------------------------------------------------------------
+// REPORTS
+// ---------------------------------------------------------------------------
+func (q *Queries) CreateReport(ctx context.Context, sessionID uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.createReportStmt, createReport, sessionID)
 	var i Report
 	err := row.Scan(
 		&i.ID,
@@ -501,16 +842,40 @@ func (q *Queries) GetReportByID(ctx context.Context, id uuid.UUID) (Report, erro
 		&i.GeneratedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
 	)
 	return i, err
 }
 
-const getReportBySessionID = `-- name: GetReportBySessionID :one
-SELECT id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at FROM reports WHERE session_id = $1 LIMIT 1
+const createScheduledReport = `-- name: CreateScheduledReport :one
+INSERT INTO reports (session_id, not_before)
+VALUES ($1, $2)
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
 `
 
-func (q *Queries) GetReportBySessionID(ctx context.Context, sessionID uuid.UUID) (Report, error) {
-	row := q.queryRow(ctx, q.getReportBySessionIDStmt, getReportBySessionID, sessionID)
+type CreateScheduledReportParams struct {
+	SessionID uuid.UUID    `db:"session_id" json:"session_id"`
+	NotBefore sql.NullTime `db:"not_before" json:"not_before"`
+}
+
+// A draft report the poller leaves alone until not_before; see
+// GetCohortScheduleEnd.
+func (q *Queries) CreateScheduledReport(ctx context.Context, arg CreateScheduledReportParams) (Report, error) {
+	row := q.queryRow(ctx, q.createScheduledReportStmt, createScheduledReport, arg.SessionID, arg.NotBefore)
 	var i Report
 	err := row.Scan(
 		&i.ID,
@@ -526,145 +891,62 @@ func (q *Queries) GetReportBySessionID(ctx context.Context, sessionID uuid.UUID)
 		&i.GeneratedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
 	)
 	return i, err
 }
 
-const getRiskResultsByReport = `-- name: GetRiskResultsByReport :many
-SELECT id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section FROM risk_results
-WHERE report_id = $1
-ORDER BY rank
-`
-
-func (q *Queries) GetRiskResultsByReport(ctx context.Context, reportID uuid.UUID) ([]RiskResult, error) {
-	rows, err := q.query(ctx, q.getRiskResultsByReportStmt, getRiskResultsByReport, reportID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []RiskResult{}
-	for rows.Next() {
-		var i RiskResult
-		if err := rows.Scan(
-			&i.ID,
-			&i.ReportID,
-			&i.QuestionID,
-			&i.Rank,
-			&i.RiskName,
-			&i.RiskDesc,
-			&i.Probability,
-			&i.Impact,
-			&i.Score,
-			&i.Tier,
-			&i.Hedge,
-			&i.AiHedge,
-			&i.Section,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
+const createSession = `-- name: CreateSession :one
 
-const getRiskStats = `-- name: GetRiskStats :many
 
-SELECT risk_name, tier, section, occurrences, avg_probability, avg_impact, avg_score FROM public_risk_stats
+INSERT INTO sessions (anon_token, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, partner)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
 `
 
-// ---------------------------------------------------------------------------
-// ANALYTICS
-// ---------------------------------------------------------------------------
-func (q *Queries) GetRiskStats(ctx context.Context) ([]PublicRiskStat, error) {
-	rows, err := q.query(ctx, q.getRiskStatsStmt, getRiskStats)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []PublicRiskStat{}
-	for rows.Next() {
-		var i PublicRiskStat
-		if err := rows.Scan(
-			&i.RiskName,
-			&i.Tier,
-			&i.Section,
-			&i.Occurrences,
-			&i.AvgProbability,
-			&i.AvgImpact,
-			&i.AvgScore,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const getScoringQuestions = `-- name: GetScoringQuestions :many
-SELECT id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at FROM question_definitions
-WHERE is_scoring = TRUE
-ORDER BY section_id, display_order
-`
-
-func (q *Queries) GetScoringQuestions(ctx context.Context) ([]QuestionDefinition, error) {
-	rows, err := q.query(ctx, q.getScoringQuestionsStmt, getScoringQuestions)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []QuestionDefinition{}
-	for rows.Next() {
-		var i QuestionDefinition
-		if err := rows.Scan(
-			&i.ID,
-			&i.QuestionVersion,
-			&i.SectionID,
-			&i.SectionTitle,
-			&i.DisplayOrder,
-			&i.Text,
-			&i.Subtext,
-			&i.Type,
-			pq.Array(&i.Opts),
-			&i.Placeholder,
-			&i.Required,
-			&i.RiskName,
-			&i.RiskDesc,
-			&i.Hedge,
-			&i.ScoringConfig,
-			&i.IsScoring,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CreateSessionParams struct {
+	AnonToken   string         `db:"anon_token" json:"anon_token"`
+	UtmSource   sql.NullString `db:"utm_source" json:"utm_source"`
+	UtmMedium   sql.NullString `db:"utm_medium" json:"utm_medium"`
+	UtmCampaign sql.NullString `db:"utm_campaign" json:"utm_campaign"`
+	Referrer    sql.NullString `db:"referrer" json:"referrer"`
+	IpHash      sql.NullString `db:"ip_hash" json:"ip_hash"`
+	UserAgent   sql.NullString `db:"user_agent" json:"user_agent"`
+	Partner     sql.NullString `db:"partner" json:"partner"`
 }
 
-const getSessionByAnonToken = `-- name: GetSessionByAnonToken :one
-SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at FROM sessions WHERE anon_token = $1 LIMIT 1
-`
-
-func (q *Queries) GetSessionByAnonToken(ctx context.Context, anonToken string) (Session, error) {
-	row := q.queryRow(ctx, q.getSessionByAnonTokenStmt, getSessionByAnonToken, anonToken)
+// =============================================================================
+// sqlc QUERIES — Asymmetric Risk Mapper
+// Run: sqlc generate  (sqlc.yaml points here)
+// =============================================================================
+// ---------------------------------------------------------------------------
+// SESSIONS
+// ---------------------------------------------------------------------------
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.queryRow(ctx, q.createSessionStmt, createSession,
+		arg.AnonToken,
+		arg.UtmSource,
+		arg.UtmMedium,
+		arg.UtmCampaign,
+		arg.Referrer,
+		arg.IpHash,
+		arg.UserAgent,
+		arg.Partner,
+	)
 	var i Session
 	err := row.Scan(
 		&i.ID,
@@ -685,98 +967,79 @@ func (q *Queries) GetSessionByAnonToken(ctx context.Context, anonToken string) (
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
 	)
 	return i, err
 }
 
-const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at FROM sessions WHERE id = $1 LIMIT 1
+const deleteExpiredAICache = `-- name: DeleteExpiredAICache :execrows
+DELETE FROM ai_cache
+WHERE fingerprint IN (
+    SELECT fingerprint FROM ai_cache
+    WHERE COALESCE(last_hit_at, created_at) < $1::timestamptz
+    LIMIT $2::int
+)
 `
 
-func (q *Queries) GetSessionByID(ctx context.Context, id uuid.UUID) (Session, error) {
-	row := q.queryRow(ctx, q.getSessionByIDStmt, getSessionByID, id)
-	var i Session
-	err := row.Scan(
-		&i.ID,
-		&i.AnonToken,
-		&i.Email,
-		&i.BizName,
-		&i.Industry,
-		&i.Stage,
-		&i.StripeCustomerID,
-		&i.StripePaymentIntent,
-		&i.PaymentStatus,
-		&i.PaidAt,
-		&i.UtmSource,
-		&i.UtmMedium,
-		&i.UtmCampaign,
-		&i.Referrer,
-		&i.IpHash,
-		&i.UserAgent,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type DeleteExpiredAICacheParams struct {
+	Cutoff    time.Time `db:"cutoff" json:"cutoff"`
+	BatchSize int32     `db:"batch_size" json:"batch_size"`
 }
 
-const getSessionByStripePI = `-- name: GetSessionByStripePI :one
-SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at FROM sessions WHERE stripe_payment_intent = $1 LIMIT 1
-`
-
-func (q *Queries) GetSessionByStripePI(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error) {
-	row := q.queryRow(ctx, q.getSessionByStripePIStmt, getSessionByStripePI, stripePaymentIntent)
-	var i Session
-	err := row.Scan(
-		&i.ID,
-		&i.AnonToken,
-		&i.Email,
-		&i.BizName,
-		&i.Industry,
-		&i.Stage,
-		&i.StripeCustomerID,
-		&i.StripePaymentIntent,
-		&i.PaymentStatus,
-		&i.PaidAt,
-		&i.UtmSource,
-		&i.UtmMedium,
-		&i.UtmCampaign,
-		&i.Referrer,
-		&i.IpHash,
-		&i.UserAgent,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) DeleteExpiredAICache(ctx context.Context, arg DeleteExpiredAICacheParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredAICacheStmt, deleteExpiredAICache, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getUnprocessedStripeEvents = `-- name: GetUnprocessedStripeEvents :many
-SELECT stripe_event_id, type, payload, processed, processed_at, error, received_at FROM stripe_events
-WHERE processed = FALSE
-  AND received_at > now() - INTERVAL '24 hours'
-ORDER BY received_at
+const deleteExpiredAITranscripts = `-- name: DeleteExpiredAITranscripts :many
+DELETE FROM ai_transcripts
+WHERE id IN (
+    SELECT id FROM ai_transcripts
+    WHERE created_at < $1::timestamptz
+    LIMIT $2::int
+)
+RETURNING storage_key
 `
 
-func (q *Queries) GetUnprocessedStripeEvents(ctx context.Context) ([]StripeEvent, error) {
-	rows, err := q.query(ctx, q.getUnprocessedStripeEventsStmt, getUnprocessedStripeEvents)
+type DeleteExpiredAITranscriptsParams struct {
+	Cutoff    time.Time `db:"cutoff" json:"cutoff"`
+	BatchSize int32     `db:"batch_size" json:"batch_size"`
+}
+
+// Returns the object storage keys of the deleted rows, for removal from the
+// bucket.
+func (q *Queries) DeleteExpiredAITranscripts(ctx context.Context, arg DeleteExpiredAITranscriptsParams) ([]sql.NullString, error) {
+	rows, err := q.query(ctx, q.deleteExpiredAITranscriptsStmt, deleteExpiredAITranscripts, arg.Cutoff, arg.BatchSize)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []StripeEvent{}
+	items := []sql.NullString{}
 	for rows.Next() {
-		var i StripeEvent
-		if err := rows.Scan(
-			&i.StripeEventID,
-			&i.Type,
-			&i.Payload,
-			&i.Processed,
-			&i.ProcessedAt,
-			&i.Error,
-			&i.ReceivedAt,
-		); err != nil {
+		var storageKey sql.NullString
+		if err := rows.Scan(&storageKey); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, storageKey)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -787,90 +1050,163 @@ func (q *Queries) GetUnprocessedStripeEvents(ctx context.Context) ([]StripeEvent
 	return items, nil
 }
 
-const getWatchAndRedRisks = `-- name: GetWatchAndRedRisks :many
-SELECT id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section FROM risk_results
-WHERE report_id = $1 AND tier IN ('watch', 'red')
-ORDER BY score DESC
+const deleteExpiredAnswers = `-- name: DeleteExpiredAnswers :execrows
+DELETE FROM answers
+WHERE id IN (
+    SELECT a.id FROM answers a
+    JOIN sessions s ON s.id = a.session_id
+    WHERE s.updated_at < $1::timestamptz
+      AND NOT EXISTS (
+          SELECT 1 FROM reports r
+          WHERE r.session_id = s.id AND r.updated_at >= $1::timestamptz
+      )
+    LIMIT $2::int
+)
 `
 
-func (q *Queries) GetWatchAndRedRisks(ctx context.Context, reportID uuid.UUID) ([]RiskResult, error) {
-	rows, err := q.query(ctx, q.getWatchAndRedRisksStmt, getWatchAndRedRisks, reportID)
+type DeleteExpiredAnswersParams struct {
+	Cutoff    time.Time `db:"cutoff" json:"cutoff"`
+	BatchSize int32     `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) DeleteExpiredAnswers(ctx context.Context, arg DeleteExpiredAnswersParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredAnswersStmt, deleteExpiredAnswers, arg.Cutoff, arg.BatchSize)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []RiskResult{}
-	for rows.Next() {
-		var i RiskResult
-		if err := rows.Scan(
-			&i.ID,
-			&i.ReportID,
-			&i.QuestionID,
-			&i.Rank,
-			&i.RiskName,
-			&i.RiskDesc,
-			&i.Probability,
-			&i.Impact,
-			&i.Score,
-			&i.Tier,
-			&i.Hedge,
-			&i.AiHedge,
-			&i.Section,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const deleteExpiredEmailLog = `-- name: DeleteExpiredEmailLog :execrows
+DELETE FROM email_log
+WHERE id IN (
+    SELECT id FROM email_log
+    WHERE created_at < $1::timestamptz
+    LIMIT $2::int
+)
+`
+
+type DeleteExpiredEmailLogParams struct {
+	Cutoff    time.Time `db:"cutoff" json:"cutoff"`
+	BatchSize int32     `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) DeleteExpiredEmailLog(ctx context.Context, arg DeleteExpiredEmailLogParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredEmailLogStmt, deleteExpiredEmailLog, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const deleteExpiredStripeEvents = `-- name: DeleteExpiredStripeEvents :execrows
+DELETE FROM stripe_events
+WHERE stripe_event_id IN (
+    SELECT stripe_event_id FROM stripe_events
+    WHERE processed AND received_at < $1::timestamptz
+    LIMIT $2::int
+)
+`
+
+type DeleteExpiredStripeEventsParams struct {
+	Cutoff    time.Time `db:"cutoff" json:"cutoff"`
+	BatchSize int32     `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) DeleteExpiredStripeEvents(ctx context.Context, arg DeleteExpiredStripeEventsParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredStripeEventsStmt, deleteExpiredStripeEvents, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const insertRiskResult = `-- name: InsertRiskResult :one
+const deleteFeatureFlag = `-- name: DeleteFeatureFlag :execrows
+DELETE FROM feature_flags WHERE name = $1 AND environment = $2
+`
 
-INSERT INTO risk_results (
-    report_id, question_id, rank, risk_name, risk_desc,
-    probability, impact, score, tier, hedge, section
-)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section
+type DeleteFeatureFlagParams struct {
+	Name        string `db:"name" json:"name"`
+	Environment string `db:"environment" json:"environment"`
+}
+
+func (q *Queries) DeleteFeatureFlag(ctx context.Context, arg DeleteFeatureFlagParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteFeatureFlagStmt, deleteFeatureFlag, arg.Name, arg.Environment)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFinishedJobs = `-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE status <> 'pending'
+  AND finished_at < $1::timestamptz
 `
 
-type InsertRiskResultParams struct {
-	ReportID    uuid.UUID `db:"report_id" json:"report_id"`
-	QuestionID  string    `db:"question_id" json:"question_id"`
-	Rank        int16     `db:"rank" json:"rank"`
-	RiskName    string    `db:"risk_name" json:"risk_name"`
-	RiskDesc    string    `db:"risk_desc" json:"risk_desc"`
-	Probability int16     `db:"probability" json:"probability"`
-	Impact      int16     `db:"impact" json:"impact"`
-	Score       int16     `db:"score" json:"score"`
-	Tier        RiskTier  `db:"tier" json:"tier"`
-	Hedge       string    `db:"hedge" json:"hedge"`
-	Section     string    `db:"section" json:"section"`
+func (q *Queries) DeleteFinishedJobs(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteFinishedJobsStmt, deleteFinishedJobs, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-// ---------------------------------------------------------------------------
-// RISK RESULTS
-// ---------------------------------------------------------------------------
-func (q *Queries) InsertRiskResult(ctx context.Context, arg InsertRiskResultParams) (RiskResult, error) {
-	row := q.queryRow(ctx, q.insertRiskResultStmt, insertRiskResult,
-		arg.ReportID,
-		arg.QuestionID,
-		arg.Rank,
-		arg.RiskName,
-		arg.RiskDesc,
-		arg.Probability,
-		arg.Impact,
-		arg.Score,
-		arg.Tier,
-		arg.Hedge,
-		arg.Section,
-	)
+const deletePlaybookSnippet = `-- name: DeletePlaybookSnippet :execrows
+DELETE FROM playbook_snippets WHERE slug = $1
+`
+
+func (q *Queries) DeletePlaybookSnippet(ctx context.Context, slug string) (int64, error) {
+	result, err := q.exec(ctx, q.deletePlaybookSnippetStmt, deletePlaybookSnippet, slug)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRiskResultsByReport = `-- name: DeleteRiskResultsByReport :execrows
+DELETE FROM risk_results WHERE report_id = $1
+`
+
+func (q *Queries) DeleteRiskResultsByReport(ctx context.Context, reportID uuid.UUID) (int64, error) {
+	result, err := q.exec(ctx, q.deleteRiskResultsByReportStmt, deleteRiskResultsByReport, reportID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRuntimeSetting = `-- name: DeleteRuntimeSetting :execrows
+DELETE FROM runtime_settings WHERE key = $1
+`
+
+func (q *Queries) DeleteRuntimeSetting(ctx context.Context, key string) (int64, error) {
+	result, err := q.exec(ctx, q.deleteRuntimeSettingStmt, deleteRuntimeSetting, key)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const editAIHedge = `-- name: EditAIHedge :one
+
+UPDATE risk_results
+SET ai_hedge           = $2,
+    ai_hedge_edited_at = now()
+WHERE id = $1
+RETURNING id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section, ai_hedge_edited_at, explanation
+`
+
+type EditAIHedgeParams struct {
+	ID      uuid.UUID      `db:"id" json:"id"`
+	AiHedge sql.NullString `db:"ai_hedge" json:"ai_hedge"`
+}
+
+// ---------------------------------------------------------------------------
+// AI EDITS
+// ---------------------------------------------------------------------------
+func (q *Queries) EditAIHedge(ctx context.Context, arg EditAIHedgeParams) (RiskResult, error) {
+	row := q.queryRow(ctx, q.editAIHedgeStmt, editAIHedge, arg.ID, arg.AiHedge)
 	var i RiskResult
 	err := row.Scan(
 		&i.ID,
@@ -886,331 +1222,4826 @@ func (q *Queries) InsertRiskResult(ctx context.Context, arg InsertRiskResultPara
 		&i.Hedge,
 		&i.AiHedge,
 		&i.Section,
+		&i.AiHedgeEditedAt,
+		&i.Explanation,
 	)
 	return i, err
 }
 
-const listPendingReports = `-- name: ListPendingReports :many
-SELECT id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at FROM reports
-WHERE status IN ('draft', 'processing')
-  AND created_at > now() - INTERVAL '1 day'
-ORDER BY created_at
-`
-
-// Used by the background worker to pick up unprocessed reports.
-func (q *Queries) ListPendingReports(ctx context.Context) ([]Report, error) {
-	rows, err := q.query(ctx, q.listPendingReportsStmt, listPendingReports)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Report{}
-	for rows.Next() {
-		var i Report
-		if err := rows.Scan(
-			&i.ID,
-			&i.SessionID,
-			&i.Status,
-			&i.ErrorMessage,
-			&i.OverallScore,
-			&i.CriticalCount,
-			&i.RisksJson,
-			&i.ExecutiveSummary,
-			&i.TopPriorityHtml,
-			&i.AccessToken,
-			&i.GeneratedAt,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const logEmail = `-- name: LogEmail :one
-
-INSERT INTO email_log (session_id, report_id, to_address, subject, template, provider_id, sent_at)
-VALUES ($1, $2, $3, $4, $5, $6, now())
-RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at
+const editExecutiveSummary = `-- name: EditExecutiveSummary :one
+UPDATE reports
+SET executive_summary           = $2,
+    executive_summary_edited_at = now()
+WHERE id = $1
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
 `
 
-type LogEmailParams struct {
-	SessionID  uuid.NullUUID  `db:"session_id" json:"session_id"`
-	ReportID   uuid.NullUUID  `db:"report_id" json:"report_id"`
-	ToAddress  string         `db:"to_address" json:"to_address"`
-	Subject    string         `db:"subject" json:"subject"`
-	Template   string         `db:"template" json:"template"`
-	ProviderID sql.NullString `db:"provider_id" json:"provider_id"`
+type EditExecutiveSummaryParams struct {
+	ID               uuid.UUID      `db:"id" json:"id"`
+	ExecutiveSummary sql.NullString `db:"executive_summary" json:"executive_summary"`
 }
 
-// ---------------------------------------------------------------------------
-// EMAIL LOG
-// ---------------------------------------------------------------------------
-func (q *Queries) LogEmail(ctx context.Context, arg LogEmailParams) (EmailLog, error) {
-	row := q.queryRow(ctx, q.logEmailStmt, logEmail,
-		arg.SessionID,
-		arg.ReportID,
-		arg.ToAddress,
-		arg.Subject,
-		arg.Template,
-		arg.ProviderID,
-	)
-	var i EmailLog
+func (q *Queries) EditExecutiveSummary(ctx context.Context, arg EditExecutiveSummaryParams) (Report, error) {
+	row := q.queryRow(ctx, q.editExecutiveSummaryStmt, editExecutiveSummary, arg.ID, arg.ExecutiveSummary)
+	var i Report
 	err := row.Scan(
 		&i.ID,
 		&i.SessionID,
-		&i.ReportID,
-		&i.ToAddress,
-		&i.Subject,
-		&i.Template,
-		&i.ProviderID,
-		&i.SentAt,
-		&i.OpenedAt,
-		&i.Error,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
 	)
 	return i, err
 }
 
-const markEmailOpened = `-- name: MarkEmailOpened :one
-UPDATE email_log SET opened_at = now() WHERE provider_id = $1 RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at
+const failJob = `-- name: FailJob :exec
+UPDATE jobs
+SET status           = 'failed',
+    finished_at      = now(),
+    claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = $1
 `
 
-func (q *Queries) MarkEmailOpened(ctx context.Context, providerID sql.NullString) (EmailLog, error) {
-	row := q.queryRow(ctx, q.markEmailOpenedStmt, markEmailOpened, providerID)
-	var i EmailLog
-	err := row.Scan(
-		&i.ID,
-		&i.SessionID,
-		&i.ReportID,
-		&i.ToAddress,
-		&i.Subject,
-		&i.Template,
-		&i.ProviderID,
-		&i.SentAt,
-		&i.OpenedAt,
-		&i.Error,
-		&i.CreatedAt,
-	)
-	return i, err
+// Gives up on a job after its last attempt; RecordJobAttempt has its error.
+func (q *Queries) FailJob(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, q.failJobStmt, failJob, id)
+	return err
 }
 
-const markSessionPaid = `-- name: MarkSessionPaid :one
-UPDATE sessions
-SET payment_status = 'paid',
-    paid_at        = now()
-WHERE stripe_payment_intent = $1
-RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at
+const finalizeReport = `-- name: FinalizeReport :one
+UPDATE reports
+SET status          = 'ready',
+    overall_score   = $2,
+    critical_count  = $3,
+    risks_json      = $4,
+    executive_summary = $5,
+    top_priority_html = $6,
+    ai_analysis     = $7,
+    ai_narrative    = $8,
+    relationships   = $9,
+    ai_quality_issues = $10,
+    ai_budget_note  = $11,
+    flags           = $12,
+    skipped_questions = $13,
+    executive_summary_edited_at = NULL,
+    job_state       = NULL,
+    generated_at    = now()
+WHERE id = $1
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
 `
 
-func (q *Queries) MarkSessionPaid(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error) {
-	row := q.queryRow(ctx, q.markSessionPaidStmt, markSessionPaid, stripePaymentIntent)
-	var i Session
+type FinalizeReportParams struct {
+	ID               uuid.UUID             `db:"id" json:"id"`
+	OverallScore     sql.NullInt16         `db:"overall_score" json:"overall_score"`
+	CriticalCount    sql.NullInt16         `db:"critical_count" json:"critical_count"`
+	RisksJson        pqtype.NullRawMessage `db:"risks_json" json:"risks_json"`
+	ExecutiveSummary sql.NullString        `db:"executive_summary" json:"executive_summary"`
+	TopPriorityHtml  sql.NullString        `db:"top_priority_html" json:"top_priority_html"`
+	AiAnalysis       pqtype.NullRawMessage `db:"ai_analysis" json:"ai_analysis"`
+	AiNarrative      pqtype.NullRawMessage `db:"ai_narrative" json:"ai_narrative"`
+	Relationships    pqtype.NullRawMessage `db:"relationships" json:"relationships"`
+	AiQualityIssues  sql.NullString        `db:"ai_quality_issues" json:"ai_quality_issues"`
+	AiBudgetNote     sql.NullString        `db:"ai_budget_note" json:"ai_budget_note"`
+	Flags            pqtype.NullRawMessage `db:"flags" json:"flags"`
+	SkippedQuestions []string              `db:"skipped_questions" json:"skipped_questions"`
+}
+
+func (q *Queries) FinalizeReport(ctx context.Context, arg FinalizeReportParams) (Report, error) {
+	row := q.queryRow(ctx, q.finalizeReportStmt, finalizeReport,
+		arg.ID,
+		arg.OverallScore,
+		arg.CriticalCount,
+		arg.RisksJson,
+		arg.ExecutiveSummary,
+		arg.TopPriorityHtml,
+		arg.AiAnalysis,
+		arg.AiNarrative,
+		arg.Relationships,
+		arg.AiQualityIssues,
+		arg.AiBudgetNote,
+		arg.Flags,
+		pq.Array(arg.SkippedQuestions),
+	)
+	var i Report
 	err := row.Scan(
 		&i.ID,
-		&i.AnonToken,
-		&i.Email,
-		&i.BizName,
-		&i.Industry,
-		&i.Stage,
-		&i.StripeCustomerID,
-		&i.StripePaymentIntent,
-		&i.PaymentStatus,
-		&i.PaidAt,
-		&i.UtmSource,
-		&i.UtmMedium,
-		&i.UtmCampaign,
-		&i.Referrer,
-		&i.IpHash,
-		&i.UserAgent,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
 	)
 	return i, err
 }
 
-const markSessionPaymentFailed = `-- name: MarkSessionPaymentFailed :one
-UPDATE sessions
-SET payment_status = 'failed'
-WHERE stripe_payment_intent = $1
-RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at
+const getAICacheEntry = `-- name: GetAICacheEntry :one
+
+UPDATE ai_cache
+SET hit_count = hit_count + 1, last_hit_at = now()
+WHERE fingerprint = $1
+  AND created_at > $2::timestamptz
+RETURNING fingerprint, hedges, executive_summary, top_priority_html, hit_count, created_at, last_hit_at, analysis, relationships
 `
 
-func (q *Queries) MarkSessionPaymentFailed(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error) {
-	row := q.queryRow(ctx, q.markSessionPaymentFailedStmt, markSessionPaymentFailed, stripePaymentIntent)
-	var i Session
+type GetAICacheEntryParams struct {
+	Fingerprint string    `db:"fingerprint" json:"fingerprint"`
+	NotBefore   time.Time `db:"not_before" json:"not_before"`
+}
+
+// ---------------------------------------------------------------------------
+// AI CACHE
+// ---------------------------------------------------------------------------
+// Returns the entry only if it was generated after not_before, and records
+// the hit so cache effectiveness can be measured.
+func (q *Queries) GetAICacheEntry(ctx context.Context, arg GetAICacheEntryParams) (AiCache, error) {
+	row := q.queryRow(ctx, q.getAICacheEntryStmt, getAICacheEntry, arg.Fingerprint, arg.NotBefore)
+	var i AiCache
 	err := row.Scan(
-		&i.ID,
-		&i.AnonToken,
-		&i.Email,
-		&i.BizName,
-		&i.Industry,
-		&i.Stage,
-		&i.StripeCustomerID,
-		&i.StripePaymentIntent,
-		&i.PaymentStatus,
-		&i.PaidAt,
-		&i.UtmSource,
-		&i.UtmMedium,
-		&i.UtmCampaign,
-		&i.Referrer,
-		&i.IpHash,
-		&i.UserAgent,
+		&i.Fingerprint,
+		&i.Hedges,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.HitCount,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.LastHitAt,
+		&i.Analysis,
+		&i.Relationships,
 	)
 	return i, err
 }
 
-const markStripeEventFailed = `-- name: MarkStripeEventFailed :one
-UPDATE stripe_events
-SET processed    = FALSE,
-    error        = $2
-WHERE stripe_event_id = $1
-RETURNING stripe_event_id, type, payload, processed, processed_at, error, received_at
+const getAllQuestionDefinitions = `-- name: GetAllQuestionDefinitions :many
+
+SELECT id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at FROM question_definitions
+ORDER BY section_id, display_order
 `
 
-type MarkStripeEventFailedParams struct {
-	StripeEventID string         `db:"stripe_event_id" json:"stripe_event_id"`
-	Error         sql.NullString `db:"error" json:"error"`
-}
+// ---------------------------------------------------------------------------
+// QUESTION DEFINITIONS
+// ---------------------------------------------------------------------------
+func (q *Queries) GetAllQuestionDefinitions(ctx context.Context) ([]QuestionDefinition, error) {
+	rows, err := q.query(ctx, q.getAllQuestionDefinitionsStmt, getAllQuestionDefinitions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QuestionDefinition{}
+	for rows.Next() {
+		var i QuestionDefinition
+		if err := rows.Scan(
+			&i.ID,
+			&i.QuestionVersion,
+			&i.SectionID,
+			&i.SectionTitle,
+			&i.DisplayOrder,
+			&i.Text,
+			&i.Subtext,
+			&i.Type,
+			pq.Array(&i.Opts),
+			&i.Placeholder,
+			&i.Required,
+			&i.RiskName,
+			&i.RiskDesc,
+			&i.Hedge,
+			&i.ScoringConfig,
+			&i.IsScoring,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
-func (q *Queries) MarkStripeEventFailed(ctx context.Context, arg MarkStripeEventFailedParams) (StripeEvent, error) {
-	row := q.queryRow(ctx, q.markStripeEventFailedStmt, markStripeEventFailed, arg.StripeEventID, arg.Error)
-	var i StripeEvent
+const getAnswersBySession = `-- name: GetAnswersBySession :many
+SELECT a.id, a.session_id, a.question_id, a.answer_text, a.client_p, a.client_i, a.answered_at, a.updated_at, a.skipped, qd.section_id, qd.risk_name, qd.risk_desc, qd.hedge, qd.scoring_config, qd.is_scoring
+FROM answers a
+JOIN question_definitions qd ON qd.id = a.question_id
+WHERE a.session_id = $1
+ORDER BY qd.display_order
+`
+
+type GetAnswersBySessionRow struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	SessionID     uuid.UUID       `db:"session_id" json:"session_id"`
+	QuestionID    string          `db:"question_id" json:"question_id"`
+	AnswerText    string          `db:"answer_text" json:"answer_text"`
+	ClientP       sql.NullInt16   `db:"client_p" json:"client_p"`
+	ClientI       sql.NullInt16   `db:"client_i" json:"client_i"`
+	AnsweredAt    time.Time       `db:"answered_at" json:"answered_at"`
+	UpdatedAt     time.Time       `db:"updated_at" json:"updated_at"`
+	Skipped       bool            `db:"skipped" json:"skipped"`
+	SectionID     SectionID       `db:"section_id" json:"section_id"`
+	RiskName      string          `db:"risk_name" json:"risk_name"`
+	RiskDesc      string          `db:"risk_desc" json:"risk_desc"`
+	Hedge         string          `db:"hedge" json:"hedge"`
+	ScoringConfig json.RawMessage `db:"scoring_config" json:"scoring_config"`
+	IsScoring     bool            `db:"is_scoring" json:"is_scoring"`
+}
+
+func (q *Queries) GetAnswersBySession(ctx context.Context, sessionID uuid.UUID) ([]GetAnswersBySessionRow, error) {
+	rows, err := q.query(ctx, q.getAnswersBySessionStmt, getAnswersBySession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAnswersBySessionRow{}
+	for rows.Next() {
+		var i GetAnswersBySessionRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.QuestionID,
+			&i.AnswerText,
+			&i.ClientP,
+			&i.ClientI,
+			&i.AnsweredAt,
+			&i.UpdatedAt,
+			&i.Skipped,
+			&i.SectionID,
+			&i.RiskName,
+			&i.RiskDesc,
+			&i.Hedge,
+			&i.ScoringConfig,
+			&i.IsScoring,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCohortScheduleEnd = `-- name: GetCohortScheduleEnd :one
+SELECT GREATEST(MAX(not_before), now())::timestamptz AS schedule_end
+FROM reports
+WHERE status IN ('draft', 'processing')
+  AND not_before > now()
+`
+
+// The latest not_before of the imported reports still waiting, or now when
+// none are, so a new import is scheduled after the ones before it.
+func (q *Queries) GetCohortScheduleEnd(ctx context.Context) (time.Time, error) {
+	row := q.queryRow(ctx, q.getCohortScheduleEndStmt, getCohortScheduleEnd)
+	var scheduleEnd time.Time
+	err := row.Scan(&scheduleEnd)
+	return scheduleEnd, err
+}
+
+const getCompletionFunnelStats = `-- name: GetCompletionFunnelStats :one
+SELECT
+    COUNT(*)                                                        AS total_sessions,
+    COUNT(*) FILTER (WHERE (SELECT COUNT(*) FROM answers a WHERE a.session_id = s.id) > 0) AS started,
+    COUNT(*) FILTER (WHERE payment_status = 'paid')                AS paid,
+    COUNT(*) FILTER (WHERE payment_status = 'paid' AND EXISTS (
+        SELECT 1 FROM reports r WHERE r.session_id = s.id AND r.status = 'ready'
+    ))                                                              AS report_delivered
+FROM sessions s
+WHERE s.cohort IS NULL
+`
+
+type GetCompletionFunnelStatsRow struct {
+	TotalSessions   int64 `db:"total_sessions" json:"total_sessions"`
+	Started         int64 `db:"started" json:"started"`
+	Paid            int64 `db:"paid" json:"paid"`
+	ReportDelivered int64 `db:"report_delivered" json:"report_delivered"`
+}
+
+// Imported cohorts never went through the funnel and are left out.
+func (q *Queries) GetCompletionFunnelStats(ctx context.Context) (GetCompletionFunnelStatsRow, error) {
+	row := q.queryRow(ctx, q.getCompletionFunnelStatsStmt, getCompletionFunnelStats)
+	var i GetCompletionFunnelStatsRow
 	err := row.Scan(
-		&i.StripeEventID,
-		&i.Type,
-		&i.Payload,
-		&i.Processed,
-		&i.ProcessedAt,
-		&i.Error,
-		&i.ReceivedAt,
+		&i.TotalSessions,
+		&i.Started,
+		&i.Paid,
+		&i.ReportDelivered,
 	)
 	return i, err
 }
 
-const markStripeEventProcessed = `-- name: MarkStripeEventProcessed :one
-UPDATE stripe_events
-SET processed    = TRUE,
-    processed_at = now()
-WHERE stripe_event_id = $1
-RETURNING stripe_event_id, type, payload, processed, processed_at, error, received_at
+const getConsultationStats = `-- name: GetConsultationStats :one
+SELECT
+    (SELECT COUNT(*) FROM reports WHERE status = 'ready')::bigint               AS reports_delivered,
+    (SELECT COUNT(*) FROM consultation_requests)::bigint                        AS requests,
+    (SELECT COUNT(*) FROM reports
+      WHERE status = 'ready' AND generated_at >= now() - INTERVAL '30 days')::bigint AS reports_delivered_30d,
+    (SELECT COUNT(*) FROM consultation_requests
+      WHERE created_at >= now() - INTERVAL '30 days')::bigint                    AS requests_30d
 `
 
-func (q *Queries) MarkStripeEventProcessed(ctx context.Context, stripeEventID string) (StripeEvent, error) {
-	row := q.queryRow(ctx, q.markStripeEventProcessedStmt, markStripeEventProcessed, stripeEventID)
-	var i StripeEvent
+type GetConsultationStatsRow struct {
+	ReportsDelivered    int64 `db:"reports_delivered" json:"reports_delivered"`
+	Requests            int64 `db:"requests" json:"requests"`
+	ReportsDelivered30d int64 `db:"reports_delivered_30d" json:"reports_delivered_30d"`
+	Requests30d         int64 `db:"requests_30d" json:"requests_30d"`
+}
+
+// Conversion from delivered report to consultation request, overall and for
+// the last 30 days.
+func (q *Queries) GetConsultationStats(ctx context.Context) (GetConsultationStatsRow, error) {
+	row := q.queryRow(ctx, q.getConsultationStatsStmt, getConsultationStats)
+	var i GetConsultationStatsRow
 	err := row.Scan(
-		&i.StripeEventID,
-		&i.Type,
-		&i.Payload,
-		&i.Processed,
-		&i.ProcessedAt,
+		&i.ReportsDelivered,
+		&i.Requests,
+		&i.ReportsDelivered30d,
+		&i.Requests30d,
+	)
+	return i, err
+}
+
+const getDailyRevenue = `-- name: GetDailyRevenue :many
+SELECT
+    DATE(s.paid_at)     AS day,
+    COUNT(*)            AS sales,
+    SUM(COALESCE(s.subtotal_cents, p.price_cents))::bigint AS revenue_cents,
+    SUM(COALESCE(s.tax_cents, 0))::bigint                  AS tax_cents
+FROM sessions s
+JOIN products p ON p.sku = COALESCE(s.product_sku, 'standard')
+WHERE s.payment_status = 'paid'
+  AND s.subscription_id IS NULL
+  AND s.cohort IS NULL
+  AND s.paid_at >= now() - INTERVAL '30 days'
+GROUP BY DATE(s.paid_at)
+ORDER BY day DESC
+`
+
+type GetDailyRevenueRow struct {
+	Day          time.Time `db:"day" json:"day"`
+	Sales        int64     `db:"sales" json:"sales"`
+	RevenueCents int64     `db:"revenue_cents" json:"revenue_cents"`
+	TaxCents     int64     `db:"tax_cents" json:"tax_cents"`
+}
+
+// Revenue excluding tax. Sessions checked out before subtotals were recorded
+// count at the product's current catalog price, or the standard price if they
+// predate the catalog too. Reports covered by a subscription are excluded; the
+// subscription's invoices are in Stripe. So are imported cohorts, which are
+// billed under their B2B deal.
+func (q *Queries) GetDailyRevenue(ctx context.Context) ([]GetDailyRevenueRow, error) {
+	rows, err := q.query(ctx, q.getDailyRevenueStmt, getDailyRevenue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetDailyRevenueRow{}
+	for rows.Next() {
+		var i GetDailyRevenueRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Sales,
+			&i.RevenueCents,
+			&i.TaxCents,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDuplicateCredit = `-- name: GetDuplicateCredit :one
+SELECT d.session_id
+FROM duplicate_purchases d
+JOIN sessions s ON s.id = d.session_id
+WHERE d.resolution = 'credit'
+  AND s.email_hash = $1::text
+  AND COALESCE(s.product_sku, 'standard') = $2::text
+  AND NOT EXISTS (SELECT 1 FROM sessions c WHERE c.credit_from_session_id = d.session_id)
+ORDER BY d.resolved_at
+LIMIT 1
+`
+
+type GetDuplicateCreditParams struct {
+	Email      string `db:"email" json:"email"`
+	ProductSku string `db:"product_sku" json:"product_sku"`
+}
+
+// The oldest duplicate purchase kept as credit for this email and product
+// that has not paid for a session yet. The store's codec replaces email with
+// its blind index.
+func (q *Queries) GetDuplicateCredit(ctx context.Context, arg GetDuplicateCreditParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.getDuplicateCreditStmt, getDuplicateCredit, arg.Email, arg.ProductSku)
+	var sessionID uuid.UUID
+	err := row.Scan(&sessionID)
+	return sessionID, err
+}
+
+const getDuplicatePurchase = `-- name: GetDuplicatePurchase :one
+SELECT d.session_id, d.original_session_id, d.changed_answers, d.resolution, d.stripe_refund_id, d.resolved_at, d.created_at, s.stripe_payment_intent, r.id AS report_id
+FROM duplicate_purchases d
+JOIN sessions s ON s.id = d.session_id
+JOIN reports  r ON r.session_id = d.session_id
+WHERE d.session_id = $1
+`
+
+type GetDuplicatePurchaseRow struct {
+	SessionID           uuid.UUID      `db:"session_id" json:"session_id"`
+	OriginalSessionID   uuid.UUID      `db:"original_session_id" json:"original_session_id"`
+	ChangedAnswers      int32          `db:"changed_answers" json:"changed_answers"`
+	Resolution          sql.NullString `db:"resolution" json:"resolution"`
+	StripeRefundID      sql.NullString `db:"stripe_refund_id" json:"stripe_refund_id"`
+	ResolvedAt          sql.NullTime   `db:"resolved_at" json:"resolved_at"`
+	CreatedAt           time.Time      `db:"created_at" json:"created_at"`
+	StripePaymentIntent sql.NullString `db:"stripe_payment_intent" json:"stripe_payment_intent"`
+	ReportID            uuid.UUID      `db:"report_id" json:"report_id"`
+}
+
+func (q *Queries) GetDuplicatePurchase(ctx context.Context, sessionID uuid.UUID) (GetDuplicatePurchaseRow, error) {
+	row := q.queryRow(ctx, q.getDuplicatePurchaseStmt, getDuplicatePurchase, sessionID)
+	var i GetDuplicatePurchaseRow
+	err := row.Scan(
+		&i.SessionID,
+		&i.OriginalSessionID,
+		&i.ChangedAnswers,
+		&i.Resolution,
+		&i.StripeRefundID,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.StripePaymentIntent,
+		&i.ReportID,
+	)
+	return i, err
+}
+
+const getEarlierCardPurchase = `-- name: GetEarlierCardPurchase :one
+
+SELECT s.id,
+       s.paid_at,
+       (
+           SELECT COUNT(DISTINCT d.question_id)
+           FROM (
+               (SELECT a.question_id, a.answer_text FROM answers a WHERE a.session_id = s.id
+                EXCEPT
+                SELECT b.question_id, b.answer_text FROM answers b WHERE b.session_id = $1::uuid)
+               UNION ALL
+               (SELECT b.question_id, b.answer_text FROM answers b WHERE b.session_id = $1::uuid
+                EXCEPT
+                SELECT a.question_id, a.answer_text FROM answers a WHERE a.session_id = s.id)
+           ) d
+       )::int AS changed_answers
+FROM sessions s
+WHERE s.email_hash = $2::text
+  AND s.id <> $1::uuid
+  AND s.payment_status = 'paid'
+  AND s.stripe_payment_intent IS NOT NULL
+  AND s.paid_at >= $3::timestamptz
+  AND NOT EXISTS (SELECT 1 FROM duplicate_purchases dp WHERE dp.session_id = s.id)
+ORDER BY changed_answers, s.paid_at DESC
+LIMIT 1
+`
+
+type GetEarlierCardPurchaseParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	EmailHash string    `db:"email_hash" json:"email_hash"`
+	Since     time.Time `db:"since" json:"since"`
+}
+
+type GetEarlierCardPurchaseRow struct {
+	ID             uuid.UUID    `db:"id" json:"id"`
+	PaidAt         sql.NullTime `db:"paid_at" json:"paid_at"`
+	ChangedAnswers int32        `db:"changed_answers" json:"changed_answers"`
+}
+
+// ---------------------------------------------------------------------------
+// DUPLICATE PURCHASES
+// ---------------------------------------------------------------------------
+// The other card-paid session for email_hash paid since `since` whose answers
+// are closest to session id's, with how many questions were answered
+// differently or only in one of them. Sessions that are themselves
+// duplicates are not candidates.
+func (q *Queries) GetEarlierCardPurchase(ctx context.Context, arg GetEarlierCardPurchaseParams) (GetEarlierCardPurchaseRow, error) {
+	row := q.queryRow(ctx, q.getEarlierCardPurchaseStmt, getEarlierCardPurchase, arg.ID, arg.EmailHash, arg.Since)
+	var i GetEarlierCardPurchaseRow
+	err := row.Scan(&i.ID, &i.PaidAt, &i.ChangedAnswers)
+	return i, err
+}
+
+const getEmailByDedupeKey = `-- name: GetEmailByDedupeKey :one
+SELECT id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of FROM email_log WHERE dedupe_key = $1
+`
+
+func (q *Queries) GetEmailByDedupeKey(ctx context.Context, dedupeKey sql.NullString) (EmailLog, error) {
+	row := q.queryRow(ctx, q.getEmailByDedupeKeyStmt, getEmailByDedupeKey, dedupeKey)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
 		&i.Error,
-		&i.ReceivedAt,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
 	)
 	return i, err
 }
 
-const setAIHedge = `-- name: SetAIHedge :one
-UPDATE risk_results
-SET ai_hedge = $2
-WHERE id = $1
-RETURNING id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section
+const getEntitledSubscription = `-- name: GetEntitledSubscription :one
+SELECT id, stripe_subscription_id, stripe_customer_id, email, status, current_period_start, current_period_end, created_at, updated_at, email_hash FROM subscriptions
+WHERE subscriptions.status IN ('active', 'trialing')
+  AND subscriptions.current_period_end > now()
+  AND (
+        subscriptions.email_hash = $1::text
+     OR subscriptions.stripe_customer_id IN (
+            SELECT sessions.stripe_customer_id FROM sessions
+            WHERE sessions.email_hash = $1::text
+              AND sessions.stripe_customer_id IS NOT NULL
+        )
+  )
+  AND NOT EXISTS (
+        SELECT 1 FROM sessions
+        WHERE sessions.subscription_id = subscriptions.id
+          AND sessions.paid_at >= subscriptions.current_period_start
+  )
+ORDER BY subscriptions.current_period_end DESC
+LIMIT 1
+`
+
+// Returns a live subscription for the email — matched directly or through the
+// Stripe customer on an earlier session — that has not yet covered a report
+// in its current billing period. The store's codec replaces email with its
+// blind index.
+func (q *Queries) GetEntitledSubscription(ctx context.Context, email string) (Subscription, error) {
+	row := q.queryRow(ctx, q.getEntitledSubscriptionStmt, getEntitledSubscription, email)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.StripeSubscriptionID,
+		&i.StripeCustomerID,
+		&i.Email,
+		&i.Status,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
+const getExperimentAssignments = `-- name: GetExperimentAssignments :many
+SELECT session_id, experiment, variant, assigned_at FROM experiment_assignments WHERE session_id = $1 ORDER BY experiment
+`
+
+func (q *Queries) GetExperimentAssignments(ctx context.Context, sessionID uuid.UUID) ([]ExperimentAssignment, error) {
+	rows, err := q.query(ctx, q.getExperimentAssignmentsStmt, getExperimentAssignments, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExperimentAssignment{}
+	for rows.Next() {
+		var i ExperimentAssignment
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.Experiment,
+			&i.Variant,
+			&i.AssignedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExperimentStats = `-- name: GetExperimentStats :many
+SELECT
+    ea.experiment,
+    ea.variant,
+    COUNT(*)                                                          AS sessions,
+    COUNT(*) FILTER (WHERE s.payment_status = 'paid')                 AS paid,
+    COUNT(r.id) FILTER (WHERE r.status = 'ready')                     AS reports,
+    COUNT(r.id) FILTER (WHERE r.status = 'ready'
+                          AND r.ai_quality_issues IS NOT NULL)        AS quality_rejected,
+    COALESCE(AVG(r.overall_score) FILTER (WHERE r.status = 'ready'), 0)::float8 AS mean_overall_score,
+    COUNT(c.id)                                                       AS consultations
+FROM experiment_assignments ea
+JOIN sessions s ON s.id = ea.session_id
+LEFT JOIN reports r ON r.session_id = s.id
+LEFT JOIN consultation_requests c ON c.report_id = r.id
+GROUP BY ea.experiment, ea.variant
+ORDER BY ea.experiment, ea.variant
+`
+
+type GetExperimentStatsRow struct {
+	Experiment       string  `db:"experiment" json:"experiment"`
+	Variant          string  `db:"variant" json:"variant"`
+	Sessions         int64   `db:"sessions" json:"sessions"`
+	Paid             int64   `db:"paid" json:"paid"`
+	Reports          int64   `db:"reports" json:"reports"`
+	QualityRejected  int64   `db:"quality_rejected" json:"quality_rejected"`
+	MeanOverallScore float64 `db:"mean_overall_score" json:"mean_overall_score"`
+	Consultations    int64   `db:"consultations" json:"consultations"`
+}
+
+// Conversion and report quality per variant of every experiment, stopped ones
+// included. A report counts as rejected when the AI quality gate threw its
+// narrative out.
+func (q *Queries) GetExperimentStats(ctx context.Context) ([]GetExperimentStatsRow, error) {
+	rows, err := q.query(ctx, q.getExperimentStatsStmt, getExperimentStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetExperimentStatsRow{}
+	for rows.Next() {
+		var i GetExperimentStatsRow
+		if err := rows.Scan(
+			&i.Experiment,
+			&i.Variant,
+			&i.Sessions,
+			&i.Paid,
+			&i.Reports,
+			&i.QualityRejected,
+			&i.MeanOverallScore,
+			&i.Consultations,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedbackByToken = `-- name: GetFeedbackByToken :one
+SELECT f.report_id, f.token, f.requested_at, f.rating, f.testimonial, f.consent_quote, f.consent_name, f.responded_at, f.approved_at, s.biz_name, s.email_hash
+FROM feedback f
+JOIN reports  r ON r.id = f.report_id
+JOIN sessions s ON s.id = r.session_id
+WHERE f.token = $1
+`
+
+type GetFeedbackByTokenRow struct {
+	ReportID     uuid.UUID      `db:"report_id" json:"report_id"`
+	Token        string         `db:"token" json:"token"`
+	RequestedAt  time.Time      `db:"requested_at" json:"requested_at"`
+	Rating       sql.NullInt16  `db:"rating" json:"rating"`
+	Testimonial  sql.NullString `db:"testimonial" json:"testimonial"`
+	ConsentQuote bool           `db:"consent_quote" json:"consent_quote"`
+	ConsentName  bool           `db:"consent_name" json:"consent_name"`
+	RespondedAt  sql.NullTime   `db:"responded_at" json:"responded_at"`
+	ApprovedAt   sql.NullTime   `db:"approved_at" json:"approved_at"`
+	BizName      sql.NullString `db:"biz_name" json:"biz_name"`
+	EmailHash    sql.NullString `db:"email_hash" json:"email_hash"`
+}
+
+func (q *Queries) GetFeedbackByToken(ctx context.Context, token string) (GetFeedbackByTokenRow, error) {
+	row := q.queryRow(ctx, q.getFeedbackByTokenStmt, getFeedbackByToken, token)
+	var i GetFeedbackByTokenRow
+	err := row.Scan(
+		&i.ReportID,
+		&i.Token,
+		&i.RequestedAt,
+		&i.Rating,
+		&i.Testimonial,
+		&i.ConsentQuote,
+		&i.ConsentName,
+		&i.RespondedAt,
+		&i.ApprovedAt,
+		&i.BizName,
+		&i.EmailHash,
+	)
+	return i, err
+}
+
+const getInvoiceByAccessToken = `-- name: GetInvoiceByAccessToken :one
+SELECT
+    r.id                        AS report_id,
+    s.id                        AS session_id,
+    s.payment_status,
+    s.paid_at,
+    s.subscription_id,
+    s.credit_from_session_id,
+    s.stripe_payment_intent,
+    s.email,
+    s.biz_name,
+    s.billing_name,
+    s.billing_address_line1,
+    s.billing_address_line2,
+    s.billing_city,
+    s.billing_postal_code,
+    s.billing_country,
+    s.billing_tax_id,
+    s.invoice_number,
+    r.revoked_at,
+    COALESCE(s.subtotal_cents, p.price_cents)::int AS subtotal_cents,
+    COALESCE(s.tax_cents, 0)::int                  AS tax_cents,
+    p.name                      AS product_name,
+    p.currency
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+JOIN products p ON p.sku = COALESCE(s.product_sku, 'standard')
+WHERE r.access_token = $1
+LIMIT 1
+`
+
+type GetInvoiceByAccessTokenRow struct {
+	ReportID            uuid.UUID      `db:"report_id" json:"report_id"`
+	SessionID           uuid.UUID      `db:"session_id" json:"session_id"`
+	PaymentStatus       PaymentStatus  `db:"payment_status" json:"payment_status"`
+	PaidAt              sql.NullTime   `db:"paid_at" json:"paid_at"`
+	SubscriptionID      uuid.NullUUID  `db:"subscription_id" json:"subscription_id"`
+	CreditFromSessionID uuid.NullUUID  `db:"credit_from_session_id" json:"credit_from_session_id"`
+	StripePaymentIntent sql.NullString `db:"stripe_payment_intent" json:"stripe_payment_intent"`
+	Email               sql.NullString `db:"email" json:"email"`
+	BizName             sql.NullString `db:"biz_name" json:"biz_name"`
+	BillingName         sql.NullString `db:"billing_name" json:"billing_name"`
+	BillingAddressLine1 sql.NullString `db:"billing_address_line1" json:"billing_address_line1"`
+	BillingAddressLine2 sql.NullString `db:"billing_address_line2" json:"billing_address_line2"`
+	BillingCity         sql.NullString `db:"billing_city" json:"billing_city"`
+	BillingPostalCode   sql.NullString `db:"billing_postal_code" json:"billing_postal_code"`
+	BillingCountry      sql.NullString `db:"billing_country" json:"billing_country"`
+	BillingTaxID        sql.NullString `db:"billing_tax_id" json:"billing_tax_id"`
+	InvoiceNumber       sql.NullInt64  `db:"invoice_number" json:"invoice_number"`
+	RevokedAt           sql.NullTime   `db:"revoked_at" json:"revoked_at"`
+	SubtotalCents       int32          `db:"subtotal_cents" json:"subtotal_cents"`
+	TaxCents            int32          `db:"tax_cents" json:"tax_cents"`
+	ProductName         string         `db:"product_name" json:"product_name"`
+	Currency            string         `db:"currency" json:"currency"`
+}
+
+// Everything printed on the invoice for a report. product_name and currency
+// come from the catalog; sessions that predate it are the standard product.
+func (q *Queries) GetInvoiceByAccessToken(ctx context.Context, accessToken string) (GetInvoiceByAccessTokenRow, error) {
+	row := q.queryRow(ctx, q.getInvoiceByAccessTokenStmt, getInvoiceByAccessToken, accessToken)
+	var i GetInvoiceByAccessTokenRow
+	err := row.Scan(
+		&i.ReportID,
+		&i.SessionID,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.SubscriptionID,
+		&i.CreditFromSessionID,
+		&i.StripePaymentIntent,
+		&i.Email,
+		&i.BizName,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingPostalCode,
+		&i.BillingCountry,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.RevokedAt,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.ProductName,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getPaymentMarginStats = `-- name: GetPaymentMarginStats :many
+SELECT
+    currency,
+    COUNT(*)::bigint                                                                     AS payments,
+    COALESCE(SUM(amount_cents), 0)::bigint                                               AS gross_cents,
+    COALESCE(SUM(fee_cents), 0)::bigint                                                  AS fee_cents,
+    COALESCE(SUM(net_cents), 0)::bigint                                                  AS net_cents,
+    (COUNT(*) FILTER (WHERE created_at >= now() - INTERVAL '30 days'))::bigint            AS payments_30d,
+    COALESCE(SUM(amount_cents) FILTER (WHERE created_at >= now() - INTERVAL '30 days'), 0)::bigint AS gross_cents_30d,
+    COALESCE(SUM(fee_cents) FILTER (WHERE created_at >= now() - INTERVAL '30 days'), 0)::bigint    AS fee_cents_30d,
+    COALESCE(SUM(net_cents) FILTER (WHERE created_at >= now() - INTERVAL '30 days'), 0)::bigint    AS net_cents_30d
+FROM payments
+GROUP BY currency
+ORDER BY currency
+`
+
+type GetPaymentMarginStatsRow struct {
+	Currency      string `db:"currency" json:"currency"`
+	Payments      int64  `db:"payments" json:"payments"`
+	GrossCents    int64  `db:"gross_cents" json:"gross_cents"`
+	FeeCents      int64  `db:"fee_cents" json:"fee_cents"`
+	NetCents      int64  `db:"net_cents" json:"net_cents"`
+	Payments30d   int64  `db:"payments_30d" json:"payments_30d"`
+	GrossCents30d int64  `db:"gross_cents_30d" json:"gross_cents_30d"`
+	FeeCents30d   int64  `db:"fee_cents_30d" json:"fee_cents_30d"`
+	NetCents30d   int64  `db:"net_cents_30d" json:"net_cents_30d"`
+}
+
+// Gross, Stripe fees and net per settlement currency, overall and for the
+// last 30 days.
+func (q *Queries) GetPaymentMarginStats(ctx context.Context) ([]GetPaymentMarginStatsRow, error) {
+	rows, err := q.query(ctx, q.getPaymentMarginStatsStmt, getPaymentMarginStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetPaymentMarginStatsRow{}
+	for rows.Next() {
+		var i GetPaymentMarginStatsRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Payments,
+			&i.GrossCents,
+			&i.FeeCents,
+			&i.NetCents,
+			&i.Payments30d,
+			&i.GrossCents30d,
+			&i.FeeCents30d,
+			&i.NetCents30d,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProductBySKU = `-- name: GetProductBySKU :one
+SELECT sku, name, price_cents, currency, report_type, active, created_at, updated_at FROM products WHERE sku = $1 LIMIT 1
+`
+
+func (q *Queries) GetProductBySKU(ctx context.Context, sku string) (Product, error) {
+	row := q.queryRow(ctx, q.getProductBySKUStmt, getProductBySKU, sku)
+	var i Product
+	err := row.Scan(
+		&i.Sku,
+		&i.Name,
+		&i.PriceCents,
+		&i.Currency,
+		&i.ReportType,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getQuestionByID = `-- name: GetQuestionByID :one
+SELECT id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at FROM question_definitions WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetQuestionByID(ctx context.Context, id string) (QuestionDefinition, error) {
+	row := q.queryRow(ctx, q.getQuestionByIDStmt, getQuestionByID, id)
+	var i QuestionDefinition
+	err := row.Scan(
+		&i.ID,
+		&i.QuestionVersion,
+		&i.SectionID,
+		&i.SectionTitle,
+		&i.DisplayOrder,
+		&i.Text,
+		&i.Subtext,
+		&i.Type,
+		pq.Array(&i.Opts),
+		&i.Placeholder,
+		&i.Required,
+		&i.RiskName,
+		&i.RiskDesc,
+		&i.Hedge,
+		&i.ScoringConfig,
+		&i.IsScoring,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReportByAccessToken = `-- name: GetReportByAccessToken :one
+SELECT r.id, r.session_id, r.status, r.error_message, r.overall_score, r.critical_count, r.risks_json, r.executive_summary, r.top_priority_html, r.access_token, r.generated_at, r.created_at, r.updated_at, r.claimed_by, r.claim_expires_at, r.revoked_at, r.revoked_reason, r.held_at, r.ai_analysis, r.ai_narrative, r.relationships, r.ai_quality_issues, r.ai_budget_note, r.not_before, r.flags, r.executive_summary_edited_at, r.skipped_questions, r.job_state, s.biz_name, s.industry, s.stage, s.email
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+WHERE r.access_token = $1
+LIMIT 1
+`
+
+type GetReportByAccessTokenRow struct {
+	ID                       uuid.UUID             `db:"id" json:"id"`
+	SessionID                uuid.UUID             `db:"session_id" json:"session_id"`
+	Status                   ReportStatus          `db:"status" json:"status"`
+	ErrorMessage             sql.NullString        `db:"error_message" json:"error_message"`
+	OverallScore             sql.NullInt16         `db:"overall_score" json:"overall_score"`
+	CriticalCount            sql.NullInt16         `db:"critical_count" json:"critical_count"`
+	RisksJson                pqtype.NullRawMessage `db:"risks_json" json:"risks_json"`
+	ExecutiveSummary         sql.NullString        `db:"executive_summary" json:"executive_summary"`
+	TopPriorityHtml          sql.NullString        `db:"top_priority_html" json:"top_priority_html"`
+	AccessToken              string                `db:"access_token" json:"access_token"`
+	GeneratedAt              sql.NullTime          `db:"generated_at" json:"generated_at"`
+	CreatedAt                time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt                time.Time             `db:"updated_at" json:"updated_at"`
+	ClaimedBy                sql.NullString        `db:"claimed_by" json:"claimed_by"`
+	ClaimExpiresAt           sql.NullTime          `db:"claim_expires_at" json:"claim_expires_at"`
+	RevokedAt                sql.NullTime          `db:"revoked_at" json:"revoked_at"`
+	RevokedReason            sql.NullString        `db:"revoked_reason" json:"revoked_reason"`
+	HeldAt                   sql.NullTime          `db:"held_at" json:"held_at"`
+	AiAnalysis               pqtype.NullRawMessage `db:"ai_analysis" json:"ai_analysis"`
+	AiNarrative              pqtype.NullRawMessage `db:"ai_narrative" json:"ai_narrative"`
+	Relationships            pqtype.NullRawMessage `db:"relationships" json:"relationships"`
+	AiQualityIssues          sql.NullString        `db:"ai_quality_issues" json:"ai_quality_issues"`
+	AiBudgetNote             sql.NullString        `db:"ai_budget_note" json:"ai_budget_note"`
+	NotBefore                sql.NullTime          `db:"not_before" json:"not_before"`
+	Flags                    pqtype.NullRawMessage `db:"flags" json:"flags"`
+	ExecutiveSummaryEditedAt sql.NullTime          `db:"executive_summary_edited_at" json:"executive_summary_edited_at"`
+	SkippedQuestions         []string              `db:"skipped_questions" json:"skipped_questions"`
+	JobState                 pqtype.NullRawMessage `db:"job_state" json:"job_state"`
+	BizName                  sql.NullString        `db:"biz_name" json:"biz_name"`
+	Industry                 sql.NullString        `db:"industry" json:"industry"`
+	Stage                    sql.NullString        `db:"stage" json:"stage"`
+	Email                    sql.NullString        `db:"email" json:"email"`
+}
+
+func (q *Queries) GetReportByAccessToken(ctx context.Context, accessToken string) (GetReportByAccessTokenRow, error) {
+	row := q.queryRow(ctx, q.getReportByAccessTokenStmt, getReportByAccessToken, accessToken)
+	var i GetReportByAccessTokenRow
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.Email,
+	)
+	return i, err
+}
+
+const getReportByID = `-- name: GetReportByID :one
+SELECT id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state FROM reports WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReportByID(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.getReportByIDStmt, getReportByID, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const getReportBySessionID = `-- name: GetReportBySessionID :one
+SELECT id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state FROM reports WHERE session_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReportBySessionID(ctx context.Context, sessionID uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.getReportBySessionIDStmt, getReportBySessionID, sessionID)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const getRiskResultByQuestion = `-- name: GetRiskResultByQuestion :one
+SELECT id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section, ai_hedge_edited_at, explanation FROM risk_results
+WHERE report_id = $1 AND question_id = $2
+`
+
+type GetRiskResultByQuestionParams struct {
+	ReportID   uuid.UUID `db:"report_id" json:"report_id"`
+	QuestionID string    `db:"question_id" json:"question_id"`
+}
+
+func (q *Queries) GetRiskResultByQuestion(ctx context.Context, arg GetRiskResultByQuestionParams) (RiskResult, error) {
+	row := q.queryRow(ctx, q.getRiskResultByQuestionStmt, getRiskResultByQuestion, arg.ReportID, arg.QuestionID)
+	var i RiskResult
+	err := row.Scan(
+		&i.ID,
+		&i.ReportID,
+		&i.QuestionID,
+		&i.Rank,
+		&i.RiskName,
+		&i.RiskDesc,
+		&i.Probability,
+		&i.Impact,
+		&i.Score,
+		&i.Tier,
+		&i.Hedge,
+		&i.AiHedge,
+		&i.Section,
+		&i.AiHedgeEditedAt,
+		&i.Explanation,
+	)
+	return i, err
+}
+
+const getRiskResultsByReport = `-- name: GetRiskResultsByReport :many
+SELECT id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section, ai_hedge_edited_at, explanation FROM risk_results
+WHERE report_id = $1
+ORDER BY rank
+`
+
+func (q *Queries) GetRiskResultsByReport(ctx context.Context, reportID uuid.UUID) ([]RiskResult, error) {
+	rows, err := q.query(ctx, q.getRiskResultsByReportStmt, getRiskResultsByReport, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RiskResult{}
+	for rows.Next() {
+		var i RiskResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReportID,
+			&i.QuestionID,
+			&i.Rank,
+			&i.RiskName,
+			&i.RiskDesc,
+			&i.Probability,
+			&i.Impact,
+			&i.Score,
+			&i.Tier,
+			&i.Hedge,
+			&i.AiHedge,
+			&i.Section,
+			&i.AiHedgeEditedAt,
+			&i.Explanation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRiskStats = `-- name: GetRiskStats :many
+
+SELECT risk_name, tier, section, occurrences, avg_probability, avg_impact, avg_score FROM public_risk_stats
+`
+
+// ---------------------------------------------------------------------------
+// ANALYTICS
+// ---------------------------------------------------------------------------
+func (q *Queries) GetRiskStats(ctx context.Context) ([]PublicRiskStat, error) {
+	rows, err := q.query(ctx, q.getRiskStatsStmt, getRiskStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PublicRiskStat{}
+	for rows.Next() {
+		var i PublicRiskStat
+		if err := rows.Scan(
+			&i.RiskName,
+			&i.Tier,
+			&i.Section,
+			&i.Occurrences,
+			&i.AvgProbability,
+			&i.AvgImpact,
+			&i.AvgScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScoringQuestions = `-- name: GetScoringQuestions :many
+SELECT id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at FROM question_definitions
+WHERE is_scoring = TRUE
+ORDER BY section_id, display_order
+`
+
+func (q *Queries) GetScoringQuestions(ctx context.Context) ([]QuestionDefinition, error) {
+	rows, err := q.query(ctx, q.getScoringQuestionsStmt, getScoringQuestions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QuestionDefinition{}
+	for rows.Next() {
+		var i QuestionDefinition
+		if err := rows.Scan(
+			&i.ID,
+			&i.QuestionVersion,
+			&i.SectionID,
+			&i.SectionTitle,
+			&i.DisplayOrder,
+			&i.Text,
+			&i.Subtext,
+			&i.Type,
+			pq.Array(&i.Opts),
+			&i.Placeholder,
+			&i.Required,
+			&i.RiskName,
+			&i.RiskDesc,
+			&i.Hedge,
+			&i.ScoringConfig,
+			&i.IsScoring,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSessionByAnonToken = `-- name: GetSessionByAnonToken :one
+SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort FROM sessions WHERE anon_token = $1 LIMIT 1
+`
+
+func (q *Queries) GetSessionByAnonToken(ctx context.Context, anonToken string) (Session, error) {
+	row := q.queryRow(ctx, q.getSessionByAnonTokenStmt, getSessionByAnonToken, anonToken)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const getSessionByID = `-- name: GetSessionByID :one
+SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort FROM sessions WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetSessionByID(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.queryRow(ctx, q.getSessionByIDStmt, getSessionByID, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const getSessionByStripePI = `-- name: GetSessionByStripePI :one
+SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort FROM sessions WHERE stripe_payment_intent = $1 LIMIT 1
+`
+
+func (q *Queries) GetSessionByStripePI(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error) {
+	row := q.queryRow(ctx, q.getSessionByStripePIStmt, getSessionByStripePI, stripePaymentIntent)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const getStripeEvent = `-- name: GetStripeEvent :one
+SELECT stripe_event_id, type, payload, processed, processed_at, error, received_at FROM stripe_events WHERE stripe_event_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetStripeEvent(ctx context.Context, stripeEventID string) (StripeEvent, error) {
+	row := q.queryRow(ctx, q.getStripeEventStmt, getStripeEvent, stripeEventID)
+	var i StripeEvent
+	err := row.Scan(
+		&i.StripeEventID,
+		&i.Type,
+		&i.Payload,
+		&i.Processed,
+		&i.ProcessedAt,
+		&i.Error,
+		&i.ReceivedAt,
+	)
+	return i, err
+}
+
+const getUnprocessedStripeEvents = `-- name: GetUnprocessedStripeEvents :many
+SELECT stripe_event_id, type, payload, processed, processed_at, error, received_at FROM stripe_events
+WHERE processed = FALSE
+  AND received_at > now() - INTERVAL '24 hours'
+ORDER BY received_at
+`
+
+func (q *Queries) GetUnprocessedStripeEvents(ctx context.Context) ([]StripeEvent, error) {
+	rows, err := q.query(ctx, q.getUnprocessedStripeEventsStmt, getUnprocessedStripeEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StripeEvent{}
+	for rows.Next() {
+		var i StripeEvent
+		if err := rows.Scan(
+			&i.StripeEventID,
+			&i.Type,
+			&i.Payload,
+			&i.Processed,
+			&i.ProcessedAt,
+			&i.Error,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWatchAndRedRisks = `-- name: GetWatchAndRedRisks :many
+SELECT id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section, ai_hedge_edited_at, explanation FROM risk_results
+WHERE report_id = $1 AND tier IN ('watch', 'red')
+ORDER BY score DESC
+`
+
+func (q *Queries) GetWatchAndRedRisks(ctx context.Context, reportID uuid.UUID) ([]RiskResult, error) {
+	rows, err := q.query(ctx, q.getWatchAndRedRisksStmt, getWatchAndRedRisks, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RiskResult{}
+	for rows.Next() {
+		var i RiskResult
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReportID,
+			&i.QuestionID,
+			&i.Rank,
+			&i.RiskName,
+			&i.RiskDesc,
+			&i.Probability,
+			&i.Impact,
+			&i.Score,
+			&i.Tier,
+			&i.Hedge,
+			&i.AiHedge,
+			&i.Section,
+			&i.AiHedgeEditedAt,
+			&i.Explanation,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const holdReport = `-- name: HoldReport :one
+UPDATE reports SET held_at = now() WHERE id = $1 RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+func (q *Queries) HoldReport(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.holdReportStmt, holdReport, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const insertAIEdit = `-- name: InsertAIEdit :one
+INSERT INTO ai_edits (report_id, field, question_id, original, edited, editor, reason)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, report_id, field, question_id, original, edited, editor, reason, created_at
+`
+
+type InsertAIEditParams struct {
+	ReportID   uuid.UUID      `db:"report_id" json:"report_id"`
+	Field      string         `db:"field" json:"field"`
+	QuestionID sql.NullString `db:"question_id" json:"question_id"`
+	Original   sql.NullString `db:"original" json:"original"`
+	Edited     string         `db:"edited" json:"edited"`
+	Editor     string         `db:"editor" json:"editor"`
+	Reason     string         `db:"reason" json:"reason"`
+}
+
+func (q *Queries) InsertAIEdit(ctx context.Context, arg InsertAIEditParams) (AiEdit, error) {
+	row := q.queryRow(ctx, q.insertAIEditStmt, insertAIEdit,
+		arg.ReportID,
+		arg.Field,
+		arg.QuestionID,
+		arg.Original,
+		arg.Edited,
+		arg.Editor,
+		arg.Reason,
+	)
+	var i AiEdit
+	err := row.Scan(
+		&i.ID,
+		&i.ReportID,
+		&i.Field,
+		&i.QuestionID,
+		&i.Original,
+		&i.Edited,
+		&i.Editor,
+		&i.Reason,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertAITranscript = `-- name: InsertAITranscript :one
+
+INSERT INTO ai_transcripts (report_id, exchanges, storage_key, body)
+VALUES ($1, $2, $3, $4)
+RETURNING id, report_id, exchanges, storage_key, body, created_at
+`
+
+type InsertAITranscriptParams struct {
+	ReportID   uuid.UUID             `db:"report_id" json:"report_id"`
+	Exchanges  int32                 `db:"exchanges" json:"exchanges"`
+	StorageKey sql.NullString        `db:"storage_key" json:"storage_key"`
+	Body       pqtype.NullRawMessage `db:"body" json:"body"`
+}
+
+// ---------------------------------------------------------------------------
+// AI TRANSCRIPTS
+// ---------------------------------------------------------------------------
+func (q *Queries) InsertAITranscript(ctx context.Context, arg InsertAITranscriptParams) (AiTranscript, error) {
+	row := q.queryRow(ctx, q.insertAITranscriptStmt, insertAITranscript,
+		arg.ReportID,
+		arg.Exchanges,
+		arg.StorageKey,
+		arg.Body,
+	)
+	var i AiTranscript
+	err := row.Scan(
+		&i.ID,
+		&i.ReportID,
+		&i.Exchanges,
+		&i.StorageKey,
+		&i.Body,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertJob = `-- name: InsertJob :one
+
+INSERT INTO jobs (type, payload, dedupe_key, run_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (dedupe_key) DO NOTHING
+RETURNING id, type, payload, dedupe_key, run_at, status, attempts, error, claimed_by, claim_expires_at, finished_at, created_at, updated_at
+`
+
+type InsertJobParams struct {
+	Type      string          `db:"type" json:"type"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	DedupeKey sql.NullString  `db:"dedupe_key" json:"dedupe_key"`
+	RunAt     time.Time       `db:"run_at" json:"run_at"`
+}
+
+// ---------------------------------------------------------------------------
+// JOBS
+//
+//	Claims work as the report claims do: a claim lasts lease_seconds, and an
+//	expired claim, or claimed_by's own, can be taken again.
+//
+// ---------------------------------------------------------------------------
+// Queues a job to run from run_at. Returns no rows when a job with the same
+// dedupe_key was already queued.
+func (q *Queries) InsertJob(ctx context.Context, arg InsertJobParams) (Job, error) {
+	row := q.queryRow(ctx, q.insertJobStmt, insertJob,
+		arg.Type,
+		arg.Payload,
+		arg.DedupeKey,
+		arg.RunAt,
+	)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.DedupeKey,
+		&i.RunAt,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.FinishedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertReportAttempt = `-- name: InsertReportAttempt :exec
+
+INSERT INTO report_attempts (report_id, attempt, error, provider, provider_error, duration_ms, worker_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type InsertReportAttemptParams struct {
+	ReportID      uuid.UUID      `db:"report_id" json:"report_id"`
+	Attempt       int32          `db:"attempt" json:"attempt"`
+	Error         string         `db:"error" json:"error"`
+	Provider      sql.NullString `db:"provider" json:"provider"`
+	ProviderError sql.NullString `db:"provider_error" json:"provider_error"`
+	DurationMs    int32          `db:"duration_ms" json:"duration_ms"`
+	WorkerID      string         `db:"worker_id" json:"worker_id"`
+}
+
+// ---------------------------------------------------------------------------
+// REPORT ATTEMPTS
+// ---------------------------------------------------------------------------
+func (q *Queries) InsertReportAttempt(ctx context.Context, arg InsertReportAttemptParams) error {
+	_, err := q.exec(ctx, q.insertReportAttemptStmt, insertReportAttempt,
+		arg.ReportID,
+		arg.Attempt,
+		arg.Error,
+		arg.Provider,
+		arg.ProviderError,
+		arg.DurationMs,
+		arg.WorkerID,
+	)
+	return err
+}
+
+const insertRiskResult = `-- name: InsertRiskResult :one
+
+INSERT INTO risk_results (
+    report_id, question_id, rank, risk_name, risk_desc,
+    probability, impact, score, tier, hedge, section, explanation
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section, ai_hedge_edited_at, explanation
+`
+
+type InsertRiskResultParams struct {
+	ReportID    uuid.UUID             `db:"report_id" json:"report_id"`
+	QuestionID  string                `db:"question_id" json:"question_id"`
+	Rank        int16                 `db:"rank" json:"rank"`
+	RiskName    string                `db:"risk_name" json:"risk_name"`
+	RiskDesc    string                `db:"risk_desc" json:"risk_desc"`
+	Probability int16                 `db:"probability" json:"probability"`
+	Impact      int16                 `db:"impact" json:"impact"`
+	Score       int16                 `db:"score" json:"score"`
+	Tier        RiskTier              `db:"tier" json:"tier"`
+	Hedge       string                `db:"hedge" json:"hedge"`
+	Section     string                `db:"section" json:"section"`
+	Explanation pqtype.NullRawMessage `db:"explanation" json:"explanation"`
+}
+
+// ---------------------------------------------------------------------------
+// RISK RESULTS
+// ---------------------------------------------------------------------------
+func (q *Queries) InsertRiskResult(ctx context.Context, arg InsertRiskResultParams) (RiskResult, error) {
+	row := q.queryRow(ctx, q.insertRiskResultStmt, insertRiskResult,
+		arg.ReportID,
+		arg.QuestionID,
+		arg.Rank,
+		arg.RiskName,
+		arg.RiskDesc,
+		arg.Probability,
+		arg.Impact,
+		arg.Score,
+		arg.Tier,
+		arg.Hedge,
+		arg.Section,
+		arg.Explanation,
+	)
+	var i RiskResult
+	err := row.Scan(
+		&i.ID,
+		&i.ReportID,
+		&i.QuestionID,
+		&i.Rank,
+		&i.RiskName,
+		&i.RiskDesc,
+		&i.Probability,
+		&i.Impact,
+		&i.Score,
+		&i.Tier,
+		&i.Hedge,
+		&i.AiHedge,
+		&i.Section,
+		&i.AiHedgeEditedAt,
+		&i.Explanation,
+	)
+	return i, err
+}
+
+const insertSupportNote = `-- name: InsertSupportNote :one
+
+INSERT INTO support_notes (session_id, report_id, author, body)
+VALUES ($1, $2, $3, $4)
+RETURNING id, session_id, report_id, author, body, created_at
+`
+
+type InsertSupportNoteParams struct {
+	SessionID uuid.UUID     `db:"session_id" json:"session_id"`
+	ReportID  uuid.NullUUID `db:"report_id" json:"report_id"`
+	Author    string        `db:"author" json:"author"`
+	Body      string        `db:"body" json:"body"`
+}
+
+// ---------------------------------------------------------------------------
+// SUPPORT NOTES
+// ---------------------------------------------------------------------------
+func (q *Queries) InsertSupportNote(ctx context.Context, arg InsertSupportNoteParams) (SupportNote, error) {
+	row := q.queryRow(ctx, q.insertSupportNoteStmt, insertSupportNote,
+		arg.SessionID,
+		arg.ReportID,
+		arg.Author,
+		arg.Body,
+	)
+	var i SupportNote
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.Author,
+		&i.Body,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAIEditsByReport = `-- name: ListAIEditsByReport :many
+SELECT id, report_id, field, question_id, original, edited, editor, reason, created_at FROM ai_edits WHERE report_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListAIEditsByReport(ctx context.Context, reportID uuid.UUID) ([]AiEdit, error) {
+	rows, err := q.query(ctx, q.listAIEditsByReportStmt, listAIEditsByReport, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AiEdit{}
+	for rows.Next() {
+		var i AiEdit
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReportID,
+			&i.Field,
+			&i.QuestionID,
+			&i.Original,
+			&i.Edited,
+			&i.Editor,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAITranscriptsByReport = `-- name: ListAITranscriptsByReport :many
+SELECT id, report_id, exchanges, storage_key, body, created_at FROM ai_transcripts WHERE report_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListAITranscriptsByReport(ctx context.Context, reportID uuid.UUID) ([]AiTranscript, error) {
+	rows, err := q.query(ctx, q.listAITranscriptsByReportStmt, listAITranscriptsByReport, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AiTranscript{}
+	for rows.Next() {
+		var i AiTranscript
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReportID,
+			&i.Exchanges,
+			&i.StorageKey,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActivePlaybookSnippetsByIndustry = `-- name: ListActivePlaybookSnippetsByIndustry :many
+SELECT slug, industry, title, body, keywords, active, created_at, updated_at FROM playbook_snippets
+WHERE active AND lower(industry) = lower($1::text)
+ORDER BY slug
+`
+
+func (q *Queries) ListActivePlaybookSnippetsByIndustry(ctx context.Context, industry string) ([]PlaybookSnippet, error) {
+	rows, err := q.query(ctx, q.listActivePlaybookSnippetsByIndustryStmt, listActivePlaybookSnippetsByIndustry, industry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PlaybookSnippet{}
+	for rows.Next() {
+		var i PlaybookSnippet
+		if err := rows.Scan(
+			&i.Slug,
+			&i.Industry,
+			&i.Title,
+			&i.Body,
+			pq.Array(&i.Keywords),
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveProducts = `-- name: ListActiveProducts :many
+
+SELECT sku, name, price_cents, currency, report_type, active, created_at, updated_at FROM products WHERE active ORDER BY price_cents, sku
+`
+
+// ---------------------------------------------------------------------------
+// PRODUCTS
+// ---------------------------------------------------------------------------
+func (q *Queries) ListActiveProducts(ctx context.Context) ([]Product, error) {
+	rows, err := q.query(ctx, q.listActiveProductsStmt, listActiveProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Product{}
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.Sku,
+			&i.Name,
+			&i.PriceCents,
+			&i.Currency,
+			&i.ReportType,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeliverableReportsByEmail = `-- name: ListDeliverableReportsByEmail :many
+SELECT r.id, r.session_id, r.access_token, s.biz_name, s.email
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+WHERE s.email_hash = $1::text
+  AND s.payment_status = 'paid'
+  AND r.status = 'ready'
+  AND r.revoked_at IS NULL
+ORDER BY r.generated_at DESC
+LIMIT $2
+`
+
+type ListDeliverableReportsByEmailParams struct {
+	Email   string `db:"email" json:"email"`
+	MaxRows int32  `db:"max_rows" json:"max_rows"`
+}
+
+type ListDeliverableReportsByEmailRow struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	SessionID   uuid.UUID      `db:"session_id" json:"session_id"`
+	AccessToken string         `db:"access_token" json:"access_token"`
+	BizName     sql.NullString `db:"biz_name" json:"biz_name"`
+	Email       sql.NullString `db:"email" json:"email"`
+}
+
+// Ready, unrevoked reports of paid sessions for this email, newest first, for
+// POST /api/report/resend. The store's codec replaces email with its blind
+// index.
+func (q *Queries) ListDeliverableReportsByEmail(ctx context.Context, arg ListDeliverableReportsByEmailParams) ([]ListDeliverableReportsByEmailRow, error) {
+	rows, err := q.query(ctx, q.listDeliverableReportsByEmailStmt, listDeliverableReportsByEmail, arg.Email, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeliverableReportsByEmailRow{}
+	for rows.Next() {
+		var i ListDeliverableReportsByEmailRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.AccessToken,
+			&i.BizName,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmailLogAddresses = `-- name: ListEmailLogAddresses :many
+SELECT id, to_address::text AS to_address FROM email_log
+WHERE id > $1::uuid
+ORDER BY id
+LIMIT $2::int
+`
+
+type ListEmailLogAddressesParams struct {
+	After   uuid.UUID `db:"after" json:"after"`
+	MaxRows int32     `db:"max_rows" json:"max_rows"`
+}
+
+type ListEmailLogAddressesRow struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	ToAddress string    `db:"to_address" json:"to_address"`
+}
+
+func (q *Queries) ListEmailLogAddresses(ctx context.Context, arg ListEmailLogAddressesParams) ([]ListEmailLogAddressesRow, error) {
+	rows, err := q.query(ctx, q.listEmailLogAddressesStmt, listEmailLogAddresses, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEmailLogAddressesRow{}
+	for rows.Next() {
+		var i ListEmailLogAddressesRow
+		if err := rows.Scan(&i.ID, &i.ToAddress); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmailLogBySession = `-- name: ListEmailLogBySession :many
+SELECT id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of FROM email_log WHERE session_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) ListEmailLogBySession(ctx context.Context, sessionID uuid.NullUUID) ([]EmailLog, error) {
+	rows, err := q.query(ctx, q.listEmailLogBySessionStmt, listEmailLogBySession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EmailLog{}
+	for rows.Next() {
+		var i EmailLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.ReportID,
+			&i.ToAddress,
+			&i.Subject,
+			&i.Template,
+			&i.ProviderID,
+			&i.SentAt,
+			&i.OpenedAt,
+			&i.Error,
+			&i.CreatedAt,
+			&i.ClickedAt,
+			&i.BouncedAt,
+			&i.ResentAt,
+			&i.DedupeKey,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmailLogPage = `-- name: ListEmailLogPage :many
+SELECT id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of FROM email_log
+WHERE ($1::text = '' OR template = $1::text)
+  AND (NOT $2::boolean
+       OR (created_at, id) < ($3::timestamptz, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListEmailLogPageParams struct {
+	Template  string    `db:"template" json:"template"`
+	HasCursor bool      `db:"has_cursor" json:"has_cursor"`
+	CursorAt  time.Time `db:"cursor_at" json:"cursor_at"`
+	CursorID  uuid.UUID `db:"cursor_id" json:"cursor_id"`
+	MaxRows   int32     `db:"max_rows" json:"max_rows"`
+}
+
+// Logged emails newest first, for the admin listing, ordered and continued
+// like ListSessionsPage. An empty template matches every template.
+func (q *Queries) ListEmailLogPage(ctx context.Context, arg ListEmailLogPageParams) ([]EmailLog, error) {
+	rows, err := q.query(ctx, q.listEmailLogPageStmt, listEmailLogPage,
+		arg.Template,
+		arg.HasCursor,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EmailLog{}
+	for rows.Next() {
+		var i EmailLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.ReportID,
+			&i.ToAddress,
+			&i.Subject,
+			&i.Template,
+			&i.ProviderID,
+			&i.SentAt,
+			&i.OpenedAt,
+			&i.Error,
+			&i.CreatedAt,
+			&i.ClickedAt,
+			&i.BouncedAt,
+			&i.ResentAt,
+			&i.DedupeKey,
+			&i.DuplicateOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeatureFlags = `-- name: ListFeatureFlags :many
+
+SELECT name, environment, percent, updated_at FROM feature_flags ORDER BY name, environment
+`
+
+// ---------------------------------------------------------------------------
+// FEATURE FLAGS
+// ---------------------------------------------------------------------------
+func (q *Queries) ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+	rows, err := q.query(ctx, q.listFeatureFlagsStmt, listFeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeatureFlag{}
+	for rows.Next() {
+		var i FeatureFlag
+		if err := rows.Scan(
+			&i.Name,
+			&i.Environment,
+			&i.Percent,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedbackCandidates = `-- name: ListFeedbackCandidates :many
+
+SELECT l.report_id, l.session_id, l.to_address, l.sent_at, s.biz_name
+FROM email_log l
+JOIN reports  r ON r.id = l.report_id
+JOIN sessions s ON s.id = l.session_id
+WHERE l.template = 'report_ready'
+  AND l.dedupe_key IS NOT NULL
+  AND l.sent_at >= $1::timestamptz
+  AND l.sent_at <  $2::timestamptz
+  AND ($3::uuid IS NULL OR l.report_id = $3::uuid)
+  AND r.status = 'ready'
+  AND r.revoked_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM feedback f WHERE f.report_id = l.report_id)
+  AND NOT EXISTS (SELECT 1 FROM email_suppressions es WHERE es.email_hash = s.email_hash)
+  AND NOT EXISTS (
+      SELECT 1 FROM email_log b
+      WHERE b.report_id = l.report_id AND b.bounced_at IS NOT NULL
+  )
+ORDER BY l.sent_at
+LIMIT $4
+`
+
+type ListFeedbackCandidatesParams struct {
+	SentAfter  time.Time     `db:"sent_after" json:"sent_after"`
+	SentBefore time.Time     `db:"sent_before" json:"sent_before"`
+	ReportID   uuid.NullUUID `db:"report_id" json:"report_id"`
+	MaxRows    int32         `db:"max_rows" json:"max_rows"`
+}
+
+type ListFeedbackCandidatesRow struct {
+	ReportID  uuid.NullUUID  `db:"report_id" json:"report_id"`
+	SessionID uuid.NullUUID  `db:"session_id" json:"session_id"`
+	ToAddress string         `db:"to_address" json:"to_address"`
+	SentAt    sql.NullTime   `db:"sent_at" json:"sent_at"`
+	BizName   sql.NullString `db:"biz_name" json:"biz_name"`
+}
+
+// ---------------------------------------------------------------------------
+// FEEDBACK
+// ---------------------------------------------------------------------------
+// Live reports whose report-ready email went out in [sent_after, sent_before)
+// and never bounced, that have not been asked for feedback and whose address
+// is not suppressed. Oldest first. Feeds the worker's feedback requester;
+// with report_id, only that report.
+func (q *Queries) ListFeedbackCandidates(ctx context.Context, arg ListFeedbackCandidatesParams) ([]ListFeedbackCandidatesRow, error) {
+	rows, err := q.query(ctx, q.listFeedbackCandidatesStmt, listFeedbackCandidates,
+		arg.SentAfter,
+		arg.SentBefore,
+		arg.ReportID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFeedbackCandidatesRow{}
+	for rows.Next() {
+		var i ListFeedbackCandidatesRow
+		if err := rows.Scan(
+			&i.ReportID,
+			&i.SessionID,
+			&i.ToAddress,
+			&i.SentAt,
+			&i.BizName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentsByStripePIs = `-- name: ListPaymentsByStripePIs :many
+SELECT id, stripe_charge_id, stripe_payment_intent, stripe_balance_transaction_id, session_id, amount_cents, fee_cents, net_cents, currency, created_at, updated_at FROM payments WHERE stripe_payment_intent = ANY($1::text[])
+`
+
+func (q *Queries) ListPaymentsByStripePIs(ctx context.Context, paymentIntents []string) ([]Payment, error) {
+	rows, err := q.query(ctx, q.listPaymentsByStripePIsStmt, listPaymentsByStripePIs, pq.Array(paymentIntents))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.StripeChargeID,
+			&i.StripePaymentIntent,
+			&i.StripeBalanceTransactionID,
+			&i.SessionID,
+			&i.AmountCents,
+			&i.FeeCents,
+			&i.NetCents,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingReports = `-- name: ListPendingReports :many
+SELECT id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state FROM reports
+WHERE status IN ('draft', 'processing')
+  AND GREATEST(updated_at, not_before) > now() - INTERVAL '1 day'
+  AND (not_before IS NULL OR not_before <= now())
+  AND revoked_at IS NULL
+  AND held_at IS NULL
+ORDER BY created_at
+`
+
+// Used by the background worker to pick up unprocessed reports. The window is
+// on updated_at so a report requeued by an operator is picked up again however
+// old it is, and on not_before so a cohort scheduled far ahead is not dropped.
+// Imported reports wait for their not_before.
+func (q *Queries) ListPendingReports(ctx context.Context) ([]Report, error) {
+	rows, err := q.query(ctx, q.listPendingReportsStmt, listPendingReports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Report{}
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.OverallScore,
+			&i.CriticalCount,
+			&i.RisksJson,
+			&i.ExecutiveSummary,
+			&i.TopPriorityHtml,
+			&i.AccessToken,
+			&i.GeneratedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ClaimedBy,
+			&i.ClaimExpiresAt,
+			&i.RevokedAt,
+			&i.RevokedReason,
+			&i.HeldAt,
+			&i.AiAnalysis,
+			&i.AiNarrative,
+			&i.Relationships,
+			&i.AiQualityIssues,
+			&i.AiBudgetNote,
+			&i.NotBefore,
+			&i.Flags,
+			&i.ExecutiveSummaryEditedAt,
+			pq.Array(&i.SkippedQuestions),
+			&i.JobState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaybookSnippets = `-- name: ListPlaybookSnippets :many
+
+SELECT slug, industry, title, body, keywords, active, created_at, updated_at FROM playbook_snippets ORDER BY lower(industry), slug
+`
+
+// ---------------------------------------------------------------------------
+// INDUSTRY PLAYBOOKS
+// ---------------------------------------------------------------------------
+func (q *Queries) ListPlaybookSnippets(ctx context.Context) ([]PlaybookSnippet, error) {
+	rows, err := q.query(ctx, q.listPlaybookSnippetsStmt, listPlaybookSnippets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PlaybookSnippet{}
+	for rows.Next() {
+		var i PlaybookSnippet
+		if err := rows.Scan(
+			&i.Slug,
+			&i.Industry,
+			&i.Title,
+			&i.Body,
+			pq.Array(&i.Keywords),
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProducts = `-- name: ListProducts :many
+SELECT sku, name, price_cents, currency, report_type, active, created_at, updated_at FROM products ORDER BY price_cents, sku
+`
+
+func (q *Queries) ListProducts(ctx context.Context) ([]Product, error) {
+	rows, err := q.query(ctx, q.listProductsStmt, listProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Product{}
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.Sku,
+			&i.Name,
+			&i.PriceCents,
+			&i.Currency,
+			&i.ReportType,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuestionSections = `-- name: ListQuestionSections :many
+
+SELECT id, title, description, display_order, gated, updated_at FROM question_sections ORDER BY display_order, id
+`
+
+// ---------------------------------------------------------------------------
+// QUESTION SECTIONS
+// ---------------------------------------------------------------------------
+func (q *Queries) ListQuestionSections(ctx context.Context) ([]QuestionSection, error) {
+	rows, err := q.query(ctx, q.listQuestionSectionsStmt, listQuestionSections)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QuestionSection{}
+	for rows.Next() {
+		var i QuestionSection
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.DisplayOrder,
+			&i.Gated,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReportAttempts = `-- name: ListReportAttempts :many
+SELECT id, report_id, attempt, error, provider, provider_error, duration_ms, worker_id, created_at FROM report_attempts
+WHERE report_id = $1
+ORDER BY created_at
+`
+
+// A report's failed attempts, oldest first.
+func (q *Queries) ListReportAttempts(ctx context.Context, reportID uuid.UUID) ([]ReportAttempt, error) {
+	rows, err := q.query(ctx, q.listReportAttemptsStmt, listReportAttempts, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReportAttempt{}
+	for rows.Next() {
+		var i ReportAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReportID,
+			&i.Attempt,
+			&i.Error,
+			&i.Provider,
+			&i.ProviderError,
+			&i.DurationMs,
+			&i.WorkerID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReprocessableStripeEvents = `-- name: ListReprocessableStripeEvents :many
+SELECT stripe_event_id, type, payload, processed, processed_at, error, received_at FROM stripe_events
+WHERE NOT processed
+  AND error IS NOT NULL
+  AND ($1::text = '' OR type = $1::text)
+  AND NOT EXISTS (SELECT 1 FROM jobs
+                  WHERE jobs.dedupe_key = 'stripe_event:' || stripe_events.stripe_event_id
+                    AND jobs.status = 'pending')
+ORDER BY received_at, stripe_event_id
+LIMIT $2
+`
+
+type ListReprocessableStripeEventsParams struct {
+	EventType string `db:"event_type" json:"event_type"`
+	MaxRows   int32  `db:"max_rows" json:"max_rows"`
+}
+
+// Failed events oldest first, for the admin bulk reprocess: not processed,
+// with an error, and with no pending process_stripe_event job that will
+// retry them, so a replay never runs beside the worker's. An empty
+// event_type matches every type.
+func (q *Queries) ListReprocessableStripeEvents(ctx context.Context, arg ListReprocessableStripeEventsParams) ([]StripeEvent, error) {
+	rows, err := q.query(ctx, q.listReprocessableStripeEventsStmt, listReprocessableStripeEvents, arg.EventType, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StripeEvent{}
+	for rows.Next() {
+		var i StripeEvent
+		if err := rows.Scan(
+			&i.StripeEventID,
+			&i.Type,
+			&i.Payload,
+			&i.Processed,
+			&i.ProcessedAt,
+			&i.Error,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listResearchReports = `-- name: ListResearchReports :many
+SELECT
+    r.id,
+    COALESCE(s.industry, '')::text       AS industry,
+    COALESCE(s.stage, '')::text          AS stage,
+    COALESCE(r.overall_score, 0)::smallint AS overall_score
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+WHERE r.status = 'ready'
+  AND r.revoked_at IS NULL
+  AND r.held_at IS NULL
+  AND r.generated_at >= $1::timestamptz
+  AND r.generated_at <  $2::timestamptz
+ORDER BY r.generated_at, r.id
+`
+
+type ListResearchReportsParams struct {
+	GeneratedFrom time.Time `db:"generated_from" json:"generated_from"`
+	GeneratedTo   time.Time `db:"generated_to" json:"generated_to"`
+}
+
+type ListResearchReportsRow struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	Industry     string    `db:"industry" json:"industry"`
+	Stage        string    `db:"stage" json:"stage"`
+	OverallScore int16     `db:"overall_score" json:"overall_score"`
+}
+
+// Delivered reports generated in [generated_from, generated_to), with only
+// the columns the anonymised research export may publish.
+func (q *Queries) ListResearchReports(ctx context.Context, arg ListResearchReportsParams) ([]ListResearchReportsRow, error) {
+	rows, err := q.query(ctx, q.listResearchReportsStmt, listResearchReports, arg.GeneratedFrom, arg.GeneratedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListResearchReportsRow{}
+	for rows.Next() {
+		var i ListResearchReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Industry,
+			&i.Stage,
+			&i.OverallScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRuntimeSettings = `-- name: ListRuntimeSettings :many
+
+SELECT key, value, updated_at FROM runtime_settings ORDER BY key
+`
+
+// ---------------------------------------------------------------------------
+// RUNTIME SETTINGS
+// ---------------------------------------------------------------------------
+func (q *Queries) ListRuntimeSettings(ctx context.Context) ([]RuntimeSetting, error) {
+	rows, err := q.query(ctx, q.listRuntimeSettingsStmt, listRuntimeSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RuntimeSetting{}
+	for rows.Next() {
+		var i RuntimeSetting
+		if err := rows.Scan(&i.Key, &i.Value, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionEmails = `-- name: ListSessionEmails :many
+
+SELECT id, email::text AS email, email_hash FROM sessions
+WHERE email IS NOT NULL AND id > $1::uuid
+ORDER BY id
+LIMIT $2::int
+`
+
+type ListSessionEmailsParams struct {
+	After   uuid.UUID `db:"after" json:"after"`
+	MaxRows int32     `db:"max_rows" json:"max_rows"`
+}
+
+type ListSessionEmailsRow struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	Email     string         `db:"email" json:"email"`
+	EmailHash sql.NullString `db:"email_hash" json:"email_hash"`
+}
+
+// ---------------------------------------------------------------------------
+// FIELD ENCRYPTION
+//
+//	Keyset-paginated scans and guarded rewrites for `armctl reencrypt`, which
+//	moves encrypted columns to the primary key. Each Set query only writes if
+//	the value is still the one that was read.
+//
+// ---------------------------------------------------------------------------
+func (q *Queries) ListSessionEmails(ctx context.Context, arg ListSessionEmailsParams) ([]ListSessionEmailsRow, error) {
+	rows, err := q.query(ctx, q.listSessionEmailsStmt, listSessionEmails, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSessionEmailsRow{}
+	for rows.Next() {
+		var i ListSessionEmailsRow
+		if err := rows.Scan(&i.ID, &i.Email, &i.EmailHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsByStripePIs = `-- name: ListSessionsByStripePIs :many
+SELECT id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort FROM sessions WHERE stripe_payment_intent = ANY($1::text[])
+`
+
+func (q *Queries) ListSessionsByStripePIs(ctx context.Context, paymentIntents []string) ([]Session, error) {
+	rows, err := q.query(ctx, q.listSessionsByStripePIsStmt, listSessionsByStripePIs, pq.Array(paymentIntents))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.AnonToken,
+			&i.Email,
+			&i.BizName,
+			&i.Industry,
+			&i.Stage,
+			&i.StripeCustomerID,
+			&i.StripePaymentIntent,
+			&i.PaymentStatus,
+			&i.PaidAt,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.Referrer,
+			&i.IpHash,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ProductSku,
+			&i.SubscriptionID,
+			&i.BillingCountry,
+			&i.BillingPostalCode,
+			&i.SubtotalCents,
+			&i.TaxCents,
+			&i.TaxCalculationID,
+			&i.BillingName,
+			&i.BillingAddressLine1,
+			&i.BillingAddressLine2,
+			&i.BillingCity,
+			&i.BillingTaxID,
+			&i.InvoiceNumber,
+			&i.EmailHash,
+			&i.CreditFromSessionID,
+			&i.Partner,
+			&i.Cohort,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsPage = `-- name: ListSessionsPage :many
+SELECT id, biz_name, industry, stage, payment_status, paid_at, partner, cohort, created_at
+FROM sessions
+WHERE ($1::text = '' OR payment_status::text = $1::text)
+  AND (NOT $2::boolean
+       OR (created_at, id) < ($3::timestamptz, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListSessionsPageParams struct {
+	PaymentStatus string    `db:"payment_status" json:"payment_status"`
+	HasCursor     bool      `db:"has_cursor" json:"has_cursor"`
+	CursorAt      time.Time `db:"cursor_at" json:"cursor_at"`
+	CursorID      uuid.UUID `db:"cursor_id" json:"cursor_id"`
+	MaxRows       int32     `db:"max_rows" json:"max_rows"`
+}
+
+type ListSessionsPageRow struct {
+	ID            uuid.UUID      `db:"id" json:"id"`
+	BizName       sql.NullString `db:"biz_name" json:"biz_name"`
+	Industry      sql.NullString `db:"industry" json:"industry"`
+	Stage         sql.NullString `db:"stage" json:"stage"`
+	PaymentStatus PaymentStatus  `db:"payment_status" json:"payment_status"`
+	PaidAt        sql.NullTime   `db:"paid_at" json:"paid_at"`
+	Partner       sql.NullString `db:"partner" json:"partner"`
+	Cohort        sql.NullString `db:"cohort" json:"cohort"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+}
+
+// Sessions newest first, for the admin listing; ties on created_at are broken
+// by id so every session lands on exactly one page. An empty payment_status
+// matches every status. With has_cursor, continues after the session at
+// (cursor_at, cursor_id). Leaves out the email so no ciphertext is read.
+func (q *Queries) ListSessionsPage(ctx context.Context, arg ListSessionsPageParams) ([]ListSessionsPageRow, error) {
+	rows, err := q.query(ctx, q.listSessionsPageStmt, listSessionsPage,
+		arg.PaymentStatus,
+		arg.HasCursor,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSessionsPageRow{}
+	for rows.Next() {
+		var i ListSessionsPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BizName,
+			&i.Industry,
+			&i.Stage,
+			&i.PaymentStatus,
+			&i.PaidAt,
+			&i.Partner,
+			&i.Cohort,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStripeEventPayloads = `-- name: ListStripeEventPayloads :many
+SELECT stripe_event_id, payload FROM stripe_events
+WHERE stripe_event_id > $1::text
+ORDER BY stripe_event_id
+LIMIT $2::int
+`
+
+type ListStripeEventPayloadsParams struct {
+	After   string `db:"after" json:"after"`
+	MaxRows int32  `db:"max_rows" json:"max_rows"`
+}
+
+type ListStripeEventPayloadsRow struct {
+	StripeEventID string          `db:"stripe_event_id" json:"stripe_event_id"`
+	Payload       json.RawMessage `db:"payload" json:"payload"`
+}
+
+func (q *Queries) ListStripeEventPayloads(ctx context.Context, arg ListStripeEventPayloadsParams) ([]ListStripeEventPayloadsRow, error) {
+	rows, err := q.query(ctx, q.listStripeEventPayloadsStmt, listStripeEventPayloads, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStripeEventPayloadsRow{}
+	for rows.Next() {
+		var i ListStripeEventPayloadsRow
+		if err := rows.Scan(&i.StripeEventID, &i.Payload); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStripeEvents = `-- name: ListStripeEvents :many
+SELECT stripe_event_id, type, payload, processed, processed_at, error, received_at FROM stripe_events
+WHERE ($1::text = ''
+       OR ($1::text = 'failed' AND NOT processed AND error IS NOT NULL)
+       OR ($1::text = 'pending' AND NOT processed AND error IS NULL)
+       OR ($1::text = 'processed' AND processed))
+  AND ($2::text = '' OR type = $2::text)
+  AND (NOT $3::boolean
+       OR (received_at, stripe_event_id) < ($4::timestamptz, $5::text))
+ORDER BY received_at DESC, stripe_event_id DESC
+LIMIT $6
+`
+
+type ListStripeEventsParams struct {
+	Status    string    `db:"status" json:"status"`
+	EventType string    `db:"event_type" json:"event_type"`
+	HasCursor bool      `db:"has_cursor" json:"has_cursor"`
+	CursorAt  time.Time `db:"cursor_at" json:"cursor_at"`
+	CursorID  string    `db:"cursor_id" json:"cursor_id"`
+	MaxRows   int32     `db:"max_rows" json:"max_rows"`
+}
+
+// Stored events newest first, for the admin listing, ordered and continued
+// like ListSessionsPage. status is empty for any, 'failed' (handler errored,
+// not processed since), 'pending' (not processed, no error) or 'processed';
+// an empty event_type matches every type.
+func (q *Queries) ListStripeEvents(ctx context.Context, arg ListStripeEventsParams) ([]StripeEvent, error) {
+	rows, err := q.query(ctx, q.listStripeEventsStmt, listStripeEvents,
+		arg.Status,
+		arg.EventType,
+		arg.HasCursor,
+		arg.CursorAt,
+		arg.CursorID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StripeEvent{}
+	for rows.Next() {
+		var i StripeEvent
+		if err := rows.Scan(
+			&i.StripeEventID,
+			&i.Type,
+			&i.Payload,
+			&i.Processed,
+			&i.ProcessedAt,
+			&i.Error,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStripeEventsForExport = `-- name: ListStripeEventsForExport :many
+SELECT stripe_event_id, type, payload, processed, processed_at, error, received_at FROM stripe_events
+WHERE type = ANY($1::text[])
+  AND received_at >= $2::timestamptz
+  AND received_at <  $3::timestamptz
+ORDER BY received_at, stripe_event_id
+`
+
+type ListStripeEventsForExportParams struct {
+	Types        []string  `db:"types" json:"types"`
+	ReceivedFrom time.Time `db:"received_from" json:"received_from"`
+	ReceivedTo   time.Time `db:"received_to" json:"received_to"`
+}
+
+// Stored events of the given types received in [received_from, received_to),
+// oldest first. Used by the accounting export.
+func (q *Queries) ListStripeEventsForExport(ctx context.Context, arg ListStripeEventsForExportParams) ([]StripeEvent, error) {
+	rows, err := q.query(ctx, q.listStripeEventsForExportStmt, listStripeEventsForExport, pq.Array(arg.Types), arg.ReceivedFrom, arg.ReceivedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []StripeEvent{}
+	for rows.Next() {
+		var i StripeEvent
+		if err := rows.Scan(
+			&i.StripeEventID,
+			&i.Type,
+			&i.Payload,
+			&i.Processed,
+			&i.ProcessedAt,
+			&i.Error,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionEmails = `-- name: ListSubscriptionEmails :many
+SELECT id, email::text AS email, email_hash FROM subscriptions
+WHERE email IS NOT NULL AND id > $1::uuid
+ORDER BY id
+LIMIT $2::int
+`
+
+type ListSubscriptionEmailsParams struct {
+	After   uuid.UUID `db:"after" json:"after"`
+	MaxRows int32     `db:"max_rows" json:"max_rows"`
+}
+
+type ListSubscriptionEmailsRow struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	Email     string         `db:"email" json:"email"`
+	EmailHash sql.NullString `db:"email_hash" json:"email_hash"`
+}
+
+func (q *Queries) ListSubscriptionEmails(ctx context.Context, arg ListSubscriptionEmailsParams) ([]ListSubscriptionEmailsRow, error) {
+	rows, err := q.query(ctx, q.listSubscriptionEmailsStmt, listSubscriptionEmails, arg.After, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSubscriptionEmailsRow{}
+	for rows.Next() {
+		var i ListSubscriptionEmailsRow
+		if err := rows.Scan(&i.ID, &i.Email, &i.EmailHash); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSupportNotesBySession = `-- name: ListSupportNotesBySession :many
+SELECT id, session_id, report_id, author, body, created_at FROM support_notes WHERE session_id = $1 ORDER BY created_at
+`
+
+// Notes written against the session's report included, oldest first.
+func (q *Queries) ListSupportNotesBySession(ctx context.Context, sessionID uuid.UUID) ([]SupportNote, error) {
+	rows, err := q.query(ctx, q.listSupportNotesBySessionStmt, listSupportNotesBySession, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SupportNote{}
+	for rows.Next() {
+		var i SupportNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.ReportID,
+			&i.Author,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTestimonials = `-- name: ListTestimonials :many
+SELECT f.report_id, f.rating, f.testimonial, f.consent_name, f.responded_at, f.approved_at, s.biz_name
+FROM feedback f
+JOIN reports  r ON r.id = f.report_id
+JOIN sessions s ON s.id = r.session_id
+WHERE f.consent_quote
+  AND f.testimonial IS NOT NULL
+  AND (f.approved_at IS NOT NULL) = $1::bool
+ORDER BY f.responded_at
+`
+
+type ListTestimonialsRow struct {
+	ReportID    uuid.UUID      `db:"report_id" json:"report_id"`
+	Rating      sql.NullInt16  `db:"rating" json:"rating"`
+	Testimonial sql.NullString `db:"testimonial" json:"testimonial"`
+	ConsentName bool           `db:"consent_name" json:"consent_name"`
+	RespondedAt sql.NullTime   `db:"responded_at" json:"responded_at"`
+	ApprovedAt  sql.NullTime   `db:"approved_at" json:"approved_at"`
+	BizName     sql.NullString `db:"biz_name" json:"biz_name"`
+}
+
+// Testimonials their authors agreed to have quoted, approved or awaiting
+// approval, oldest response first.
+func (q *Queries) ListTestimonials(ctx context.Context, approved bool) ([]ListTestimonialsRow, error) {
+	rows, err := q.query(ctx, q.listTestimonialsStmt, listTestimonials, approved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTestimonialsRow{}
+	for rows.Next() {
+		var i ListTestimonialsRow
+		if err := rows.Scan(
+			&i.ReportID,
+			&i.Rating,
+			&i.Testimonial,
+			&i.ConsentName,
+			&i.RespondedAt,
+			&i.ApprovedAt,
+			&i.BizName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTierThemes = `-- name: ListTierThemes :many
+SELECT tier, label, color, description, cadence, updated_at FROM tier_themes
+ORDER BY tier
+`
+
+func (q *Queries) ListTierThemes(ctx context.Context) ([]TierTheme, error) {
+	rows, err := q.query(ctx, q.listTierThemesStmt, listTierThemes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TierTheme{}
+	for rows.Next() {
+		var i TierTheme
+		if err := rows.Scan(
+			&i.Tier,
+			&i.Label,
+			&i.Color,
+			&i.Description,
+			&i.Cadence,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnopenedReportEmails = `-- name: ListUnopenedReportEmails :many
+SELECT l.id, l.session_id, l.report_id, l.to_address, l.sent_at,
+       r.access_token, s.biz_name
+FROM email_log l
+JOIN reports  r ON r.id = l.report_id
+JOIN sessions s ON s.id = l.session_id
+WHERE l.template = 'report_ready'
+  AND l.opened_at  IS NULL
+  AND l.bounced_at IS NULL
+  AND l.resent_at  IS NULL
+  AND l.sent_at >= $1::timestamptz
+  AND l.sent_at <  $2::timestamptz
+  AND ($3::uuid IS NULL OR l.report_id = $3::uuid)
+  AND r.revoked_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM email_log other
+      WHERE other.report_id = l.report_id
+        AND other.id <> l.id
+        AND other.duplicate_of IS NULL
+        AND (other.created_at > l.created_at OR other.opened_at IS NOT NULL)
+  )
+ORDER BY l.sent_at
+LIMIT $4
+`
+
+type ListUnopenedReportEmailsParams struct {
+	SentAfter  time.Time     `db:"sent_after" json:"sent_after"`
+	SentBefore time.Time     `db:"sent_before" json:"sent_before"`
+	ReportID   uuid.NullUUID `db:"report_id" json:"report_id"`
+	MaxRows    int32         `db:"max_rows" json:"max_rows"`
+}
+
+type ListUnopenedReportEmailsRow struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	SessionID   uuid.NullUUID  `db:"session_id" json:"session_id"`
+	ReportID    uuid.NullUUID  `db:"report_id" json:"report_id"`
+	ToAddress   string         `db:"to_address" json:"to_address"`
+	SentAt      sql.NullTime   `db:"sent_at" json:"sent_at"`
+	AccessToken string         `db:"access_token" json:"access_token"`
+	BizName     sql.NullString `db:"biz_name" json:"biz_name"`
+}
+
+// Report-ready emails sent in [sent_after, sent_before) that were not opened,
+// bounced or resent, for live reports with no other email that is later or
+// was opened. Oldest first. Feeds the worker's resender; with report_id, only
+// that report's.
+func (q *Queries) ListUnopenedReportEmails(ctx context.Context, arg ListUnopenedReportEmailsParams) ([]ListUnopenedReportEmailsRow, error) {
+	rows, err := q.query(ctx, q.listUnopenedReportEmailsStmt, listUnopenedReportEmails,
+		arg.SentAfter,
+		arg.SentBefore,
+		arg.ReportID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnopenedReportEmailsRow{}
+	for rows.Next() {
+		var i ListUnopenedReportEmailsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.ReportID,
+			&i.ToAddress,
+			&i.SentAt,
+			&i.AccessToken,
+			&i.BizName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnresolvedDuplicatePurchases = `-- name: ListUnresolvedDuplicatePurchases :many
+SELECT d.session_id, d.original_session_id, d.changed_answers, d.created_at,
+       s.stripe_payment_intent, s.paid_at, s.product_sku, s.biz_name,
+       r.id AS report_id, o.paid_at AS original_paid_at
+FROM duplicate_purchases d
+JOIN sessions s ON s.id = d.session_id
+JOIN sessions o ON o.id = d.original_session_id
+JOIN reports  r ON r.session_id = d.session_id
+WHERE d.resolution IS NULL
+ORDER BY d.created_at
+`
+
+type ListUnresolvedDuplicatePurchasesRow struct {
+	SessionID           uuid.UUID      `db:"session_id" json:"session_id"`
+	OriginalSessionID   uuid.UUID      `db:"original_session_id" json:"original_session_id"`
+	ChangedAnswers      int32          `db:"changed_answers" json:"changed_answers"`
+	CreatedAt           time.Time      `db:"created_at" json:"created_at"`
+	StripePaymentIntent sql.NullString `db:"stripe_payment_intent" json:"stripe_payment_intent"`
+	PaidAt              sql.NullTime   `db:"paid_at" json:"paid_at"`
+	ProductSku          sql.NullString `db:"product_sku" json:"product_sku"`
+	BizName             sql.NullString `db:"biz_name" json:"biz_name"`
+	ReportID            uuid.UUID      `db:"report_id" json:"report_id"`
+	OriginalPaidAt      sql.NullTime   `db:"original_paid_at" json:"original_paid_at"`
+}
+
+func (q *Queries) ListUnresolvedDuplicatePurchases(ctx context.Context) ([]ListUnresolvedDuplicatePurchasesRow, error) {
+	rows, err := q.query(ctx, q.listUnresolvedDuplicatePurchasesStmt, listUnresolvedDuplicatePurchases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnresolvedDuplicatePurchasesRow{}
+	for rows.Next() {
+		var i ListUnresolvedDuplicatePurchasesRow
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.OriginalSessionID,
+			&i.ChangedAnswers,
+			&i.CreatedAt,
+			&i.StripePaymentIntent,
+			&i.PaidAt,
+			&i.ProductSku,
+			&i.BizName,
+			&i.ReportID,
+			&i.OriginalPaidAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const logEmail = `-- name: LogEmail :one
+
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, provider_id, sent_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+type LogEmailParams struct {
+	SessionID  uuid.NullUUID  `db:"session_id" json:"session_id"`
+	ReportID   uuid.NullUUID  `db:"report_id" json:"report_id"`
+	ToAddress  string         `db:"to_address" json:"to_address"`
+	Subject    string         `db:"subject" json:"subject"`
+	Template   string         `db:"template" json:"template"`
+	ProviderID sql.NullString `db:"provider_id" json:"provider_id"`
+}
+
+// ---------------------------------------------------------------------------
+// EMAIL LOG
+// ---------------------------------------------------------------------------
+func (q *Queries) LogEmail(ctx context.Context, arg LogEmailParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.logEmailStmt, logEmail,
+		arg.SessionID,
+		arg.ReportID,
+		arg.ToAddress,
+		arg.Subject,
+		arg.Template,
+		arg.ProviderID,
+	)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const logEmailFailure = `-- name: LogEmailFailure :one
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, error)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+type LogEmailFailureParams struct {
+	SessionID uuid.NullUUID  `db:"session_id" json:"session_id"`
+	ReportID  uuid.NullUUID  `db:"report_id" json:"report_id"`
+	ToAddress string         `db:"to_address" json:"to_address"`
+	Subject   string         `db:"subject" json:"subject"`
+	Template  string         `db:"template" json:"template"`
+	Error     sql.NullString `db:"error" json:"error"`
+}
+
+// Records an email the provider refused. It has no sent_at.
+func (q *Queries) LogEmailFailure(ctx context.Context, arg LogEmailFailureParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.logEmailFailureStmt, logEmailFailure,
+		arg.SessionID,
+		arg.ReportID,
+		arg.ToAddress,
+		arg.Subject,
+		arg.Template,
+		arg.Error,
+	)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const logSkippedEmail = `-- name: LogSkippedEmail :one
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, duplicate_of)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+type LogSkippedEmailParams struct {
+	SessionID   uuid.NullUUID `db:"session_id" json:"session_id"`
+	ReportID    uuid.NullUUID `db:"report_id" json:"report_id"`
+	ToAddress   string        `db:"to_address" json:"to_address"`
+	Subject     string        `db:"subject" json:"subject"`
+	Template    string        `db:"template" json:"template"`
+	DuplicateOf uuid.NullUUID `db:"duplicate_of" json:"duplicate_of"`
+}
+
+// Records a send skipped as a duplicate of an earlier email. It has no
+// sent_at.
+func (q *Queries) LogSkippedEmail(ctx context.Context, arg LogSkippedEmailParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.logSkippedEmailStmt, logSkippedEmail,
+		arg.SessionID,
+		arg.ReportID,
+		arg.ToAddress,
+		arg.Subject,
+		arg.Template,
+		arg.DuplicateOf,
+	)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const markEmailBounced = `-- name: MarkEmailBounced :one
+UPDATE email_log
+SET bounced_at = COALESCE(bounced_at, now()),
+    error      = $1
+WHERE provider_id = $2
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+type MarkEmailBouncedParams struct {
+	Reason     sql.NullString `db:"reason" json:"reason"`
+	ProviderID sql.NullString `db:"provider_id" json:"provider_id"`
+}
+
+func (q *Queries) MarkEmailBounced(ctx context.Context, arg MarkEmailBouncedParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.markEmailBouncedStmt, markEmailBounced, arg.Reason, arg.ProviderID)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const markEmailClaimSent = `-- name: MarkEmailClaimSent :one
+UPDATE email_log
+SET subject     = $1,
+    provider_id = $2,
+    sent_at     = now()
+WHERE dedupe_key = $3 AND sent_at IS NULL
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+type MarkEmailClaimSentParams struct {
+	Subject    string         `db:"subject" json:"subject"`
+	ProviderID sql.NullString `db:"provider_id" json:"provider_id"`
+	DedupeKey  sql.NullString `db:"dedupe_key" json:"dedupe_key"`
+}
+
+func (q *Queries) MarkEmailClaimSent(ctx context.Context, arg MarkEmailClaimSentParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.markEmailClaimSentStmt, markEmailClaimSent, arg.Subject, arg.ProviderID, arg.DedupeKey)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const markEmailClicked = `-- name: MarkEmailClicked :one
+UPDATE email_log
+SET clicked_at = COALESCE(clicked_at, now()),
+    opened_at  = COALESCE(opened_at, now())
+WHERE provider_id = $1
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+// A click implies an open, even when the client blocked the tracking pixel.
+func (q *Queries) MarkEmailClicked(ctx context.Context, providerID sql.NullString) (EmailLog, error) {
+	row := q.queryRow(ctx, q.markEmailClickedStmt, markEmailClicked, providerID)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const markEmailOpened = `-- name: MarkEmailOpened :one
+UPDATE email_log SET opened_at = COALESCE(opened_at, now()) WHERE provider_id = $1 RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+// Webhooks are delivered at least once; the first open is kept.
+func (q *Queries) MarkEmailOpened(ctx context.Context, providerID sql.NullString) (EmailLog, error) {
+	row := q.queryRow(ctx, q.markEmailOpenedStmt, markEmailOpened, providerID)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const markEmailResent = `-- name: MarkEmailResent :execrows
+UPDATE email_log SET resent_at = now() WHERE id = $1 AND resent_at IS NULL
+`
+
+// Claims an email for resending; 0 when another replica already has.
+func (q *Queries) MarkEmailResent(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.exec(ctx, q.markEmailResentStmt, markEmailResent, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markSessionPaid = `-- name: MarkSessionPaid :one
+UPDATE sessions
+SET payment_status = 'paid',
+    paid_at        = now()
+WHERE stripe_payment_intent = $1
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
+`
+
+func (q *Queries) MarkSessionPaid(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error) {
+	row := q.queryRow(ctx, q.markSessionPaidStmt, markSessionPaid, stripePaymentIntent)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const markSessionPaidByCredit = `-- name: MarkSessionPaidByCredit :one
+UPDATE sessions
+SET payment_status         = 'paid',
+    paid_at                = now(),
+    email                  = $2,
+    credit_from_session_id = $3,
+    product_sku            = $4,
+    email_hash             = $5
+WHERE id = $1
+  AND payment_status <> 'paid'
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
+`
+
+type MarkSessionPaidByCreditParams struct {
+	ID                  uuid.UUID      `db:"id" json:"id"`
+	Email               sql.NullString `db:"email" json:"email"`
+	CreditFromSessionID uuid.NullUUID  `db:"credit_from_session_id" json:"credit_from_session_id"`
+	ProductSku          sql.NullString `db:"product_sku" json:"product_sku"`
+	EmailHash           sql.NullString `db:"email_hash" json:"email_hash"`
+}
+
+func (q *Queries) MarkSessionPaidByCredit(ctx context.Context, arg MarkSessionPaidByCreditParams) (Session, error) {
+	row := q.queryRow(ctx, q.markSessionPaidByCreditStmt, markSessionPaidByCredit,
+		arg.ID,
+		arg.Email,
+		arg.CreditFromSessionID,
+		arg.ProductSku,
+		arg.EmailHash,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const markSessionPaidBySubscription = `-- name: MarkSessionPaidBySubscription :one
+UPDATE sessions
+SET payment_status  = 'paid',
+    paid_at         = now(),
+    email           = $2,
+    subscription_id = $3,
+    product_sku     = $4,
+    email_hash      = $5
+WHERE id = $1
+  AND payment_status <> 'paid'
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
+`
+
+type MarkSessionPaidBySubscriptionParams struct {
+	ID             uuid.UUID      `db:"id" json:"id"`
+	Email          sql.NullString `db:"email" json:"email"`
+	SubscriptionID uuid.NullUUID  `db:"subscription_id" json:"subscription_id"`
+	ProductSku     sql.NullString `db:"product_sku" json:"product_sku"`
+	EmailHash      sql.NullString `db:"email_hash" json:"email_hash"`
+}
+
+func (q *Queries) MarkSessionPaidBySubscription(ctx context.Context, arg MarkSessionPaidBySubscriptionParams) (Session, error) {
+	row := q.queryRow(ctx, q.markSessionPaidBySubscriptionStmt, markSessionPaidBySubscription,
+		arg.ID,
+		arg.Email,
+		arg.SubscriptionID,
+		arg.ProductSku,
+		arg.EmailHash,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const markSessionPaymentFailed = `-- name: MarkSessionPaymentFailed :one
+UPDATE sessions
+SET payment_status = 'failed'
+WHERE stripe_payment_intent = $1
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
+`
+
+func (q *Queries) MarkSessionPaymentFailed(ctx context.Context, stripePaymentIntent sql.NullString) (Session, error) {
+	row := q.queryRow(ctx, q.markSessionPaymentFailedStmt, markSessionPaymentFailed, stripePaymentIntent)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const markSessionRefunded = `-- name: MarkSessionRefunded :one
+UPDATE sessions SET payment_status = 'refunded' WHERE id = $1 RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
+`
+
+func (q *Queries) MarkSessionRefunded(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.queryRow(ctx, q.markSessionRefundedStmt, markSessionRefunded, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.AnonToken,
+		&i.Email,
+		&i.BizName,
+		&i.Industry,
+		&i.Stage,
+		&i.StripeCustomerID,
+		&i.StripePaymentIntent,
+		&i.PaymentStatus,
+		&i.PaidAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.Referrer,
+		&i.IpHash,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const markStripeEventFailed = `-- name: MarkStripeEventFailed :one
+UPDATE stripe_events
+SET processed    = FALSE,
+    error        = $2
+WHERE stripe_event_id = $1
+RETURNING stripe_event_id, type, payload, processed, processed_at, error, received_at
+`
+
+type MarkStripeEventFailedParams struct {
+	StripeEventID string         `db:"stripe_event_id" json:"stripe_event_id"`
+	Error         sql.NullString `db:"error" json:"error"`
+}
+
+func (q *Queries) MarkStripeEventFailed(ctx context.Context, arg MarkStripeEventFailedParams) (StripeEvent, error) {
+	row := q.queryRow(ctx, q.markStripeEventFailedStmt, markStripeEventFailed, arg.StripeEventID, arg.Error)
+	var i StripeEvent
+	err := row.Scan(
+		&i.StripeEventID,
+		&i.Type,
+		&i.Payload,
+		&i.Processed,
+		&i.ProcessedAt,
+		&i.Error,
+		&i.ReceivedAt,
+	)
+	return i, err
+}
+
+const markStripeEventProcessed = `-- name: MarkStripeEventProcessed :one
+UPDATE stripe_events
+SET processed    = TRUE,
+    processed_at = now()
+WHERE stripe_event_id = $1
+RETURNING stripe_event_id, type, payload, processed, processed_at, error, received_at
+`
+
+func (q *Queries) MarkStripeEventProcessed(ctx context.Context, stripeEventID string) (StripeEvent, error) {
+	row := q.queryRow(ctx, q.markStripeEventProcessedStmt, markStripeEventProcessed, stripeEventID)
+	var i StripeEvent
+	err := row.Scan(
+		&i.StripeEventID,
+		&i.Type,
+		&i.Payload,
+		&i.Processed,
+		&i.ProcessedAt,
+		&i.Error,
+		&i.ReceivedAt,
+	)
+	return i, err
+}
+
+const parkQuestionDisplayOrders = `-- name: ParkQuestionDisplayOrders :exec
+UPDATE question_definitions
+SET display_order = -1 - display_order
+WHERE id = ANY($1::text[])
+`
+
+// Moves questions to unused negative display orders so a reordering can be
+// written row by row without tripping idx_qdef_section_order.
+func (q *Queries) ParkQuestionDisplayOrders(ctx context.Context, ids []string) error {
+	_, err := q.exec(ctx, q.parkQuestionDisplayOrdersStmt, parkQuestionDisplayOrders, pq.Array(ids))
+	return err
+}
+
+const recordFeedback = `-- name: RecordFeedback :one
+UPDATE feedback
+SET rating        = $1,
+    testimonial   = $2,
+    consent_quote = $3,
+    consent_name  = $4,
+    responded_at  = now(),
+    approved_at   = CASE
+        WHEN $3::bool AND testimonial IS NOT DISTINCT FROM $2 THEN approved_at
+    END
+WHERE token = $5
+RETURNING report_id, token, requested_at, rating, testimonial, consent_quote, consent_name, responded_at, approved_at
+`
+
+type RecordFeedbackParams struct {
+	Rating       sql.NullInt16  `db:"rating" json:"rating"`
+	Testimonial  sql.NullString `db:"testimonial" json:"testimonial"`
+	ConsentQuote bool           `db:"consent_quote" json:"consent_quote"`
+	ConsentName  bool           `db:"consent_name" json:"consent_name"`
+	Token        string         `db:"token" json:"token"`
+}
+
+// Stores the customer's answer; they may answer again. An approval only
+// survives when the testimonial and consent to quote it are unchanged.
+func (q *Queries) RecordFeedback(ctx context.Context, arg RecordFeedbackParams) (Feedback, error) {
+	row := q.queryRow(ctx, q.recordFeedbackStmt, recordFeedback,
+		arg.Rating,
+		arg.Testimonial,
+		arg.ConsentQuote,
+		arg.ConsentName,
+		arg.Token,
+	)
+	var i Feedback
+	err := row.Scan(
+		&i.ReportID,
+		&i.Token,
+		&i.RequestedAt,
+		&i.Rating,
+		&i.Testimonial,
+		&i.ConsentQuote,
+		&i.ConsentName,
+		&i.RespondedAt,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const recordJobAttempt = `-- name: RecordJobAttempt :exec
+UPDATE jobs
+SET attempts = attempts + 1,
+    error    = $2
+WHERE id = $1
+`
+
+type RecordJobAttemptParams struct {
+	ID    uuid.UUID      `db:"id" json:"id"`
+	Error sql.NullString `db:"error" json:"error"`
+}
+
+// Counts a failed attempt and keeps its error.
+func (q *Queries) RecordJobAttempt(ctx context.Context, arg RecordJobAttemptParams) error {
+	_, err := q.exec(ctx, q.recordJobAttemptStmt, recordJobAttempt, arg.ID, arg.Error)
+	return err
+}
+
+const releaseEmailClaim = `-- name: ReleaseEmailClaim :one
+UPDATE email_log
+SET subject    = $1,
+    error      = $2,
+    dedupe_key = NULL
+WHERE dedupe_key = $3 AND sent_at IS NULL
+RETURNING id, session_id, report_id, to_address, subject, template, provider_id, sent_at, opened_at, error, created_at, clicked_at, bounced_at, resent_at, dedupe_key, duplicate_of
+`
+
+type ReleaseEmailClaimParams struct {
+	Subject   string         `db:"subject" json:"subject"`
+	Error     sql.NullString `db:"error" json:"error"`
+	DedupeKey sql.NullString `db:"dedupe_key" json:"dedupe_key"`
+}
+
+// Records a failed send and frees its key for the next attempt.
+func (q *Queries) ReleaseEmailClaim(ctx context.Context, arg ReleaseEmailClaimParams) (EmailLog, error) {
+	row := q.queryRow(ctx, q.releaseEmailClaimStmt, releaseEmailClaim, arg.Subject, arg.Error, arg.DedupeKey)
+	var i EmailLog
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.ReportID,
+		&i.ToAddress,
+		&i.Subject,
+		&i.Template,
+		&i.ProviderID,
+		&i.SentAt,
+		&i.OpenedAt,
+		&i.Error,
+		&i.CreatedAt,
+		&i.ClickedAt,
+		&i.BouncedAt,
+		&i.ResentAt,
+		&i.DedupeKey,
+		&i.DuplicateOf,
+	)
+	return i, err
+}
+
+const releaseJobClaim = `-- name: ReleaseJobClaim :exec
+UPDATE jobs
+SET claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = $1 AND claimed_by = $2::text
+`
+
+type ReleaseJobClaimParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	ClaimedBy string    `db:"claimed_by" json:"claimed_by"`
+}
+
+// Gives up claimed_by's claim so another worker can take the job at once.
+func (q *Queries) ReleaseJobClaim(ctx context.Context, arg ReleaseJobClaimParams) error {
+	_, err := q.exec(ctx, q.releaseJobClaimStmt, releaseJobClaim, arg.ID, arg.ClaimedBy)
+	return err
+}
+
+const releaseReport = `-- name: ReleaseReport :one
+UPDATE reports SET held_at = NULL, updated_at = now() WHERE id = $1 RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+// updated_at is bumped so the poller's one-day window starts again.
+func (q *Queries) ReleaseReport(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.releaseReportStmt, releaseReport, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const releaseReportClaim = `-- name: ReleaseReportClaim :exec
+UPDATE reports
+SET claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = $1 AND claimed_by = $2::text
+`
+
+type ReleaseReportClaimParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	ClaimedBy string    `db:"claimed_by" json:"claimed_by"`
+}
+
+// Gives up claimed_by's claim so another worker can take the report at once.
+func (q *Queries) ReleaseReportClaim(ctx context.Context, arg ReleaseReportClaimParams) error {
+	_, err := q.exec(ctx, q.releaseReportClaimStmt, releaseReportClaim, arg.ID, arg.ClaimedBy)
+	return err
+}
+
+const requeueReport = `-- name: RequeueReport :one
+UPDATE reports
+SET status           = 'draft',
+    error_message    = NULL,
+    claimed_by       = NULL,
+    claim_expires_at = NULL,
+    ai_analysis      = NULL,
+    ai_narrative     = NULL,
+    relationships    = NULL,
+    job_state        = NULL
+WHERE id = $1
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+// Returns a report to draft so the worker's poller generates it again.
+func (q *Queries) RequeueReport(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.requeueReportStmt, requeueReport, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const requeueReportNarrative = `-- name: RequeueReportNarrative :one
+UPDATE reports
+SET status           = 'draft',
+    error_message    = NULL,
+    claimed_by       = NULL,
+    claim_expires_at = NULL,
+    ai_narrative     = NULL,
+    relationships    = NULL,
+    job_state        = NULL
+WHERE id = $1
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+// Like RequeueReport, but keeps the stored AI analysis so the worker only
+// runs the narrative stage again.
+func (q *Queries) RequeueReportNarrative(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.requeueReportNarrativeStmt, requeueReportNarrative, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const resolveDuplicatePurchase = `-- name: ResolveDuplicatePurchase :one
+UPDATE duplicate_purchases
+SET resolution       = $1::text,
+    stripe_refund_id = $2,
+    resolved_at      = now()
+WHERE session_id = $3 AND resolution IS NULL
+RETURNING session_id, original_session_id, changed_answers, resolution, stripe_refund_id, resolved_at, created_at
+`
+
+type ResolveDuplicatePurchaseParams struct {
+	Resolution     string         `db:"resolution" json:"resolution"`
+	StripeRefundID sql.NullString `db:"stripe_refund_id" json:"stripe_refund_id"`
+	SessionID      uuid.UUID      `db:"session_id" json:"session_id"`
+}
+
+// Returns no rows when the duplicate is unknown or already resolved.
+func (q *Queries) ResolveDuplicatePurchase(ctx context.Context, arg ResolveDuplicatePurchaseParams) (DuplicatePurchase, error) {
+	row := q.queryRow(ctx, q.resolveDuplicatePurchaseStmt, resolveDuplicatePurchase, arg.Resolution, arg.StripeRefundID, arg.SessionID)
+	var i DuplicatePurchase
+	err := row.Scan(
+		&i.SessionID,
+		&i.OriginalSessionID,
+		&i.ChangedAnswers,
+		&i.Resolution,
+		&i.StripeRefundID,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const revokeReport = `-- name: RevokeReport :one
+UPDATE reports
+SET revoked_at     = now(),
+    revoked_reason = $1::text
+WHERE id = $2 AND revoked_at IS NULL
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+type RevokeReportParams struct {
+	RevokedReason string    `db:"revoked_reason" json:"revoked_reason"`
+	ID            uuid.UUID `db:"id" json:"id"`
+}
+
+// Soft-deletes a report: the row and its results stay for accounting and
+// audit, but the access token stops working and the worker skips it. Returns
+// no rows when the report does not exist or is already revoked.
+func (q *Queries) RevokeReport(ctx context.Context, arg RevokeReportParams) (Report, error) {
+	row := q.queryRow(ctx, q.revokeReportStmt, revokeReport, arg.RevokedReason, arg.ID)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const rotateReportAccessToken = `-- name: RotateReportAccessToken :one
+UPDATE reports
+SET access_token = encode(gen_random_bytes(24), 'base64url')
+WHERE id = $1
+  AND access_token = $2
+RETURNING access_token
+`
+
+type RotateReportAccessTokenParams struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	AccessToken string    `db:"access_token" json:"access_token"`
+}
+
+// Gives a report a new access token, so links holding the old one stop
+// working. Matching the old token makes concurrent rotations issue one new
+// token; the loser gets no row.
+func (q *Queries) RotateReportAccessToken(ctx context.Context, arg RotateReportAccessTokenParams) (string, error) {
+	row := q.queryRow(ctx, q.rotateReportAccessTokenStmt, rotateReportAccessToken, arg.ID, arg.AccessToken)
+	var accessToken string
+	err := row.Scan(&accessToken)
+	return accessToken, err
+}
+
+const saveReportJobState = `-- name: SaveReportJobState :exec
+UPDATE reports
+SET job_state = $2
+WHERE id = $1
+`
+
+type SaveReportJobStateParams struct {
+	ID       uuid.UUID             `db:"id" json:"id"`
+	JobState pqtype.NullRawMessage `db:"job_state" json:"job_state"`
+}
+
+// Checkpoints the worker's progress on a report; see reports.job_state.
+func (q *Queries) SaveReportJobState(ctx context.Context, arg SaveReportJobStateParams) error {
+	_, err := q.exec(ctx, q.saveReportJobStateStmt, saveReportJobState, arg.ID, arg.JobState)
+	return err
+}
+
+const setAIHedge = `-- name: SetAIHedge :one
+UPDATE risk_results
+SET ai_hedge = $2
+WHERE id = $1
+RETURNING id, report_id, question_id, rank, risk_name, risk_desc, probability, impact, score, tier, hedge, ai_hedge, section, ai_hedge_edited_at, explanation
+`
+
+type SetAIHedgeParams struct {
+	ID      uuid.UUID      `db:"id" json:"id"`
+	AiHedge sql.NullString `db:"ai_hedge" json:"ai_hedge"`
+}
+
+func (q *Queries) SetAIHedge(ctx context.Context, arg SetAIHedgeParams) (RiskResult, error) {
+	row := q.queryRow(ctx, q.setAIHedgeStmt, setAIHedge, arg.ID, arg.AiHedge)
+	var i RiskResult
+	err := row.Scan(
+		&i.ID,
+		&i.ReportID,
+		&i.QuestionID,
+		&i.Rank,
+		&i.RiskName,
+		&i.RiskDesc,
+		&i.Probability,
+		&i.Impact,
+		&i.Score,
+		&i.Tier,
+		&i.Hedge,
+		&i.AiHedge,
+		&i.Section,
+		&i.AiHedgeEditedAt,
+		&i.Explanation,
+	)
+	return i, err
+}
+
+const setEmailLogAddress = `-- name: SetEmailLogAddress :execrows
+UPDATE email_log
+SET to_address = $1::text
+WHERE id = $2::uuid AND to_address::text = $3::text
+`
+
+type SetEmailLogAddressParams struct {
+	ToAddress    string    `db:"to_address" json:"to_address"`
+	ID           uuid.UUID `db:"id" json:"id"`
+	OldToAddress string    `db:"old_to_address" json:"old_to_address"`
+}
+
+func (q *Queries) SetEmailLogAddress(ctx context.Context, arg SetEmailLogAddressParams) (int64, error) {
+	result, err := q.exec(ctx, q.setEmailLogAddressStmt, setEmailLogAddress, arg.ToAddress, arg.ID, arg.OldToAddress)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setReportError = `-- name: SetReportError :one
+UPDATE reports
+SET status        = 'error',
+    error_message = $2
+WHERE id = $1
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+type SetReportErrorParams struct {
+	ID           uuid.UUID      `db:"id" json:"id"`
+	ErrorMessage sql.NullString `db:"error_message" json:"error_message"`
+}
+
+func (q *Queries) SetReportError(ctx context.Context, arg SetReportErrorParams) (Report, error) {
+	row := q.queryRow(ctx, q.setReportErrorStmt, setReportError, arg.ID, arg.ErrorMessage)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const setReportProcessing = `-- name: SetReportProcessing :one
+UPDATE reports
+SET status = 'processing'
+WHERE id = $1
+RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at, claimed_by, claim_expires_at, revoked_at, revoked_reason, held_at, ai_analysis, ai_narrative, relationships, ai_quality_issues, ai_budget_note, not_before, flags, executive_summary_edited_at, skipped_questions, job_state
+`
+
+func (q *Queries) SetReportProcessing(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.queryRow(ctx, q.setReportProcessingStmt, setReportProcessing, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.OverallScore,
+		&i.CriticalCount,
+		&i.RisksJson,
+		&i.ExecutiveSummary,
+		&i.TopPriorityHtml,
+		&i.AccessToken,
+		&i.GeneratedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedReason,
+		&i.HeldAt,
+		&i.AiAnalysis,
+		&i.AiNarrative,
+		&i.Relationships,
+		&i.AiQualityIssues,
+		&i.AiBudgetNote,
+		&i.NotBefore,
+		&i.Flags,
+		&i.ExecutiveSummaryEditedAt,
+		pq.Array(&i.SkippedQuestions),
+		&i.JobState,
+	)
+	return i, err
+}
+
+const setSessionEmail = `-- name: SetSessionEmail :execrows
+UPDATE sessions
+SET email      = $1::text,
+    email_hash = $2::text
+WHERE id = $3::uuid AND email::text = $4::text
+`
+
+type SetSessionEmailParams struct {
+	Email     string    `db:"email" json:"email"`
+	EmailHash string    `db:"email_hash" json:"email_hash"`
+	ID        uuid.UUID `db:"id" json:"id"`
+	OldEmail  string    `db:"old_email" json:"old_email"`
+}
+
+func (q *Queries) SetSessionEmail(ctx context.Context, arg SetSessionEmailParams) (int64, error) {
+	result, err := q.exec(ctx, q.setSessionEmailStmt, setSessionEmail,
+		arg.Email,
+		arg.EmailHash,
+		arg.ID,
+		arg.OldEmail,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setStripeEventPayload = `-- name: SetStripeEventPayload :execrows
+UPDATE stripe_events
+SET payload = $1::jsonb
+WHERE stripe_event_id = $2::text AND payload = $3::jsonb
+`
+
+type SetStripeEventPayloadParams struct {
+	Payload       json.RawMessage `db:"payload" json:"payload"`
+	StripeEventID string          `db:"stripe_event_id" json:"stripe_event_id"`
+	OldPayload    json.RawMessage `db:"old_payload" json:"old_payload"`
+}
+
+func (q *Queries) SetStripeEventPayload(ctx context.Context, arg SetStripeEventPayloadParams) (int64, error) {
+	result, err := q.exec(ctx, q.setStripeEventPayloadStmt, setStripeEventPayload, arg.Payload, arg.StripeEventID, arg.OldPayload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setSubscriptionEmail = `-- name: SetSubscriptionEmail :execrows
+UPDATE subscriptions
+SET email      = $1::text,
+    email_hash = $2::text
+WHERE id = $3::uuid AND email::text = $4::text
+`
+
+type SetSubscriptionEmailParams struct {
+	Email     string    `db:"email" json:"email"`
+	EmailHash string    `db:"email_hash" json:"email_hash"`
+	ID        uuid.UUID `db:"id" json:"id"`
+	OldEmail  string    `db:"old_email" json:"old_email"`
+}
+
+func (q *Queries) SetSubscriptionEmail(ctx context.Context, arg SetSubscriptionEmailParams) (int64, error) {
+	result, err := q.exec(ctx, q.setSubscriptionEmailStmt, setSubscriptionEmail,
+		arg.Email,
+		arg.EmailHash,
+		arg.ID,
+		arg.OldEmail,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const summariseReportAttempts = `-- name: SummariseReportAttempts :many
+SELECT COALESCE(provider, '')::text       AS provider,
+       COALESCE(provider_error, '')::text AS provider_error,
+       count(*)                           AS attempts,
+       count(DISTINCT report_id)          AS reports,
+       max(created_at)::timestamptz       AS last_seen
+FROM report_attempts
+WHERE created_at >= $1::timestamptz
+GROUP BY 1, 2
+ORDER BY attempts DESC, last_seen DESC
+`
+
+type SummariseReportAttemptsRow struct {
+	Provider      string    `db:"provider" json:"provider"`
+	ProviderError string    `db:"provider_error" json:"provider_error"`
+	Attempts      int64     `db:"attempts" json:"attempts"`
+	Reports       int64     `db:"reports" json:"reports"`
+	LastSeen      time.Time `db:"last_seen" json:"last_seen"`
+}
+
+// Failed attempts since a time, grouped by provider and its last error, most
+// frequent first: "all failures last night were DeepSeek 429s" at a glance.
+func (q *Queries) SummariseReportAttempts(ctx context.Context, since time.Time) ([]SummariseReportAttemptsRow, error) {
+	rows, err := q.query(ctx, q.summariseReportAttemptsStmt, summariseReportAttempts, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SummariseReportAttemptsRow{}
+	for rows.Next() {
+		var i SummariseReportAttemptsRow
+		if err := rows.Scan(
+			&i.Provider,
+			&i.ProviderError,
+			&i.Attempts,
+			&i.Reports,
+			&i.LastSeen,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const summarizeShadowScores = `-- name: SummarizeShadowScores :many
+SELECT profile, production_band, shadow_band,
+       COUNT(*)                                          AS reports,
+       SUM(shadow_score - production_score)::bigint      AS delta_sum,
+       SUM(ABS(shadow_score - production_score))::bigint AS abs_delta_sum
+FROM shadow_scores
+WHERE created_at >= $1::timestamptz
+GROUP BY profile, production_band, shadow_band
+ORDER BY profile, production_band, shadow_band
+`
+
+type SummarizeShadowScoresRow struct {
+	Profile        string `db:"profile" json:"profile"`
+	ProductionBand string `db:"production_band" json:"production_band"`
+	ShadowBand     string `db:"shadow_band" json:"shadow_band"`
+	Reports        int64  `db:"reports" json:"reports"`
+	DeltaSum       int64  `db:"delta_sum" json:"delta_sum"`
+	AbsDeltaSum    int64  `db:"abs_delta_sum" json:"abs_delta_sum"`
+}
+
+// Shadow scores recorded since the given time, per candidate profile and
+// pair of bands, with the sums of the score differences (shadow − production).
+func (q *Queries) SummarizeShadowScores(ctx context.Context, since time.Time) ([]SummarizeShadowScoresRow, error) {
+	rows, err := q.query(ctx, q.summarizeShadowScoresStmt, summarizeShadowScores, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SummarizeShadowScoresRow{}
+	for rows.Next() {
+		var i SummarizeShadowScoresRow
+		if err := rows.Scan(
+			&i.Profile,
+			&i.ProductionBand,
+			&i.ShadowBand,
+			&i.Reports,
+			&i.DeltaSum,
+			&i.AbsDeltaSum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const suppressEmail = `-- name: SuppressEmail :exec
+INSERT INTO email_suppressions (email_hash, reason) VALUES ($1, $2)
+ON CONFLICT (email_hash) DO NOTHING
 `
 
-type SetAIHedgeParams struct {
-	ID      uuid.UUID      `db:"id" json:"id"`
-	AiHedge sql.NullString `db:"ai_hedge" json:"ai_hedge"`
+type SuppressEmailParams struct {
+	EmailHash string `db:"email_hash" json:"email_hash"`
+	Reason    string `db:"reason" json:"reason"`
 }
 
-func (q *Queries) SetAIHedge(ctx context.Context, arg SetAIHedgeParams) (RiskResult, error) {
-	row := q.queryRow(ctx, q.setAIHedgeStmt, setAIHedge, arg.ID, arg.AiHedge)
-	var i RiskResult
-	err := row.Scan(
-		&i.ID,
-		&i.ReportID,
-		&i.QuestionID,
-		&i.Rank,
-		&i.RiskName,
-		&i.RiskDesc,
-		&i.Probability,
-		&i.Impact,
-		&i.Score,
-		&i.Tier,
-		&i.Hedge,
-		&i.AiHedge,
-		&i.Section,
-	)
-	return i, err
+// Stops optional emails (feedback requests) to an address, by its blind
+// index. The first reason is kept.
+func (q *Queries) SuppressEmail(ctx context.Context, arg SuppressEmailParams) error {
+	_, err := q.exec(ctx, q.suppressEmailStmt, suppressEmail, arg.EmailHash, arg.Reason)
+	return err
 }
 
-const setReportError = `-- name: SetReportError :one
-UPDATE reports
-SET status        = 'error',
-    error_message = $2
-WHERE id = $1
-RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at
+const suppressSessionEmail = `-- name: SuppressSessionEmail :exec
+INSERT INTO email_suppressions (email_hash, reason)
+SELECT email_hash, $1::text FROM sessions
+WHERE id = $2 AND email_hash IS NOT NULL
+ON CONFLICT (email_hash) DO NOTHING
 `
 
-type SetReportErrorParams struct {
-	ID           uuid.UUID      `db:"id" json:"id"`
-	ErrorMessage sql.NullString `db:"error_message" json:"error_message"`
+type SuppressSessionEmailParams struct {
+	Reason    string    `db:"reason" json:"reason"`
+	SessionID uuid.UUID `db:"session_id" json:"session_id"`
 }
 
-func (q *Queries) SetReportError(ctx context.Context, arg SetReportErrorParams) (Report, error) {
-	row := q.queryRow(ctx, q.setReportErrorStmt, setReportError, arg.ID, arg.ErrorMessage)
-	var i Report
-	err := row.Scan(
-		&i.ID,
-		&i.SessionID,
-		&i.Status,
-		&i.ErrorMessage,
-		&i.OverallScore,
-		&i.CriticalCount,
-		&i.RisksJson,
-		&i.ExecutiveSummary,
-		&i.TopPriorityHtml,
-		&i.AccessToken,
-		&i.GeneratedAt,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) SuppressSessionEmail(ctx context.Context, arg SuppressSessionEmailParams) error {
+	_, err := q.exec(ctx, q.suppressSessionEmailStmt, suppressSessionEmail, arg.Reason, arg.SessionID)
+	return err
 }
 
-const setReportProcessing = `-- name: SetReportProcessing :one
-UPDATE reports
-SET status = 'processing'
+const tryLockSession = `-- name: TryLockSession :one
+SELECT pg_try_advisory_lock(hashtextextended('session:' || ($1::uuid)::text, 0))::boolean AS locked
+`
+
+// Takes the session's advisory lock on this connection without waiting, and
+// returns whether it was taken. Held until UnlockSession or the connection
+// closes; see store.LockSession.
+func (q *Queries) TryLockSession(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	row := q.queryRow(ctx, q.tryLockSessionStmt, tryLockSession, sessionID)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
+
+const unlockSession = `-- name: UnlockSession :one
+SELECT pg_advisory_unlock(hashtextextended('session:' || ($1::uuid)::text, 0))::boolean AS unlocked
+`
+
+func (q *Queries) UnlockSession(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	row := q.queryRow(ctx, q.unlockSessionStmt, unlockSession, sessionID)
+	var unlocked bool
+	err := row.Scan(&unlocked)
+	return unlocked, err
+}
+
+const updateQuestionSection = `-- name: UpdateQuestionSection :one
+UPDATE question_sections
+SET title = $2, description = $3, display_order = $4, gated = $5
 WHERE id = $1
-RETURNING id, session_id, status, error_message, overall_score, critical_count, risks_json, executive_summary, top_priority_html, access_token, generated_at, created_at, updated_at
+RETURNING id, title, description, display_order, gated, updated_at
 `
 
-func (q *Queries) SetReportProcessing(ctx context.Context, id uuid.UUID) (Report, error) {
-	row := q.queryRow(ctx, q.setReportProcessingStmt, setReportProcessing, id)
-	var i Report
+type UpdateQuestionSectionParams struct {
+	ID           SectionID `db:"id" json:"id"`
+	Title        string    `db:"title" json:"title"`
+	Description  string    `db:"description" json:"description"`
+	DisplayOrder int16     `db:"display_order" json:"display_order"`
+	Gated        bool      `db:"gated" json:"gated"`
+}
+
+func (q *Queries) UpdateQuestionSection(ctx context.Context, arg UpdateQuestionSectionParams) (QuestionSection, error) {
+	row := q.queryRow(ctx, q.updateQuestionSectionStmt, updateQuestionSection,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.DisplayOrder,
+		arg.Gated,
+	)
+	var i QuestionSection
 	err := row.Scan(
 		&i.ID,
-		&i.SessionID,
-		&i.Status,
-		&i.ErrorMessage,
-		&i.OverallScore,
-		&i.CriticalCount,
-		&i.RisksJson,
-		&i.ExecutiveSummary,
-		&i.TopPriorityHtml,
-		&i.AccessToken,
-		&i.GeneratedAt,
-		&i.CreatedAt,
+		&i.Title,
+		&i.Description,
+		&i.DisplayOrder,
+		&i.Gated,
 		&i.UpdatedAt,
 	)
 	return i, err
@@ -1222,7 +6053,7 @@ SET biz_name = $2,
     industry = $3,
     stage    = $4
 WHERE id = $1
-RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at
+RETURNING id, anon_token, email, biz_name, industry, stage, stripe_customer_id, stripe_payment_intent, payment_status, paid_at, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, created_at, updated_at, product_sku, subscription_id, billing_country, billing_postal_code, subtotal_cents, tax_cents, tax_calculation_id, billing_name, billing_address_line1, billing_address_line2, billing_city, billing_tax_id, invoice_number, email_hash, credit_from_session_id, partner, cohort
 `
 
 type UpdateSessionContextParams struct {
@@ -1259,21 +6090,113 @@ func (q *Queries) UpdateSessionContext(ctx context.Context, arg UpdateSessionCon
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ProductSku,
+		&i.SubscriptionID,
+		&i.BillingCountry,
+		&i.BillingPostalCode,
+		&i.SubtotalCents,
+		&i.TaxCents,
+		&i.TaxCalculationID,
+		&i.BillingName,
+		&i.BillingAddressLine1,
+		&i.BillingAddressLine2,
+		&i.BillingCity,
+		&i.BillingTaxID,
+		&i.InvoiceNumber,
+		&i.EmailHash,
+		&i.CreditFromSessionID,
+		&i.Partner,
+		&i.Cohort,
+	)
+	return i, err
+}
+
+const updateTierTheme = `-- name: UpdateTierTheme :one
+UPDATE tier_themes
+SET label       = $2,
+    color       = $3,
+    description = $4,
+    cadence     = $5
+WHERE tier = $1
+RETURNING tier, label, color, description, cadence, updated_at
+`
+
+type UpdateTierThemeParams struct {
+	Tier        RiskTier `db:"tier" json:"tier"`
+	Label       string   `db:"label" json:"label"`
+	Color       string   `db:"color" json:"color"`
+	Description string   `db:"description" json:"description"`
+	Cadence     string   `db:"cadence" json:"cadence"`
+}
+
+func (q *Queries) UpdateTierTheme(ctx context.Context, arg UpdateTierThemeParams) (TierTheme, error) {
+	row := q.queryRow(ctx, q.updateTierThemeStmt, updateTierTheme,
+		arg.Tier,
+		arg.Label,
+		arg.Color,
+		arg.Description,
+		arg.Cadence,
+	)
+	var i TierTheme
+	err := row.Scan(
+		&i.Tier,
+		&i.Label,
+		&i.Color,
+		&i.Description,
+		&i.Cadence,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const upsertAICacheEntry = `-- name: UpsertAICacheEntry :exec
+INSERT INTO ai_cache (fingerprint, hedges, executive_summary, top_priority_html, analysis, relationships)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (fingerprint) DO UPDATE SET
+    hedges            = EXCLUDED.hedges,
+    executive_summary = EXCLUDED.executive_summary,
+    top_priority_html = EXCLUDED.top_priority_html,
+    analysis          = EXCLUDED.analysis,
+    relationships     = EXCLUDED.relationships,
+    hit_count         = 0,
+    created_at        = now(),
+    last_hit_at       = NULL
+`
+
+type UpsertAICacheEntryParams struct {
+	Fingerprint      string                `db:"fingerprint" json:"fingerprint"`
+	Hedges           json.RawMessage       `db:"hedges" json:"hedges"`
+	ExecutiveSummary string                `db:"executive_summary" json:"executive_summary"`
+	TopPriorityHtml  string                `db:"top_priority_html" json:"top_priority_html"`
+	Analysis         pqtype.NullRawMessage `db:"analysis" json:"analysis"`
+	Relationships    pqtype.NullRawMessage `db:"relationships" json:"relationships"`
+}
+
+// Replaces an expired entry for the same fingerprint rather than failing.
+func (q *Queries) UpsertAICacheEntry(ctx context.Context, arg UpsertAICacheEntryParams) error {
+	_, err := q.exec(ctx, q.upsertAICacheEntryStmt, upsertAICacheEntry,
+		arg.Fingerprint,
+		arg.Hedges,
+		arg.ExecutiveSummary,
+		arg.TopPriorityHtml,
+		arg.Analysis,
+		arg.Relationships,
+	)
+	return err
+}
+
 const upsertAnswer = `-- name: UpsertAnswer :one
 
-INSERT INTO answers (session_id, question_id, answer_text, client_p, client_i)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO answers (session_id, question_id, answer_text, client_p, client_i, skipped)
+VALUES ($1, $2, $3, $4, $5, $6)
 ON CONFLICT (session_id, question_id)
 DO UPDATE SET
     answer_text = EXCLUDED.answer_text,
     client_p    = EXCLUDED.client_p,
     client_i    = EXCLUDED.client_i,
+    skipped     = EXCLUDED.skipped,
     updated_at  = now()
-RETURNING id, session_id, question_id, answer_text, client_p, client_i, answered_at, updated_at
+RETURNING id, session_id, question_id, answer_text, client_p, client_i, answered_at, updated_at, skipped
 `
 
 type UpsertAnswerParams struct {
@@ -1282,6 +6205,7 @@ type UpsertAnswerParams struct {
 	AnswerText string        `db:"answer_text" json:"answer_text"`
 	ClientP    sql.NullInt16 `db:"client_p" json:"client_p"`
 	ClientI    sql.NullInt16 `db:"client_i" json:"client_i"`
+	Skipped    bool          `db:"skipped" json:"skipped"`
 }
 
 // ---------------------------------------------------------------------------
@@ -1294,6 +6218,7 @@ func (q *Queries) UpsertAnswer(ctx context.Context, arg UpsertAnswerParams) (Ans
 		arg.AnswerText,
 		arg.ClientP,
 		arg.ClientI,
+		arg.Skipped,
 	)
 	var i Answer
 	err := row.Scan(
@@ -1305,10 +6230,375 @@ func (q *Queries) UpsertAnswer(ctx context.Context, arg UpsertAnswerParams) (Ans
 		&i.ClientI,
 		&i.AnsweredAt,
 		&i.UpdatedAt,
+		&i.Skipped,
+	)
+	return i, err
+}
+
+const upsertConsultationRequest = `-- name: UpsertConsultationRequest :one
+
+INSERT INTO consultation_requests (report_id, session_id, note)
+VALUES ($1, $2, $3)
+ON CONFLICT (report_id) DO UPDATE
+SET note = COALESCE(EXCLUDED.note, consultation_requests.note)
+RETURNING id, report_id, session_id, note, created_at
+`
+
+type UpsertConsultationRequestParams struct {
+	ReportID  uuid.UUID      `db:"report_id" json:"report_id"`
+	SessionID uuid.UUID      `db:"session_id" json:"session_id"`
+	Note      sql.NullString `db:"note" json:"note"`
+}
+
+// ---------------------------------------------------------------------------
+// CONSULTATION REQUESTS
+// ---------------------------------------------------------------------------
+func (q *Queries) UpsertConsultationRequest(ctx context.Context, arg UpsertConsultationRequestParams) (ConsultationRequest, error) {
+	row := q.queryRow(ctx, q.upsertConsultationRequestStmt, upsertConsultationRequest, arg.ReportID, arg.SessionID, arg.Note)
+	var i ConsultationRequest
+	err := row.Scan(
+		&i.ID,
+		&i.ReportID,
+		&i.SessionID,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertFeatureFlag = `-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (name, environment, percent)
+VALUES ($1, $2, $3)
+ON CONFLICT (name, environment) DO UPDATE SET percent = EXCLUDED.percent
+RETURNING name, environment, percent, updated_at
+`
+
+type UpsertFeatureFlagParams struct {
+	Name        string `db:"name" json:"name"`
+	Environment string `db:"environment" json:"environment"`
+	Percent     int16  `db:"percent" json:"percent"`
+}
+
+func (q *Queries) UpsertFeatureFlag(ctx context.Context, arg UpsertFeatureFlagParams) (FeatureFlag, error) {
+	row := q.queryRow(ctx, q.upsertFeatureFlagStmt, upsertFeatureFlag, arg.Name, arg.Environment, arg.Percent)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.Name,
+		&i.Environment,
+		&i.Percent,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertPayment = `-- name: UpsertPayment :one
+
+INSERT INTO payments (
+    stripe_charge_id, stripe_payment_intent, stripe_balance_transaction_id,
+    session_id, amount_cents, fee_cents, net_cents, currency
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT (stripe_charge_id) DO UPDATE
+SET stripe_payment_intent         = EXCLUDED.stripe_payment_intent,
+    stripe_balance_transaction_id = EXCLUDED.stripe_balance_transaction_id,
+    session_id                    = COALESCE(EXCLUDED.session_id, payments.session_id),
+    amount_cents                  = EXCLUDED.amount_cents,
+    fee_cents                     = EXCLUDED.fee_cents,
+    net_cents                     = EXCLUDED.net_cents,
+    currency                      = EXCLUDED.currency
+RETURNING id, stripe_charge_id, stripe_payment_intent, stripe_balance_transaction_id, session_id, amount_cents, fee_cents, net_cents, currency, created_at, updated_at
+`
+
+type UpsertPaymentParams struct {
+	StripeChargeID             string         `db:"stripe_charge_id" json:"stripe_charge_id"`
+	StripePaymentIntent        sql.NullString `db:"stripe_payment_intent" json:"stripe_payment_intent"`
+	StripeBalanceTransactionID string         `db:"stripe_balance_transaction_id" json:"stripe_balance_transaction_id"`
+	SessionID                  uuid.NullUUID  `db:"session_id" json:"session_id"`
+	AmountCents                int64          `db:"amount_cents" json:"amount_cents"`
+	FeeCents                   int64          `db:"fee_cents" json:"fee_cents"`
+	NetCents                   int64          `db:"net_cents" json:"net_cents"`
+	Currency                   string         `db:"currency" json:"currency"`
+}
+
+// ---------------------------------------------------------------------------
+// PAYMENTS
+// ---------------------------------------------------------------------------
+// Called for charge.succeeded and charge.updated. A charge's balance
+// transaction does not change once it exists, so redelivery rewrites the
+// same values.
+func (q *Queries) UpsertPayment(ctx context.Context, arg UpsertPaymentParams) (Payment, error) {
+	row := q.queryRow(ctx, q.upsertPaymentStmt, upsertPayment,
+		arg.StripeChargeID,
+		arg.StripePaymentIntent,
+		arg.StripeBalanceTransactionID,
+		arg.SessionID,
+		arg.AmountCents,
+		arg.FeeCents,
+		arg.NetCents,
+		arg.Currency,
+	)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.StripeChargeID,
+		&i.StripePaymentIntent,
+		&i.StripeBalanceTransactionID,
+		&i.SessionID,
+		&i.AmountCents,
+		&i.FeeCents,
+		&i.NetCents,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertPlaybookSnippet = `-- name: UpsertPlaybookSnippet :one
+INSERT INTO playbook_snippets (slug, industry, title, body, keywords, active)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (slug) DO UPDATE SET
+    industry = EXCLUDED.industry,
+    title    = EXCLUDED.title,
+    body     = EXCLUDED.body,
+    keywords = EXCLUDED.keywords,
+    active   = EXCLUDED.active
+RETURNING slug, industry, title, body, keywords, active, created_at, updated_at
+`
+
+type UpsertPlaybookSnippetParams struct {
+	Slug     string   `db:"slug" json:"slug"`
+	Industry string   `db:"industry" json:"industry"`
+	Title    string   `db:"title" json:"title"`
+	Body     string   `db:"body" json:"body"`
+	Keywords []string `db:"keywords" json:"keywords"`
+	Active   bool     `db:"active" json:"active"`
+}
+
+func (q *Queries) UpsertPlaybookSnippet(ctx context.Context, arg UpsertPlaybookSnippetParams) (PlaybookSnippet, error) {
+	row := q.queryRow(ctx, q.upsertPlaybookSnippetStmt, upsertPlaybookSnippet,
+		arg.Slug,
+		arg.Industry,
+		arg.Title,
+		arg.Body,
+		pq.Array(arg.Keywords),
+		arg.Active,
+	)
+	var i PlaybookSnippet
+	err := row.Scan(
+		&i.Slug,
+		&i.Industry,
+		&i.Title,
+		&i.Body,
+		pq.Array(&i.Keywords),
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertProduct = `-- name: UpsertProduct :one
+INSERT INTO products (sku, name, price_cents, currency, report_type, active)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (sku) DO UPDATE SET
+    name        = EXCLUDED.name,
+    price_cents = EXCLUDED.price_cents,
+    currency    = EXCLUDED.currency,
+    report_type = EXCLUDED.report_type,
+    active      = EXCLUDED.active
+RETURNING sku, name, price_cents, currency, report_type, active, created_at, updated_at
+`
+
+type UpsertProductParams struct {
+	Sku        string     `db:"sku" json:"sku"`
+	Name       string     `db:"name" json:"name"`
+	PriceCents int32      `db:"price_cents" json:"price_cents"`
+	Currency   string     `db:"currency" json:"currency"`
+	ReportType ReportType `db:"report_type" json:"report_type"`
+	Active     bool       `db:"active" json:"active"`
+}
+
+func (q *Queries) UpsertProduct(ctx context.Context, arg UpsertProductParams) (Product, error) {
+	row := q.queryRow(ctx, q.upsertProductStmt, upsertProduct,
+		arg.Sku,
+		arg.Name,
+		arg.PriceCents,
+		arg.Currency,
+		arg.ReportType,
+		arg.Active,
+	)
+	var i Product
+	err := row.Scan(
+		&i.Sku,
+		&i.Name,
+		&i.PriceCents,
+		&i.Currency,
+		&i.ReportType,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertQuestionDefinition = `-- name: UpsertQuestionDefinition :one
+INSERT INTO question_definitions (
+    id, question_version, section_id, section_title, display_order,
+    text, subtext, type, opts, placeholder, required,
+    risk_name, risk_desc, hedge, scoring_config, is_scoring
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+ON CONFLICT (id) DO UPDATE SET
+    question_version = EXCLUDED.question_version,
+    section_id       = EXCLUDED.section_id,
+    section_title    = EXCLUDED.section_title,
+    display_order    = EXCLUDED.display_order,
+    text             = EXCLUDED.text,
+    subtext          = EXCLUDED.subtext,
+    type             = EXCLUDED.type,
+    opts             = EXCLUDED.opts,
+    placeholder      = EXCLUDED.placeholder,
+    required         = EXCLUDED.required,
+    risk_name        = EXCLUDED.risk_name,
+    risk_desc        = EXCLUDED.risk_desc,
+    hedge            = EXCLUDED.hedge,
+    scoring_config   = EXCLUDED.scoring_config,
+    is_scoring       = EXCLUDED.is_scoring
+RETURNING id, question_version, section_id, section_title, display_order, text, subtext, type, opts, placeholder, required, risk_name, risk_desc, hedge, scoring_config, is_scoring, created_at
+`
+
+type UpsertQuestionDefinitionParams struct {
+	ID              string          `db:"id" json:"id"`
+	QuestionVersion int16           `db:"question_version" json:"question_version"`
+	SectionID       SectionID       `db:"section_id" json:"section_id"`
+	SectionTitle    string          `db:"section_title" json:"section_title"`
+	DisplayOrder    int16           `db:"display_order" json:"display_order"`
+	Text            string          `db:"text" json:"text"`
+	Subtext         sql.NullString  `db:"subtext" json:"subtext"`
+	Type            QuestionType    `db:"type" json:"type"`
+	Opts            []string        `db:"opts" json:"opts"`
+	Placeholder     sql.NullString  `db:"placeholder" json:"placeholder"`
+	Required        bool            `db:"required" json:"required"`
+	RiskName        string          `db:"risk_name" json:"risk_name"`
+	RiskDesc        string          `db:"risk_desc" json:"risk_desc"`
+	Hedge           string          `db:"hedge" json:"hedge"`
+	ScoringConfig   json.RawMessage `db:"scoring_config" json:"scoring_config"`
+	IsScoring       bool            `db:"is_scoring" json:"is_scoring"`
+}
+
+// Used by the question seed loader (internal/seed). created_at is kept on
+// update.
+func (q *Queries) UpsertQuestionDefinition(ctx context.Context, arg UpsertQuestionDefinitionParams) (QuestionDefinition, error) {
+	row := q.queryRow(ctx, q.upsertQuestionDefinitionStmt, upsertQuestionDefinition,
+		arg.ID,
+		arg.QuestionVersion,
+		arg.SectionID,
+		arg.SectionTitle,
+		arg.DisplayOrder,
+		arg.Text,
+		arg.Subtext,
+		arg.Type,
+		pq.Array(arg.Opts),
+		arg.Placeholder,
+		arg.Required,
+		arg.RiskName,
+		arg.RiskDesc,
+		arg.Hedge,
+		arg.ScoringConfig,
+		arg.IsScoring,
+	)
+	var i QuestionDefinition
+	err := row.Scan(
+		&i.ID,
+		&i.QuestionVersion,
+		&i.SectionID,
+		&i.SectionTitle,
+		&i.DisplayOrder,
+		&i.Text,
+		&i.Subtext,
+		&i.Type,
+		pq.Array(&i.Opts),
+		&i.Placeholder,
+		&i.Required,
+		&i.RiskName,
+		&i.RiskDesc,
+		&i.Hedge,
+		&i.ScoringConfig,
+		&i.IsScoring,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const upsertRuntimeSetting = `-- name: UpsertRuntimeSetting :one
+INSERT INTO runtime_settings (key, value)
+VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value
+RETURNING key, value, updated_at
+`
+
+type UpsertRuntimeSettingParams struct {
+	Key   string `db:"key" json:"key"`
+	Value string `db:"value" json:"value"`
+}
+
+func (q *Queries) UpsertRuntimeSetting(ctx context.Context, arg UpsertRuntimeSettingParams) (RuntimeSetting, error) {
+	row := q.queryRow(ctx, q.upsertRuntimeSettingStmt, upsertRuntimeSetting, arg.Key, arg.Value)
+	var i RuntimeSetting
+	err := row.Scan(&i.Key, &i.Value, &i.UpdatedAt)
+	return i, err
+}
+
+const upsertShadowScore = `-- name: UpsertShadowScore :exec
+
+INSERT INTO shadow_scores (report_id, profile, production_profile, production_score, shadow_score, production_band, shadow_band)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (report_id, profile) DO UPDATE SET
+    production_profile = EXCLUDED.production_profile,
+    production_score   = EXCLUDED.production_score,
+    shadow_score       = EXCLUDED.shadow_score,
+    production_band    = EXCLUDED.production_band,
+    shadow_band        = EXCLUDED.shadow_band,
+    created_at         = now()
+`
+
+type UpsertShadowScoreParams struct {
+	ReportID          uuid.UUID `db:"report_id" json:"report_id"`
+	Profile           string    `db:"profile" json:"profile"`
+	ProductionProfile string    `db:"production_profile" json:"production_profile"`
+	ProductionScore   int16     `db:"production_score" json:"production_score"`
+	ShadowScore       int16     `db:"shadow_score" json:"shadow_score"`
+	ProductionBand    string    `db:"production_band" json:"production_band"`
+	ShadowBand        string    `db:"shadow_band" json:"shadow_band"`
+}
+
+// ---------------------------------------------------------------------------
+// SHADOW SCORES
+// ---------------------------------------------------------------------------
+// A regenerated report replaces its earlier shadow score for the profile.
+func (q *Queries) UpsertShadowScore(ctx context.Context, arg UpsertShadowScoreParams) error {
+	_, err := q.exec(ctx, q.upsertShadowScoreStmt, upsertShadowScore,
+		arg.ReportID,
+		arg.Profile,
+		arg.ProductionProfile,
+		arg.ProductionScore,
+		arg.ShadowScore,
+		arg.ProductionBand,
+		arg.ShadowBand,
+	)
+	return err
+}
+
 const upsertStripeEvent = `-- name: UpsertStripeEvent :one
 
 INSERT INTO stripe_events (stripe_event_id, type, payload)
@@ -1340,3 +6630,66 @@ func (q *Queries) UpsertStripeEvent(ctx context.Context, arg UpsertStripeEventPa
 	)
 	return i, err
 }
+
+const upsertSubscription = `-- name: UpsertSubscription :one
+
+INSERT INTO subscriptions (stripe_subscription_id, stripe_customer_id, email, email_hash, status, current_period_start, current_period_end)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    COALESCE($5::text, 'active'),
+    $6,
+    $7
+)
+ON CONFLICT (stripe_subscription_id) DO UPDATE
+SET stripe_customer_id   = EXCLUDED.stripe_customer_id,
+    email                = COALESCE(EXCLUDED.email, subscriptions.email),
+    email_hash           = COALESCE(EXCLUDED.email_hash, subscriptions.email_hash),
+    status               = COALESCE($5::text, subscriptions.status),
+    current_period_start = COALESCE(EXCLUDED.current_period_start, subscriptions.current_period_start),
+    current_period_end   = COALESCE(EXCLUDED.current_period_end, subscriptions.current_period_end)
+RETURNING id, stripe_subscription_id, stripe_customer_id, email, status, current_period_start, current_period_end, created_at, updated_at, email_hash
+`
+
+type UpsertSubscriptionParams struct {
+	StripeSubscriptionID string         `db:"stripe_subscription_id" json:"stripe_subscription_id"`
+	StripeCustomerID     string         `db:"stripe_customer_id" json:"stripe_customer_id"`
+	Email                sql.NullString `db:"email" json:"email"`
+	EmailHash            sql.NullString `db:"email_hash" json:"email_hash"`
+	Status               sql.NullString `db:"status" json:"status"`
+	CurrentPeriodStart   sql.NullTime   `db:"current_period_start" json:"current_period_start"`
+	CurrentPeriodEnd     sql.NullTime   `db:"current_period_end" json:"current_period_end"`
+}
+
+// ---------------------------------------------------------------------------
+// SUBSCRIPTIONS
+// ---------------------------------------------------------------------------
+// Called for customer.subscription.* and invoice.paid. Null arguments leave
+// the stored value alone, so each event only overwrites what it carries.
+func (q *Queries) UpsertSubscription(ctx context.Context, arg UpsertSubscriptionParams) (Subscription, error) {
+	row := q.queryRow(ctx, q.upsertSubscriptionStmt, upsertSubscription,
+		arg.StripeSubscriptionID,
+		arg.StripeCustomerID,
+		arg.Email,
+		arg.EmailHash,
+		arg.Status,
+		arg.CurrentPeriodStart,
+		arg.CurrentPeriodEnd,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.StripeSubscriptionID,
+		&i.StripeCustomerID,
+		&i.Email,
+		&i.Status,
+		&i.CurrentPeriodStart,
+		&i.CurrentPeriodEnd,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EmailHash,
+	)
+	return i, err
+}
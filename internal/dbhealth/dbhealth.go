@@ -0,0 +1,147 @@
+// Package dbhealth probes the database in the background so the API can tell
+// an outage from a slow query. Once enough probes in a row fail, the Monitor
+// reports the database down and the API answers from its report cache or
+// with 503 straight away, instead of every request waiting out its own
+// timeout. While down it probes every second, so service resumes as soon as
+// the pool can reconnect.
+package dbhealth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// recoveryInterval is how often a Monitor probes while the database is down.
+const recoveryInterval = time.Second
+
+// Pinger is implemented by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Config holds the probe settings.
+type Config struct {
+	// Interval is how often the database is probed while up. Default: 5s.
+	Interval time.Duration
+	// Timeout bounds each probe. Default: 2s.
+	Timeout time.Duration
+	// Failures is how many probes in a row must fail before the database
+	// counts as down. Default: 2.
+	Failures int
+}
+
+// Monitor is safe for concurrent use. A nil *Monitor always reports the
+// database up.
+type Monitor struct {
+	db     Pinger
+	cfg    Config
+	logger *slog.Logger
+
+	mu        sync.RWMutex
+	failures  int
+	down      bool
+	downSince time.Time
+	lastErr   error
+}
+
+// New returns a Monitor for db. It starts out up; call Start to probe.
+func New(db Pinger, cfg Config, logger *slog.Logger) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.Failures <= 0 {
+		cfg.Failures = 2
+	}
+	return &Monitor{db: db, cfg: cfg, logger: logger}
+}
+
+// Check probes the database once and records the result.
+func (m *Monitor) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	err := m.db.PingContext(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		if m.down {
+			m.logger.Info("database: recovered", "down_for", time.Since(m.downSince).Round(time.Second))
+		}
+		m.failures, m.down, m.lastErr = 0, false, nil
+		return nil
+	}
+
+	m.failures++
+	m.lastErr = err
+	if !m.down && m.failures >= m.cfg.Failures {
+		m.down, m.downSince = true, time.Now()
+		m.logger.Error("database: unreachable, serving degraded", "failures", m.failures, "error", err)
+	}
+	return err
+}
+
+// Start probes on every interval until ctx is cancelled, and every second
+// while the database is down.
+func (m *Monitor) Start(ctx context.Context) {
+	timer := time.NewTimer(m.cfg.Interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			_ = m.Check(ctx)
+			timer.Reset(m.next())
+		}
+	}
+}
+
+func (m *Monitor) next() time.Duration {
+	if m.Up() {
+		return m.cfg.Interval
+	}
+	return min(recoveryInterval, m.cfg.Interval)
+}
+
+// Up reports whether the database is usable as far as the probes know.
+func (m *Monitor) Up() bool {
+	if m == nil {
+		return true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !m.down
+}
+
+// Err returns nil while the database is up, and the last probe error
+// otherwise. It suits a readiness check.
+func (m *Monitor) Err() error {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.down {
+		return nil
+	}
+	if m.lastErr == nil {
+		return errors.New("database: down")
+	}
+	return m.lastErr
+}
+
+// RetryAfter is a hint for clients refused while the database is down: the
+// time until the next probe could bring it back.
+func (m *Monitor) RetryAfter() time.Duration {
+	if m == nil {
+		return 0
+	}
+	return min(recoveryInterval, m.cfg.Interval)
+}
@@ -0,0 +1,42 @@
+package dbhealth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+type fakeDB struct{ err error }
+
+func (f *fakeDB) PingContext(context.Context) error { return f.err }
+
+func TestCheck_GoesDownAfterFailuresAndRecovers(t *testing.T) {
+	db := &fakeDB{err: errors.New("connection refused")}
+	m := New(db, Config{Failures: 2}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	if err := m.Check(ctx); err == nil || !m.Up() {
+		t.Fatalf("one failed probe should not take the database down (err=%v, up=%v)", err, m.Up())
+	}
+	_ = m.Check(ctx)
+	if m.Up() || m.Err() == nil {
+		t.Fatal("expected the database down after two failed probes")
+	}
+	if m.next() != recoveryInterval {
+		t.Errorf("expected recovery probing every %s, got %s", recoveryInterval, m.next())
+	}
+
+	db.err = nil
+	if err := m.Check(ctx); err != nil || !m.Up() || m.Err() != nil {
+		t.Fatalf("expected recovery on the first good probe (err=%v, up=%v)", err, m.Up())
+	}
+}
+
+func TestNilMonitorIsAlwaysUp(t *testing.T) {
+	var m *Monitor
+	if !m.Up() || m.Err() != nil || m.RetryAfter() != 0 {
+		t.Error("a nil Monitor must report the database up")
+	}
+}
@@ -0,0 +1,131 @@
+// Package dbretry runs read-only queries again when they fail because the
+// connection went away — a managed Postgres failing over, restarting or
+// dropping an idle connection — so a failover costs a polling report page a
+// few hundred milliseconds instead of a 500.
+//
+// Only statements with a sqlc header whose SQL is a plain SELECT are retried,
+// and only when the error arrives before any row is read; writes are never
+// retried, since one may have been applied before the connection dropped.
+// Wrap the pool's handle, not a transaction's: a transaction does not survive
+// its connection.
+package dbretry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/querywatch"
+)
+
+// Config holds the retry settings.
+type Config struct {
+	// Attempts is how many times a read is run in total. Values below 2
+	// disable retrying.
+	Attempts int
+	// Backoff is the wait before the first retry; it doubles after each.
+	// Default: 50ms.
+	Backoff time.Duration
+}
+
+// Wrap returns h with its read-only queries retried on transient errors.
+func Wrap(h db.DBTX, cfg Config, logger *slog.Logger) db.DBTX {
+	if cfg.Attempts < 2 {
+		return h
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 50 * time.Millisecond
+	}
+	return retrying{DBTX: h, cfg: cfg, logger: logger}
+}
+
+// retrying is a db.DBTX that retries reads.
+type retrying struct {
+	db.DBTX
+	cfg    Config
+	logger *slog.Logger
+}
+
+func (r retrying) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if !readOnly(query) {
+		return r.DBTX.QueryContext(ctx, query, args...)
+	}
+	var rows *sql.Rows
+	err := r.do(ctx, query, func() (err error) {
+		rows, err = r.DBTX.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (r retrying) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if !readOnly(query) {
+		return r.DBTX.QueryRowContext(ctx, query, args...)
+	}
+	var row *sql.Row
+	_ = r.do(ctx, query, func() error {
+		row = r.DBTX.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// do runs fn, a read of query, until it succeeds, fails for good or has been
+// run cfg.Attempts times, and returns its last error.
+func (r retrying) do(ctx context.Context, query string, fn func() error) error {
+	backoff := r.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.cfg.Attempts || !Transient(err) {
+			return err
+		}
+		r.logger.WarnContext(ctx, "db: retrying read after transient error",
+			"statement", querywatch.StatementName(query),
+			"attempt", attempt,
+			"error", err,
+		)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Transient reports whether err means the connection, not the query, failed:
+// a connection exception (SQLSTATE class 08), the server shutting down or
+// still starting (57P01, 57P02, 57P03), or the driver finding the connection
+// broken.
+func Transient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case "57P01", "57P02", "57P03":
+		return true
+	}
+	return pqErr.Code.Class() == "08"
+}
+
+// readOnly reports whether query is a sqlc statement that only reads.
+func readOnly(query string) bool {
+	if querywatch.StatementName(query) == querywatch.Unnamed {
+		return false
+	}
+	_, body, _ := strings.Cut(query, "\n")
+	body = strings.TrimSpace(body)
+	return len(body) >= 6 && strings.EqualFold(body[:6], "SELECT")
+}
@@ -0,0 +1,79 @@
+package dbretry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestTransient(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&pq.Error{Code: "57P01"}, true}, // admin_shutdown
+		{&pq.Error{Code: "08006"}, true}, // connection_failure
+		{fmt.Errorf("get report: %w", driver.ErrBadConn), true},
+		{&pq.Error{Code: "40001"}, false}, // serialization_failure: a tx retry's job
+		{&pq.Error{Code: "23505"}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, c := range cases {
+		if got := Transient(c.err); got != c.want {
+			t.Errorf("Transient(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	cases := map[string]bool{
+		"-- name: GetReportByAccessToken :one\nSELECT r.id FROM reports r":            true,
+		"-- name: ListStripeEvents :many\n  select id FROM stripe_events":             true,
+		"-- name: CreateReport :one\nINSERT INTO reports DEFAULT VALUES RETURNING id": false,
+		"SELECT set_config('application_name', $1, true)":                             false,
+	}
+	for query, want := range cases {
+		if got := readOnly(query); got != want {
+			t.Errorf("readOnly(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestDo_RetriesTransientErrorsUpToAttempts(t *testing.T) {
+	r := retrying{cfg: Config{Attempts: 3, Backoff: time.Millisecond}, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	const query = "-- name: GetReportByAccessToken :one\nSELECT 1"
+
+	calls := 0
+	err := r.do(context.Background(), query, func() error {
+		calls++
+		if calls == 1 {
+			return &pq.Error{Code: "57P01"}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("after a failover: err = %v, calls = %d; want success on the second call", err, calls)
+	}
+
+	calls = 0
+	err = r.do(context.Background(), query, func() error {
+		calls++
+		return &pq.Error{Code: "08006"}
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("database down: err = %v, calls = %d; want the error after 3 calls", err, calls)
+	}
+
+	calls = 0
+	notFound := errors.New("not transient")
+	if err := r.do(context.Background(), query, func() error { calls++; return notFound }); err != notFound || calls != 1 {
+		t.Errorf("permanent error: err = %v, calls = %d; want it returned at once", err, calls)
+	}
+}
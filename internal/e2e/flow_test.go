@@ -0,0 +1,258 @@
+//go:build integration
+
+// Package e2e_test runs the whole purchase flow in-process against a real,
+// freshly migrated Postgres: session → answers → checkout → signed
+// payment_intent.succeeded webhook → worker → report. Stripe's API, the AI
+// provider and email delivery are faked; everything between them is the
+// production code, with field encryption on. It builds with the integration
+// tag and needs docker (see internal/testdb).
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v82/webhook"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/api"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/seed"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/testdb"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/pkg/client"
+)
+
+func TestMain(m *testing.M) { os.Exit(testdb.Main(m)) }
+
+const webhookSecret = "whsec_e2e"
+
+const questions = `{
+  "version": 1,
+  "questions": [
+    {
+      "id": "e2e_runway", "section_id": "dependency", "section_title": "Dependency", "display_order": 1,
+      "text": "How much cash runway do you have?", "type": "radio", "opts": ["< 3 months", "> 12 months"],
+      "risk_name": "Cash runway", "risk_desc": "Running out of cash.", "hedge": "Extend runway.",
+      "scoring_config": {"type": "radio", "opts": ["< 3 months", "> 12 months"], "p_scores": [9, 2], "i_scores": [9, 2]}
+    },
+    {
+      "id": "e2e_supplier", "section_id": "operational", "section_title": "Operational", "display_order": 1,
+      "text": "How many suppliers could you lose?", "type": "radio", "opts": ["None", "Several"],
+      "risk_name": "Supplier concentration", "risk_desc": "One supplier failing stops trading.", "hedge": "Dual-source.",
+      "scoring_config": {"type": "radio", "opts": ["None", "Several"], "p_scores": [3, 8], "i_scores": [8, 3]}
+    }
+  ]
+}`
+
+// ─── FAKES ────────────────────────────────────────────────────────────────────
+
+// fakeStripe answers the Stripe API calls locally. Webhook verification is
+// the real client's, so the test signs events exactly as Stripe does.
+type fakeStripe struct {
+	stripeinternal.Client
+}
+
+func (fakeStripe) CreatePaymentIntent(_ context.Context, _ stripeinternal.CreatePaymentIntentParams) (stripeinternal.PaymentIntent, error) {
+	id := "pi_e2e_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	return stripeinternal.PaymentIntent{ID: id, ClientSecret: id + "_secret"}, nil
+}
+
+func (fakeStripe) GetClientSecret(_ context.Context, id string) (string, error) {
+	return id + "_secret", nil
+}
+
+func (fakeStripe) GetCharge(_ context.Context, id string) (stripeinternal.Charge, error) {
+	return stripeinternal.Charge{ID: id, CardBrand: "visa", CardLast4: "4242"}, nil
+}
+
+type fakeHedger struct{}
+
+func (fakeHedger) GenerateHedges(_ context.Context, risks []scoring.ScoredRisk) (ai.HedgeResult, error) {
+	res := ai.HedgeResult{Hedges: map[string]string{}, ExecutiveSummary: "Summary."}
+	for _, r := range risks {
+		res.Hedges[r.QuestionID] = "AI hedge for " + r.RiskName
+	}
+	return res, nil
+}
+
+// fakeMailer hands report-ready emails to the test.
+type fakeMailer struct {
+	mu       sync.Mutex
+	receipts []email.ReceiptParams
+	ready    chan email.ReportReadyParams
+}
+
+func (m *fakeMailer) SendReportReady(_ context.Context, p email.ReportReadyParams) (email.Sent, error) {
+	select {
+	case m.ready <- p:
+	default: // a duplicate delivery; the test only reads the first
+	}
+	return email.Sent{ProviderID: "msg_report_" + p.AccessToken, Subject: "Your Risk Assessment is Ready"}, nil
+}
+
+func (m *fakeMailer) SendReceipt(_ context.Context, p email.ReceiptParams) (email.Sent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.receipts = append(m.receipts, p)
+	return email.Sent{ProviderID: "msg_receipt", Subject: "Payment Confirmed"}, nil
+}
+
+func (m *fakeMailer) SendFeedbackRequest(context.Context, email.FeedbackRequestParams) (email.Sent, error) {
+	return email.Sent{ProviderID: "msg_feedback", Subject: "How did your Risk Assessment do?"}, nil
+}
+
+// ─── FLOW ─────────────────────────────────────────────────────────────────────
+
+func TestPurchaseFlow(t *testing.T) {
+	pool := testdb.OpenContainer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	codec, err := fieldcrypt.New([]string{"e2e:" + strings.Repeat("A", 43) + "="}, "e2e-index")
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	st := store.New(pool, db.New(pool))
+	st.SetCodec(codec)
+	q := st.Q()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	file, err := seed.Parse(strings.NewReader(questions))
+	if err != nil {
+		t.Fatalf("parse questions: %v", err)
+	}
+	if err := st.UpsertQuestionDefinitions(ctx, seed.Diff(file, nil).Changes()); err != nil {
+		t.Fatalf("seed questions: %v", err)
+	}
+
+	mailer := &fakeMailer{ready: make(chan email.ReportReadyParams, 1)}
+	job := worker.NewJob(q, st, fakeHedger{}, mailer, worker.JobConfig{}, logger)
+	runner := worker.NewRunner(job, st, q, worker.RunnerConfig{Workers: 1, PollInterval: 500 * time.Millisecond, MaxRetries: 1}, logger)
+	go runner.Start(ctx)
+
+	handler := api.NewServer(q, st, fakeStripe{stripeinternal.NewClient("sk_test_e2e")}, runner, mailer, api.Config{
+		Env:                  "development",
+		BaseURL:              "http://localhost",
+		StripeWebhookSecrets: []string{webhookSecret},
+	}, logger)
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := client.New(client.Config{BaseURL: srv.URL, MaxRetries: -1})
+
+	// ── Questionnaire and checkout ────────────────────────────────────────────
+	sess, err := c.CreateSession(ctx, client.CreateSessionRequest{BizName: "Acme", Industry: "SaaS", Stage: "growth"})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := c.UpsertAnswers(ctx, sess, []client.Answer{
+		{QuestionID: "e2e_runway", AnswerText: "< 3 months"},
+		{QuestionID: "e2e_supplier", AnswerText: "Several"},
+	}); err != nil {
+		t.Fatalf("upsert answers: %v", err)
+	}
+	checkout, err := c.CreateCheckout(ctx, sess, client.CheckoutRequest{Email: "owner@example.com"})
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	piID := strings.TrimSuffix(checkout.ClientSecret, "_secret")
+
+	// ── Payment ───────────────────────────────────────────────────────────────
+	postSignedEvent(t, srv.URL, "payment_intent.succeeded", map[string]any{
+		"id": piID, "object": "payment_intent", "amount_received": checkout.AmountCents,
+		"currency": checkout.Currency, "latest_charge": "ch_e2e",
+	})
+
+	// ── Worker and report ─────────────────────────────────────────────────────
+	var delivered email.ReportReadyParams
+	select {
+	case delivered = <-mailer.ready:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the report email")
+	}
+	if delivered.To != "owner@example.com" || delivered.BizName != "Acme" {
+		t.Errorf("unexpected report email: %+v", delivered)
+	}
+
+	report, err := c.GetReport(ctx, delivered.AccessToken)
+	if err != nil {
+		t.Fatalf("get report: %v", err)
+	}
+	if !report.Ready() || len(report.Risks) != 2 {
+		t.Fatalf("expected a ready report with 2 risks, got %+v", report)
+	}
+	top := report.Risks[0]
+	if top.QuestionID != "e2e_runway" || top.Score != 81 || top.Tier != "watch" || top.Hedge != "AI hedge for Cash runway" {
+		t.Errorf("unexpected top risk: %+v", top)
+	}
+	if report.OverallScore != 53 || report.CriticalCount != 1 {
+		t.Errorf("expected overall 53 with 1 critical, got %d and %d", report.OverallScore, report.CriticalCount)
+	}
+
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+	if len(mailer.receipts) != 1 || mailer.receipts[0].CardLast4 != "4242" {
+		t.Errorf("expected one receipt with card details, got %+v", mailer.receipts)
+	}
+
+	// ── At rest ───────────────────────────────────────────────────────────────
+	var storedEmail, storedPayload string
+	if err := pool.QueryRowContext(ctx, "SELECT email::text FROM sessions WHERE id = $1", sess.SessionID).Scan(&storedEmail); err != nil {
+		t.Fatalf("read stored email: %v", err)
+	}
+	if !strings.HasPrefix(storedEmail, "enc:v1:e2e:") {
+		t.Errorf("expected the session email encrypted at rest, got %q", storedEmail)
+	}
+	if err := pool.QueryRowContext(ctx, "SELECT payload::text FROM stripe_events LIMIT 1").Scan(&storedPayload); err != nil {
+		t.Fatalf("read stored payload: %v", err)
+	}
+	if strings.Contains(storedPayload, piID) {
+		t.Errorf("expected the Stripe payload encrypted at rest, got %s", storedPayload)
+	}
+}
+
+// postSignedEvent delivers a webhook signed with webhookSecret.
+func postSignedEvent(t *testing.T, baseURL, typ string, object map[string]any) {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"id":          "evt_e2e_" + uuid.NewString(),
+		"object":      "event",
+		"type":        typ,
+		"api_version": "2025-03-31.basil",
+		"created":     time.Now().Unix(),
+		"data":        map[string]any{"object": object},
+	})
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: webhookSecret})
+
+	req, _ := http.NewRequest(http.MethodPost, baseURL+"/api/webhooks/stripe", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", signed.Header)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("webhook: HTTP %d: %s", resp.StatusCode, body)
+	}
+}
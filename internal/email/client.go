@@ -9,14 +9,54 @@ type ReportReadyParams struct {
 	To          string // recipient email address
 	BizName     string // used in the subject line; may be empty
 	AccessToken string // opaque token — inserted into the report URL
+	// Reminder marks a resend of an email that was never opened; the subject
+	// says so.
+	Reminder bool
 }
 
 // ReceiptParams holds the data for the post-payment receipt email.
 type ReceiptParams struct {
 	To          string
 	BizName     string
-	AmountCents int64  // e.g. 5900 for $59.00
+	AmountCents int64  // amount actually received, in minor units, e.g. 5900 for $59.00
 	Currency    string // e.g. "usd"
+	TaxCents    int64  // tax included in AmountCents; 0 when none was charged
+	CardBrand   string // e.g. "visa"; empty for non-card payments
+	CardLast4   string // empty for non-card payments
+	ReceiptURL  string // Stripe-hosted receipt; may be empty
+
+	// ReadyInMinutes is the expected wait for the report when the worker is
+	// backed up; 0 means the usual "shortly".
+	ReadyInMinutes int
+}
+
+// FeedbackRequestParams holds the data for the rating/testimonial request
+// sent a while after delivery.
+type FeedbackRequestParams struct {
+	To      string
+	BizName string
+	Token   string // feedback.token — inserted into the rating and unsubscribe links
+}
+
+// Template names recorded in email_log.template.
+const (
+	TemplateReportReady = "report_ready"
+	TemplateReceipt     = "receipt"
+	// TemplateReportReminder is the automatic resend of an unopened
+	// report-ready email. It is never resent itself.
+	TemplateReportReminder = "report_ready_reminder"
+	// TemplateFeedbackRequest asks for a rating and testimonial. It is
+	// optional, so never sent to a suppressed address.
+	TemplateFeedbackRequest = "feedback_request"
+)
+
+// Sent describes a message handed to the provider, for email_log.
+type Sent struct {
+	// ProviderID is the provider's message ID, which its tracking webhooks
+	// refer to. Empty when the send failed.
+	ProviderID string
+	// Subject is set even when the send failed.
+	Subject string
 }
 
 // Sender is the interface the worker and webhook handler use to send email.
@@ -24,9 +64,13 @@ type ReceiptParams struct {
 type Sender interface {
 	// SendReportReady sends the "your report is ready" email with the access
 	// token link. Called by the worker after PersistScoredReport succeeds.
-	SendReportReady(ctx context.Context, p ReportReadyParams) error
+	SendReportReady(ctx context.Context, p ReportReadyParams) (Sent, error)
 
 	// SendReceipt sends the payment receipt. Called by the webhook handler
 	// immediately after payment confirmation, before the report is generated.
-	SendReceipt(ctx context.Context, p ReceiptParams) error
+	SendReceipt(ctx context.Context, p ReceiptParams) (Sent, error)
+
+	// SendFeedbackRequest asks for a 0–10 rating and a testimonial, with a
+	// link to unsubscribe. Called by the worker's feedback requester.
+	SendFeedbackRequest(ctx context.Context, p FeedbackRequestParams) (Sent, error)
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// LogEntry says who an email went to and what it was about.
+type LogEntry struct {
+	SessionID uuid.UUID // uuid.Nil when not tied to a session
+	ReportID  uuid.UUID // uuid.Nil when not tied to a report
+	To        string
+	Template  string // one of the Template constants
+	// DedupeKey, when set, makes the email one that is sent at most once:
+	// see Claim. Empty for emails that may legitimately go out again.
+	DedupeKey string
+}
+
+// ErrDuplicate is returned by Claim when an email with the same DedupeKey
+// was already sent, or is being sent by another attempt.
+var ErrDuplicate = errors.New("email: already sent")
+
+// claimTimeout is how long a claim may go unfinished — the process died
+// between claiming and recording — before another attempt takes it over.
+const claimTimeout = 10 * time.Minute
+
+// DedupeKey returns the key for an email sent at most once per report, or ""
+// when there is no report to tie it to.
+func DedupeKey(reportID uuid.UUID, template string) string {
+	if reportID == uuid.Nil {
+		return ""
+	}
+	return reportID.String() + ":" + template
+}
+
+// Claim reserves e.DedupeKey before the email is sent, so webhook and job
+// retries cannot send it twice. It returns ErrDuplicate when the key is
+// already claimed, after recording the skipped send in email_log against the
+// claiming row. Record finishes the claim with the outcome of the send; a
+// failed send releases it for the next attempt. Claim does nothing for an
+// entry without a DedupeKey.
+//
+// Any other error means the claim could not be checked; callers send anyway
+// rather than lose the email.
+func Claim(ctx context.Context, q db.EmailLogStore, e LogEntry) error {
+	if e.DedupeKey == "" {
+		return nil
+	}
+	key := sql.NullString{String: e.DedupeKey, Valid: true}
+	_, err := q.ClaimEmail(ctx, db.ClaimEmailParams{
+		SessionID:   uuid.NullUUID{UUID: e.SessionID, Valid: e.SessionID != uuid.Nil},
+		ReportID:    uuid.NullUUID{UUID: e.ReportID, Valid: e.ReportID != uuid.Nil},
+		ToAddress:   e.To,
+		Template:    e.Template,
+		DedupeKey:   key,
+		StaleBefore: time.Now().Add(-claimTimeout),
+	})
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	original, err := q.GetEmailByDedupeKey(ctx, key)
+	if err != nil {
+		return errors.Join(ErrDuplicate, fmt.Errorf("get claiming email: %w", err))
+	}
+	_, err = q.LogSkippedEmail(ctx, db.LogSkippedEmailParams{
+		SessionID:   uuid.NullUUID{UUID: e.SessionID, Valid: e.SessionID != uuid.Nil},
+		ReportID:    uuid.NullUUID{UUID: e.ReportID, Valid: e.ReportID != uuid.Nil},
+		ToAddress:   e.To,
+		Subject:     original.Subject,
+		Template:    e.Template,
+		DuplicateOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		return errors.Join(ErrDuplicate, fmt.Errorf("log skipped email: %w", err))
+	}
+	return ErrDuplicate
+}
+
+// Record writes the outcome of a send to email_log: the provider's message ID
+// on success, so tracking webhooks can find the row, or sendErr on failure.
+// With a DedupeKey it finishes the row Claim created.
+// Callers log Record's own error and carry on — the email has already gone.
+func Record(ctx context.Context, q db.EmailLogStore, e LogEntry, sent Sent, sendErr error) error {
+	if e.DedupeKey != "" {
+		err := finishClaim(ctx, q, e, sent, sendErr)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		// Never claimed (Claim failed and the email was sent anyway):
+		// log it as an ordinary send.
+	}
+
+	session := uuid.NullUUID{UUID: e.SessionID, Valid: e.SessionID != uuid.Nil}
+	report := uuid.NullUUID{UUID: e.ReportID, Valid: e.ReportID != uuid.Nil}
+	if sendErr != nil {
+		_, err := q.LogEmailFailure(ctx, db.LogEmailFailureParams{
+			SessionID: session,
+			ReportID:  report,
+			ToAddress: e.To,
+			Subject:   sent.Subject,
+			Template:  e.Template,
+			Error:     sql.NullString{String: sendErr.Error(), Valid: true},
+		})
+		return err
+	}
+	_, err := q.LogEmail(ctx, db.LogEmailParams{
+		SessionID:  session,
+		ReportID:   report,
+		ToAddress:  e.To,
+		Subject:    sent.Subject,
+		Template:   e.Template,
+		ProviderID: sql.NullString{String: sent.ProviderID, Valid: sent.ProviderID != ""},
+	})
+	return err
+}
+
+func finishClaim(ctx context.Context, q db.EmailLogStore, e LogEntry, sent Sent, sendErr error) error {
+	key := sql.NullString{String: e.DedupeKey, Valid: true}
+	if sendErr != nil {
+		_, err := q.ReleaseEmailClaim(ctx, db.ReleaseEmailClaimParams{
+			Subject:   sent.Subject,
+			Error:     sql.NullString{String: sendErr.Error(), Valid: true},
+			DedupeKey: key,
+		})
+		return err
+	}
+	_, err := q.MarkEmailClaimSent(ctx, db.MarkEmailClaimSentParams{
+		Subject:    sent.Subject,
+		ProviderID: sql.NullString{String: sent.ProviderID, Valid: sent.ProviderID != ""},
+		DedupeKey:  key,
+	})
+	return err
+}
@@ -0,0 +1,128 @@
+package email_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// logStore keeps email_log rows in memory, with the claim semantics of the
+// queries: one claimed row per dedupe_key.
+type logStore struct {
+	rows []db.EmailLog
+}
+
+func (s *logStore) add(e db.EmailLog) (db.EmailLog, error) {
+	e.ID = uuid.New()
+	s.rows = append(s.rows, e)
+	return e, nil
+}
+
+func (s *logStore) byKey(key sql.NullString) *db.EmailLog {
+	for i := range s.rows {
+		if s.rows[i].DedupeKey == key {
+			return &s.rows[i]
+		}
+	}
+	return nil
+}
+
+func (s *logStore) LogEmail(_ context.Context, arg db.LogEmailParams) (db.EmailLog, error) {
+	return s.add(db.EmailLog{ToAddress: arg.ToAddress, Template: arg.Template, ProviderID: arg.ProviderID, SentAt: sql.NullTime{Valid: true}})
+}
+
+func (s *logStore) LogEmailFailure(_ context.Context, arg db.LogEmailFailureParams) (db.EmailLog, error) {
+	return s.add(db.EmailLog{ToAddress: arg.ToAddress, Template: arg.Template, Error: arg.Error})
+}
+
+func (s *logStore) ClaimEmail(_ context.Context, arg db.ClaimEmailParams) (db.EmailLog, error) {
+	if s.byKey(arg.DedupeKey) != nil {
+		return db.EmailLog{}, sql.ErrNoRows
+	}
+	return s.add(db.EmailLog{ToAddress: arg.ToAddress, Template: arg.Template, DedupeKey: arg.DedupeKey})
+}
+
+func (s *logStore) MarkEmailClaimSent(_ context.Context, arg db.MarkEmailClaimSentParams) (db.EmailLog, error) {
+	e := s.byKey(arg.DedupeKey)
+	if e == nil || e.SentAt.Valid {
+		return db.EmailLog{}, sql.ErrNoRows
+	}
+	e.Subject, e.ProviderID, e.SentAt = arg.Subject, arg.ProviderID, sql.NullTime{Valid: true}
+	return *e, nil
+}
+
+func (s *logStore) ReleaseEmailClaim(_ context.Context, arg db.ReleaseEmailClaimParams) (db.EmailLog, error) {
+	e := s.byKey(arg.DedupeKey)
+	if e == nil || e.SentAt.Valid {
+		return db.EmailLog{}, sql.ErrNoRows
+	}
+	e.Subject, e.Error, e.DedupeKey = arg.Subject, arg.Error, sql.NullString{}
+	return *e, nil
+}
+
+func (s *logStore) GetEmailByDedupeKey(_ context.Context, key sql.NullString) (db.EmailLog, error) {
+	if e := s.byKey(key); e != nil {
+		return *e, nil
+	}
+	return db.EmailLog{}, sql.ErrNoRows
+}
+
+func (s *logStore) LogSkippedEmail(_ context.Context, arg db.LogSkippedEmailParams) (db.EmailLog, error) {
+	return s.add(db.EmailLog{ToAddress: arg.ToAddress, Template: arg.Template, Subject: arg.Subject, DuplicateOf: arg.DuplicateOf})
+}
+
+func TestClaim_SendsOncePerKeyAndLogsSkippedDuplicates(t *testing.T) {
+	ctx := context.Background()
+	q := &logStore{}
+	reportID := uuid.New()
+	entry := email.LogEntry{
+		ReportID:  reportID,
+		To:        "owner@example.com",
+		Template:  email.TemplateReceipt,
+		DedupeKey: email.DedupeKey(reportID, email.TemplateReceipt),
+	}
+
+	// A failed send releases its claim, so the retry sends.
+	if err := email.Claim(ctx, q, entry); err != nil {
+		t.Fatalf("first claim: %v", err)
+	}
+	if err := email.Record(ctx, q, entry, email.Sent{Subject: "Payment Confirmed"}, errors.New("provider down")); err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+	if err := email.Claim(ctx, q, entry); err != nil {
+		t.Fatalf("claim after a failed send: %v", err)
+	}
+	if err := email.Record(ctx, q, entry, email.Sent{ProviderID: "msg_1", Subject: "Payment Confirmed"}, nil); err != nil {
+		t.Fatalf("record send: %v", err)
+	}
+	sent := q.rows[1]
+
+	if err := email.Claim(ctx, q, entry); !errors.Is(err, email.ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate once sent, got %v", err)
+	}
+	if len(q.rows) != 3 {
+		t.Fatalf("expected failure, send and skip rows, got %+v", q.rows)
+	}
+	skipped := q.rows[2]
+	if skipped.DuplicateOf.UUID != sent.ID || skipped.SentAt.Valid || skipped.Subject != "Payment Confirmed" {
+		t.Errorf("unexpected skipped row %+v (sent row %s)", skipped, sent.ID)
+	}
+}
+
+func TestClaim_IgnoresEntriesWithoutAKey(t *testing.T) {
+	q := &logStore{}
+	entry := email.LogEntry{To: "owner@example.com", Template: email.TemplateReportReady, DedupeKey: email.DedupeKey(uuid.Nil, email.TemplateReportReady)}
+	for i := 0; i < 2; i++ {
+		if err := email.Claim(context.Background(), q, entry); err != nil {
+			t.Fatalf("claim %d: %v", i, err)
+		}
+	}
+	if len(q.rows) != 0 {
+		t.Errorf("expected no claim rows, got %+v", q.rows)
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
 )
 
 // resendClient is the concrete Sender backed by the Resend API.
@@ -16,16 +19,20 @@ type resendClient struct {
 	fromAddr   string // e.g. "reports@asymmetricrisk.com"
 	fromName   string // e.g. "Asymmetric Risk"
 	baseURL    string // report access URL base, e.g. "https://app.asymmetricrisk.com"
+	consultURL string // scheduling link offered in the report email; may be empty
 	httpClient *http.Client
 }
 
-// NewResendClient returns a Sender that delivers email via Resend.
-func NewResendClient(apiKey, fromAddr, fromName, baseURL string) Sender {
+// NewResendClient returns a Sender that delivers email via Resend. When
+// consultURL is non-empty the report-ready email offers a paid consultation
+// booked through that link.
+func NewResendClient(apiKey, fromAddr, fromName, baseURL, consultURL string) Sender {
 	return &resendClient{
-		apiKey:   apiKey,
-		fromAddr: fromAddr,
-		fromName: fromName,
-		baseURL:  baseURL,
+		apiKey:     apiKey,
+		fromAddr:   fromAddr,
+		fromName:   fromName,
+		baseURL:    baseURL,
+		consultURL: consultURL,
 		httpClient: &http.Client{
 			Timeout: 15 * time.Second,
 		},
@@ -53,35 +60,58 @@ type resendResponse struct {
 // ─── SENDER IMPLEMENTATION ────────────────────────────────────────────────────
 
 // SendReportReady sends the "your report is ready" delivery email.
-func (c *resendClient) SendReportReady(ctx context.Context, p ReportReadyParams) error {
+func (c *resendClient) SendReportReady(ctx context.Context, p ReportReadyParams) (Sent, error) {
 	subject := "Your Risk Assessment is Ready"
 	if p.BizName != "" {
 		subject = fmt.Sprintf("%s — Your Risk Assessment is Ready", p.BizName)
 	}
+	if p.Reminder {
+		subject = "Reminder: " + subject
+	}
 
 	reportURL := fmt.Sprintf("%s/report/%s", c.baseURL, p.AccessToken)
 
-	html := reportReadyHTML(p.BizName, reportURL)
+	html := reportReadyHTML(p.BizName, reportURL, c.consultURL)
 
 	return c.send(ctx, p.To, subject, html)
 }
 
 // SendReceipt sends the post-payment receipt email.
-func (c *resendClient) SendReceipt(ctx context.Context, p ReceiptParams) error {
+func (c *resendClient) SendReceipt(ctx context.Context, p ReceiptParams) (Sent, error) {
 	subject := "Your payment was received"
 	if p.BizName != "" {
 		subject = fmt.Sprintf("%s — Payment Confirmed", p.BizName)
 	}
 
-	amount := fmt.Sprintf("$%.2f", float64(p.AmountCents)/100)
-	html := receiptHTML(p.BizName, amount)
+	amount := formatAmount(p.AmountCents, p.Currency)
+	if p.TaxCents > 0 {
+		amount += fmt.Sprintf(" (including %s tax)", formatAmount(p.TaxCents, p.Currency))
+	}
+	html := receiptHTML(p.BizName, amount, cardLabel(p.CardBrand, p.CardLast4), p.ReceiptURL, p.ReadyInMinutes)
+
+	return c.send(ctx, p.To, subject, html)
+}
+
+// SendFeedbackRequest sends the rating/testimonial request.
+func (c *resendClient) SendFeedbackRequest(ctx context.Context, p FeedbackRequestParams) (Sent, error) {
+	subject := "How did your Risk Assessment do?"
+	if p.BizName != "" {
+		subject = fmt.Sprintf("%s — How did your Risk Assessment do?", p.BizName)
+	}
+
+	feedbackURL := fmt.Sprintf("%s/feedback/%s", c.baseURL, p.Token)
+
+	html := feedbackRequestHTML(p.BizName, feedbackURL)
 
 	return c.send(ctx, p.To, subject, html)
 }
 
 // ─── HTTP SEND ────────────────────────────────────────────────────────────────
 
-func (c *resendClient) send(ctx context.Context, to, subject, html string) error {
+// send posts one message to Resend. The returned Sent carries the subject
+// whatever the outcome, and Resend's message ID on success.
+func (c *resendClient) send(ctx context.Context, to, subject, html string) (Sent, error) {
+	sent := Sent{Subject: subject}
 	from := fmt.Sprintf("%s <%s>", c.fromName, c.fromAddr)
 
 	reqBody := resendRequest{
@@ -93,7 +123,7 @@ func (c *resendClient) send(ctx context.Context, to, subject, html string) error
 
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		return fmt.Errorf("email: marshal request: %w", err)
+		return sent, fmt.Errorf("email: marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
@@ -101,47 +131,115 @@ func (c *resendClient) send(ctx context.Context, to, subject, html string) error
 		bytes.NewReader(bodyBytes),
 	)
 	if err != nil {
-		return fmt.Errorf("email: build request: %w", err)
+		return sent, fmt.Errorf("email: build request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	requestid.SetHeader(ctx, req.Header)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("email: http request: %w", err)
+		return sent, fmt.Errorf("email: http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBytes, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
 	if err != nil {
-		return fmt.Errorf("email: read response: %w", err)
+		return sent, fmt.Errorf("email: read response: %w", err)
 	}
 
 	var parsed resendResponse
 	if err := json.Unmarshal(respBytes, &parsed); err != nil {
-		return fmt.Errorf("email: unmarshal response (status %d): %w", resp.StatusCode, err)
+		return sent, fmt.Errorf("email: unmarshal response (status %d): %w", resp.StatusCode, err)
 	}
 
 	if parsed.Error != nil {
-		return fmt.Errorf("email: Resend error %s: %s", parsed.Error.Name, parsed.Error.Message)
+		return sent, fmt.Errorf("email: Resend error %s: %s", parsed.Error.Name, parsed.Error.Message)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("email: unexpected status %d: %.200s", resp.StatusCode, string(respBytes))
+		return sent, fmt.Errorf("email: unexpected status %d: %.200s", resp.StatusCode, string(respBytes))
+	}
+
+	sent.ProviderID = parsed.ID
+	return sent, nil
+}
+
+// ─── FORMATTING ───────────────────────────────────────────────────────────────
+
+// currencySymbols covers the currencies we expect to sell in. Anything else is
+// shown with its ISO code after the amount.
+var currencySymbols = map[string]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+}
+
+// zeroDecimalCurrencies are charged in whole units — Stripe amounts for them
+// are not multiplied by 100.
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true,
+	"kmf": true, "krw": true, "mga": true, "pyg": true, "rwf": true,
+	"ugx": true, "vnd": true, "vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// formatAmount renders a Stripe amount for display, e.g. 5900 usd → "$59.00"
+// and 5900 jpy → "5900 JPY".
+func formatAmount(amount int64, currency string) string {
+	currency = strings.ToLower(currency)
+
+	value := fmt.Sprintf("%d.%02d", amount/100, amount%100)
+	if zeroDecimalCurrencies[currency] {
+		value = fmt.Sprintf("%d", amount)
 	}
 
-	return nil
+	if sym, ok := currencySymbols[currency]; ok {
+		return sym + value
+	}
+	return value + " " + strings.ToUpper(currency)
+}
+
+// cardBrandNames maps Stripe's card brand identifiers to display names.
+var cardBrandNames = map[string]string{
+	"amex":       "American Express",
+	"diners":     "Diners Club",
+	"discover":   "Discover",
+	"jcb":        "JCB",
+	"mastercard": "Mastercard",
+	"unionpay":   "UnionPay",
+	"visa":       "Visa",
+}
+
+// cardLabel returns e.g. "Visa ending in 4242", or "" when the payment was
+// not made by card.
+func cardLabel(brand, last4 string) string {
+	if last4 == "" {
+		return ""
+	}
+	name, ok := cardBrandNames[brand]
+	if !ok {
+		name = "Card"
+	}
+	return fmt.Sprintf("%s ending in %s", name, last4)
 }
 
 // ─── HTML TEMPLATES ───────────────────────────────────────────────────────────
 
-func reportReadyHTML(bizName, reportURL string) string {
+func reportReadyHTML(bizName, reportURL, consultURL string) string {
 	greeting := "Hello"
 	if bizName != "" {
 		greeting = fmt.Sprintf("Hello %s", bizName)
 	}
 
+	consult := ""
+	if consultURL != "" {
+		consult = fmt.Sprintf(`
+  <p>Want help putting the plan into action? Book a one-to-one consultation
+  to walk through your top risks with an advisor:
+  <a href="%s" style="color: #0f172a; font-weight: 600;">schedule a call</a>.</p>`, consultURL)
+	}
+
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head><meta charset="utf-8"></head>
@@ -161,30 +259,48 @@ func reportReadyHTML(bizName, reportURL string) string {
     Bookmark this link — it is your permanent access to your report.<br>
     If the button above does not work, copy this URL:<br>
     <a href="%s" style="color: #6b7280;">%s</a>
-  </p>
+  </p>%s
   <hr style="border: none; border-top: 1px solid #e5e7eb; margin: 32px 0;">
   <p style="color: #9ca3af; font-size: 12px;">
     Asymmetric Risk Mapper · One-time assessment · No account required
   </p>
 </body>
-</html>`, greeting, reportURL, reportURL, reportURL)
+</html>`, greeting, reportURL, reportURL, reportURL, consult)
 }
 
-func receiptHTML(bizName, amount string) string {
+func receiptHTML(bizName, amount, card, receiptURL string, readyIn int) string {
 	greeting := "Hello"
 	if bizName != "" {
 		greeting = fmt.Sprintf("Hello %s", bizName)
 	}
 
+	paidWith := ""
+	if card != "" {
+		paidWith = fmt.Sprintf(" with your %s", card)
+	}
+
+	when := "shortly"
+	if readyIn > 0 {
+		when = fmt.Sprintf("in about %d minutes — we are busier than usual", readyIn)
+	}
+
+	receipt := ""
+	if receiptURL != "" {
+		receipt = fmt.Sprintf(`
+  <p style="color: #6b7280; font-size: 14px;">
+    <a href="%s" style="color: #6b7280;">View your Stripe receipt</a>
+  </p>`, receiptURL)
+	}
+
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head><meta charset="utf-8"></head>
 <body style="font-family: sans-serif; color: #1a1a1a; max-width: 560px; margin: 0 auto; padding: 24px;">
   <h2 style="margin-bottom: 8px;">Payment Confirmed</h2>
   <p>%s,</p>
-  <p>We have received your payment of <strong>%s</strong> for the
+  <p>We have received your payment of <strong>%s</strong>%s for the
   Asymmetric Risk assessment. Your report is now being generated and you
-  will receive a separate email with a link to view it shortly.</p>
+  will receive a separate email with a link to view it %s.</p>%s
   <p style="color: #6b7280; font-size: 14px;">
     If you have any questions, reply to this email.
   </p>
@@ -193,5 +309,45 @@ func receiptHTML(bizName, amount string) string {
     Asymmetric Risk Mapper · One-time assessment · No account required
   </p>
 </body>
-</html>`, greeting, amount)
-}
\ No newline at end of file
+</html>`, greeting, amount, paidWith, when, receipt)
+}
+
+// feedbackRequestHTML links each score to the feedback page with the score
+// preselected; the page collects the testimonial and consent.
+func feedbackRequestHTML(bizName, feedbackURL string) string {
+	greeting := "Hello"
+	if bizName != "" {
+		greeting = fmt.Sprintf("Hello %s", bizName)
+	}
+
+	var scores strings.Builder
+	for n := 0; n <= 10; n++ {
+		fmt.Fprintf(&scores, `
+    <a href="%s?rating=%d"
+       style="display: inline-block; width: 28px; padding: 6px 0; margin: 2px;
+              border: 1px solid #e5e7eb; border-radius: 4px; text-align: center;
+              color: #0f172a; text-decoration: none;">%d</a>`, feedbackURL, n, n)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"></head>
+<body style="font-family: sans-serif; color: #1a1a1a; max-width: 560px; margin: 0 auto; padding: 24px;">
+  <h2 style="margin-bottom: 8px;">How did we do?</h2>
+  <p>%s,</p>
+  <p>It has been a week since you received your Asymmetric Risk assessment.
+  How likely are you to recommend it to a friend or colleague?</p>
+  <p style="margin: 24px 0;">%s
+  </p>
+  <p style="color: #6b7280; font-size: 14px;">
+    0 is not at all likely, 10 is extremely likely. If you would like to add a
+    few words we may, with your permission, quote them on our website.
+  </p>
+  <hr style="border: none; border-top: 1px solid #e5e7eb; margin: 32px 0;">
+  <p style="color: #9ca3af; font-size: 12px;">
+    Asymmetric Risk Mapper · One-time assessment · No account required<br>
+    <a href="%s/unsubscribe" style="color: #9ca3af;">Unsubscribe</a> from emails like this one.
+  </p>
+</body>
+</html>`, greeting, scores.String(), feedbackURL)
+}
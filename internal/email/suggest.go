@@ -0,0 +1,137 @@
+package email
+
+import "strings"
+
+// ─── ADDRESS TYPOS ────────────────────────────────────────────────────────────
+//
+// A customer who mistypes their address at checkout pays and never receives
+// the report. Suggest catches the common cases — a misspelled webmail domain
+// (gmial.com) or top-level domain (acme.con) — so the checkout form can ask
+// "did you mean …?" before payment. It only suggests; the address is used as
+// typed.
+
+// popularDomains are the webmail domains most customers use. A domain one or
+// two keystrokes away from one of them, but not on the list, is taken for a
+// typo; lookalikes that are real providers (mail.com, email.com) are listed
+// so they are not "corrected".
+var popularDomains = []string{
+	"gmail.com", "googlemail.com",
+	"yahoo.com", "yahoo.co.uk", "ymail.com",
+	"hotmail.com", "hotmail.co.uk", "outlook.com", "live.com", "msn.com",
+	"icloud.com", "me.com", "mac.com",
+	"aol.com", "protonmail.com", "proton.me", "gmx.com", "zoho.com",
+	"mail.com", "email.com",
+}
+
+// tldTypos maps misspelled top-level domains to the intended one. Typos that
+// are themselves real TLDs (.co, .cm, .om) are left out.
+var tldTypos = map[string]string{
+	"con": "com", "cmo": "com", "ocm": "com", "vom": "com", "xom": "com",
+	"comm": "com", "coom": "com", "copm": "com", "cpm": "com",
+	"nte": "net", "ent": "net", "nett": "net",
+	"ogr": "org", "rog": "org", "orgg": "org",
+}
+
+// Suggest returns addr with its domain corrected when the domain looks like
+// a typo of a popular webmail domain or has a misspelled top-level domain,
+// and "" otherwise. The local part is kept as typed.
+func Suggest(addr string) string {
+	at := strings.LastIndexByte(addr, '@')
+	if at <= 0 || at == len(addr)-1 {
+		return ""
+	}
+	local, domain := addr[:at], strings.ToLower(strings.TrimSpace(addr[at+1:]))
+
+	if fixed := suggestDomain(domain); fixed != "" && fixed != domain {
+		return local + "@" + fixed
+	}
+	return ""
+}
+
+// suggestDomain returns the popular domain that domain is a typo of, or
+// domain with its top-level domain corrected, or "". A typo is either in the
+// provider's name (gmial.com) or in its suffix (gmail.cm), never both; a
+// suffix further off is taken for a regional domain (hotmail.de, gmx.net).
+func suggestDomain(domain string) string {
+	label, suffix, ok := strings.Cut(domain, ".")
+	if !ok {
+		return ""
+	}
+
+	best, bestDist, tie := "", 0, false
+	for _, known := range popularDomains {
+		if domain == known {
+			return ""
+		}
+		knownLabel, knownSuffix, _ := strings.Cut(known, ".")
+		var d int
+		switch {
+		case suffix == knownSuffix:
+			d = editDistance(label, knownLabel)
+			if d > maxTypoDistance(knownLabel) {
+				continue
+			}
+		case label == knownLabel:
+			if d = editDistance(suffix, knownSuffix); d > 1 {
+				continue
+			}
+		default:
+			continue
+		}
+		switch {
+		case best == "" || d < bestDist:
+			best, bestDist, tie = known, d, false
+		case d == bestDist:
+			tie = true
+		}
+	}
+	if best != "" && !tie {
+		return best
+	}
+
+	dot := strings.LastIndexByte(domain, '.')
+	if tld, ok := tldTypos[domain[dot+1:]]; ok {
+		return domain[:dot+1] + tld
+	}
+	return ""
+}
+
+// maxTypoDistance is the most edits a typo of a provider name may be: short
+// names sit too close to unrelated domains (mac.com, max.com) to allow any.
+func maxTypoDistance(name string) int {
+	switch {
+	case len(name) < 4:
+		return 0
+	case len(name) < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of adjacent bytes each
+// count as one edit.
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,31 @@
+package email
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	for addr, want := range map[string]string{
+		"jo@gmial.com":       "jo@gmail.com",
+		"jo@gamil.com":       "jo@gmail.com",
+		"Jo.Smith@GMAIL.CO":  "Jo.Smith@gmail.com",
+		"jo@hotmial.com":     "jo@hotmail.com",
+		"jo@yahooo.com":      "jo@yahoo.com",
+		"jo@outlok.com":      "jo@outlook.com",
+		"jo@acme.con":        "jo@acme.com",
+		"jo@acme.co.za":      "",
+		"jo@gmail.com":       "",
+		"jo@mail.com":        "",
+		"jo@hotmail.de":      "",
+		"jo@gmx.net":         "",
+		"jo@max.com":         "",
+		"jo@acme.co":         "",
+		"not-an-address":     "",
+		"jo@":                "",
+		"jo@localhost":       "",
+		"ceo@yahoo.co.uk":    "",
+		"ceo@hotmail.co.ukk": "ceo@hotmail.co.uk",
+	} {
+		if got := Suggest(addr); got != want {
+			t.Errorf("Suggest(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package email_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+var testKey = []byte("resend-webhook-signing-key")
+
+var testSecret = "whsec_" + base64.StdEncoding.EncodeToString(testKey)
+
+func signedHeaders(body []byte, at time.Time, key []byte) http.Header {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("msg_1." + ts + "."))
+	mac.Write(body)
+	h := http.Header{}
+	h.Set("svix-id", "msg_1")
+	h.Set("svix-timestamp", ts)
+	h.Set("svix-signature", "v1,bm90LXRoaXMtb25l v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return h
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"email.bounced","created_at":"2026-10-01T10:00:00Z","data":{"email_id":"re_123","bounce":{"type":"Permanent","message":"mailbox does not exist"}}}`)
+
+	event, err := email.VerifyWebhook(testSecret, signedHeaders(body, now, testKey), body, now)
+	if err != nil {
+		t.Fatalf("VerifyWebhook: %v", err)
+	}
+	if event.Type != email.EventBounced || event.Data.EmailID != "re_123" || event.Data.Bounce == nil || event.Data.Bounce.Message != "mailbox does not exist" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	for name, tc := range map[string]struct {
+		h    http.Header
+		body []byte
+	}{
+		"tampered body": {signedHeaders(body, now, testKey), []byte(`{"type":"email.opened"}`)},
+		"wrong key":     {signedHeaders(body, now, []byte("other")), body},
+		"stale":         {signedHeaders(body, now.Add(-10*time.Minute), testKey), body},
+		"no headers":    {http.Header{}, body},
+	} {
+		if _, err := email.VerifyWebhook(testSecret, tc.h, tc.body, now); !errors.Is(err, email.ErrInvalidSignature) {
+			t.Errorf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package embed issues the short-lived tokens that let a partner's site
+// iframe the assessment. The partner's page asks for a token from the
+// browser, so the request carries its Origin; a token is only issued to an
+// origin on that partner's allow-list. The page passes the token into the
+// iframe, which sends it when it creates the session, and the session is
+// attributed to the partner.
+//
+// A token is "<payload>.<signature>", both base64url (unpadded): a JSON
+// payload {partner, origin, expires_at} and its HMAC-SHA256 under the
+// partner's key from PARTNER_KEYS. Signatures are domain-separated from
+// pre-fill tokens, so one can never be passed off as the other.
+package embed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/prefill"
+)
+
+var (
+	// ErrOriginNotAllowed is returned by Issue for an origin that is not on
+	// the partner's allow-list, or a partner without one.
+	ErrOriginNotAllowed = errors.New("embed: origin not allowed")
+	// ErrInvalidToken is returned by Verify for a token that is malformed,
+	// not issued by this server, or expired.
+	ErrInvalidToken = errors.New("embed: invalid token")
+)
+
+// signingPrefix separates embed signatures from pre-fill signatures made
+// with the same partner key.
+const signingPrefix = "embed."
+
+// Config configures an Issuer.
+type Config struct {
+	// Keys are the partner keys; a partner needs one to embed.
+	Keys prefill.Keys
+	// Origins are "partner:origin" entries, e.g.
+	// "acme:https://www.acme-accounting.com". A partner may have several.
+	Origins []string
+	// TTL is how long a token stays valid. Default: 15m.
+	TTL time.Duration
+}
+
+// Issuer issues and verifies embed tokens. A nil *Issuer issues nothing and
+// verifies nothing.
+type Issuer struct {
+	keys    prefill.Keys
+	origins map[string]map[string]bool // partner → origins
+	ttl     time.Duration
+}
+
+// Claims is what a verified token says.
+type Claims struct {
+	Partner   string    `json:"partner"`
+	Origin    string    `json:"origin"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// New returns an Issuer, or nil when no origins are configured.
+func New(cfg Config) (*Issuer, error) {
+	if len(cfg.Origins) == 0 {
+		return nil, nil
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Minute
+	}
+	i := &Issuer{keys: cfg.Keys, origins: map[string]map[string]bool{}, ttl: cfg.TTL}
+	for _, entry := range cfg.Origins {
+		partner, origin, err := ParseOrigin(entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := cfg.Keys[partner]; !ok {
+			return nil, fmt.Errorf("embed: partner %q has no key", partner)
+		}
+		if i.origins[partner] == nil {
+			i.origins[partner] = map[string]bool{}
+		}
+		i.origins[partner][origin] = true
+	}
+	return i, nil
+}
+
+// ParseOrigin splits a "partner:origin" entry and normalises the origin to
+// scheme://host[:port].
+func ParseOrigin(entry string) (partner, origin string, err error) {
+	partner, origin, ok := strings.Cut(entry, ":")
+	if !ok || partner == "" {
+		return "", "", errors.New(`embed: entries must be "partner:origin"`)
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+		return "", "", fmt.Errorf("embed: %q is not an origin like https://example.com", origin)
+	}
+	return partner, strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// Issue returns a token for partner's page at origin, the value of the
+// request's Origin header.
+func (i *Issuer) Issue(partner, origin string, now time.Time) (string, Claims, error) {
+	if i == nil || !i.origins[partner][strings.ToLower(origin)] {
+		return "", Claims{}, ErrOriginNotAllowed
+	}
+	c := Claims{Partner: partner, Origin: strings.ToLower(origin), ExpiresAt: now.Add(i.ttl).UTC()}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", Claims{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(i.sign(encoded, i.keys[partner])), c, nil
+}
+
+// Verify checks a token issued by Issue and returns its claims.
+func (i *Issuer) Verify(token string, now time.Time) (Claims, error) {
+	if i == nil {
+		return Claims{}, ErrInvalidToken
+	}
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(body, &c); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	key, ok := i.keys[c.Partner]
+	if !ok || !hmac.Equal(mac, i.sign(encoded, key)) {
+		return Claims{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+	if !now.Before(c.ExpiresAt) {
+		return Claims{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	return c, nil
+}
+
+func (i *Issuer) sign(encoded string, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingPrefix + encoded))
+	return mac.Sum(nil)
+}
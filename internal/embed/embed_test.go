@@ -0,0 +1,79 @@
+package embed
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/prefill"
+)
+
+func testIssuer(t *testing.T) *Issuer {
+	t.Helper()
+	i, err := New(Config{
+		Keys:    prefill.Keys{"acme": []byte(strings.Repeat("a", 32)), "beta": []byte(strings.Repeat("b", 32))},
+		Origins: []string{"acme:https://www.acme.example", "acme:https://Clients.Acme.example/", "beta:https://beta.example"},
+		TTL:     time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return i
+}
+
+func TestIssue_OnlyToAllowedOrigins(t *testing.T) {
+	i := testIssuer(t)
+	now := time.Now()
+
+	token, claims, err := i.Issue("acme", "https://clients.acme.example", now)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	got, err := i.Verify(token, now.Add(30*time.Second))
+	if err != nil || got.Partner != "acme" || got.Origin != "https://clients.acme.example" || !got.ExpiresAt.Equal(claims.ExpiresAt) {
+		t.Fatalf("Verify = %+v, %v", got, err)
+	}
+	if _, err := i.Verify(token, now.Add(time.Minute)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+
+	for _, tc := range []struct{ partner, origin string }{
+		{"acme", "https://beta.example"},
+		{"beta", "https://www.acme.example"},
+		{"gamma", "https://www.acme.example"},
+		{"acme", ""},
+	} {
+		if _, _, err := i.Issue(tc.partner, tc.origin, now); !errors.Is(err, ErrOriginNotAllowed) {
+			t.Errorf("%s from %q: expected ErrOriginNotAllowed, got %v", tc.partner, tc.origin, err)
+		}
+	}
+}
+
+func TestVerify_RejectsPrefillTokens(t *testing.T) {
+	i := testIssuer(t)
+	token, err := prefill.Sign(prefill.Payload{Partner: "acme", ExpiresAt: time.Now().Add(time.Hour)}, []byte(strings.Repeat("a", 32)))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := i.Verify(token, time.Now()); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("a pre-fill token must not verify as an embed token, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	keys := prefill.Keys{"acme": []byte(strings.Repeat("a", 32))}
+	if i, err := New(Config{Keys: keys}); i != nil || err != nil {
+		t.Errorf("expected no issuer without origins, got %v, %v", i, err)
+	}
+	for _, origins := range [][]string{
+		{"beta:https://beta.example"},
+		{"acme:https://acme.example/widget"},
+		{"acme:acme.example"},
+		{"https://acme.example"},
+	} {
+		if _, err := New(Config{Keys: keys, Origins: origins}); err == nil {
+			t.Errorf("%q: expected an error", origins)
+		}
+	}
+}
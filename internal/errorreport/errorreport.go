@@ -0,0 +1,342 @@
+// Package errorreport sends panics and unexpected errors to a Sentry-compatible
+// collector (Sentry, GlitchTip, self-hosted Sentry) using the store endpoint of
+// the Sentry protocol, so the process needs no SDK dependency.
+//
+// Every event is tagged with the environment and release and scrubbed before it
+// leaves the process: email addresses and anything shaped like an anon token,
+// report access token or API key are replaced (see redact.String), and
+// credential headers are dropped from request data.
+//
+// A nil *Reporter is valid and does nothing, so callers never need to check
+// whether SENTRY_DSN was configured.
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/redact"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
+)
+
+// ─── CONSTRUCTOR ──────────────────────────────────────────────────────────────
+
+// Config identifies where events go and how they are tagged.
+type Config struct {
+	// DSN is the project DSN, e.g. "https://<key>@o1.ingest.sentry.io/42".
+	DSN string
+	// Environment is reported as the event environment, e.g. "production".
+	Environment string
+	// Release is the deployed version, e.g. a git SHA.
+	Release string
+}
+
+// Reporter delivers events asynchronously. Construct it with New.
+type Reporter struct {
+	storeURL    string
+	authHeader  string
+	environment string
+	release     string
+	serverName  string
+	httpClient  *http.Client
+	logger      *slog.Logger
+
+	wg sync.WaitGroup
+}
+
+// New parses cfg.DSN and returns a Reporter. An empty DSN returns a nil
+// Reporter, which discards everything.
+func New(cfg Config, logger *slog.Logger) (*Reporter, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+	storeURL, key, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Reporter{
+		storeURL:    storeURL,
+		authHeader:  fmt.Sprintf("Sentry sentry_version=7, sentry_client=arm-errorreport/1.0, sentry_key=%s", key),
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  host,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		logger:      logger,
+	}, nil
+}
+
+// parseDSN turns "https://key@host/path/42" into the store endpoint
+// "https://host/path/api/42/store/" and the public key.
+func parseDSN(dsn string) (storeURL, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("errorreport: invalid DSN: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", "", errors.New("errorreport: DSN has no public key")
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := "", path
+	if i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	if project == "" || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", "", errors.New("errorreport: DSN must look like https://<key>@<host>/<project>")
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project), u.User.Username(), nil
+}
+
+// ─── CAPTURE ──────────────────────────────────────────────────────────────────
+
+// CaptureError reports err at level "error". tags are added to the event;
+// the request ID on ctx, if any, is added as request_id.
+func (r *Reporter) CaptureError(ctx context.Context, err error, tags map[string]string) {
+	if r == nil || err == nil {
+		return
+	}
+	// Group by the root cause's type rather than the outermost wrapper.
+	root := err
+	for next := errors.Unwrap(root); next != nil; next = errors.Unwrap(root) {
+		root = next
+	}
+	ev := r.newEvent(ctx, "error", tags)
+	ev.Exception = &exceptions{Values: []exception{{
+		Type:       fmt.Sprintf("%T", root),
+		Value:      redact.String(err.Error()),
+		Stacktrace: callerStack(3),
+	}}}
+	r.send(ev)
+}
+
+// CapturePanic reports a recovered panic value at level "fatal". Call it from
+// the deferred function that recovered, so the stack still shows where the
+// panic happened.
+func (r *Reporter) CapturePanic(ctx context.Context, recovered any, tags map[string]string) {
+	if r == nil || recovered == nil {
+		return
+	}
+	ev := r.newEvent(ctx, "fatal", tags)
+	ev.Exception = &exceptions{Values: []exception{{
+		Type:       "panic",
+		Value:      redact.String(fmt.Sprint(recovered)),
+		Stacktrace: callerStack(3),
+	}}}
+	r.send(ev)
+}
+
+// Flush waits up to timeout for queued events to be delivered. Call it before
+// the process exits.
+func (r *Reporter) Flush(timeout time.Duration) {
+	if r == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// ─── HTTP MIDDLEWARE ──────────────────────────────────────────────────────────
+
+// Middleware reports handler panics with the (scrubbed) request attached and
+// re-panics so the outer recoverer still logs the panic and writes the 500.
+// Mount it inside middleware.Recoverer. A nil Reporter returns next unchanged.
+func (r *Reporter) Middleware(next http.Handler) http.Handler {
+	if r == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// ErrAbortHandler is how net/http aborts a response on purpose.
+			if rec != http.ErrAbortHandler {
+				ev := r.newEvent(req.Context(), "fatal", map[string]string{"route": req.Method + " " + redact.String(req.URL.Path)})
+				ev.Exception = &exceptions{Values: []exception{{
+					Type:       "panic",
+					Value:      redact.String(fmt.Sprint(rec)),
+					Stacktrace: callerStack(3),
+				}}}
+				ev.Request = newRequest(req)
+				r.send(ev)
+			}
+			panic(rec)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// ─── EVENT ────────────────────────────────────────────────────────────────────
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Exception   *exceptions       `json:"exception,omitempty"`
+	Request     *request          `json:"request,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func (r *Reporter) newEvent(ctx context.Context, level string, tags map[string]string) *event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	merged := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		merged[k] = redact.String(v)
+	}
+	if rid := requestid.From(ctx); rid != "" {
+		merged["request_id"] = rid
+	}
+	return &event{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Level:       level,
+		Platform:    "go",
+		Environment: r.environment,
+		Release:     r.release,
+		ServerName:  r.serverName,
+		Tags:        merged,
+	}
+}
+
+// droppedHeaders carry credentials and are never sent.
+var droppedHeaders = map[string]bool{
+	"Authorization":    true,
+	"Cookie":           true,
+	"X-Anon-Token":     true,
+	"Stripe-Signature": true,
+}
+
+// newRequest describes req without its body, query string or credentials.
+func newRequest(req *http.Request) *request {
+	headers := make(map[string]string)
+	for k, vs := range req.Header {
+		if droppedHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		headers[k] = redact.String(strings.Join(vs, ", "))
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return &request{
+		Method:  req.Method,
+		URL:     redact.String(scheme + "://" + req.Host + req.URL.Path),
+		Headers: headers,
+	}
+}
+
+// callerStack returns the calling goroutine's stack, oldest frame first as
+// Sentry expects, skipping skip frames (runtime.Callers and this package).
+func callerStack(skip int) *stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var out []frame
+	for {
+		f, more := frames.Next()
+		out = append(out, frame{
+			Function: f.Function,
+			Filename: f.File,
+			Lineno:   f.Line,
+			InApp:    strings.Contains(f.Function, "asymmetric-risk-mapper-backend/"),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return &stacktrace{Frames: out}
+}
+
+// send delivers ev in the background. Failures are logged, never returned:
+// error reporting must not take anything else down with it.
+func (r *Reporter) send(ev *event) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		r.logger.Warn("errorreport: marshal event", "error", err)
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		req, err := http.NewRequest(http.MethodPost, r.storeURL, bytes.NewReader(body))
+		if err != nil {
+			r.logger.Warn("errorreport: build request", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", r.authHeader)
+
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			r.logger.Warn("errorreport: send event", "event_id", ev.EventID, "error", err)
+			return
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		if resp.StatusCode >= 300 {
+			r.logger.Warn("errorreport: collector rejected event", "event_id", ev.EventID, "status", resp.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,147 @@
+package errorreport_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/errorreport"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
+)
+
+// collector records the events posted to it.
+type collector struct {
+	mu     sync.Mutex
+	paths  []string
+	auths  []string
+	events []map[string]any
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	t.Helper()
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		c.mu.Lock()
+		c.paths = append(c.paths, r.URL.Path)
+		c.auths = append(c.auths, r.Header.Get("X-Sentry-Auth"))
+		c.events = append(c.events, ev)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func newReporter(t *testing.T, srv *httptest.Server) *errorreport.Reporter {
+	t.Helper()
+	dsn := strings.Replace(srv.URL, "http://", "http://pubkey@", 1) + "/42"
+	r, err := errorreport.New(errorreport.Config{DSN: dsn, Environment: "staging", Release: "abc123"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return r
+}
+
+func TestNew_EmptyDSNDisablesReporting(t *testing.T) {
+	r, err := errorreport.New(errorreport.Config{}, nil)
+	if err != nil || r != nil {
+		t.Fatalf("expected a nil reporter, got %v, %v", r, err)
+	}
+	// Every method is safe on nil.
+	r.CaptureError(context.Background(), errors.New("boom"), nil)
+	r.CapturePanic(context.Background(), "boom", nil)
+	r.Flush(time.Second)
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	if r.Middleware(h) == nil {
+		t.Error("expected Middleware to return the handler")
+	}
+}
+
+func TestNew_InvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"https://sentry.io/42", "https://key@sentry.io/", "not a url"} {
+		if _, err := errorreport.New(errorreport.Config{DSN: dsn}, nil); err == nil {
+			t.Errorf("DSN %q: expected an error", dsn)
+		}
+	}
+}
+
+func TestCaptureError_SendsTaggedScrubbedEvent(t *testing.T) {
+	c, srv := newCollector(t)
+	r := newReporter(t, srv)
+
+	ctx := requestid.With(context.Background(), "req-1")
+	err := fmt.Errorf("send receipt to jane@example.com: %w", errors.New("smtp down"))
+	r.CaptureError(ctx, err, map[string]string{"component": "worker"})
+	r.Flush(5 * time.Second)
+
+	if len(c.events) != 1 {
+		t.Fatalf("expected one event, got %d", len(c.events))
+	}
+	if c.paths[0] != "/api/42/store/" {
+		t.Errorf("expected the store endpoint, got %s", c.paths[0])
+	}
+	if !strings.Contains(c.auths[0], "sentry_key=pubkey") {
+		t.Errorf("expected the DSN key in X-Sentry-Auth, got %q", c.auths[0])
+	}
+
+	ev := c.events[0]
+	if ev["environment"] != "staging" || ev["release"] != "abc123" || ev["level"] != "error" {
+		t.Errorf("unexpected event tagging: %v", ev)
+	}
+	tags, _ := ev["tags"].(map[string]any)
+	if tags["request_id"] != "req-1" || tags["component"] != "worker" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	body, _ := json.Marshal(ev)
+	if strings.Contains(string(body), "jane@example.com") {
+		t.Errorf("expected the email to be scrubbed: %s", body)
+	}
+}
+
+func TestMiddleware_ReportsAndRepanics(t *testing.T) {
+	c, srv := newCollector(t)
+	r := newReporter(t, srv)
+
+	h := r.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("nil map")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/report/"+strings.Repeat("a", 32), nil)
+	req.Header.Set("X-Anon-Token", "secret-anon-token")
+	req.Header.Set("User-Agent", "test")
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate to the outer recoverer")
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	r.Flush(5 * time.Second)
+
+	if len(c.events) != 1 {
+		t.Fatalf("expected one event, got %d", len(c.events))
+	}
+	body, _ := json.Marshal(c.events[0])
+	for _, leaked := range []string{"secret-anon-token", strings.Repeat("a", 32)} {
+		if strings.Contains(string(body), leaked) {
+			t.Errorf("expected %q to be scrubbed: %s", leaked, body)
+		}
+	}
+	if c.events[0]["level"] != "fatal" {
+		t.Errorf("expected level fatal, got %v", c.events[0]["level"])
+	}
+}
@@ -0,0 +1,128 @@
+// Package experiments runs A/B tests. Each running experiment splits new
+// sessions between a control (variant a) and one alternative (variant b):
+//
+//   - prompt: b reports are generated with an alternative AI prompt template
+//     (see ai.WithPromptVariant).
+//   - price: b sessions pay the EXPERIMENT_PRICES price for a product instead
+//     of its catalog price, e.g. $49 instead of $59.
+//
+// A session is assigned when it is created, by a hash of the experiment name
+// and the session ID, and the assignment is stored in experiment_assignments
+// so conversion and report quality can be compared per variant. Sessions
+// created before an experiment started are not in it, and once it stops
+// every session gets the control again; the assignments are kept.
+package experiments
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// Known experiments.
+const (
+	Prompt = "prompt"
+	Price  = "price"
+)
+
+// Variants. Every experiment has the two.
+const (
+	Control     = "a"
+	Alternative = "b"
+)
+
+// Known maps every experiment to what its alternative changes.
+var Known = map[string]string{
+	Prompt: "reports are generated with the alternative AI prompt template",
+	Price:  "checkout charges the EXPERIMENT_PRICES price instead of the catalog price",
+}
+
+// Set is the experiments this process runs. A nil *Set runs none.
+type Set struct {
+	running []string
+	prices  map[string]int32 // sku → variant b price in cents
+}
+
+// New returns the Set running the named experiments. prices are
+// EXPERIMENT_PRICES entries, <sku>:<cents>, and are required by the price
+// experiment.
+func New(running, prices []string) (*Set, error) {
+	s := &Set{prices: map[string]int32{}}
+	for _, name := range running {
+		if _, ok := Known[name]; !ok {
+			return nil, fmt.Errorf("unknown experiment %q", name)
+		}
+		if slices.Contains(s.running, name) {
+			return nil, fmt.Errorf("experiment %q listed twice", name)
+		}
+		s.running = append(s.running, name)
+	}
+	for _, entry := range prices {
+		sku, cents, ok := strings.Cut(entry, ":")
+		n, err := strconv.ParseInt(strings.TrimSpace(cents), 10, 32)
+		if !ok || sku == "" || err != nil || n <= 0 {
+			return nil, fmt.Errorf("price %q must look like <sku>:<cents> with cents above 0", entry)
+		}
+		s.prices[strings.TrimSpace(sku)] = int32(n)
+	}
+	if s.Running(Price) && len(s.prices) == 0 {
+		return nil, fmt.Errorf("the %s experiment needs EXPERIMENT_PRICES", Price)
+	}
+	return s, nil
+}
+
+// Running reports whether the experiment is running.
+func (s *Set) Running(name string) bool {
+	return s != nil && slices.Contains(s.running, name)
+}
+
+// Names returns the running experiments.
+func (s *Set) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return slices.Clone(s.running)
+}
+
+// Assign returns the variant a new session gets in an experiment. The same
+// session always gets the same variant.
+func Assign(name string, sessionID uuid.UUID) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write(sessionID[:])
+	if h.Sum32()%2 == 0 {
+		return Control
+	}
+	return Alternative
+}
+
+// Variant returns the session's variant of an experiment given its stored
+// assignments: the assigned one while the experiment runs, else the control.
+func (s *Set) Variant(name string, assignments []db.ExperimentAssignment) string {
+	if !s.Running(name) {
+		return Control
+	}
+	for _, a := range assignments {
+		if a.Experiment == name {
+			return a.Variant
+		}
+	}
+	return Control
+}
+
+// PriceCents returns what a session in variant pays for a product whose
+// catalog price is catalog.
+func (s *Set) PriceCents(variant, sku string, catalog int32) int32 {
+	if variant != Alternative || s == nil {
+		return catalog
+	}
+	if p, ok := s.prices[sku]; ok {
+		return p
+	}
+	return catalog
+}
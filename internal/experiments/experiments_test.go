@@ -0,0 +1,81 @@
+package experiments_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		running, prices []string
+		ok              bool
+	}{
+		{nil, nil, true},
+		{[]string{"prompt"}, nil, true},
+		{[]string{"price"}, []string{"standard:4900"}, true},
+		{[]string{"price"}, nil, false},
+		{[]string{"prompt", "prompt"}, nil, false},
+		{[]string{"checkout_copy"}, nil, false},
+		{nil, []string{"standard"}, false},
+		{nil, []string{"standard:0"}, false},
+		{nil, []string{"standard:49.00"}, false},
+	}
+	for _, tc := range cases {
+		_, err := experiments.New(tc.running, tc.prices)
+		if (err == nil) != tc.ok {
+			t.Errorf("New(%q, %q) = %v, want ok=%v", tc.running, tc.prices, err, tc.ok)
+		}
+	}
+}
+
+func TestAssign_StableAndRoughlyEven(t *testing.T) {
+	b := 0
+	for range 1000 {
+		id := uuid.New()
+		v := experiments.Assign(experiments.Price, id)
+		if v != experiments.Assign(experiments.Price, id) {
+			t.Fatal("expected the same variant for the same session")
+		}
+		if v == experiments.Alternative {
+			b++
+		}
+	}
+	if b < 400 || b > 600 {
+		t.Errorf("expected about half of 1000 sessions in b, got %d", b)
+	}
+}
+
+func TestVariantAndPrice_ControlUnlessRunningAndAssigned(t *testing.T) {
+	set, err := experiments.New([]string{experiments.Price}, []string{"standard:4900"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned := []db.ExperimentAssignment{
+		{Experiment: experiments.Price, Variant: experiments.Alternative},
+		{Experiment: experiments.Prompt, Variant: experiments.Alternative},
+	}
+
+	if got := set.Variant(experiments.Price, assigned); got != experiments.Alternative {
+		t.Errorf("price: got %q, want b", got)
+	}
+	if got := set.Variant(experiments.Prompt, assigned); got != experiments.Control {
+		t.Errorf("a stopped experiment should give the control, got %q", got)
+	}
+	if got := set.Variant(experiments.Price, nil); got != experiments.Control {
+		t.Errorf("an unassigned session should get the control, got %q", got)
+	}
+
+	if got := set.PriceCents(experiments.Alternative, "standard", 5900); got != 4900 {
+		t.Errorf("b standard: got %d, want 4900", got)
+	}
+	if got := set.PriceCents(experiments.Alternative, "premium", 14900); got != 14900 {
+		t.Errorf("b premium has no experiment price, got %d", got)
+	}
+	var none *experiments.Set
+	if none.Running(experiments.Price) || none.PriceCents(experiments.Alternative, "standard", 5900) != 5900 {
+		t.Error("a nil set should run nothing")
+	}
+}
@@ -0,0 +1,254 @@
+// Package fieldcrypt encrypts individual column values with AES-256-GCM, so
+// email addresses and Stripe payloads are unreadable in backups, replicas and
+// ad-hoc SQL sessions.
+//
+// An encrypted value is stored as text:
+//
+//	enc:v1:<key-id>:<base64url(nonce || ciphertext)>
+//
+// The key ID selects one of several configured keys, which is what makes
+// rotation possible: new values are written with the primary (first) key,
+// older keys stay configured for decryption until every value has been
+// rewritten. Values without the prefix are plaintext from before encryption
+// was enabled and are returned unchanged.
+//
+// Encrypted columns cannot be compared in SQL, so columns that are looked up
+// by value get a companion blind index (Index): an HMAC of the normalised
+// value under a separate key that never rotates.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks a value as encrypted by this package, format version 1.
+const prefix = "enc:v1:"
+
+// KeySize is the length in bytes of an AES-256 key.
+const KeySize = 32
+
+// ErrUnknownKey is returned when a value was encrypted with a key that is no
+// longer configured.
+var ErrUnknownKey = errors.New("fieldcrypt: value encrypted with an unknown key")
+
+// Codec encrypts and decrypts column values. A nil *Codec, or one without
+// keys, stores plaintext but still computes blind indexes, so lookups work
+// the same whether or not encryption is enabled.
+type Codec struct {
+	primary  string
+	aeads    map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// New builds a Codec from "id:base64key" entries, primary first, and the
+// blind index key. Each key must decode to KeySize bytes. With no entries the
+// Codec stores plaintext; an empty indexKey makes Index an unkeyed SHA-256.
+func New(keys []string, indexKey string) (*Codec, error) {
+	c := &Codec{aeads: make(map[string]cipher.AEAD, len(keys)), indexKey: []byte(indexKey)}
+	for _, entry := range keys {
+		id, key, err := ParseKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := c.aeads[id]; dup {
+			return nil, fmt.Errorf("fieldcrypt: key id %q configured twice", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("fieldcrypt: key %q: %w", id, err)
+		}
+		c.aeads[id] = aead
+		if c.primary == "" {
+			c.primary = id
+		}
+	}
+	return c, nil
+}
+
+// ParseKey splits an "id:base64key" entry and decodes the key. The ID may not
+// contain a colon; the key is standard or URL-safe base64 of KeySize bytes.
+func ParseKey(entry string) (id string, key []byte, err error) {
+	id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+	if !ok || id == "" {
+		return "", nil, errors.New("fieldcrypt: key must look like <id>:<base64 key>")
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if key, err = enc.DecodeString(encoded); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("fieldcrypt: key %q is not base64", id)
+	}
+	if len(key) != KeySize {
+		return "", nil, fmt.Errorf("fieldcrypt: key %q is %d bytes, want %d", id, len(key), KeySize)
+	}
+	return id, key, nil
+}
+
+// Enabled reports whether values are encrypted on write.
+func (c *Codec) Enabled() bool {
+	return c != nil && c.primary != ""
+}
+
+// PrimaryKeyID is the ID of the key new values are encrypted with, or "" when
+// encryption is disabled.
+func (c *Codec) PrimaryKeyID() string {
+	if c == nil {
+		return ""
+	}
+	return c.primary
+}
+
+// DecryptOnly returns a copy of c that decrypts with every configured key but
+// writes plaintext, for taking a database back out of encryption.
+func (c *Codec) DecryptOnly() *Codec {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	cp.primary = ""
+	return &cp
+}
+
+// Encrypt encrypts plaintext with the primary key. field names the column,
+// e.g. "sessions.email", and is authenticated with the value so a ciphertext
+// copied into another column fails to decrypt. Empty values and a disabled
+// Codec return plaintext unchanged.
+func (c *Codec) Encrypt(field, plaintext string) (string, error) {
+	if !c.Enabled() || plaintext == "" {
+		return plaintext, nil
+	}
+	aead := c.aeads[c.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("fieldcrypt: nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+	return prefix + c.primary + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt with whichever configured key the value names.
+// Values without the encryption prefix are returned unchanged.
+func (c *Codec) Decrypt(field, stored string) (string, error) {
+	id, body, ok := split(stored)
+	if !ok {
+		return stored, nil
+	}
+	var aead cipher.AEAD
+	if c != nil {
+		aead = c.aeads[id]
+	}
+	if aead == nil {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("fieldcrypt: %s: malformed ciphertext", field)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("fieldcrypt: %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// KeyID returns the ID of the key stored was encrypted with, and false for
+// plaintext.
+func KeyID(stored string) (string, bool) {
+	id, _, ok := split(stored)
+	return id, ok
+}
+
+// Current reports whether stored is already in the form Encrypt would write
+// now: encrypted with the primary key, or plaintext when encryption is off.
+func (c *Codec) Current(stored string) bool {
+	id, encrypted := KeyID(stored)
+	if !c.Enabled() {
+		return !encrypted
+	}
+	return stored == "" || (encrypted && id == c.primary)
+}
+
+func split(stored string) (id, body string, ok bool) {
+	rest, ok := strings.CutPrefix(stored, prefix)
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
+
+// EncryptJSON encrypts a JSON document into a JSON string, so the result can
+// still be stored in a JSONB column.
+func (c *Codec) EncryptJSON(field string, doc json.RawMessage) (json.RawMessage, error) {
+	if !c.Enabled() || len(doc) == 0 {
+		return doc, nil
+	}
+	enc, err := c.Encrypt(field, string(doc))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(enc)
+}
+
+// DecryptJSON reverses EncryptJSON. Documents that are not an encrypted JSON
+// string are returned unchanged.
+func (c *Codec) DecryptJSON(field string, stored json.RawMessage) (json.RawMessage, error) {
+	s, ok := StoredJSONString(stored)
+	if !ok {
+		return stored, nil
+	}
+	doc, err := c.Decrypt(field, s)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(doc), nil
+}
+
+// StoredJSONString returns the encrypted value inside a document written by
+// EncryptJSON, and false for any other document.
+func StoredJSONString(stored json.RawMessage) (string, bool) {
+	// JSONB may store the document with leading whitespace stripped or not;
+	// an encrypted one is always a bare string.
+	trimmed := strings.TrimSpace(string(stored))
+	if !strings.HasPrefix(trimmed, `"`+prefix) {
+		return "", false
+	}
+	var s string
+	if err := json.Unmarshal([]byte(trimmed), &s); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// Index returns the blind index of an email address: a hex HMAC-SHA256 of
+// the trimmed, lower-cased address under the index key, or a plain SHA-256
+// when no index key is configured. Empty addresses index to "".
+func (c *Codec) Index(email string) string {
+	norm := strings.ToLower(strings.TrimSpace(email))
+	if norm == "" {
+		return ""
+	}
+	if c == nil || len(c.indexKey) == 0 {
+		sum := sha256.Sum256([]byte(norm))
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(norm))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,137 @@
+package fieldcrypt_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+)
+
+func key(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), fieldcrypt.KeySize)))
+}
+
+func newCodec(t *testing.T, keys ...string) *fieldcrypt.Codec {
+	t.Helper()
+	c, err := fieldcrypt.New(keys, "index-key")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestEncrypt_RoundTripsAndHidesThePlaintext(t *testing.T) {
+	c := newCodec(t, key("k1", 1))
+	enc, err := c.Encrypt("sessions.email", "owner@acme.co.za")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(enc, "enc:v1:k1:") || strings.Contains(enc, "acme") {
+		t.Fatalf("unexpected ciphertext %q", enc)
+	}
+	again, _ := c.Encrypt("sessions.email", "owner@acme.co.za")
+	if again == enc {
+		t.Error("two encryptions of one value should differ")
+	}
+	plain, err := c.Decrypt("sessions.email", enc)
+	if err != nil || plain != "owner@acme.co.za" {
+		t.Errorf("Decrypt = %q, %v", plain, err)
+	}
+}
+
+func TestDecrypt_RejectsCiphertextFromAnotherColumn(t *testing.T) {
+	c := newCodec(t, key("k1", 1))
+	enc, _ := c.Encrypt("sessions.email", "owner@acme.co.za")
+	if _, err := c.Decrypt("subscriptions.email", enc); err == nil {
+		t.Error("expected decryption under another field name to fail")
+	}
+}
+
+func TestDecrypt_PassesPlaintextThrough(t *testing.T) {
+	c := newCodec(t, key("k1", 1))
+	plain, err := c.Decrypt("sessions.email", "legacy@acme.co.za")
+	if err != nil || plain != "legacy@acme.co.za" {
+		t.Errorf("Decrypt = %q, %v", plain, err)
+	}
+}
+
+func TestRotation_OldKeyStillDecryptsAndIsStale(t *testing.T) {
+	old := newCodec(t, key("k1", 1))
+	enc, _ := old.Encrypt("sessions.email", "owner@acme.co.za")
+
+	rotated := newCodec(t, key("k2", 2), key("k1", 1))
+	plain, err := rotated.Decrypt("sessions.email", enc)
+	if err != nil || plain != "owner@acme.co.za" {
+		t.Fatalf("Decrypt with retired key = %q, %v", plain, err)
+	}
+	if rotated.Current(enc) {
+		t.Error("a value under a retired key should not be current")
+	}
+	fresh, _ := rotated.Encrypt("sessions.email", plain)
+	if id, _ := fieldcrypt.KeyID(fresh); id != "k2" || !rotated.Current(fresh) {
+		t.Errorf("expected new values under k2, got %q", fresh)
+	}
+
+	removed := newCodec(t, key("k2", 2))
+	if _, err := removed.Decrypt("sessions.email", enc); !errors.Is(err, fieldcrypt.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey once k1 is removed, got %v", err)
+	}
+}
+
+func TestDisabledCodec_StoresPlaintextButStillIndexes(t *testing.T) {
+	var c *fieldcrypt.Codec
+	enc, err := c.Encrypt("sessions.email", "owner@acme.co.za")
+	if err != nil || enc != "owner@acme.co.za" {
+		t.Errorf("Encrypt = %q, %v", enc, err)
+	}
+	if !c.Current(enc) {
+		t.Error("plaintext should be current when encryption is off")
+	}
+	if c.Index("Owner@Acme.co.za ") != c.Index("owner@acme.co.za") || c.Index("owner@acme.co.za") == "" {
+		t.Error("index should ignore case and surrounding space")
+	}
+}
+
+func TestIndex_IsKeyed(t *testing.T) {
+	a, _ := fieldcrypt.New(nil, "one")
+	b, _ := fieldcrypt.New(nil, "two")
+	var unkeyed *fieldcrypt.Codec
+	if a.Index("owner@acme.co.za") == b.Index("owner@acme.co.za") || a.Index("owner@acme.co.za") == unkeyed.Index("owner@acme.co.za") {
+		t.Error("different index keys should give different indexes")
+	}
+}
+
+func TestEncryptJSON_StaysValidJSON(t *testing.T) {
+	c := newCodec(t, key("k1", 1))
+	doc := json.RawMessage(`{"id":"evt_1","type":"payment_intent.succeeded"}`)
+	enc, err := c.EncryptJSON("stripe_events.payload", doc)
+	if err != nil {
+		t.Fatalf("EncryptJSON: %v", err)
+	}
+	if !json.Valid(enc) || strings.Contains(string(enc), "evt_1") {
+		t.Fatalf("unexpected stored document %s", enc)
+	}
+	back, err := c.DecryptJSON("stripe_events.payload", enc)
+	if err != nil || string(back) != string(doc) {
+		t.Errorf("DecryptJSON = %s, %v", back, err)
+	}
+	if same, _ := c.DecryptJSON("stripe_events.payload", doc); string(same) != string(doc) {
+		t.Error("a plaintext document should pass through")
+	}
+}
+
+func TestNew_RejectsBadKeys(t *testing.T) {
+	for _, keys := range [][]string{
+		{"nokey"},
+		{"k1:not-base64!"},
+		{"k1:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		{key("k1", 1), key("k1", 2)},
+	} {
+		if _, err := fieldcrypt.New(keys, "index"); err == nil {
+			t.Errorf("expected an error for %q", keys)
+		}
+	}
+}
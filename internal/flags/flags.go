@@ -0,0 +1,279 @@
+// Package flags soft-launches risky behaviour. Each flag is on for a share of
+// sessions, chosen per environment, so a change can run on staging first and
+// then on a few percent of production before everyone gets it.
+//
+// Rules come from FEATURE_FLAGS and from the feature_flags table, whose rows
+// override the variable's for the same flag and environment. A Watcher
+// reloads the table on an interval, like the runtime settings, and publishes
+// an immutable snapshot. A flag with no rule keeps its built-in default.
+//
+// Whether a flag is on for a session is a hash of the flag name and the
+// session ID, so a session stays on the same side of a flag for as long as
+// its percentage does not change, and raising the percentage only adds
+// sessions. Reports record the state of every flag they were generated under.
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── FLAGS ────────────────────────────────────────────────────────────────────
+
+// Known flags. Every one defaults to on, which is how the code behaved before
+// it was gated: set a flag below 100% when a change to what it gates needs a
+// soft launch, e.g. a new score_profile setting or a newly added AI provider.
+const (
+	// ScoreProfile applies the score_profile runtime setting. Sessions it is
+	// off for are scored with the plain mean.
+	ScoreProfile = "score_profile"
+
+	// AIProviderDeepSeek and AIProviderAnthropic let the report use that AI
+	// provider. Sessions a provider's flag is off for skip it in the chain.
+	AIProviderDeepSeek  = "ai_provider_deepseek"
+	AIProviderAnthropic = "ai_provider_anthropic"
+)
+
+// aiProviderPrefix names the flag of an AI provider; see AIProvider.
+const aiProviderPrefix = "ai_provider_"
+
+// Known maps every flag to what it gates. Rules for other names are rejected.
+var Known = map[string]string{
+	ScoreProfile:        "apply the score_profile runtime setting; off scores with the plain mean",
+	AIProviderDeepSeek:  "let reports use DeepSeek",
+	AIProviderAnthropic: "let reports use Anthropic",
+}
+
+// AIProvider returns the flag of the named AI provider (settings.Provider*).
+func AIProvider(provider string) string {
+	return aiProviderPrefix + provider
+}
+
+// ─── RULES ────────────────────────────────────────────────────────────────────
+
+// Rule turns a flag on for Percent of sessions in Environment, or in every
+// environment when Environment is empty.
+type Rule struct {
+	Flag        string
+	Environment string
+	Percent     int
+}
+
+type ruleKey struct{ flag, env string }
+
+// ParseRules parses FEATURE_FLAGS entries, each flag=value or
+// flag=value@environment, where value is on, off or a percentage such as 25%.
+// A flag may have one entry per environment and one without.
+func ParseRules(entries []string) ([]Rule, error) {
+	var rules []Rule
+	seen := map[ruleKey]bool{}
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: each entry must look like <flag>=<on|off|N%%>[@<environment>]", entry)
+		}
+		value, env, _ := strings.Cut(value, "@")
+		r := Rule{Flag: strings.TrimSpace(name), Environment: strings.TrimSpace(env)}
+		if err := Validate(r.Flag); err != nil {
+			return nil, err
+		}
+		percent, err := ParsePercent(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Flag, err)
+		}
+		r.Percent = percent
+		k := ruleKey{r.Flag, r.Environment}
+		if seen[k] {
+			return nil, fmt.Errorf("%q appears twice", entry)
+		}
+		seen[k] = true
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// ParsePercent parses a rule value: on (100), off (0) or N% for N in 0–100.
+func ParsePercent(value string) (int, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "on":
+		return 100, nil
+	case "off":
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	if err != nil || !strings.HasSuffix(value, "%") || n < 0 || n > 100 {
+		return 0, fmt.Errorf("%q must be on, off or a percentage from 0%% to 100%%", value)
+	}
+	return n, nil
+}
+
+// Validate reports whether name is a known flag.
+func Validate(name string) error {
+	if _, ok := Known[name]; !ok {
+		return fmt.Errorf("unknown flag %q", name)
+	}
+	return nil
+}
+
+// ─── SNAPSHOT ────────────────────────────────────────────────────────────────
+
+// Flags is an immutable snapshot of the percentages in one environment.
+type Flags struct {
+	percent map[string]int
+}
+
+// Percent returns the share of sessions name is on for.
+func (f Flags) Percent(name string) int {
+	if p, ok := f.percent[name]; ok {
+		return p
+	}
+	return 100
+}
+
+// Enabled reports whether name is on for the session.
+func (f Flags) Enabled(name string, sessionID uuid.UUID) bool {
+	return bucket(name, sessionID) < f.Percent(name)
+}
+
+// For returns whether each known flag is on for the session, as recorded on
+// its report.
+func (f Flags) For(sessionID uuid.UUID) map[string]bool {
+	state := make(map[string]bool, len(Known))
+	for name := range Known {
+		state[name] = f.Enabled(name, sessionID)
+	}
+	return state
+}
+
+// String lists every known flag with its percentage, e.g.
+// "ai_provider_anthropic=100%,score_profile=10%".
+func (f Flags) String() string {
+	names := make([]string, 0, len(Known))
+	for name := range Known {
+		names = append(names, fmt.Sprintf("%s=%d%%", name, f.Percent(name)))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// bucket places a session in 0–99 for a flag. The flag name is part of the
+// hash so the sessions in 10% of one flag are not the same as in another's.
+func bucket(name string, sessionID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write(sessionID[:])
+	return int(h.Sum32() % 100)
+}
+
+// resolve builds the snapshot for env: a rule for env beats one for every
+// environment, and a table row beats a FEATURE_FLAGS entry.
+func resolve(env string, base []Rule, rows []db.FeatureFlag) Flags {
+	byKey := map[ruleKey]int{}
+	for _, r := range base {
+		byKey[ruleKey{r.Flag, r.Environment}] = r.Percent
+	}
+	for _, row := range rows {
+		byKey[ruleKey{row.Name, row.Environment}] = int(row.Percent)
+	}
+
+	f := Flags{percent: map[string]int{}}
+	for name := range Known {
+		if p, ok := byKey[ruleKey{name, env}]; ok {
+			f.percent[name] = p
+		} else if p, ok := byKey[ruleKey{name, ""}]; ok {
+			f.percent[name] = p
+		}
+	}
+	return f
+}
+
+// ─── WATCHER ──────────────────────────────────────────────────────────────────
+
+// Watcher loads feature_flags and publishes the result atomically. A nil
+// *Watcher is valid and reports every flag on.
+type Watcher struct {
+	q        db.FeatureFlagReader
+	env      string
+	base     []Rule
+	interval time.Duration
+	logger   *slog.Logger
+
+	current atomic.Pointer[Flags]
+}
+
+// NewWatcher returns a Watcher for environment env that starts out with the
+// FEATURE_FLAGS rules in base. Call Reload once before serving traffic, then
+// Start to keep it up to date.
+func NewWatcher(q db.FeatureFlagReader, env string, base []Rule, interval time.Duration, logger *slog.Logger) *Watcher {
+	w := &Watcher{
+		q:        q,
+		env:      env,
+		base:     base,
+		interval: interval,
+		logger:   logger,
+	}
+	f := resolve(env, base, nil)
+	w.current.Store(&f)
+	return w
+}
+
+// Current returns the latest snapshot.
+func (w *Watcher) Current() Flags {
+	if w == nil {
+		return Flags{}
+	}
+	return *w.current.Load()
+}
+
+// Reload reads the table and swaps in a new snapshot. Rows for unknown flags
+// or out-of-range percentages are logged and skipped.
+func (w *Watcher) Reload(ctx context.Context) error {
+	rows, err := w.q.ListFeatureFlags(ctx)
+	if err != nil {
+		return fmt.Errorf("flags: list: %w", err)
+	}
+
+	valid := rows[:0:0]
+	for _, row := range rows {
+		if err := Validate(row.Name); err != nil {
+			w.logger.Warn("flags: ignoring feature flag row", "flag", row.Name, "environment", row.Environment, "error", err)
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	next := resolve(w.env, w.base, valid)
+	prev := w.current.Swap(&next)
+	if !maps.Equal(prev.percent, next.percent) {
+		w.logger.Info("flags: feature flags changed", "environment", w.env, "flags", next.String())
+	}
+	return nil
+}
+
+// Start reloads on every interval tick until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Reload(ctx); err != nil {
+				w.logger.Error("flags: reload failed", "error", err)
+			}
+		}
+	}
+}
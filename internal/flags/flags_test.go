@@ -0,0 +1,129 @@
+package flags_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+)
+
+type stubQuerier struct {
+	rows []db.FeatureFlag
+}
+
+func (q *stubQuerier) ListFeatureFlags(_ context.Context) ([]db.FeatureFlag, error) {
+	return q.rows, nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestParseRules(t *testing.T) {
+	cases := []struct {
+		entries []string
+		ok      bool
+	}{
+		{[]string{"score_profile=10%"}, true},
+		{[]string{"score_profile=on@staging", "score_profile=5%@production", "score_profile=off"}, true},
+		{[]string{"ai_provider_anthropic=OFF"}, true},
+		{[]string{"score_profile=10"}, false},
+		{[]string{"score_profile=101%"}, false},
+		{[]string{"score_profile"}, false},
+		{[]string{"new_checkout=on"}, false},
+		{[]string{"score_profile=on@staging", "score_profile=off@staging"}, false},
+	}
+	for _, tc := range cases {
+		_, err := flags.ParseRules(tc.entries)
+		if (err == nil) != tc.ok {
+			t.Errorf("ParseRules(%q) = %v, want ok=%v", tc.entries, err, tc.ok)
+		}
+	}
+}
+
+func TestNilWatcher_EverythingOn(t *testing.T) {
+	var w *flags.Watcher
+	for name, on := range w.Current().For(uuid.New()) {
+		if !on {
+			t.Errorf("expected %s on by default", name)
+		}
+	}
+}
+
+func TestWatcher_RowsOverrideRulesAndEnvironmentBeatsAll(t *testing.T) {
+	rules, err := flags.ParseRules([]string{
+		"score_profile=off",
+		"ai_provider_anthropic=off@production",
+		"ai_provider_deepseek=on@production",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &stubQuerier{rows: []db.FeatureFlag{
+		{Name: flags.AIProviderDeepSeek, Environment: "production", Percent: 25},
+		{Name: flags.ScoreProfile, Environment: "staging", Percent: 100}, // another environment
+		{Name: "retired_flag", Percent: 100},
+	}}
+
+	w := flags.NewWatcher(q, "production", rules, time.Minute, discardLogger())
+	if err := w.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	cur := w.Current()
+	for name, want := range map[string]int{
+		flags.ScoreProfile:        0,
+		flags.AIProviderAnthropic: 0,
+		flags.AIProviderDeepSeek:  25,
+	} {
+		if got := cur.Percent(name); got != want {
+			t.Errorf("%s: got %d%%, want %d%%", name, got, want)
+		}
+	}
+
+	// Removing the row reverts to the FEATURE_FLAGS rule on the next reload.
+	q.rows = nil
+	if err := w.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := w.Current().Percent(flags.AIProviderDeepSeek); got != 100 {
+		t.Errorf("expected the FEATURE_FLAGS rule after row removal, got %d%%", got)
+	}
+}
+
+func TestEnabled_StableAndOnlyAddsSessionsAsThePercentageRises(t *testing.T) {
+	sessions := make([]uuid.UUID, 1000)
+	for i := range sessions {
+		sessions[i] = uuid.New()
+	}
+	at := func(percent int16) flags.Flags {
+		q := &stubQuerier{rows: []db.FeatureFlag{{Name: flags.ScoreProfile, Percent: percent}}}
+		w := flags.NewWatcher(q, "production", nil, time.Minute, discardLogger())
+		if err := w.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return w.Current()
+	}
+
+	ten, fifty := at(10), at(50)
+	on := 0
+	for _, id := range sessions {
+		if ten.Enabled(flags.ScoreProfile, id) != ten.Enabled(flags.ScoreProfile, id) {
+			t.Fatal("expected the same answer for the same session")
+		}
+		if ten.Enabled(flags.ScoreProfile, id) {
+			on++
+			if !fifty.Enabled(flags.ScoreProfile, id) {
+				t.Errorf("session %s on at 10%% but off at 50%%", id)
+			}
+		}
+	}
+	if on < 50 || on > 150 {
+		t.Errorf("expected about 100 of 1000 sessions on at 10%%, got %d", on)
+	}
+}
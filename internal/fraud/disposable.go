@@ -0,0 +1,41 @@
+package fraud
+
+// disposableDomains are common throwaway email providers. It is not meant to
+// be complete — FRAUD_DISPOSABLE_DOMAINS adds to it without a deploy.
+var disposableDomains = []string{
+	"10minutemail.com",
+	"33mail.com",
+	"burnermail.io",
+	"discard.email",
+	"dispostable.com",
+	"emailondeck.com",
+	"fakeinbox.com",
+	"getairmail.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"guerrillamail.net",
+	"guerrillamailblock.com",
+	"harakirimail.com",
+	"inboxkitten.com",
+	"mailcatch.com",
+	"maildrop.cc",
+	"mailinator.com",
+	"mailnesia.com",
+	"mintemail.com",
+	"mohmal.com",
+	"moakt.com",
+	"mytemp.email",
+	"sharklasers.com",
+	"spamgourmet.com",
+	"temp-mail.org",
+	"tempail.com",
+	"tempmail.com",
+	"tempmail.dev",
+	"tempmailo.com",
+	"tempr.email",
+	"throwawaymail.com",
+	"trashmail.com",
+	"trashmail.de",
+	"yopmail.com",
+	"yopmail.net",
+}
@@ -0,0 +1,189 @@
+// Package fraud runs cheap abuse heuristics before checkout creates a
+// PaymentIntent: throwaway email domains, bursts of sessions from one IP and
+// repeated failed payments for one email — the usual signs of card testing.
+//
+// A Checker never fails a checkout on its own errors. A check whose query
+// fails is skipped and logged, so a database hiccup degrades to "allow".
+package fraud
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// Mode decides what happens to a checkout that trips a check.
+type Mode string
+
+const (
+	ModeOff   Mode = "off"   // checks are not run
+	ModeFlag  Mode = "flag"  // checks run and are logged; checkout proceeds
+	ModeBlock Mode = "block" // checkout is refused
+)
+
+// Action is the outcome of a Check.
+type Action string
+
+const (
+	ActionAllow Action = "allow"
+	ActionFlag  Action = "flag"
+	ActionBlock Action = "block"
+)
+
+// Config holds the thresholds. A zero limit disables that check.
+type Config struct {
+	Mode Mode
+	// MaxSessionsPerIPHour is the most sessions one IP hash may start in an
+	// hour before its checkouts are flagged.
+	MaxSessionsPerIPHour int
+	// MaxFailedPayments is how many failed payments one email may run up in
+	// failedPaymentWindow before its checkouts are flagged.
+	MaxFailedPayments int
+	// DisposableDomains extends the built-in list of throwaway email domains.
+	DisposableDomains []string
+}
+
+// failedPaymentWindow is how far back failed payments are counted.
+const failedPaymentWindow = 24 * time.Hour
+
+// Store is the subset of db.Querier the checks read.
+type Store interface {
+	CountSessionsByIPHashSince(ctx context.Context, arg db.CountSessionsByIPHashSinceParams) (int64, error)
+	CountFailedPaymentsByEmailSince(ctx context.Context, arg db.CountFailedPaymentsByEmailSinceParams) (int64, error)
+}
+
+// Input describes the checkout being checked.
+type Input struct {
+	SessionID uuid.UUID
+	Email     string
+	IPHash    string // sessions.ip_hash; empty skips the velocity check
+}
+
+// Decision is the result of a Check. Reasons lists every check that tripped,
+// in a stable order, whatever the Action.
+type Decision struct {
+	Action  Action
+	Reasons []string
+}
+
+// Checker runs the heuristics. Construct it with NewChecker.
+type Checker struct {
+	cfg        Config
+	store      Store
+	logger     *slog.Logger
+	disposable map[string]bool
+	now        func() time.Time
+}
+
+// NewChecker returns a Checker using the built-in disposable domain list plus
+// cfg.DisposableDomains.
+func NewChecker(cfg Config, store Store, logger *slog.Logger) *Checker {
+	disposable := make(map[string]bool, len(disposableDomains)+len(cfg.DisposableDomains))
+	for _, d := range disposableDomains {
+		disposable[d] = true
+	}
+	for _, d := range cfg.DisposableDomains {
+		disposable[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+	return &Checker{cfg: cfg, store: store, logger: logger, disposable: disposable, now: time.Now}
+}
+
+// Check runs every enabled heuristic and logs the decision with audit=true.
+// In ModeOff it returns ActionAllow without running anything.
+func (c *Checker) Check(ctx context.Context, in Input) Decision {
+	if c.cfg.Mode == ModeOff {
+		return Decision{Action: ActionAllow}
+	}
+
+	var reasons []string
+	if c.IsDisposable(in.Email) {
+		reasons = append(reasons, "disposable_email")
+	}
+
+	if c.cfg.MaxSessionsPerIPHour > 0 && in.IPHash != "" {
+		n, err := c.store.CountSessionsByIPHashSince(ctx, db.CountSessionsByIPHashSinceParams{
+			IpHash: nullString(in.IPHash),
+			Since:  c.now().Add(-time.Hour),
+		})
+		switch {
+		case err != nil:
+			c.logger.WarnContext(ctx, "fraud: ip velocity check skipped", "session_id", in.SessionID, "error", err)
+		case n > int64(c.cfg.MaxSessionsPerIPHour):
+			reasons = append(reasons, "ip_velocity")
+		}
+	}
+
+	if c.cfg.MaxFailedPayments > 0 && in.Email != "" {
+		n, err := c.store.CountFailedPaymentsByEmailSince(ctx, db.CountFailedPaymentsByEmailSinceParams{
+			Email: nullString(in.Email),
+			Since: c.now().Add(-failedPaymentWindow),
+		})
+		switch {
+		case err != nil:
+			c.logger.WarnContext(ctx, "fraud: failed payment check skipped", "session_id", in.SessionID, "error", err)
+		case n >= int64(c.cfg.MaxFailedPayments):
+			reasons = append(reasons, "repeated_failed_payments")
+		}
+	}
+
+	d := Decision{Action: ActionAllow, Reasons: reasons}
+	if len(reasons) > 0 {
+		d.Action = ActionFlag
+		if c.cfg.Mode == ModeBlock {
+			d.Action = ActionBlock
+		}
+	}
+
+	// The email domain, not the address, is enough to review a decision.
+	attrs := []any{
+		"audit", true,
+		"session_id", in.SessionID,
+		"action", d.Action,
+		"reasons", d.Reasons,
+		"email_domain", emailDomain(in.Email),
+	}
+	switch d.Action {
+	case ActionBlock:
+		c.logger.WarnContext(ctx, "fraud: checkout blocked", attrs...)
+	case ActionFlag:
+		c.logger.WarnContext(ctx, "fraud: checkout flagged", attrs...)
+	default:
+		c.logger.DebugContext(ctx, "fraud: checkout allowed", attrs...)
+	}
+	return d
+}
+
+// IsDisposable reports whether email uses a known throwaway domain.
+// Subdomains match too: "x.mailinator.com" counts as "mailinator.com".
+func (c *Checker) IsDisposable(email string) bool {
+	domain := emailDomain(email)
+	for domain != "" {
+		if c.disposable[domain] {
+			return true
+		}
+		_, rest, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = rest
+	}
+	return false
+}
+
+// emailDomain returns the lower-cased part after the last @, or "".
+func emailDomain(email string) string {
+	i := strings.LastIndexByte(email, '@')
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[i+1:]))
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,119 @@
+package fraud_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fraud"
+)
+
+type stubStore struct {
+	ipSessions     int64
+	failedPayments int64
+	err            error
+	calls          int
+}
+
+func (s *stubStore) CountSessionsByIPHashSince(_ context.Context, _ db.CountSessionsByIPHashSinceParams) (int64, error) {
+	s.calls++
+	return s.ipSessions, s.err
+}
+
+func (s *stubStore) CountFailedPaymentsByEmailSince(_ context.Context, _ db.CountFailedPaymentsByEmailSinceParams) (int64, error) {
+	s.calls++
+	return s.failedPayments, s.err
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newChecker(mode fraud.Mode, store *stubStore) *fraud.Checker {
+	return fraud.NewChecker(fraud.Config{
+		Mode:                 mode,
+		MaxSessionsPerIPHour: 5,
+		MaxFailedPayments:    3,
+		DisposableDomains:    []string{" Burner.Example "},
+	}, store, discardLogger())
+}
+
+var input = fraud.Input{SessionID: uuid.New(), Email: "owner@acme.co.za", IPHash: "abc123"}
+
+func TestCheck_CleanCheckoutIsAllowed(t *testing.T) {
+	d := newChecker(fraud.ModeBlock, &stubStore{ipSessions: 5, failedPayments: 2}).Check(context.Background(), input)
+	if d.Action != fraud.ActionAllow || len(d.Reasons) != 0 {
+		t.Errorf("expected allow with no reasons, got %+v", d)
+	}
+}
+
+func TestCheck_ReasonsInStableOrder(t *testing.T) {
+	in := input
+	in.Email = "x@mailinator.com"
+	d := newChecker(fraud.ModeFlag, &stubStore{ipSessions: 6, failedPayments: 3}).Check(context.Background(), in)
+	want := []string{"disposable_email", "ip_velocity", "repeated_failed_payments"}
+	if !reflect.DeepEqual(d.Reasons, want) {
+		t.Errorf("reasons = %v, want %v", d.Reasons, want)
+	}
+	if d.Action != fraud.ActionFlag {
+		t.Errorf("expected flag in flag mode, got %s", d.Action)
+	}
+}
+
+func TestCheck_BlockModeBlocks(t *testing.T) {
+	d := newChecker(fraud.ModeBlock, &stubStore{ipSessions: 6}).Check(context.Background(), input)
+	if d.Action != fraud.ActionBlock {
+		t.Errorf("expected block, got %+v", d)
+	}
+}
+
+func TestCheck_OffModeRunsNothing(t *testing.T) {
+	store := &stubStore{ipSessions: 100, failedPayments: 100}
+	in := input
+	in.Email = "x@mailinator.com"
+	d := newChecker(fraud.ModeOff, store).Check(context.Background(), in)
+	if d.Action != fraud.ActionAllow || store.calls != 0 {
+		t.Errorf("expected allow without queries, got %+v after %d calls", d, store.calls)
+	}
+}
+
+func TestCheck_QueryErrorFailsOpen(t *testing.T) {
+	d := newChecker(fraud.ModeBlock, &stubStore{err: errors.New("db down")}).Check(context.Background(), input)
+	if d.Action != fraud.ActionAllow {
+		t.Errorf("expected allow when the checks cannot run, got %+v", d)
+	}
+}
+
+func TestCheck_MissingIPHashSkipsVelocity(t *testing.T) {
+	store := &stubStore{ipSessions: 100}
+	in := input
+	in.IPHash = ""
+	d := newChecker(fraud.ModeBlock, store).Check(context.Background(), in)
+	if d.Action != fraud.ActionAllow || store.calls != 1 {
+		t.Errorf("expected only the failed-payment query, got %+v after %d calls", d, store.calls)
+	}
+}
+
+func TestIsDisposable(t *testing.T) {
+	c := newChecker(fraud.ModeFlag, &stubStore{})
+	cases := map[string]bool{
+		"a@mailinator.com":    true,
+		"a@MAILINATOR.COM":    true,
+		"a@eu.mailinator.com": true,
+		"a@burner.example":    true, // from Config.DisposableDomains
+		"a@notmailinator.com": false,
+		"a@acme.co.za":        false,
+		"not-an-email":        false,
+		"":                    false,
+	}
+	for email, want := range cases {
+		if got := c.IsDisposable(email); got != want {
+			t.Errorf("IsDisposable(%q) = %v, want %v", email, got, want)
+		}
+	}
+}
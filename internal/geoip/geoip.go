@@ -0,0 +1,145 @@
+// Package geoip resolves IP addresses to countries from a local copy of the
+// free DB-IP "IP to Country Lite" database (https://db-ip.com/db/lite.php),
+// so request logs can carry a country without sending addresses to a third
+// party. Download the CSV (gzipped or not) and point GEOIP_DB at it; refresh
+// it monthly, as DB-IP does.
+//
+// The file has one range per line: first address, last address, ISO 3166
+// country code. IPv4 ranges are kept as integers, which keeps a full
+// database at a few tens of megabytes.
+package geoip
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DB is read-only once loaded and safe for concurrent use. A nil *DB knows
+// no country.
+type DB struct {
+	v4 []v4Range
+	v6 []v6Range
+}
+
+type v4Range struct {
+	first, last uint32
+	country     [2]byte
+}
+
+type v6Range struct {
+	first, last netip.Addr
+	country     [2]byte
+}
+
+// Open loads the database at path, gunzipping it when the name ends in .gz.
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: %w", err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	db, err := Load(r)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Load reads a database in DB-IP's country CSV format.
+func Load(r io.Reader) (*DB, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	db := &DB{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 3 {
+			return nil, fmt.Errorf("line %d: want first,last,country", line)
+		}
+		first, err1 := netip.ParseAddr(rec[0])
+		last, err2 := netip.ParseAddr(rec[1])
+		if err1 != nil || err2 != nil || first.Is4() != last.Is4() || last.Less(first) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, rec[0], rec[1])
+		}
+		cc := strings.ToUpper(rec[2])
+		if len(cc) != 2 {
+			return nil, fmt.Errorf("line %d: invalid country %q", line, rec[2])
+		}
+		country := [2]byte{cc[0], cc[1]}
+		if first.Is4() {
+			db.v4 = append(db.v4, v4Range{first: v4Int(first), last: v4Int(last), country: country})
+		} else {
+			db.v6 = append(db.v6, v6Range{first: first, last: last, country: country})
+		}
+	}
+	if len(db.v4)+len(db.v6) == 0 {
+		return nil, errors.New("no ranges")
+	}
+	sort.Slice(db.v4, func(i, j int) bool { return db.v4[i].first < db.v4[j].first })
+	sort.Slice(db.v6, func(i, j int) bool { return db.v6[i].first.Less(db.v6[j].first) })
+	return db, nil
+}
+
+// Len is the number of ranges loaded.
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.v4) + len(db.v6)
+}
+
+// Country returns the ISO 3166 code of ip's country, or "" when ip is in no
+// range or the database does not know (DB-IP's ZZ).
+func (db *DB) Country(ip netip.Addr) string {
+	if db == nil || !ip.IsValid() {
+		return ""
+	}
+	ip = ip.Unmap()
+	var cc [2]byte
+	if ip.Is4() {
+		n := v4Int(ip)
+		i := sort.Search(len(db.v4), func(i int) bool { return db.v4[i].first > n }) - 1
+		if i < 0 || n > db.v4[i].last {
+			return ""
+		}
+		cc = db.v4[i].country
+	} else {
+		i := sort.Search(len(db.v6), func(i int) bool { return ip.Less(db.v6[i].first) }) - 1
+		if i < 0 || db.v6[i].last.Less(ip) {
+			return ""
+		}
+		cc = db.v6[i].country
+	}
+	if cc == [2]byte{'Z', 'Z'} {
+		return ""
+	}
+	return string(cc[:])
+}
+
+func v4Int(ip netip.Addr) uint32 {
+	b := ip.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
@@ -0,0 +1,73 @@
+package geoip
+
+import (
+	"compress/gzip"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = `1.0.0.0,1.0.0.255,AU
+1.0.1.0,1.0.3.255,CN
+41.0.0.0,41.31.255.255,ZA
+10.0.0.0,10.255.255.255,ZZ
+2001:4860::,2001:4860:ffff:ffff:ffff:ffff:ffff:ffff,US
+`
+
+func TestCountry(t *testing.T) {
+	db, err := Load(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"1.0.0.7":              "AU",
+		"1.0.2.1":              "CN",
+		"41.13.7.9":            "ZA",
+		"::ffff:41.13.7.9":     "ZA",
+		"10.1.2.3":             "", // ZZ
+		"1.0.4.0":              "", // between ranges
+		"0.0.0.1":              "", // before the first
+		"2001:4860:4860::8888": "US",
+		"2a00:1450::1":         "",
+	}
+	for ip, want := range cases {
+		if got := db.Country(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("Country(%s) = %q, want %q", ip, got, want)
+		}
+	}
+
+	var none *DB
+	if got := none.Country(netip.MustParseAddr("1.0.0.7")); got != "" {
+		t.Errorf("nil DB returned %q", got)
+	}
+}
+
+func TestOpen_Gzipped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dbip-country-lite.csv.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(sample))
+	gz.Close()
+	f.Close()
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", db.Len())
+	}
+}
+
+func TestLoad_RejectsMalformedRanges(t *testing.T) {
+	for _, in := range []string{"", "1.0.0.255,1.0.0.0,AU\n", "1.0.0.0,::1,AU\n", "1.0.0.0,1.0.0.255,Australia\n"} {
+		if _, err := Load(strings.NewReader(in)); err == nil {
+			t.Errorf("Load(%q) succeeded", in)
+		}
+	}
+}
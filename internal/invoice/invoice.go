@@ -0,0 +1,122 @@
+// Package invoice renders the downloadable invoice for a paid report as a
+// one-page PDF.
+//
+// Dependency rule: invoice imports nothing from this module. The api package
+// maps database rows onto Invoice.
+package invoice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Invoice is everything printed on the document. Amounts are in the
+// currency's minor unit, as Stripe reports them.
+type Invoice struct {
+	Number   string    // e.g. "INV-001000"
+	IssuedAt time.Time // the payment date
+
+	Issuer []string // seller name, address and tax registration, one per line
+	BillTo []string // customer name, address and tax ID, one per line
+
+	Description   string // the single line item, e.g. the product name
+	SubtotalCents int64
+	TaxCents      int64
+	Currency      string // ISO 4217, any case
+
+	// PaymentNote is printed under the totals, e.g. the Stripe payment ID.
+	PaymentNote string
+}
+
+// TotalCents is the amount charged.
+func (inv Invoice) TotalCents() int64 {
+	return inv.SubtotalCents + inv.TaxCents
+}
+
+// Render lays the invoice out on an A4 page and returns the PDF bytes.
+func Render(inv Invoice) []byte {
+	const (
+		left     = 56.0
+		amountX  = 440.0
+		right    = pageWidth - 56.0
+		lineStep = 14.0
+	)
+
+	var c canvas
+	y := float64(pageHeight - 72)
+
+	c.text(left, y, fontBold, 22, "INVOICE")
+	c.text(amountX, y, fontRegular, 10, "No. "+inv.Number)
+	c.text(amountX, y-lineStep, fontRegular, 10, "Date "+inv.IssuedAt.UTC().Format("2 January 2006"))
+
+	// ── Parties ───────────────────────────────────────────────────────────────
+	y -= 56
+	c.text(left, y, fontBold, 10, "From")
+	c.text(300, y, fontBold, 10, "Bill to")
+	for i := 0; i < len(inv.Issuer) || i < len(inv.BillTo); i++ {
+		y -= lineStep
+		if i < len(inv.Issuer) {
+			c.text(left, y, fontRegular, 10, inv.Issuer[i])
+		}
+		if i < len(inv.BillTo) {
+			c.text(300, y, fontRegular, 10, inv.BillTo[i])
+		}
+	}
+
+	// ── Line item and totals ──────────────────────────────────────────────────
+	y -= 40
+	c.text(left, y, fontBold, 10, "Description")
+	c.text(amountX, y, fontBold, 10, "Amount")
+	y -= 6
+	c.rule(left, right, y)
+
+	y -= 18
+	c.text(left, y, fontRegular, 10, inv.Description)
+	c.text(amountX, y, fontRegular, 10, Money(inv.SubtotalCents, inv.Currency))
+
+	y -= 10
+	c.rule(left, right, y)
+	y -= 18
+	c.text(300, y, fontRegular, 10, "Subtotal")
+	c.text(amountX, y, fontRegular, 10, Money(inv.SubtotalCents, inv.Currency))
+	y -= lineStep
+	c.text(300, y, fontRegular, 10, "Tax")
+	c.text(amountX, y, fontRegular, 10, Money(inv.TaxCents, inv.Currency))
+	y -= lineStep + 4
+	c.text(300, y, fontBold, 11, "Total paid")
+	c.text(amountX, y, fontBold, 11, Money(inv.TotalCents(), inv.Currency))
+
+	if inv.PaymentNote != "" {
+		y -= 40
+		c.text(left, y, fontRegular, 9, inv.PaymentNote)
+	}
+
+	return document(c.buf.Bytes(), "Invoice "+inv.Number)
+}
+
+// zeroDecimal lists the currencies Stripe charges in whole units.
+var zeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "JPY": true,
+	"KMF": true, "KRW": true, "MGA": true, "PYG": true, "RWF": true,
+	"UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// Money formats an amount the way invoices conventionally do, with the ISO
+// code first: 5900 usd → "USD 59.00".
+func Money(amount int64, currency string) string {
+	return strings.ToUpper(currency) + " " + Decimal(amount, currency)
+}
+
+// Decimal formats an amount in the smallest currency unit as a plain decimal
+// in major units: 5900 usd → "59.00", -500 jpy → "-500".
+func Decimal(amount int64, currency string) string {
+	if zeroDecimal[strings.ToUpper(currency)] {
+		return fmt.Sprintf("%d", amount)
+	}
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
@@ -0,0 +1,95 @@
+package invoice_test
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/invoice"
+)
+
+func sample() invoice.Invoice {
+	return invoice.Invoice{
+		Number:        "INV-001000",
+		IssuedAt:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Issuer:        []string{"Asymmetric Risk Ltd", "1 High Street"},
+		BillTo:        []string{"Acme (Europe) GmbH", "Berlin", "DE", "VAT DE123456789"},
+		Description:   "Risk Report",
+		SubtotalCents: 5900,
+		TaxCents:      1121,
+		Currency:      "eur",
+		PaymentNote:   "Paid by card · Stripe payment pi_123",
+	}
+}
+
+// ─── Render ───────────────────────────────────────────────────────────────────
+
+func TestRender_IsWellFormedPDF(t *testing.T) {
+	pdf := invoice.Render(sample())
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Fatalf("missing PDF header: %q", pdf[:16])
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Error("missing EOF trailer")
+	}
+
+	// startxref must point at the xref table, and every xref entry at the
+	// object it names, or readers fall back to slow (or failed) recovery.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at xref table", xref)
+	}
+	for i, e := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf[xref:], -1) {
+		off, _ := strconv.Atoi(string(e[1]))
+		want := strconv.Itoa(i+1) + " 0 obj"
+		if !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, pdf[off:off+len(want)], want)
+		}
+	}
+}
+
+func TestRender_ContainsInvoiceDetails(t *testing.T) {
+	pdf := invoice.Render(sample())
+
+	for _, want := range []string{
+		"No. INV-001000",
+		"Date 1 March 2026",
+		`Acme \(Europe\) GmbH`, // parentheses escaped inside PDF strings
+		"VAT DE123456789",
+		"EUR 59.00",
+		"EUR 11.21",
+		"EUR 70.21",
+		"Paid by card \xb7 Stripe payment pi_123", // Latin-1 middle dot, one byte
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF does not contain %q", want)
+		}
+	}
+}
+
+// ─── Money ────────────────────────────────────────────────────────────────────
+
+func TestMoney(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{5900, "usd", "USD 59.00"},
+		{5, "gbp", "GBP 0.05"},
+		{5900, "jpy", "JPY 5900"},
+		{-250, "eur", "EUR -2.50"},
+	}
+	for _, c := range cases {
+		if got := invoice.Money(c.amount, c.currency); got != c.want {
+			t.Errorf("Money(%d, %q) = %q, want %q", c.amount, c.currency, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ─── MINIMAL PDF WRITER ───────────────────────────────────────────────────────
+//
+// Invoices are a single page of text and rules, which the PDF format can
+// express with the two standard Helvetica fonts every reader ships. Writing
+// that by hand is a few dozen lines and keeps a layout engine out of the
+// dependency tree.
+
+const (
+	pageWidth  = 595 // A4 in points
+	pageHeight = 842
+
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// canvas accumulates a page's content stream.
+type canvas struct {
+	buf bytes.Buffer
+}
+
+// text draws s with its baseline starting at (x, y), measured from the
+// bottom-left corner of the page.
+func (c *canvas) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(&c.buf, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+// rule draws a thin horizontal line from x1 to x2 at height y.
+func (c *canvas) rule(x1, x2, y float64) {
+	fmt.Fprintf(&c.buf, "0.5 w %.1f %.1f m %.1f %.1f l S\n", x1, y, x2, y)
+}
+
+// escape encodes s as the body of a PDF literal string in WinAnsiEncoding,
+// the encoding the standard fonts are declared with. Characters it cannot
+// represent become '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		var ch byte
+		switch {
+		case r < 0x20:
+			ch = ' '
+		case r < 0x80, r >= 0xA0 && r <= 0xFF:
+			ch = byte(r)
+		default:
+			var ok bool
+			if ch, ok = winAnsiExtras[r]; !ok {
+				ch = '?'
+			}
+		}
+		if ch == '(' || ch == ')' || ch == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+// winAnsiExtras maps the characters WinAnsiEncoding places in 0x80–0x9F.
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// document wraps a single page's content stream in the objects a PDF reader
+// needs: catalog, page tree, page, fonts and the cross-reference table.
+func document(content []byte, title string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /%s 5 0 R /%s 6 0 R >> >> /Contents 4 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) >>", escape(title)),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref)
+
+	return out.Bytes()
+}
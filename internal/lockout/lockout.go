@@ -0,0 +1,196 @@
+// Package lockout counts failures per key — a client IP, a guessed token —
+// and locks a key out for a while once it fails too often in a window. It is
+// what stops report access tokens being enumerated one 404 at a time.
+//
+// State is held in memory unless Config.Redis is set, in which case every
+// replica sharing the Redis counts against one budget. In memory each replica
+// counts on its own: with N replicas behind a round-robin load balancer a
+// client gets up to N times the budget before every replica has locked it out.
+package lockout
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxEntries bounds the keys a Tracker remembers. A distributed guesser sends
+// a new token on every request; once the map is full, expired keys are swept
+// and, if none can go, new keys are not tracked until some expire.
+const maxEntries = 100_000
+
+// Config holds the thresholds. A zero MaxFailures disables the Tracker.
+type Config struct {
+	// MaxFailures is how many failures one key may have in Window before it
+	// is locked out.
+	MaxFailures int
+	// Window is how long failures are counted for. Default: 15m.
+	Window time.Duration
+	// Duration is how long a lockout lasts. Default: 15m.
+	Duration time.Duration
+
+	// Redis, when set, holds the counts and lockouts instead of this
+	// process's memory. While it cannot be reached the Tracker counts in
+	// memory, as if it were unset, and logs the error to Logger.
+	Redis  *redis.Client
+	Logger *slog.Logger
+	// Name keeps this Tracker's keys in Redis apart from other Trackers',
+	// e.g. "report_ip".
+	Name string
+}
+
+type entry struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// Tracker is safe for concurrent use. A nil *Tracker never locks anything.
+type Tracker struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New returns a Tracker for cfg, or nil when cfg.MaxFailures is not positive.
+func New(cfg Config) *Tracker {
+	if cfg.MaxFailures <= 0 {
+		return nil
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = 15 * time.Minute
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &Tracker{cfg: cfg, now: time.Now, entries: make(map[string]*entry)}
+}
+
+// Locked reports whether key is locked out and, if so, for how much longer.
+func (t *Tracker) Locked(ctx context.Context, key string) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	if t.cfg.Redis != nil {
+		left, err := t.cfg.Redis.PTTL(ctx, t.redisKey(key, "lock")).Result()
+		if err == nil {
+			// PTTL answers -2 for a key that does not exist.
+			return max(left, 0), left > 0
+		}
+		t.redisFailed("locked", err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		return 0, false
+	}
+	if left := e.lockedUntil.Sub(t.now()); left > 0 {
+		return left, true
+	}
+	return 0, false
+}
+
+// Fail records a failure for key. It returns the lockout duration and true
+// when this failure is the one that locks key out, so the caller can log
+// the lockout exactly once. Failures while locked out are not counted.
+func (t *Tracker) Fail(ctx context.Context, key string) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	if t.cfg.Redis != nil {
+		locked, err := failScript.Run(ctx, t.cfg.Redis,
+			[]string{t.redisKey(key, "failures"), t.redisKey(key, "lock")},
+			t.cfg.MaxFailures, t.cfg.Window.Milliseconds(), t.cfg.Duration.Milliseconds(),
+		).Bool()
+		if err == nil {
+			if locked {
+				return t.cfg.Duration, true
+			}
+			return 0, false
+		}
+		t.redisFailed("fail", err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	e, ok := t.entries[key]
+	if !ok {
+		if len(t.entries) >= maxEntries && !t.sweep(now) {
+			return 0, false
+		}
+		e = &entry{windowStart: now}
+		t.entries[key] = e
+	}
+	if now.Before(e.lockedUntil) {
+		return 0, false
+	}
+	if now.Sub(e.windowStart) >= t.cfg.Window {
+		e.failures, e.windowStart = 0, now
+	}
+
+	e.failures++
+	if e.failures < t.cfg.MaxFailures {
+		return 0, false
+	}
+	e.failures, e.windowStart = 0, now
+	e.lockedUntil = now.Add(t.cfg.Duration)
+	return t.cfg.Duration, true
+}
+
+// sweep drops keys whose window and lockout have both passed, and reports
+// whether that made room for a new one. Called with mu held.
+func (t *Tracker) sweep(now time.Time) bool {
+	for k, e := range t.entries {
+		if now.Sub(e.windowStart) >= t.cfg.Window && !now.Before(e.lockedUntil) {
+			delete(t.entries, k)
+		}
+	}
+	return len(t.entries) < maxEntries
+}
+
+// ─── REDIS ────────────────────────────────────────────────────────────────────
+
+// failScript is Fail in Redis: KEYS are the failure count and the lockout,
+// ARGV MaxFailures, Window and Duration in milliseconds. It returns 1 when
+// this failure locks the key out. The count expires with its window, which
+// starts at the first failure, so the behaviour matches the in-memory one.
+var failScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if n < tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('SET', KEYS[2], '1', 'PX', ARGV[3])
+return 1
+`)
+
+// redisKey names one of key's entries, e.g. arm:lockout:report_ip:1.2.3.4:lock.
+func (t *Tracker) redisKey(key, kind string) string {
+	return cluster.KeyPrefix + "lockout:" + t.cfg.Name + ":" + key + ":" + kind
+}
+
+func (t *Tracker) redisFailed(op string, err error) {
+	t.cfg.Logger.Warn("lockout: redis unavailable, counting in memory",
+		"tracker", t.cfg.Name,
+		"op", op,
+		"error", err,
+	)
+}
@@ -0,0 +1,152 @@
+package lockout
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/cluster"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestTracker(cfg Config) (*Tracker, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	t := New(cfg)
+	t.now = func() time.Time { return now }
+	return t, &now
+}
+
+func TestFail_LocksOutAfterMaxFailuresAndExpires(t *testing.T) {
+	ctx := context.Background()
+	tr, now := newTestTracker(Config{MaxFailures: 3, Window: time.Minute, Duration: 10 * time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if _, locked := tr.Fail(ctx, "1.2.3.4"); locked {
+			t.Fatalf("failure %d should not lock out", i+1)
+		}
+	}
+	d, locked := tr.Fail(ctx, "1.2.3.4")
+	if !locked || d != 10*time.Minute {
+		t.Fatalf("third failure should lock out for 10m, got %v %v", d, locked)
+	}
+	if _, again := tr.Fail(ctx, "1.2.3.4"); again {
+		t.Error("failures while locked out should not report a new lockout")
+	}
+	if left, ok := tr.Locked(ctx, "1.2.3.4"); !ok || left != 10*time.Minute {
+		t.Errorf("Locked = %v %v", left, ok)
+	}
+	if _, ok := tr.Locked(ctx, "5.6.7.8"); ok {
+		t.Error("other keys must not be locked")
+	}
+
+	*now = now.Add(10 * time.Minute)
+	if _, ok := tr.Locked(ctx, "1.2.3.4"); ok {
+		t.Error("lockout should have expired")
+	}
+}
+
+func TestFail_CountsOnlyWithinTheWindow(t *testing.T) {
+	ctx := context.Background()
+	tr, now := newTestTracker(Config{MaxFailures: 2, Window: time.Minute})
+
+	tr.Fail(ctx, "k")
+	*now = now.Add(2 * time.Minute)
+	if _, locked := tr.Fail(ctx, "k"); locked {
+		t.Error("a failure outside the window should start a new count")
+	}
+	if _, locked := tr.Fail(ctx, "k"); !locked {
+		t.Error("two failures inside the window should lock out")
+	}
+}
+
+func TestFail_SweepsExpiredKeysWhenFull(t *testing.T) {
+	ctx := context.Background()
+	tr, now := newTestTracker(Config{MaxFailures: 5, Window: time.Minute})
+	for i := 0; i < maxEntries; i++ {
+		tr.entries[strconv.Itoa(i)] = &entry{failures: 1, windowStart: *now}
+	}
+
+	tr.Fail(ctx, "new")
+	if _, ok := tr.entries["new"]; ok {
+		t.Error("a full tracker with nothing expired should not take new keys")
+	}
+	*now = now.Add(time.Minute)
+	tr.Fail(ctx, "new")
+	if len(tr.entries) != 1 {
+		t.Errorf("expected expired keys swept, %d remain", len(tr.entries))
+	}
+}
+
+func TestNilTracker_NeverLocks(t *testing.T) {
+	ctx := context.Background()
+	tr := New(Config{})
+	if tr != nil {
+		t.Fatal("zero MaxFailures should disable the tracker")
+	}
+	if _, locked := tr.Fail(ctx, "k"); locked {
+		t.Error("nil tracker locked out")
+	}
+	if _, locked := tr.Locked(ctx, "k"); locked {
+		t.Error("nil tracker locked out")
+	}
+}
+
+func TestFail_FallsBackToMemoryWhenRedisIsDown(t *testing.T) {
+	ctx := context.Background()
+	down := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer down.Close()
+	tr, _ := newTestTracker(Config{
+		MaxFailures: 2,
+		Redis:       down,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Name:        "test",
+	})
+
+	tr.Fail(ctx, "k")
+	if _, locked := tr.Fail(ctx, "k"); !locked {
+		t.Fatal("failures should still be counted in memory")
+	}
+	if _, ok := tr.Locked(ctx, "k"); !ok {
+		t.Error("the in-memory lockout should apply")
+	}
+}
+
+// TestRedis_SharesOneBudgetAcrossTrackers needs a Redis server; it runs when
+// REDIS_URL is set.
+func TestRedis_SharesOneBudgetAcrossTrackers(t *testing.T) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL not set")
+	}
+	ctx := context.Background()
+	client, err := cluster.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer client.Close()
+
+	cfg := Config{
+		MaxFailures: 3,
+		Window:      time.Minute,
+		Duration:    time.Minute,
+		Redis:       client,
+		Name:        "test_" + strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+	a, b := New(cfg), New(cfg)
+
+	a.Fail(ctx, "1.2.3.4")
+	b.Fail(ctx, "1.2.3.4")
+	if d, locked := a.Fail(ctx, "1.2.3.4"); !locked || d != time.Minute {
+		t.Fatalf("the third failure across both trackers should lock out, got %v %v", d, locked)
+	}
+	if left, ok := b.Locked(ctx, "1.2.3.4"); !ok || left <= 0 || left > time.Minute {
+		t.Errorf("the other tracker should see the lockout, got %v %v", left, ok)
+	}
+	if _, again := b.Fail(ctx, "1.2.3.4"); again {
+		t.Error("failures while locked out should not report a new lockout")
+	}
+}
@@ -0,0 +1,247 @@
+// Package logging provides the slog handlers shared by every component of the
+// process. It has two jobs:
+//
+//   - ContextHandler copies attributes stored on a context.Context onto every
+//     record logged with that context, so a job can tag its report_id,
+//     session_id, attempt and trace_id once instead of threading .With calls
+//     through every function it touches.
+//   - SamplingHandler drops a configurable fraction of debug records so noisy
+//     per-step logs can stay enabled in production without flooding the sink.
+//   - RedactingHandler rewrites the message and every string-like attribute
+//     through a caller-supplied function, so personal data and credentials
+//     never reach the sink.
+//
+// None of the handlers knows anything about the rest of the application; main
+// wires them together with New.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+)
+
+// ─── CONSTRUCTOR ──────────────────────────────────────────────────────────────
+
+// Options controls the handler chain built by New.
+type Options struct {
+	// JSON selects the JSON handler (production) instead of the text handler.
+	JSON bool
+
+	// Level is the minimum level that reaches the sink.
+	Level slog.Level
+
+	// DebugSampleRate is the fraction (0–1) of debug records that are kept.
+	// Records at Info and above are never sampled. A value >= 1 disables
+	// sampling entirely.
+	DebugSampleRate float64
+
+	// Redact, when non-nil, is applied to the message and to every string,
+	// error and Stringer attribute value before it is written.
+	Redact func(string) string
+}
+
+// New builds the application logger: base handler → redactor → sampler →
+// context tagger.
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+
+	var h slog.Handler
+	if opts.JSON {
+		h = slog.NewJSONHandler(w, handlerOpts)
+	} else {
+		h = slog.NewTextHandler(w, handlerOpts)
+	}
+
+	if opts.Redact != nil {
+		h = NewRedactingHandler(h, opts.Redact)
+	}
+	if opts.DebugSampleRate < 1 {
+		h = NewSamplingHandler(h, opts.DebugSampleRate)
+	}
+
+	return slog.New(NewContextHandler(h))
+}
+
+// ─── CONTEXT ATTRIBUTES ───────────────────────────────────────────────────────
+
+type ctxKey struct{}
+
+// With returns a copy of ctx carrying args (in slog key/value form) in addition
+// to any attributes already attached. Every record logged through a
+// ContextHandler with the returned context includes them.
+func With(ctx context.Context, args ...any) context.Context {
+	if len(args) == 0 {
+		return ctx
+	}
+	existing := attrsFrom(ctx)
+	added := argsToAttrs(args)
+
+	merged := make([]slog.Attr, 0, len(existing)+len(added))
+	merged = append(merged, existing...)
+	merged = append(merged, added...)
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+// argsToAttrs converts alternating key/value pairs the same way slog does,
+// including accepting slog.Attr values directly.
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+// ContextHandler decorates another handler, appending the attributes attached
+// to the record's context via With.
+type ContextHandler struct {
+	next slog.Handler
+}
+
+// NewContextHandler wraps next.
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := attrsFrom(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name)}
+}
+
+// ─── SAMPLING ─────────────────────────────────────────────────────────────────
+
+// SamplingHandler keeps only a fraction of records below slog.LevelInfo.
+// Warnings and errors always pass through untouched.
+type SamplingHandler struct {
+	next slog.Handler
+	rate float64
+	roll func() float64
+}
+
+// NewSamplingHandler wraps next, keeping roughly rate×100% of debug records.
+// rate is clamped to [0, 1].
+func NewSamplingHandler(next slog.Handler, rate float64) *SamplingHandler {
+	return &SamplingHandler{
+		next: next,
+		rate: min(max(rate, 0), 1),
+		roll: rand.Float64,
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < slog.LevelInfo && h.rate == 0 {
+		return false
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelInfo && h.roll() >= h.rate {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), rate: h.rate, roll: h.roll}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), rate: h.rate, roll: h.roll}
+}
+
+// ─── REDACTION ────────────────────────────────────────────────────────────────
+
+// RedactingHandler passes the record message and attribute values through a
+// redaction function before handing the record on. It sits directly above the
+// base handler so attributes added by ContextHandler and WithAttrs are covered
+// too.
+type RedactingHandler struct {
+	next   slog.Handler
+	redact func(string) string
+}
+
+// NewRedactingHandler wraps next, rewriting text with redact.
+func NewRedactingHandler(next slog.Handler, redact func(string) string) *RedactingHandler {
+	return &RedactingHandler{next: next, redact: redact}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.attr(a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), redact: h.redact}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), redact: h.redact}
+}
+
+// attr redacts a's value. Numbers, bools, times and durations cannot carry
+// text and pass through; errors and Stringers are flattened to their string
+// form first.
+func (h *RedactingHandler) attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, g := range group {
+			redacted[i] = h.attr(g)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, h.redact(x.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, h.redact(x.String()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,104 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/logging"
+)
+
+func TestWith_AttrsAppearOnEveryContextRecord(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{Level: slog.LevelDebug, DebugSampleRate: 1})
+
+	ctx := logging.With(context.Background(), "report_id", "r-1", "attempt", 2)
+	ctx = logging.With(ctx, "session_id", "s-1")
+
+	logger.InfoContext(ctx, "first")
+	logger.DebugContext(ctx, "second")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %q", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		for _, want := range []string{"report_id=r-1", "attempt=2", "session_id=s-1"} {
+			if !strings.Contains(line, want) {
+				t.Errorf("line %q missing %q", line, want)
+			}
+		}
+	}
+}
+
+func TestWith_DoesNotLeakIntoParentContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{Level: slog.LevelInfo, DebugSampleRate: 1})
+
+	parent := logging.With(context.Background(), "report_id", "r-1")
+	_ = logging.With(parent, "attempt", 1)
+
+	logger.InfoContext(parent, "msg")
+	if strings.Contains(buf.String(), "attempt") {
+		t.Errorf("child attribute leaked into parent: %q", buf.String())
+	}
+}
+
+func TestSampling_ZeroRateDropsDebugButKeepsWarn(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{Level: slog.LevelDebug, DebugSampleRate: 0})
+
+	for range 50 {
+		logger.Debug("noisy")
+	}
+	logger.Warn("important")
+
+	out := buf.String()
+	if strings.Contains(out, "noisy") {
+		t.Errorf("debug line should have been sampled out: %q", out)
+	}
+	if !strings.Contains(out, "important") {
+		t.Errorf("warn line must never be sampled: %q", out)
+	}
+}
+
+func TestSampling_FullRateKeepsAllDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Options{Level: slog.LevelDebug, DebugSampleRate: 1})
+
+	for range 20 {
+		logger.Debug("noisy")
+	}
+
+	if got := strings.Count(buf.String(), "noisy"); got != 20 {
+		t.Errorf("expected 20 debug lines, got %d", got)
+	}
+}
+
+func TestRedact_RewritesMessageAttrsAndContext(t *testing.T) {
+	var buf bytes.Buffer
+	redact := func(s string) string { return strings.ReplaceAll(s, "secret", "[redacted]") }
+	logger := logging.New(&buf, logging.Options{Level: slog.LevelInfo, DebugSampleRate: 1, Redact: redact}).
+		With("bound", "secret-bound")
+
+	ctx := logging.With(context.Background(), "ctx", "secret-ctx")
+	logger.InfoContext(ctx, "sent secret",
+		"to", "secret-to",
+		"error", errors.New("secret-err"),
+		slog.Group("req", "token", "secret-group"),
+		"count", 3,
+	)
+
+	out := buf.String()
+	if strings.Contains(out, "secret") {
+		t.Errorf("expected every occurrence to be redacted: %q", out)
+	}
+	for _, want := range []string{"msg=\"sent [redacted]\"", "to=[redacted]-to", "req.token=[redacted]-group", "ctx=[redacted]-ctx", "bound=[redacted]-bound", "count=3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q missing %q", out, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package prefill verifies the signed pre-fill tokens partners (accountants,
+// consultants) put in the links they send their clients, so a client opens an
+// assessment with the facts the partner already knows filled in.
+//
+// A token is two base64url (unpadded) parts joined by a dot:
+//
+//	<payload>.<HMAC-SHA256 of the encoded payload under the partner's key>
+//
+// and the payload is JSON:
+//
+//	{
+//	  "partner": "acme-accounting",
+//	  "expires_at": "2026-11-30T00:00:00Z",
+//	  "biz_name": "Harbour Bakery",
+//	  "industry": "Food & drink",
+//	  "stage": "Established",
+//	  "answers": {"s1_headcount": "10-49", "s1_revenue": "R1m-R5m"}
+//	}
+//
+// partner selects the key; expires_at is required. answers are keyed by
+// question ID, as returned by the questions endpoint.
+package prefill
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned by Verify for a token that is malformed, not
+// signed with its partner's key, or expired.
+var ErrInvalidToken = errors.New("prefill: invalid token")
+
+// minKeyLen is the shortest partner key accepted, in bytes.
+const minKeyLen = 32
+
+// Keys maps partner IDs to their signing keys.
+type Keys map[string][]byte
+
+// ParseKeys parses "partner:key" entries, as configured in PARTNER_KEYS.
+func ParseKeys(entries []string) (Keys, error) {
+	keys := make(Keys, len(entries))
+	for _, entry := range entries {
+		id, key, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, errors.New(`entries must be "partner:key"`)
+		}
+		if len(key) < minKeyLen {
+			return nil, fmt.Errorf("key for partner %q must be at least %d characters", id, minKeyLen)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("partner %q appears twice", id)
+		}
+		keys[id] = []byte(key)
+	}
+	return keys, nil
+}
+
+// Payload is what a partner knows about the client's business.
+type Payload struct {
+	Partner   string            `json:"partner"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	BizName   string            `json:"biz_name,omitempty"`
+	Industry  string            `json:"industry,omitempty"`
+	Stage     string            `json:"stage,omitempty"`
+	Answers   map[string]string `json:"answers,omitempty"`
+}
+
+// Sign returns a token for p under key. Partners sign their own tokens; this
+// is for tests and tooling.
+func Sign(p Payload, key []byte) (string, error) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(body)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(encoded, key)), nil
+}
+
+// Verify checks token's signature against its partner's key and returns the
+// payload.
+func (k Keys) Verify(token string, now time.Time) (Payload, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Payload{}, ErrInvalidToken
+	}
+	body, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Payload{}, ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return Payload{}, ErrInvalidToken
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Payload{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	key, ok := k[p.Partner]
+	if !ok || !hmac.Equal(mac, sign(encoded, key)) {
+		return Payload{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+	if p.ExpiresAt.IsZero() || !now.Before(p.ExpiresAt) {
+		return Payload{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	return p, nil
+}
+
+func sign(encoded string, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
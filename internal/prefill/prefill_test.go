@@ -0,0 +1,67 @@
+package prefill
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = strings.Repeat("k", minKeyLen)
+
+func TestVerify(t *testing.T) {
+	keys, err := ParseKeys([]string{"acme:" + testKey})
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	payload := Payload{Partner: "acme", ExpiresAt: now.Add(time.Hour), Industry: "Retail", Answers: map[string]string{"s1_headcount": "10-49"}}
+
+	token, err := Sign(payload, []byte(testKey))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	got, err := keys.Verify(token, now)
+	if err != nil || got.Industry != "Retail" || got.Answers["s1_headcount"] != "10-49" {
+		t.Fatalf("Verify = %+v, %v", got, err)
+	}
+
+	otherKey, _ := Sign(payload, []byte(strings.Repeat("x", minKeyLen)))
+	unknown, _ := Sign(Payload{Partner: "beta", ExpiresAt: now.Add(time.Hour)}, []byte(testKey))
+	noExpiry, _ := Sign(Payload{Partner: "acme"}, []byte(testKey))
+	// The payload of one token with the signature of another.
+	later, _ := Sign(Payload{Partner: "acme", ExpiresAt: now.Add(24 * time.Hour)}, []byte(testKey))
+	encoded, _, _ := strings.Cut(later, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	tampered := encoded + "." + sig
+
+	cases := map[string]struct {
+		token string
+		at    time.Time
+	}{
+		"wrong key":       {otherKey, now},
+		"unknown partner": {unknown, now},
+		"expired":         {token, now.Add(time.Hour)},
+		"no expiry":       {noExpiry, now},
+		"tampered":        {tampered, now},
+		"malformed":       {"not-a-token", now},
+	}
+	for name, tc := range cases {
+		if _, err := keys.Verify(tc.token, tc.at); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestParseKeys_RejectsBadEntries(t *testing.T) {
+	for _, entries := range [][]string{
+		{"acme"},
+		{":" + testKey},
+		{"acme:short"},
+		{"acme:" + testKey, "acme:" + testKey},
+	} {
+		if _, err := ParseKeys(entries); err == nil {
+			t.Errorf("%q: expected an error", entries)
+		}
+	}
+}
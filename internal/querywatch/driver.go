@@ -0,0 +1,131 @@
+package querywatch
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// ─── RELEASING TIMEOUTS ───────────────────────────────────────────────────────
+//
+// A query's timeout context has to outlive QueryContext and QueryRowContext,
+// because the caller reads the rows after they return, but *sql.Rows and
+// *sql.Row have no hook for when they are closed. So watched puts the
+// context's cancel func on the context itself, and the connections Connector
+// opens call it when the driver's rows close: at Rows.Close, when Rows.Next
+// runs out, or at the end of Row.Scan. That stops the timer there and then
+// instead of when DB_QUERY_TIMEOUT passes.
+//
+// A pool not opened through Connector still works; its timeouts are released
+// at their deadline.
+
+type releaseKey struct{}
+
+// withRelease returns ctx carrying release, for the driver to call once the
+// query's rows are closed.
+func withRelease(ctx context.Context, release context.CancelFunc) context.Context {
+	return context.WithValue(ctx, releaseKey{}, release)
+}
+
+// Connector returns c with each query's timeout released as soon as its rows
+// are closed. Open every pool a Watcher wraps through it:
+//
+//	connector, err := pq.NewConnector(dsn)
+//	pool := sql.OpenDB(querywatch.Connector(connector))
+func Connector(c driver.Connector) driver.Connector {
+	return connector{Connector: c}
+}
+
+type connector struct {
+	driver.Connector
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return releasingConn{Conn: conn}, nil
+}
+
+// releasingConn wraps the rows of queries carrying a release func. Every
+// optional interface database/sql looks for is passed through, so the
+// wrapped driver behaves as it would unwrapped.
+type releasingConn struct {
+	driver.Conn
+}
+
+func (c releasingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if release, ok := ctx.Value(releaseKey{}).(context.CancelFunc); ok {
+		return releasingRows{Rows: rows, release: release}, nil
+	}
+	return rows, nil
+}
+
+func (c releasingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c releasingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c releasingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+func (c releasingConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c releasingConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c releasingConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c releasingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// releasingRows releases its query's timeout when closed.
+type releasingRows struct {
+	driver.Rows
+	release context.CancelFunc
+}
+
+func (r releasingRows) Close() error {
+	err := r.Rows.Close()
+	r.release()
+	return err
+}
@@ -0,0 +1,234 @@
+// Package querywatch wraps the handle db.Queries runs its statements on, so
+// every query gets a timeout, slow ones are logged by sqlc statement name and
+// per-statement counts and timings are kept for GET /api/admin/queries. An
+// N+1 shows up as a statement with a call count out of proportion to its
+// callers; a missing index as one whose mean creeps up — both well before
+// the pool runs dry.
+//
+// It sits under db.Queries rather than around db.Querier: every generated
+// query passes through the four db.DBTX methods, so new queries are covered
+// without a wrapper method each. The statement name comes from the
+// "-- name: GetReportByID :one" header sqlc puts on every query. A pool
+// opened through Connector also releases each query's timeout as soon as its
+// rows are closed.
+package querywatch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// Unnamed is the statement name of SQL without a sqlc header, such as the
+// transaction tagging in store.
+const Unnamed = "(unnamed)"
+
+// Config holds the limits. Zero values disable the respective feature.
+type Config struct {
+	// Timeout bounds each statement, reading its rows included. A caller's
+	// earlier deadline still wins.
+	Timeout time.Duration
+	// SlowThreshold is the duration above which a statement is logged.
+	SlowThreshold time.Duration
+}
+
+// Stats are one statement's counters since the process started. A query's
+// duration is the time until its first row is available; rows are read by
+// the caller afterwards.
+type Stats struct {
+	Name     string        `json:"name"`
+	Calls    int64         `json:"calls"`
+	Errors   int64         `json:"errors"`   // timeouts and cancellations included
+	Timeouts int64         `json:"timeouts"` // cut off by Config.Timeout
+	Canceled int64         `json:"canceled"` // the caller's context ended first
+	Slow     int64         `json:"slow"`     // above Config.SlowThreshold
+	Total    time.Duration `json:"-"`
+	Max      time.Duration `json:"-"`
+}
+
+// Mean is the average duration of a call.
+func (s Stats) Mean() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// Watcher is safe for concurrent use, and one may wrap several handles (the
+// pool, a replica, each transaction). A nil *Watcher wraps nothing.
+type Watcher struct {
+	cfg    Config
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	stats map[string]*Stats
+}
+
+// New returns a Watcher with empty counters.
+func New(cfg Config, logger *slog.Logger) *Watcher {
+	return &Watcher{cfg: cfg, logger: logger, stats: make(map[string]*Stats)}
+}
+
+// Config returns the limits w was built with.
+func (w *Watcher) Config() Config {
+	if w == nil {
+		return Config{}
+	}
+	return w.cfg
+}
+
+// Wrap returns h with its statements timed out, logged and counted by w.
+func (w *Watcher) Wrap(h db.DBTX) db.DBTX {
+	if w == nil {
+		return h
+	}
+	return watched{DBTX: h, w: w}
+}
+
+// Snapshot returns every statement's counters, the most total time first.
+func (w *Watcher) Snapshot() []Stats {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	out := make([]Stats, 0, len(w.stats))
+	for _, s := range w.stats {
+		out = append(out, *s)
+	}
+	w.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// watched is a db.DBTX whose statements go through its Watcher.
+type watched struct {
+	db.DBTX
+	w *Watcher
+}
+
+func (d watched) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	qctx, cancel := d.w.withTimeout(ctx)
+	defer cancel()
+	start := time.Now()
+	res, err := d.DBTX.ExecContext(qctx, query, args...)
+	d.w.observe(ctx, qctx, query, start, err)
+	return res, err
+}
+
+// PrepareContext is not timed: preparing is cheap, and a statement prepared
+// once outlives any one query's deadline.
+func (d watched) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.DBTX.PrepareContext(ctx, query)
+}
+
+func (d watched) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	// The caller reads the rows after this returns, so the timeout context
+	// must outlive the call. It is released when the rows close (see
+	// driver.go).
+	qctx, cancel := d.w.withTimeout(ctx)
+	start := time.Now()
+	rows, err := d.DBTX.QueryContext(qctx, query, args...)
+	d.w.observe(ctx, qctx, query, start, err)
+	if err != nil {
+		cancel()
+	}
+	return rows, err
+}
+
+func (d watched) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	// As in QueryContext, the row is scanned after this returns; Scan
+	// closes its rows, which releases the timeout.
+	qctx, cancel := d.w.withTimeout(ctx)
+	start := time.Now()
+	row := d.DBTX.QueryRowContext(qctx, query, args...)
+	d.w.observe(ctx, qctx, query, start, row.Err())
+	if row.Err() != nil {
+		cancel()
+	}
+	return row
+}
+
+// withTimeout returns ctx bounded by the configured timeout, carrying its
+// cancel func so a Connector's rows can release it early.
+func (w *Watcher) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w.cfg.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	qctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	return withRelease(qctx, cancel), cancel
+}
+
+// observe counts a statement started at start on qctx, derived from the
+// caller's ctx, that returned err, and logs it when slow or cut off.
+func (w *Watcher) observe(ctx, qctx context.Context, query string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	name := StatementName(query)
+
+	var timedOut, canceled bool
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		switch {
+		case ctx.Err() != nil:
+			canceled = true
+		case errors.Is(qctx.Err(), context.DeadlineExceeded):
+			timedOut = true
+		}
+	}
+	slow := w.cfg.SlowThreshold > 0 && elapsed > w.cfg.SlowThreshold
+
+	w.mu.Lock()
+	s, ok := w.stats[name]
+	if !ok {
+		s = &Stats{Name: name}
+		w.stats[name] = s
+	}
+	s.Calls++
+	s.Total += elapsed
+	s.Max = max(s.Max, elapsed)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Errors++
+	}
+	if timedOut {
+		s.Timeouts++
+	}
+	if canceled {
+		s.Canceled++
+	}
+	if slow {
+		s.Slow++
+	}
+	w.mu.Unlock()
+
+	switch {
+	case timedOut:
+		w.logger.WarnContext(ctx, "db: query timed out", "statement", name, "timeout", w.cfg.Timeout)
+	case slow:
+		w.logger.WarnContext(ctx, "db: slow query", "statement", name, "duration_ms", elapsed.Milliseconds())
+	}
+}
+
+// StatementName returns the name in query's sqlc header, or Unnamed.
+func StatementName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return Unnamed
+	}
+	if i := strings.IndexAny(rest, " \n"); i >= 0 {
+		rest = rest[:i]
+	}
+	if rest == "" {
+		return Unnamed
+	}
+	return rest
+}
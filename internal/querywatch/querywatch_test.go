@@ -0,0 +1,172 @@
+package querywatch
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// fakeDBTX runs each Exec for delay, or until its context ends.
+type fakeDBTX struct {
+	delay time.Duration
+}
+
+func (f fakeDBTX) ExecContext(ctx context.Context, _ string, _ ...interface{}) (sql.Result, error) {
+	select {
+	case <-time.After(f.delay):
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (fakeDBTX) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (fakeDBTX) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (fakeDBTX) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestStatementName(t *testing.T) {
+	cases := map[string]string{
+		"-- name: GetReportByID :one\nSELECT 1":           "GetReportByID",
+		"-- name: ListStripeEvents :many\nSELECT 1":       "ListStripeEvents",
+		"SELECT set_config('application_name', $1, true)": Unnamed,
+		"": Unnamed,
+	}
+	for query, want := range cases {
+		if got := StatementName(query); got != want {
+			t.Errorf("StatementName(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestWatcher_TimesOutAndCountsSlowStatements(t *testing.T) {
+	w := New(Config{Timeout: 20 * time.Millisecond, SlowThreshold: 5 * time.Millisecond},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	if _, err := w.Wrap(fakeDBTX{delay: time.Second}).ExecContext(ctx, "-- name: Stuck :exec\nSELECT pg_sleep(1)"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stuck statement: err = %v, want deadline exceeded", err)
+	}
+	if _, err := w.Wrap(fakeDBTX{delay: 10 * time.Millisecond}).ExecContext(ctx, "-- name: Slow :exec\nSELECT 1"); err != nil {
+		t.Fatalf("slow statement: %v", err)
+	}
+	for range 3 {
+		if _, err := w.Wrap(fakeDBTX{}).ExecContext(ctx, "-- name: Fast :exec\nSELECT 1"); err != nil {
+			t.Fatalf("fast statement: %v", err)
+		}
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := w.Wrap(fakeDBTX{delay: time.Second}).ExecContext(canceled, "-- name: Fast :exec\nSELECT 1"); err == nil {
+		t.Fatal("canceled statement succeeded")
+	}
+
+	got := map[string]Stats{}
+	for _, s := range w.Snapshot() {
+		got[s.Name] = s
+	}
+	if s := got["Stuck"]; s.Calls != 1 || s.Timeouts != 1 || s.Errors != 1 || s.Canceled != 0 {
+		t.Errorf("Stuck = %+v, want 1 call, 1 timeout", s)
+	}
+	if s := got["Slow"]; s.Calls != 1 || s.Slow != 1 || s.Errors != 0 {
+		t.Errorf("Slow = %+v, want 1 slow call", s)
+	}
+	if s := got["Fast"]; s.Calls != 4 || s.Slow != 0 || s.Canceled != 1 || s.Timeouts != 0 {
+		t.Errorf("Fast = %+v, want 4 calls, 1 canceled", s)
+	}
+	if first := w.Snapshot()[0].Name; first != "Stuck" {
+		t.Errorf("first statement = %q, want the one with the most total time", first)
+	}
+}
+
+func TestWatcher_NilWrapsNothing(t *testing.T) {
+	var w *Watcher
+	h := fakeDBTX{}
+	if got := w.Wrap(h); got != h {
+		t.Errorf("nil Watcher wrapped the handle: %T", got)
+	}
+	if w.Snapshot() != nil {
+		t.Error("nil Watcher has stats")
+	}
+}
+
+// oneRowConnector opens connections whose queries return a single row and
+// remember the context they ran on.
+type oneRowConnector struct {
+	ctxs *[]context.Context
+}
+
+func (c oneRowConnector) Connect(context.Context) (driver.Conn, error) { return oneRowConn(c), nil }
+func (c oneRowConnector) Driver() driver.Driver                        { return nil }
+
+type oneRowConn struct {
+	ctxs *[]context.Context
+}
+
+func (oneRowConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not implemented") }
+func (oneRowConn) Close() error                        { return nil }
+func (oneRowConn) Begin() (driver.Tx, error)           { return nil, errors.New("not implemented") }
+
+func (c oneRowConn) QueryContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Rows, error) {
+	*c.ctxs = append(*c.ctxs, ctx)
+	return &oneRow{}, nil
+}
+
+type oneRow struct{ read bool }
+
+func (*oneRow) Columns() []string { return []string{"n"} }
+func (*oneRow) Close() error      { return nil }
+
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestConnector_ReleasesTheTimeoutWhenRowsClose(t *testing.T) {
+	var ctxs []context.Context
+	pool := sql.OpenDB(Connector(oneRowConnector{ctxs: &ctxs}))
+	defer pool.Close()
+	w := New(Config{Timeout: time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h := w.Wrap(pool)
+	ctx := context.Background()
+
+	rows, err := h.QueryContext(ctx, "-- name: ListN :many\nSELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctxs[0].Err() != nil {
+		t.Fatal("query context done before its rows were read")
+	}
+	for rows.Next() {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ctxs[0].Err() == nil {
+		t.Error("query context still live after its rows were closed")
+	}
+
+	var n int
+	if err := h.QueryRowContext(ctx, "-- name: GetN :one\nSELECT 1").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("Scan = %d, %v", n, err)
+	}
+	if ctxs[1].Err() == nil {
+		t.Error("query row context still live after Scan")
+	}
+}
@@ -0,0 +1,32 @@
+// Package redact removes personal data and credentials from free text before
+// it leaves the process — in log lines (see logging.Options.Redact) and in
+// error reports (see errorreport).
+//
+// It works on the shape of the value rather than its name, so a token
+// interpolated into an error message is caught as well as one logged under
+// its own key.
+package redact
+
+import "regexp"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// tokenPattern matches long unbroken runs of token characters: anon tokens
+	// (64 hex), report access tokens (32 base64url), API keys.
+	tokenPattern = regexp.MustCompile(`[A-Za-z0-9_\-]{32,}`)
+	// uuidPattern exempts IDs such as session_id, which identify nothing
+	// outside this system and are needed to follow a log line or event up.
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// String replaces email addresses with "[email]" and token-like strings with
+// "[token]". UUIDs are kept.
+func String(s string) string {
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	return tokenPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if uuidPattern.MatchString(m) {
+			return m
+		}
+		return "[token]"
+	})
+}
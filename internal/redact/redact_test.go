@@ -0,0 +1,23 @@
+package redact_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/redact"
+)
+
+func TestString(t *testing.T) {
+	cases := map[string]string{
+		"email jane.doe+x@example.co.za failed":        "email [email] failed",
+		"token " + strings.Repeat("ab12", 16):          "token [token]",
+		"access Zm9vYmFyYmF6cXV4LV9fZm9vYmFyYmF6":      "access [token]",
+		"session 3f2b8c1e-4a5d-4e6f-9a7b-0c1d2e3f4a5b": "session 3f2b8c1e-4a5d-4e6f-9a7b-0c1d2e3f4a5b",
+		"plain message": "plain message",
+	}
+	for in, want := range cases {
+		if got := redact.String(in); got != want {
+			t.Errorf("String(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Package requestid carries the correlation ID of the operation in progress —
+// the HTTP request ID, or the trace_id of a worker run — on a context.Context,
+// so the store, Stripe, AI and email calls made on its behalf can be tied back
+// to it without matching timestamps across services.
+//
+// The api package attaches the chi request ID and the worker attaches its
+// trace_id; outbound clients read it with From and forward it with SetHeader.
+package requestid
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header is the header the ID is sent and echoed in.
+const Header = "X-Request-ID"
+
+type ctxKey struct{}
+
+// With returns a copy of ctx carrying id. An empty id leaves ctx unchanged.
+func With(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// From returns the ID attached to ctx, or "" when there is none.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// SetHeader sets Header on h when ctx carries an ID.
+func SetHeader(ctx context.Context, h http.Header) {
+	if id := From(ctx); id != "" {
+		h.Set(Header, id)
+	}
+}
@@ -0,0 +1,35 @@
+package requestid_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
+)
+
+func TestWithAndFrom(t *testing.T) {
+	ctx := context.Background()
+	if got := requestid.From(ctx); got != "" {
+		t.Errorf("expected no ID on a bare context, got %q", got)
+	}
+	if requestid.With(ctx, "") != ctx {
+		t.Error("expected an empty ID to leave the context unchanged")
+	}
+	if got := requestid.From(requestid.With(ctx, "req-1")); got != "req-1" {
+		t.Errorf("expected req-1, got %q", got)
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	h := http.Header{}
+	requestid.SetHeader(context.Background(), h)
+	if h.Get(requestid.Header) != "" {
+		t.Error("expected no header without an ID")
+	}
+
+	requestid.SetHeader(requestid.With(context.Background(), "req-1"), h)
+	if got := h.Get(requestid.Header); got != "req-1" {
+		t.Errorf("expected X-Request-ID req-1, got %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+// Package retention deletes data that has outlived its retention window, so
+// the database does not keep personal data indefinitely. Each data class has
+// its own window; a zero window keeps that class forever.
+//
+// An Enforcer runs on a schedule inside the API process (RunScheduled, as
+// the worker's cleanup_sessions job) and on demand from armctl. In dry-run
+// mode it only counts and logs what it would delete. Deletes are idempotent,
+// so replicas running it concurrently is harmless.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
+)
+
+// Class names a kind of data with its own retention window.
+type Class string
+
+const (
+	// ClassAnswers is the raw questionnaire answers of sessions untouched for
+	// the window. Generated reports keep their scored risks.
+	ClassAnswers Class = "answers"
+	// ClassStripeEvents is processed Stripe webhook payloads. Payment exports
+	// read refunds and disputes from them, so keep these at least as long as
+	// exports may be needed.
+	ClassStripeEvents Class = "stripe_events"
+	// ClassEmailLog is the record of sent emails, including recipients.
+	ClassEmailLog Class = "email_log"
+	// ClassAICache is stored AI output not reused within the window.
+	ClassAICache Class = "ai_cache"
+	// ClassAITranscripts is the stored AI prompts and responses, which quote
+	// the customer's answers. Objects in the bucket go with their rows.
+	ClassAITranscripts Class = "ai_transcripts"
+)
+
+// Config holds the windows and schedule. A zero window disables that class.
+type Config struct {
+	Answers       time.Duration
+	StripeEvents  time.Duration
+	EmailLog      time.Duration
+	AICache       time.Duration
+	AITranscripts time.Duration
+
+	// Storage holds transcripts kept in a bucket. May be nil.
+	Storage storage.Store
+
+	// Interval is how often the scheduled pass runs. Default: 24h.
+	Interval time.Duration
+	// BatchSize caps the rows deleted per statement. Default: 1000.
+	BatchSize int
+	// DryRun makes RunScheduled report instead of delete.
+	DryRun bool
+}
+
+// Result is what one pass found, and deleted, for one class.
+type Result struct {
+	Class   Class         `json:"class"`
+	Window  time.Duration `json:"window"`
+	Cutoff  time.Time     `json:"cutoff"`
+	Expired int64         `json:"expired"` // rows past the window when the pass started
+	Deleted int64         `json:"deleted"` // zero in a dry run
+}
+
+// Store is the subset of db.Querier the enforcer uses.
+type Store interface {
+	CountExpiredAnswers(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteExpiredAnswers(ctx context.Context, arg db.DeleteExpiredAnswersParams) (int64, error)
+	CountExpiredStripeEvents(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteExpiredStripeEvents(ctx context.Context, arg db.DeleteExpiredStripeEventsParams) (int64, error)
+	CountExpiredEmailLog(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteExpiredEmailLog(ctx context.Context, arg db.DeleteExpiredEmailLogParams) (int64, error)
+	CountExpiredAICache(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteExpiredAICache(ctx context.Context, arg db.DeleteExpiredAICacheParams) (int64, error)
+	CountExpiredAITranscripts(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteExpiredAITranscripts(ctx context.Context, arg db.DeleteExpiredAITranscriptsParams) ([]sql.NullString, error)
+}
+
+// Enforcer applies a Config.
+type Enforcer struct {
+	q      Store
+	cfg    Config
+	logger *slog.Logger
+}
+
+// NewEnforcer returns an Enforcer. Schedule RunScheduled on Interval to run
+// it.
+func NewEnforcer(q Store, cfg Config, logger *slog.Logger) *Enforcer {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	return &Enforcer{q: q, cfg: cfg, logger: logger}
+}
+
+// Enabled reports whether any class has a window.
+func (e *Enforcer) Enabled() bool {
+	return len(e.classes()) > 0
+}
+
+// Interval is how often the scheduled pass runs.
+func (e *Enforcer) Interval() time.Duration {
+	return e.cfg.Interval
+}
+
+// class binds a Class to its window and queries.
+type class struct {
+	name   Class
+	window time.Duration
+	count  func(ctx context.Context, cutoff time.Time) (int64, error)
+	delete func(ctx context.Context, cutoff time.Time, batch int32) (int64, error)
+}
+
+func (e *Enforcer) classes() []class {
+	all := []class{
+		{ClassAnswers, e.cfg.Answers, e.q.CountExpiredAnswers,
+			func(ctx context.Context, cutoff time.Time, batch int32) (int64, error) {
+				return e.q.DeleteExpiredAnswers(ctx, db.DeleteExpiredAnswersParams{Cutoff: cutoff, BatchSize: batch})
+			}},
+		{ClassStripeEvents, e.cfg.StripeEvents, e.q.CountExpiredStripeEvents,
+			func(ctx context.Context, cutoff time.Time, batch int32) (int64, error) {
+				return e.q.DeleteExpiredStripeEvents(ctx, db.DeleteExpiredStripeEventsParams{Cutoff: cutoff, BatchSize: batch})
+			}},
+		{ClassEmailLog, e.cfg.EmailLog, e.q.CountExpiredEmailLog,
+			func(ctx context.Context, cutoff time.Time, batch int32) (int64, error) {
+				return e.q.DeleteExpiredEmailLog(ctx, db.DeleteExpiredEmailLogParams{Cutoff: cutoff, BatchSize: batch})
+			}},
+		{ClassAICache, e.cfg.AICache, e.q.CountExpiredAICache,
+			func(ctx context.Context, cutoff time.Time, batch int32) (int64, error) {
+				return e.q.DeleteExpiredAICache(ctx, db.DeleteExpiredAICacheParams{Cutoff: cutoff, BatchSize: batch})
+			}},
+		{ClassAITranscripts, e.cfg.AITranscripts, e.q.CountExpiredAITranscripts, e.deleteAITranscripts},
+	}
+	enabled := all[:0]
+	for _, c := range all {
+		if c.window > 0 {
+			enabled = append(enabled, c)
+		}
+	}
+	return enabled
+}
+
+// deleteAITranscripts deletes a batch of transcript rows, then their objects.
+// A row is gone even if its object cannot be deleted; that is logged, and
+// the bucket's own lifecycle rules are the backstop.
+func (e *Enforcer) deleteAITranscripts(ctx context.Context, cutoff time.Time, batch int32) (int64, error) {
+	keys, err := e.q.DeleteExpiredAITranscripts(ctx, db.DeleteExpiredAITranscriptsParams{Cutoff: cutoff, BatchSize: batch})
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if !key.Valid {
+			continue
+		}
+		if e.cfg.Storage == nil {
+			e.logger.Warn("retention: transcript object left in bucket, storage not configured", "key", key.String)
+			continue
+		}
+		if err := e.cfg.Storage.Delete(ctx, key.String); err != nil {
+			e.logger.Warn("retention: could not delete transcript object", "key", key.String, "error", err)
+		}
+	}
+	return int64(len(keys)), nil
+}
+
+// Run makes one pass over every enabled class. With dryRun it only counts.
+// A failing class is reported in the returned error after the others have
+// run, alongside the results gathered so far.
+func (e *Enforcer) Run(ctx context.Context, dryRun bool) ([]Result, error) {
+	now := time.Now()
+	var results []Result
+	var firstErr error
+	for _, c := range e.classes() {
+		res := Result{Class: c.name, Window: c.window, Cutoff: now.Add(-c.window)}
+		err := e.runClass(ctx, c, &res, dryRun)
+		results = append(results, res)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("retention: %s: %w", c.name, err)
+		}
+	}
+	return results, firstErr
+}
+
+func (e *Enforcer) runClass(ctx context.Context, c class, res *Result, dryRun bool) error {
+	expired, err := c.count(ctx, res.Cutoff)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+	res.Expired = expired
+	if dryRun || expired == 0 {
+		return nil
+	}
+
+	// Short statements, so a large first run does not hold locks for long.
+	for {
+		n, err := c.delete(ctx, res.Cutoff, int32(e.cfg.BatchSize))
+		res.Deleted += n
+		if err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		if n < int64(e.cfg.BatchSize) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// RunScheduled makes the scheduled pass, in dry-run mode when the Config
+// says so, and logs each class's outcome. It does nothing when no class is
+// enabled.
+func (e *Enforcer) RunScheduled(ctx context.Context) error {
+	if !e.Enabled() {
+		return nil
+	}
+	results, err := e.Run(ctx, e.cfg.DryRun)
+	for _, r := range results {
+		e.logger.Info("retention: pass complete",
+			"class", r.Class,
+			"window", r.Window,
+			"cutoff", r.Cutoff.UTC().Format(time.RFC3339),
+			"expired", r.Expired,
+			"deleted", r.Deleted,
+			"dry_run", e.cfg.DryRun,
+			"audit", !e.cfg.DryRun && r.Deleted > 0,
+		)
+	}
+	return err
+}
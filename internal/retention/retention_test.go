@@ -0,0 +1,210 @@
+package retention_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/retention"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
+)
+
+// stubStore holds a number of expired rows per class and deletes them in
+// batches, recording each cutoff it was given.
+type stubStore struct {
+	expired map[retention.Class]int64
+	cutoffs map[retention.Class]time.Time
+	deletes int
+	err     error // returned by DeleteExpiredEmailLog
+
+	transcriptKeys []sql.NullString // storage keys of expired transcripts
+}
+
+func newStub(expired map[retention.Class]int64) *stubStore {
+	return &stubStore{expired: expired, cutoffs: map[retention.Class]time.Time{}}
+}
+
+func (s *stubStore) count(c retention.Class, cutoff time.Time) (int64, error) {
+	s.cutoffs[c] = cutoff
+	return s.expired[c], nil
+}
+
+func (s *stubStore) delete(c retention.Class, batch int32) (int64, error) {
+	s.deletes++
+	n := min(s.expired[c], int64(batch))
+	s.expired[c] -= n
+	return n, nil
+}
+
+func (s *stubStore) CountExpiredAnswers(_ context.Context, cutoff time.Time) (int64, error) {
+	return s.count(retention.ClassAnswers, cutoff)
+}
+
+func (s *stubStore) DeleteExpiredAnswers(_ context.Context, arg db.DeleteExpiredAnswersParams) (int64, error) {
+	return s.delete(retention.ClassAnswers, arg.BatchSize)
+}
+
+func (s *stubStore) CountExpiredStripeEvents(_ context.Context, cutoff time.Time) (int64, error) {
+	return s.count(retention.ClassStripeEvents, cutoff)
+}
+
+func (s *stubStore) DeleteExpiredStripeEvents(_ context.Context, arg db.DeleteExpiredStripeEventsParams) (int64, error) {
+	return s.delete(retention.ClassStripeEvents, arg.BatchSize)
+}
+
+func (s *stubStore) CountExpiredEmailLog(_ context.Context, cutoff time.Time) (int64, error) {
+	return s.count(retention.ClassEmailLog, cutoff)
+}
+
+func (s *stubStore) DeleteExpiredEmailLog(_ context.Context, arg db.DeleteExpiredEmailLogParams) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.delete(retention.ClassEmailLog, arg.BatchSize)
+}
+
+func (s *stubStore) CountExpiredAICache(_ context.Context, cutoff time.Time) (int64, error) {
+	return s.count(retention.ClassAICache, cutoff)
+}
+
+func (s *stubStore) DeleteExpiredAICache(_ context.Context, arg db.DeleteExpiredAICacheParams) (int64, error) {
+	return s.delete(retention.ClassAICache, arg.BatchSize)
+}
+
+func (s *stubStore) CountExpiredAITranscripts(_ context.Context, cutoff time.Time) (int64, error) {
+	s.cutoffs[retention.ClassAITranscripts] = cutoff
+	return int64(len(s.transcriptKeys)), nil
+}
+
+func (s *stubStore) DeleteExpiredAITranscripts(_ context.Context, arg db.DeleteExpiredAITranscriptsParams) ([]sql.NullString, error) {
+	s.deletes++
+	n := min(len(s.transcriptKeys), int(arg.BatchSize))
+	keys := s.transcriptKeys[:n]
+	s.transcriptKeys = s.transcriptKeys[n:]
+	return keys, nil
+}
+
+// stubBucket records the keys deleted from it.
+type stubBucket struct {
+	storage.Store // embedded to panic on unused methods
+	deleted       []string
+}
+
+func (b *stubBucket) Delete(_ context.Context, key string) error {
+	b.deleted = append(b.deleted, key)
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestRun_DeletesInBatchesOnlyForConfiguredClasses(t *testing.T) {
+	store := newStub(map[retention.Class]int64{
+		retention.ClassAnswers:      25,
+		retention.ClassStripeEvents: 7,
+		retention.ClassAICache:      3,
+	})
+	e := retention.NewEnforcer(store, retention.Config{
+		Answers:      90 * 24 * time.Hour,
+		StripeEvents: 365 * 24 * time.Hour,
+		BatchSize:    10,
+	}, discardLogger())
+
+	before := time.Now()
+	results, err := e.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected results for 2 configured classes, got %+v", results)
+	}
+	answers, events := results[0], results[1]
+	if answers.Class != retention.ClassAnswers || answers.Expired != 25 || answers.Deleted != 25 {
+		t.Errorf("unexpected answers result: %+v", answers)
+	}
+	if events.Class != retention.ClassStripeEvents || events.Expired != 7 || events.Deleted != 7 {
+		t.Errorf("unexpected stripe events result: %+v", events)
+	}
+	// 10 + 10 + 5 for answers, then one short batch for events.
+	if store.deletes != 4 {
+		t.Errorf("expected 4 delete statements, got %d", store.deletes)
+	}
+	if store.expired[retention.ClassAICache] != 3 {
+		t.Error("AI cache has no window and must be kept")
+	}
+	if want := before.Add(-90 * 24 * time.Hour); store.cutoffs[retention.ClassAnswers].Before(want) {
+		t.Errorf("answers cutoff %v is earlier than now minus the window", store.cutoffs[retention.ClassAnswers])
+	}
+}
+
+func TestRun_DryRunOnlyCounts(t *testing.T) {
+	store := newStub(map[retention.Class]int64{retention.ClassEmailLog: 12})
+	e := retention.NewEnforcer(store, retention.Config{EmailLog: time.Hour}, discardLogger())
+
+	results, err := e.Run(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 1 || results[0].Expired != 12 || results[0].Deleted != 0 {
+		t.Errorf("expected 12 expired and none deleted, got %+v", results)
+	}
+	if store.deletes != 0 || store.expired[retention.ClassEmailLog] != 12 {
+		t.Error("dry run must not delete")
+	}
+}
+
+func TestRun_FailingClassDoesNotStopTheOthers(t *testing.T) {
+	store := newStub(map[retention.Class]int64{retention.ClassEmailLog: 1, retention.ClassAICache: 4})
+	store.err = errors.New("connection reset")
+	e := retention.NewEnforcer(store, retention.Config{EmailLog: time.Hour, AICache: time.Hour}, discardLogger())
+
+	results, err := e.Run(context.Background(), false)
+	if err == nil || !errors.Is(err, store.err) {
+		t.Fatalf("expected the email log error, got %v", err)
+	}
+	if len(results) != 2 || results[1].Class != retention.ClassAICache || results[1].Deleted != 4 {
+		t.Errorf("expected the AI cache to be cleaned regardless, got %+v", results)
+	}
+}
+
+func TestRun_DeletesTranscriptObjectsWithTheirRows(t *testing.T) {
+	store := newStub(nil)
+	store.transcriptKeys = []sql.NullString{
+		{String: "transcripts/a.json", Valid: true},
+		{}, // kept inline in Postgres
+		{String: "transcripts/b.json", Valid: true},
+	}
+	bucket := &stubBucket{}
+	e := retention.NewEnforcer(store, retention.Config{
+		AITranscripts: time.Hour,
+		Storage:       bucket,
+		BatchSize:     2,
+	}, discardLogger())
+
+	results, err := e.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 1 || results[0].Expired != 3 || results[0].Deleted != 3 {
+		t.Errorf("expected 3 transcripts deleted, got %+v", results)
+	}
+	if want := []string{"transcripts/a.json", "transcripts/b.json"}; !slices.Equal(bucket.deleted, want) {
+		t.Errorf("expected objects %v deleted, got %v", want, bucket.deleted)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if retention.NewEnforcer(newStub(nil), retention.Config{}, discardLogger()).Enabled() {
+		t.Error("no windows configured should be disabled")
+	}
+	if !retention.NewEnforcer(newStub(nil), retention.Config{AICache: time.Hour}, discardLogger()).Enabled() {
+		t.Error("one window configured should be enabled")
+	}
+}
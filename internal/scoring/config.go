@@ -6,6 +6,8 @@ package scoring
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // configType is the discriminator field inside every scoring_config JSONB blob.
@@ -40,6 +42,18 @@ type RadioConfig struct {
 	IScores []int      `json:"i_scores"`
 }
 
+// HasOption reports whether answer, trimmed, is exactly one of Opts — the
+// same match ScoreAnswer uses.
+func (c RadioConfig) HasOption(answer string) bool {
+	answer = strings.TrimSpace(answer)
+	for _, opt := range c.Opts {
+		if opt == answer {
+			return true
+		}
+	}
+	return false
+}
+
 // Validate checks that the slices have consistent lengths and every score is
 // in [1, 10]. Call this once at seed/startup time, not on every request.
 func (c RadioConfig) Validate() error {
@@ -168,4 +182,29 @@ func (sc *ScoringConfig) IsText() bool { return sc.text != nil }
 func (sc *ScoringConfig) Radio() RadioConfig { return *sc.radio }
 
 // Text returns the underlying TextConfig. Panics if IsText() is false.
-func (sc *ScoringConfig) Text() TextConfig { return *sc.text }
\ No newline at end of file
+func (sc *ScoringConfig) Text() TextConfig { return *sc.text }
+
+// ConfigError names the question whose scoring config is invalid.
+type ConfigError struct {
+	QuestionID string
+	Err        error
+}
+
+func (e *ConfigError) Error() string { return fmt.Sprintf("question %s: %v", e.QuestionID, e.Err) }
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// ValidateConfigs runs ParseScoringConfig over every config, keyed by question
+// ID, and returns one *ConfigError per invalid config, ordered by question ID.
+// Run it at startup: ComputeRisks fails the whole report on the first invalid
+// config, which otherwise surfaces only after a customer has paid.
+func ValidateConfigs(configs map[string]json.RawMessage) []*ConfigError {
+	var errs []*ConfigError
+	for id, raw := range configs {
+		if _, err := ParseScoringConfig(raw); err != nil {
+			errs = append(errs, &ConfigError{QuestionID: id, Err: err})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].QuestionID < errs[j].QuestionID })
+	return errs
+}
@@ -0,0 +1,124 @@
+package scoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ─── SCORE EXPLANATIONS ───────────────────────────────────────────────────────
+//
+// An Explanation records how a risk got its scores: the answer as scoring saw
+// it, the question's configured P/I mapping, the tier rule that classified
+// the result and the risk's weight in the overall score. It is captured when
+// the report is scored, so it stays true after the question's scoring config
+// is edited.
+
+// Explanation is why a risk scored and ranked as it did.
+type Explanation struct {
+	Radio *RadioExplanation `json:"radio,omitempty"`
+	Text  *TextExplanation  `json:"text,omitempty"`
+	P     int               `json:"probability"`
+	I     int               `json:"impact"`
+	Tier  RiskTier          `json:"tier"`
+	// TierRule states the thresholds GetTier compared P and I against.
+	TierRule string `json:"tier_rule"`
+	// ScoreProfile and Weight are set when the report is persisted: the
+	// profile the overall score was computed under and this risk's weight
+	// in its mean (see Profile.Weight).
+	ScoreProfile string `json:"score_profile,omitempty"`
+	Weight       int    `json:"weight,omitempty"`
+}
+
+// RadioExplanation is the scoring of a radio answer.
+type RadioExplanation struct {
+	Selected string `json:"selected"` // the answer, trimmed
+	// Matched is false when Selected is not one of Options, which scores
+	// the minimum (1,1).
+	Matched bool          `json:"matched"`
+	Options []OptionScore `json:"options"`
+}
+
+// OptionScore is one radio option and the scores it maps to.
+type OptionScore struct {
+	Label string `json:"label"`
+	P     int    `json:"probability"`
+	I     int    `json:"impact"`
+}
+
+// TextExplanation is the scoring of a text answer. The answer itself is left
+// out; only its length matters.
+type TextExplanation struct {
+	Length    int  `json:"length"` // trimmed, in bytes as ScoreAnswer counts
+	Threshold int  `json:"threshold"`
+	Long      bool `json:"long"` // Length > Threshold: the long scores apply
+	PShort    int  `json:"p_short"`
+	PLong     int  `json:"p_long"`
+	IShort    int  `json:"i_short"`
+	ILong     int  `json:"i_long"`
+}
+
+// ExplainAnswer scores answer under rawConfig as ScoreAnswer does and says
+// how. Returns an error only if rawConfig cannot be parsed.
+func ExplainAnswer(rawConfig json.RawMessage, answer string) (Explanation, error) {
+	cfg, err := ParseScoringConfig(rawConfig)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	answer = strings.TrimSpace(answer)
+
+	var ex Explanation
+	switch {
+	case cfg.IsRadio():
+		rc := cfg.Radio()
+		re := &RadioExplanation{Selected: answer, Options: make([]OptionScore, len(rc.Opts))}
+		ex.P, ex.I = 1, 1
+		for idx, opt := range rc.Opts {
+			re.Options[idx] = OptionScore{Label: opt, P: rc.PScores[idx], I: rc.IScores[idx]}
+			if opt == answer && !re.Matched {
+				re.Matched = true
+				ex.P, ex.I = clamp(rc.PScores[idx]), clamp(rc.IScores[idx])
+			}
+		}
+		ex.Radio = re
+
+	case cfg.IsText():
+		tc := cfg.Text()
+		te := &TextExplanation{
+			Length:    len(answer),
+			Threshold: tc.Threshold,
+			Long:      len(answer) > tc.Threshold,
+			PShort:    tc.PShort,
+			PLong:     tc.PLong,
+			IShort:    tc.IShort,
+			ILong:     tc.ILong,
+		}
+		if te.Long {
+			ex.P, ex.I = clamp(tc.PLong), clamp(tc.ILong)
+		} else {
+			ex.P, ex.I = clamp(tc.PShort), clamp(tc.IShort)
+		}
+		ex.Text = te
+
+	default:
+		ex.P, ex.I = 1, 1
+	}
+
+	ex.Tier = GetTier(ex.P, ex.I)
+	ex.TierRule = TierRule(ex.P, ex.I)
+	return ex, nil
+}
+
+// TierRule describes GetTier's decision for (p, i), e.g.
+// "probability 7 >= 6 and impact 4 < 7: manage".
+func TierRule(p, i int) string {
+	cmp := func(v, threshold int) string {
+		if v >= threshold {
+			return fmt.Sprintf("%d >= %d", v, threshold)
+		}
+		return fmt.Sprintf("%d < %d", v, threshold)
+	}
+	return fmt.Sprintf("probability %s and impact %s: %s",
+		cmp(p, highProbThreshold), cmp(i, highImpactThreshold), GetTier(p, i))
+}
@@ -0,0 +1,53 @@
+package scoring
+
+// ─── DISPLAY METADATA ─────────────────────────────────────────────────────────
+//
+// Labels, descriptions and ranges for the tiers and bands, served to the
+// frontend and PDF renderer so neither keeps its own copy of the thresholds.
+
+// Overall and per-risk scores run from 0 (no scored risks) or 1 up to 100.
+const (
+	MinOverallScore = 0
+	MaxRiskScore    = MaxScore * MaxScore
+)
+
+// TierInfo describes a tier and the region of the probability × impact grid
+// it covers. Ranges are inclusive.
+type TierInfo struct {
+	Tier        RiskTier
+	Label       string
+	Description string
+	MinP, MaxP  int
+	MinI, MaxI  int
+}
+
+// Tiers returns the four tiers, most urgent first.
+func Tiers() []TierInfo {
+	lowP, highP := [2]int{MinScore, highProbThreshold - 1}, [2]int{highProbThreshold, MaxScore}
+	lowI, highI := [2]int{MinScore, highImpactThreshold - 1}, [2]int{highImpactThreshold, MaxScore}
+	tier := func(t RiskTier, label, desc string, p, i [2]int) TierInfo {
+		return TierInfo{Tier: t, Label: label, Description: desc, MinP: p[0], MaxP: p[1], MinI: i[0], MaxI: i[1]}
+	}
+	return []TierInfo{
+		tier(TierWatch, "Watch", "Likely and severe: already on fire, slowly. Act on these first.", highP, highI),
+		tier(TierRed, "Red", "Unlikely but existential if it happens. Hedge now, while it is cheap.", lowP, highI),
+		tier(TierManage, "Manage", "Likely but survivable. Handle operationally.", highP, lowI),
+		tier(TierIgnore, "Ignore", "Unlikely and survivable. Not worth attention yet.", lowP, lowI),
+	}
+}
+
+// BandInfo is the inclusive range of overall scores a ScoreBand covers.
+type BandInfo struct {
+	Band     ScoreBand
+	Min, Max int
+}
+
+// Bands returns the score bands, highest first.
+func Bands() []BandInfo {
+	return []BandInfo{
+		{Band: BandHigh, Min: 60, Max: MaxRiskScore},
+		{Band: BandElevated, Min: 40, Max: 59},
+		{Band: BandModerate, Min: 20, Max: 39},
+		{Band: BandLow, Min: MinOverallScore, Max: 19},
+	}
+}
@@ -0,0 +1,139 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ─── OVERALL SCORE PROFILES ───────────────────────────────────────────────────
+//
+// A plain mean lets one devastating risk be averaged away by many harmless
+// ones: a 90 among nineteen 5s reports as 9. The other modes keep the worst
+// risks visible in the overall score.
+
+// ScoreMode selects how a Profile aggregates risk scores.
+type ScoreMode string
+
+const (
+	ModeMean        ScoreMode = "mean"         // every risk counts equally
+	ModeWeighted    ScoreMode = "weighted"     // mean weighted by tier, watch heaviest
+	ModeTopN        ScoreMode = "top_n"        // mean of the N highest scores
+	ModeMaxDominant ScoreMode = "max_dominant" // highest score, raised by the mean of all
+)
+
+// DefaultTopN is the N for ModeTopN when the profile does not give one.
+const DefaultTopN = 5
+
+// tierWeights are ModeWeighted's weights.
+var tierWeights = map[RiskTier]int{TierWatch: 4, TierRed: 3, TierManage: 2, TierIgnore: 1}
+
+// Profile is an overall score strategy. The zero value is ModeMean.
+type Profile struct {
+	Mode ScoreMode
+	TopN int // ModeTopN only
+}
+
+// ParseProfile parses "mean", "weighted", "max_dominant", "top_n" or
+// "top_n:N".
+func ParseProfile(s string) (Profile, error) {
+	mode, n, hasN := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	p := Profile{Mode: ScoreMode(mode)}
+	switch p.Mode {
+	case ModeMean, ModeWeighted, ModeMaxDominant:
+		if hasN {
+			return Profile{}, fmt.Errorf("score profile %q takes no parameter", mode)
+		}
+	case ModeTopN:
+		p.TopN = DefaultTopN
+		if hasN {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 1 {
+				return Profile{}, fmt.Errorf("score profile %q: N must be a positive integer", s)
+			}
+			p.TopN = v
+		}
+	default:
+		return Profile{}, fmt.Errorf("unknown score profile %q (want mean, weighted, top_n[:N] or max_dominant)", s)
+	}
+	return p, nil
+}
+
+// String returns the form ParseProfile accepts.
+func (p Profile) String() string {
+	switch p.Mode {
+	case "":
+		return string(ModeMean)
+	case ModeTopN:
+		return fmt.Sprintf("%s:%d", ModeTopN, p.topN())
+	default:
+		return string(p.Mode)
+	}
+}
+
+// OverallScore computes the overall risk score (0–100) under p. Returns 0 for
+// an empty slice.
+func (p Profile) OverallScore(risks []ScoredRisk) int {
+	if len(risks) == 0 {
+		return 0
+	}
+	switch p.Mode {
+	case ModeWeighted:
+		total, weights := 0, 0
+		for _, r := range risks {
+			w := p.Weight(r.Tier)
+			total += r.Score * w
+			weights += w
+		}
+		return round(float64(total) / float64(weights))
+	case ModeTopN:
+		scores := make([]int, len(risks))
+		for i, r := range risks {
+			scores[i] = r.Score
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(scores)))
+		scores = scores[:min(p.topN(), len(scores))]
+		total := 0
+		for _, s := range scores {
+			total += s
+		}
+		return round(float64(total) / float64(len(scores)))
+	case ModeMaxDominant:
+		// The highest score sets the floor; the mean fills that share of
+		// the headroom above it, so the result never drops below the worst
+		// risk and climbs further the more the other risks score.
+		highest, total := 0, 0
+		for _, r := range risks {
+			highest = max(highest, r.Score)
+			total += r.Score
+		}
+		mean := float64(total) / float64(len(risks))
+		return round(float64(highest) + float64(MaxRiskScore-highest)*mean/MaxRiskScore)
+	default:
+		return OverallScore(risks)
+	}
+}
+
+// Weight is a risk of tier t's weight in the mean p takes: the tier weight
+// under ModeWeighted, otherwise 1. ModeTopN counts only the N highest scores
+// and ModeMaxDominant starts from the highest, each at weight 1.
+func (p Profile) Weight(t RiskTier) int {
+	if p.Mode == ModeWeighted {
+		if w := tierWeights[t]; w > 0 {
+			return w
+		}
+	}
+	return 1
+}
+
+func (p Profile) topN() int {
+	if p.TopN < 1 {
+		return DefaultTopN
+	}
+	return p.TopN
+}
+
+func round(v float64) int {
+	return int(v + 0.5)
+}
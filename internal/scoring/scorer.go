@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
-	"strings"
 )
 
 // ─── CONSTANTS ────────────────────────────────────────────────────────────────
@@ -15,6 +14,12 @@ const (
 	highProbThreshold   = 6 // p >= 6  → high probability
 )
 
+// MinScore and MaxScore bound every probability and impact score.
+const (
+	MinScore = 1
+	MaxScore = 10
+)
+
 // ─── TYPES ────────────────────────────────────────────────────────────────────
 
 // RiskTier is the four-bucket classification. String values deliberately match
@@ -44,6 +49,8 @@ type ScoredRisk struct {
 	I          int      // impact      1–10
 	Score      int      // P × I, max 100
 	Tier       RiskTier
+	// Explanation says how P, I and Tier were arrived at.
+	Explanation Explanation
 }
 
 // AnswerRow is the minimal slice of db.GetAnswersBySessionRow that the scoring
@@ -58,17 +65,18 @@ type AnswerRow struct {
 	Hedge         string
 	ScoringConfig json.RawMessage
 	IsScoring     bool
+	Skipped       bool // marked not applicable; excluded rather than scored (1,1)
 }
 
 // ─── CORE FUNCTIONS ───────────────────────────────────────────────────────────
 
 // clamp constrains a score value to [1, 10], matching risks.ts clamp().
 func clamp(v int) int {
-	if v < 1 {
-		return 1
+	if v < MinScore {
+		return MinScore
 	}
-	if v > 10 {
-		return 10
+	if v > MaxScore {
+		return MaxScore
 	}
 	return v
 }
@@ -85,37 +93,15 @@ func clamp(v int) int {
 //
 // Returns an error only if rawConfig cannot be parsed; a missing/empty answer
 // is NOT an error — it returns the minimum scores (1, 1).
+//
+// The scoring itself lives in ExplainAnswer, so an explanation can never
+// disagree with the scores.
 func ScoreAnswer(rawConfig json.RawMessage, answer string) (p, i int, err error) {
-	cfg, err := ParseScoringConfig(rawConfig)
+	ex, err := ExplainAnswer(rawConfig, answer)
 	if err != nil {
 		return 0, 0, fmt.Errorf("ScoreAnswer: %w", err)
 	}
-
-	answer = strings.TrimSpace(answer)
-
-	switch {
-	case cfg.IsRadio():
-		rc := cfg.Radio()
-		for idx, opt := range rc.Opts {
-			if opt == answer {
-				return clamp(rc.PScores[idx]), clamp(rc.IScores[idx]), nil
-			}
-		}
-		// Answer not found in options (empty / skipped optional question).
-		return 1, 1, nil
-
-	case cfg.IsText():
-		tc := cfg.Text()
-		if len(answer) > tc.Threshold {
-			return clamp(tc.PLong), clamp(tc.ILong), nil
-		}
-		return clamp(tc.PShort), clamp(tc.IShort), nil
-
-	default:
-		// ParseScoringConfig guarantees one of the two branches above, so this
-		// is unreachable — but the compiler needs it.
-		return 1, 1, nil
-	}
+	return ex.P, ex.I, nil
 }
 
 // GetTier classifies a (probability, impact) pair into one of the four
@@ -141,11 +127,21 @@ func GetTier(p, i int) RiskTier {
 	}
 }
 
+// Thresholds returns the lowest probability that counts as high probability
+// and the lowest impact that counts as high impact — the boundaries GetTier
+// draws between the four tiers.
+func Thresholds() (highProb, highImpact int) {
+	return highProbThreshold, highImpactThreshold
+}
+
 // ComputeRisks scores all answers for a session and returns a sorted,
 // ranked slice of ScoredRisk ready to be persisted.
 //
 // Rows where IsScoring=false (snapshot/context questions) are silently skipped,
-// matching the risks.ts filter `q.sectionId !== "snapshot"`.
+// matching the risks.ts filter `q.sectionId !== "snapshot"`. So are rows the
+// customer marked Skipped: a question that does not apply to the business
+// yields no risk, and so does not pull OverallScore towards the (1,1) a blank
+// answer scores. SkippedQuestions lists them for the report.
 //
 // The returned slice is sorted by Score descending (ties broken by QuestionID
 // for determinism). Rank is 1-indexed and set on each element.
@@ -157,27 +153,29 @@ func ComputeRisks(rows []AnswerRow) ([]ScoredRisk, error) {
 	risks := make([]ScoredRisk, 0, len(rows))
 
 	for _, row := range rows {
-		if !row.IsScoring {
+		if !row.IsScoring || row.Skipped {
 			continue
 		}
 
-		p, i, err := ScoreAnswer(row.ScoringConfig, row.AnswerText)
+		ex, err := ExplainAnswer(row.ScoringConfig, row.AnswerText)
 		if err != nil {
-			return nil, fmt.Errorf("question %q: %w", row.QuestionID, err)
+			return nil, fmt.Errorf("question %q: ScoreAnswer: %w", row.QuestionID, err)
 		}
 
+		p, i := ex.P, ex.I
 		score := p * i
 
 		risks = append(risks, ScoredRisk{
-			QuestionID: row.QuestionID,
-			RiskName:   row.RiskName,
-			RiskDesc:   row.RiskDesc,
-			Hedge:      row.Hedge,
-			Section:    row.SectionTitle,
-			P:          p,
-			I:          i,
-			Score:      score,
-			Tier:       GetTier(p, i),
+			QuestionID:  row.QuestionID,
+			RiskName:    row.RiskName,
+			RiskDesc:    row.RiskDesc,
+			Hedge:       row.Hedge,
+			Section:     row.SectionTitle,
+			P:           p,
+			I:           i,
+			Score:       score,
+			Tier:        ex.Tier,
+			Explanation: ex,
 		})
 	}
 
@@ -197,10 +195,24 @@ func ComputeRisks(rows []AnswerRow) ([]ScoredRisk, error) {
 	return risks, nil
 }
 
+// SkippedQuestions returns the IDs of the scoring questions ComputeRisks left
+// out because the customer marked them Skipped, sorted. Nil when none were.
+func SkippedQuestions(rows []AnswerRow) []string {
+	var out []string
+	for _, row := range rows {
+		if row.IsScoring && row.Skipped {
+			out = append(out, row.QuestionID)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
 // ─── AGGREGATE HELPERS ────────────────────────────────────────────────────────
 
 // OverallScore computes the overall risk score (0–100) as a rounded mean of
-// all individual scores. Returns 0 for an empty slice.
+// all individual scores. Returns 0 for an empty slice. This is the default
+// profile; see Profile for the alternatives.
 func OverallScore(risks []ScoredRisk) int {
 	if len(risks) == 0 {
 		return 0
@@ -212,6 +224,27 @@ func OverallScore(risks []ScoredRisk) int {
 	return int(float64(total)/float64(len(risks)) + 0.5)
 }
 
+// ScoreBand is a coarse label for an overall score, for showing before the
+// score itself is revealed.
+type ScoreBand string
+
+const (
+	BandLow      ScoreBand = "low"      // 0–19
+	BandModerate ScoreBand = "moderate" // 20–39
+	BandElevated ScoreBand = "elevated" // 40–59
+	BandHigh     ScoreBand = "high"     // 60–100
+)
+
+// Band classifies an OverallScore result.
+func Band(score int) ScoreBand {
+	for _, b := range Bands() {
+		if score >= b.Min {
+			return b.Band
+		}
+	}
+	return BandLow
+}
+
 // CriticalCount returns the number of risks in the Watch tier — those that are
 // both high-probability and high-impact. These are the ones flagged in the UI
 // with "⚠ N Critical Risks Detected".
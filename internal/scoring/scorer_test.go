@@ -254,6 +254,78 @@ func TestComputeRisks_SkipsNonScoringRows(t *testing.T) {
 	}
 }
 
+func TestComputeRisks_ExcludesSkippedRows(t *testing.T) {
+	rows := []scoring.AnswerRow{
+		{QuestionID: "q_na", IsScoring: true, Skipped: true, ScoringConfig: makeRadioCfg("opt", 9, 9)},
+		{QuestionID: "q_blank", IsScoring: true, ScoringConfig: makeRadioCfg("opt", 9, 9)},
+		{QuestionID: "q_score", AnswerText: "opt", IsScoring: true, ScoringConfig: makeRadioCfg("opt", 5, 5)},
+		{QuestionID: "q_context", IsScoring: false, Skipped: true, ScoringConfig: makeRadioCfg("opt", 9, 9)},
+	}
+
+	risks, err := scoring.ComputeRisks(rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The blank answer still scores (1,1); the skipped one is left out.
+	if len(risks) != 2 || risks[0].QuestionID != "q_score" || risks[1].QuestionID != "q_blank" {
+		t.Fatalf("expected q_score then q_blank, got %+v", risks)
+	}
+	if got := scoring.OverallScore(risks); got != 13 {
+		t.Errorf("OverallScore = %d, want 13", got)
+	}
+	if got := scoring.SkippedQuestions(rows); len(got) != 1 || got[0] != "q_na" {
+		t.Errorf("SkippedQuestions = %v, want [q_na]", got)
+	}
+}
+
+func TestExplainAnswer(t *testing.T) {
+	radio := json.RawMessage(`{"type":"radio","opts":["Low","High"],"p_scores":[2,8],"i_scores":[3,9]}`)
+	text := json.RawMessage(`{"type":"text","threshold":5,"p_short":2,"p_long":6,"i_short":2,"i_long":8}`)
+
+	ex, err := scoring.ExplainAnswer(radio, " High ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ex.Radio == nil || !ex.Radio.Matched || ex.Radio.Selected != "High" || len(ex.Radio.Options) != 2 {
+		t.Errorf("radio explanation: %+v", ex.Radio)
+	}
+	if ex.P != 8 || ex.I != 9 || ex.Tier != scoring.TierWatch || ex.TierRule != "probability 8 >= 6 and impact 9 >= 7: watch" {
+		t.Errorf("radio scores: %+v", ex)
+	}
+
+	ex, _ = scoring.ExplainAnswer(radio, "Unlisted")
+	if ex.Radio.Matched || ex.P != 1 || ex.I != 1 {
+		t.Errorf("unmatched radio answer should score (1,1) unmatched, got %+v", ex)
+	}
+
+	ex, _ = scoring.ExplainAnswer(text, "a long answer")
+	if ex.Text == nil || !ex.Text.Long || ex.Text.Length != 13 || ex.P != 6 || ex.I != 8 {
+		t.Errorf("text explanation: %+v %+v", ex, ex.Text)
+	}
+	if ex.TierRule != "probability 6 >= 6 and impact 8 >= 7: watch" {
+		t.Errorf("tier rule: %q", ex.TierRule)
+	}
+
+	// ComputeRisks carries the explanation through, agreeing with the scores.
+	risks, err := scoring.ComputeRisks([]scoring.AnswerRow{{QuestionID: "q", AnswerText: "Low", IsScoring: true, ScoringConfig: radio}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := risks[0]; r.Explanation.P != r.P || r.Explanation.I != r.I || r.Explanation.Tier != r.Tier {
+		t.Errorf("explanation disagrees with the risk: %+v", r)
+	}
+}
+
+func TestProfile_Weight(t *testing.T) {
+	weighted := scoring.Profile{Mode: scoring.ModeWeighted}
+	if got := weighted.Weight(scoring.TierWatch); got != 4 {
+		t.Errorf("weighted watch weight = %d, want 4", got)
+	}
+	if got := (scoring.Profile{}).Weight(scoring.TierWatch); got != 1 {
+		t.Errorf("mean weight = %d, want 1", got)
+	}
+}
+
 func TestComputeRisks_EmptyInput(t *testing.T) {
 	risks, err := scoring.ComputeRisks(nil)
 	if err != nil {
@@ -350,6 +422,85 @@ func TestOverallScore(t *testing.T) {
 	}
 }
 
+// ─── Band ─────────────────────────────────────────────────────────────────────
+
+func TestProfile_OverallScore(t *testing.T) {
+	// One devastating risk among many harmless ones.
+	risks := []scoring.ScoredRisk{{Score: 90, Tier: scoring.TierWatch}}
+	for i := 0; i < 9; i++ {
+		risks = append(risks, scoring.ScoredRisk{Score: 4, Tier: scoring.TierIgnore})
+	}
+	cases := map[string]int{
+		"mean":         13, // (90 + 36) / 10
+		"weighted":     30, // (360 + 36) / 13
+		"top_n:2":      47, // (90 + 4) / 2
+		"top_n":        21, // (90 + 16) / 5
+		"max_dominant": 91, // 90 + 10 × 12.6/100
+	}
+	for s, want := range cases {
+		p, err := scoring.ParseProfile(s)
+		if err != nil {
+			t.Fatalf("ParseProfile(%q): %v", s, err)
+		}
+		if got := p.OverallScore(risks); got != want {
+			t.Errorf("%s: got %d, want %d", s, got, want)
+		}
+		if got := p.OverallScore(nil); got != 0 {
+			t.Errorf("%s: expected 0 for no risks, got %d", s, got)
+		}
+	}
+	if got, want := (scoring.Profile{}).OverallScore(risks), scoring.OverallScore(risks); got != want {
+		t.Errorf("zero Profile: got %d, want the mean %d", got, want)
+	}
+}
+
+func TestParseProfile_RejectsUnknownModes(t *testing.T) {
+	for _, s := range []string{"", "median", "top_n:0", "top_n:x", "mean:3"} {
+		if _, err := scoring.ParseProfile(s); err == nil {
+			t.Errorf("ParseProfile(%q): expected an error", s)
+		}
+	}
+	if p, _ := scoring.ParseProfile(" Top_N:3 "); p.String() != "top_n:3" {
+		t.Errorf("String() = %q, want top_n:3", p.String())
+	}
+}
+
+func TestBand(t *testing.T) {
+	cases := map[int]scoring.ScoreBand{
+		0: scoring.BandLow, 19: scoring.BandLow,
+		20: scoring.BandModerate, 39: scoring.BandModerate,
+		40: scoring.BandElevated, 59: scoring.BandElevated,
+		60: scoring.BandHigh, 100: scoring.BandHigh,
+	}
+	for score, want := range cases {
+		if got := scoring.Band(score); got != want {
+			t.Errorf("Band(%d) = %q, want %q", score, got, want)
+		}
+	}
+}
+
+func TestTiers_MatchGetTier(t *testing.T) {
+	for _, info := range scoring.Tiers() {
+		for p := info.MinP; p <= info.MaxP; p++ {
+			for i := info.MinI; i <= info.MaxI; i++ {
+				if got := scoring.GetTier(p, i); got != info.Tier {
+					t.Errorf("GetTier(%d, %d) = %q, but Tiers places it in %q", p, i, got, info.Tier)
+				}
+			}
+		}
+	}
+}
+
+func TestBands_MatchBand(t *testing.T) {
+	for _, info := range scoring.Bands() {
+		for _, score := range []int{info.Min, info.Max} {
+			if got := scoring.Band(score); got != info.Band {
+				t.Errorf("Band(%d) = %q, but Bands places it in %q", score, got, info.Band)
+			}
+		}
+	}
+}
+
 // ─── CriticalCount ───────────────────────────────────────────────────────────
 
 func TestCriticalCount(t *testing.T) {
@@ -455,6 +606,15 @@ func TestParseScoringConfig_RadioValid(t *testing.T) {
 	}
 }
 
+func TestRadioConfig_HasOption(t *testing.T) {
+	rc := scoring.RadioConfig{Opts: []string{"Yes", "No"}}
+	for answer, want := range map[string]bool{"Yes": true, " No ": true, "yes": false, "": false, "Maybe": false} {
+		if got := rc.HasOption(answer); got != want {
+			t.Errorf("HasOption(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
+
 func TestParseScoringConfig_TextValid(t *testing.T) {
 	cfg, err := scoring.ParseScoringConfig(json.RawMessage(`{
 		"type":"text","threshold":10,"p_short":2,"p_long":6,"i_short":2,"i_long":8
@@ -469,4 +629,24 @@ func TestParseScoringConfig_TextValid(t *testing.T) {
 	if tc.Threshold != 10 {
 		t.Errorf("expected threshold 10, got %d", tc.Threshold)
 	}
-}
\ No newline at end of file
+}
+
+func TestValidateConfigs_ReportsEachInvalidConfigInOrder(t *testing.T) {
+	errs := scoring.ValidateConfigs(map[string]json.RawMessage{
+		"q_ok":      makeRadioCfg("A", 3, 3),
+		"q_range":   json.RawMessage(`{"type":"radio","opts":["A"],"p_scores":[11],"i_scores":[1]}`),
+		"q_unknown": json.RawMessage(`{"type":"slider"}`),
+		"q_empty":   nil,
+	})
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	for i, want := range []string{"q_empty", "q_range", "q_unknown"} {
+		if errs[i].QuestionID != want {
+			t.Errorf("errs[%d] = %s, want %s", i, errs[i].QuestionID, want)
+		}
+	}
+	if scoring.ValidateConfigs(map[string]json.RawMessage{"q_ok": makeRadioCfg("A", 3, 3)}) != nil {
+		t.Error("expected no errors for a valid config")
+	}
+}
@@ -0,0 +1,370 @@
+// Package seed keeps question_definitions in step with a source-controlled
+// JSON file, so a questionnaire change is reviewed like code instead of being
+// applied by hand-written SQL.
+//
+// The file mirrors the Question objects in the frontend's risks.ts:
+//
+//	{
+//	  "version": 3,
+//	  "questions": [
+//	    {
+//	      "id": "s2_supplier",
+//	      "section_id": "dependency",
+//	      "section_title": "Dependency risks",
+//	      "display_order": 1,
+//	      "text": "How many suppliers could you lose before you stop trading?",
+//	      "type": "radio",
+//	      "opts": ["None", "One", "Several"],
+//	      "risk_name": "Supplier concentration",
+//	      "risk_desc": "...",
+//	      "hedge": "...",
+//	      "scoring_config": {"type": "radio", "opts": ["None", "One", "Several"], "p_scores": [9, 5, 2], "i_scores": [9, 6, 3]}
+//	    }
+//	  ]
+//	}
+//
+// required and is_scoring default to true and question_version to 1. Parse
+// validates the file, Diff compares it with the rows in the database, and
+// Plan.Changes yields the rows to upsert. Questions in the database but not in
+// the file are reported, never deleted: answers reference them.
+package seed
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── FILE FORMAT ──────────────────────────────────────────────────────────────
+
+// File is a versioned questionnaire.
+type File struct {
+	// Version is bumped on every change to the file. It is informational: the
+	// diff, not the version, decides what is written.
+	Version   int        `json:"version"`
+	Questions []Question `json:"questions"`
+}
+
+// Question is one question_definitions row. Optional fields may be omitted.
+type Question struct {
+	ID              string          `json:"id"`
+	QuestionVersion int16           `json:"question_version,omitempty"`
+	SectionID       string          `json:"section_id"`
+	SectionTitle    string          `json:"section_title"`
+	DisplayOrder    int16           `json:"display_order"`
+	Text            string          `json:"text"`
+	Subtext         string          `json:"subtext,omitempty"`
+	Type            string          `json:"type"`
+	Opts            []string        `json:"opts,omitempty"`
+	Placeholder     string          `json:"placeholder,omitempty"`
+	Required        *bool           `json:"required,omitempty"`
+	RiskName        string          `json:"risk_name"`
+	RiskDesc        string          `json:"risk_desc"`
+	Hedge           string          `json:"hedge"`
+	ScoringConfig   json.RawMessage `json:"scoring_config"`
+	IsScoring       *bool           `json:"is_scoring,omitempty"`
+}
+
+// LoadFile reads and validates the seed file at path.
+func LoadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse decodes a seed file and validates every question, returning all
+// problems joined rather than stopping at the first.
+func Parse(r io.Reader) (*File, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var file File
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("seed: decode: %w", err)
+	}
+	if err := file.validate(); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (f *File) validate() error {
+	var errs []error
+	if len(f.Questions) == 0 {
+		errs = append(errs, errors.New("seed: no questions"))
+	}
+
+	ids := make(map[string]bool, len(f.Questions))
+	slots := make(map[string]string, len(f.Questions)) // section/order/version → id
+	for i, q := range f.Questions {
+		p := q.params()
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("seed: question %d (%q): %s", i, q.ID, fmt.Sprintf(format, args...)))
+		}
+
+		if q.ID == "" {
+			fail("id is required")
+		} else if ids[q.ID] {
+			fail("duplicate id")
+		}
+		ids[q.ID] = true
+
+		if !validSection(p.SectionID) {
+			fail("unknown section_id %q", q.SectionID)
+		}
+		if !validType(p.Type) {
+			fail("unknown type %q", q.Type)
+		}
+		for field, v := range map[string]string{"section_title": q.SectionTitle, "text": q.Text, "risk_name": q.RiskName, "risk_desc": q.RiskDesc, "hedge": q.Hedge} {
+			if v == "" {
+				fail("%s is required", field)
+			}
+		}
+
+		slot := fmt.Sprintf("%s/%d/v%d", p.SectionID, p.DisplayOrder, p.QuestionVersion)
+		if other, taken := slots[slot]; taken {
+			fail("display_order %d in %s is also used by %q", p.DisplayOrder, p.SectionID, other)
+		}
+		slots[slot] = q.ID
+
+		if len(q.ScoringConfig) == 0 {
+			fail("scoring_config is required")
+			continue
+		}
+		if !p.IsScoring {
+			continue
+		}
+		cfg, err := scoring.ParseScoringConfig(q.ScoringConfig)
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+		if p.Type != db.QuestionTypeText && cfg.IsRadio() && !slices.Equal(cfg.Radio().Opts, q.Opts) {
+			fail("scoring_config opts %q do not match opts %q", cfg.Radio().Opts, q.Opts)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validSection(s db.SectionID) bool {
+	switch s {
+	case db.SectionIDSnapshot, db.SectionIDDependency, db.SectionIDMarket,
+		db.SectionIDOperational, db.SectionIDLegal, db.SectionIDBlindspots:
+		return true
+	}
+	return false
+}
+
+func validType(t db.QuestionType) bool {
+	switch t {
+	case db.QuestionTypeRadio, db.QuestionTypeText, db.QuestionTypeSelect:
+		return true
+	}
+	return false
+}
+
+// params converts q to the row it describes, applying the defaults.
+func (q Question) params() db.UpsertQuestionDefinitionParams {
+	version := q.QuestionVersion
+	if version == 0 {
+		version = 1
+	}
+	return db.UpsertQuestionDefinitionParams{
+		ID:              q.ID,
+		QuestionVersion: version,
+		SectionID:       db.SectionID(q.SectionID),
+		SectionTitle:    q.SectionTitle,
+		DisplayOrder:    q.DisplayOrder,
+		Text:            q.Text,
+		Subtext:         sql.NullString{String: q.Subtext, Valid: q.Subtext != ""},
+		Type:            db.QuestionType(q.Type),
+		Opts:            q.Opts,
+		Placeholder:     sql.NullString{String: q.Placeholder, Valid: q.Placeholder != ""},
+		Required:        q.Required == nil || *q.Required,
+		RiskName:        q.RiskName,
+		RiskDesc:        q.RiskDesc,
+		Hedge:           q.Hedge,
+		ScoringConfig:   q.ScoringConfig,
+		IsScoring:       q.IsScoring == nil || *q.IsScoring,
+	}
+}
+
+// Export builds a seed file from the rows currently in the database, ordered
+// like the questionnaire. Use it once to bring an existing questionnaire under
+// source control.
+func Export(defs []db.QuestionDefinition, version int) *File {
+	file := &File{Version: version, Questions: make([]Question, len(defs))}
+	for i, d := range defs {
+		required, isScoring := d.Required, d.IsScoring
+		file.Questions[i] = Question{
+			ID:              d.ID,
+			QuestionVersion: d.QuestionVersion,
+			SectionID:       string(d.SectionID),
+			SectionTitle:    d.SectionTitle,
+			DisplayOrder:    d.DisplayOrder,
+			Text:            d.Text,
+			Subtext:         d.Subtext.String,
+			Type:            string(d.Type),
+			Opts:            d.Opts,
+			Placeholder:     d.Placeholder.String,
+			Required:        &required,
+			RiskName:        d.RiskName,
+			RiskDesc:        d.RiskDesc,
+			Hedge:           d.Hedge,
+			ScoringConfig:   d.ScoringConfig,
+			IsScoring:       &isScoring,
+		}
+	}
+	return file
+}
+
+// AnswerRows pairs answers (question ID → answer text) with the file's
+// questions the way the worker pairs stored answers with question_definitions,
+// so scoring.ComputeRisks can be run against a seed file without a database.
+// Questions without an answer are left out, as they are for a real session.
+func (f *File) AnswerRows(answers map[string]string) ([]scoring.AnswerRow, error) {
+	known := make(map[string]bool, len(f.Questions))
+	rows := make([]scoring.AnswerRow, 0, len(answers))
+	for _, q := range f.Questions {
+		known[q.ID] = true
+		answer, ok := answers[q.ID]
+		if !ok {
+			continue
+		}
+		p := q.params()
+		rows = append(rows, scoring.AnswerRow{
+			QuestionID:    p.ID,
+			AnswerText:    answer,
+			SectionTitle:  string(p.SectionID), // as the worker does
+			RiskName:      p.RiskName,
+			RiskDesc:      p.RiskDesc,
+			Hedge:         p.Hedge,
+			ScoringConfig: p.ScoringConfig,
+			IsScoring:     p.IsScoring,
+		})
+	}
+
+	var unknown []string
+	for id := range answers {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("seed: answers for unknown questions: %v", unknown)
+	}
+	return rows, nil
+}
+
+// ─── DIFF ─────────────────────────────────────────────────────────────────────
+
+// Update is a question whose row differs from the file.
+type Update struct {
+	Row    db.UpsertQuestionDefinitionParams
+	Fields []string // names of the changed columns
+}
+
+// Plan is the difference between a seed file and the database.
+type Plan struct {
+	Inserts   []db.UpsertQuestionDefinitionParams
+	Updates   []Update
+	Unchanged int
+	// Missing lists questions in the database that the file does not mention.
+	// They are left alone.
+	Missing []string
+}
+
+// Empty reports whether applying the plan would change nothing.
+func (p *Plan) Empty() bool { return len(p.Inserts) == 0 && len(p.Updates) == 0 }
+
+// Changes returns the rows to upsert: inserts then updates.
+func (p *Plan) Changes() []db.UpsertQuestionDefinitionParams {
+	rows := slices.Clone(p.Inserts)
+	for _, u := range p.Updates {
+		rows = append(rows, u.Row)
+	}
+	return rows
+}
+
+// Diff compares file with the existing rows.
+func Diff(file *File, existing []db.QuestionDefinition) *Plan {
+	byID := make(map[string]db.QuestionDefinition, len(existing))
+	for _, d := range existing {
+		byID[d.ID] = d
+	}
+
+	plan := &Plan{}
+	seen := make(map[string]bool, len(file.Questions))
+	for _, q := range file.Questions {
+		row := q.params()
+		seen[row.ID] = true
+		cur, ok := byID[row.ID]
+		if !ok {
+			plan.Inserts = append(plan.Inserts, row)
+			continue
+		}
+		if fields := changedFields(cur, row); len(fields) > 0 {
+			plan.Updates = append(plan.Updates, Update{Row: row, Fields: fields})
+		} else {
+			plan.Unchanged++
+		}
+	}
+	for id := range byID {
+		if !seen[id] {
+			plan.Missing = append(plan.Missing, id)
+		}
+	}
+	sort.Strings(plan.Missing)
+	return plan
+}
+
+func changedFields(cur db.QuestionDefinition, row db.UpsertQuestionDefinitionParams) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	check("question_version", cur.QuestionVersion != row.QuestionVersion)
+	check("section_id", cur.SectionID != row.SectionID)
+	check("section_title", cur.SectionTitle != row.SectionTitle)
+	check("display_order", cur.DisplayOrder != row.DisplayOrder)
+	check("text", cur.Text != row.Text)
+	check("subtext", cur.Subtext != row.Subtext)
+	check("type", cur.Type != row.Type)
+	check("opts", !slices.Equal(cur.Opts, row.Opts))
+	check("placeholder", cur.Placeholder != row.Placeholder)
+	check("required", cur.Required != row.Required)
+	check("risk_name", cur.RiskName != row.RiskName)
+	check("risk_desc", cur.RiskDesc != row.RiskDesc)
+	check("hedge", cur.Hedge != row.Hedge)
+	check("scoring_config", !sameJSON(cur.ScoringConfig, row.ScoringConfig))
+	check("is_scoring", cur.IsScoring != row.IsScoring)
+	return fields
+}
+
+// sameJSON compares two JSON documents by value, so key order and whitespace
+// — which JSONB does not preserve — are not reported as changes.
+func sameJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
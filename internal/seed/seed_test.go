@@ -0,0 +1,177 @@
+package seed_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/seed"
+)
+
+const validFile = `{
+  "version": 2,
+  "questions": [
+    {
+      "id": "q_runway",
+      "section_id": "dependency",
+      "section_title": "Dependency",
+      "display_order": 1,
+      "text": "How much cash runway do you have?",
+      "type": "radio",
+      "opts": ["< 3 months", "> 3 months"],
+      "risk_name": "Cash runway",
+      "risk_desc": "Running out of cash.",
+      "hedge": "Extend runway.",
+      "scoring_config": {"type": "radio", "opts": ["< 3 months", "> 3 months"], "p_scores": [9, 2], "i_scores": [9, 2]}
+    },
+    {
+      "id": "q_name",
+      "section_id": "snapshot",
+      "section_title": "Snapshot",
+      "display_order": 1,
+      "text": "Business name",
+      "type": "text",
+      "required": false,
+      "is_scoring": false,
+      "risk_name": "-",
+      "risk_desc": "-",
+      "hedge": "-",
+      "scoring_config": {}
+    }
+  ]
+}`
+
+func TestParse_AppliesDefaults(t *testing.T) {
+	file, err := seed.Parse(strings.NewReader(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if file.Version != 2 || len(file.Questions) != 2 {
+		t.Fatalf("unexpected file: %+v", file)
+	}
+
+	plan := seed.Diff(file, nil)
+	if len(plan.Inserts) != 2 {
+		t.Fatalf("expected 2 inserts, got %+v", plan)
+	}
+	runway, name := plan.Inserts[0], plan.Inserts[1]
+	if runway.QuestionVersion != 1 || !runway.Required || !runway.IsScoring {
+		t.Errorf("expected defaults on q_runway, got %+v", runway)
+	}
+	if name.Required || name.IsScoring {
+		t.Errorf("expected explicit false on q_name, got %+v", name)
+	}
+}
+
+func TestParse_ReportsEveryProblem(t *testing.T) {
+	bad := `{"version": 1, "questions": [
+	  {"id": "q_a", "section_id": "nowhere", "section_title": "X", "display_order": 1, "text": "?", "type": "radio",
+	   "opts": ["Yes", "No"], "risk_name": "r", "risk_desc": "d", "hedge": "h",
+	   "scoring_config": {"type": "radio", "opts": ["Yes", "Maybe"], "p_scores": [1, 2], "i_scores": [1, 2]}},
+	  {"id": "q_a", "section_id": "legal", "section_title": "X", "display_order": 1, "text": "?", "type": "text",
+	   "risk_name": "r", "risk_desc": "d", "hedge": "h",
+	   "scoring_config": {"type": "text", "threshold": 5, "p_short": 0, "p_long": 2, "i_short": 1, "i_long": 2}}
+	]}`
+	_, err := seed.Parse(strings.NewReader(bad))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`unknown section_id "nowhere"`, "do not match opts", "duplicate id", "p_short=0"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestParse_RejectsUnknownFields(t *testing.T) {
+	if _, err := seed.Parse(strings.NewReader(`{"version": 1, "questions": [], "extra": true}`)); err == nil {
+		t.Error("expected unknown field to be rejected")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	file, err := seed.Parse(strings.NewReader(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	existing := []db.QuestionDefinition{
+		{
+			ID: "q_runway", QuestionVersion: 1, SectionID: db.SectionIDDependency, SectionTitle: "Dependency",
+			DisplayOrder: 2, Text: "How much cash runway do you have?", Type: db.QuestionTypeRadio,
+			Opts: []string{"< 3 months", "> 3 months"}, Required: true, RiskName: "Cash runway",
+			RiskDesc: "Running out of cash.", Hedge: "Old hedge.", IsScoring: true,
+			// Same document, different key order and spacing, as JSONB returns it.
+			ScoringConfig: json.RawMessage(`{"opts": ["< 3 months", "> 3 months"], "type": "radio", "i_scores": [9, 2], "p_scores": [9, 2]}`),
+		},
+		{ID: "q_retired", SectionID: db.SectionIDLegal, Subtext: sql.NullString{}},
+	}
+
+	plan := seed.Diff(file, existing)
+	if len(plan.Inserts) != 1 || plan.Inserts[0].ID != "q_name" {
+		t.Errorf("expected q_name to be inserted, got %+v", plan.Inserts)
+	}
+	if len(plan.Updates) != 1 || !slices.Equal(plan.Updates[0].Fields, []string{"display_order", "hedge"}) {
+		t.Errorf("expected q_runway display_order and hedge to change, got %+v", plan.Updates)
+	}
+	if !slices.Equal(plan.Missing, []string{"q_retired"}) {
+		t.Errorf("expected q_retired to be reported missing, got %v", plan.Missing)
+	}
+	if len(plan.Changes()) != 2 || plan.Empty() {
+		t.Errorf("expected 2 changes, got %d", len(plan.Changes()))
+	}
+}
+
+func TestExport_RoundTripsWithoutChanges(t *testing.T) {
+	file, err := seed.Parse(strings.NewReader(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var rows []db.QuestionDefinition
+	for _, r := range seed.Diff(file, nil).Inserts {
+		rows = append(rows, db.QuestionDefinition{
+			ID: r.ID, QuestionVersion: r.QuestionVersion, SectionID: r.SectionID, SectionTitle: r.SectionTitle,
+			DisplayOrder: r.DisplayOrder, Text: r.Text, Subtext: r.Subtext, Type: r.Type, Opts: r.Opts,
+			Placeholder: r.Placeholder, Required: r.Required, RiskName: r.RiskName, RiskDesc: r.RiskDesc,
+			Hedge: r.Hedge, ScoringConfig: r.ScoringConfig, IsScoring: r.IsScoring,
+		})
+	}
+
+	exported, err := json.Marshal(seed.Export(rows, 2))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	reparsed, err := seed.Parse(strings.NewReader(string(exported)))
+	if err != nil {
+		t.Fatalf("re-parse exported file: %v", err)
+	}
+	if plan := seed.Diff(reparsed, rows); !plan.Empty() || plan.Unchanged != 2 {
+		t.Errorf("expected no changes after a round trip, got %+v", plan)
+	}
+}
+
+func TestAnswerRows_FeedsComputeRisks(t *testing.T) {
+	file, err := seed.Parse(strings.NewReader(validFile))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	rows, err := file.AnswerRows(map[string]string{"q_runway": "< 3 months", "q_name": "Acme"})
+	if err != nil {
+		t.Fatalf("AnswerRows: %v", err)
+	}
+	risks, err := scoring.ComputeRisks(rows)
+	if err != nil {
+		t.Fatalf("ComputeRisks: %v", err)
+	}
+	if len(risks) != 1 || risks[0].QuestionID != "q_runway" || risks[0].Score != 81 || risks[0].Section != "dependency" {
+		t.Errorf("unexpected risks: %+v", risks)
+	}
+
+	if _, err := file.AnswerRows(map[string]string{"q_typo": "x"}); err == nil || !strings.Contains(err.Error(), "q_typo") {
+		t.Errorf("expected an unknown-question error naming q_typo, got %v", err)
+	}
+}
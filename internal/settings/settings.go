@@ -0,0 +1,213 @@
+// Package settings holds the operator-tunable values that may change while the
+// process is running. Values live in the runtime_settings table; a Watcher
+// reloads them on an interval (and on demand, e.g. on SIGHUP or after an admin
+// update) and publishes an immutable snapshot that components read on every
+// use. Anything not listed here still requires a restart.
+package settings
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── KEYS ─────────────────────────────────────────────────────────────────────
+
+// Keys accepted in runtime_settings. Rows with any other key are ignored.
+const (
+	KeyPollInterval    = "poll_interval"     // Go duration, e.g. "30s"
+	KeyAIProviderOrder = "ai_provider_order" // comma-separated, e.g. "anthropic,deepseek"
+	KeyScoreProfile    = "score_profile"     // scoring.ParseProfile, e.g. "top_n:5"
+	// KeyShadowScoreProfile is a candidate score_profile that new reports
+	// are also scored with, for comparison only; same syntax.
+	KeyShadowScoreProfile = "shadow_score_profile"
+)
+
+// Known AI provider names for KeyAIProviderOrder.
+const (
+	ProviderDeepSeek  = "deepseek"
+	ProviderAnthropic = "anthropic"
+)
+
+// ─── SNAPSHOT ────────────────────────────────────────────────────────────────
+
+// Settings is an immutable snapshot. Components must not modify the slices.
+type Settings struct {
+	// PollInterval is how often the worker's fallback poller runs.
+	PollInterval time.Duration
+
+	// AIProviderOrder is the order in which AI providers are tried.
+	AIProviderOrder []string
+
+	// ScoreProfile is how reports generated from now on aggregate their risk
+	// scores into the overall score.
+	ScoreProfile scoring.Profile
+
+	// ShadowScoreProfile, when set, is a candidate ScoreProfile. The worker
+	// also scores each new report with it and stores the result in
+	// shadow_scores, where customers never see it. Nil when none is set.
+	ShadowScoreProfile *scoring.Profile
+}
+
+// apply parses value for key and stores it on s.
+func (s *Settings) apply(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case KeyPollInterval:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if d < time.Second {
+			return fmt.Errorf("%s: must be at least 1s (got %s)", key, d)
+		}
+		s.PollInterval = d
+	case KeyAIProviderOrder:
+		var order []string
+		for _, p := range strings.Split(value, ",") {
+			p = strings.ToLower(strings.TrimSpace(p))
+			if p != ProviderDeepSeek && p != ProviderAnthropic {
+				return fmt.Errorf("%s: unknown provider %q", key, p)
+			}
+			if slices.Contains(order, p) {
+				return fmt.Errorf("%s: provider %q listed twice", key, p)
+			}
+			order = append(order, p)
+		}
+		s.AIProviderOrder = order
+	case KeyScoreProfile:
+		p, err := scoring.ParseProfile(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		s.ScoreProfile = p
+	case KeyShadowScoreProfile:
+		p, err := scoring.ParseProfile(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		s.ShadowScoreProfile = &p
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// Validate reports whether value is acceptable for key. The admin API calls
+// this before writing so a typo never reaches the table.
+func Validate(key, value string) error {
+	var s Settings
+	return s.apply(key, value)
+}
+
+// ─── WATCHER ──────────────────────────────────────────────────────────────────
+
+// Watcher loads runtime_settings and publishes the result atomically. A nil
+// *Watcher is valid and always reports its defaults, which keeps tests and
+// callers that do not care about hot reload simple.
+type Watcher struct {
+	q        db.SettingsReader
+	defaults Settings
+	interval time.Duration
+	logger   *slog.Logger
+
+	current atomic.Pointer[Settings]
+}
+
+// NewWatcher returns a Watcher that starts out serving defaults. Call Reload
+// once before serving traffic, then Start to keep it up to date.
+func NewWatcher(q db.SettingsReader, defaults Settings, interval time.Duration, logger *slog.Logger) *Watcher {
+	w := &Watcher{
+		q:        q,
+		defaults: defaults,
+		interval: interval,
+		logger:   logger,
+	}
+	w.current.Store(&defaults)
+	return w
+}
+
+// Current returns the latest snapshot.
+func (w *Watcher) Current() Settings {
+	if w == nil {
+		return Defaults()
+	}
+	return *w.current.Load()
+}
+
+// Defaults returns the built-in values used when nothing is configured.
+func Defaults() Settings {
+	return Settings{
+		PollInterval:    30 * time.Second,
+		AIProviderOrder: []string{ProviderDeepSeek, ProviderAnthropic},
+		ScoreProfile:    scoring.Profile{Mode: scoring.ModeMean},
+	}
+}
+
+// Reload reads the table and swaps in a new snapshot. Each row is applied on
+// top of the defaults; a row that fails to parse is logged and skipped so one
+// bad value cannot take the others down with it.
+func (w *Watcher) Reload(ctx context.Context) error {
+	rows, err := w.q.ListRuntimeSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("settings: list: %w", err)
+	}
+
+	next := w.defaults
+	for _, row := range rows {
+		if err := next.apply(row.Key, row.Value); err != nil {
+			w.logger.Warn("settings: ignoring invalid runtime setting", "key", row.Key, "error", err)
+		}
+	}
+
+	prev := w.current.Swap(&next)
+	if !equal(*prev, next) {
+		w.logger.Info("settings: runtime settings changed",
+			"poll_interval", next.PollInterval,
+			"ai_provider_order", strings.Join(next.AIProviderOrder, ","),
+			"score_profile", next.ScoreProfile.String(),
+			"shadow_score_profile", next.ShadowString(),
+		)
+	}
+	return nil
+}
+
+// Start reloads on every interval tick until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Reload(ctx); err != nil {
+				w.logger.Error("settings: reload failed", "error", err)
+			}
+		}
+	}
+}
+
+func equal(a, b Settings) bool {
+	return a.PollInterval == b.PollInterval &&
+		slices.Equal(a.AIProviderOrder, b.AIProviderOrder) &&
+		a.ScoreProfile == b.ScoreProfile &&
+		a.ShadowString() == b.ShadowString()
+}
+
+// ShadowString returns ShadowScoreProfile in the form ParseProfile accepts,
+// or "" when none is set.
+func (s Settings) ShadowString() string {
+	if s.ShadowScoreProfile == nil {
+		return ""
+	}
+	return s.ShadowScoreProfile.String()
+}
@@ -0,0 +1,90 @@
+package settings_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/settings"
+)
+
+type stubQuerier struct {
+	rows []db.RuntimeSetting
+}
+
+func (q *stubQuerier) ListRuntimeSettings(_ context.Context) ([]db.RuntimeSetting, error) {
+	return q.rows, nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		key, value string
+		ok         bool
+	}{
+		{settings.KeyPollInterval, "45s", true},
+		{settings.KeyPollInterval, "10ms", false},
+		{settings.KeyPollInterval, "soon", false},
+		{settings.KeyAIProviderOrder, "anthropic, deepseek", true},
+		{settings.KeyAIProviderOrder, "anthropic,anthropic", false},
+		{settings.KeyAIProviderOrder, "openai", false},
+		{settings.KeyScoreProfile, "top_n:3", true},
+		{settings.KeyScoreProfile, "max_dominant", true},
+		{settings.KeyScoreProfile, "top_n:0", false},
+		{settings.KeyScoreProfile, "median", false},
+		{settings.KeyShadowScoreProfile, "weighted", true},
+		{settings.KeyShadowScoreProfile, "", false},
+		{"price_cents", "4900", false}, // moved to the products table
+		{"max_widgets", "3", false},
+	}
+	for _, tc := range cases {
+		err := settings.Validate(tc.key, tc.value)
+		if (err == nil) != tc.ok {
+			t.Errorf("Validate(%q, %q) = %v, want ok=%v", tc.key, tc.value, err, tc.ok)
+		}
+	}
+}
+
+func TestNilWatcher_ReturnsDefaults(t *testing.T) {
+	var w *settings.Watcher
+	if got := w.Current().PollInterval; got != settings.Defaults().PollInterval {
+		t.Errorf("expected default poll interval, got %s", got)
+	}
+}
+
+func TestWatcher_ReloadAppliesValidRowsAndSkipsInvalid(t *testing.T) {
+	q := &stubQuerier{rows: []db.RuntimeSetting{
+		{Key: settings.KeyPollInterval, Value: "not-a-duration"},
+		{Key: settings.KeyAIProviderOrder, Value: "anthropic,deepseek"},
+	}}
+	defaults := settings.Defaults()
+	defaults.PollInterval = 15 * time.Second
+
+	w := settings.NewWatcher(q, defaults, time.Minute, discardLogger())
+	if err := w.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	cur := w.Current()
+	if cur.PollInterval != 15*time.Second {
+		t.Errorf("invalid row should leave default poll interval, got %s", cur.PollInterval)
+	}
+	if len(cur.AIProviderOrder) != 2 || cur.AIProviderOrder[0] != settings.ProviderAnthropic {
+		t.Errorf("unexpected provider order %v", cur.AIProviderOrder)
+	}
+
+	// Removing a row reverts to the default on the next reload.
+	q.rows = nil
+	if err := w.Reload(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := w.Current().AIProviderOrder; got[0] != settings.ProviderDeepSeek {
+		t.Errorf("expected default provider order after row removal, got %v", got)
+	}
+}
@@ -0,0 +1,297 @@
+// Package storage keeps bulky generated artifacts — CSV exports, PDFs, AI
+// transcripts — in an S3-compatible bucket instead of Postgres, and hands out
+// signed URLs to download them without going through the API.
+//
+// The client speaks the S3 REST API directly with AWS Signature Version 4,
+// so it works against AWS S3 and against MinIO, Cloudflare R2, Backblaze B2
+// and other S3-compatible stores (set PathStyle for the ones that do not
+// support bucket subdomains, MinIO included).
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get for a key the bucket does not hold.
+var ErrNotFound = errors.New("storage: object not found")
+
+// maxErrorBody bounds how much of an error response is kept for the error
+// message.
+const maxErrorBody = 1 << 10
+
+// Store puts, fetches and deletes objects by key. Tests inject a stub that
+// never hits the network.
+type Store interface {
+	// Put stores body under key, replacing any object already there.
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// Get returns the object under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the object under key. Deleting a missing key is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads key without credentials until
+	// it expires. A non-empty filename makes browsers save it under that
+	// name.
+	SignedURL(key, filename string) (url string, expires time.Time)
+}
+
+// Config holds the bucket and its credentials.
+type Config struct {
+	// Endpoint is the S3 API base URL. Default: https://s3.amazonaws.com.
+	Endpoint string
+	// Region signs requests. Default: us-east-1.
+	Region string
+	Bucket string
+	// AccessKeyID and SecretAccessKey are the credentials requests are
+	// signed with.
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket as <endpoint>/<bucket> instead of
+	// <bucket>.<endpoint host>.
+	PathStyle bool
+	// URLTTL is how long a signed URL is valid for. Default: 15m.
+	URLTTL time.Duration
+}
+
+// s3Client is the concrete Store for S3-compatible services.
+type s3Client struct {
+	cfg        Config
+	base       *url.URL // the bucket's URL, without a trailing slash
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// New returns a Store for cfg, or an error when Endpoint is not a URL.
+func New(cfg Config) (Store, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3.amazonaws.com"
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = 15 * time.Minute
+	}
+	base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("storage: endpoint %q must be an http(s) URL", cfg.Endpoint)
+	}
+	if cfg.PathStyle {
+		base.Path += "/" + cfg.Bucket
+	} else {
+		base.Host = cfg.Bucket + "." + base.Host
+	}
+	return &s3Client{
+		cfg:  cfg,
+		base: base,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (c *s3Client) Put(ctx context.Context, key, contentType string, body []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, key, contentType, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *s3Client) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("storage: read %s: %w", key, err)
+	}
+	return body, nil
+}
+
+func (c *s3Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, "", nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *s3Client) SignedURL(key, filename string) (string, time.Time) {
+	now := c.now().UTC()
+	u := c.objectURL(key)
+
+	q := url.Values{
+		"X-Amz-Algorithm":     {algorithm},
+		"X-Amz-Credential":    {c.cfg.AccessKeyID + "/" + c.scope(now)},
+		"X-Amz-Date":          {now.Format(amzDateLayout)},
+		"X-Amz-Expires":       {strconv.Itoa(int(c.cfg.URLTTL.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	if filename != "" {
+		q.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	u.RawQuery = canonicalQuery(q)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + c.sign(now, canonical)
+	return u.String(), now.Add(c.cfg.URLTTL)
+}
+
+// do sends a signed request for key and returns the response when it is a
+// 2xx. A 404 is ErrNotFound; any other status is an error carrying the start
+// of S3's error document.
+func (c *s3Client) do(ctx context.Context, method, key, contentType string, body []byte) (*http.Response, error) {
+	u := c.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("storage: build request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	now := c.now().UTC()
+	payloadHash := hashHex(body)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateLayout))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		"",
+		"host:" + u.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + now.Format(amzDateLayout) + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, c.cfg.AccessKeyID, c.scope(now), signedHeaders, c.sign(now, canonical)))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: %s %s: %w", method, key, err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return nil, fmt.Errorf("storage: %s %s: status %d: %s", method, key, resp.StatusCode, bytes.TrimSpace(detail))
+}
+
+// objectURL returns the URL of key in the bucket.
+func (c *s3Client) objectURL(key string) *url.URL {
+	u := *c.base
+	u.Path += "/" + key
+	var escaped []string
+	for _, seg := range strings.Split(u.Path, "/") {
+		escaped = append(escaped, uriEncode(seg))
+	}
+	u.RawPath = strings.Join(escaped, "/")
+	return &u
+}
+
+// ─── SIGNATURE V4 ─────────────────────────────────────────────────────────────
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	amzDateLayout   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// scope is the credential scope of a request made at t.
+func (c *s3Client) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + c.cfg.Region + "/s3/aws4_request"
+}
+
+// sign returns the signature of a canonical request made at t.
+func (c *s3Client) sign(t time.Time, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		algorithm,
+		t.Format(amzDateLayout),
+		c.scope(t),
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalQuery encodes q sorted by name, as Signature V4 requires.
+func canonicalQuery(q url.Values) string {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, v := range q[name] {
+			parts = append(parts, uriEncode(name)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, which
+// is stricter than url.QueryEscape (spaces become %20, not +).
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if 'A' <= ch && ch <= 'Z' || 'a' <= ch && ch <= 'z' || '0' <= ch && ch <= '9' || strings.IndexByte("-_.~", ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,620 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+)
+
+// Encrypted columns, named as fieldcrypt binds them to their ciphertext.
+const (
+	fieldSessionEmail      = "sessions.email"
+	fieldSubscriptionEmail = "subscriptions.email"
+	fieldEmailLogAddress   = "email_log.to_address"
+	fieldStripePayload     = "stripe_events.payload"
+)
+
+// codecQuerier encrypts the sensitive columns on their way into the database
+// and decrypts them on their way out, so callers only ever see plaintext. It
+// also fills the email_hash blind indexes on write and turns the email
+// arguments of lookups into their index.
+//
+// Only the queries that touch an encrypted column are overridden; everything
+// else goes straight to the embedded Querier. A new query that reads or
+// writes one of these columns must be added here.
+type codecQuerier struct {
+	db.Querier
+	c *fieldcrypt.Codec
+}
+
+func (q codecQuerier) encryptEmail(field string, email sql.NullString) (sql.NullString, error) {
+	if !email.Valid {
+		return email, nil
+	}
+	enc, err := q.c.Encrypt(field, email.String)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: enc, Valid: true}, nil
+}
+
+func (q codecQuerier) decryptEmail(field string, email *sql.NullString) error {
+	if !email.Valid {
+		return nil
+	}
+	plain, err := q.c.Decrypt(field, email.String)
+	if err != nil {
+		return err
+	}
+	email.String = plain
+	return nil
+}
+
+func (q codecQuerier) index(email sql.NullString) sql.NullString {
+	h := q.c.Index(email.String)
+	return sql.NullString{String: h, Valid: email.Valid && h != ""}
+}
+
+// ─── SESSIONS ─────────────────────────────────────────────────────────────────
+
+func (q codecQuerier) session(s db.Session, err error) (db.Session, error) {
+	if err != nil {
+		return s, err
+	}
+	if err := q.decryptEmail(fieldSessionEmail, &s.Email); err != nil {
+		return db.Session{}, fmt.Errorf("session %s: %w", s.ID, err)
+	}
+	return s, nil
+}
+
+func (q codecQuerier) AttachStripeCustomer(ctx context.Context, arg db.AttachStripeCustomerParams) (db.Session, error) {
+	arg.EmailHash = q.index(arg.Email)
+	var err error
+	if arg.Email, err = q.encryptEmail(fieldSessionEmail, arg.Email); err != nil {
+		return db.Session{}, err
+	}
+	return q.session(q.Querier.AttachStripeCustomer(ctx, arg))
+}
+
+func (q codecQuerier) MarkSessionPaidBySubscription(ctx context.Context, arg db.MarkSessionPaidBySubscriptionParams) (db.Session, error) {
+	arg.EmailHash = q.index(arg.Email)
+	var err error
+	if arg.Email, err = q.encryptEmail(fieldSessionEmail, arg.Email); err != nil {
+		return db.Session{}, err
+	}
+	return q.session(q.Querier.MarkSessionPaidBySubscription(ctx, arg))
+}
+
+func (q codecQuerier) MarkSessionPaidByCredit(ctx context.Context, arg db.MarkSessionPaidByCreditParams) (db.Session, error) {
+	arg.EmailHash = q.index(arg.Email)
+	var err error
+	if arg.Email, err = q.encryptEmail(fieldSessionEmail, arg.Email); err != nil {
+		return db.Session{}, err
+	}
+	return q.session(q.Querier.MarkSessionPaidByCredit(ctx, arg))
+}
+
+func (q codecQuerier) CreateCohortSession(ctx context.Context, arg db.CreateCohortSessionParams) (db.Session, error) {
+	arg.EmailHash = q.index(arg.Email)
+	var err error
+	if arg.Email, err = q.encryptEmail(fieldSessionEmail, arg.Email); err != nil {
+		return db.Session{}, err
+	}
+	return q.session(q.Querier.CreateCohortSession(ctx, arg))
+}
+
+func (q codecQuerier) MarkSessionRefunded(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	return q.session(q.Querier.MarkSessionRefunded(ctx, id))
+}
+
+// GetDuplicateCredit takes the plain address, like every caller has.
+func (q codecQuerier) GetDuplicateCredit(ctx context.Context, arg db.GetDuplicateCreditParams) (uuid.UUID, error) {
+	arg.Email = q.c.Index(arg.Email)
+	return q.Querier.GetDuplicateCredit(ctx, arg)
+}
+
+func (q codecQuerier) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	return q.session(q.Querier.CreateSession(ctx, arg))
+}
+
+func (q codecQuerier) GetSessionByAnonToken(ctx context.Context, anonToken string) (db.Session, error) {
+	return q.session(q.Querier.GetSessionByAnonToken(ctx, anonToken))
+}
+
+func (q codecQuerier) GetSessionByID(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	return q.session(q.Querier.GetSessionByID(ctx, id))
+}
+
+func (q codecQuerier) GetSessionByStripePI(ctx context.Context, pi sql.NullString) (db.Session, error) {
+	return q.session(q.Querier.GetSessionByStripePI(ctx, pi))
+}
+
+func (q codecQuerier) MarkSessionPaid(ctx context.Context, pi sql.NullString) (db.Session, error) {
+	return q.session(q.Querier.MarkSessionPaid(ctx, pi))
+}
+
+func (q codecQuerier) MarkSessionPaymentFailed(ctx context.Context, pi sql.NullString) (db.Session, error) {
+	return q.session(q.Querier.MarkSessionPaymentFailed(ctx, pi))
+}
+
+func (q codecQuerier) UpdateSessionContext(ctx context.Context, arg db.UpdateSessionContextParams) (db.Session, error) {
+	return q.session(q.Querier.UpdateSessionContext(ctx, arg))
+}
+
+func (q codecQuerier) ListSessionsByStripePIs(ctx context.Context, pis []string) ([]db.Session, error) {
+	sessions, err := q.Querier.ListSessionsByStripePIs(ctx, pis)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		if sessions[i], err = q.session(sessions[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+func (q codecQuerier) CountFailedPaymentsByEmailSince(ctx context.Context, arg db.CountFailedPaymentsByEmailSinceParams) (int64, error) {
+	arg.Email = q.index(arg.Email)
+	return q.Querier.CountFailedPaymentsByEmailSince(ctx, arg)
+}
+
+func (q codecQuerier) GetReportByAccessToken(ctx context.Context, accessToken string) (db.GetReportByAccessTokenRow, error) {
+	row, err := q.Querier.GetReportByAccessToken(ctx, accessToken)
+	if err != nil {
+		return row, err
+	}
+	if err := q.decryptEmail(fieldSessionEmail, &row.Email); err != nil {
+		return db.GetReportByAccessTokenRow{}, fmt.Errorf("report %s: %w", row.ID, err)
+	}
+	return row, nil
+}
+
+func (q codecQuerier) GetInvoiceByAccessToken(ctx context.Context, accessToken string) (db.GetInvoiceByAccessTokenRow, error) {
+	row, err := q.Querier.GetInvoiceByAccessToken(ctx, accessToken)
+	if err != nil {
+		return row, err
+	}
+	if err := q.decryptEmail(fieldSessionEmail, &row.Email); err != nil {
+		return db.GetInvoiceByAccessTokenRow{}, fmt.Errorf("session %s: %w", row.SessionID, err)
+	}
+	return row, nil
+}
+
+// ListDeliverableReportsByEmail takes the plain address, like every caller has.
+func (q codecQuerier) ListDeliverableReportsByEmail(ctx context.Context, arg db.ListDeliverableReportsByEmailParams) ([]db.ListDeliverableReportsByEmailRow, error) {
+	arg.Email = q.c.Index(arg.Email)
+	rows, err := q.Querier.ListDeliverableReportsByEmail(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if err := q.decryptEmail(fieldSessionEmail, &rows[i].Email); err != nil {
+			return nil, fmt.Errorf("session %s: %w", rows[i].SessionID, err)
+		}
+	}
+	return rows, nil
+}
+
+// ─── SUBSCRIPTIONS ────────────────────────────────────────────────────────────
+
+func (q codecQuerier) subscription(s db.Subscription, err error) (db.Subscription, error) {
+	if err != nil {
+		return s, err
+	}
+	if err := q.decryptEmail(fieldSubscriptionEmail, &s.Email); err != nil {
+		return db.Subscription{}, fmt.Errorf("subscription %s: %w", s.ID, err)
+	}
+	return s, nil
+}
+
+func (q codecQuerier) UpsertSubscription(ctx context.Context, arg db.UpsertSubscriptionParams) (db.Subscription, error) {
+	arg.EmailHash = q.index(arg.Email)
+	var err error
+	if arg.Email, err = q.encryptEmail(fieldSubscriptionEmail, arg.Email); err != nil {
+		return db.Subscription{}, err
+	}
+	return q.subscription(q.Querier.UpsertSubscription(ctx, arg))
+}
+
+// GetEntitledSubscription takes the plain address, like every caller has.
+func (q codecQuerier) GetEntitledSubscription(ctx context.Context, email string) (db.Subscription, error) {
+	return q.subscription(q.Querier.GetEntitledSubscription(ctx, q.c.Index(email)))
+}
+
+// ─── EMAIL LOG ────────────────────────────────────────────────────────────────
+
+func (q codecQuerier) emailLog(e db.EmailLog, err error) (db.EmailLog, error) {
+	if err != nil {
+		return e, err
+	}
+	if e.ToAddress, err = q.c.Decrypt(fieldEmailLogAddress, e.ToAddress); err != nil {
+		return db.EmailLog{}, fmt.Errorf("email log %s: %w", e.ID, err)
+	}
+	return e, nil
+}
+
+func (q codecQuerier) LogEmail(ctx context.Context, arg db.LogEmailParams) (db.EmailLog, error) {
+	var err error
+	if arg.ToAddress, err = q.c.Encrypt(fieldEmailLogAddress, arg.ToAddress); err != nil {
+		return db.EmailLog{}, err
+	}
+	return q.emailLog(q.Querier.LogEmail(ctx, arg))
+}
+
+func (q codecQuerier) LogEmailFailure(ctx context.Context, arg db.LogEmailFailureParams) (db.EmailLog, error) {
+	var err error
+	if arg.ToAddress, err = q.c.Encrypt(fieldEmailLogAddress, arg.ToAddress); err != nil {
+		return db.EmailLog{}, err
+	}
+	return q.emailLog(q.Querier.LogEmailFailure(ctx, arg))
+}
+
+func (q codecQuerier) ClaimEmail(ctx context.Context, arg db.ClaimEmailParams) (db.EmailLog, error) {
+	var err error
+	if arg.ToAddress, err = q.c.Encrypt(fieldEmailLogAddress, arg.ToAddress); err != nil {
+		return db.EmailLog{}, err
+	}
+	return q.emailLog(q.Querier.ClaimEmail(ctx, arg))
+}
+
+func (q codecQuerier) MarkEmailClaimSent(ctx context.Context, arg db.MarkEmailClaimSentParams) (db.EmailLog, error) {
+	return q.emailLog(q.Querier.MarkEmailClaimSent(ctx, arg))
+}
+
+func (q codecQuerier) ReleaseEmailClaim(ctx context.Context, arg db.ReleaseEmailClaimParams) (db.EmailLog, error) {
+	return q.emailLog(q.Querier.ReleaseEmailClaim(ctx, arg))
+}
+
+func (q codecQuerier) GetEmailByDedupeKey(ctx context.Context, dedupeKey sql.NullString) (db.EmailLog, error) {
+	return q.emailLog(q.Querier.GetEmailByDedupeKey(ctx, dedupeKey))
+}
+
+func (q codecQuerier) LogSkippedEmail(ctx context.Context, arg db.LogSkippedEmailParams) (db.EmailLog, error) {
+	var err error
+	if arg.ToAddress, err = q.c.Encrypt(fieldEmailLogAddress, arg.ToAddress); err != nil {
+		return db.EmailLog{}, err
+	}
+	return q.emailLog(q.Querier.LogSkippedEmail(ctx, arg))
+}
+
+func (q codecQuerier) ListEmailLogBySession(ctx context.Context, sessionID uuid.NullUUID) ([]db.EmailLog, error) {
+	rows, err := q.Querier.ListEmailLogBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i], err = q.emailLog(rows[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func (q codecQuerier) ListEmailLogPage(ctx context.Context, arg db.ListEmailLogPageParams) ([]db.EmailLog, error) {
+	rows, err := q.Querier.ListEmailLogPage(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i], err = q.emailLog(rows[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+func (q codecQuerier) MarkEmailOpened(ctx context.Context, providerID sql.NullString) (db.EmailLog, error) {
+	return q.emailLog(q.Querier.MarkEmailOpened(ctx, providerID))
+}
+
+func (q codecQuerier) MarkEmailClicked(ctx context.Context, providerID sql.NullString) (db.EmailLog, error) {
+	return q.emailLog(q.Querier.MarkEmailClicked(ctx, providerID))
+}
+
+func (q codecQuerier) MarkEmailBounced(ctx context.Context, arg db.MarkEmailBouncedParams) (db.EmailLog, error) {
+	return q.emailLog(q.Querier.MarkEmailBounced(ctx, arg))
+}
+
+func (q codecQuerier) ListUnopenedReportEmails(ctx context.Context, arg db.ListUnopenedReportEmailsParams) ([]db.ListUnopenedReportEmailsRow, error) {
+	rows, err := q.Querier.ListUnopenedReportEmails(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].ToAddress, err = q.c.Decrypt(fieldEmailLogAddress, rows[i].ToAddress); err != nil {
+			return nil, fmt.Errorf("email log %s: %w", rows[i].ID, err)
+		}
+	}
+	return rows, nil
+}
+
+func (q codecQuerier) ListFeedbackCandidates(ctx context.Context, arg db.ListFeedbackCandidatesParams) ([]db.ListFeedbackCandidatesRow, error) {
+	rows, err := q.Querier.ListFeedbackCandidates(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].ToAddress, err = q.c.Decrypt(fieldEmailLogAddress, rows[i].ToAddress); err != nil {
+			return nil, fmt.Errorf("email log for report %s: %w", rows[i].ReportID.UUID, err)
+		}
+	}
+	return rows, nil
+}
+
+// ─── STRIPE EVENTS ────────────────────────────────────────────────────────────
+
+func (q codecQuerier) stripeEvent(e db.StripeEvent, err error) (db.StripeEvent, error) {
+	if err != nil {
+		return e, err
+	}
+	if e.Payload, err = q.c.DecryptJSON(fieldStripePayload, e.Payload); err != nil {
+		return db.StripeEvent{}, fmt.Errorf("stripe event %s: %w", e.StripeEventID, err)
+	}
+	return e, nil
+}
+
+func (q codecQuerier) stripeEvents(events []db.StripeEvent, err error) ([]db.StripeEvent, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if events[i], err = q.stripeEvent(events[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+func (q codecQuerier) UpsertStripeEvent(ctx context.Context, arg db.UpsertStripeEventParams) (db.StripeEvent, error) {
+	var err error
+	if arg.Payload, err = q.c.EncryptJSON(fieldStripePayload, arg.Payload); err != nil {
+		return db.StripeEvent{}, err
+	}
+	return q.stripeEvent(q.Querier.UpsertStripeEvent(ctx, arg))
+}
+
+func (q codecQuerier) GetStripeEvent(ctx context.Context, id string) (db.StripeEvent, error) {
+	return q.stripeEvent(q.Querier.GetStripeEvent(ctx, id))
+}
+
+func (q codecQuerier) MarkStripeEventFailed(ctx context.Context, arg db.MarkStripeEventFailedParams) (db.StripeEvent, error) {
+	return q.stripeEvent(q.Querier.MarkStripeEventFailed(ctx, arg))
+}
+
+func (q codecQuerier) MarkStripeEventProcessed(ctx context.Context, id string) (db.StripeEvent, error) {
+	return q.stripeEvent(q.Querier.MarkStripeEventProcessed(ctx, id))
+}
+
+func (q codecQuerier) GetUnprocessedStripeEvents(ctx context.Context) ([]db.StripeEvent, error) {
+	return q.stripeEvents(q.Querier.GetUnprocessedStripeEvents(ctx))
+}
+
+func (q codecQuerier) ListStripeEventsForExport(ctx context.Context, arg db.ListStripeEventsForExportParams) ([]db.StripeEvent, error) {
+	return q.stripeEvents(q.Querier.ListStripeEventsForExport(ctx, arg))
+}
+
+func (q codecQuerier) ListReprocessableStripeEvents(ctx context.Context, arg db.ListReprocessableStripeEventsParams) ([]db.StripeEvent, error) {
+	return q.stripeEvents(q.Querier.ListReprocessableStripeEvents(ctx, arg))
+}
+
+func (q codecQuerier) ListStripeEvents(ctx context.Context, arg db.ListStripeEventsParams) ([]db.StripeEvent, error) {
+	return q.stripeEvents(q.Querier.ListStripeEvents(ctx, arg))
+}
+
+// ─── REENCRYPTION ─────────────────────────────────────────────────────────────
+
+// ReencryptResult counts, for one column, the values that were not in the
+// form the codec writes now and how many of them were rewritten.
+type ReencryptResult struct {
+	Column    string `json:"column"`
+	Scanned   int    `json:"scanned"`
+	Stale     int    `json:"stale"`
+	Rewritten int    `json:"rewritten"`
+}
+
+// reencryptBatch is how many rows each scan reads.
+const reencryptBatch = 500
+
+// Reencrypt brings every encrypted column in line with the codec: plaintext
+// is encrypted, values under a retired key move to the primary key, and
+// email_hash is recomputed where the index key changed. With decrypt, values
+// are written back as plaintext instead, before encryption is switched off.
+// Without apply it only counts.
+//
+// Each row is rewritten only if it still holds the value that was read, so
+// running this beside live traffic is safe; a row changed meanwhile was
+// written by the current codec anyway. Rewriting a session bumps its
+// updated_at.
+func (s *Store) Reencrypt(ctx context.Context, apply, decrypt bool) ([]ReencryptResult, error) {
+	c := s.codec
+	if decrypt {
+		c = c.DecryptOnly()
+	}
+	raw := s.raw
+	scans := []func(context.Context, *fieldcrypt.Codec, db.Querier, bool) (ReencryptResult, error){
+		reencryptSessionEmails, reencryptSubscriptionEmails, reencryptEmailLog, reencryptStripePayloads,
+	}
+	var results []ReencryptResult
+	for _, scan := range scans {
+		res, err := scan(ctx, c, raw, apply)
+		results = append(results, res)
+		if err != nil {
+			return results, fmt.Errorf("store: reencrypt %s: %w", res.Column, err)
+		}
+	}
+	return results, nil
+}
+
+// rewriteEmail returns the value and index c would write for stored, and
+// whether either differs from what is stored.
+func rewriteEmail(c *fieldcrypt.Codec, field, stored string, hash sql.NullString) (enc, idx string, stale bool, err error) {
+	plain, err := c.Decrypt(field, stored)
+	if err != nil {
+		return "", "", false, err
+	}
+	idx = c.Index(plain)
+	if c.Current(stored) && hash.String == idx {
+		return stored, idx, false, nil
+	}
+	enc, err = c.Encrypt(field, plain)
+	return enc, idx, true, err
+}
+
+func reencryptSessionEmails(ctx context.Context, c *fieldcrypt.Codec, q db.Querier, apply bool) (ReencryptResult, error) {
+	res := ReencryptResult{Column: fieldSessionEmail}
+	after := uuid.Nil
+	for {
+		rows, err := q.ListSessionEmails(ctx, db.ListSessionEmailsParams{After: after, MaxRows: reencryptBatch})
+		if err != nil {
+			return res, err
+		}
+		for _, row := range rows {
+			res.Scanned++
+			enc, idx, stale, err := rewriteEmail(c, fieldSessionEmail, row.Email, row.EmailHash)
+			if err != nil {
+				return res, fmt.Errorf("session %s: %w", row.ID, err)
+			}
+			if !stale {
+				continue
+			}
+			res.Stale++
+			if !apply {
+				continue
+			}
+			n, err := q.SetSessionEmail(ctx, db.SetSessionEmailParams{ID: row.ID, Email: enc, EmailHash: idx, OldEmail: row.Email})
+			if err != nil {
+				return res, fmt.Errorf("session %s: %w", row.ID, err)
+			}
+			res.Rewritten += int(n)
+		}
+		if len(rows) < reencryptBatch {
+			return res, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+func reencryptSubscriptionEmails(ctx context.Context, c *fieldcrypt.Codec, q db.Querier, apply bool) (ReencryptResult, error) {
+	res := ReencryptResult{Column: fieldSubscriptionEmail}
+	after := uuid.Nil
+	for {
+		rows, err := q.ListSubscriptionEmails(ctx, db.ListSubscriptionEmailsParams{After: after, MaxRows: reencryptBatch})
+		if err != nil {
+			return res, err
+		}
+		for _, row := range rows {
+			res.Scanned++
+			enc, idx, stale, err := rewriteEmail(c, fieldSubscriptionEmail, row.Email, row.EmailHash)
+			if err != nil {
+				return res, fmt.Errorf("subscription %s: %w", row.ID, err)
+			}
+			if !stale {
+				continue
+			}
+			res.Stale++
+			if !apply {
+				continue
+			}
+			n, err := q.SetSubscriptionEmail(ctx, db.SetSubscriptionEmailParams{ID: row.ID, Email: enc, EmailHash: idx, OldEmail: row.Email})
+			if err != nil {
+				return res, fmt.Errorf("subscription %s: %w", row.ID, err)
+			}
+			res.Rewritten += int(n)
+		}
+		if len(rows) < reencryptBatch {
+			return res, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+func reencryptEmailLog(ctx context.Context, c *fieldcrypt.Codec, q db.Querier, apply bool) (ReencryptResult, error) {
+	res := ReencryptResult{Column: fieldEmailLogAddress}
+	after := uuid.Nil
+	for {
+		rows, err := q.ListEmailLogAddresses(ctx, db.ListEmailLogAddressesParams{After: after, MaxRows: reencryptBatch})
+		if err != nil {
+			return res, err
+		}
+		for _, row := range rows {
+			res.Scanned++
+			if c.Current(row.ToAddress) {
+				continue
+			}
+			res.Stale++
+			plain, err := c.Decrypt(fieldEmailLogAddress, row.ToAddress)
+			if err != nil {
+				return res, fmt.Errorf("email log %s: %w", row.ID, err)
+			}
+			if !apply {
+				continue
+			}
+			enc, err := c.Encrypt(fieldEmailLogAddress, plain)
+			if err != nil {
+				return res, err
+			}
+			n, err := q.SetEmailLogAddress(ctx, db.SetEmailLogAddressParams{ID: row.ID, ToAddress: enc, OldToAddress: row.ToAddress})
+			if err != nil {
+				return res, fmt.Errorf("email log %s: %w", row.ID, err)
+			}
+			res.Rewritten += int(n)
+		}
+		if len(rows) < reencryptBatch {
+			return res, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+func reencryptStripePayloads(ctx context.Context, c *fieldcrypt.Codec, q db.Querier, apply bool) (ReencryptResult, error) {
+	res := ReencryptResult{Column: fieldStripePayload}
+	after := ""
+	for {
+		rows, err := q.ListStripeEventPayloads(ctx, db.ListStripeEventPayloadsParams{After: after, MaxRows: reencryptBatch})
+		if err != nil {
+			return res, err
+		}
+		for _, row := range rows {
+			res.Scanned++
+			stored, encrypted := fieldcrypt.StoredJSONString(row.Payload)
+			if !encrypted {
+				stored = string(row.Payload)
+			}
+			if c.Current(stored) {
+				continue
+			}
+			res.Stale++
+			doc, err := c.DecryptJSON(fieldStripePayload, row.Payload)
+			if err != nil {
+				return res, fmt.Errorf("stripe event %s: %w", row.StripeEventID, err)
+			}
+			if !apply {
+				continue
+			}
+			enc, err := c.EncryptJSON(fieldStripePayload, doc)
+			if err != nil {
+				return res, err
+			}
+			n, err := q.SetStripeEventPayload(ctx, db.SetStripeEventPayloadParams{
+				StripeEventID: row.StripeEventID,
+				Payload:       json.RawMessage(enc),
+				OldPayload:    row.Payload,
+			})
+			if err != nil {
+				return res, fmt.Errorf("stripe event %s: %w", row.StripeEventID, err)
+			}
+			res.Rewritten += int(n)
+		}
+		if len(rows) < reencryptBatch {
+			return res, nil
+		}
+		after = rows[len(rows)-1].StripeEventID
+	}
+}
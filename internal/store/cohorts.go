@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── INPUT TYPES ─────────────────────────────────────────────────────────────
+
+// CohortSession is one pre-answered assessment in an admin bulk import.
+type CohortSession struct {
+	AnonToken  string // generated by the caller, as for CreateSession
+	Email      string // the report-ready email goes here
+	BizName    string
+	Industry   string
+	Stage      string
+	ProductSKU string            // empty for the standard product
+	Answers    map[string]string // question_id → answer_text
+}
+
+// ImportCohortParams is a validated bulk import.
+type ImportCohortParams struct {
+	Cohort   string
+	Sessions []CohortSession
+
+	// Spacing is the time between two reports' not_before; zero schedules
+	// every report at once.
+	Spacing time.Duration
+}
+
+// ─── METHODS ─────────────────────────────────────────────────────────────────
+
+// ImportCohort creates a paid session, its answers and a draft report for
+// every row of a bulk import, in one transaction so a failed import leaves
+// nothing behind and can simply be posted again.
+//
+// Reports are scheduled Spacing apart, starting after the last report of any
+// earlier import still waiting, and the poller picks each up once its
+// not_before has passed. The returned reports are in p.Sessions order.
+func (s *Store) ImportCohort(ctx context.Context, p ImportCohortParams) ([]db.Report, error) {
+	var reports []db.Report
+
+	err := s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		reports = make([]db.Report, 0, len(p.Sessions))
+
+		start, err := q.GetCohortScheduleEnd(ctx)
+		if err != nil {
+			return fmt.Errorf("ImportCohort: get schedule end: %w", err)
+		}
+
+		for i, cs := range p.Sessions {
+			session, err := q.CreateCohortSession(ctx, db.CreateCohortSessionParams{
+				AnonToken:  cs.AnonToken,
+				Email:      sql.NullString{String: cs.Email, Valid: true},
+				BizName:    nullString(cs.BizName),
+				Industry:   nullString(cs.Industry),
+				Stage:      nullString(cs.Stage),
+				ProductSku: nullString(cs.ProductSKU),
+				Cohort:     sql.NullString{String: p.Cohort, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("ImportCohort: row %d: create session: %w", i+1, err)
+			}
+
+			// Sorted so the writes do not depend on map order.
+			ids := make([]string, 0, len(cs.Answers))
+			for id := range cs.Answers {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			for _, id := range ids {
+				if _, err := q.UpsertAnswer(ctx, db.UpsertAnswerParams{
+					SessionID:  session.ID,
+					QuestionID: id,
+					AnswerText: cs.Answers[id],
+				}); err != nil {
+					return fmt.Errorf("ImportCohort: row %d: upsert answer %q: %w", i+1, id, err)
+				}
+			}
+
+			report, err := q.CreateScheduledReport(ctx, db.CreateScheduledReportParams{
+				SessionID: session.ID,
+				NotBefore: sql.NullTime{Time: start.Add(time.Duration(i) * p.Spacing), Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("ImportCohort: row %d: create report: %w", i+1, err)
+			}
+			reports = append(reports, report)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
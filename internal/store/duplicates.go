@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── INPUT TYPES ─────────────────────────────────────────────────────────────
+
+// DuplicatePolicy decides when InitialiseReport holds a report as a duplicate
+// purchase: a card payment from an email that already paid by card within
+// Window, with at most MaxChangedAnswers questions answered differently.
+type DuplicatePolicy struct {
+	// Window is how long after a purchase a second one counts as a possible
+	// duplicate. Zero disables detection.
+	Window time.Duration
+
+	// MaxChangedAnswers is how many questions may differ between the two
+	// sessions for the reports to still be near-identical.
+	MaxChangedAnswers int
+}
+
+// Resolutions of a held duplicate purchase.
+const (
+	// DuplicateRefunded: the payment was refunded and the report revoked.
+	DuplicateRefunded = "refunded"
+	// DuplicateCredit: the payment is kept and pays for the email's next
+	// report of the same product; the held report is revoked.
+	DuplicateCredit = "credit"
+	// DuplicateReleased: not a duplicate after all; the report is generated.
+	DuplicateReleased = "released"
+)
+
+// ResolveDuplicateParams records an operator's (or the automatic) decision on
+// a held duplicate purchase.
+type ResolveDuplicateParams struct {
+	SessionID      uuid.UUID
+	Resolution     string // one of the Duplicate constants
+	StripeRefundID string // set for DuplicateRefunded
+}
+
+// ─── ERRORS ──────────────────────────────────────────────────────────────────
+
+// ErrDuplicateResolved is returned by ResolveDuplicate when the duplicate
+// purchase is unknown or has already been resolved.
+var ErrDuplicateResolved = errors.New("store: duplicate purchase already resolved")
+
+// ─── METHODS ─────────────────────────────────────────────────────────────────
+
+// holdIfDuplicate is the last step of InitialiseReport. When the session's
+// email paid by card for a near-identical session within the policy window,
+// it records the duplicate and holds the new report so the worker does not
+// generate it. Otherwise report is returned unchanged.
+func (s *Store) holdIfDuplicate(ctx context.Context, q db.Querier, session db.Session, report db.Report) (db.Report, error) {
+	if s.duplicates.Window <= 0 || !session.EmailHash.Valid || !session.StripePaymentIntent.Valid {
+		return report, nil
+	}
+
+	earlier, err := q.GetEarlierCardPurchase(ctx, db.GetEarlierCardPurchaseParams{
+		ID:        session.ID,
+		EmailHash: session.EmailHash.String,
+		Since:     time.Now().Add(-s.duplicates.Window),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return report, nil
+	}
+	if err != nil {
+		return db.Report{}, fmt.Errorf("find earlier purchase: %w", err)
+	}
+	if int(earlier.ChangedAnswers) > s.duplicates.MaxChangedAnswers {
+		return report, nil
+	}
+
+	if _, err := q.CreateDuplicatePurchase(ctx, db.CreateDuplicatePurchaseParams{
+		SessionID:         session.ID,
+		OriginalSessionID: earlier.ID,
+		ChangedAnswers:    earlier.ChangedAnswers,
+	}); err != nil {
+		return db.Report{}, fmt.Errorf("record duplicate purchase: %w", err)
+	}
+	held, err := q.HoldReport(ctx, report.ID)
+	if err != nil {
+		return db.Report{}, fmt.Errorf("hold report: %w", err)
+	}
+	return held, nil
+}
+
+// ResolveDuplicate applies a decision on a held duplicate purchase. It
+// atomically:
+//
+//  1. Records the resolution, failing with ErrDuplicateResolved if another
+//     decision got there first.
+//  2. For DuplicateRefunded, marks the session refunded and revokes its report.
+//  3. For DuplicateCredit, revokes the report; the session stays paid and its
+//     payment is redeemed by RedeemDuplicateCredit.
+//  4. For DuplicateReleased, releases the report for the worker to generate.
+//
+// Any Stripe refund must already have been made. The resolved duplicate and
+// its report are returned.
+func (s *Store) ResolveDuplicate(ctx context.Context, p ResolveDuplicateParams) (db.DuplicatePurchase, db.Report, error) {
+	var (
+		dup    db.DuplicatePurchase
+		report db.Report
+	)
+
+	err := s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		// 1. Record the resolution. Zero rows means it was already resolved.
+		var err error
+		dup, err = q.ResolveDuplicatePurchase(ctx, db.ResolveDuplicatePurchaseParams{
+			SessionID:      p.SessionID,
+			Resolution:     p.Resolution,
+			StripeRefundID: sql.NullString{String: p.StripeRefundID, Valid: p.StripeRefundID != ""},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDuplicateResolved
+		}
+		if err != nil {
+			return fmt.Errorf("ResolveDuplicate: resolve: %w", err)
+		}
+
+		report, err = q.GetReportBySessionID(ctx, p.SessionID)
+		if err != nil {
+			return fmt.Errorf("ResolveDuplicate: get report: %w", err)
+		}
+
+		switch p.Resolution {
+		case DuplicateRefunded:
+			// 2. Refunded.
+			if _, err := q.MarkSessionRefunded(ctx, p.SessionID); err != nil {
+				return fmt.Errorf("ResolveDuplicate: mark session refunded: %w", err)
+			}
+			return revokeDuplicateReport(ctx, q, &report, "duplicate purchase refunded")
+		case DuplicateCredit:
+			// 3. Kept as credit.
+			return revokeDuplicateReport(ctx, q, &report, "duplicate purchase kept as re-assessment credit")
+		case DuplicateReleased:
+			// 4. Released.
+			released, err := q.ReleaseReport(ctx, report.ID)
+			if err != nil {
+				return fmt.Errorf("ResolveDuplicate: release report: %w", err)
+			}
+			report = released
+			return nil
+		default:
+			return fmt.Errorf("ResolveDuplicate: unknown resolution %q", p.Resolution)
+		}
+	})
+	if err != nil {
+		return db.DuplicatePurchase{}, db.Report{}, err
+	}
+
+	return dup, report, nil
+}
+
+// revokeDuplicateReport revokes a held report, leaving one revoked by an
+// operator in the meantime as it is.
+func revokeDuplicateReport(ctx context.Context, q db.Querier, report *db.Report, reason string) error {
+	revoked, err := q.RevokeReport(ctx, db.RevokeReportParams{ID: report.ID, RevokedReason: reason})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ResolveDuplicate: revoke report: %w", err)
+	}
+	*report = revoked
+	return nil
+}
+
+// RedeemDuplicateCredit pays for a session with a duplicate purchase the same
+// email made earlier and was allowed to keep as credit. Like
+// RedeemSubscription it atomically:
+//
+//  1. Finds an unredeemed credit for the email and product.
+//  2. Marks the session paid and links it to the credited session.
+//  3. Creates a new report row in draft status.
+//
+// The unique index on credit_from_session_id means a credit pays for one
+// session only; a concurrent redemption is aborted and on retry finds the
+// credit used, returning ErrNotEntitled.
+func (s *Store) RedeemDuplicateCredit(ctx context.Context, p RedeemSubscriptionParams) (db.Report, error) {
+	var report db.Report
+
+	err := s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		// 1. Credit check.
+		creditFrom, err := q.GetDuplicateCredit(ctx, db.GetDuplicateCreditParams{
+			Email:      p.Email,
+			ProductSku: p.ProductSKU,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotEntitled
+		}
+		if err != nil {
+			return fmt.Errorf("RedeemDuplicateCredit: get credit: %w", err)
+		}
+
+		// 2. Mark session paid. Zero rows means it was already paid.
+		session, err := q.MarkSessionPaidByCredit(ctx, db.MarkSessionPaidByCreditParams{
+			ID:                  p.SessionID,
+			Email:               sql.NullString{String: p.Email, Valid: true},
+			CreditFromSessionID: uuid.NullUUID{UUID: creditFrom, Valid: true},
+			ProductSku:          sql.NullString{String: p.ProductSKU, Valid: p.ProductSKU != ""},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionAlreadyPaid
+		}
+		if err != nil {
+			return fmt.Errorf("RedeemDuplicateCredit: mark session paid: %w", err)
+		}
+
+		// 3. Create draft report.
+		created, err := q.CreateReport(ctx, session.ID)
+		if err != nil {
+			return fmt.Errorf("RedeemDuplicateCredit: create report: %w", err)
+		}
+
+		report = created
+		return nil
+	})
+	if err != nil {
+		return db.Report{}, err
+	}
+
+	return report, nil
+}
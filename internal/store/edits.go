@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// Fields of a report an admin may correct.
+const (
+	FieldAIHedge          = "ai_hedge"
+	FieldExecutiveSummary = "executive_summary"
+)
+
+// ErrReportNotReady is returned by EditAIText for a report that has not been
+// generated yet, or has been revoked.
+var ErrReportNotReady = errors.New("store: report is not ready")
+
+// ─── INPUT TYPES ─────────────────────────────────────────────────────────────
+
+// EditAITextParams is an admin's correction of generated text.
+type EditAITextParams struct {
+	ReportID uuid.UUID
+	Field    string // FieldAIHedge or FieldExecutiveSummary
+	// QuestionID is the risk whose hedge is corrected, for FieldAIHedge.
+	QuestionID string
+	Text       string
+	Editor     string
+	Reason     string
+}
+
+// ─── METHODS ─────────────────────────────────────────────────────────────────
+
+// EditAIText replaces a ready report's executive summary or one risk's AI
+// hedge and records the value it replaced, the editor and the reason in
+// ai_edits, in one transaction. A risk without an AI hedge can be given one;
+// its original is then NULL. sql.ErrNoRows means the report or risk does
+// not exist.
+func (s *Store) EditAIText(ctx context.Context, p EditAITextParams) (db.AiEdit, error) {
+	var edit db.AiEdit
+
+	err := s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		report, err := q.GetReportByID(ctx, p.ReportID)
+		if err != nil {
+			return fmt.Errorf("EditAIText: get report: %w", err)
+		}
+		if report.Status != db.ReportStatusReady || report.RevokedAt.Valid {
+			return ErrReportNotReady
+		}
+
+		params := db.InsertAIEditParams{
+			ReportID: p.ReportID,
+			Field:    p.Field,
+			Edited:   p.Text,
+			Editor:   p.Editor,
+			Reason:   p.Reason,
+		}
+		text := sql.NullString{String: p.Text, Valid: true}
+		switch p.Field {
+		case FieldExecutiveSummary:
+			params.Original = report.ExecutiveSummary
+			if _, err := q.EditExecutiveSummary(ctx, db.EditExecutiveSummaryParams{ID: report.ID, ExecutiveSummary: text}); err != nil {
+				return fmt.Errorf("EditAIText: edit executive summary: %w", err)
+			}
+		case FieldAIHedge:
+			risk, err := q.GetRiskResultByQuestion(ctx, db.GetRiskResultByQuestionParams{ReportID: report.ID, QuestionID: p.QuestionID})
+			if err != nil {
+				return fmt.Errorf("EditAIText: get risk %q: %w", p.QuestionID, err)
+			}
+			params.QuestionID = sql.NullString{String: p.QuestionID, Valid: true}
+			params.Original = risk.AiHedge
+			if _, err := q.EditAIHedge(ctx, db.EditAIHedgeParams{ID: risk.ID, AiHedge: text}); err != nil {
+				return fmt.Errorf("EditAIText: edit AI hedge %q: %w", p.QuestionID, err)
+			}
+		default:
+			return fmt.Errorf("EditAIText: unknown field %q", p.Field)
+		}
+
+		edit, err = q.InsertAIEdit(ctx, params)
+		if err != nil {
+			return fmt.Errorf("EditAIText: record edit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return db.AiEdit{}, err
+	}
+
+	return edit, nil
+}
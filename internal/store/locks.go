@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ErrSessionBusy is returned by LockSession when another job already holds
+// the session's lock.
+var ErrSessionBusy = errors.New("store: session is locked by another job")
+
+// LockSession takes a session-scoped lock for a job that writes the session's
+// report, so two jobs — a report requeued while its earlier run is still
+// going, say, and the poller's — never generate it at once. It does not wait:
+// a session already locked, on any replica, returns ErrSessionBusy.
+//
+// The lock is a Postgres advisory lock, held on a connection of its own until
+// unlock is called, so each locked session keeps one pool connection busy. If
+// the process dies the connection closes and the lock goes with it.
+func (s *Store) LockSession(ctx context.Context, sessionID uuid.UUID) (unlock func(), err error) {
+	conn, err := s.pool.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("LockSession: get connection: %w", err)
+	}
+	q := db.New(conn)
+
+	locked, err := q.TryLockSession(ctx, sessionID)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("LockSession: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrSessionBusy
+	}
+
+	return func() {
+		// The job's context may be done by now; the unlock must still run, or
+		// the connection goes back to the pool holding the lock.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if _, err := q.UnlockSession(ctx, sessionID); err != nil {
+			// Unlocking failed, so discard the connection rather than return
+			// it to the pool still locked; closing it releases the lock.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
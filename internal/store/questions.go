@@ -0,0 +1,35 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// UpsertQuestionDefinitions writes every definition in one transaction, so a
+// questionnaire change is applied completely or not at all. The rows being
+// written are parked at negative display orders first, which lets questions
+// swap places without violating the (section_id, display_order,
+// question_version) unique index mid-transaction.
+func (s *Store) UpsertQuestionDefinitions(ctx context.Context, defs []db.UpsertQuestionDefinitionParams) error {
+	if len(defs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(defs))
+	for i, d := range defs {
+		ids[i] = d.ID
+	}
+
+	return s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		if err := q.ParkQuestionDisplayOrders(ctx, ids); err != nil {
+			return fmt.Errorf("UpsertQuestionDefinitions: park display orders: %w", err)
+		}
+		for _, d := range defs {
+			if _, err := q.UpsertQuestionDefinition(ctx, d); err != nil {
+				return fmt.Errorf("UpsertQuestionDefinitions: upsert %q: %w", d.ID, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── READ REPLICA ─────────────────────────────────────────────────────────────
+
+// replicaQuerier sends the reads behind GET /api/report — the access-token
+// lookup and the risk results, read on every poll of every open report page —
+// to a read replica, and every other query to the primary it embeds.
+//
+// A replica error falls back to the primary, and so does a miss: a token or
+// a report's risks missing from the replica may just not be replicated yet.
+//
+// A lagging replica must not serve a report after it is revoked or its token
+// rotated, so a token's row is only taken from the replica once the primary
+// has confirmed it in the same state — the same status, revoked or not. Any
+// other state, or a token not yet confirmed, is read again from the primary.
+// A page polling a report so costs the primary one read per status change.
+// Revoking or rotating through this Querier forgets the token at once; done
+// elsewhere, the replica's row stops matching as soon as the change reaches
+// it.
+type replicaQuerier struct {
+	db.Querier
+	replica   db.Querier
+	confirmed *tokenStates
+}
+
+func (q replicaQuerier) GetReportByAccessToken(ctx context.Context, accessToken string) (db.GetReportByAccessTokenRow, error) {
+	row, err := q.replica.GetReportByAccessToken(ctx, accessToken)
+	if err == nil && q.confirmed.matches(accessToken, stateOf(row)) {
+		return row, nil
+	}
+	row, err = q.Querier.GetReportByAccessToken(ctx, accessToken)
+	switch {
+	case err == nil:
+		q.confirmed.set(accessToken, stateOf(row))
+	case errors.Is(err, sql.ErrNoRows):
+		q.confirmed.forget(accessToken)
+	}
+	return row, err
+}
+
+func (q replicaQuerier) RotateReportAccessToken(ctx context.Context, arg db.RotateReportAccessTokenParams) (string, error) {
+	token, err := q.Querier.RotateReportAccessToken(ctx, arg)
+	if err == nil {
+		q.confirmed.forget(arg.AccessToken)
+	}
+	return token, err
+}
+
+func (q replicaQuerier) RevokeReport(ctx context.Context, arg db.RevokeReportParams) (db.Report, error) {
+	report, err := q.Querier.RevokeReport(ctx, arg)
+	if err == nil {
+		q.confirmed.forget(report.AccessToken)
+	}
+	return report, err
+}
+
+func (q replicaQuerier) GetRiskResultsByReport(ctx context.Context, reportID uuid.UUID) ([]db.RiskResult, error) {
+	results, err := q.replica.GetRiskResultsByReport(ctx, reportID)
+	if err == nil && len(results) > 0 {
+		return results, nil
+	}
+	return q.Querier.GetRiskResultsByReport(ctx, reportID)
+}
+
+// reportState is what the primary has to confirm before a token's row is
+// read from the replica.
+type reportState struct {
+	status  db.ReportStatus
+	revoked bool
+}
+
+func stateOf(row db.GetReportByAccessTokenRow) reportState {
+	return reportState{status: row.Status, revoked: row.RevokedAt.Valid}
+}
+
+// maxConfirmedTokens bounds tokenStates. Past it the set starts over, which
+// costs each open report page one more primary read.
+const maxConfirmedTokens = 10000
+
+// tokenStates holds each access token's state as last read from the primary.
+type tokenStates struct {
+	mu     sync.Mutex
+	states map[string]reportState
+}
+
+func (t *tokenStates) matches(token string, s reportState) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	confirmed, ok := t.states[token]
+	return ok && confirmed == s
+}
+
+func (t *tokenStates) set(token string, s reportState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.states[token]; !ok && len(t.states) >= maxConfirmedTokens {
+		t.states = make(map[string]reportState)
+	}
+	t.states[token] = s
+}
+
+func (t *tokenStates) forget(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, token)
+}
+
+// SetReplica sets the Querier of a read replica for ReplicaQ. The default,
+// nil, reads everything from the primary. Call it before the Store is shared.
+func (s *Store) SetReplica(q db.Querier) {
+	s.replica = q
+	s.confirmed = &tokenStates{states: make(map[string]reportState)}
+}
+
+// ReplicaQ is Q with the report reads of GET /api/report — the access-token
+// lookup and the risk results — taken from the replica set by SetReplica,
+// falling back to the primary. Use it where those may be a moment stale —
+// the API, not the worker that has just written the report.
+func (s *Store) ReplicaQ() db.Querier {
+	if s.replica == nil {
+		return s.q
+	}
+	return replicaQuerier{Querier: s.q, replica: codecQuerier{Querier: s.replica, c: s.codec}, confirmed: s.confirmed}
+}
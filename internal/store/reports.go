@@ -20,9 +20,26 @@ import (
 type PersistScoredReportParams struct {
 	ReportID         uuid.UUID
 	Risks            []scoring.ScoredRisk // sorted, ranked — from scoring.ComputeRisks
+	ScoreProfile     scoring.Profile      // zero value is the plain mean
 	AIHedges         map[string]string    // question_id → AI-generated hedge text; may be nil
 	ExecutiveSummary string               // AI-generated; empty string is fine
 	TopPriorityHTML  string               // AI-generated; empty string is fine
+	AIAnalysis       json.RawMessage      // analysis stage output; nil if it did not run
+	AINarrative      json.RawMessage      // narrative stage output; nil if it did not run
+	Relationships    json.RawMessage      // AI-identified links between risks; nil if none
+	AIQualityIssues  string               // why AI output was rejected; empty if none was
+	AIBudgetNote     string               // what the AI token budget cut; empty if nothing
+	Flags            map[string]bool      // feature flag → on for this report; may be nil
+	SkippedQuestions []string             // from scoring.SkippedQuestions; may be nil
+}
+
+// RedeemSubscriptionParams identifies the session a customer wants covered by
+// their subscription, or by a duplicate purchase kept as credit, instead of a
+// card payment.
+type RedeemSubscriptionParams struct {
+	SessionID  uuid.UUID
+	Email      string
+	ProductSKU string
 }
 
 // ─── ERRORS ──────────────────────────────────────────────────────────────────
@@ -33,6 +50,14 @@ type PersistScoredReportParams struct {
 // not create a second report.
 var ErrReportAlreadyExists = errors.New("store: report already exists for session")
 
+// ErrNotEntitled is returned by RedeemSubscription when the email has no live
+// subscription, or it has already covered a report this billing period.
+var ErrNotEntitled = errors.New("store: no subscription entitlement available")
+
+// ErrSessionAlreadyPaid is returned by RedeemSubscription when the session has
+// already been paid for, by card or by an earlier redemption.
+var ErrSessionAlreadyPaid = errors.New("store: session already paid")
+
 // ─── METHODS ─────────────────────────────────────────────────────────────────
 
 // InitialiseReport is called by the Stripe webhook handler on
@@ -41,6 +66,9 @@ var ErrReportAlreadyExists = errors.New("store: report already exists for sessio
 //  1. Marks the session as paid.
 //  2. Checks whether a report row already exists (idempotency guard).
 //  3. Creates a new report row in draft status.
+//  4. Holds it instead when the payment duplicates an earlier one by the same
+//     email under the DuplicatePolicy; the returned report has HeldAt set and
+//     must not be enqueued.
 //
 // If the session was already marked paid and a report already exists (duplicate
 // webhook delivery), ErrReportAlreadyExists is returned. The caller should log
@@ -82,6 +110,11 @@ func (s *Store) InitialiseReport(ctx context.Context, stripePaymentIntent string
 			return fmt.Errorf("InitialiseReport: create report: %w", err)
 		}
 
+		// 4. Hold it if the purchase is a duplicate.
+		if created, err = s.holdIfDuplicate(ctx, q, session, created); err != nil {
+			return fmt.Errorf("InitialiseReport: %w", err)
+		}
+
 		report = created
 		return nil
 	})
@@ -96,6 +129,60 @@ func (s *Store) InitialiseReport(ctx context.Context, stripePaymentIntent string
 	return report, nil
 }
 
+// RedeemSubscription pays for a session with the subscriber's quarterly
+// re-assessment entitlement. It atomically:
+//
+//  1. Finds a live subscription for the email with no report this period.
+//  2. Marks the session paid and links it to the subscription.
+//  3. Creates a new report row in draft status.
+//
+// Serializable isolation makes the entitlement check and the redemption a
+// single step, so two sessions redeemed concurrently cannot both use the same
+// period's allowance — one of them is aborted, and on retry finds the
+// allowance used and returns ErrNotEntitled.
+func (s *Store) RedeemSubscription(ctx context.Context, p RedeemSubscriptionParams) (db.Report, error) {
+	var report db.Report
+
+	err := s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		// 1. Entitlement check.
+		sub, err := q.GetEntitledSubscription(ctx, p.Email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotEntitled
+		}
+		if err != nil {
+			return fmt.Errorf("RedeemSubscription: get entitled subscription: %w", err)
+		}
+
+		// 2. Mark session paid. Zero rows means it was already paid.
+		session, err := q.MarkSessionPaidBySubscription(ctx, db.MarkSessionPaidBySubscriptionParams{
+			ID:             p.SessionID,
+			Email:          sql.NullString{String: p.Email, Valid: true},
+			SubscriptionID: uuid.NullUUID{UUID: sub.ID, Valid: true},
+			ProductSku:     sql.NullString{String: p.ProductSKU, Valid: p.ProductSKU != ""},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionAlreadyPaid
+		}
+		if err != nil {
+			return fmt.Errorf("RedeemSubscription: mark session paid: %w", err)
+		}
+
+		// 3. Create draft report.
+		created, err := q.CreateReport(ctx, session.ID)
+		if err != nil {
+			return fmt.Errorf("RedeemSubscription: create report: %w", err)
+		}
+
+		report = created
+		return nil
+	})
+	if err != nil {
+		return db.Report{}, err
+	}
+
+	return report, nil
+}
+
 // PersistScoredReport is called by the background worker once scoring and AI
 // hedge generation are complete. It atomically:
 //
@@ -129,6 +216,13 @@ func (s *Store) PersistScoredReport(ctx context.Context, p PersistScoredReportPa
 		resultIDs := make(map[string]uuid.UUID, len(p.Risks)) // question_id → risk_result.id
 
 		for _, risk := range p.Risks {
+			explanation := risk.Explanation
+			explanation.ScoreProfile = p.ScoreProfile.String()
+			explanation.Weight = p.ScoreProfile.Weight(risk.Tier)
+			explanationJSON, err := json.Marshal(explanation)
+			if err != nil {
+				return fmt.Errorf("PersistScoredReport: marshal explanation %q: %w", risk.QuestionID, err)
+			}
 			row, err := q.InsertRiskResult(ctx, db.InsertRiskResultParams{
 				ReportID:    p.ReportID,
 				QuestionID:  risk.QuestionID,
@@ -141,6 +235,7 @@ func (s *Store) PersistScoredReport(ctx context.Context, p PersistScoredReportPa
 				Tier:        db.RiskTier(risk.Tier), // scoring.RiskTier and db.RiskTier share string values
 				Hedge:       risk.Hedge,
 				Section:     risk.Section,
+				Explanation: pqtype.NullRawMessage{RawMessage: explanationJSON, Valid: true},
 			})
 			if err != nil {
 				return fmt.Errorf("PersistScoredReport: insert risk %q: %w", risk.QuestionID, err)
@@ -172,7 +267,7 @@ func (s *Store) PersistScoredReport(ctx context.Context, p PersistScoredReportPa
 		}
 
 		// 4. Compute aggregate stats and serialise the risks snapshot.
-		overallScore := scoring.OverallScore(p.Risks)
+		overallScore := p.ScoreProfile.OverallScore(p.Risks)
 		criticalCount := scoring.CriticalCount(p.Risks)
 
 		risksJSON, err := json.Marshal(p.Risks)
@@ -180,6 +275,13 @@ func (s *Store) PersistScoredReport(ctx context.Context, p PersistScoredReportPa
 			return fmt.Errorf("PersistScoredReport: marshal risks JSON: %w", err)
 		}
 
+		var flagsJSON json.RawMessage
+		if p.Flags != nil {
+			if flagsJSON, err = json.Marshal(p.Flags); err != nil {
+				return fmt.Errorf("PersistScoredReport: marshal flags: %w", err)
+			}
+		}
+
 		finalised, err := q.FinalizeReport(ctx, db.FinalizeReportParams{
 			ID:            p.ReportID,
 			OverallScore:  sql.NullInt16{Int16: int16(overallScore), Valid: true},
@@ -196,6 +298,31 @@ func (s *Store) PersistScoredReport(ctx context.Context, p PersistScoredReportPa
 				String: p.TopPriorityHTML,
 				Valid:  p.TopPriorityHTML != "",
 			},
+			AiAnalysis: pqtype.NullRawMessage{
+				RawMessage: p.AIAnalysis,
+				Valid:      len(p.AIAnalysis) > 0,
+			},
+			AiNarrative: pqtype.NullRawMessage{
+				RawMessage: p.AINarrative,
+				Valid:      len(p.AINarrative) > 0,
+			},
+			Relationships: pqtype.NullRawMessage{
+				RawMessage: p.Relationships,
+				Valid:      len(p.Relationships) > 0,
+			},
+			AiQualityIssues: sql.NullString{
+				String: p.AIQualityIssues,
+				Valid:  p.AIQualityIssues != "",
+			},
+			AiBudgetNote: sql.NullString{
+				String: p.AIBudgetNote,
+				Valid:  p.AIBudgetNote != "",
+			},
+			Flags: pqtype.NullRawMessage{
+				RawMessage: flagsJSON,
+				Valid:      len(flagsJSON) > 0,
+			},
+			SkippedQuestions: p.SkippedQuestions,
 		})
 		if err != nil {
 			return fmt.Errorf("PersistScoredReport: finalize report: %w", err)
@@ -229,4 +356,45 @@ func (s *Store) MarkReportFailed(ctx context.Context, reportID uuid.UUID, reason
 		return db.Report{}, fmt.Errorf("MarkReportFailed: %w", err)
 	}
 	return report, nil
-}
\ No newline at end of file
+}
+// RequeueReport discards a report's generated risk rows and returns it to
+// draft, so the worker's poller generates it from scratch on its next tick.
+// Used by operators (armctl requeue-report) to retry a report that failed
+// permanently or was generated from a bad scoring config.
+func (s *Store) RequeueReport(ctx context.Context, reportID uuid.UUID) (db.Report, error) {
+	return s.requeueReport(ctx, reportID, false)
+}
+
+// RequeueReportNarrative is RequeueReport for a new AI narrative only: the
+// report keeps its stored AI analysis, so the worker skips the analysis stage
+// and writes the narrative from it (armctl requeue-report -narrative). A
+// report without a stored analysis is generated in full.
+func (s *Store) RequeueReportNarrative(ctx context.Context, reportID uuid.UUID) (db.Report, error) {
+	return s.requeueReport(ctx, reportID, true)
+}
+
+func (s *Store) requeueReport(ctx context.Context, reportID uuid.UUID, keepAnalysis bool) (db.Report, error) {
+	var report db.Report
+
+	err := s.withTx(ctx, func(ctx context.Context, q db.Querier) error {
+		if _, err := q.DeleteRiskResultsByReport(ctx, reportID); err != nil {
+			return fmt.Errorf("RequeueReport: delete risk results: %w", err)
+		}
+		requeue := q.RequeueReport
+		if keepAnalysis {
+			requeue = q.RequeueReportNarrative
+		}
+		requeued, err := requeue(ctx, reportID)
+		if err != nil {
+			return fmt.Errorf("RequeueReport: reset status: %w", err)
+		}
+		report = requeued
+		return nil
+	})
+
+	if err != nil {
+		return db.Report{}, err
+	}
+
+	return report, nil
+}
@@ -12,13 +12,26 @@ import (
 
 // ─── INPUT TYPES ─────────────────────────────────────────────────────────────
 
-// AttachPaymentIntentParams groups the Stripe and email fields written
-// together when checkout is initiated.
+// AttachPaymentIntentParams groups the Stripe, email, product and tax fields
+// written together when checkout is initiated.
 type AttachPaymentIntentParams struct {
 	SessionID           uuid.UUID
 	StripeCustomerID    string
 	StripePaymentIntent string
 	Email               string
+	ProductSKU          string
+	BillingCountry      string
+	BillingPostalCode   string
+	SubtotalCents       int32
+	TaxCents            int32
+	TaxCalculationID    string // empty when Stripe Tax is disabled
+
+	// Invoice details; all optional.
+	BillingName         string
+	BillingAddressLine1 string
+	BillingAddressLine2 string
+	BillingCity         string
+	BillingTaxID        string
 }
 
 // ─── ERRORS ──────────────────────────────────────────────────────────────────
@@ -32,7 +45,8 @@ var ErrPaymentIntentAlreadyAttached = errors.New("store: payment intent already
 // ─── METHODS ─────────────────────────────────────────────────────────────────
 
 // AttachPaymentIntent atomically guards against double-attachment of a Stripe
-// PaymentIntent to a session, then writes the customer ID, PI, and email.
+// PaymentIntent to a session, then writes the customer ID, PI, email,
+// product SKU and the amounts the PI was priced at.
 //
 // Race scenario without this guard:
 //  1. Two browser tabs call POST /checkout simultaneously.
@@ -40,8 +54,9 @@ var ErrPaymentIntentAlreadyAttached = errors.New("store: payment intent already
 //  3. Both try to write — the second write silently overwrites the first PI,
 //     orphaning a Stripe PaymentIntent that will never be confirmed.
 //
-// With serializable isolation the second concurrent transaction will see the
-// first commit and hit the already-attached check, returning
+// With serializable isolation the second concurrent transaction either sees
+// the first commit or is aborted and retried by withTx; either way it hits the
+// already-attached check, returning
 // ErrPaymentIntentAlreadyAttached. The handler then reads the existing PI from
 // the session and returns its client_secret — no orphaned object, no charge.
 func (s *Store) AttachPaymentIntent(ctx context.Context, p AttachPaymentIntentParams) (db.Session, error) {
@@ -77,6 +92,29 @@ func (s *Store) AttachPaymentIntent(ctx context.Context, p AttachPaymentIntentPa
 				String: p.Email,
 				Valid:  p.Email != "",
 			},
+			ProductSku: sql.NullString{
+				String: p.ProductSKU,
+				Valid:  p.ProductSKU != "",
+			},
+			BillingCountry: sql.NullString{
+				String: p.BillingCountry,
+				Valid:  p.BillingCountry != "",
+			},
+			BillingPostalCode: sql.NullString{
+				String: p.BillingPostalCode,
+				Valid:  p.BillingPostalCode != "",
+			},
+			SubtotalCents: sql.NullInt32{Int32: p.SubtotalCents, Valid: true},
+			TaxCents:      sql.NullInt32{Int32: p.TaxCents, Valid: true},
+			TaxCalculationID: sql.NullString{
+				String: p.TaxCalculationID,
+				Valid:  p.TaxCalculationID != "",
+			},
+			BillingName:         nullString(p.BillingName),
+			BillingAddressLine1: nullString(p.BillingAddressLine1),
+			BillingAddressLine2: nullString(p.BillingAddressLine2),
+			BillingCity:         nullString(p.BillingCity),
+			BillingTaxID:        nullString(p.BillingTaxID),
 		})
 		if err != nil {
 			return fmt.Errorf("AttachPaymentIntent: attach stripe customer: %w", err)
@@ -96,4 +134,9 @@ func (s *Store) AttachPaymentIntent(ctx context.Context, p AttachPaymentIntentPa
 	}
 
 	return session, nil
+}
+
+// nullString maps "" to NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
\ No newline at end of file
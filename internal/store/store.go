@@ -5,38 +5,117 @@
 // called directly on db.Querier in handlers — there is no value in proxying
 // them through this package.
 //
-// Dependency rule: store imports db only. It never imports api, worker,
-// scoring, ai, or email.
+// Sensitive columns are encrypted and decrypted here, by a codec wrapped
+// around the Querier (see codec.go), so Q() and transactions only ever hand
+// out plaintext.
+//
+// Dependency rule: store imports db, plus the requestid context helper and
+// fieldcrypt. It never imports api, worker, scoring, ai, or email.
 package store
 
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
 )
 
+// applicationName prefixes the request ID in a transaction's Postgres
+// application_name.
+const applicationName = "arm"
+
+// DefaultTxAttempts is how many times withTx runs a transaction that Postgres
+// aborts with a serialization failure or deadlock before returning the error.
+const DefaultTxAttempts = 3
+
+// maxTxBackoff caps the wait between transaction attempts.
+const maxTxBackoff = 200 * time.Millisecond
+
 // Store holds a *sql.DB for starting transactions and a db.Querier for
-// executing queries outside of transactions. The two operation files
-// (sessions.go, reports.go) attach methods to this type.
+// executing queries outside of transactions. The operation files
+// (sessions.go, reports.go, questions.go) attach methods to this type.
 type Store struct {
 	// pool is the raw connection pool, used only to begin transactions.
 	pool *sql.DB
 
 	// q is the Querier used for non-transactional calls. Handlers that hold a
 	// *Store can also access it directly via store.Q() for single-query reads.
+	// It is raw wrapped in the codec.
 	q db.Querier
+
+	// raw is the Querier New was given, which sees ciphertext.
+	raw db.Querier
+
+	// codec encrypts sensitive columns; see SetCodec.
+	codec *fieldcrypt.Codec
+
+	// replica is the read replica's raw Querier, or nil; see SetReplica.
+	replica db.Querier
+
+	// confirmed is what ReplicaQ has read from the primary per access token;
+	// see replicaQuerier.
+	confirmed *tokenStates
+
+	// txAttempts bounds withTx's retries; see SetTxAttempts.
+	txAttempts int
+
+	// duplicates decides which purchases InitialiseReport holds; see
+	// SetDuplicatePolicy.
+	duplicates DuplicatePolicy
+
+	// txWrap wraps each transaction's handle, or is nil; see SetTxWrapper.
+	txWrap func(db.DBTX) db.DBTX
 }
 
 // New creates a Store from a live connection pool. The pool must already be
 // open and verified (e.g. via db.PingContext) before calling New.
 func New(pool *sql.DB, q db.Querier) *Store {
-	return &Store{pool: pool, q: q}
+	s := &Store{pool: pool, raw: q, txAttempts: DefaultTxAttempts}
+	s.SetCodec(nil)
+	return s
+}
+
+// SetCodec sets the codec for sensitive columns. The default, nil, stores
+// plaintext but still maintains the email_hash indexes. Call it before the
+// Store is shared.
+func (s *Store) SetCodec(c *fieldcrypt.Codec) {
+	s.codec = c
+	s.q = codecQuerier{Querier: s.raw, c: c}
+}
+
+// SetTxAttempts sets how many times a transaction that fails with a
+// serialization failure or deadlock is run in total. Values below 1 are
+// treated as 1, which disables retrying. Call it before the Store is shared.
+func (s *Store) SetTxAttempts(n int) {
+	s.txAttempts = max(n, 1)
+}
+
+// SetDuplicatePolicy sets when InitialiseReport holds a report as a
+// duplicate purchase. The zero policy, the default, never does. Call it before
+// the Store is shared.
+func (s *Store) SetDuplicatePolicy(p DuplicatePolicy) {
+	s.duplicates = p
 }
 
-// Q exposes the underlying Querier so callers (handlers, worker) can run
-// single-query reads without going through a store method.
+// SetTxWrapper sets a function that wraps the handle of every transaction,
+// so its statements are timed and counted like the pool's (see querywatch).
+// The default, nil, runs them on the *sql.Tx directly. Call it before the
+// Store is shared.
+func (s *Store) SetTxWrapper(wrap func(db.DBTX) db.DBTX) {
+	s.txWrap = wrap
+}
+
+// Q exposes the Querier so callers (handlers, worker) can run single-query
+// reads without going through a store method. Sensitive columns are already
+// decrypted; use it rather than the db.Queries the Store was built from.
 //
 //	session, err := s.Q().GetSessionByID(ctx, id)
 func (s *Store) Q() db.Querier {
@@ -54,7 +133,44 @@ type txQuerier func(ctx context.Context, q db.Querier) error
 // operations involve a read-then-write pattern (checking for existing rows
 // before inserting). Callers that need a different isolation level should open
 // their own transaction.
+//
+// Under serializable isolation Postgres aborts one of two conflicting
+// transactions with SQLSTATE 40001, expecting the client to retry. withTx does
+// so, running fn again from the start in a new transaction, up to txAttempts
+// times with a short jittered back-off. fn must therefore only touch the
+// database, and must reset anything it captures on each run.
 func (s *Store) withTx(ctx context.Context, fn txQuerier) error {
+	backoff := 10 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, fn)
+		if err == nil || attempt >= s.txAttempts || !isRetryableTxErr(err) {
+			return err
+		}
+
+		// Full jitter, so transactions that collided once do not collide
+		// again on the same schedule.
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(rand.N(backoff) + time.Millisecond):
+		}
+		backoff = min(backoff*2, maxTxBackoff)
+	}
+}
+
+// isRetryableTxErr reports whether err is Postgres aborting the transaction
+// for a conflict that running it again can resolve: a serialization failure
+// (40001) or a deadlock (40P01).
+func isRetryableTxErr(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// runTx is one attempt of withTx.
+func (s *Store) runTx(ctx context.Context, fn txQuerier) error {
 	tx, err := s.pool.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
@@ -70,8 +186,22 @@ func (s *Store) withTx(ctx context.Context, fn txQuerier) error {
 		}
 	}()
 
+	// Tag the transaction with the request ID so it can be found in
+	// pg_stat_activity and the Postgres logs (%a in log_line_prefix).
+	if id := requestid.From(ctx); id != "" {
+		if _, err := tx.ExecContext(ctx, "SELECT set_config('application_name', $1, true)", applicationName+"/"+id); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("store: tag transaction: %w", err)
+		}
+	}
+
 	// db.Queries.WithTx re-uses prepared statements scoped to the transaction.
-	txQ := s.q.(*db.Queries).WithTx(tx)
+	// A wrapped handle gets fresh Queries instead: the wrapper sees only
+	// statements sent through it, and none are prepared on the pool anyway.
+	txQ := codecQuerier{Querier: s.raw.(*db.Queries).WithTx(tx), c: s.codec}
+	if s.txWrap != nil {
+		txQ.Querier = db.New(s.txWrap(tx))
+	}
 
 	if err := fn(ctx, txQ); err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
@@ -0,0 +1,862 @@
+//go:build integration
+
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/fieldcrypt"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/testdb"
+)
+
+// ─── TEST INFRASTRUCTURE ──────────────────────────────────────────────────────
+
+func TestMain(m *testing.M) { os.Exit(testdb.Main(m)) }
+
+// openTestDB returns a *sql.DB on DATABASE_URL or, when it is not set, on a
+// throwaway Postgres container. Fails when neither is available; these tests
+// only build with -tags integration.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	return testdb.Open(t)
+}
+
+// ensureQuestion inserts a placeholder question_definitions row for id unless
+// one exists, so risk_results can reference it on a freshly migrated database.
+func ensureQuestion(t *testing.T, ctx context.Context, pool *sql.DB, id string) {
+	t.Helper()
+	_, err := pool.ExecContext(ctx, `
+		INSERT INTO question_definitions
+			(id, section_id, section_title, display_order, text, type, risk_name, risk_desc, hedge, scoring_config)
+		VALUES ($1, 'snapshot', 'Snapshot', 999, 'Test question', 'text', 'Test risk', '-', '-', '{}')
+		ON CONFLICT DO NOTHING`, id)
+	if err != nil {
+		t.Fatalf("seed question %s: %v", id, err)
+	}
+}
+
+// withRollback runs fn inside a transaction that is always rolled back,
+// leaving the database clean after each test.
+func withRollback(t *testing.T, pool *sql.DB, fn func(ctx context.Context, q db.Querier, st *store.Store)) {
+	t.Helper()
+	ctx := context.Background()
+
+	// The store uses serializable transactions internally, so we open a
+	// plain read-committed wrapper here just to seed data that we roll back.
+	tx, err := pool.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin rollback tx: %v", err)
+	}
+	t.Cleanup(func() { _ = tx.Rollback() })
+
+	q := db.New(pool).WithTx(tx)
+	// The store is given the outer pool so its internal transactions can see
+	// the seeded rows (same connection via the shared pool in tests).
+	st := store.New(pool, db.New(pool))
+
+	fn(ctx, q, st)
+}
+
+// seedSession inserts a minimal anonymous session and returns it.
+func seedSession(t *testing.T, ctx context.Context, q db.Querier, suffix string) db.Session {
+	t.Helper()
+	s, err := q.CreateSession(ctx, db.CreateSessionParams{
+		AnonToken: fmt.Sprintf("test_token_%s_%s", t.Name(), suffix),
+	})
+	if err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	return s
+}
+
+// attachPI attaches a fake Stripe PI to a session so InitialiseReport can
+// call MarkSessionPaid, which looks up the session by stripe_payment_intent.
+func attachPI(t *testing.T, ctx context.Context, q db.Querier, sessionID uuid.UUID, piID string) {
+	t.Helper()
+	_, err := q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  sessionID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+		Email:               sql.NullString{String: "test@example.com", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attachPI: %v", err)
+	}
+}
+
+// ─── AttachPaymentIntent ──────────────────────────────────────────────────────
+
+func TestAttachPaymentIntent_FirstCallSucceeds(t *testing.T) {
+	pool := openTestDB(t)
+
+	ctx := context.Background()
+	q := db.New(pool)
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_attach_first_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID) })
+
+	st := store.New(pool, q)
+	updated, err := st.AttachPaymentIntent(ctx, store.AttachPaymentIntentParams{
+		SessionID:           session.ID,
+		StripeCustomerID:    "cus_test_first",
+		StripePaymentIntent: "pi_test_first_" + t.Name(),
+		Email:               "test@example.com",
+	})
+	if err != nil {
+		t.Fatalf("AttachPaymentIntent: %v", err)
+	}
+	if !updated.StripePaymentIntent.Valid {
+		t.Error("expected StripePaymentIntent to be set")
+	}
+	if updated.Email.String != "test@example.com" {
+		t.Errorf("email: got %q", updated.Email.String)
+	}
+}
+
+func TestAttachPaymentIntent_SecondCallReturnsErrAlreadyAttached(t *testing.T) {
+	pool := openTestDB(t)
+
+	ctx := context.Background()
+	q := db.New(pool)
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_attach_second_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID) })
+
+	st := store.New(pool, q)
+	params := store.AttachPaymentIntentParams{
+		SessionID:           session.ID,
+		StripeCustomerID:    "cus_test",
+		StripePaymentIntent: "pi_test_race_" + t.Name(),
+		Email:               "test@example.com",
+	}
+
+	if _, err := st.AttachPaymentIntent(ctx, params); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	// Second call for same session must return the sentinel error.
+	params.StripePaymentIntent = "pi_test_duplicate_" + t.Name()
+	_, err = st.AttachPaymentIntent(ctx, params)
+	if !errors.Is(err, store.ErrPaymentIntentAlreadyAttached) {
+		t.Errorf("expected ErrPaymentIntentAlreadyAttached, got: %v", err)
+	}
+}
+
+// Concurrent checkouts for one session conflict under serializable isolation.
+// withTx retries the loser, which then sees the winner's PI, so exactly one
+// call succeeds and the rest get the sentinel — never a serialization failure.
+func TestAttachPaymentIntent_ConcurrentCallsRetrySerializationFailures(t *testing.T) {
+	pool := openTestDB(t)
+
+	ctx := context.Background()
+	q := db.New(pool)
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_attach_concurrent_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID) })
+
+	st := store.New(pool, q)
+	st.SetTxAttempts(10)
+
+	const callers = 5
+	errs := make(chan error, callers)
+	for i := range callers {
+		go func() {
+			_, err := st.AttachPaymentIntent(ctx, store.AttachPaymentIntentParams{
+				SessionID:           session.ID,
+				StripePaymentIntent: fmt.Sprintf("pi_test_concurrent_%d_%s", i, t.Name()),
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for range callers {
+		err := <-errs
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, store.ErrPaymentIntentAlreadyAttached):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one call to attach its PI, got %d", succeeded)
+	}
+}
+
+// ─── InitialiseReport ─────────────────────────────────────────────────────────
+
+func TestInitialiseReport_CreatesDraftReport(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_init_draft_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_draft_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+
+	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  session.ID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+		Email:               sql.NullString{String: "x@example.com", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attach pi: %v", err)
+	}
+
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+	if report.ID.String() == "" {
+		t.Error("expected non-empty report ID")
+	}
+	if report.Status != db.ReportStatusDraft {
+		t.Errorf("expected status draft, got %s", report.Status)
+	}
+	if report.SessionID != session.ID {
+		t.Error("session ID mismatch")
+	}
+	if report.AccessToken == "" {
+		t.Error("expected non-empty access token")
+	}
+}
+
+func TestInitialiseReport_DuplicateDeliveryReturnsErrAlreadyExists(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_idem_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_idem_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+
+	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  session.ID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attach pi: %v", err)
+	}
+
+	first, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	second, err := st.InitialiseReport(ctx, piID)
+	if !errors.Is(err, store.ErrReportAlreadyExists) {
+		t.Errorf("expected ErrReportAlreadyExists, got: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("returned report ID mismatch: got %s, want %s", second.ID, first.ID)
+	}
+}
+
+func TestInitialiseReport_MarksSessionPaid(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_paid_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_paid_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+
+	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  session.ID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attach pi: %v", err)
+	}
+
+	if _, err := st.InitialiseReport(ctx, piID); err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+
+	updated, err := q.GetSessionByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	if updated.PaymentStatus != db.PaymentStatusPaid {
+		t.Errorf("expected payment_status=paid, got %s", updated.PaymentStatus)
+	}
+	if !updated.PaidAt.Valid {
+		t.Error("expected paid_at to be set")
+	}
+}
+
+func TestInitialiseReport_HoldsDuplicatePurchaseUntilResolved(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+	st.SetDuplicatePolicy(store.DuplicatePolicy{Window: time.Hour})
+
+	email := "dup-" + uuid.NewString() + "@example.com"
+	var reports []db.Report
+	for i := 0; i < 2; i++ {
+		session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: fmt.Sprintf("tok_dup_%d_%s", i, t.Name())})
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		t.Cleanup(func() {
+			_, _ = pool.ExecContext(ctx, "DELETE FROM duplicate_purchases WHERE session_id=$1", session.ID)
+			_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+			_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+		})
+		piID := fmt.Sprintf("pi_dup_%d_%s", i, t.Name())
+		if _, err := st.AttachPaymentIntent(ctx, store.AttachPaymentIntentParams{
+			SessionID:           session.ID,
+			StripePaymentIntent: piID,
+			Email:               email,
+		}); err != nil {
+			t.Fatalf("AttachPaymentIntent: %v", err)
+		}
+		report, err := st.InitialiseReport(ctx, piID)
+		if err != nil {
+			t.Fatalf("InitialiseReport %d: %v", i, err)
+		}
+		reports = append(reports, report)
+	}
+
+	if reports[0].HeldAt.Valid {
+		t.Error("the first purchase should not be held")
+	}
+	if !reports[1].HeldAt.Valid {
+		t.Fatal("expected the repeat purchase to be held")
+	}
+
+	dup, released, err := st.ResolveDuplicate(ctx, store.ResolveDuplicateParams{
+		SessionID:  reports[1].SessionID,
+		Resolution: store.DuplicateReleased,
+	})
+	if err != nil {
+		t.Fatalf("ResolveDuplicate: %v", err)
+	}
+	if dup.OriginalSessionID != reports[0].SessionID || released.HeldAt.Valid {
+		t.Errorf("unexpected resolution: %+v, held_at=%v", dup, released.HeldAt)
+	}
+
+	_, _, err = st.ResolveDuplicate(ctx, store.ResolveDuplicateParams{
+		SessionID:  reports[1].SessionID,
+		Resolution: store.DuplicateCredit,
+	})
+	if !errors.Is(err, store.ErrDuplicateResolved) {
+		t.Errorf("expected ErrDuplicateResolved, got %v", err)
+	}
+}
+
+// ─── MarkReportFailed ─────────────────────────────────────────────────────────
+
+func TestMarkReportFailed_SetsErrorStatus(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_fail_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_fail_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+
+	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  session.ID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attach pi: %v", err)
+	}
+
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+
+	failed, err := st.MarkReportFailed(ctx, report.ID, "ai service unavailable")
+	if err != nil {
+		t.Fatalf("MarkReportFailed: %v", err)
+	}
+	if failed.Status != db.ReportStatusError {
+		t.Errorf("expected status=error, got %s", failed.Status)
+	}
+	if !failed.ErrorMessage.Valid || failed.ErrorMessage.String != "ai service unavailable" {
+		t.Errorf("error message: %+v", failed.ErrorMessage)
+	}
+}
+
+func TestRequeueReport_ResetsFailedReportToDraft(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_requeue_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_requeue_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+
+	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  session.ID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attach pi: %v", err)
+	}
+
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+	if _, err := st.MarkReportFailed(ctx, report.ID, "ai service unavailable"); err != nil {
+		t.Fatalf("MarkReportFailed: %v", err)
+	}
+
+	requeued, err := st.RequeueReport(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("RequeueReport: %v", err)
+	}
+	if requeued.Status != db.ReportStatusDraft {
+		t.Errorf("expected status=draft, got %s", requeued.Status)
+	}
+	if requeued.ErrorMessage.Valid {
+		t.Errorf("expected error message cleared, got %+v", requeued.ErrorMessage)
+	}
+}
+
+func TestClaimReport_ExcludesOtherReplicasUntilReleased(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_claim_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_claim_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+	attachPI(t, ctx, q, session.ID, piID)
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+
+	claim := func(by string) error {
+		_, err := q.ClaimReport(ctx, db.ClaimReportParams{ClaimedBy: by, LeaseSeconds: 60, ID: report.ID})
+		return err
+	}
+	if err := claim("replica-a"); err != nil {
+		t.Fatalf("first claim: %v", err)
+	}
+	if err := claim("replica-b"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected replica-b to be refused, got %v", err)
+	}
+	if err := claim("replica-a"); err != nil {
+		t.Errorf("expected replica-a to renew its own claim, got %v", err)
+	}
+
+	if err := q.ReleaseReportClaim(ctx, db.ReleaseReportClaimParams{ID: report.ID, ClaimedBy: "replica-a"}); err != nil {
+		t.Fatalf("ReleaseReportClaim: %v", err)
+	}
+	if err := claim("replica-b"); err != nil {
+		t.Errorf("expected replica-b to claim a released report, got %v", err)
+	}
+}
+
+// ─── ImportCohort ─────────────────────────────────────────────────────────────
+
+func TestImportCohort_SchedulesPaidReportsTheWorkerWaitsFor(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+	ensureQuestion(t, ctx, pool, "q_cohort")
+
+	reports, err := st.ImportCohort(ctx, store.ImportCohortParams{
+		Cohort: "test-" + strings.ToLower(t.Name()),
+		Sessions: []store.CohortSession{
+			{AnonToken: "tok_cohort_a_" + t.Name(), Email: "a@example.com", BizName: "Acme", Answers: map[string]string{"q_cohort": "yes"}},
+			{AnonToken: "tok_cohort_b_" + t.Name(), Email: "b@example.com", Answers: map[string]string{"q_cohort": "no"}},
+		},
+		Spacing: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("ImportCohort: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, r := range reports {
+			_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE id=$1", r.ID)
+			_, _ = pool.ExecContext(ctx, "DELETE FROM answers WHERE session_id=$1", r.SessionID)
+			_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", r.SessionID)
+		}
+	})
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	if gap := reports[1].NotBefore.Time.Sub(reports[0].NotBefore.Time); gap != time.Minute {
+		t.Errorf("expected reports a minute apart, got %v", gap)
+	}
+
+	session, err := st.Q().GetSessionByID(ctx, reports[0].SessionID)
+	if err != nil {
+		t.Fatalf("GetSessionByID: %v", err)
+	}
+	if session.PaymentStatus != db.PaymentStatusPaid || session.Cohort.String != "test-"+strings.ToLower(t.Name()) || session.Email.String != "a@example.com" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+	answers, err := q.GetAnswersBySession(ctx, session.ID)
+	if err != nil || len(answers) != 1 || answers[0].AnswerText != "yes" {
+		t.Errorf("unexpected answers: %+v, %v", answers, err)
+	}
+
+	// The second report is a minute out at least, so the poller skips it.
+	pending, err := q.ListPendingReports(ctx)
+	if err != nil {
+		t.Fatalf("ListPendingReports: %v", err)
+	}
+	for _, p := range pending {
+		if p.ID == reports[1].ID {
+			t.Error("expected the scheduled report to wait for its not_before")
+		}
+	}
+}
+
+// ─── PersistScoredReport ──────────────────────────────────────────────────────
+
+func TestPersistScoredReport_FinalizesReport(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_persist_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_persist_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM risk_results WHERE report_id IN (SELECT id FROM reports WHERE session_id=$1)", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+
+	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
+		ID:                  session.ID,
+		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("attach pi: %v", err)
+	}
+
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+
+	// Seed question_definitions the risks reference.
+	ensureQuestion(t, ctx, pool, "q_cash_runway")
+	risks := []scoring.ScoredRisk{
+		{
+			QuestionID: "q_cash_runway",
+			Rank:       1,
+			RiskName:   "Cash Runway Risk",
+			RiskDesc:   "Running out of cash",
+			Hedge:      "Maintain 6+ months runway",
+			Section:    "snapshot",
+			P:          9, I: 9, Score: 81,
+			Tier: scoring.TierWatch,
+		},
+	}
+
+	finalised, err := st.PersistScoredReport(ctx, store.PersistScoredReportParams{
+		ReportID:         report.ID,
+		Risks:            risks,
+		AIHedges:         map[string]string{"q_cash_runway": "AI-generated hedge narrative"},
+		ExecutiveSummary: "High risk posture.",
+		TopPriorityHTML:  "<strong>Act now.</strong>",
+	})
+	if err != nil {
+		t.Fatalf("PersistScoredReport: %v", err)
+	}
+
+	if finalised.Status != db.ReportStatusReady {
+		t.Errorf("expected status=ready, got %s", finalised.Status)
+	}
+	if !finalised.OverallScore.Valid || finalised.OverallScore.Int16 != 81 {
+		t.Errorf("overall score: %+v", finalised.OverallScore)
+	}
+	if !finalised.CriticalCount.Valid || finalised.CriticalCount.Int16 != 1 {
+		t.Errorf("critical count: %+v", finalised.CriticalCount)
+	}
+	if !finalised.ExecutiveSummary.Valid || finalised.ExecutiveSummary.String != "High risk posture." {
+		t.Errorf("executive summary: %+v", finalised.ExecutiveSummary)
+	}
+	if !finalised.GeneratedAt.Valid {
+		t.Error("expected generated_at to be set")
+	}
+}
+
+func TestRequeueReportNarrative_KeepsAIAnalysis(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_narrative_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_narrative_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM risk_results WHERE report_id IN (SELECT id FROM reports WHERE session_id=$1)", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+	attachPI(t, ctx, q, session.ID, piID)
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+
+	ensureQuestion(t, ctx, pool, "q_cash_runway")
+	persist := func() {
+		t.Helper()
+		_, err := st.PersistScoredReport(ctx, store.PersistScoredReportParams{
+			ReportID:    report.ID,
+			Risks:       []scoring.ScoredRisk{{QuestionID: "q_cash_runway", Rank: 1, P: 9, I: 9, Score: 81, Tier: scoring.TierWatch}},
+			AIAnalysis:  json.RawMessage(`{"priorities":["q_cash_runway"],"top_priority":"q_cash_runway","dependencies":[]}`),
+			AINarrative: json.RawMessage(`{"executive_summary":"s","top_priority_html":"t","hedges":{}}`),
+		})
+		if err != nil {
+			t.Fatalf("PersistScoredReport: %v", err)
+		}
+	}
+
+	persist()
+	requeued, err := st.RequeueReportNarrative(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("RequeueReportNarrative: %v", err)
+	}
+	if requeued.Status != db.ReportStatusDraft || !requeued.AiAnalysis.Valid || requeued.AiNarrative.Valid {
+		t.Errorf("expected a draft with its analysis and no narrative, got %+v", requeued)
+	}
+
+	persist()
+	requeued, err = st.RequeueReport(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("RequeueReport: %v", err)
+	}
+	if requeued.AiAnalysis.Valid {
+		t.Errorf("expected a full requeue to clear the analysis, got %s", requeued.AiAnalysis.RawMessage)
+	}
+}
+
+// ─── EditAIText ───────────────────────────────────────────────────────────────
+
+func TestLockSession_RefusesASecondHolderUntilUnlocked(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	st := store.New(pool, db.New(pool))
+	sessionID := uuid.New()
+
+	unlock, err := st.LockSession(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+	if _, err := st.LockSession(ctx, sessionID); !errors.Is(err, store.ErrSessionBusy) {
+		t.Fatalf("second lock: got %v, want ErrSessionBusy", err)
+	}
+	other, err := st.LockSession(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("another session's lock: %v", err)
+	}
+	other()
+
+	unlock()
+	again, err := st.LockSession(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("lock after unlock: %v", err)
+	}
+	again()
+}
+
+func TestEditAIText_RecordsOriginalAndMarksField(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	q := db.New(pool)
+	st := store.New(pool, q)
+
+	piID := "pi_edit_" + t.Name()
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_edit_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.ExecContext(ctx, "DELETE FROM ai_edits WHERE report_id IN (SELECT id FROM reports WHERE session_id=$1)", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM risk_results WHERE report_id IN (SELECT id FROM reports WHERE session_id=$1)", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
+		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
+	})
+	attachPI(t, ctx, q, session.ID, piID)
+	report, err := st.InitialiseReport(ctx, piID)
+	if err != nil {
+		t.Fatalf("InitialiseReport: %v", err)
+	}
+
+	edit := store.EditAITextParams{ReportID: report.ID, Field: store.FieldExecutiveSummary, Text: "Fixed.", Editor: "ops@example.com", Reason: "wrong industry"}
+	if _, err := st.EditAIText(ctx, edit); !errors.Is(err, store.ErrReportNotReady) {
+		t.Fatalf("expected ErrReportNotReady for a draft, got %v", err)
+	}
+
+	ensureQuestion(t, ctx, pool, "q_cash_runway")
+	_, err = st.PersistScoredReport(ctx, store.PersistScoredReportParams{
+		ReportID:         report.ID,
+		Risks:            []scoring.ScoredRisk{{QuestionID: "q_cash_runway", Rank: 1, P: 9, I: 9, Score: 81, Tier: scoring.TierWatch}},
+		AIHedges:         map[string]string{"q_cash_runway": "Raise a bridge round."},
+		ExecutiveSummary: "A retail business.",
+	})
+	if err != nil {
+		t.Fatalf("PersistScoredReport: %v", err)
+	}
+
+	got, err := st.EditAIText(ctx, edit)
+	if err != nil {
+		t.Fatalf("EditAIText summary: %v", err)
+	}
+	if got.Original.String != "A retail business." || got.Edited != "Fixed." || got.Editor != "ops@example.com" {
+		t.Errorf("unexpected summary edit %+v", got)
+	}
+	edited, err := q.GetReportByID(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("GetReportByID: %v", err)
+	}
+	if edited.ExecutiveSummary.String != "Fixed." || !edited.ExecutiveSummaryEditedAt.Valid {
+		t.Errorf("expected the summary replaced and marked edited, got %+v", edited)
+	}
+
+	got, err = st.EditAIText(ctx, store.EditAITextParams{ReportID: report.ID, Field: store.FieldAIHedge, QuestionID: "q_cash_runway", Text: "Cut costs.", Editor: "ops@example.com", Reason: "unrealistic"})
+	if err != nil {
+		t.Fatalf("EditAIText hedge: %v", err)
+	}
+	if got.Original.String != "Raise a bridge round." || got.QuestionID.String != "q_cash_runway" {
+		t.Errorf("unexpected hedge edit %+v", got)
+	}
+	risk, err := q.GetRiskResultByQuestion(ctx, db.GetRiskResultByQuestionParams{ReportID: report.ID, QuestionID: "q_cash_runway"})
+	if err != nil {
+		t.Fatalf("GetRiskResultByQuestion: %v", err)
+	}
+	if risk.AiHedge.String != "Cut costs." || !risk.AiHedgeEditedAt.Valid {
+		t.Errorf("expected the hedge replaced and marked edited, got %+v", risk)
+	}
+
+	_, err = st.EditAIText(ctx, store.EditAITextParams{ReportID: report.ID, Field: store.FieldAIHedge, QuestionID: "q_unknown", Text: "x", Editor: "e", Reason: "r"})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for an unknown risk, got %v", err)
+	}
+}
+
+// ─── Field encryption ─────────────────────────────────────────────────────────
+
+func TestCodec_EncryptsEmailAtRestAndLooksItUpByIndex(t *testing.T) {
+	pool := openTestDB(t)
+
+	ctx := context.Background()
+	q := db.New(pool)
+	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_codec_" + t.Name()})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID) })
+
+	codec, err := fieldcrypt.New([]string{"test:" + strings.Repeat("A", 43) + "="}, "test-index")
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	st := store.New(pool, q)
+	st.SetCodec(codec)
+	email := "codec-" + uuid.NewString() + "@example.com"
+	piID := "pi_test_codec_" + t.Name()
+	if _, err := st.AttachPaymentIntent(ctx, store.AttachPaymentIntentParams{
+		SessionID:           session.ID,
+		StripePaymentIntent: piID,
+		Email:               email,
+	}); err != nil {
+		t.Fatalf("AttachPaymentIntent: %v", err)
+	}
+	if _, err := st.Q().MarkSessionPaymentFailed(ctx, sql.NullString{String: piID, Valid: true}); err != nil {
+		t.Fatalf("MarkSessionPaymentFailed: %v", err)
+	}
+
+	raw, err := q.GetSessionByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("raw get: %v", err)
+	}
+	if !strings.HasPrefix(raw.Email.String, "enc:v1:test:") {
+		t.Errorf("expected ciphertext at rest, got %q", raw.Email.String)
+	}
+	decrypted, err := st.Q().GetSessionByID(ctx, session.ID)
+	if err != nil || decrypted.Email.String != email {
+		t.Errorf("expected %q through the store, got %q (%v)", email, decrypted.Email.String, err)
+	}
+
+	n, err := st.Q().CountFailedPaymentsByEmailSince(ctx, db.CountFailedPaymentsByEmailSinceParams{
+		Email: sql.NullString{String: strings.ToUpper(email), Valid: true},
+		Since: time.Now().Add(-time.Hour),
+	})
+	if err != nil || n != 1 {
+		t.Errorf("expected the failed payment found by email, got %d (%v)", n, err)
+	}
+}
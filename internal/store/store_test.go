@@ -4,386 +4,175 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
-	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
-	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
 )
 
-// ─── TEST INFRASTRUCTURE ──────────────────────────────────────────────────────
-
-// openTestDB returns a *sql.DB from DATABASE_URL. Skips if the env var is
-// not set so the test suite still passes in CI without a Postgres instance.
-func openTestDB(t *testing.T) *sql.DB {
-	t.Helper()
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		t.Skip("DATABASE_URL not set — skipping store integration tests")
-	}
-	pool, err := sql.Open("postgres", dsn)
-	if err != nil {
-		t.Fatalf("sql.Open: %v", err)
-	}
-	if err := pool.PingContext(context.Background()); err != nil {
-		pool.Close()
-		t.Fatalf("ping: %v", err)
-	}
-	t.Cleanup(func() { pool.Close() })
-	return pool
+// ─── ReplicaQ ─────────────────────────────────────────────────────────────────
+
+// reportReader answers report and risk reads from fixed sets and counts
+// them, and rotates and revokes within them; every other Querier method is
+// left unimplemented.
+type reportReader struct {
+	db.Querier
+	reports    map[string]db.GetReportByAccessTokenRow
+	risks      map[uuid.UUID][]db.RiskResult
+	tokenReads int
+	riskReads  int
 }
 
-// withRollback runs fn inside a transaction that is always rolled back,
-// leaving the database clean after each test.
-func withRollback(t *testing.T, pool *sql.DB, fn func(ctx context.Context, q db.Querier, st *store.Store)) {
-	t.Helper()
-	ctx := context.Background()
-
-	// The store uses serializable transactions internally, so we open a
-	// plain read-committed wrapper here just to seed data that we roll back.
-	tx, err := pool.BeginTx(ctx, nil)
-	if err != nil {
-		t.Fatalf("begin rollback tx: %v", err)
+func (r *reportReader) GetReportByAccessToken(_ context.Context, token string) (db.GetReportByAccessTokenRow, error) {
+	r.tokenReads++
+	row, ok := r.reports[token]
+	if !ok {
+		return db.GetReportByAccessTokenRow{}, sql.ErrNoRows
 	}
-	t.Cleanup(func() { _ = tx.Rollback() })
-
-	q := db.New(pool).WithTx(tx)
-	// The store is given the outer pool so its internal transactions can see
-	// the seeded rows (same connection via the shared pool in tests).
-	st := store.New(pool, db.New(pool))
-
-	fn(ctx, q, st)
+	return row, nil
 }
 
-// seedSession inserts a minimal anonymous session and returns it.
-func seedSession(t *testing.T, ctx context.Context, q db.Querier, suffix string) db.Session {
-	t.Helper()
-	s, err := q.CreateSession(ctx, db.CreateSessionParams{
-		AnonToken: fmt.Sprintf("test_token_%s_%s", t.Name(), suffix),
-	})
-	if err != nil {
-		t.Fatalf("seed session: %v", err)
+func (r *reportReader) RotateReportAccessToken(_ context.Context, arg db.RotateReportAccessTokenParams) (string, error) {
+	row, ok := r.reports[arg.AccessToken]
+	if !ok || row.ID != arg.ID {
+		return "", sql.ErrNoRows
 	}
-	return s
+	delete(r.reports, arg.AccessToken)
+	row.AccessToken = "tok_rotated"
+	r.reports[row.AccessToken] = row
+	return row.AccessToken, nil
 }
 
-// attachPI attaches a fake Stripe PI to a session so InitialiseReport can
-// call MarkSessionPaid, which looks up the session by stripe_payment_intent.
-func attachPI(t *testing.T, ctx context.Context, q db.Querier, sessionID uuid.UUID , piID string) {
-	t.Helper()
-	_, err := q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
-		ID:                  sessionID,
-		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
-		Email:               sql.NullString{String: "test@example.com", Valid: true},
-	})
-	if err != nil {
-		t.Fatalf("attachPI: %v", err)
+func (r *reportReader) RevokeReport(_ context.Context, arg db.RevokeReportParams) (db.Report, error) {
+	for token, row := range r.reports {
+		if row.ID == arg.ID {
+			row.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			r.reports[token] = row
+			return db.Report{ID: row.ID, AccessToken: token}, nil
+		}
 	}
+	return db.Report{}, sql.ErrNoRows
 }
 
-// ─── AttachPaymentIntent ──────────────────────────────────────────────────────
-
-func TestAttachPaymentIntent_FirstCallSucceeds(t *testing.T) {
-	pool := openTestDB(t)
-
-	ctx := context.Background()
-	q := db.New(pool)
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_attach_first_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
-	}
-	t.Cleanup(func() { _, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID) })
-
-	st := store.New(pool, q)
-	updated, err := st.AttachPaymentIntent(ctx, store.AttachPaymentIntentParams{
-		SessionID:           session.ID,
-		StripeCustomerID:    "cus_test_first",
-		StripePaymentIntent: "pi_test_first_" + t.Name(),
-		Email:               "test@example.com",
-	})
-	if err != nil {
-		t.Fatalf("AttachPaymentIntent: %v", err)
-	}
-	if !updated.StripePaymentIntent.Valid {
-		t.Error("expected StripePaymentIntent to be set")
-	}
-	if updated.Email.String != "test@example.com" {
-		t.Errorf("email: got %q", updated.Email.String)
-	}
+func (r *reportReader) GetRiskResultsByReport(_ context.Context, reportID uuid.UUID) ([]db.RiskResult, error) {
+	r.riskReads++
+	return r.risks[reportID], nil
 }
 
-func TestAttachPaymentIntent_SecondCallReturnsErrAlreadyAttached(t *testing.T) {
-	pool := openTestDB(t)
-
+func TestReplicaQ_ReadsRisksFromTheReplicaWithPrimaryFallback(t *testing.T) {
+	onBoth, primaryOnly := uuid.New(), uuid.New()
+	risk := []db.RiskResult{{ID: uuid.New()}}
+	primary := &reportReader{risks: map[uuid.UUID][]db.RiskResult{onBoth: risk, primaryOnly: risk}}
+	replica := &reportReader{risks: map[uuid.UUID][]db.RiskResult{onBoth: risk}}
+	st := store.New(nil, primary)
+	st.SetReplica(replica)
 	ctx := context.Background()
-	q := db.New(pool)
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_attach_second_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
-	}
-	t.Cleanup(func() { _, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID) })
 
-	st := store.New(pool, q)
-	params := store.AttachPaymentIntentParams{
-		SessionID:           session.ID,
-		StripeCustomerID:    "cus_test",
-		StripePaymentIntent: "pi_test_race_" + t.Name(),
-		Email:               "test@example.com",
+	if got, err := st.ReplicaQ().GetRiskResultsByReport(ctx, onBoth); err != nil || len(got) != 1 || primary.riskReads != 0 {
+		t.Fatalf("expected the replica to answer, got %v (%v), primary reads %d", got, err, primary.riskReads)
 	}
-
-	if _, err := st.AttachPaymentIntent(ctx, params); err != nil {
-		t.Fatalf("first call: %v", err)
+	if got, err := st.ReplicaQ().GetRiskResultsByReport(ctx, primaryOnly); err != nil || len(got) != 1 || primary.riskReads != 1 {
+		t.Errorf("expected risks not yet replicated read from the primary, got %v (%v)", got, err)
 	}
-
-	// Second call for same session must return the sentinel error.
-	params.StripePaymentIntent = "pi_test_duplicate_" + t.Name()
-	_, err = st.AttachPaymentIntent(ctx, params)
-	if !errors.Is(err, store.ErrPaymentIntentAlreadyAttached) {
-		t.Errorf("expected ErrPaymentIntentAlreadyAttached, got: %v", err)
+	before := replica.riskReads
+	if _, err := st.Q().GetRiskResultsByReport(ctx, onBoth); err != nil || replica.riskReads != before {
+		t.Errorf("Q must keep reading from the primary (%v)", err)
 	}
 }
 
-// ─── InitialiseReport ─────────────────────────────────────────────────────────
-
-func TestInitialiseReport_CreatesDraftReport(t *testing.T) {
-	pool := openTestDB(t)
+func TestReplicaQ_ReadsAccessTokensFromTheReplicaOnceThePrimaryConfirms(t *testing.T) {
+	row := db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusProcessing}
+	primary := &reportReader{reports: map[string]db.GetReportByAccessTokenRow{"tok": row, "tok_new": row}}
+	replica := &reportReader{reports: map[string]db.GetReportByAccessTokenRow{"tok": row}}
+	st := store.New(nil, primary)
+	st.SetReplica(replica)
 	ctx := context.Background()
-	q := db.New(pool)
-	st := store.New(pool, q)
 
-	piID := "pi_init_draft_" + t.Name()
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_draft_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
+	// The first lookup confirms the token on the primary; the next are
+	// answered by the replica.
+	for i := 0; i < 3; i++ {
+		if got, err := st.ReplicaQ().GetReportByAccessToken(ctx, "tok"); err != nil || got.ID != row.ID {
+			t.Fatalf("lookup %d: got %v (%v)", i, got.ID, err)
+		}
 	}
-	t.Cleanup(func() {
-		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
-		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
-	})
-
-	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
-		ID:                  session.ID,
-		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
-		Email:               sql.NullString{String: "x@example.com", Valid: true},
-	})
-	if err != nil {
-		t.Fatalf("attach pi: %v", err)
+	if primary.tokenReads != 1 || replica.tokenReads != 3 {
+		t.Errorf("expected 1 primary and 3 replica reads, got %d and %d", primary.tokenReads, replica.tokenReads)
 	}
 
-	report, err := st.InitialiseReport(ctx, piID)
-	if err != nil {
-		t.Fatalf("InitialiseReport: %v", err)
-	}
-	if report.ID.String() == "" {
-		t.Error("expected non-empty report ID")
-	}
-	if report.Status != db.ReportStatusDraft {
-		t.Errorf("expected status draft, got %s", report.Status)
-	}
-	if report.SessionID != session.ID {
-		t.Error("session ID mismatch")
-	}
-	if report.AccessToken == "" {
-		t.Error("expected non-empty access token")
-	}
-}
-
-func TestInitialiseReport_DuplicateDeliveryReturnsErrAlreadyExists(t *testing.T) {
-	pool := openTestDB(t)
-	ctx := context.Background()
-	q := db.New(pool)
-	st := store.New(pool, q)
-
-	piID := "pi_idem_" + t.Name()
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_idem_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
-	}
-	t.Cleanup(func() {
-		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
-		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
-	})
-
-	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
-		ID:                  session.ID,
-		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
-	})
-	if err != nil {
-		t.Fatalf("attach pi: %v", err)
+	// A new status on the replica is confirmed again.
+	ready := row
+	ready.Status = db.ReportStatusReady
+	primary.reports["tok"], replica.reports["tok"] = ready, ready
+	if got, err := st.ReplicaQ().GetReportByAccessToken(ctx, "tok"); err != nil || got.Status != db.ReportStatusReady || primary.tokenReads != 2 {
+		t.Errorf("expected the status change read from the primary, got %q (%v), primary reads %d", got.Status, err, primary.tokenReads)
 	}
 
-	first, err := st.InitialiseReport(ctx, piID)
-	if err != nil {
-		t.Fatalf("first call: %v", err)
-	}
-
-	second, err := st.InitialiseReport(ctx, piID)
-	if !errors.Is(err, store.ErrReportAlreadyExists) {
-		t.Errorf("expected ErrReportAlreadyExists, got: %v", err)
-	}
-	if second.ID != first.ID {
-		t.Errorf("returned report ID mismatch: got %s, want %s", second.ID, first.ID)
+	// A token not yet replicated is read from the primary.
+	if _, err := st.ReplicaQ().GetReportByAccessToken(ctx, "tok_new"); err != nil || primary.tokenReads != 3 {
+		t.Errorf("expected the replica miss to fall back to the primary (%v)", err)
 	}
 }
 
-func TestInitialiseReport_MarksSessionPaid(t *testing.T) {
-	pool := openTestDB(t)
-	ctx := context.Background()
-	q := db.New(pool)
-	st := store.New(pool, q)
-
-	piID := "pi_paid_" + t.Name()
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_paid_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
-	}
-	t.Cleanup(func() {
-		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
-		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
-	})
-
-	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
-		ID:                  session.ID,
-		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
-	})
-	if err != nil {
-		t.Fatalf("attach pi: %v", err)
-	}
-
-	if _, err := st.InitialiseReport(ctx, piID); err != nil {
-		t.Fatalf("InitialiseReport: %v", err)
-	}
-
-	updated, err := q.GetSessionByID(ctx, session.ID)
-	if err != nil {
-		t.Fatalf("GetSessionByID: %v", err)
-	}
-	if updated.PaymentStatus != db.PaymentStatusPaid {
-		t.Errorf("expected payment_status=paid, got %s", updated.PaymentStatus)
-	}
-	if !updated.PaidAt.Valid {
-		t.Error("expected paid_at to be set")
-	}
-}
-
-// ─── MarkReportFailed ─────────────────────────────────────────────────────────
-
-func TestMarkReportFailed_SetsErrorStatus(t *testing.T) {
-	pool := openTestDB(t)
-	ctx := context.Background()
-	q := db.New(pool)
-	st := store.New(pool, q)
-
-	piID := "pi_fail_" + t.Name()
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_fail_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
-	}
-	t.Cleanup(func() {
-		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
-		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
-	})
-
-	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
-		ID:                  session.ID,
-		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
-	})
-	if err != nil {
-		t.Fatalf("attach pi: %v", err)
-	}
-
-	report, err := st.InitialiseReport(ctx, piID)
-	if err != nil {
-		t.Fatalf("InitialiseReport: %v", err)
-	}
-
-	failed, err := st.MarkReportFailed(ctx, report.ID, "ai service unavailable")
-	if err != nil {
-		t.Fatalf("MarkReportFailed: %v", err)
-	}
-	if failed.Status != db.ReportStatusError {
-		t.Errorf("expected status=error, got %s", failed.Status)
-	}
-	if !failed.ErrorMessage.Valid || failed.ErrorMessage.String != "ai service unavailable" {
-		t.Errorf("error message: %+v", failed.ErrorMessage)
-	}
-}
-
-// ─── PersistScoredReport ──────────────────────────────────────────────────────
-
-func TestPersistScoredReport_FinalizesReport(t *testing.T) {
-	pool := openTestDB(t)
-	ctx := context.Background()
-	q := db.New(pool)
-	st := store.New(pool, q)
-
-	piID := "pi_persist_" + t.Name()
-	session, err := q.CreateSession(ctx, db.CreateSessionParams{AnonToken: "tok_persist_" + t.Name()})
-	if err != nil {
-		t.Fatalf("create session: %v", err)
-	}
-	t.Cleanup(func() {
-		_, _ = pool.ExecContext(ctx, "DELETE FROM risk_results WHERE report_id IN (SELECT id FROM reports WHERE session_id=$1)", session.ID)
-		_, _ = pool.ExecContext(ctx, "DELETE FROM reports WHERE session_id=$1", session.ID)
-		_, _ = pool.ExecContext(ctx, "DELETE FROM sessions WHERE id=$1", session.ID)
-	})
-
-	_, err = q.AttachStripeCustomer(ctx, db.AttachStripeCustomerParams{
-		ID:                  session.ID,
-		StripePaymentIntent: sql.NullString{String: piID, Valid: true},
-	})
-	if err != nil {
-		t.Fatalf("attach pi: %v", err)
-	}
-
-	report, err := st.InitialiseReport(ctx, piID)
-	if err != nil {
-		t.Fatalf("InitialiseReport: %v", err)
-	}
-
-	// Seed question_definitions the risks reference.
-	// (skip if q_cash_runway already exists from your seed data)
-	risks := []scoring.ScoredRisk{
+func TestReplicaQ_NeverServesARevokedOrRotatedTokenFromTheReplica(t *testing.T) {
+	row := db.GetReportByAccessTokenRow{ID: uuid.New(), Status: db.ReportStatusReady}
+	for _, tc := range []struct {
+		name string
+		// change is made on the primary; replicated copies it to the
+		// replica, otherwise the replica still has the token as it was.
+		change     func(t *testing.T, q db.Querier, primary *reportReader)
+		replicated bool
+		check      func(row db.GetReportByAccessTokenRow, err error) bool
+	}{
 		{
-			QuestionID: "q_cash_runway",
-			Rank:       1,
-			RiskName:   "Cash Runway Risk",
-			RiskDesc:   "Running out of cash",
-			Hedge:      "Maintain 6+ months runway",
-			Section:    "snapshot",
-			P:          9, I: 9, Score: 81,
-			Tier: scoring.TierWatch,
+			name: "rotated",
+			change: func(t *testing.T, q db.Querier, _ *reportReader) {
+				if _, err := q.RotateReportAccessToken(context.Background(), db.RotateReportAccessTokenParams{ID: row.ID, AccessToken: "tok"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(_ db.GetReportByAccessTokenRow, err error) bool { return errors.Is(err, sql.ErrNoRows) },
 		},
+		{
+			name: "revoked",
+			change: func(t *testing.T, q db.Querier, _ *reportReader) {
+				if _, err := q.RevokeReport(context.Background(), db.RevokeReportParams{ID: row.ID}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(got db.GetReportByAccessTokenRow, err error) bool { return err == nil && got.RevokedAt.Valid },
+		},
+		{
+			// Revoked without ReplicaQ: the replica's row no longer matches
+			// once the revocation reaches it.
+			name: "revoked elsewhere",
+			change: func(t *testing.T, _ db.Querier, primary *reportReader) {
+				if _, err := primary.RevokeReport(context.Background(), db.RevokeReportParams{ID: row.ID}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			replicated: true,
+			check:      func(got db.GetReportByAccessTokenRow, err error) bool { return err == nil && got.RevokedAt.Valid },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := &reportReader{reports: map[string]db.GetReportByAccessTokenRow{"tok": row}}
+			replica := &reportReader{reports: map[string]db.GetReportByAccessTokenRow{"tok": row}}
+			st := store.New(nil, primary)
+			st.SetReplica(replica)
+			ctx := context.Background()
+			if _, err := st.ReplicaQ().GetReportByAccessToken(ctx, "tok"); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.change(t, st.ReplicaQ(), primary)
+			if tc.replicated {
+				replica.reports["tok"] = primary.reports["tok"]
+			}
+			if got, err := st.ReplicaQ().GetReportByAccessToken(ctx, "tok"); !tc.check(got, err) {
+				t.Errorf("expected the change to be served, got %+v (%v)", got, err)
+			}
+		})
 	}
-
-	finalised, err := st.PersistScoredReport(ctx, store.PersistScoredReportParams{
-		ReportID:         report.ID,
-		Risks:            risks,
-		AIHedges:         map[string]string{"q_cash_runway": "AI-generated hedge narrative"},
-		ExecutiveSummary: "High risk posture.",
-		TopPriorityHTML:  "<strong>Act now.</strong>",
-	})
-	if err != nil {
-		t.Fatalf("PersistScoredReport: %v", err)
-	}
-
-	if finalised.Status != db.ReportStatusReady {
-		t.Errorf("expected status=ready, got %s", finalised.Status)
-	}
-	if !finalised.OverallScore.Valid || finalised.OverallScore.Int16 != 81 {
-		t.Errorf("overall score: %+v", finalised.OverallScore)
-	}
-	if !finalised.CriticalCount.Valid || finalised.CriticalCount.Int16 != 1 {
-		t.Errorf("critical count: %+v", finalised.CriticalCount)
-	}
-	if !finalised.ExecutiveSummary.Valid || finalised.ExecutiveSummary.String != "High risk posture." {
-		t.Errorf("executive summary: %+v", finalised.ExecutiveSummary)
-	}
-	if !finalised.GeneratedAt.Valid {
-		t.Error("expected generated_at to be set")
-	}
-}
\ No newline at end of file
+}
@@ -6,7 +6,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
 )
@@ -28,6 +30,45 @@ type PaymentIntent struct {
 	CustomerID   string // may be empty if no Customer was created
 }
 
+// TaxParams describes a single-item purchase to price with Stripe Tax.
+type TaxParams struct {
+	AmountCents int64 // product price, tax exclusive
+	Currency    string
+	Country     string // ISO 3166-1 alpha-2
+	PostalCode  string // required by Stripe for US and CA addresses
+	Reference   string // line item reference, e.g. the product SKU
+}
+
+// TaxCalculation is the result of pricing a purchase with Stripe Tax.
+type TaxCalculation struct {
+	ID               string
+	AmountTotalCents int64 // price plus tax — what the PaymentIntent charges
+	TaxCents         int64
+}
+
+// ErrInvalidTaxLocation is returned by CalculateTax when Stripe cannot
+// determine a tax jurisdiction from the billing address, e.g. a US address
+// without a valid postal code. It is the customer's input at fault.
+var ErrInvalidTaxLocation = errors.New("stripe: billing address is not a valid tax location")
+
+// Charge is the card and receipt detail of a Stripe charge shown on our
+// receipt email. Card fields are empty for non-card payment methods.
+type Charge struct {
+	ID         string
+	CardBrand  string // e.g. "visa"
+	CardLast4  string
+	ReceiptURL string // Stripe-hosted receipt page
+}
+
+// PaymentDetails is what a succeeded PaymentIntent says was actually charged.
+// Charge.ID is set without the other Charge fields when the event carried
+// only the charge ID; fetch the rest with Client.GetCharge.
+type PaymentDetails struct {
+	AmountCents int64 // amount_received, in the currency's minor unit
+	Currency    string
+	Charge      Charge
+}
+
 // Event is a parsed Stripe webhook event. DataRaw contains the raw JSON of the
 // event's data.object so handlers can unmarshal only what they need.
 type Event struct {
@@ -36,6 +77,22 @@ type Event struct {
 	DataRaw json.RawMessage
 }
 
+// Subscription is the subset of a Stripe subscription mirrored into the
+// subscriptions table. Empty strings and zero times mean the event did not
+// carry the field.
+type Subscription struct {
+	ID                 string
+	CustomerID         string
+	Email              string
+	Status             string
+	CurrentPeriodStart time.Time
+	CurrentPeriodEnd   time.Time
+}
+
+// ErrNoSubscription is returned by ExtractPaidInvoice for invoices that do not
+// belong to a subscription.
+var ErrNoSubscription = errors.New("stripe: invoice has no subscription")
+
 // ─── CLIENT INTERFACE ─────────────────────────────────────────────────────────
 
 // Client is the interface the api and worker packages use for all Stripe calls.
@@ -49,9 +106,32 @@ type Client interface {
 	// Used when the session already has a PI attached (checkout retry path).
 	GetClientSecret(ctx context.Context, paymentIntentID string) (string, error)
 
-	// VerifyWebhook validates the Stripe-Signature header and returns the
-	// parsed event. Returns an error if the signature is invalid or expired.
-	VerifyWebhook(payload []byte, sigHeader string, secret string) (Event, error)
+	// CalculateTax prices a purchase with Stripe Tax. Tax is added on top of
+	// AmountCents.
+	CalculateTax(ctx context.Context, p TaxParams) (TaxCalculation, error)
+
+	// RecordTaxTransaction turns a calculation into a tax transaction once the
+	// payment has succeeded, so Stripe includes it in tax reporting. reference
+	// must be unique per payment; the PaymentIntent ID is used.
+	RecordTaxTransaction(ctx context.Context, calculationID, reference string) error
+
+	// GetCharge retrieves card and receipt details for a charge by ID. Used
+	// when a payment_intent.succeeded event references its charge by ID only.
+	GetCharge(ctx context.Context, chargeID string) (Charge, error)
+
+	// GetBalanceTransaction retrieves the fee and net amount Stripe recorded
+	// for a charge. Used when a charge event references it by ID only.
+	GetBalanceTransaction(ctx context.Context, id string) (BalanceTransaction, error)
+
+	// RefundPaymentIntent refunds a PaymentIntent in full as a duplicate and
+	// returns the refund ID. Calling it again for the same PaymentIntent
+	// returns the same refund rather than refunding twice.
+	RefundPaymentIntent(ctx context.Context, paymentIntentID string) (string, error)
+
+	// VerifyWebhook validates the Stripe-Signature header against each of
+	// secrets and returns the parsed event. Returns an error if the signature
+	// matches none of them or has expired.
+	VerifyWebhook(payload []byte, sigHeader string, secrets []string) (Event, error)
 }
 
 // ─── HELPERS USED BY api/ ────────────────────────────────────────────────────
@@ -90,6 +170,68 @@ func ExtractPaymentIntentID(event Event) (string, error) {
 	return obj.ID, nil
 }
 
+// chargeObject is the part of a charge object read into Charge.
+type chargeObject struct {
+	ID                   string `json:"id"`
+	ReceiptURL           string `json:"receipt_url"`
+	PaymentMethodDetails struct {
+		Card struct {
+			Brand string `json:"brand"`
+			Last4 string `json:"last4"`
+		} `json:"card"`
+	} `json:"payment_method_details"`
+}
+
+func (c chargeObject) toCharge() Charge {
+	return Charge{
+		ID:         c.ID,
+		CardBrand:  c.PaymentMethodDetails.Card.Brand,
+		CardLast4:  c.PaymentMethodDetails.Card.Last4,
+		ReceiptURL: c.ReceiptURL,
+	}
+}
+
+// ExtractPaymentDetails reads the amount received, currency and charge from a
+// PaymentIntent. Works for payment_intent.succeeded events.
+//
+// latest_charge is usually just an ID in webhook payloads but is read in full
+// when expanded; API versions before 2022-11-15 embed the charge under
+// charges.data instead.
+func ExtractPaymentDetails(event Event) (PaymentDetails, error) {
+	var obj struct {
+		AmountReceived int64           `json:"amount_received"`
+		Currency       string          `json:"currency"`
+		LatestCharge   json.RawMessage `json:"latest_charge"`
+		Charges        struct {
+			Data []chargeObject `json:"data"`
+		} `json:"charges"`
+	}
+	if err := json.Unmarshal(event.DataRaw, &obj); err != nil {
+		return PaymentDetails{}, fmt.Errorf("stripe: unmarshal payment intent: %w", err)
+	}
+	if obj.Currency == "" {
+		return PaymentDetails{}, fmt.Errorf("stripe: payment intent currency is empty in event %s", event.ID)
+	}
+
+	details := PaymentDetails{AmountCents: obj.AmountReceived, Currency: obj.Currency}
+
+	var chargeID string
+	var expanded chargeObject
+	switch {
+	case len(obj.LatestCharge) == 0 || string(obj.LatestCharge) == "null":
+		// No charge on the object; fall back to charges.data below.
+	case json.Unmarshal(obj.LatestCharge, &chargeID) == nil:
+		details.Charge.ID = chargeID
+	case json.Unmarshal(obj.LatestCharge, &expanded) == nil:
+		details.Charge = expanded.toCharge()
+	}
+	if details.Charge.ID == "" && len(obj.Charges.Data) > 0 {
+		details.Charge = obj.Charges.Data[0].toCharge()
+	}
+
+	return details, nil
+}
+
 // ExtractPIFromCharge pulls the payment_intent field from a charge object.
 // Works for charge.refunded events.
 func ExtractPIFromCharge(event Event) (string, error) {
@@ -103,4 +245,269 @@ func ExtractPIFromCharge(event Event) (string, error) {
 		return "", fmt.Errorf("stripe: no payment_intent on charge in event %s", event.ID)
 	}
 	return obj.PaymentIntent, nil
+}
+
+// SettledCharge is a charge as read from a charge.succeeded or charge.updated
+// event. BalanceTransactionID is empty until Stripe has created the balance
+// transaction, which for some payment methods happens after charge.succeeded.
+// BalanceTransaction is set only when the event carried it expanded.
+type SettledCharge struct {
+	ID                   string
+	PaymentIntentID      string
+	Status               string
+	BalanceTransactionID string
+	BalanceTransaction   *BalanceTransaction
+}
+
+// ExtractSettledCharge reads a charge object. Works for charge.succeeded and
+// charge.updated events.
+func ExtractSettledCharge(event Event) (SettledCharge, error) {
+	var obj struct {
+		ID                 string          `json:"id"`
+		PaymentIntent      string          `json:"payment_intent"`
+		Status             string          `json:"status"`
+		BalanceTransaction json.RawMessage `json:"balance_transaction"`
+	}
+	if err := json.Unmarshal(event.DataRaw, &obj); err != nil {
+		return SettledCharge{}, fmt.Errorf("stripe: unmarshal charge: %w", err)
+	}
+	if obj.ID == "" {
+		return SettledCharge{}, fmt.Errorf("stripe: charge id is empty in event %s", event.ID)
+	}
+
+	out := SettledCharge{ID: obj.ID, PaymentIntentID: obj.PaymentIntent, Status: obj.Status}
+	var btID string
+	var expanded BalanceTransaction
+	switch {
+	case len(obj.BalanceTransaction) == 0 || string(obj.BalanceTransaction) == "null":
+		// Not created yet; a charge.updated event follows when it is.
+	case json.Unmarshal(obj.BalanceTransaction, &btID) == nil:
+		out.BalanceTransactionID = btID
+	case json.Unmarshal(obj.BalanceTransaction, &expanded) == nil:
+		out.BalanceTransactionID = expanded.ID
+		out.BalanceTransaction = &expanded
+	}
+	return out, nil
+}
+
+// Refund is the refunded state of a charge, read from a charge.refunded event.
+// AmountRefunded is cumulative: a second partial refund reports the total of
+// both, not just the new one.
+type Refund struct {
+	ChargeID        string
+	PaymentIntentID string
+	AmountRefunded  int64
+	Currency        string
+}
+
+// ExtractRefund reads a charge object. Works for charge.refunded events.
+func ExtractRefund(event Event) (Refund, error) {
+	var obj struct {
+		ID             string `json:"id"`
+		PaymentIntent  string `json:"payment_intent"`
+		AmountRefunded int64  `json:"amount_refunded"`
+		Currency       string `json:"currency"`
+	}
+	if err := json.Unmarshal(event.DataRaw, &obj); err != nil {
+		return Refund{}, fmt.Errorf("stripe: unmarshal charge: %w", err)
+	}
+	if obj.ID == "" {
+		return Refund{}, fmt.Errorf("stripe: charge id is empty in event %s", event.ID)
+	}
+	return Refund{
+		ChargeID:        obj.ID,
+		PaymentIntentID: obj.PaymentIntent,
+		AmountRefunded:  obj.AmountRefunded,
+		Currency:        obj.Currency,
+	}, nil
+}
+
+// Dispute is the subset of a Stripe dispute used for reconciliation.
+type Dispute struct {
+	ID              string
+	ChargeID        string
+	PaymentIntentID string
+	AmountCents     int64
+	Currency        string
+	Status          string
+	Reason          string
+	// BalanceTransactions are the movements the dispute caused, oldest first:
+	// the withdrawal when it opened and, if it was won, the reinstatement.
+	BalanceTransactions []BalanceTransaction
+}
+
+// BalanceTransaction is one movement of funds in the Stripe balance. Amount
+// and Net are signed; Fee is what Stripe kept.
+type BalanceTransaction struct {
+	ID          string `json:"id"`
+	AmountCents int64  `json:"amount"`
+	FeeCents    int64  `json:"fee"`
+	NetCents    int64  `json:"net"`
+	Currency    string `json:"currency"`
+}
+
+// ExtractDispute reads a dispute object. Works for charge.dispute.* events.
+func ExtractDispute(event Event) (Dispute, error) {
+	var obj struct {
+		ID            string `json:"id"`
+		Charge        string `json:"charge"`
+		PaymentIntent string `json:"payment_intent"`
+		Amount        int64  `json:"amount"`
+		Currency      string `json:"currency"`
+		Status        string `json:"status"`
+		Reason        string `json:"reason"`
+
+		BalanceTransactions []BalanceTransaction `json:"balance_transactions"`
+	}
+	if err := json.Unmarshal(event.DataRaw, &obj); err != nil {
+		return Dispute{}, fmt.Errorf("stripe: unmarshal dispute: %w", err)
+	}
+	if obj.ID == "" {
+		return Dispute{}, fmt.Errorf("stripe: dispute id is empty in event %s", event.ID)
+	}
+	return Dispute{
+		ID:              obj.ID,
+		ChargeID:        obj.Charge,
+		PaymentIntentID: obj.PaymentIntent,
+		AmountCents:     obj.Amount,
+		Currency:        obj.Currency,
+		Status:          obj.Status,
+		Reason:          obj.Reason,
+
+		BalanceTransactions: obj.BalanceTransactions,
+	}, nil
+}
+
+// ParseStoredEvent rebuilds an Event from a webhook body saved in
+// stripe_events.payload. The signature is not checked again — it was verified
+// when the event was received.
+func ParseStoredEvent(payload []byte) (Event, error) {
+	var raw struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return Event{}, fmt.Errorf("stripe: unmarshal stored event: %w", err)
+	}
+	return Event{ID: raw.ID, Type: raw.Type, DataRaw: raw.Data.Object}, nil
+}
+
+// period is a Stripe start/end pair in Unix seconds.
+type period struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// unixTime converts Unix seconds to a UTC time, keeping 0 as the zero time.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
+
+// ExtractSubscription reads a subscription object. Works for
+// customer.subscription.created, .updated and .deleted events.
+//
+// API versions from 2025-03-31 report the billing period on each subscription
+// item rather than the subscription; both shapes are accepted.
+func ExtractSubscription(event Event) (Subscription, error) {
+	var obj struct {
+		ID                 string `json:"id"`
+		Customer           string `json:"customer"`
+		Status             string `json:"status"`
+		CurrentPeriodStart int64  `json:"current_period_start"`
+		CurrentPeriodEnd   int64  `json:"current_period_end"`
+		Items              struct {
+			Data []struct {
+				CurrentPeriodStart int64 `json:"current_period_start"`
+				CurrentPeriodEnd   int64 `json:"current_period_end"`
+			} `json:"data"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(event.DataRaw, &obj); err != nil {
+		return Subscription{}, fmt.Errorf("stripe: unmarshal subscription: %w", err)
+	}
+	if obj.ID == "" || obj.Customer == "" {
+		return Subscription{}, fmt.Errorf("stripe: subscription id or customer is empty in event %s", event.ID)
+	}
+
+	start, end := obj.CurrentPeriodStart, obj.CurrentPeriodEnd
+	if end == 0 && len(obj.Items.Data) > 0 {
+		start, end = obj.Items.Data[0].CurrentPeriodStart, obj.Items.Data[0].CurrentPeriodEnd
+	}
+
+	return Subscription{
+		ID:                 obj.ID,
+		CustomerID:         obj.Customer,
+		Status:             obj.Status,
+		CurrentPeriodStart: unixTime(start),
+		CurrentPeriodEnd:   unixTime(end),
+	}, nil
+}
+
+// ExtractPaidInvoice reads the subscription an invoice paid for, along with
+// the customer's email and the billing period it covers. Works for
+// invoice.paid events. Status is left empty — an invoice says nothing
+// reliable about the subscription's state when events arrive out of order.
+//
+// Returns ErrNoSubscription for one-off invoices.
+func ExtractPaidInvoice(event Event) (Subscription, error) {
+	var obj struct {
+		Customer      string `json:"customer"`
+		CustomerEmail string `json:"customer_email"`
+		Subscription  string `json:"subscription"`
+		Parent        struct {
+			SubscriptionDetails struct {
+				Subscription string `json:"subscription"`
+			} `json:"subscription_details"`
+		} `json:"parent"`
+		Lines struct {
+			Data []struct {
+				Period period `json:"period"`
+			} `json:"data"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal(event.DataRaw, &obj); err != nil {
+		return Subscription{}, fmt.Errorf("stripe: unmarshal invoice: %w", err)
+	}
+
+	subID := obj.Subscription
+	if subID == "" {
+		subID = obj.Parent.SubscriptionDetails.Subscription
+	}
+	if subID == "" {
+		return Subscription{}, ErrNoSubscription
+	}
+	if obj.Customer == "" {
+		return Subscription{}, fmt.Errorf("stripe: invoice customer is empty in event %s", event.ID)
+	}
+
+	sub := Subscription{
+		ID:         subID,
+		CustomerID: obj.Customer,
+		Email:      obj.CustomerEmail,
+	}
+	if len(obj.Lines.Data) > 0 {
+		sub.CurrentPeriodStart = unixTime(obj.Lines.Data[0].Period.Start)
+		sub.CurrentPeriodEnd = unixTime(obj.Lines.Data[0].Period.End)
+	}
+	return sub, nil
+}
+
+// ToUpsertSubscriptionParams builds the params for db.Querier.UpsertSubscription.
+// Fields the event did not carry are passed as NULL so the stored values are
+// kept.
+func ToUpsertSubscriptionParams(sub Subscription) db.UpsertSubscriptionParams {
+	return db.UpsertSubscriptionParams{
+		StripeSubscriptionID: sub.ID,
+		StripeCustomerID:     sub.CustomerID,
+		Email:                sql.NullString{String: sub.Email, Valid: sub.Email != ""},
+		Status:               sql.NullString{String: sub.Status, Valid: sub.Status != ""},
+		CurrentPeriodStart:   sql.NullTime{Time: sub.CurrentPeriodStart, Valid: !sub.CurrentPeriodStart.IsZero()},
+		CurrentPeriodEnd:     sql.NullTime{Time: sub.CurrentPeriodEnd, Valid: !sub.CurrentPeriodEnd.IsZero()},
+	}
 }
\ No newline at end of file
@@ -2,11 +2,19 @@ package stripe
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
 	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/balancetransaction"
+	"github.com/stripe/stripe-go/v82/charge"
 	"github.com/stripe/stripe-go/v82/customer"
 	"github.com/stripe/stripe-go/v82/paymentintent"
+	"github.com/stripe/stripe-go/v82/refund"
+	"github.com/stripe/stripe-go/v82/tax/calculation"
+	"github.com/stripe/stripe-go/v82/tax/transaction"
 	"github.com/stripe/stripe-go/v82/webhook"
 )
 
@@ -22,6 +30,18 @@ func NewClient(secretKey string) Client {
 	return &stripeClient{secretKey: secretKey}
 }
 
+// withContext attaches ctx to p so the call honours its deadline, and
+// forwards the request ID as X-Request-ID.
+func withContext(ctx context.Context, p *stripe.Params) {
+	p.Context = ctx
+	if id := requestid.From(ctx); id != "" {
+		if p.Headers == nil {
+			p.Headers = http.Header{}
+		}
+		p.Headers.Set(requestid.Header, id)
+	}
+}
+
 // CreatePaymentIntent creates a Stripe Customer (for receipt emails) and a
 // PaymentIntent in one call. The Customer ID is stored on the session so
 // Stripe's dashboard shows purchases per customer.
@@ -33,6 +53,7 @@ func (c *stripeClient) CreatePaymentIntent(ctx context.Context, p CreatePaymentI
 	custParams := &stripe.CustomerParams{
 		Email: stripe.String(p.Email),
 	}
+	withContext(ctx, &custParams.Params)
 	cust, err := customer.New(custParams)
 	if err != nil {
 		return PaymentIntent{}, fmt.Errorf("stripe: create customer: %w", err)
@@ -43,6 +64,10 @@ func (c *stripeClient) CreatePaymentIntent(ctx context.Context, p CreatePaymentI
 	for k, v := range p.Metadata {
 		meta[k] = v
 	}
+	// The request ID ties the PaymentIntent in the dashboard back to our logs.
+	if id := requestid.From(ctx); id != "" {
+		meta["request_id"] = id
+	}
 
 	piParams := &stripe.PaymentIntentParams{
 		Amount:   stripe.Int64(p.AmountCents),
@@ -54,8 +79,8 @@ func (c *stripeClient) CreatePaymentIntent(ctx context.Context, p CreatePaymentI
 		},
 		Metadata: meta,
 	}
-	// Propagate context deadline to the Stripe HTTP call.
-	piParams.Context = ctx
+	// Propagate context deadline and request ID to the Stripe HTTP call.
+	withContext(ctx, &piParams.Params)
 
 	pi, err := paymentintent.New(piParams)
 	if err != nil {
@@ -75,7 +100,7 @@ func (c *stripeClient) GetClientSecret(ctx context.Context, paymentIntentID stri
 	stripe.Key = c.secretKey
 
 	params := &stripe.PaymentIntentParams{}
-	params.Context = ctx
+	withContext(ctx, &params.Params)
 
 	pi, err := paymentintent.Get(paymentIntentID, params)
 	if err != nil {
@@ -85,22 +110,155 @@ func (c *stripeClient) GetClientSecret(ctx context.Context, paymentIntentID stri
 	return pi.ClientSecret, nil
 }
 
-// VerifyWebhook validates the Stripe-Signature header and returns the parsed
-// event. Returns an error if the signature is invalid or the tolerance window
-// (300 seconds by default in the Stripe SDK) has expired.
-func (c *stripeClient) VerifyWebhook(payload []byte, sigHeader string, secret string) (Event, error) {
-	stripeEvent, err := webhook.ConstructEventWithOptions(payload, sigHeader, secret,
-		webhook.ConstructEventOptions{
-			IgnoreAPIVersionMismatch: true,
+// CalculateTax prices a single line item with Stripe Tax. The billing address
+// is the only location input — we sell a digital service with no shipping.
+func (c *stripeClient) CalculateTax(ctx context.Context, p TaxParams) (TaxCalculation, error) {
+	stripe.Key = c.secretKey
+
+	address := &stripe.AddressParams{Country: stripe.String(p.Country)}
+	if p.PostalCode != "" {
+		address.PostalCode = stripe.String(p.PostalCode)
+	}
+
+	params := &stripe.TaxCalculationParams{
+		Currency: stripe.String(p.Currency),
+		CustomerDetails: &stripe.TaxCalculationCustomerDetailsParams{
+			Address:       address,
+			AddressSource: stripe.String("billing"),
 		},
-	)
+		LineItems: []*stripe.TaxCalculationLineItemParams{{
+			Amount:      stripe.Int64(p.AmountCents),
+			Reference:   stripe.String(p.Reference),
+			TaxBehavior: stripe.String("exclusive"),
+		}},
+	}
+	withContext(ctx, &params.Params)
+
+	calc, err := calculation.New(params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeCustomerTaxLocationInvalid {
+			return TaxCalculation{}, ErrInvalidTaxLocation
+		}
+		return TaxCalculation{}, fmt.Errorf("stripe: calculate tax: %w", err)
+	}
+
+	return TaxCalculation{
+		ID:               calc.ID,
+		AmountTotalCents: calc.AmountTotal,
+		TaxCents:         calc.TaxAmountExclusive,
+	}, nil
+}
+
+// RecordTaxTransaction creates a tax transaction from a calculation.
+func (c *stripeClient) RecordTaxTransaction(ctx context.Context, calculationID, reference string) error {
+	stripe.Key = c.secretKey
+
+	params := &stripe.TaxTransactionCreateFromCalculationParams{
+		Calculation: stripe.String(calculationID),
+		Reference:   stripe.String(reference),
+	}
+	withContext(ctx, &params.Params)
+
+	if _, err := transaction.CreateFromCalculation(params); err != nil {
+		return fmt.Errorf("stripe: record tax transaction for %s: %w", calculationID, err)
+	}
+	return nil
+}
+
+// GetCharge retrieves the card brand, last four digits and receipt URL of a
+// charge.
+func (c *stripeClient) GetCharge(ctx context.Context, chargeID string) (Charge, error) {
+	stripe.Key = c.secretKey
+
+	params := &stripe.ChargeParams{}
+	withContext(ctx, &params.Params)
+
+	ch, err := charge.Get(chargeID, params)
 	if err != nil {
-		return Event{}, fmt.Errorf("stripe: webhook verification failed: %w", err)
+		return Charge{}, fmt.Errorf("stripe: get charge %s: %w", chargeID, err)
+	}
+
+	out := Charge{ID: ch.ID, ReceiptURL: ch.ReceiptURL}
+	if ch.PaymentMethodDetails != nil && ch.PaymentMethodDetails.Card != nil {
+		out.CardBrand = string(ch.PaymentMethodDetails.Card.Brand)
+		out.CardLast4 = ch.PaymentMethodDetails.Card.Last4
 	}
+	return out, nil
+}
+
+// GetBalanceTransaction retrieves the amount, fee and net of a balance
+// transaction.
+func (c *stripeClient) GetBalanceTransaction(ctx context.Context, id string) (BalanceTransaction, error) {
+	stripe.Key = c.secretKey
 
-	return Event{
-		ID:      stripeEvent.ID,
-		Type:    string(stripeEvent.Type),
-		DataRaw: stripeEvent.Data.Raw,
+	params := &stripe.BalanceTransactionParams{}
+	withContext(ctx, &params.Params)
+
+	bt, err := balancetransaction.Get(id, params)
+	if err != nil {
+		return BalanceTransaction{}, fmt.Errorf("stripe: get balance transaction %s: %w", id, err)
+	}
+	return BalanceTransaction{
+		ID:          bt.ID,
+		AmountCents: bt.Amount,
+		FeeCents:    bt.Fee,
+		NetCents:    bt.Net,
+		Currency:    string(bt.Currency),
 	}, nil
 }
+
+// RefundPaymentIntent refunds the whole PaymentIntent with reason duplicate.
+// The idempotency key is derived from the PaymentIntent, so a retry after a
+// timeout returns the refund already made.
+func (c *stripeClient) RefundPaymentIntent(ctx context.Context, paymentIntentID string) (string, error) {
+	stripe.Key = c.secretKey
+
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+		Reason:        stripe.String(string(stripe.RefundReasonDuplicate)),
+	}
+	withContext(ctx, &params.Params)
+	params.SetIdempotencyKey("duplicate-refund-" + paymentIntentID)
+
+	re, err := refund.New(params)
+	if err != nil {
+		return "", fmt.Errorf("stripe: refund %s: %w", paymentIntentID, err)
+	}
+	return re.ID, nil
+}
+
+// VerifyWebhook validates the Stripe-Signature header against each secret in
+// turn and returns the parsed event from the first one that matches. Returns
+// an error if no secret matches or the tolerance window (300 seconds by
+// default in the Stripe SDK) has expired.
+//
+// Trying several secrets lets an endpoint's signing secret be rolled in the
+// Stripe dashboard without rejecting deliveries signed with the old one during
+// the overlap period.
+func (c *stripeClient) VerifyWebhook(payload []byte, sigHeader string, secrets []string) (Event, error) {
+	if len(secrets) == 0 {
+		return Event{}, errors.New("stripe: no webhook signing secret configured")
+	}
+
+	var errs []error
+	for _, secret := range secrets {
+		stripeEvent, err := webhook.ConstructEventWithOptions(payload, sigHeader, secret,
+			webhook.ConstructEventOptions{
+				IgnoreAPIVersionMismatch: true,
+			},
+		)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return Event{
+			ID:      stripeEvent.ID,
+			Type:    string(stripeEvent.Type),
+			DataRaw: stripeEvent.Data.Raw,
+		}, nil
+	}
+
+	return Event{}, fmt.Errorf("stripe: webhook verification failed: %w", errors.Join(errs...))
+}
@@ -2,9 +2,11 @@ package stripe_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+	"github.com/stripe/stripe-go/v82/webhook"
 )
 
 // ─── ExtractPaymentIntentID ───────────────────────────────────────────────────
@@ -50,6 +52,80 @@ func TestExtractPaymentIntentID_MalformedJSONReturnsError(t *testing.T) {
 	}
 }
 
+// ─── ExtractPaymentDetails ────────────────────────────────────────────────────
+
+func TestExtractPaymentDetails_ChargeReferencedByID(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"id":              "pi_1",
+		"amount_received": 14900,
+		"currency":        "eur",
+		"latest_charge":   "ch_1",
+	})
+
+	details, err := stripeinternal.ExtractPaymentDetails(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if details.AmountCents != 14900 || details.Currency != "eur" {
+		t.Errorf("unexpected amount: %+v", details)
+	}
+	if details.Charge.ID != "ch_1" || details.Charge.CardLast4 != "" {
+		t.Errorf("expected bare charge ID, got %+v", details.Charge)
+	}
+}
+
+func TestExtractPaymentDetails_ExpandedCharge(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"amount_received": 5900,
+		"currency":        "usd",
+		"latest_charge": map[string]any{
+			"id":          "ch_1",
+			"receipt_url": "https://pay.stripe.com/receipts/ch_1",
+			"payment_method_details": map[string]any{
+				"card": map[string]any{"brand": "visa", "last4": "4242"},
+			},
+		},
+	})
+
+	details, err := stripeinternal.ExtractPaymentDetails(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := stripeinternal.Charge{ID: "ch_1", CardBrand: "visa", CardLast4: "4242", ReceiptURL: "https://pay.stripe.com/receipts/ch_1"}
+	if details.Charge != want {
+		t.Errorf("expected %+v, got %+v", want, details.Charge)
+	}
+}
+
+func TestExtractPaymentDetails_LegacyChargesList(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"amount_received": 5900,
+		"currency":        "usd",
+		"charges": map[string]any{"data": []any{
+			map[string]any{
+				"id":                     "ch_old",
+				"payment_method_details": map[string]any{"card": map[string]any{"brand": "amex", "last4": "0005"}},
+			},
+		}},
+	})
+
+	details, err := stripeinternal.ExtractPaymentDetails(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if details.Charge.ID != "ch_old" || details.Charge.CardLast4 != "0005" {
+		t.Errorf("expected legacy charge, got %+v", details.Charge)
+	}
+}
+
+func TestExtractPaymentDetails_MissingCurrencyReturnsError(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{"amount_received": 5900})
+
+	if _, err := stripeinternal.ExtractPaymentDetails(stripeinternal.Event{DataRaw: raw}); err == nil {
+		t.Error("expected error for missing currency")
+	}
+}
+
 // ─── ExtractPIFromCharge ──────────────────────────────────────────────────────
 
 func TestExtractPIFromCharge_Success(t *testing.T) {
@@ -94,6 +170,164 @@ func TestExtractPIFromCharge_EmptyPIReturnsError(t *testing.T) {
 	}
 }
 
+// ─── ExtractRefund / ExtractDispute ───────────────────────────────────────────
+
+func TestExtractRefund_Success(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"id":              "ch_1",
+		"object":          "charge",
+		"payment_intent":  "pi_1",
+		"amount_refunded": 2500,
+		"currency":        "usd",
+	})
+	refund, err := stripeinternal.ExtractRefund(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := stripeinternal.Refund{ChargeID: "ch_1", PaymentIntentID: "pi_1", AmountRefunded: 2500, Currency: "usd"}
+	if refund != want {
+		t.Errorf("got %+v, want %+v", refund, want)
+	}
+}
+
+func TestExtractDispute_Success(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"id":             "dp_1",
+		"object":         "dispute",
+		"charge":         "ch_1",
+		"payment_intent": "pi_1",
+		"amount":         5900,
+		"currency":       "usd",
+		"status":         "needs_response",
+		"reason":         "fraudulent",
+		"balance_transactions": []map[string]any{
+			{"id": "txn_1", "amount": -5900, "fee": 1500, "net": -7400, "currency": "usd"},
+		},
+	})
+	dispute, err := stripeinternal.ExtractDispute(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dispute.ID != "dp_1" || dispute.PaymentIntentID != "pi_1" || dispute.AmountCents != 5900 || dispute.Reason != "fraudulent" {
+		t.Errorf("unexpected dispute: %+v", dispute)
+	}
+	if len(dispute.BalanceTransactions) != 1 || dispute.BalanceTransactions[0].FeeCents != 1500 {
+		t.Errorf("balance transactions: got %+v", dispute.BalanceTransactions)
+	}
+}
+
+func TestParseStoredEvent(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"charge.refunded","data":{"object":{"id":"ch_1","payment_intent":"pi_1"}}}`)
+
+	event, err := stripeinternal.ParseStoredEvent(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.ID != "evt_1" || event.Type != "charge.refunded" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if pi, err := stripeinternal.ExtractPIFromCharge(event); err != nil || pi != "pi_1" {
+		t.Errorf("ExtractPIFromCharge = %q, %v; want pi_1", pi, err)
+	}
+}
+
+// ─── ExtractSubscription ──────────────────────────────────────────────────────
+
+func TestExtractSubscription_PeriodOnSubscription(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"id":                   "sub_1",
+		"object":               "subscription",
+		"customer":             "cus_1",
+		"status":               "active",
+		"current_period_start": 1700000000,
+		"current_period_end":   1707776000,
+	})
+
+	sub, err := stripeinternal.ExtractSubscription(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.ID != "sub_1" || sub.CustomerID != "cus_1" || sub.Status != "active" {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+	if sub.CurrentPeriodEnd.Unix() != 1707776000 {
+		t.Errorf("expected period end 1707776000, got %v", sub.CurrentPeriodEnd)
+	}
+}
+
+func TestExtractSubscription_PeriodOnItems(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"id":       "sub_1",
+		"customer": "cus_1",
+		"status":   "trialing",
+		"items": map[string]any{"data": []any{
+			map[string]any{"current_period_start": 1700000000, "current_period_end": 1707776000},
+		}},
+	})
+
+	sub, err := stripeinternal.ExtractSubscription(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.CurrentPeriodStart.Unix() != 1700000000 || sub.CurrentPeriodEnd.Unix() != 1707776000 {
+		t.Errorf("expected period from items, got %v – %v", sub.CurrentPeriodStart, sub.CurrentPeriodEnd)
+	}
+}
+
+func TestExtractSubscription_MissingCustomerReturnsError(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{"id": "sub_1", "status": "active"})
+
+	if _, err := stripeinternal.ExtractSubscription(stripeinternal.Event{DataRaw: raw}); err == nil {
+		t.Error("expected error for missing customer")
+	}
+}
+
+// ─── ExtractPaidInvoice ───────────────────────────────────────────────────────
+
+func TestExtractPaidInvoice_SubscriptionUnderParent(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{
+		"id":             "in_1",
+		"customer":       "cus_1",
+		"customer_email": "owner@example.com",
+		"parent": map[string]any{
+			"subscription_details": map[string]any{"subscription": "sub_1"},
+		},
+		"lines": map[string]any{"data": []any{
+			map[string]any{"period": map[string]any{"start": 1700000000, "end": 1707776000}},
+		}},
+	})
+
+	sub, err := stripeinternal.ExtractPaidInvoice(stripeinternal.Event{DataRaw: raw})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.ID != "sub_1" || sub.Email != "owner@example.com" || sub.Status != "" {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+	if sub.CurrentPeriodEnd.Unix() != 1707776000 {
+		t.Errorf("expected period end from invoice line, got %v", sub.CurrentPeriodEnd)
+	}
+}
+
+func TestExtractPaidInvoice_OneOffInvoice(t *testing.T) {
+	raw, _ := json.Marshal(map[string]any{"id": "in_1", "customer": "cus_1"})
+
+	_, err := stripeinternal.ExtractPaidInvoice(stripeinternal.Event{DataRaw: raw})
+	if !errors.Is(err, stripeinternal.ErrNoSubscription) {
+		t.Errorf("expected ErrNoSubscription, got %v", err)
+	}
+}
+
+// ─── ToUpsertSubscriptionParams ───────────────────────────────────────────────
+
+func TestToUpsertSubscriptionParams_MissingFieldsAreNull(t *testing.T) {
+	p := stripeinternal.ToUpsertSubscriptionParams(stripeinternal.Subscription{ID: "sub_1", CustomerID: "cus_1"})
+
+	if p.Email.Valid || p.Status.Valid || p.CurrentPeriodStart.Valid || p.CurrentPeriodEnd.Valid {
+		t.Errorf("expected absent fields to be NULL, got %+v", p)
+	}
+}
+
 // ─── ToUpsertParams ───────────────────────────────────────────────────────────
 
 func TestToUpsertParams_SetsAllFields(t *testing.T) {
@@ -135,4 +369,48 @@ func TestToMarkFailedParams_SetsErrorMessage(t *testing.T) {
 
 type testError struct{ msg string }
 
-func (e *testError) Error() string { return e.msg }
\ No newline at end of file
+func (e *testError) Error() string { return e.msg }
+
+// ─── VerifyWebhook ────────────────────────────────────────────────────────────
+
+func signedTestEvent(secret string) *webhook.SignedPayload {
+	return webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload: []byte(`{"id":"evt_rotate","object":"event","type":"payment_intent.succeeded","data":{"object":{"id":"pi_1"}}}`),
+		Secret:  secret,
+	})
+}
+
+func TestVerifyWebhook_AcceptsAnyConfiguredSecret(t *testing.T) {
+	client := stripeinternal.NewClient("sk_test")
+
+	// During rotation both the new and the old secret are configured; events
+	// signed with either must verify.
+	for _, signer := range []string{"whsec_new", "whsec_old"} {
+		signed := signedTestEvent(signer)
+		event, err := client.VerifyWebhook(signed.Payload, signed.Header, []string{"whsec_new", "whsec_old"})
+		if err != nil {
+			t.Fatalf("signed with %s: unexpected error: %v", signer, err)
+		}
+		if event.ID != "evt_rotate" {
+			t.Errorf("signed with %s: expected evt_rotate, got %q", signer, event.ID)
+		}
+	}
+}
+
+func TestVerifyWebhook_RejectsUnknownSecret(t *testing.T) {
+	client := stripeinternal.NewClient("sk_test")
+	signed := signedTestEvent("whsec_attacker")
+
+	if _, err := client.VerifyWebhook(signed.Payload, signed.Header, []string{"whsec_new", "whsec_old"}); err == nil {
+		t.Fatal("expected verification to fail for an unknown secret")
+	}
+}
+
+func TestVerifyWebhook_NoSecretsConfigured(t *testing.T) {
+	client := stripeinternal.NewClient("sk_test")
+	signed := signedTestEvent("whsec_new")
+
+	if _, err := client.VerifyWebhook(signed.Payload, signed.Header, nil); err == nil {
+		t.Fatal("expected an error with no secrets configured")
+	}
+}
@@ -0,0 +1,174 @@
+// Package testdb gives integration tests a migrated Postgres database.
+//
+// Open uses DATABASE_URL when it is set, and otherwise starts a throwaway
+// Postgres container through dockertest and applies migrations/*.up.sql to
+// it. With neither the test fails: a database test that cannot reach a
+// database has not passed.
+//
+// The tests that use it build only with the integration tag, so a plain
+// `go test ./...` needs no database and CI opts in with
+//
+//	go test -tags integration ./...
+//
+// A package that calls Open must clean up the container from TestMain:
+//
+//	func TestMain(m *testing.M) { os.Exit(testdb.Main(m)) }
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+// defaultImage matches the Postgres major version in docker-compose.yml.
+const defaultImage = "postgres:16-alpine"
+
+// containerTTL is how long docker keeps the container if the test binary
+// dies before Main removes it.
+const containerTTL = 10 * time.Minute
+
+var (
+	once         sync.Once
+	pool         *dockertest.Pool
+	container    *dockertest.Resource
+	containerDSN string
+	startErr     error
+)
+
+// Open returns a pool on a migrated database, closed when t ends. The database
+// is shared by every test in the package; tests must not assume it is empty.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		return open(t, dsn)
+	}
+	return OpenContainer(t)
+}
+
+// OpenContainer is Open without the DATABASE_URL fallback, for tests that
+// write rows a developer's database should not keep, such as question
+// definitions.
+func OpenContainer(t testing.TB) *sql.DB {
+	t.Helper()
+	once.Do(func() { containerDSN, startErr = startContainer() })
+	if startErr != nil {
+		t.Fatalf("testdb: no database (set DATABASE_URL or start docker): %v", startErr)
+	}
+	return open(t, containerDSN)
+}
+
+// Main runs the package's tests and removes the container, if one was
+// started. Use it as the body of TestMain.
+func Main(m *testing.M) int {
+	code := m.Run()
+	if container != nil {
+		_ = pool.Purge(container)
+	}
+	return code
+}
+
+func open(t testing.TB, dsn string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		t.Fatalf("ping: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// ─── CONTAINER ────────────────────────────────────────────────────────────────
+
+func startContainer() (string, error) {
+	var err error
+	if pool, err = dockertest.NewPool(""); err != nil {
+		return "", fmt.Errorf("docker: %w", err)
+	}
+	if err := pool.Client.Ping(); err != nil {
+		return "", fmt.Errorf("docker: %w", err)
+	}
+
+	image := os.Getenv("TESTDB_IMAGE")
+	if image == "" {
+		image = defaultImage
+	}
+	repository, tag := splitImage(image)
+	container, err = pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: repository,
+		Tag:        tag,
+		Env:        []string{"POSTGRES_PASSWORD=test", "POSTGRES_DB=arm_test"},
+		Labels:     map[string]string{"arm-testdb": ""},
+	}, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return "", fmt.Errorf("start %s: %w", image, err)
+	}
+	_ = container.Expire(uint(containerTTL.Seconds()))
+
+	dsn := fmt.Sprintf("postgres://postgres:test@%s/arm_test?sslmode=disable", container.GetHostPort("5432/tcp"))
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	// The image's init scripts run on a socket-only server first, so a
+	// successful ping over TCP means the final server is up.
+	pool.MaxWait = 60 * time.Second
+	if err := pool.Retry(db.Ping); err != nil {
+		return "", fmt.Errorf("postgres not ready after %s: %w", pool.MaxWait, err)
+	}
+	if err := migrate(db); err != nil {
+		return "", err
+	}
+	return dsn, nil
+}
+
+// splitImage splits "repository[:tag]"; the tag defaults to latest. A colon
+// before the last slash belongs to a registry host, not a tag.
+func splitImage(image string) (repository, tag string) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return image, "latest"
+	}
+	return image[:i], image[i+1:]
+}
+
+// migrate applies every up migration in order, as `migrate up` would.
+func migrate(db *sql.DB) error {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "migrations")
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		return fmt.Errorf("no migrations found in %s", dir)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		sqlText, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(string(sqlText)); err != nil {
+			return fmt.Errorf("migration %s: %w", filepath.Base(f), err)
+		}
+	}
+	return nil
+}
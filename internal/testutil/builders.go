@@ -0,0 +1,72 @@
+package testutil
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+)
+
+// ─── BUILDERS ─────────────────────────────────────────────────────────────────
+//
+// Each builder returns a row with every field a handler needs set to a
+// plausible value; options change the rest. IDs are random, so a golden test
+// sets them (and any timestamps) with an option.
+
+// Epoch is a fixed time for rows a golden file records.
+var Epoch = time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+// Session returns an unpaid session with a fresh ID and anon token.
+func Session(opts ...func(*db.Session)) db.Session {
+	id := uuid.New()
+	s := db.Session{
+		ID:        id,
+		AnonToken: "test_tok_" + id.String(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// Report returns a ready report for a fresh session, as GET /api/report
+// reads it.
+func Report(opts ...func(*db.GetReportByAccessTokenRow)) db.GetReportByAccessTokenRow {
+	id := uuid.New()
+	r := db.GetReportByAccessTokenRow{
+		ID:          id,
+		SessionID:   uuid.New(),
+		AccessToken: "tok_" + id.String(),
+		Status:      db.ReportStatusReady,
+		BizName:     sql.NullString{String: "Acme", Valid: true},
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// Risk returns a scored risk for questionID at rank, with its score and tier
+// worked out from p and i the way the scorer does.
+func Risk(rank int, questionID string, p, i int, opts ...func(*db.RiskResult)) db.RiskResult {
+	r := db.RiskResult{
+		ID:          uuid.New(),
+		QuestionID:  questionID,
+		Rank:        int16(rank),
+		RiskName:    questionID,
+		Probability: int16(p),
+		Impact:      int16(i),
+		Score:       int16(p * i),
+		Tier:        db.RiskTier(scoring.GetTier(p, i)),
+		Hedge:       "Hedge for " + questionID,
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
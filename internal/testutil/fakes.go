@@ -0,0 +1,148 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/captcha"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
+	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/worker"
+)
+
+// ─── FAKES ────────────────────────────────────────────────────────────────────
+
+// Stripe is a controllable Stripe client.
+type Stripe struct {
+	PI           stripeinternal.PaymentIntent
+	ClientSecret string
+	CreateErr    error
+	GetSecretErr error
+	VerifyEvent  stripeinternal.Event
+	VerifyErr    error
+	Created      []stripeinternal.CreatePaymentIntentParams
+	TaxCalc      stripeinternal.TaxCalculation
+	TaxErr       error
+	TaxRequests  []stripeinternal.TaxParams
+	RequestIDs   []string // requestid.From(ctx) per CreatePaymentIntent
+	Refunds      []string // payment intents passed to RefundPaymentIntent
+}
+
+func (s *Stripe) CreatePaymentIntent(ctx context.Context, p stripeinternal.CreatePaymentIntentParams) (stripeinternal.PaymentIntent, error) {
+	s.Created = append(s.Created, p)
+	s.RequestIDs = append(s.RequestIDs, requestid.From(ctx))
+	return s.PI, s.CreateErr
+}
+
+func (s *Stripe) GetClientSecret(_ context.Context, _ string) (string, error) {
+	return s.ClientSecret, s.GetSecretErr
+}
+
+func (s *Stripe) CalculateTax(_ context.Context, p stripeinternal.TaxParams) (stripeinternal.TaxCalculation, error) {
+	s.TaxRequests = append(s.TaxRequests, p)
+	return s.TaxCalc, s.TaxErr
+}
+
+func (s *Stripe) RecordTaxTransaction(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (s *Stripe) GetCharge(_ context.Context, id string) (stripeinternal.Charge, error) {
+	return stripeinternal.Charge{ID: id}, nil
+}
+
+func (s *Stripe) GetBalanceTransaction(_ context.Context, id string) (stripeinternal.BalanceTransaction, error) {
+	return stripeinternal.BalanceTransaction{ID: id, AmountCents: 5900, FeeCents: 201, NetCents: 5699, Currency: "usd"}, nil
+}
+
+func (s *Stripe) RefundPaymentIntent(_ context.Context, id string) (string, error) {
+	s.Refunds = append(s.Refunds, id)
+	return "re_" + id, nil
+}
+
+func (s *Stripe) VerifyWebhook(_ []byte, _ string, _ []string) (stripeinternal.Event, error) {
+	return s.VerifyEvent, s.VerifyErr
+}
+
+// Worker records enqueued jobs and reports stats as its backlog.
+type Worker struct {
+	Enqueued []uuid.UUID
+	Err      error
+	Stats    worker.QueueStats
+	draining bool
+}
+
+func (w *Worker) Enqueue(_ context.Context, id uuid.UUID) error {
+	w.Enqueued = append(w.Enqueued, id)
+	return w.Err
+}
+
+func (w *Worker) QueueStats() worker.QueueStats {
+	return w.Stats
+}
+
+func (w *Worker) Drain()         { w.draining = true }
+func (w *Worker) Draining() bool { return w.draining }
+
+// Mailer captures sent emails.
+// Mailer is locked because some handlers send after responding.
+type Mailer struct {
+	mu           sync.Mutex
+	Receipts     []email.ReceiptParams
+	ReportReadys []email.ReportReadyParams
+	Err          error
+}
+
+func (m *Mailer) SendReceipt(_ context.Context, p email.ReceiptParams) (email.Sent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Receipts = append(m.Receipts, p)
+	return email.Sent{ProviderID: "msg_receipt", Subject: "Payment Confirmed"}, m.Err
+}
+
+func (m *Mailer) SendFeedbackRequest(context.Context, email.FeedbackRequestParams) (email.Sent, error) {
+	return email.Sent{ProviderID: "msg_feedback", Subject: "How did your Risk Assessment do?"}, nil
+}
+
+func (m *Mailer) SendReportReady(_ context.Context, p email.ReportReadyParams) (email.Sent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ReportReadys = append(m.ReportReadys, p)
+	return email.Sent{ProviderID: "msg_report", Subject: "Your Risk Assessment is Ready"}, m.Err
+}
+
+// WaitReportReadys waits for n report-ready emails sent in the background.
+func (m *Mailer) WaitReportReadys(t *testing.T, n int) []email.ReportReadyParams {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		m.mu.Lock()
+		sent := append([]email.ReportReadyParams(nil), m.ReportReadys...)
+		m.mu.Unlock()
+		if len(sent) >= n {
+			return sent
+		}
+	}
+	t.Fatalf("expected %d report-ready emails", n)
+	return nil
+}
+
+// Captcha accepts exactly the token "ok"; err overrides the result.
+type Captcha struct {
+	Tokens []string
+	Err    error
+}
+
+func (c *Captcha) Verify(_ context.Context, token, _ string) error {
+	c.Tokens = append(c.Tokens, token)
+	if c.Err != nil {
+		return c.Err
+	}
+	if token != "ok" {
+		return captcha.ErrRejected
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ─── GOLDEN FILES ─────────────────────────────────────────────────────────────
+//
+// A golden file pins a whole response body, so a change to any field shows
+// up in review as a diff of testdata rather than going unnoticed because no
+// assertion named it. Rewrite them after an intended change with
+//
+//	go test ./internal/api -run TestName -update
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// AssertGoldenJSON compares got with testdata/<name>.golden.json in the
+// calling package. Both are compared indented with sorted keys, so only a
+// change in content fails the test.
+func AssertGoldenJSON(t *testing.T, name string, got []byte) {
+	t.Helper()
+	want, err := canonicalJSON(got)
+	if err != nil {
+		t.Fatalf("golden %s: response is not JSON: %v (raw: %s)", name, err, got)
+	}
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden %s: %v (run with -update to create it)", name, err)
+	}
+	golden, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatalf("golden %s: %s is not JSON: %v", name, path, err)
+	}
+	if !bytes.Equal(golden, want) {
+		t.Errorf("golden %s: response differs from %s (run with -update to accept it)\n--- want\n%s--- got\n%s", name, path, golden, want)
+	}
+}
+
+// canonicalJSON re-encodes raw indented, with object keys sorted.
+func canonicalJSON(raw []byte) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
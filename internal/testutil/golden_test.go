@@ -0,0 +1,21 @@
+package testutil
+
+import "testing"
+
+func TestCanonicalJSON_IgnoresKeyOrderAndLayout(t *testing.T) {
+	a, err := canonicalJSON([]byte(`{"b":1,"a":{"y":[1,2],"x":0.10}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := canonicalJSON([]byte("{\n  \"a\": {\"x\": 0.10, \"y\": [1, 2]},\n  \"b\": 1\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(a) != string(b) {
+		t.Errorf("canonical forms differ:\n%s\n%s", a, b)
+	}
+	// Numbers keep their text, so 0.10 is not rounded away.
+	if want := "{\n  \"a\": {\n    \"x\": 0.10,\n    \"y\": [\n      1,\n      2\n    ]\n  },\n  \"b\": 1\n}\n"; string(a) != want {
+		t.Errorf("canonicalJSON = %q, want %q", a, want)
+	}
+}
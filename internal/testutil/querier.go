@@ -0,0 +1,786 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── QUERIER ──────────────────────────────────────────────────────────────────
+
+// Querier satisfies db.Querier with in-memory state.
+// Fields may be set per-test to control behaviour.
+type Querier struct {
+	db.Querier                             // embedded to panic on unimplemented methods
+	Sessions         map[string]db.Session // keyed by anon_token
+	SessionsByID     map[uuid.UUID]db.Session
+	Reports          map[string]db.GetReportByAccessTokenRow // keyed by access_token
+	RiskResults      map[uuid.UUID][]db.RiskResult
+	Products         map[string]db.Product
+	Consultations    map[uuid.UUID]db.UpsertConsultationRequestParams // keyed by report_id
+	Entitled         map[string]db.Subscription                       // keyed by email
+	Invoices         map[string]db.GetInvoiceByAccessTokenRow         // keyed by access_token
+	Subscriptions    []db.UpsertSubscriptionParams
+	StripeEvents     []db.StripeEvent
+	Jobs             []db.Job // only read, by the queries that check for pending jobs
+	Payments         []db.UpsertPaymentParams
+	Questions        []db.QuestionDefinition
+	Answers          map[uuid.UUID][]db.GetAnswersBySessionRow
+	EmailLog         map[string]*db.EmailLog // keyed by provider_id
+	Playbooks        map[string]db.PlaybookSnippet
+	FeatureFlags     []db.FeatureFlag
+	Assignments      []db.ExperimentAssignment
+	ExperimentStats  []db.GetExperimentStatsRow
+	Transcripts      []db.AiTranscript
+	AIEdits          []db.AiEdit
+	SupportNotes     []db.SupportNote
+	Sections         []db.QuestionSection
+	TierThemes       []db.TierTheme
+	Feedback         map[string]*db.GetFeedbackByTokenRow // keyed by token
+	Suppressed       map[string]string                    // email_hash → reason
+	ShadowScores     []db.SummarizeShadowScoresRow
+	ShadowSince      time.Time
+	ReportsAhead     int64
+	CreateSessionErr error
+	UpsertAnswerErr  error
+}
+
+// NewQuerier returns a Querier with empty state, three questions and three
+// products (standard, premium and an inactive retired SKU).
+func NewQuerier() *Querier {
+	return &Querier{
+		Sessions:      make(map[string]db.Session),
+		SessionsByID:  make(map[uuid.UUID]db.Session),
+		Reports:       make(map[string]db.GetReportByAccessTokenRow),
+		RiskResults:   make(map[uuid.UUID][]db.RiskResult),
+		Consultations: make(map[uuid.UUID]db.UpsertConsultationRequestParams),
+		Entitled:      make(map[string]db.Subscription),
+		Invoices:      make(map[string]db.GetInvoiceByAccessTokenRow),
+		Answers:       make(map[uuid.UUID][]db.GetAnswersBySessionRow),
+		EmailLog:      make(map[string]*db.EmailLog),
+		Playbooks:     make(map[string]db.PlaybookSnippet),
+		Feedback:      make(map[string]*db.GetFeedbackByTokenRow),
+		Suppressed:    make(map[string]string),
+		Questions: []db.QuestionDefinition{
+			{ID: "q_x", SectionID: db.SectionIDSnapshot, Type: db.QuestionTypeText, ScoringConfig: json.RawMessage(`{"type":"text","threshold":10,"p_short":2,"p_long":6,"i_short":2,"i_long":8}`)},
+			{ID: "q_cash_runway", SectionID: db.SectionIDDependency, Type: db.QuestionTypeRadio, Required: true, ScoringConfig: json.RawMessage(`{"type":"radio","opts":["< 3 months","3–6 months","> 6 months"],"p_scores":[9,6,2],"i_scores":[9,6,2]}`)},
+			{ID: "q_key_person", SectionID: db.SectionIDDependency, Type: db.QuestionTypeRadio, Required: true, ScoringConfig: json.RawMessage(`{"type":"radio","opts":["Yes","No"],"p_scores":[8,2],"i_scores":[9,2]}`)},
+		},
+		Products: map[string]db.Product{
+			"standard": {Sku: "standard", Name: "Report", PriceCents: 5900, Currency: "usd", ReportType: db.ReportTypeStandard, Active: true},
+			"premium":  {Sku: "premium", Name: "Premium", PriceCents: 14900, Currency: "usd", ReportType: db.ReportTypePremium, Active: true},
+			"retired":  {Sku: "retired", Name: "Old", PriceCents: 2900, Currency: "usd", ReportType: db.ReportTypeStandard},
+		},
+	}
+}
+
+// AddSession stores s under its anon token and its ID.
+func (q *Querier) AddSession(token string, s db.Session) {
+	q.Sessions[token] = s
+	q.SessionsByID[s.ID] = s
+}
+
+func (q *Querier) CreateSession(_ context.Context, p db.CreateSessionParams) (db.Session, error) {
+	if q.CreateSessionErr != nil {
+		return db.Session{}, q.CreateSessionErr
+	}
+	s := db.Session{
+		ID:        uuid.New(),
+		AnonToken: p.AnonToken,
+		IpHash:    p.IpHash,
+		Partner:   p.Partner,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	q.AddSession(p.AnonToken, s)
+	return s, nil
+}
+
+func (q *Querier) GetSessionByAnonToken(_ context.Context, token string) (db.Session, error) {
+	s, ok := q.Sessions[token]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+func (q *Querier) GetSessionByID(_ context.Context, id uuid.UUID) (db.Session, error) {
+	s, ok := q.SessionsByID[id]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+func (q *Querier) UpdateSessionContext(_ context.Context, p db.UpdateSessionContextParams) (db.Session, error) {
+	s, ok := q.SessionsByID[p.ID]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	s.BizName = p.BizName
+	s.Industry = p.Industry
+	s.Stage = p.Stage
+	q.SessionsByID[p.ID] = s
+	for tok, sess := range q.Sessions {
+		if sess.ID == p.ID {
+			q.Sessions[tok] = s
+		}
+	}
+	return s, nil
+}
+
+func (q *Querier) GetAllQuestionDefinitions(_ context.Context) ([]db.QuestionDefinition, error) {
+	return q.Questions, nil
+}
+
+func (q *Querier) GetAnswersBySession(_ context.Context, sessionID uuid.UUID) ([]db.GetAnswersBySessionRow, error) {
+	return q.Answers[sessionID], nil
+}
+
+func (q *Querier) ListQuestionSections(_ context.Context) ([]db.QuestionSection, error) {
+	return q.Sections, nil
+}
+
+func (q *Querier) UpdateQuestionSection(_ context.Context, p db.UpdateQuestionSectionParams) (db.QuestionSection, error) {
+	for i, sec := range q.Sections {
+		if sec.ID == p.ID {
+			q.Sections[i] = db.QuestionSection{ID: p.ID, Title: p.Title, Description: p.Description, DisplayOrder: p.DisplayOrder, Gated: p.Gated}
+			return q.Sections[i], nil
+		}
+	}
+	return db.QuestionSection{}, sql.ErrNoRows
+}
+
+func (q *Querier) ListTierThemes(_ context.Context) ([]db.TierTheme, error) {
+	return q.TierThemes, nil
+}
+
+func (q *Querier) UpdateTierTheme(_ context.Context, p db.UpdateTierThemeParams) (db.TierTheme, error) {
+	theme := db.TierTheme{Tier: p.Tier, Label: p.Label, Color: p.Color, Description: p.Description, Cadence: p.Cadence}
+	for i, t := range q.TierThemes {
+		if t.Tier == p.Tier {
+			q.TierThemes[i] = theme
+			return theme, nil
+		}
+	}
+	q.TierThemes = append(q.TierThemes, theme)
+	return theme, nil
+}
+
+func (q *Querier) UpsertAnswer(_ context.Context, p db.UpsertAnswerParams) (db.Answer, error) {
+	if q.UpsertAnswerErr != nil {
+		return db.Answer{}, q.UpsertAnswerErr
+	}
+	q.Answers[p.SessionID] = append(q.Answers[p.SessionID], db.GetAnswersBySessionRow{
+		SessionID:  p.SessionID,
+		QuestionID: p.QuestionID,
+		AnswerText: p.AnswerText,
+		Skipped:    p.Skipped,
+	})
+	return db.Answer{
+		ID:         uuid.New(),
+		SessionID:  p.SessionID,
+		QuestionID: p.QuestionID,
+		AnswerText: p.AnswerText,
+	}, nil
+}
+
+func (q *Querier) GetReportByAccessToken(_ context.Context, token string) (db.GetReportByAccessTokenRow, error) {
+	r, ok := q.Reports[token]
+	if !ok {
+		return db.GetReportByAccessTokenRow{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (q *Querier) RotateReportAccessToken(_ context.Context, arg db.RotateReportAccessTokenParams) (string, error) {
+	r, ok := q.Reports[arg.AccessToken]
+	if !ok || r.ID != arg.ID {
+		return "", sql.ErrNoRows
+	}
+	delete(q.Reports, arg.AccessToken)
+	newToken := "tok_" + uuid.NewString()
+	r.AccessToken = newToken
+	q.Reports[newToken] = r
+	return newToken, nil
+}
+
+func (q *Querier) CountReportsAhead(context.Context, uuid.UUID) (int64, error) {
+	return q.ReportsAhead, nil
+}
+
+func (q *Querier) GetReportByID(_ context.Context, id uuid.UUID) (db.Report, error) {
+	for _, r := range q.Reports {
+		if r.ID == id {
+			return db.Report{ID: r.ID, SessionID: r.SessionID, Status: r.Status, RevokedAt: r.RevokedAt, RevokedReason: r.RevokedReason}, nil
+		}
+	}
+	return db.Report{}, sql.ErrNoRows
+}
+
+func (q *Querier) ListAITranscriptsByReport(_ context.Context, reportID uuid.UUID) ([]db.AiTranscript, error) {
+	var out []db.AiTranscript
+	for _, t := range q.Transcripts {
+		if t.ReportID == reportID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) ListAIEditsByReport(_ context.Context, reportID uuid.UUID) ([]db.AiEdit, error) {
+	var out []db.AiEdit
+	for _, e := range q.AIEdits {
+		if e.ReportID == reportID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) InsertSupportNote(_ context.Context, p db.InsertSupportNoteParams) (db.SupportNote, error) {
+	n := db.SupportNote{ID: uuid.New(), SessionID: p.SessionID, ReportID: p.ReportID, Author: p.Author, Body: p.Body, CreatedAt: time.Now()}
+	q.SupportNotes = append(q.SupportNotes, n)
+	return n, nil
+}
+
+func (q *Querier) ListSupportNotesBySession(_ context.Context, sessionID uuid.UUID) ([]db.SupportNote, error) {
+	var out []db.SupportNote
+	for _, n := range q.SupportNotes {
+		if n.SessionID == sessionID {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) RevokeReport(ctx context.Context, p db.RevokeReportParams) (db.Report, error) {
+	for token, r := range q.Reports {
+		if r.ID == p.ID && !r.RevokedAt.Valid {
+			r.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			r.RevokedReason = sql.NullString{String: p.RevokedReason, Valid: true}
+			q.Reports[token] = r
+			return q.GetReportByID(ctx, p.ID)
+		}
+	}
+	return db.Report{}, sql.ErrNoRows
+}
+
+func (q *Querier) GetRiskResultsByReport(_ context.Context, id uuid.UUID) ([]db.RiskResult, error) {
+	return q.RiskResults[id], nil
+}
+
+func (q *Querier) UpsertStripeEvent(_ context.Context, _ db.UpsertStripeEventParams) (db.StripeEvent, error) {
+	return db.StripeEvent{}, nil
+}
+
+func (q *Querier) GetStripeEvent(_ context.Context, id string) (db.StripeEvent, error) {
+	for _, e := range q.StripeEvents {
+		if e.StripeEventID == id {
+			return e, nil
+		}
+	}
+	return db.StripeEvent{}, sql.ErrNoRows
+}
+
+func (q *Querier) MarkStripeEventProcessed(_ context.Context, _ string) (db.StripeEvent, error) {
+	return db.StripeEvent{}, nil
+}
+
+func (q *Querier) MarkStripeEventFailed(_ context.Context, _ db.MarkStripeEventFailedParams) (db.StripeEvent, error) {
+	return db.StripeEvent{}, nil
+}
+
+func (q *Querier) MarkSessionPaymentFailed(_ context.Context, _ sql.NullString) (db.Session, error) {
+	return db.Session{}, nil
+}
+
+func (q *Querier) AttachStripeCustomer(_ context.Context, p db.AttachStripeCustomerParams) (db.Session, error) {
+	s, ok := q.SessionsByID[p.ID]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	s.StripePaymentIntent = p.StripePaymentIntent
+	s.Email = p.Email
+	s.ProductSku = p.ProductSku
+	q.SessionsByID[p.ID] = s
+	return s, nil
+}
+
+func (q *Querier) GetProductBySKU(_ context.Context, sku string) (db.Product, error) {
+	p, ok := q.Products[sku]
+	if !ok {
+		return db.Product{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (q *Querier) UpsertConsultationRequest(_ context.Context, p db.UpsertConsultationRequestParams) (db.ConsultationRequest, error) {
+	q.Consultations[p.ReportID] = p
+	return db.ConsultationRequest{ID: uuid.New(), ReportID: p.ReportID, SessionID: p.SessionID, Note: p.Note}, nil
+}
+
+func (q *Querier) GetCompletionFunnelStats(_ context.Context) (db.GetCompletionFunnelStatsRow, error) {
+	return db.GetCompletionFunnelStatsRow{TotalSessions: 10, Started: 8, Paid: 5, ReportDelivered: 4}, nil
+}
+
+func (q *Querier) GetConsultationStats(_ context.Context) (db.GetConsultationStatsRow, error) {
+	return db.GetConsultationStatsRow{ReportsDelivered: 4, Requests: int64(len(q.Consultations))}, nil
+}
+
+func (q *Querier) GetEntitledSubscription(_ context.Context, email string) (db.Subscription, error) {
+	sub, ok := q.Entitled[email]
+	if !ok {
+		return db.Subscription{}, sql.ErrNoRows
+	}
+	return sub, nil
+}
+
+func (q *Querier) UpsertSubscription(_ context.Context, p db.UpsertSubscriptionParams) (db.Subscription, error) {
+	q.Subscriptions = append(q.Subscriptions, p)
+	return db.Subscription{ID: uuid.New(), StripeSubscriptionID: p.StripeSubscriptionID}, nil
+}
+
+func (q *Querier) GetInvoiceByAccessToken(_ context.Context, token string) (db.GetInvoiceByAccessTokenRow, error) {
+	row, ok := q.Invoices[token]
+	if !ok {
+		return db.GetInvoiceByAccessTokenRow{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (q *Querier) emailByProviderID(id sql.NullString, mark func(*db.EmailLog)) (db.EmailLog, error) {
+	e, ok := q.EmailLog[id.String]
+	if !ok {
+		return db.EmailLog{}, sql.ErrNoRows
+	}
+	mark(e)
+	return *e, nil
+}
+
+func (q *Querier) MarkEmailOpened(_ context.Context, id sql.NullString) (db.EmailLog, error) {
+	return q.emailByProviderID(id, func(e *db.EmailLog) {
+		e.OpenedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	})
+}
+
+func (q *Querier) MarkEmailClicked(_ context.Context, id sql.NullString) (db.EmailLog, error) {
+	return q.emailByProviderID(id, func(e *db.EmailLog) {
+		e.ClickedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		e.OpenedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	})
+}
+
+func (q *Querier) MarkEmailBounced(_ context.Context, arg db.MarkEmailBouncedParams) (db.EmailLog, error) {
+	return q.emailByProviderID(arg.ProviderID, func(e *db.EmailLog) {
+		e.BouncedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		e.Error = arg.Reason
+	})
+}
+
+func (q *Querier) SuppressEmail(_ context.Context, arg db.SuppressEmailParams) error {
+	if _, ok := q.Suppressed[arg.EmailHash]; !ok {
+		q.Suppressed[arg.EmailHash] = arg.Reason
+	}
+	return nil
+}
+
+func (q *Querier) SuppressSessionEmail(ctx context.Context, arg db.SuppressSessionEmailParams) error {
+	if hash := q.SessionsByID[arg.SessionID].EmailHash; hash.Valid {
+		return q.SuppressEmail(ctx, db.SuppressEmailParams{EmailHash: hash.String, Reason: arg.Reason})
+	}
+	return nil
+}
+
+func (q *Querier) SummarizeShadowScores(_ context.Context, since time.Time) ([]db.SummarizeShadowScoresRow, error) {
+	q.ShadowSince = since
+	return q.ShadowScores, nil
+}
+
+func (q *Querier) GetFeedbackByToken(_ context.Context, token string) (db.GetFeedbackByTokenRow, error) {
+	if f, ok := q.Feedback[token]; ok {
+		return *f, nil
+	}
+	return db.GetFeedbackByTokenRow{}, sql.ErrNoRows
+}
+
+func (q *Querier) RecordFeedback(_ context.Context, arg db.RecordFeedbackParams) (db.Feedback, error) {
+	f, ok := q.Feedback[arg.Token]
+	if !ok {
+		return db.Feedback{}, sql.ErrNoRows
+	}
+	if !arg.ConsentQuote || f.Testimonial != arg.Testimonial {
+		f.ApprovedAt = sql.NullTime{}
+	}
+	f.Rating, f.Testimonial, f.ConsentQuote, f.ConsentName = arg.Rating, arg.Testimonial, arg.ConsentQuote, arg.ConsentName
+	f.RespondedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return db.Feedback{
+		ReportID:     f.ReportID,
+		Token:        f.Token,
+		Rating:       f.Rating,
+		Testimonial:  f.Testimonial,
+		ConsentQuote: f.ConsentQuote,
+		ConsentName:  f.ConsentName,
+		RespondedAt:  f.RespondedAt,
+		ApprovedAt:   f.ApprovedAt,
+	}, nil
+}
+
+func (q *Querier) LogEmail(_ context.Context, arg db.LogEmailParams) (db.EmailLog, error) {
+	return db.EmailLog{ID: uuid.New(), ToAddress: arg.ToAddress, Template: arg.Template, ProviderID: arg.ProviderID}, nil
+}
+
+func (q *Querier) ListDeliverableReportsByEmail(_ context.Context, arg db.ListDeliverableReportsByEmailParams) ([]db.ListDeliverableReportsByEmailRow, error) {
+	out := []db.ListDeliverableReportsByEmailRow{}
+	for token, r := range q.Reports {
+		if !strings.EqualFold(r.Email.String, arg.Email) || r.Status != db.ReportStatusReady || r.RevokedAt.Valid ||
+			q.SessionsByID[r.SessionID].PaymentStatus != db.PaymentStatusPaid || len(out) == int(arg.MaxRows) {
+			continue
+		}
+		out = append(out, db.ListDeliverableReportsByEmailRow{
+			ID:          r.ID,
+			SessionID:   r.SessionID,
+			AccessToken: token,
+			BizName:     r.BizName,
+			Email:       r.Email,
+		})
+	}
+	return out, nil
+}
+
+func (q *Querier) GetDuplicateCredit(_ context.Context, _ db.GetDuplicateCreditParams) (uuid.UUID, error) {
+	return uuid.Nil, sql.ErrNoRows
+}
+
+func (q *Querier) GetDuplicatePurchase(_ context.Context, _ uuid.UUID) (db.GetDuplicatePurchaseRow, error) {
+	return db.GetDuplicatePurchaseRow{}, sql.ErrNoRows
+}
+
+func (q *Querier) ListUnresolvedDuplicatePurchases(_ context.Context) ([]db.ListUnresolvedDuplicatePurchasesRow, error) {
+	return nil, nil
+}
+
+func (q *Querier) AssignInvoiceNumber(_ context.Context, _ uuid.UUID) (int64, error) {
+	return 1000, nil
+}
+
+func (q *Querier) ListStripeEventsForExport(_ context.Context, p db.ListStripeEventsForExportParams) ([]db.StripeEvent, error) {
+	out := []db.StripeEvent{}
+	for _, e := range q.StripeEvents {
+		for _, t := range p.Types {
+			if e.Type == t && !e.ReceivedAt.Before(p.ReceivedFrom) && e.ReceivedAt.Before(p.ReceivedTo) {
+				out = append(out, e)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) researchReports(from, to time.Time) []db.GetReportByAccessTokenRow {
+	var out []db.GetReportByAccessTokenRow
+	for _, r := range q.Reports {
+		at := r.GeneratedAt.Time
+		if r.Status == db.ReportStatusReady && !r.RevokedAt.Valid && !r.HeldAt.Valid && !at.Before(from) && at.Before(to) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (q *Querier) ListResearchReports(_ context.Context, p db.ListResearchReportsParams) ([]db.ListResearchReportsRow, error) {
+	var out []db.ListResearchReportsRow
+	for _, r := range q.researchReports(p.GeneratedFrom, p.GeneratedTo) {
+		out = append(out, db.ListResearchReportsRow{ID: r.ID, Industry: r.Industry.String, Stage: r.Stage.String, OverallScore: r.OverallScore.Int16})
+	}
+	return out, nil
+}
+
+func (q *Querier) CountResearchRiskTiers(_ context.Context, p db.CountResearchRiskTiersParams) ([]db.CountResearchRiskTiersRow, error) {
+	counts := map[db.CountResearchRiskTiersRow]int32{}
+	for _, r := range q.researchReports(p.GeneratedFrom, p.GeneratedTo) {
+		for _, rr := range q.RiskResults[r.ID] {
+			counts[db.CountResearchRiskTiersRow{QuestionID: rr.QuestionID, Tier: rr.Tier}]++
+		}
+	}
+	var out []db.CountResearchRiskTiersRow
+	for row, n := range counts {
+		row.Reports = n
+		out = append(out, row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].QuestionID != out[j].QuestionID {
+			return out[i].QuestionID < out[j].QuestionID
+		}
+		return out[i].Tier < out[j].Tier
+	})
+	return out, nil
+}
+
+func (q *Querier) ListStripeEvents(_ context.Context, p db.ListStripeEventsParams) ([]db.StripeEvent, error) {
+	out := []db.StripeEvent{}
+	events := q.StripeEvents
+	key := func(i int) (time.Time, string) { return events[i].ReceivedAt, events[i].StripeEventID }
+	for _, i := range keysetPage(len(events), key, p.HasCursor, p.CursorAt, p.CursorID) {
+		if len(out) == int(p.MaxRows) {
+			break
+		}
+		e := events[i]
+		status := "pending"
+		switch {
+		case e.Processed:
+			status = "processed"
+		case e.Error.Valid:
+			status = "failed"
+		}
+		if (p.Status == "" || p.Status == status) && (p.EventType == "" || p.EventType == e.Type) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ListReprocessableStripeEvents returns failed events oldest first, leaving
+// out any with a pending job in Jobs under the event's dedupe key, as the
+// query does.
+func (q *Querier) ListReprocessableStripeEvents(_ context.Context, p db.ListReprocessableStripeEventsParams) ([]db.StripeEvent, error) {
+	queued := map[string]bool{}
+	for _, j := range q.Jobs {
+		if j.Status == "pending" && j.DedupeKey.Valid {
+			queued[j.DedupeKey.String] = true
+		}
+	}
+	out := []db.StripeEvent{}
+	for _, e := range q.StripeEvents {
+		if !e.Processed && e.Error.Valid && (p.EventType == "" || p.EventType == e.Type) && !queued["stripe_event:"+e.StripeEventID] {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].ReceivedAt.Equal(out[j].ReceivedAt) {
+			return out[i].ReceivedAt.Before(out[j].ReceivedAt)
+		}
+		return out[i].StripeEventID < out[j].StripeEventID
+	})
+	if len(out) > int(p.MaxRows) {
+		out = out[:p.MaxRows]
+	}
+	return out, nil
+}
+
+// keysetPage returns the indexes of keys sorted newest first, by time then
+// ID, that fall after the cursor, as the *Page queries order and continue.
+func keysetPage(n int, key func(int) (time.Time, string), hasCursor bool, cursorAt time.Time, cursorID string) []int {
+	idx := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		at, id := key(i)
+		if !hasCursor || at.Before(cursorAt) || (at.Equal(cursorAt) && id < cursorID) {
+			idx = append(idx, i)
+		}
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		atA, idA := key(idx[a])
+		atB, idB := key(idx[b])
+		if !atA.Equal(atB) {
+			return atA.After(atB)
+		}
+		return idA > idB
+	})
+	return idx
+}
+
+func (q *Querier) ListSessionsPage(_ context.Context, p db.ListSessionsPageParams) ([]db.ListSessionsPageRow, error) {
+	var all []db.Session
+	for _, sess := range q.SessionsByID {
+		if p.PaymentStatus == "" || string(sess.PaymentStatus) == p.PaymentStatus {
+			all = append(all, sess)
+		}
+	}
+	out := []db.ListSessionsPageRow{}
+	for _, i := range keysetPage(len(all), func(i int) (time.Time, string) { return all[i].CreatedAt, all[i].ID.String() }, p.HasCursor, p.CursorAt, p.CursorID.String()) {
+		if len(out) == int(p.MaxRows) {
+			break
+		}
+		sess := all[i]
+		out = append(out, db.ListSessionsPageRow{
+			ID: sess.ID, BizName: sess.BizName, Industry: sess.Industry, Stage: sess.Stage,
+			PaymentStatus: sess.PaymentStatus, PaidAt: sess.PaidAt, Partner: sess.Partner, Cohort: sess.Cohort,
+			CreatedAt: sess.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+func (q *Querier) ListEmailLogPage(_ context.Context, p db.ListEmailLogPageParams) ([]db.EmailLog, error) {
+	var all []db.EmailLog
+	for _, e := range q.EmailLog {
+		if p.Template == "" || e.Template == p.Template {
+			all = append(all, *e)
+		}
+	}
+	out := []db.EmailLog{}
+	for _, i := range keysetPage(len(all), func(i int) (time.Time, string) { return all[i].CreatedAt, all[i].ID.String() }, p.HasCursor, p.CursorAt, p.CursorID.String()) {
+		if len(out) == int(p.MaxRows) {
+			break
+		}
+		out = append(out, all[i])
+	}
+	return out, nil
+}
+
+func (q *Querier) GetSessionByStripePI(_ context.Context, pi sql.NullString) (db.Session, error) {
+	for _, sess := range q.SessionsByID {
+		if sess.StripePaymentIntent == pi {
+			return sess, nil
+		}
+	}
+	return db.Session{}, sql.ErrNoRows
+}
+
+func (q *Querier) UpsertPayment(_ context.Context, p db.UpsertPaymentParams) (db.Payment, error) {
+	q.Payments = append(q.Payments, p)
+	return db.Payment{ID: uuid.New(), StripeChargeID: p.StripeChargeID}, nil
+}
+
+func (q *Querier) ListPaymentsByStripePIs(_ context.Context, pis []string) ([]db.Payment, error) {
+	out := []db.Payment{}
+	for _, p := range q.Payments {
+		for _, pi := range pis {
+			if p.StripePaymentIntent.String == pi {
+				out = append(out, db.Payment{
+					StripeChargeID:      p.StripeChargeID,
+					StripePaymentIntent: p.StripePaymentIntent,
+					AmountCents:         p.AmountCents,
+					FeeCents:            p.FeeCents,
+					NetCents:            p.NetCents,
+					Currency:            p.Currency,
+				})
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) GetPaymentMarginStats(_ context.Context) ([]db.GetPaymentMarginStatsRow, error) {
+	out := []db.GetPaymentMarginStatsRow{}
+	for _, p := range q.Payments {
+		if len(out) == 0 {
+			out = append(out, db.GetPaymentMarginStatsRow{Currency: p.Currency})
+		}
+		out[0].Payments++
+		out[0].GrossCents += p.AmountCents
+		out[0].FeeCents += p.FeeCents
+		out[0].NetCents += p.NetCents
+	}
+	return out, nil
+}
+
+func (q *Querier) ListSessionsByStripePIs(_ context.Context, pis []string) ([]db.Session, error) {
+	out := []db.Session{}
+	for _, sess := range q.SessionsByID {
+		for _, pi := range pis {
+			if sess.StripePaymentIntent.String == pi {
+				out = append(out, sess)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) ListActiveProducts(_ context.Context) ([]db.Product, error) {
+	var out []db.Product
+	for _, p := range q.Products {
+		if p.Active {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PriceCents != out[j].PriceCents {
+			return out[i].PriceCents < out[j].PriceCents
+		}
+		return out[i].Sku < out[j].Sku
+	})
+	return out, nil
+}
+
+func (q *Querier) ListPlaybookSnippets(_ context.Context) ([]db.PlaybookSnippet, error) {
+	out := []db.PlaybookSnippet{}
+	for _, s := range q.Playbooks {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Slug < out[j].Slug })
+	return out, nil
+}
+
+func (q *Querier) UpsertPlaybookSnippet(_ context.Context, p db.UpsertPlaybookSnippetParams) (db.PlaybookSnippet, error) {
+	s := db.PlaybookSnippet{
+		Slug:     p.Slug,
+		Industry: p.Industry,
+		Title:    p.Title,
+		Body:     p.Body,
+		Keywords: p.Keywords,
+		Active:   p.Active,
+	}
+	q.Playbooks[p.Slug] = s
+	return s, nil
+}
+
+func (q *Querier) DeletePlaybookSnippet(_ context.Context, slug string) (int64, error) {
+	if _, ok := q.Playbooks[slug]; !ok {
+		return 0, nil
+	}
+	delete(q.Playbooks, slug)
+	return 1, nil
+}
+
+func (q *Querier) ListFeatureFlags(_ context.Context) ([]db.FeatureFlag, error) {
+	return q.FeatureFlags, nil
+}
+
+func (q *Querier) UpsertFeatureFlag(_ context.Context, p db.UpsertFeatureFlagParams) (db.FeatureFlag, error) {
+	f := db.FeatureFlag{Name: p.Name, Environment: p.Environment, Percent: p.Percent}
+	for i, existing := range q.FeatureFlags {
+		if existing.Name == p.Name && existing.Environment == p.Environment {
+			q.FeatureFlags[i] = f
+			return f, nil
+		}
+	}
+	q.FeatureFlags = append(q.FeatureFlags, f)
+	return f, nil
+}
+
+func (q *Querier) DeleteFeatureFlag(_ context.Context, p db.DeleteFeatureFlagParams) (int64, error) {
+	for i, f := range q.FeatureFlags {
+		if f.Name == p.Name && f.Environment == p.Environment {
+			q.FeatureFlags = append(q.FeatureFlags[:i], q.FeatureFlags[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (q *Querier) AssignExperiment(_ context.Context, p db.AssignExperimentParams) error {
+	q.Assignments = append(q.Assignments, db.ExperimentAssignment{SessionID: p.SessionID, Experiment: p.Experiment, Variant: p.Variant})
+	return nil
+}
+
+func (q *Querier) GetExperimentAssignments(_ context.Context, sessionID uuid.UUID) ([]db.ExperimentAssignment, error) {
+	var out []db.ExperimentAssignment
+	for _, a := range q.Assignments {
+		if a.SessionID == sessionID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (q *Querier) GetExperimentStats(_ context.Context) ([]db.GetExperimentStatsRow, error) {
+	return q.ExperimentStats, nil
+}
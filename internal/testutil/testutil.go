@@ -0,0 +1,112 @@
+// Package testutil holds the fakes, fixture builders and assertions shared by
+// handler tests, so a new test seeds state rather than declaring its own stubs.
+//
+// NewServer wires api.NewServer to in-memory fakes — a Querier, a Stripe
+// client, a Worker and a Mailer — and returns them with the handler:
+//
+//	srv := testutil.NewServer(t)
+//	srv.Q.Reports["tok"] = testutil.Report()
+//	rr := srv.Do(t, http.MethodGet, "/api/report/tok", nil, nil)
+//	testutil.AssertGoldenJSON(t, "report_ready", rr.Body.Bytes())
+//
+// Querier implements the queries the API tests exercise; any other query
+// panics through its embedded nil db.Querier, so a test that needs one more
+// adds it here, once, for every test.
+//
+// It is imported only from _test.go files.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/api"
+	stripeinternal "github.com/nyashahama/asymmetric-risk-mapper-backend/internal/stripe"
+)
+
+// Server is an API handler wired to fakes the test can seed and inspect.
+type Server struct {
+	Q       *Querier
+	Stripe  *Stripe
+	Worker  *Worker
+	Mailer  *Mailer
+	Handler http.Handler
+}
+
+// NewServer returns a handler built like production's from a development
+// config, which cfgOverrides may change before it is built.
+func NewServer(t *testing.T, cfgOverrides ...func(*api.Config)) *Server {
+	t.Helper()
+
+	q := NewQuerier()
+	strp := &Stripe{
+		PI:           stripeinternal.PaymentIntent{ID: "pi_test", ClientSecret: "cs_test"},
+		ClientSecret: "cs_test",
+	}
+	wk := &Worker{}
+	ml := &Mailer{}
+
+	cfg := api.Config{
+		Env:                  "development",
+		BaseURL:              "http://localhost:8080",
+		StripeWebhookSecrets: []string{"whsec_test"},
+	}
+	for _, fn := range cfgOverrides {
+		fn(&cfg)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return &Server{
+		Q:       q,
+		Stripe:  strp,
+		Worker:  wk,
+		Mailer:  ml,
+		Handler: api.NewServer(q, nil, strp, wk, ml, cfg, logger),
+	}
+}
+
+// Do serves one request. A non-nil body is sent as JSON.
+func (s *Server) Do(t *testing.T, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var bodyReader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal body: %v", err)
+		}
+		bodyReader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, bodyReader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	s.Handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// NewSession seeds a session and returns its ID and anon token.
+func (s *Server) NewSession() (uuid.UUID, string) {
+	sess := Session()
+	s.Q.AddSession(sess.AnonToken, sess)
+	return sess.ID, sess.AnonToken
+}
+
+// DecodeJSON decodes the response body into dst.
+func DecodeJSON(t *testing.T, rr *httptest.ResponseRecorder, dst any) {
+	t.Helper()
+	if err := json.NewDecoder(rr.Body).Decode(dst); err != nil {
+		t.Fatalf("decode response body: %v (raw: %s)", err, rr.Body.String())
+	}
+}
@@ -0,0 +1,51 @@
+// Package useragent sorts User-Agent headers into coarse classes for request
+// logs, so abuse (a scraper walking report tokens, a script creating
+// sessions) can be told from customers, and marketing attribution can leave
+// crawlers out. It is a heuristic over the header, which any client can set;
+// treat "browser" as a claim, not a fact.
+package useragent
+
+import "strings"
+
+// Classes returned by Class.
+const (
+	Browser = "browser"
+	Bot     = "bot"     // declared crawlers, link previews, HTTP libraries, headless browsers
+	Unknown = "unknown" // empty or unrecognised
+)
+
+// botMarkers appear, lowercased, in the User-Agent of crawlers, link
+// unfurlers, monitoring and HTTP client libraries. Headless browsers say so
+// too, and are counted as bots.
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "scrape", "preview", "facebookexternalhit",
+	"headless", "phantomjs", "lighthouse", "monitor", "pingdom", "uptime",
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp", "httpx",
+	"go-http-client", "java/", "okhttp", "axios/", "node-fetch", "undici",
+	"libwww-perl", "postmanruntime", "insomnia", "httpie",
+}
+
+// browserMarkers appear in the User-Agent of every mainstream browser after
+// the leading "Mozilla/5.0".
+var browserMarkers = []string{"gecko/", "applewebkit/", "chrome/", "safari/", "firefox/", "edg/", "trident/"}
+
+// Class returns Browser, Bot or Unknown for a User-Agent header.
+func Class(ua string) string {
+	ua = strings.ToLower(strings.TrimSpace(ua))
+	if ua == "" {
+		return Unknown
+	}
+	for _, m := range botMarkers {
+		if strings.Contains(ua, m) {
+			return Bot
+		}
+	}
+	if strings.HasPrefix(ua, "mozilla/") {
+		for _, m := range browserMarkers {
+			if strings.Contains(ua, m) {
+				return Browser
+			}
+		}
+	}
+	return Unknown
+}
@@ -0,0 +1,23 @@
+package useragent
+
+import "testing"
+
+func TestClass(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36": Browser,
+		"Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0":                                                Browser,
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                                              Bot,
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/129.0.0.0 Safari/537.36":         Bot,
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)":                                             Bot,
+		"curl/8.5.0":             Bot,
+		"python-requests/2.32.3": Bot,
+		"Go-http-client/2.0":     Bot,
+		"":                       Unknown,
+		"SomeApp/1.0":            Unknown,
+	}
+	for ua, want := range cases {
+		if got := Class(ua); got != want {
+			t.Errorf("Class(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/retention"
+)
+
+// ─── CLEANUP ──────────────────────────────────────────────────────────────────
+
+// finishedJobsKept is how long a finished job's row is kept for debugging.
+const finishedJobsKept = 30 * 24 * time.Hour
+
+// CleanupSessionsSpec returns the cleanup_sessions job type: a retention pass
+// with e, then the deletion of jobs finished more than 30 days ago. Schedule
+// it with Runner.Every on e.Interval().
+func CleanupSessionsSpec(e *retention.Enforcer, q db.JobPruner, logger *slog.Logger) JobSpec {
+	return JobSpec{
+		Type: JobCleanupSessions,
+		Run: func(ctx context.Context, _ json.RawMessage) error {
+			if err := e.RunScheduled(ctx); err != nil {
+				return err
+			}
+			n, err := q.DeleteFinishedJobs(ctx, time.Now().Add(-finishedJobsKept))
+			if err != nil {
+				return fmt.Errorf("worker: delete finished jobs: %w", err)
+			}
+			if n > 0 {
+				logger.InfoContext(ctx, "worker: deleted finished jobs", "deleted", n)
+			}
+			return nil
+		},
+		MaxAttempts: 2,
+		Timeout:     30 * time.Minute,
+		Backoff:     time.Minute,
+	}
+}
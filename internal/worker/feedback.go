@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// ─── FEEDBACK REQUESTER ───────────────────────────────────────────────────────
+
+// FeedbackConfig controls the rating/testimonial request sent after delivery.
+type FeedbackConfig struct {
+	// After is how long after the report-ready email the request is sent.
+	// Zero disables feedback requests.
+	After time.Duration
+
+	// MaxAge stops reports delivered longer ago than this from being asked,
+	// so a request held up for days, by an outage, is dropped rather than
+	// sent late. Default: After + 5 days.
+	MaxAge time.Duration
+}
+
+// FeedbackRequester asks each customer, once, to rate their report and leave
+// a testimonial. It runs the send_feedback_request jobs the score_report job
+// schedules for After past each delivery. Addresses in email_suppressions —
+// unsubscribed, or bounced — are never asked.
+type FeedbackRequester struct {
+	q      db.FeedbackRequestStore
+	mailer email.Sender
+	cfg    FeedbackConfig
+	logger *slog.Logger
+}
+
+// NewFeedbackRequester returns a FeedbackRequester. Register its Spec with
+// the Runner.
+func NewFeedbackRequester(q db.FeedbackRequestStore, mailer email.Sender, cfg FeedbackConfig, logger *slog.Logger) *FeedbackRequester {
+	if cfg.MaxAge <= cfg.After {
+		cfg.MaxAge = cfg.After + 5*24*time.Hour
+	}
+	return &FeedbackRequester{q: q, mailer: mailer, cfg: cfg, logger: logger}
+}
+
+// Spec is the send_feedback_request job type.
+func (fr *FeedbackRequester) Spec() JobSpec {
+	return JobSpec{
+		Type: JobSendFeedbackRequest,
+		Run: func(ctx context.Context, payload json.RawMessage) error {
+			var p reportPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return fmt.Errorf("feedback: decode payload: %w", err)
+			}
+			_, err := fr.Ask(ctx, p.ReportID)
+			return err
+		},
+		MaxAttempts: 3,
+		Timeout:     30 * time.Second,
+		Backoff:     time.Minute,
+	}
+}
+
+// Ask sends the report's feedback request if it is still due, and returns
+// how many requests were sent. The report is claimed first
+// (CreateFeedbackRequest), so it is never asked twice; a claimed request
+// whose send then fails is not retried — its failure is in email_log. With
+// feedback requests disabled since the job was scheduled it sends nothing.
+func (fr *FeedbackRequester) Ask(ctx context.Context, reportID uuid.UUID) (int, error) {
+	if fr.cfg.After <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	rows, err := fr.q.ListFeedbackCandidates(ctx, db.ListFeedbackCandidatesParams{
+		SentAfter:  now.Add(-fr.cfg.MaxAge),
+		SentBefore: now.Add(-fr.cfg.After),
+		ReportID:   uuid.NullUUID{UUID: reportID, Valid: true},
+		MaxRows:    1,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sentCount := 0
+	for _, row := range rows {
+		fb, err := fr.q.CreateFeedbackRequest(ctx, row.ReportID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return sentCount, err
+		}
+
+		sent, sendErr := fr.mailer.SendFeedbackRequest(ctx, email.FeedbackRequestParams{
+			To:      row.ToAddress,
+			BizName: row.BizName.String,
+			Token:   fb.Token,
+		})
+		if err := email.Record(ctx, fr.q, email.LogEntry{
+			SessionID: row.SessionID.UUID,
+			ReportID:  row.ReportID.UUID,
+			To:        row.ToAddress,
+			Template:  email.TemplateFeedbackRequest,
+		}, sent, sendErr); err != nil {
+			fr.logger.WarnContext(ctx, "feedback: could not record request", "report_id", row.ReportID.UUID, "error", err)
+		}
+		if sendErr != nil {
+			fr.logger.ErrorContext(ctx, "feedback: request failed",
+				"report_id", row.ReportID.UUID,
+				"error", sendErr,
+			)
+			continue
+		}
+		fr.logger.InfoContext(ctx, "feedback: request sent",
+			"report_id", row.ReportID.UUID,
+			"delivered", now.Sub(row.SentAt.Time).Round(time.Hour),
+		)
+		sentCount++
+	}
+	return sentCount, nil
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// feedbackQuerier serves a fixed list of candidates and records claims and
+// email_log writes. askedElsewhere lists reports another replica already has.
+type feedbackQuerier struct {
+	db.FeedbackRequestStore // embedded to panic on unimplemented methods
+	candidates              []db.ListFeedbackCandidatesRow
+	askedElsewhere          map[uuid.UUID]bool
+	listed                  db.ListFeedbackCandidatesParams
+	claimed                 []uuid.UUID
+	logged                  []db.LogEmailParams
+}
+
+func (q *feedbackQuerier) ListFeedbackCandidates(_ context.Context, arg db.ListFeedbackCandidatesParams) ([]db.ListFeedbackCandidatesRow, error) {
+	q.listed = arg
+	return q.candidates, nil
+}
+
+func (q *feedbackQuerier) CreateFeedbackRequest(_ context.Context, reportID uuid.UUID) (db.Feedback, error) {
+	if q.askedElsewhere[reportID] {
+		return db.Feedback{}, sql.ErrNoRows
+	}
+	q.claimed = append(q.claimed, reportID)
+	return db.Feedback{ReportID: reportID, Token: "fb_" + reportID.String()}, nil
+}
+
+func (q *feedbackQuerier) LogEmail(_ context.Context, arg db.LogEmailParams) (db.EmailLog, error) {
+	q.logged = append(q.logged, arg)
+	return db.EmailLog{}, nil
+}
+
+func TestFeedbackRequester_AsksClaimedReportsOnce(t *testing.T) {
+	row := func(to string) db.ListFeedbackCandidatesRow {
+		return db.ListFeedbackCandidatesRow{
+			ReportID:  uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			SessionID: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			ToAddress: to,
+			BizName:   sql.NullString{String: "Acme", Valid: true},
+		}
+	}
+	a, b := row("a@acme.co"), row("b@acme.co")
+	q := &feedbackQuerier{
+		candidates:     []db.ListFeedbackCandidatesRow{a, b},
+		askedElsewhere: map[uuid.UUID]bool{b.ReportID.UUID: true},
+	}
+	m := &reminderMailer{}
+	fr := NewFeedbackRequester(q, m, FeedbackConfig{After: 7 * 24 * time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	n, err := fr.Ask(context.Background(), a.ReportID.UUID)
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	if q.listed.ReportID != a.ReportID {
+		t.Errorf("listed report %v, want %v", q.listed.ReportID, a.ReportID)
+	}
+	if n != 1 || len(m.feedback) != 1 || m.feedback[0].To != "a@acme.co" || m.feedback[0].Token != "fb_"+a.ReportID.UUID.String() {
+		t.Fatalf("expected one request to a@acme.co with its token, got %d: %+v", n, m.feedback)
+	}
+	if len(q.logged) != 1 || q.logged[0].Template != email.TemplateFeedbackRequest {
+		t.Errorf("expected the request logged, got %+v", q.logged)
+	}
+}
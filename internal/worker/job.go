@@ -2,34 +2,104 @@ package worker
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/experiments"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/flags"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/logging"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/settings"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/storage"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+	"github.com/sqlc-dev/pqtype"
 )
 
 // Job holds the dependencies for the score-and-generate pipeline. Each step
 // is a separate method so they can be tested independently and so the Run
 // method reads like a spec.
 type Job struct {
-	q      db.Querier
+	q      db.ReportJobStore
 	store  *store.Store
 	hedger ai.Hedger
 	mailer email.Sender
+	cfg    JobConfig
 	logger *slog.Logger
+
+	// jobs queues follow-up work; set by NewRunner. Nil outside a Runner.
+	jobs submitter
+}
+
+// submitter is the part of the Runner a Job queues work through.
+type submitter interface {
+	Submit(ctx context.Context, typ JobType, payload any, dedupeKey string) error
+	SubmitAt(ctx context.Context, typ JobType, payload any, dedupeKey string, runAt time.Time) error
+}
+
+// JobConfig controls how a Job talks to the AI provider.
+type JobConfig struct {
+	// AIChunkSize caps the number of risks sent to the hedger per call. Zero
+	// or less disables chunking.
+	AIChunkSize int
+
+	// AICacheTTL is how long a cached hedge generation may be reused for an
+	// identical answer pattern. Zero disables the cache.
+	AICacheTTL time.Duration
+
+	// PlaybookSnippets is how many of the session industry's playbook
+	// snippets are sent with the risks. Zero disables them.
+	PlaybookSnippets int
+
+	// AIMaxReportTokens caps the estimated AI tokens one report may use.
+	// Zero disables the cap.
+	AIMaxReportTokens int
+
+	// Settings supplies the score profile. May be nil, in which case
+	// settings.Defaults() apply.
+	Settings *settings.Watcher
+
+	// Flags gates the score profile and AI providers per session. May be
+	// nil, in which case every flag is on.
+	Flags *flags.Watcher
+
+	// Experiments are the running A/B experiments; the prompt experiment
+	// picks the prompt template. May be nil, in which case none run.
+	Experiments *experiments.Set
+
+	// Transcripts stores every AI request and raw response of a report in
+	// ai_transcripts for debugging.
+	Transcripts bool
+
+	// Storage, when set, holds the transcripts instead of Postgres; the row
+	// keeps the object key. May be nil.
+	Storage storage.Store
+
+	// ReminderAfter schedules a send_report_reminder job this long after a
+	// report-ready email is sent. Zero schedules none.
+	ReminderAfter time.Duration
+
+	// FeedbackAfter schedules a send_feedback_request job this long after a
+	// report-ready email is sent. Zero schedules none.
+	FeedbackAfter time.Duration
 }
 
 // NewJob constructs a Job with all required dependencies.
 func NewJob(
-	q db.Querier,
+	q db.ReportJobStore,
 	st *store.Store,
 	hedger ai.Hedger,
 	mailer email.Sender,
+	cfg JobConfig,
 	logger *slog.Logger,
 ) *Job {
 	return &Job{
@@ -37,6 +107,7 @@ func NewJob(
 		store:  st,
 		hedger: hedger,
 		mailer: mailer,
+		cfg:    cfg,
 		logger: logger,
 	}
 }
@@ -45,21 +116,49 @@ func NewJob(
 //
 //  1. Load answers from the database.
 //  2. Score every answer → []ScoredRisk.
-//  3. Call the AI to generate hedge narratives for critical/red risks.
+//  3. Call the AI to analyse the critical/red risks and generate their hedge
+//     narratives, reusing a cached generation when the answer pattern has
+//     been seen.
 //  4. Persist everything atomically via store.PersistScoredReport.
 //  5. Send the delivery email.
 //
+// The scored risks, and then the AI output, are checkpointed on the report
+// (see jobState), so a retry after the AI stage succeeded does not call the
+// provider again.
+//
 // Any error is returned to the Runner, which will retry up to MaxRetries times
 // before calling store.MarkReportFailed.
+//
+// Log lines are written with the *Context variants so the report_id, attempt
+// and trace_id the Runner attached to ctx (plus the session_id added below)
+// are included automatically — see logging.With.
 func (j *Job) Run(ctx context.Context, reportID uuid.UUID) error {
-	log := j.logger.With("report_id", reportID)
-	log.Info("job: starting")
+	j.logger.InfoContext(ctx, "job: starting")
 
 	// ── 1. Load the report to get the session ID ──────────────────────────────
 	report, err := j.q.GetReportByID(ctx, reportID)
 	if err != nil {
 		return fmt.Errorf("job: get report: %w", err)
 	}
+	ctx = logging.With(ctx, "session_id", report.SessionID)
+
+	// One job at a time generates a session's report. When another holds the
+	// lock the Runner skips this one: that job is doing the work already.
+	unlock, err := j.store.LockSession(ctx, report.SessionID)
+	if err != nil {
+		return fmt.Errorf("job: lock session: %w", err)
+	}
+	defer unlock()
+
+	// Read again under the lock, in case a job that held it just finished.
+	if report, err = j.q.GetReportByID(ctx, reportID); err != nil {
+		return fmt.Errorf("job: get report: %w", err)
+	}
+	if report.Status == db.ReportStatusReady || report.Status == db.ReportStatusError {
+		j.logger.InfoContext(ctx, "job: report finished by another job, skipping", "status", report.Status)
+		return nil
+	}
+	state := j.loadState(ctx, report)
 
 	// ── 2. Load answers with their question metadata ───────────────────────────
 	rows, err := j.q.GetAnswersBySession(ctx, report.SessionID)
@@ -71,7 +170,7 @@ func (j *Job) Run(ctx context.Context, reportID uuid.UUID) error {
 		return fmt.Errorf("job: no answers found for session %s", report.SessionID)
 	}
 
-	log.Debug("job: loaded answers", "count", len(rows))
+	j.logger.DebugContext(ctx, "job: loaded answers", "count", len(rows))
 
 	// ── 3. Map db rows → scoring.AnswerRow (keeps scoring/ dep-free) ──────────
 	answerRows := make([]scoring.AnswerRow, len(rows))
@@ -85,19 +184,35 @@ func (j *Job) Run(ctx context.Context, reportID uuid.UUID) error {
 			Hedge:         r.Hedge,
 			ScoringConfig: r.ScoringConfig,
 			IsScoring:     r.IsScoring,
+			Skipped:       r.Skipped,
 		}
 	}
 
 	// ── 4. Score ──────────────────────────────────────────────────────────────
-	risks, err := scoring.ComputeRisks(answerRows)
-	if err != nil {
-		return fmt.Errorf("job: compute risks: %w", err)
+	// A retry keeps the risks the earlier attempt scored, which its AI
+	// output, if checkpointed, was written for.
+	risks := state.Risks
+	if risks == nil {
+		if risks, err = scoring.ComputeRisks(answerRows); err != nil {
+			return fmt.Errorf("job: compute risks: %w", err)
+		}
+		state = jobState{Risks: risks}
+		j.saveState(ctx, reportID, state)
 	}
 
-	log.Debug("job: scored risks",
+	// The report is generated under the session's side of each feature flag,
+	// and records it.
+	flagState := j.cfg.Flags.Current().For(report.SessionID)
+	current := j.cfg.Settings.Current()
+	profile := current.ScoreProfile
+	if !flagState[flags.ScoreProfile] {
+		profile = scoring.Profile{Mode: scoring.ModeMean}
+	}
+	j.logger.DebugContext(ctx, "job: scored risks",
 		"total", len(risks),
 		"critical", scoring.CriticalCount(risks),
-		"overall_score", scoring.OverallScore(risks),
+		"overall_score", profile.OverallScore(risks),
+		"score_profile", profile.String(),
 	)
 
 	// ── 5. Generate AI hedge narratives ───────────────────────────────────────
@@ -106,14 +221,114 @@ func (j *Job) Run(ctx context.Context, reportID uuid.UUID) error {
 	// hedge text from question_definitions.
 	priorityRisks := scoring.FilterByTier(risks, scoring.TierWatch, scoring.TierRed)
 
+	// The session supplies the industry/stage for the cache fingerprint and
+	// the recipient for step 7. A failure here is not fatal to either step.
+	session, sessionErr := j.q.GetSessionByID(ctx, report.SessionID)
+
+	// Premium products get longer narratives. Sessions from before the
+	// product catalog have no SKU and get the standard report.
+	reportType := ai.ReportStandard
+	if sessionErr == nil && session.ProductSku.Valid {
+		product, err := j.q.GetProductBySKU(ctx, session.ProductSku.String)
+		if err != nil {
+			return fmt.Errorf("job: get product %q: %w", session.ProductSku.String, err)
+		}
+		reportType = string(product.ReportType)
+	}
+	ctx = ai.WithReportType(logging.With(ctx, "report_type", reportType), reportType)
+	var disabled []string
+	for _, provider := range []string{settings.ProviderDeepSeek, settings.ProviderAnthropic} {
+		if !flagState[flags.AIProvider(provider)] {
+			disabled = append(disabled, provider)
+		}
+	}
+	if len(disabled) > 0 {
+		ctx = ai.WithoutProviders(ctx, disabled...)
+	}
+	if sessionErr == nil && j.cfg.Experiments.Running(experiments.Prompt) {
+		// A session whose assignment cannot be read gets the default prompt,
+		// like one that is not in the experiment.
+		assignments, err := j.q.GetExperimentAssignments(ctx, session.ID)
+		if err != nil {
+			j.logger.WarnContext(ctx, "job: could not load experiment assignments, using the default prompt", "error", err)
+		}
+		if j.cfg.Experiments.Variant(experiments.Prompt, assignments) == experiments.Alternative {
+			ctx = ai.WithPromptVariant(logging.With(ctx, "prompt_variant", ai.PromptAlternative), ai.PromptAlternative)
+		}
+	}
+
 	var hedgeResult ai.HedgeResult
-	if len(priorityRisks) > 0 {
-		hedgeResult, err = j.hedger.GenerateHedges(ctx, priorityRisks)
+	var budgetNote string
+	if state.AI != nil {
+		j.logger.InfoContext(ctx, "job: reusing AI output from an earlier attempt")
+		hedgeResult, budgetNote = state.AI.Result, state.AI.BudgetNote
+	} else if len(priorityRisks) > 0 {
+		if sessionErr == nil {
+			ctx = j.withPlaybook(ctx, priorityRisks, session.Industry.String)
+		}
+		// A report requeued for its narrative alone keeps the analysis it
+		// was first generated from.
+		if report.AiAnalysis.Valid {
+			var analysis ai.Analysis
+			if err := json.Unmarshal(report.AiAnalysis.RawMessage, &analysis); err != nil {
+				j.logger.WarnContext(ctx, "job: ignoring unreadable stored AI analysis", "error", err)
+			} else {
+				j.logger.InfoContext(ctx, "job: regenerating narrative from stored AI analysis")
+				ctx = ai.WithAnalysis(ctx, &analysis)
+			}
+		}
+		var transcript *ai.Transcript
+		if j.cfg.Transcripts {
+			transcript = ai.NewTranscript()
+			ctx = ai.WithTranscript(ctx, transcript)
+		}
+		// Every AI call spends from the report's token budget; if the
+		// projected cost is over it, the least severe risks are not sent.
+		var budget *ai.Budget
+		if j.cfg.AIMaxReportTokens > 0 {
+			budget = ai.NewBudget(j.cfg.AIMaxReportTokens)
+			ctx = ai.WithBudget(ctx, budget)
+			priorityRisks, budgetNote = j.fitBudget(ctx, priorityRisks)
+		}
+		if len(priorityRisks) > 0 {
+			hedgeResult, err = j.cachedHedges(ctx, priorityRisks, session, sessionErr == nil)
+		}
+		j.saveTranscript(ctx, reportID, transcript)
+		if budget.Exceeded() {
+			note := fmt.Sprintf("budget of %d tokens ran out after %d; later AI calls were refused", j.cfg.AIMaxReportTokens, budget.Spent())
+			j.logger.WarnContext(ctx, "job: AI token budget exhausted", "budget", j.cfg.AIMaxReportTokens, "spent", budget.Spent())
+			budgetNote = strings.TrimPrefix(budgetNote+"; "+note, "; ")
+		}
 		if err != nil {
 			// AI failure is non-fatal: we log it and continue with static hedges.
-			// The report is still valuable without AI narratives.
-			log.Warn("job: AI hedge generation failed, using static hedges", "error", err)
-			hedgeResult = ai.HedgeResult{}
+			// The report is still valuable without AI narratives. The analysis,
+			// if it ran, is kept for debugging, and the quality gate's reasons
+			// for rejecting the output are recorded on the report.
+			j.logger.WarnContext(ctx, "job: AI hedge generation failed, using static hedges", "error", err)
+			hedgeResult = ai.HedgeResult{Analysis: hedgeResult.Analysis, QualityIssues: ai.QualityIssues(err)}
+		} else {
+			// A failed generation is not kept: a retry may well succeed.
+			state.AI = &aiCheckpoint{Result: hedgeResult, BudgetNote: budgetNote}
+			j.saveState(ctx, reportID, state)
+		}
+	}
+
+	// Both stages' output is stored for debugging and so the narrative can be
+	// regenerated alone (armctl requeue-report -narrative).
+	var analysisJSON, narrativeJSON, relationshipsJSON json.RawMessage
+	if hedgeResult.Analysis != nil {
+		if analysisJSON, err = json.Marshal(hedgeResult.Analysis); err != nil {
+			return fmt.Errorf("job: marshal AI analysis: %w", err)
+		}
+	}
+	if len(hedgeResult.Hedges) > 0 || hedgeResult.ExecutiveSummary != "" {
+		if narrativeJSON, err = hedgeResult.Narrative(); err != nil {
+			return fmt.Errorf("job: marshal AI narrative: %w", err)
+		}
+	}
+	if len(hedgeResult.Relationships) > 0 {
+		if relationshipsJSON, err = json.Marshal(hedgeResult.Relationships); err != nil {
+			return fmt.Errorf("job: marshal AI relationships: %w", err)
 		}
 	}
 
@@ -121,47 +336,544 @@ func (j *Job) Run(ctx context.Context, reportID uuid.UUID) error {
 	finalReport, err := j.store.PersistScoredReport(ctx, store.PersistScoredReportParams{
 		ReportID:         reportID,
 		Risks:            risks,
+		ScoreProfile:     profile,
 		AIHedges:         hedgeResult.Hedges,
 		ExecutiveSummary: hedgeResult.ExecutiveSummary,
 		TopPriorityHTML:  hedgeResult.TopPriorityHTML,
+		AIAnalysis:       analysisJSON,
+		AINarrative:      narrativeJSON,
+		Relationships:    relationshipsJSON,
+		AIQualityIssues:  strings.Join(hedgeResult.QualityIssues, "; "),
+		AIBudgetNote:     budgetNote,
+		Flags:            flagState,
+		SkippedQuestions: scoring.SkippedQuestions(answerRows),
 	})
 	if err != nil {
 		return fmt.Errorf("job: persist report: %w", err)
 	}
 
-	log.Info("job: report persisted",
+	j.logger.InfoContext(ctx, "job: report persisted",
 		"overall_score", finalReport.OverallScore.Int16,
 		"critical_count", finalReport.CriticalCount.Int16,
 		"access_token", finalReport.AccessToken,
 	)
+	j.shadowScore(ctx, reportID, risks, profile, current.ShadowScoreProfile)
 
 	// ── 7. Send delivery email ────────────────────────────────────────────────
-	// Load the session to get the recipient email address.
-	session, err := j.q.GetSessionByID(ctx, report.SessionID)
-	if err != nil {
+	if sessionErr != nil {
 		// Email failure should not fail the job — the report is ready and
 		// accessible via the access token. Log and return nil.
-		log.Error("job: could not load session for email delivery", "error", err)
+		j.logger.ErrorContext(ctx, "job: could not load session for email delivery", "error", sessionErr)
 		return nil
 	}
 
 	if !session.Email.Valid || session.Email.String == "" {
-		log.Warn("job: session has no email address, skipping delivery email")
+		j.logger.WarnContext(ctx, "job: session has no email address, skipping delivery email")
 		return nil
 	}
 
-	if err := j.mailer.SendReportReady(ctx, email.ReportReadyParams{
-		To:          session.Email.String,
-		BizName:     session.BizName.String,
-		AccessToken: finalReport.AccessToken,
-	}); err != nil {
+	if err := j.sendReportEmail(ctx, session, reportID, finalReport.AccessToken); err != nil {
 		// Log but do not fail — the user can still access their report via the
-		// token. A failed email is surfaced in the email_log table.
-		log.Error("job: failed to send report email",
+		// token. A failed email is surfaced in the email_log table and sent
+		// again by a send_email_retry job.
+		j.logger.ErrorContext(ctx, "job: failed to send report email",
 			"to", session.Email.String,
 			"error", err,
 		)
+		j.queueEmailRetry(ctx, reportID)
 	}
 
 	return nil
 }
+
+// sendReportEmail sends the report-ready email and records it in email_log.
+// It returns the send's error; an email already sent is skipped, not an
+// error.
+func (j *Job) sendReportEmail(ctx context.Context, session db.Session, reportID uuid.UUID, accessToken string) error {
+	// A retried job must not email the customer a second time.
+	entry := email.LogEntry{
+		SessionID: session.ID,
+		ReportID:  reportID,
+		To:        session.Email.String,
+		Template:  email.TemplateReportReady,
+		DedupeKey: email.DedupeKey(reportID, email.TemplateReportReady),
+	}
+	if err := email.Claim(ctx, j.q, entry); errors.Is(err, email.ErrDuplicate) {
+		j.logger.InfoContext(ctx, "job: report email already sent, skipping", "dedupe_key", entry.DedupeKey, "detail", err)
+		return nil
+	} else if err != nil {
+		j.logger.WarnContext(ctx, "job: could not claim report email, sending anyway", "error", err)
+	}
+
+	sent, sendErr := j.mailer.SendReportReady(ctx, email.ReportReadyParams{
+		To:          session.Email.String,
+		BizName:     session.BizName.String,
+		AccessToken: accessToken,
+	})
+	if err := email.Record(ctx, j.q, entry, sent, sendErr); err != nil {
+		j.logger.WarnContext(ctx, "job: could not record report email", "error", err)
+	}
+	if sendErr == nil {
+		j.scheduleFollowUps(ctx, reportID)
+	}
+	return sendErr
+}
+
+// scheduleFollowUps queues the emails that follow a delivered report: the
+// reminder if it goes unopened, and the feedback request. Each job decides
+// when it runs whether it is still due, so nothing is cancelled when the
+// customer opens the email or rates the report first.
+func (j *Job) scheduleFollowUps(ctx context.Context, reportID uuid.UUID) {
+	if j.jobs == nil {
+		return
+	}
+	followUps := []struct {
+		typ   JobType
+		after time.Duration
+	}{
+		{JobSendReportReminder, j.cfg.ReminderAfter},
+		{JobSendFeedbackRequest, j.cfg.FeedbackAfter},
+	}
+	for _, f := range followUps {
+		if f.after <= 0 {
+			continue
+		}
+		runAt := time.Now().Add(f.after)
+		err := j.jobs.SubmitAt(ctx, f.typ, reportPayload{ReportID: reportID}, string(f.typ)+":"+reportID.String(), runAt)
+		if err != nil {
+			j.logger.ErrorContext(ctx, "job: could not schedule follow-up email", "job_type", f.typ, "error", err)
+		}
+	}
+}
+
+// ─── SEND EMAIL RETRY ─────────────────────────────────────────────────────────
+//
+// A report-ready email that failed to send (the provider was down, or rate
+// limited us) is sent again by a send_email_retry job, with back-off, rather
+// than left for support to notice in email_log.
+
+// emailRetryPayload is the payload of a JobSendEmailRetry job.
+type emailRetryPayload struct {
+	ReportID uuid.UUID `json:"report_id"`
+}
+
+// queueEmailRetry submits a send_email_retry job for the report's email.
+func (j *Job) queueEmailRetry(ctx context.Context, reportID uuid.UUID) {
+	if j.jobs == nil {
+		return
+	}
+	err := j.jobs.Submit(ctx, JobSendEmailRetry, emailRetryPayload{ReportID: reportID}, "send_email_retry:"+reportID.String())
+	if err != nil {
+		j.logger.ErrorContext(ctx, "job: could not queue report email retry", "error", err)
+	}
+}
+
+func (j *Job) emailRetrySpec() JobSpec {
+	return JobSpec{
+		Type:        JobSendEmailRetry,
+		Run:         j.retryReportEmail,
+		MaxAttempts: 5,
+		Timeout:     30 * time.Second,
+		Backoff:     30 * time.Second,
+	}
+}
+
+// retryReportEmail sends a report's report-ready email again. A report
+// revoked since, or a session without an address, has nothing to send.
+func (j *Job) retryReportEmail(ctx context.Context, payload json.RawMessage) error {
+	var p emailRetryPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("job: decode email retry payload: %w", err)
+	}
+	report, err := j.q.GetReportByID(ctx, p.ReportID)
+	if err != nil {
+		return fmt.Errorf("job: get report: %w", err)
+	}
+	if report.RevokedAt.Valid {
+		j.logger.InfoContext(ctx, "job: report revoked, not retrying its email", "report_id", report.ID)
+		return nil
+	}
+	session, err := j.q.GetSessionByID(ctx, report.SessionID)
+	if err != nil {
+		return fmt.Errorf("job: get session: %w", err)
+	}
+	if !session.Email.Valid || session.Email.String == "" {
+		return nil
+	}
+	return j.sendReportEmail(ctx, session, report.ID, report.AccessToken)
+}
+
+// ─── CHECKPOINTS ──────────────────────────────────────────────────────────────
+
+// jobState is a report's pipeline checkpoint, kept in reports.job_state until
+// the report is finalized or requeued.
+type jobState struct {
+	Risks []scoring.ScoredRisk `json:"risks"`
+
+	// AI is set once the AI stage has succeeded.
+	AI *aiCheckpoint `json:"ai,omitempty"`
+}
+
+// aiCheckpoint is the AI stage's output.
+type aiCheckpoint struct {
+	Result     ai.HedgeResult `json:"result"`
+	BudgetNote string         `json:"budget_note,omitempty"`
+}
+
+// loadState returns the report's checkpoint, or an empty one when there is
+// none. An unreadable checkpoint is ignored: the pipeline starts over.
+func (j *Job) loadState(ctx context.Context, report db.Report) jobState {
+	var state jobState
+	if !report.JobState.Valid {
+		return state
+	}
+	if err := json.Unmarshal(report.JobState.RawMessage, &state); err != nil {
+		j.logger.WarnContext(ctx, "job: ignoring unreadable checkpoint", "error", err)
+		return jobState{}
+	}
+	j.logger.InfoContext(ctx, "job: resuming from checkpoint", "ai_done", state.AI != nil)
+	return state
+}
+
+// saveState checkpoints the report. Failure is logged only: a retry without
+// the checkpoint redoes the work, which is slower but still correct.
+func (j *Job) saveState(ctx context.Context, reportID uuid.UUID, state jobState) {
+	body, err := json.Marshal(state)
+	if err == nil {
+		err = j.q.SaveReportJobState(ctx, db.SaveReportJobStateParams{
+			ID:       reportID,
+			JobState: pqtype.NullRawMessage{RawMessage: body, Valid: true},
+		})
+	}
+	if err != nil {
+		j.logger.WarnContext(ctx, "job: could not save checkpoint", "error", err)
+	}
+}
+
+// saveTranscript stores the AI calls recorded for a report, in the bucket
+// when one is configured. A cached generation makes no calls and stores
+// nothing. Failure is logged only: the transcript is a debugging aid and must
+// not fail the report.
+func (j *Job) saveTranscript(ctx context.Context, reportID uuid.UUID, transcript *ai.Transcript) {
+	exchanges := transcript.Exchanges()
+	if len(exchanges) == 0 {
+		return
+	}
+	body, err := json.Marshal(exchanges)
+	if err != nil {
+		j.logger.WarnContext(ctx, "job: could not marshal AI transcript", "error", err)
+		return
+	}
+
+	params := db.InsertAITranscriptParams{
+		ReportID:  reportID,
+		Exchanges: int32(len(exchanges)),
+	}
+	if j.cfg.Storage != nil {
+		key := fmt.Sprintf("transcripts/%s/%s.json", reportID, uuid.New())
+		if err := j.cfg.Storage.Put(ctx, key, "application/json", body); err != nil {
+			j.logger.WarnContext(ctx, "job: could not upload AI transcript", "key", key, "error", err)
+			return
+		}
+		params.StorageKey = sql.NullString{String: key, Valid: true}
+	} else {
+		params.Body = pqtype.NullRawMessage{RawMessage: body, Valid: true}
+	}
+	if _, err := j.q.InsertAITranscript(ctx, params); err != nil {
+		j.logger.WarnContext(ctx, "job: could not record AI transcript", "error", err)
+		return
+	}
+	j.logger.InfoContext(ctx, "job: AI transcript stored", "exchanges", len(exchanges), "storage_key", params.StorageKey.String)
+}
+
+// shadowScore records the overall score risks get under the candidate profile
+// shadow next to the one they got under production, for comparing a scoring
+// change before rollout. It does nothing without a candidate, or when the
+// candidate is what the report was scored with. Failure is logged only.
+func (j *Job) shadowScore(ctx context.Context, reportID uuid.UUID, risks []scoring.ScoredRisk, production scoring.Profile, shadow *scoring.Profile) {
+	if shadow == nil || shadow.String() == production.String() {
+		return
+	}
+	productionScore, shadowScore := production.OverallScore(risks), shadow.OverallScore(risks)
+	err := j.q.UpsertShadowScore(ctx, db.UpsertShadowScoreParams{
+		ReportID:          reportID,
+		Profile:           shadow.String(),
+		ProductionProfile: production.String(),
+		ProductionScore:   int16(productionScore),
+		ShadowScore:       int16(shadowScore),
+		ProductionBand:    string(scoring.Band(productionScore)),
+		ShadowBand:        string(scoring.Band(shadowScore)),
+	})
+	if err != nil {
+		j.logger.WarnContext(ctx, "job: could not record shadow score", "shadow_profile", shadow.String(), "error", err)
+		return
+	}
+	j.logger.DebugContext(ctx, "job: shadow score recorded",
+		"shadow_profile", shadow.String(),
+		"overall_score", productionScore,
+		"shadow_score", shadowScore,
+	)
+}
+
+// withPlaybook attaches the industry playbook snippets most relevant to risks
+// to ctx (see ai.WithSnippets). The playbook is an enrichment: if it cannot be
+// loaded the hedges are generated without it.
+func (j *Job) withPlaybook(ctx context.Context, risks []scoring.ScoredRisk, industry string) context.Context {
+	if j.cfg.PlaybookSnippets <= 0 || industry == "" {
+		return ctx
+	}
+	rows, err := j.q.ListActivePlaybookSnippetsByIndustry(ctx, industry)
+	if err != nil {
+		j.logger.WarnContext(ctx, "job: could not load industry playbook, continuing without it", "industry", industry, "error", err)
+		return ctx
+	}
+	if len(rows) == 0 {
+		return ctx
+	}
+	snippets := make([]ai.Snippet, len(rows))
+	for i, row := range rows {
+		snippets[i] = ai.Snippet{Title: row.Title, Body: row.Body, Keywords: row.Keywords}
+	}
+	snippets = ai.RankSnippets(snippets, risks, j.cfg.PlaybookSnippets)
+	j.logger.DebugContext(ctx, "job: sending industry playbook", "industry", industry, "snippets", len(snippets))
+	return ai.WithSnippets(ctx, snippets)
+}
+
+// cachedHedges returns a prior generation for the same fingerprint when one
+// exists within AICacheTTL, and otherwise generates and stores a new one.
+// Cache lookups and writes are best-effort: a database error is logged and
+// the AI is called as if the cache were disabled.
+//
+// Only results that cover every risk are cached, so a partial generation (a
+// failed chunk, or a model reply missing some hedges) is never replayed. A
+// narrative regeneration (an analysis already on ctx) bypasses the cache: a
+// fresh narrative is the point.
+func (j *Job) cachedHedges(ctx context.Context, risks []scoring.ScoredRisk, session db.Session, haveSession bool) (ai.HedgeResult, error) {
+	if j.cfg.AICacheTTL <= 0 || !haveSession || ai.AnalysisFrom(ctx) != nil {
+		return j.generateHedges(ctx, risks)
+	}
+
+	fp := ai.Fingerprint(risks, session.Industry.String, session.Stage.String, ai.ReportTypeFrom(ctx), ai.PromptVariantFrom(ctx), ai.SnippetsFrom(ctx)...)
+
+	entry, err := j.q.GetAICacheEntry(ctx, db.GetAICacheEntryParams{
+		Fingerprint: fp,
+		NotBefore:   time.Now().Add(-j.cfg.AICacheTTL),
+	})
+	switch {
+	case err == nil:
+		var hedges map[string]string
+		if err := json.Unmarshal(entry.Hedges, &hedges); err != nil {
+			j.logger.WarnContext(ctx, "job: ignoring unreadable AI cache entry", "fingerprint", fp, "error", err)
+			break
+		}
+		j.logger.InfoContext(ctx, "job: AI cache hit", "fingerprint", fp, "hits", entry.HitCount)
+		result := ai.HedgeResult{
+			Hedges:           hedges,
+			ExecutiveSummary: entry.ExecutiveSummary,
+			TopPriorityHTML:  entry.TopPriorityHtml,
+		}
+		if entry.Analysis.Valid {
+			var analysis ai.Analysis
+			if json.Unmarshal(entry.Analysis.RawMessage, &analysis) == nil {
+				result.Analysis = &analysis
+			}
+		}
+		if entry.Relationships.Valid {
+			_ = json.Unmarshal(entry.Relationships.RawMessage, &result.Relationships)
+		}
+		return result, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		j.logger.WarnContext(ctx, "job: AI cache lookup failed", "fingerprint", fp, "error", err)
+	}
+
+	result, err := j.generateHedges(ctx, risks)
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.Hedges) < len(risks) {
+		j.logger.DebugContext(ctx, "job: not caching partial AI result",
+			"hedges", len(result.Hedges),
+			"risks", len(risks),
+		)
+		return result, nil
+	}
+
+	hedgesJSON, err := json.Marshal(result.Hedges)
+	var analysisJSON, relationshipsJSON []byte
+	if err == nil && result.Analysis != nil {
+		analysisJSON, err = json.Marshal(result.Analysis)
+	}
+	if err == nil && len(result.Relationships) > 0 {
+		relationshipsJSON, err = json.Marshal(result.Relationships)
+	}
+	if err == nil {
+		err = j.q.UpsertAICacheEntry(ctx, db.UpsertAICacheEntryParams{
+			Fingerprint:      fp,
+			Hedges:           hedgesJSON,
+			ExecutiveSummary: result.ExecutiveSummary,
+			TopPriorityHtml:  result.TopPriorityHTML,
+			Analysis:         pqtype.NullRawMessage{RawMessage: analysisJSON, Valid: analysisJSON != nil},
+			Relationships:    pqtype.NullRawMessage{RawMessage: relationshipsJSON, Valid: relationshipsJSON != nil},
+		})
+	}
+	if err != nil {
+		j.logger.WarnContext(ctx, "job: could not store AI cache entry", "fingerprint", fp, "error", err)
+	}
+	return result, nil
+}
+
+// generateHedges runs both AI stages: the analysis over all of risks (see
+// analyse), then the narrative. The analysis's dependencies are added to the
+// narrative's relationships, so a link between risks sent in different chunks
+// is not lost. On error the result still carries the analysis, if there was
+// one.
+func (j *Job) generateHedges(ctx context.Context, risks []scoring.ScoredRisk) (ai.HedgeResult, error) {
+	ctx = j.analyse(ctx, risks)
+	result, err := j.generateNarrative(ctx, risks)
+	result.Analysis = ai.AnalysisFrom(ctx)
+	if err == nil && result.Analysis != nil {
+		result.Relationships = ai.MergeRelationships(result.Relationships, result.Analysis.Relationships())
+	}
+	return result, err
+}
+
+// analyse runs the analysis stage once for all of risks, so every narrative
+// chunk is written from the same priorities, and returns ctx carrying the
+// result. It does nothing if ctx already has an analysis (a narrative
+// regeneration) or the hedger cannot analyse. A failed analysis is logged and
+// the narrative is written without one, as in a single-stage generation.
+func (j *Job) analyse(ctx context.Context, risks []scoring.ScoredRisk) context.Context {
+	if ai.AnalysisFrom(ctx) != nil {
+		return ctx
+	}
+	analyser, ok := j.hedger.(ai.Analyser)
+	if !ok {
+		return ctx
+	}
+	analysis, err := analyser.Analyse(ctx, risks)
+	if err != nil {
+		j.logger.WarnContext(ctx, "job: AI analysis failed, writing narrative without it", "error", err)
+		return ctx
+	}
+	j.logger.DebugContext(ctx, "job: AI analysis done",
+		"top_priority", analysis.TopPriority,
+		"dependencies", len(analysis.Dependencies),
+	)
+	return ai.WithAnalysis(ctx, &analysis)
+}
+
+// generateNarrative calls the hedger once per chunk of at most AIChunkSize
+// risks so a long questionnaire never produces a prompt (or a response) too
+// large for the model. Chunks run in parallel and their hedges and
+// relationships are merged.
+//
+// A failed chunk is logged and skipped — its risks keep their static hedges —
+// and an error is returned only if every chunk fails. risks arrive sorted by
+// score, so the first chunk holds the most severe risks; its executive summary
+// and top-priority block are used for the report, falling back to the next
+// successful chunk if it failed.
+func (j *Job) generateNarrative(ctx context.Context, risks []scoring.ScoredRisk) (ai.HedgeResult, error) {
+	chunks := chunkRisks(risks, j.cfg.AIChunkSize)
+	if len(chunks) == 1 {
+		return j.hedger.GenerateHedges(ctx, risks)
+	}
+
+	results := make([]ai.HedgeResult, len(chunks))
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = j.hedger.GenerateHedges(ctx, chunk)
+		}()
+	}
+	wg.Wait()
+
+	merged := ai.HedgeResult{Hedges: make(map[string]string, len(risks))}
+	failed := 0
+	for i, res := range results {
+		if errs[i] != nil {
+			failed++
+			j.logger.WarnContext(ctx, "job: AI chunk failed, using static hedges for its risks",
+				"chunk", i+1,
+				"chunks", len(chunks),
+				"risks", len(chunks[i]),
+				"error", errs[i],
+			)
+			merged.QualityIssues = append(merged.QualityIssues, ai.QualityIssues(errs[i])...)
+			continue
+		}
+		for id, hedge := range res.Hedges {
+			merged.Hedges[id] = hedge
+		}
+		merged.Relationships = ai.MergeRelationships(merged.Relationships, res.Relationships)
+		if merged.ExecutiveSummary == "" {
+			merged.ExecutiveSummary = res.ExecutiveSummary
+		}
+		if merged.TopPriorityHTML == "" {
+			merged.TopPriorityHTML = res.TopPriorityHTML
+		}
+	}
+
+	if failed == len(chunks) {
+		return ai.HedgeResult{}, fmt.Errorf("all %d chunks failed: %w", len(chunks), errors.Join(errs...))
+	}
+
+	j.logger.DebugContext(ctx, "job: generated hedges in chunks",
+		"chunks", len(chunks),
+		"failed", failed,
+		"hedges", len(merged.Hedges),
+	)
+	return merged, nil
+}
+
+// fitBudget drops the least severe of risks — they arrive sorted by score —
+// until the projected cost of generating the rest fits AIMaxReportTokens, and
+// returns a note of what it dropped. The dropped risks keep static hedges; if
+// not even one risk fits, none is sent.
+func (j *Job) fitBudget(ctx context.Context, risks []scoring.ScoredRisk) ([]scoring.ScoredRisk, string) {
+	n := len(risks)
+	for n > 0 && j.projectTokens(ctx, risks[:n]) > j.cfg.AIMaxReportTokens {
+		n--
+	}
+	if n == len(risks) {
+		return risks, ""
+	}
+	j.logger.WarnContext(ctx, "job: AI token budget too small for every risk, sending fewer",
+		"budget", j.cfg.AIMaxReportTokens,
+		"projected", j.projectTokens(ctx, risks),
+		"sent", n,
+		"risks", len(risks),
+	)
+	return risks[:n], fmt.Sprintf("sent %d of %d risks to the AI to stay within the %d-token budget", n, len(risks), j.cfg.AIMaxReportTokens)
+}
+
+// projectTokens estimates the tokens generateHedges would use for risks: the
+// analysis call, if one would run, and one narrative call per chunk. Retries
+// are not projected; the budget on ctx refuses them once it runs out.
+func (j *Job) projectTokens(ctx context.Context, risks []scoring.ScoredRisk) int {
+	total := 0
+	if _, ok := j.hedger.(ai.Analyser); ok && ai.AnalysisFrom(ctx) == nil {
+		total += ai.EstimateAnalysisTokens(ctx, risks)
+	}
+	for _, chunk := range chunkRisks(risks, j.cfg.AIChunkSize) {
+		total += ai.EstimateTokens(ctx, chunk)
+	}
+	return total
+}
+
+// chunkRisks splits risks into consecutive slices of at most size elements.
+// A size of zero or less returns risks as a single chunk.
+func chunkRisks(risks []scoring.ScoredRisk, size int) [][]scoring.ScoredRisk {
+	if size <= 0 || len(risks) <= size {
+		return [][]scoring.ScoredRisk{risks}
+	}
+	chunks := make([][]scoring.ScoredRisk, 0, (len(risks)+size-1)/size)
+	for start := 0; start < len(risks); start += size {
+		chunks = append(chunks, risks[start:min(start+size, len(risks))])
+	}
+	return chunks
+}
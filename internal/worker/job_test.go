@@ -0,0 +1,477 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/scoring"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// chunkHedger returns one hedge per risk and fails any call that contains a
+// risk listed in failOn.
+type chunkHedger struct {
+	mu     sync.Mutex
+	calls  int
+	failOn map[string]bool
+	err    error // returned for failOn chunks; a plain provider error if nil
+}
+
+func (h *chunkHedger) GenerateHedges(_ context.Context, risks []scoring.ScoredRisk) (ai.HedgeResult, error) {
+	h.mu.Lock()
+	h.calls++
+	h.mu.Unlock()
+
+	res := ai.HedgeResult{
+		Hedges:           make(map[string]string, len(risks)),
+		ExecutiveSummary: "summary from " + risks[0].QuestionID,
+	}
+	for _, r := range risks {
+		if h.failOn[r.QuestionID] {
+			if h.err != nil {
+				return ai.HedgeResult{}, h.err
+			}
+			return ai.HedgeResult{}, errors.New("provider error")
+		}
+		res.Hedges[r.QuestionID] = "hedge " + r.QuestionID
+	}
+	return res, nil
+}
+
+func makeRisks(ids ...string) []scoring.ScoredRisk {
+	risks := make([]scoring.ScoredRisk, len(ids))
+	for i, id := range ids {
+		risks[i] = scoring.ScoredRisk{QuestionID: id}
+	}
+	return risks
+}
+
+func newChunkJob(h ai.Hedger, size int) *Job {
+	return NewJob(nil, nil, h, nil, JobConfig{AIChunkSize: size}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestChunkRisks(t *testing.T) {
+	risks := makeRisks("a", "b", "c", "d", "e")
+
+	cases := []struct {
+		size int
+		want []int
+	}{
+		{0, []int{5}},
+		{5, []int{5}},
+		{10, []int{5}},
+		{2, []int{2, 2, 1}},
+		{1, []int{1, 1, 1, 1, 1}},
+	}
+	for _, tc := range cases {
+		chunks := chunkRisks(risks, tc.size)
+		if len(chunks) != len(tc.want) {
+			t.Errorf("size %d: expected %d chunks, got %d", tc.size, len(tc.want), len(chunks))
+			continue
+		}
+		for i, c := range chunks {
+			if len(c) != tc.want[i] {
+				t.Errorf("size %d: chunk %d has %d risks, want %d", tc.size, i, len(c), tc.want[i])
+			}
+		}
+	}
+}
+
+func TestGenerateHedges_MergesChunks(t *testing.T) {
+	h := &chunkHedger{}
+	job := newChunkJob(h, 2)
+
+	res, err := job.generateHedges(context.Background(), makeRisks("a", "b", "c", "d", "e"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", h.calls)
+	}
+	if len(res.Hedges) != 5 {
+		t.Errorf("expected 5 merged hedges, got %d", len(res.Hedges))
+	}
+	if res.ExecutiveSummary != "summary from a" {
+		t.Errorf("expected summary from the first chunk, got %q", res.ExecutiveSummary)
+	}
+}
+
+func TestGenerateHedges_PartialFailure(t *testing.T) {
+	h := &chunkHedger{failOn: map[string]bool{"a": true}}
+	job := newChunkJob(h, 2)
+
+	res, err := job.generateHedges(context.Background(), makeRisks("a", "b", "c", "d"))
+	if err != nil {
+		t.Fatalf("expected partial failure to be tolerated, got %v", err)
+	}
+	if len(res.Hedges) != 2 {
+		t.Errorf("expected hedges for the surviving chunk only, got %v", res.Hedges)
+	}
+	if _, ok := res.Hedges["a"]; ok {
+		t.Error("did not expect a hedge for a risk in the failed chunk")
+	}
+	if res.ExecutiveSummary != "summary from c" {
+		t.Errorf("expected summary from the next successful chunk, got %q", res.ExecutiveSummary)
+	}
+}
+
+func TestGenerateHedges_RecordsRejectedChunkIssues(t *testing.T) {
+	h := &chunkHedger{
+		failOn: map[string]bool{"a": true},
+		err:    &ai.QualityError{Issues: []string{"reply is not in English"}},
+	}
+	job := newChunkJob(h, 2)
+
+	res, err := job.generateHedges(context.Background(), makeRisks("a", "b", "c", "d"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.QualityIssues) != 1 || res.QualityIssues[0] != "reply is not in English" {
+		t.Errorf("expected the rejected chunk's issues on the result, got %v", res.QualityIssues)
+	}
+}
+
+func TestGenerateHedges_AllChunksFail(t *testing.T) {
+	h := &chunkHedger{failOn: map[string]bool{"a": true, "c": true}}
+	job := newChunkJob(h, 2)
+
+	if _, err := job.generateHedges(context.Background(), makeRisks("a", "b", "c", "d")); err == nil {
+		t.Fatal("expected an error when every chunk fails")
+	}
+}
+
+func TestFitBudget_SendsTheMostSevereRisksThatFit(t *testing.T) {
+	job := newChunkJob(&chunkHedger{}, 2)
+	risks := makeRisks("a", "b", "c", "d", "e")
+	ctx := context.Background()
+	job.cfg.AIMaxReportTokens = job.projectTokens(ctx, risks[:3])
+
+	sent, note := job.fitBudget(ctx, risks)
+	if len(sent) != 3 || sent[0].QuestionID != "a" {
+		t.Errorf("expected the first three risks, got %+v", sent)
+	}
+	if note == "" {
+		t.Error("expected a note of what was dropped")
+	}
+
+	job.cfg.AIMaxReportTokens = 1
+	if sent, _ := job.fitBudget(ctx, risks); len(sent) != 0 {
+		t.Errorf("expected no risks when none fits, got %d", len(sent))
+	}
+	job.cfg.AIMaxReportTokens = job.projectTokens(ctx, risks)
+	if sent, note := job.fitBudget(ctx, risks); len(sent) != len(risks) || note != "" {
+		t.Errorf("expected every risk and no note within budget, got %d and %q", len(sent), note)
+	}
+}
+
+// ─── AI cache ─────────────────────────────────────────────────────────────────
+
+// cacheQuerier keeps ai_cache rows in memory.
+type cacheQuerier struct {
+	db.ReportJobStore // embedded to panic on unimplemented methods
+	entries           map[string]db.AiCache
+}
+
+func (q *cacheQuerier) GetAICacheEntry(_ context.Context, arg db.GetAICacheEntryParams) (db.AiCache, error) {
+	e, ok := q.entries[arg.Fingerprint]
+	if !ok || !e.CreatedAt.After(arg.NotBefore) {
+		return db.AiCache{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (q *cacheQuerier) UpsertAICacheEntry(_ context.Context, arg db.UpsertAICacheEntryParams) error {
+	q.entries[arg.Fingerprint] = db.AiCache{
+		Fingerprint:      arg.Fingerprint,
+		Hedges:           arg.Hedges,
+		ExecutiveSummary: arg.ExecutiveSummary,
+		TopPriorityHtml:  arg.TopPriorityHtml,
+		CreatedAt:        time.Now(),
+	}
+	return nil
+}
+
+func newCacheJob(q db.ReportJobStore, h ai.Hedger) *Job {
+	return NewJob(q, nil, h, nil, JobConfig{AICacheTTL: time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCachedHedges_MissThenHit(t *testing.T) {
+	q := &cacheQuerier{entries: map[string]db.AiCache{}}
+	h := &chunkHedger{}
+	job := newCacheJob(q, h)
+	session := db.Session{Industry: sql.NullString{String: "saas", Valid: true}}
+	risks := makeRisks("a", "b")
+
+	first, err := job.cachedHedges(context.Background(), risks, session, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := job.cachedHedges(context.Background(), risks, session, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if h.calls != 1 {
+		t.Errorf("expected the second call to be served from cache, got %d AI calls", h.calls)
+	}
+	if second.ExecutiveSummary != first.ExecutiveSummary || len(second.Hedges) != 2 {
+		t.Errorf("expected cached result to match the original, got %+v", second)
+	}
+}
+
+func TestCachedHedges_SkipsPartialResults(t *testing.T) {
+	q := &cacheQuerier{entries: map[string]db.AiCache{}}
+	job := newCacheJob(q, &chunkHedger{failOn: map[string]bool{"a": true}})
+	job.cfg.AIChunkSize = 1
+
+	if _, err := job.cachedHedges(context.Background(), makeRisks("a", "b"), db.Session{}, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(q.entries) != 0 {
+		t.Errorf("expected partial result not to be cached, got %d entries", len(q.entries))
+	}
+}
+
+func TestCachedHedges_IgnoresExpiredEntries(t *testing.T) {
+	risks := makeRisks("a")
+	fp := ai.Fingerprint(risks, "", "", ai.ReportStandard, "")
+	hedges, _ := json.Marshal(map[string]string{"a": "stale"})
+
+	q := &cacheQuerier{entries: map[string]db.AiCache{
+		fp: {Fingerprint: fp, Hedges: hedges, CreatedAt: time.Now().Add(-2 * time.Hour)},
+	}}
+	h := &chunkHedger{}
+	job := newCacheJob(q, h)
+
+	res, err := job.cachedHedges(context.Background(), risks, db.Session{}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.calls != 1 || res.Hedges["a"] != "hedge a" {
+		t.Errorf("expected an expired entry to be regenerated, got %+v after %d calls", res, h.calls)
+	}
+}
+
+type playbookQuerier struct {
+	db.ReportJobStore // embedded to panic on unimplemented methods
+	snippets          []db.PlaybookSnippet
+	err               error
+}
+
+func (q *playbookQuerier) ListActivePlaybookSnippetsByIndustry(_ context.Context, _ string) ([]db.PlaybookSnippet, error) {
+	return q.snippets, q.err
+}
+
+func TestWithPlaybook_AttachesRankedSnippets(t *testing.T) {
+	q := &playbookQuerier{snippets: []db.PlaybookSnippet{
+		{Title: "general", Body: "b"},
+		{Title: "supply", Body: "b", Keywords: []string{"supplier"}},
+	}}
+	job := NewJob(q, nil, nil, nil, JobConfig{PlaybookSnippets: 1}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	risks := []scoring.ScoredRisk{{QuestionID: "q1", RiskName: "Single supplier"}}
+
+	got := ai.SnippetsFrom(job.withPlaybook(context.Background(), risks, "Retail"))
+	if len(got) != 1 || got[0].Title != "supply" {
+		t.Errorf("expected the matching snippet only, got %+v", got)
+	}
+
+	q.err = errors.New("db down")
+	if got := ai.SnippetsFrom(job.withPlaybook(context.Background(), risks, "Retail")); got != nil {
+		t.Errorf("expected no snippets when the playbook cannot be loaded, got %+v", got)
+	}
+
+	job.cfg.PlaybookSnippets = 0
+	q.err = nil
+	if got := ai.SnippetsFrom(job.withPlaybook(context.Background(), risks, "Retail")); got != nil {
+		t.Errorf("expected no snippets when disabled, got %+v", got)
+	}
+}
+
+// analysingHedger is a chunkHedger that also implements ai.Analyser and
+// records whether each narrative call was given an analysis.
+type analysingHedger struct {
+	chunkHedger
+	analyseErr   error
+	dependencies []ai.Dependency
+	analyseCalls int
+	withAnalysis int
+}
+
+func (h *analysingHedger) Analyse(_ context.Context, risks []scoring.ScoredRisk) (ai.Analysis, error) {
+	h.mu.Lock()
+	h.analyseCalls++
+	h.mu.Unlock()
+	if h.analyseErr != nil {
+		return ai.Analysis{}, h.analyseErr
+	}
+	return ai.Analysis{TopPriority: risks[0].QuestionID, Dependencies: h.dependencies}, nil
+}
+
+func (h *analysingHedger) GenerateHedges(ctx context.Context, risks []scoring.ScoredRisk) (ai.HedgeResult, error) {
+	if ai.AnalysisFrom(ctx) != nil {
+		h.mu.Lock()
+		h.withAnalysis++
+		h.mu.Unlock()
+	}
+	return h.chunkHedger.GenerateHedges(ctx, risks)
+}
+
+func TestGenerateHedges_AnalysesOnceForAllChunks(t *testing.T) {
+	h := &analysingHedger{}
+	job := newChunkJob(h, 1)
+
+	res, err := job.generateHedges(context.Background(), makeRisks("a", "b", "c"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.analyseCalls != 1 || h.withAnalysis != 3 {
+		t.Errorf("expected one analysis shared by 3 chunks, got %d analyses and %d chunks with one", h.analyseCalls, h.withAnalysis)
+	}
+	if res.Analysis == nil || res.Analysis.TopPriority != "a" {
+		t.Errorf("expected the analysis on the result, got %+v", res.Analysis)
+	}
+}
+
+func TestGenerateHedges_KeepsDependenciesAcrossChunks(t *testing.T) {
+	h := &analysingHedger{dependencies: []ai.Dependency{{From: "a", To: "c", Note: "a drives c"}}}
+	job := newChunkJob(h, 1)
+
+	res, err := job.generateHedges(context.Background(), makeRisks("a", "b", "c"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ai.Relationship{From: "a", To: "c", Description: "a drives c"}
+	if len(res.Relationships) != 1 || res.Relationships[0] != want {
+		t.Errorf("expected the analysis dependency as a relationship, got %+v", res.Relationships)
+	}
+}
+
+func TestGenerateHedges_AnalysisFailureFallsBackToSingleStage(t *testing.T) {
+	h := &analysingHedger{analyseErr: errors.New("analysis down")}
+	job := newChunkJob(h, 0)
+
+	res, err := job.generateHedges(context.Background(), makeRisks("a", "b"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.withAnalysis != 0 || res.Analysis != nil || len(res.Hedges) != 2 {
+		t.Errorf("expected a single-stage result, got %+v", res)
+	}
+}
+
+func TestCachedHedges_StoredAnalysisSkipsAnalysisAndCache(t *testing.T) {
+	risks := makeRisks("a")
+	fp := ai.Fingerprint(risks, "", "", ai.ReportStandard, "")
+	hedges, _ := json.Marshal(map[string]string{"a": "cached"})
+	q := &cacheQuerier{entries: map[string]db.AiCache{
+		fp: {Fingerprint: fp, Hedges: hedges, CreatedAt: time.Now()},
+	}}
+	h := &analysingHedger{}
+	job := newCacheJob(q, h)
+
+	stored := &ai.Analysis{TopPriority: "a"}
+	res, err := job.cachedHedges(ai.WithAnalysis(context.Background(), stored), risks, db.Session{}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.analyseCalls != 0 || h.calls != 1 || res.Hedges["a"] != "hedge a" {
+		t.Errorf("expected a fresh narrative from the stored analysis, got %+v after %d analyses and %d calls", res, h.analyseCalls, h.calls)
+	}
+	if res.Analysis != stored {
+		t.Errorf("expected the stored analysis on the result, got %+v", res.Analysis)
+	}
+}
+
+// shadowQuerier records shadow scores.
+type shadowQuerier struct {
+	db.ReportJobStore // embedded to panic on unimplemented methods
+	scores            []db.UpsertShadowScoreParams
+}
+
+func (q *shadowQuerier) UpsertShadowScore(_ context.Context, arg db.UpsertShadowScoreParams) error {
+	q.scores = append(q.scores, arg)
+	return nil
+}
+
+func TestShadowScore_RecordsCandidateNextToProduction(t *testing.T) {
+	q := &shadowQuerier{}
+	job := NewJob(q, nil, nil, nil, JobConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	risks := []scoring.ScoredRisk{{Score: 90, Tier: scoring.TierWatch}}
+	for i := 0; i < 9; i++ {
+		risks = append(risks, scoring.ScoredRisk{Score: 10, Tier: scoring.TierIgnore})
+	}
+	mean := scoring.Profile{Mode: scoring.ModeMean}
+	maxDominant := scoring.Profile{Mode: scoring.ModeMaxDominant}
+
+	job.shadowScore(context.Background(), uuid.New(), risks, mean, nil)
+	job.shadowScore(context.Background(), uuid.New(), risks, mean, &mean)
+	if len(q.scores) != 0 {
+		t.Fatalf("expected nothing recorded without a distinct candidate, got %+v", q.scores)
+	}
+
+	job.shadowScore(context.Background(), uuid.New(), risks, mean, &maxDominant)
+	if len(q.scores) != 1 {
+		t.Fatalf("expected one shadow score, got %d", len(q.scores))
+	}
+	got := q.scores[0]
+	if got.Profile != "max_dominant" || got.ProductionProfile != "mean" || got.ProductionScore != 18 || got.ShadowScore <= 90 {
+		t.Errorf("unexpected shadow score %+v", got)
+	}
+	if got.ProductionBand != string(scoring.Band(18)) || got.ShadowBand != string(scoring.Band(int(got.ShadowScore))) {
+		t.Errorf("unexpected bands %+v", got)
+	}
+}
+
+// stateQuerier keeps the checkpoints saved for reports.
+type stateQuerier struct {
+	db.ReportJobStore // embedded to panic on unimplemented methods
+	saved             map[uuid.UUID]json.RawMessage
+}
+
+func (q *stateQuerier) SaveReportJobState(_ context.Context, arg db.SaveReportJobStateParams) error {
+	q.saved[arg.ID] = arg.JobState.RawMessage
+	return nil
+}
+
+func TestJobState_RoundTripsAIOutput(t *testing.T) {
+	q := &stateQuerier{saved: make(map[uuid.UUID]json.RawMessage)}
+	j := NewJob(q, nil, nil, nil, JobConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	reportID := uuid.New()
+
+	risks := makeRisks("q1", "q2")
+	risks[0].Tier = scoring.TierWatch
+	risks[0].Explanation = scoring.Explanation{P: 8, I: 7, Tier: scoring.TierWatch}
+	want := jobState{Risks: risks, AI: &aiCheckpoint{
+		Result: ai.HedgeResult{
+			Hedges:           map[string]string{"q1": "hedge q1"},
+			ExecutiveSummary: "summary",
+			Relationships:    []ai.Relationship{{From: "q1", To: "q2", Description: "worse"}},
+			Analysis:         &ai.Analysis{Priorities: []string{"q1"}, TopPriority: "q1"},
+		},
+		BudgetNote: "left off q2",
+	}}
+	j.saveState(context.Background(), reportID, want)
+
+	got := j.loadState(context.Background(), db.Report{
+		ID:       reportID,
+		JobState: pqtype.NullRawMessage{RawMessage: q.saved[reportID], Valid: true},
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+
+	if got := j.loadState(context.Background(), db.Report{JobState: pqtype.NullRawMessage{RawMessage: json.RawMessage(`{"risks":`), Valid: true}}); got.Risks != nil || got.AI != nil {
+		t.Errorf("unreadable checkpoint loaded as %+v, want a fresh start", got)
+	}
+}
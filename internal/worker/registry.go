@@ -0,0 +1,227 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── JOB TYPES ────────────────────────────────────────────────────────────────
+//
+// The Runner runs every kind of background work, each registered as a
+// JobType with its own handler, attempt limit and timeout. Scoring a report
+// is queued by its reports row, as before; every other type is queued in the
+// jobs table, so work submitted on one replica survives a restart and is
+// shared out by the other replicas' pollers. A job can be queued to run
+// later (SubmitAt), so follow-ups such as reminder emails are scheduled when
+// their cause happens rather than found by scanning for them.
+
+// JobType names a kind of background job. It is stored in jobs.type.
+type JobType string
+
+const (
+	// JobScoreReport scores a paid report, generates its hedges and emails
+	// the customer. Built in; queued by Enqueue.
+	JobScoreReport JobType = "score_report"
+	// JobProcessStripeEvent dispatches a stored Stripe webhook event to the
+	// StripeEventHandler. Built in; queued by EnqueueStripeEvent.
+	JobProcessStripeEvent JobType = "process_stripe_event"
+	// JobSendEmailRetry sends a report-ready email again after its first
+	// send failed. Built in; queued by the score_report job.
+	JobSendEmailRetry JobType = "send_email_retry"
+	// JobCleanupSessions runs the retention pass, which deletes the answers
+	// of abandoned sessions and other data past its window. Registered by
+	// main with CleanupSessionsSpec.
+	JobCleanupSessions JobType = "cleanup_sessions"
+	// JobSendReportReminder resends a report-ready email still unopened a
+	// while after it was sent. Registered by main with Resender.Spec;
+	// scheduled by the score_report job.
+	JobSendReportReminder JobType = "send_report_reminder"
+	// JobSendFeedbackRequest asks a customer to rate their report a while
+	// after delivery. Registered by main with FeedbackRequester.Spec;
+	// scheduled by the score_report job.
+	JobSendFeedbackRequest JobType = "send_feedback_request"
+)
+
+// JobSpec describes how jobs of one type run.
+type JobSpec struct {
+	Type JobType
+
+	// Run does one attempt at a job. payload is what the job was submitted
+	// with. A returned error is retried until MaxAttempts is reached.
+	Run func(ctx context.Context, payload json.RawMessage) error
+
+	// MaxAttempts is the number of times a job is tried, across restarts.
+	// Default: RunnerConfig.MaxRetries.
+	MaxAttempts int
+
+	// Timeout bounds one attempt. Default: RunnerConfig.JobTimeout.
+	Timeout time.Duration
+
+	// Backoff is the wait after the first failed attempt, doubling after
+	// each one after it. Default: 2s.
+	Backoff time.Duration
+
+	// Failed is called once a job has failed its last attempt, with that
+	// attempt's error. May be nil.
+	Failed func(ctx context.Context, payload json.RawMessage, err error)
+}
+
+// backoff returns the wait after the given failed attempt.
+func (s JobSpec) backoff(attempt int) time.Duration {
+	return s.Backoff << (attempt - 1)
+}
+
+// lease is how long a claim keeps other replicas off a job: long enough for
+// every attempt and back-off, plus a minute's slack. A replica that crashes
+// holds its jobs for this long.
+func (s JobSpec) lease() time.Duration {
+	d := time.Minute
+	for attempt := 1; attempt <= s.MaxAttempts; attempt++ {
+		d += s.Timeout + s.backoff(attempt)
+	}
+	return d
+}
+
+// Register adds a job type. Call it before Start. It panics when the type is
+// already registered or spec has no Run: both are programming errors.
+func (r *Runner) Register(spec JobSpec) {
+	if spec.Run == nil {
+		panic(fmt.Sprintf("worker: job type %q registered without Run", spec.Type))
+	}
+	if spec.MaxAttempts <= 0 {
+		spec.MaxAttempts = r.cfg.MaxRetries
+	}
+	if spec.Timeout <= 0 {
+		spec.Timeout = r.cfg.JobTimeout
+	}
+	if spec.Backoff <= 0 {
+		spec.Backoff = 2 * time.Second
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.specs[spec.Type]; ok {
+		panic(fmt.Sprintf("worker: job type %q registered twice", spec.Type))
+	}
+	r.specs[spec.Type] = spec
+}
+
+// spec returns the registration for typ.
+func (r *Runner) spec(typ JobType) (JobSpec, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spec, ok := r.specs[typ]
+	return spec, ok
+}
+
+// tableTypes lists the registered types queued in the jobs table, for the
+// poller.
+func (r *Runner) tableTypes() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]string, 0, len(r.specs))
+	for typ := range r.specs {
+		if typ != JobScoreReport {
+			types = append(types, string(typ))
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// Submit queues a job of a registered type to run now, with payload
+// marshalled to JSON as what its Run receives. With a dedupeKey, a job
+// already submitted with the same key is not queued again and Submit returns
+// nil. The job is stored first, so when the in-process queue is full, or the
+// Runner is draining, a poller still picks it up.
+func (r *Runner) Submit(ctx context.Context, typ JobType, payload any, dedupeKey string) error {
+	return r.SubmitAt(ctx, typ, payload, dedupeKey, time.Now())
+}
+
+// SubmitAt is Submit for a job that is not to run before runAt. A job due
+// later is left to the pollers, so it runs within a poll interval of runAt.
+func (r *Runner) SubmitAt(ctx context.Context, typ JobType, payload any, dedupeKey string, runAt time.Time) error {
+	if typ == JobScoreReport {
+		return errors.New("worker: reports are queued with Enqueue")
+	}
+	if _, ok := r.spec(typ); !ok {
+		return fmt.Errorf("worker: job type %q is not registered", typ)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("worker: marshal %s payload: %w", typ, err)
+	}
+	job, err := r.q.InsertJob(ctx, db.InsertJobParams{
+		Type:      string(typ),
+		Payload:   raw,
+		DedupeKey: sql.NullString{String: dedupeKey, Valid: dedupeKey != ""},
+		RunAt:     runAt,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.DebugContext(ctx, "worker: job already submitted", "job_type", typ, "dedupe_key", dedupeKey)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("worker: insert %s job: %w", typ, err)
+	}
+
+	if r.Draining() || runAt.After(time.Now()) {
+		return nil
+	}
+	select {
+	case r.queue <- task{typ: typ, id: job.ID, payload: raw}:
+		r.logger.DebugContext(ctx, "worker: enqueued job", "job_type", typ, "job_id", job.ID)
+	default:
+		r.logger.WarnContext(ctx, "worker: queue is full, job will be picked up by poller", "job_type", typ, "job_id", job.ID)
+	}
+	return nil
+}
+
+// Every runs a job of typ at the start of every interval until ctx is
+// cancelled: the current interval's job is submitted at once and the next
+// one's is scheduled for its start, ahead of time, so it runs on time even
+// if this replica has stopped by then. Each interval's job has a dedupe key,
+// so with several replicas doing the same it still runs once per interval.
+func (r *Runner) Every(ctx context.Context, typ JobType, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		current := time.Now().Truncate(interval)
+		for _, at := range []time.Time{current, current.Add(interval)} {
+			key := string(typ) + ":" + at.UTC().Format(time.RFC3339)
+			if err := r.SubmitAt(ctx, typ, struct{}{}, key, at); err != nil {
+				r.logger.ErrorContext(ctx, "worker: could not submit scheduled job", "job_type", typ, "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// task is one queued job: a report for JobScoreReport, a jobs row for every
+// other type.
+type task struct {
+	typ     JobType
+	id      uuid.UUID // the report's ID, or the jobs row's
+	payload json.RawMessage
+}
+
+// tags identifies the task in error reports.
+func (t task) tags() map[string]string {
+	if t.typ == JobScoreReport {
+		return map[string]string{"component": "worker", "report_id": t.id.String()}
+	}
+	return map[string]string{"component": "worker", "job_type": string(t.typ), "job_id": t.id.String()}
+}
@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// jobsQuerier keeps jobs in memory and records what the Runner does to them.
+type jobsQuerier struct {
+	db.RunnerStore // embedded to panic on unimplemented methods
+	jobs           map[uuid.UUID]db.Job
+	dedupe         map[string]bool
+	attempts       []db.RecordJobAttemptParams
+	completed      []uuid.UUID
+	failed         []uuid.UUID
+}
+
+func newJobsQuerier() *jobsQuerier {
+	return &jobsQuerier{jobs: make(map[uuid.UUID]db.Job), dedupe: make(map[string]bool)}
+}
+
+func (q *jobsQuerier) InsertJob(_ context.Context, arg db.InsertJobParams) (db.Job, error) {
+	if arg.DedupeKey.Valid {
+		if q.dedupe[arg.DedupeKey.String] {
+			return db.Job{}, sql.ErrNoRows
+		}
+		q.dedupe[arg.DedupeKey.String] = true
+	}
+	job := db.Job{ID: uuid.New(), Type: arg.Type, Payload: arg.Payload, DedupeKey: arg.DedupeKey, Status: "pending", RunAt: arg.RunAt}
+	q.jobs[job.ID] = job
+	return job, nil
+}
+
+func (q *jobsQuerier) ClaimJob(_ context.Context, arg db.ClaimJobParams) (db.Job, error) {
+	job, ok := q.jobs[arg.ID]
+	if !ok || job.Status != "pending" || job.RunAt.After(time.Now()) {
+		return db.Job{}, sql.ErrNoRows
+	}
+	return job, nil
+}
+
+func (q *jobsQuerier) RecordJobAttempt(_ context.Context, arg db.RecordJobAttemptParams) error {
+	q.attempts = append(q.attempts, arg)
+	job := q.jobs[arg.ID]
+	job.Attempts++
+	q.jobs[arg.ID] = job
+	return nil
+}
+
+func (q *jobsQuerier) CompleteJob(_ context.Context, id uuid.UUID) error {
+	q.completed = append(q.completed, id)
+	job := q.jobs[id]
+	job.Status = "done"
+	q.jobs[id] = job
+	return nil
+}
+
+func (q *jobsQuerier) FailJob(_ context.Context, id uuid.UUID) error {
+	q.failed = append(q.failed, id)
+	job := q.jobs[id]
+	job.Status = "failed"
+	q.jobs[id] = job
+	return nil
+}
+
+func newTestRunner(q db.RunnerStore) *Runner {
+	return NewRunner(nil, nil, q, RunnerConfig{Workers: 2}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// runQueued runs every task waiting in the Runner's queue.
+func runQueued(r *Runner) {
+	for {
+		select {
+		case t := <-r.queue:
+			r.runWithRetry(context.Background(), t, r.logger)
+		default:
+			return
+		}
+	}
+}
+
+const testJobType JobType = "test_job"
+
+func TestSubmit_RetriesUntilTheJobSucceeds(t *testing.T) {
+	q := newJobsQuerier()
+	r := newTestRunner(q)
+	var payloads []string
+	r.Register(JobSpec{
+		Type:        testJobType,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		Run: func(_ context.Context, payload json.RawMessage) error {
+			payloads = append(payloads, string(payload))
+			if len(payloads) == 1 {
+				return errors.New("provider down")
+			}
+			return nil
+		},
+	})
+
+	if err := r.Submit(context.Background(), testJobType, map[string]int{"n": 1}, ""); err != nil {
+		t.Fatal(err)
+	}
+	runQueued(r)
+
+	if len(payloads) != 2 || payloads[1] != `{"n":1}` {
+		t.Fatalf("runs = %q, want two with the submitted payload", payloads)
+	}
+	if len(q.attempts) != 1 || q.attempts[0].Error.String != "provider down" {
+		t.Errorf("attempts recorded = %+v, want the one failure", q.attempts)
+	}
+	if len(q.completed) != 1 || len(q.failed) != 0 {
+		t.Errorf("completed = %v, failed = %v; want one completed", q.completed, q.failed)
+	}
+}
+
+func TestSubmit_GivesUpAfterMaxAttempts(t *testing.T) {
+	q := newJobsQuerier()
+	r := newTestRunner(q)
+	runs := 0
+	var failedWith error
+	r.Register(JobSpec{
+		Type:        testJobType,
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		Run: func(context.Context, json.RawMessage) error {
+			runs++
+			return errors.New("still down")
+		},
+		Failed: func(_ context.Context, _ json.RawMessage, err error) { failedWith = err },
+	})
+
+	if err := r.Submit(context.Background(), testJobType, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	runQueued(r)
+
+	if runs != 2 {
+		t.Errorf("runs = %d, want 2", runs)
+	}
+	if len(q.failed) != 1 || len(q.completed) != 0 {
+		t.Errorf("failed = %v, completed = %v; want one failed", q.failed, q.completed)
+	}
+	if failedWith == nil || failedWith.Error() != "still down" {
+		t.Errorf("Failed hook got %v, want the last error", failedWith)
+	}
+}
+
+func TestRunWithRetry_CountsAttemptsFromBeforeARestart(t *testing.T) {
+	q := newJobsQuerier()
+	r := newTestRunner(q)
+	runs := 0
+	r.Register(JobSpec{
+		Type:        testJobType,
+		MaxAttempts: 3,
+		Run: func(context.Context, json.RawMessage) error {
+			runs++
+			return errors.New("down")
+		},
+	})
+	job := db.Job{ID: uuid.New(), Type: string(testJobType), Status: "pending", Attempts: 2}
+	q.jobs[job.ID] = job
+
+	r.runWithRetry(context.Background(), task{typ: testJobType, id: job.ID}, r.logger)
+
+	if runs != 1 || len(q.failed) != 1 {
+		t.Errorf("runs = %d, failed = %v; want the one attempt left, then failed", runs, q.failed)
+	}
+}
+
+func TestSubmit_DedupeKeyQueuesOnce(t *testing.T) {
+	q := newJobsQuerier()
+	r := newTestRunner(q)
+	r.Register(JobSpec{Type: testJobType, Run: func(context.Context, json.RawMessage) error { return nil }})
+
+	for range 2 {
+		if err := r.Submit(context.Background(), testJobType, nil, "once"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(q.jobs) != 1 || len(r.queue) != 1 {
+		t.Errorf("jobs = %d, queued = %d; want 1 and 1", len(q.jobs), len(r.queue))
+	}
+}
+
+func TestSubmitAt_LeavesFutureJobsToThePoller(t *testing.T) {
+	q := newJobsQuerier()
+	r := newTestRunner(q)
+	r.Register(JobSpec{Type: testJobType, Run: func(context.Context, json.RawMessage) error { return nil }})
+
+	if err := r.SubmitAt(context.Background(), testJobType, nil, "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.jobs) != 1 || len(r.queue) != 0 {
+		t.Errorf("jobs = %d, queued = %d; want the job stored but not queued", len(q.jobs), len(r.queue))
+	}
+}
+
+func TestSubmit_RejectsUnknownTypes(t *testing.T) {
+	r := newTestRunner(newJobsQuerier())
+	if err := r.Submit(context.Background(), testJobType, nil, ""); err == nil {
+		t.Error("Submit of an unregistered type: want an error")
+	}
+	if err := r.Submit(context.Background(), JobScoreReport, nil, ""); err == nil {
+		t.Error("Submit of score_report: want an error, reports are Enqueued")
+	}
+}
+
+func TestRegister_PanicsOnDuplicateType(t *testing.T) {
+	r := newTestRunner(newJobsQuerier())
+	defer func() {
+		if recover() == nil {
+			t.Error("registering process_stripe_event again: want a panic")
+		}
+	}()
+	r.Register(JobSpec{Type: JobProcessStripeEvent, Run: func(context.Context, json.RawMessage) error { return nil }})
+}
+
+func TestJobSpec_LeaseCoversEveryAttempt(t *testing.T) {
+	spec := JobSpec{MaxAttempts: 3, Timeout: time.Minute, Backoff: 2 * time.Second}
+	// Three attempts, 2s+4s+8s of back-off and a minute's slack.
+	if got, want := spec.lease(), 4*time.Minute+14*time.Second; got != want {
+		t.Errorf("lease = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// ─── RESENDER ─────────────────────────────────────────────────────────────────
+
+// ResendConfig controls the automatic resend of unopened report emails.
+type ResendConfig struct {
+	// After is how long a report-ready email may go unopened before it is
+	// sent again. Zero disables resending.
+	After time.Duration
+
+	// MaxAge stops emails older than this from being resent, so a reminder
+	// held up for days, by an outage, is dropped rather than sent late.
+	// Default: After + 5 days.
+	MaxAge time.Duration
+}
+
+// Resender sends a reminder, once, for report-ready emails that were never
+// opened. It runs the send_report_reminder jobs the score_report job
+// schedules for After past each delivery. It relies on opens and clicks
+// reported by Resend's webhook: without it every email looks unopened, so
+// only schedule reminders with the webhook configured.
+type Resender struct {
+	q      db.ReminderStore
+	mailer email.Sender
+	cfg    ResendConfig
+	logger *slog.Logger
+}
+
+// NewResender returns a Resender. Register its Spec with the Runner.
+func NewResender(q db.ReminderStore, mailer email.Sender, cfg ResendConfig, logger *slog.Logger) *Resender {
+	if cfg.MaxAge <= cfg.After {
+		cfg.MaxAge = cfg.After + 5*24*time.Hour
+	}
+	return &Resender{q: q, mailer: mailer, cfg: cfg, logger: logger}
+}
+
+// Spec is the send_report_reminder job type.
+func (rs *Resender) Spec() JobSpec {
+	return JobSpec{
+		Type: JobSendReportReminder,
+		Run: func(ctx context.Context, payload json.RawMessage) error {
+			var p reportPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return fmt.Errorf("resender: decode payload: %w", err)
+			}
+			_, err := rs.Remind(ctx, p.ReportID)
+			return err
+		},
+		MaxAttempts: 3,
+		Timeout:     30 * time.Second,
+		Backoff:     time.Minute,
+	}
+}
+
+// Remind resends the report's report-ready email if it is still unopened, and
+// returns how many reminders were sent. The email is claimed first
+// (MarkEmailResent), so it is never reminded twice; a claimed email whose
+// send then fails is not retried — its failure is in email_log for support to
+// follow up. With resending disabled since the job was scheduled it sends
+// nothing.
+func (rs *Resender) Remind(ctx context.Context, reportID uuid.UUID) (int, error) {
+	if rs.cfg.After <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	rows, err := rs.q.ListUnopenedReportEmails(ctx, db.ListUnopenedReportEmailsParams{
+		SentAfter:  now.Add(-rs.cfg.MaxAge),
+		SentBefore: now.Add(-rs.cfg.After),
+		ReportID:   uuid.NullUUID{UUID: reportID, Valid: true},
+		MaxRows:    1,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sentCount := 0
+	for _, row := range rows {
+		claimed, err := rs.q.MarkEmailResent(ctx, row.ID)
+		if err != nil {
+			return sentCount, err
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		sent, sendErr := rs.mailer.SendReportReady(ctx, email.ReportReadyParams{
+			To:          row.ToAddress,
+			BizName:     row.BizName.String,
+			AccessToken: row.AccessToken,
+			Reminder:    true,
+		})
+		if err := email.Record(ctx, rs.q, email.LogEntry{
+			SessionID: row.SessionID.UUID,
+			ReportID:  row.ReportID.UUID,
+			To:        row.ToAddress,
+			Template:  email.TemplateReportReminder,
+		}, sent, sendErr); err != nil {
+			rs.logger.WarnContext(ctx, "resender: could not record reminder", "email_log_id", row.ID, "error", err)
+		}
+		if sendErr != nil {
+			rs.logger.ErrorContext(ctx, "resender: reminder failed",
+				"email_log_id", row.ID,
+				"report_id", row.ReportID.UUID,
+				"error", sendErr,
+			)
+			continue
+		}
+		rs.logger.InfoContext(ctx, "resender: reminder sent",
+			"email_log_id", row.ID,
+			"report_id", row.ReportID.UUID,
+			"unopened_for", now.Sub(row.SentAt.Time).Round(time.Minute),
+		)
+		sentCount++
+	}
+	return sentCount, nil
+}
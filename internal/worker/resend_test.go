@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/email"
+)
+
+// resendQuerier serves a fixed list of unopened emails and records claims and
+// email_log writes. claimedElsewhere lists rows another replica already has.
+type resendQuerier struct {
+	db.ReminderStore // embedded to panic on unimplemented methods
+	unopened         []db.ListUnopenedReportEmailsRow
+	claimedElsewhere map[uuid.UUID]bool
+	listed           db.ListUnopenedReportEmailsParams
+	claimed          []uuid.UUID
+	logged           []db.LogEmailParams
+	failed           []db.LogEmailFailureParams
+}
+
+func (q *resendQuerier) ListUnopenedReportEmails(_ context.Context, arg db.ListUnopenedReportEmailsParams) ([]db.ListUnopenedReportEmailsRow, error) {
+	q.listed = arg
+	return q.unopened, nil
+}
+
+func (q *resendQuerier) MarkEmailResent(_ context.Context, id uuid.UUID) (int64, error) {
+	if q.claimedElsewhere[id] {
+		return 0, nil
+	}
+	q.claimed = append(q.claimed, id)
+	return 1, nil
+}
+
+func (q *resendQuerier) LogEmail(_ context.Context, arg db.LogEmailParams) (db.EmailLog, error) {
+	q.logged = append(q.logged, arg)
+	return db.EmailLog{}, nil
+}
+
+func (q *resendQuerier) LogEmailFailure(_ context.Context, arg db.LogEmailFailureParams) (db.EmailLog, error) {
+	q.failed = append(q.failed, arg)
+	return db.EmailLog{}, nil
+}
+
+// reminderMailer records report-ready and feedback sends and fails
+// report-ready sends to failTo.
+type reminderMailer struct {
+	sent     []email.ReportReadyParams
+	feedback []email.FeedbackRequestParams
+	failTo   string
+}
+
+func (m *reminderMailer) SendReportReady(_ context.Context, p email.ReportReadyParams) (email.Sent, error) {
+	if p.To == m.failTo {
+		return email.Sent{Subject: "s"}, errors.New("resend: 422")
+	}
+	m.sent = append(m.sent, p)
+	return email.Sent{ProviderID: "msg_" + p.To, Subject: "s"}, nil
+}
+
+func (m *reminderMailer) SendReceipt(context.Context, email.ReceiptParams) (email.Sent, error) {
+	return email.Sent{}, nil
+}
+
+func (m *reminderMailer) SendFeedbackRequest(_ context.Context, p email.FeedbackRequestParams) (email.Sent, error) {
+	m.feedback = append(m.feedback, p)
+	return email.Sent{ProviderID: "msg_" + p.To, Subject: "s"}, nil
+}
+
+func unopenedRow(to string) db.ListUnopenedReportEmailsRow {
+	return db.ListUnopenedReportEmailsRow{
+		ID:          uuid.New(),
+		SessionID:   uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		ReportID:    uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		ToAddress:   to,
+		AccessToken: "tok_" + to,
+	}
+}
+
+func TestResender_SendsRemindersForClaimedEmailsOnly(t *testing.T) {
+	a, b, c := unopenedRow("a@acme.co"), unopenedRow("b@acme.co"), unopenedRow("c@acme.co")
+	q := &resendQuerier{
+		unopened:         []db.ListUnopenedReportEmailsRow{a, b, c},
+		claimedElsewhere: map[uuid.UUID]bool{b.ID: true},
+	}
+	m := &reminderMailer{failTo: "c@acme.co"}
+	rs := NewResender(q, m, ResendConfig{After: 48 * time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	reportID := uuid.New()
+	before := time.Now()
+	n, err := rs.Remind(context.Background(), reportID)
+	if err != nil {
+		t.Fatalf("Remind: %v", err)
+	}
+	if n != 1 || len(m.sent) != 1 || m.sent[0].To != "a@acme.co" || !m.sent[0].Reminder || m.sent[0].AccessToken != "tok_a@acme.co" {
+		t.Fatalf("expected one reminder to a@acme.co, got %d: %+v", n, m.sent)
+	}
+	if len(q.logged) != 1 || q.logged[0].Template != email.TemplateReportReminder || q.logged[0].ProviderID.String != "msg_a@acme.co" {
+		t.Errorf("expected the reminder logged with its message ID, got %+v", q.logged)
+	}
+	if len(q.failed) != 1 || q.failed[0].ToAddress != "c@acme.co" {
+		t.Errorf("expected the failed reminder logged, got %+v", q.failed)
+	}
+	if want := before.Add(-48 * time.Hour); q.listed.SentBefore.After(want.Add(time.Second)) || q.listed.SentAfter.After(q.listed.SentBefore) {
+		t.Errorf("unexpected window [%v, %v)", q.listed.SentAfter, q.listed.SentBefore)
+	}
+	if q.listed.ReportID != (uuid.NullUUID{UUID: reportID, Valid: true}) {
+		t.Errorf("listed report %v, want %v", q.listed.ReportID, reportID)
+	}
+}
+
+func TestResender_SendsNothingOnceDisabled(t *testing.T) {
+	q := &resendQuerier{unopened: []db.ListUnopenedReportEmailsRow{unopenedRow("a@acme.co")}}
+	m := &reminderMailer{}
+	rs := NewResender(q, m, ResendConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	n, err := rs.Remind(context.Background(), uuid.New())
+	if err != nil || n != 0 || len(m.sent) != 0 {
+		t.Errorf("Remind with resending disabled = %d, %v; sent %+v; want nothing sent", n, err, m.sent)
+	}
+}
@@ -1,19 +1,29 @@
 // Package worker contains the background job pipeline that scores answers,
 // generates AI hedge narratives, persists the report, and sends the delivery
-// email. It is intentionally decoupled from the HTTP layer: the api package
-// holds a worker.Enqueuer interface and calls Enqueue — it never imports the
-// concrete Runner or Job types.
+// email, and the other background jobs registered with the Runner. It is
+// intentionally decoupled from the HTTP layer: the api package holds a
+// worker.Enqueuer interface and calls Enqueue — it never imports the concrete
+// Runner or Job types.
 package worker
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/errorreport"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/logging"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/requestid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/settings"
 	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
 )
 
@@ -29,6 +39,58 @@ type Enqueuer interface {
 	Enqueue(ctx context.Context, reportID uuid.UUID) error
 }
 
+// Backlog is implemented by Enqueuers that can say how busy they are. The api
+// package checks for it with a type assertion and, during a spike, tells the
+// customer how long their report will take.
+type Backlog interface {
+	QueueStats() QueueStats
+}
+
+// Drainer is implemented by Enqueuers that can stop taking work ahead of a
+// rolling deploy. The api package checks for it with a type assertion to
+// serve POST /internal/drain and fail /readyz while draining.
+type Drainer interface {
+	Drain()
+	Draining() bool
+}
+
+// QueueStats is a snapshot of the Runner's queue.
+type QueueStats struct {
+	Queued   int // reports waiting for a worker
+	Capacity int // size of the in-process queue
+	Running  int // reports being generated now
+	Workers  int
+
+	// AvgJobDuration is the recent average time to generate a report, or
+	// defaultJobDuration before any has finished.
+	AvgJobDuration time.Duration
+}
+
+// defaultJobDuration stands in for the average until a job has finished.
+const defaultJobDuration = time.Minute
+
+// Busy reports whether a report enqueued now would wait for a full round of
+// jobs before a worker picks it up.
+func (s QueueStats) Busy() bool {
+	return s.Workers > 0 && s.Queued >= s.Workers
+}
+
+// Wait estimates how long a report enqueued now takes to be ready.
+func (s QueueStats) Wait() time.Duration {
+	return s.ETA(s.Queued + s.Running)
+}
+
+// ETA estimates how long a report with ahead reports in front of it takes to
+// be ready: the rounds of jobs ahead of it, plus its own. It counts this
+// replica's workers only, so with several replicas it errs long.
+func (s QueueStats) ETA(ahead int) time.Duration {
+	if s.Workers <= 0 {
+		return s.AvgJobDuration
+	}
+	rounds := ahead/s.Workers + 1
+	return time.Duration(rounds) * s.AvgJobDuration
+}
+
 // ─── RUNNER ───────────────────────────────────────────────────────────────────
 
 // RunnerConfig holds tuning parameters for the Runner. All fields have
@@ -49,6 +111,19 @@ type RunnerConfig struct {
 	// MaxRetries is the number of times a job is retried before the report is
 	// marked as permanently failed. Default: 3.
 	MaxRetries int
+
+	// Settings, when non-nil, overrides PollInterval at runtime. The poller
+	// picks up a changed interval after its next tick.
+	Settings *settings.Watcher
+
+	// ErrorReporter receives job panics and permanently failed jobs. May be
+	// nil.
+	ErrorReporter *errorreport.Reporter
+
+	// InstanceID identifies this replica in report claims and must differ
+	// between replicas sharing a database. Default: the hostname, so a
+	// restarted container takes back its own claims at once.
+	InstanceID string
 }
 
 // DefaultRunnerConfig returns safe production defaults.
@@ -62,25 +137,47 @@ func DefaultRunnerConfig() RunnerConfig {
 }
 
 // Runner manages a pool of worker goroutines. It accepts jobs via an in-process
-// channel (fast path, used for new payments) and also polls the database
-// periodically to pick up any reports that were in-flight when the process last
-// restarted (recovery path).
+// channel (fast path, used for new payments and submitted jobs) and also polls
+// the database periodically to pick up any reports and jobs that were
+// in-flight when the process last restarted (recovery path). What a job does
+// is looked up by its type; see Register.
 type Runner struct {
 	job    *Job
 	store  *store.Store
-	q      db.Querier
+	q      db.RunnerStore
 	cfg    RunnerConfig
 	logger *slog.Logger
 
-	queue chan uuid.UUID
+	queue chan task
 	wg    sync.WaitGroup
+
+	// inFlight holds the reports and jobs this process is running, so one
+	// enqueued again while it runs (a duplicate webhook, the poller) is not
+	// run twice concurrently. Claims only keep other replicas away.
+	mu       sync.Mutex
+	inFlight map[uuid.UUID]bool
+
+	// specs are the registered job types, guarded by mu.
+	specs map[JobType]JobSpec
+
+	// stripeHandler receives Stripe events, guarded by mu. See SetHandler.
+	stripeHandler StripeEventHandler
+
+	// avgJob is a moving average of successful report durations, guarded by
+	// mu.
+	avgJob time.Duration
+
+	// drain is closed by Drain.
+	drain     chan struct{}
+	drainOnce sync.Once
 }
 
-// NewRunner constructs a Runner. Call Start() to begin processing.
+// NewRunner constructs a Runner with the built-in job types registered. Call
+// Start() to begin processing.
 func NewRunner(
 	job *Job,
 	st *store.Store,
-	q db.Querier,
+	q db.RunnerStore,
 	cfg RunnerConfig,
 	logger *slog.Logger,
 ) *Runner {
@@ -96,24 +193,120 @@ func NewRunner(
 	if cfg.MaxRetries <= 0 {
 		cfg.MaxRetries = DefaultRunnerConfig().MaxRetries
 	}
+	if cfg.InstanceID == "" {
+		cfg.InstanceID, _ = os.Hostname()
+	}
+	if cfg.InstanceID == "" {
+		cfg.InstanceID = uuid.NewString()
+	}
 
-	return &Runner{
+	r := &Runner{
 		job:    job,
 		store:  st,
 		q:      q,
 		cfg:    cfg,
 		logger: logger,
 		// Buffer = Workers*2 so Enqueue never blocks under normal load.
-		queue: make(chan uuid.UUID, cfg.Workers*2),
+		queue:    make(chan task, cfg.Workers*2),
+		inFlight: make(map[uuid.UUID]bool),
+		specs:    make(map[JobType]JobSpec),
+		drain:    make(chan struct{}),
+	}
+	r.Register(JobSpec{Type: JobScoreReport, Run: r.scoreReport})
+	r.Register(r.stripeEventSpec())
+	if job != nil {
+		// The pipeline queues its own follow-up work.
+		job.jobs = r
+		r.Register(job.emailRetrySpec())
+	}
+	return r
+}
+
+// reportPayload is the payload of a JobScoreReport task, and of the
+// follow-up email jobs scheduled for a report.
+type reportPayload struct {
+	ReportID uuid.UUID `json:"report_id"`
+}
+
+// scoreReport runs the report pipeline. It is JobScoreReport's Run.
+func (r *Runner) scoreReport(ctx context.Context, payload json.RawMessage) error {
+	var p reportPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("worker: decode report payload: %w", err)
+	}
+	return r.job.Run(ctx, p.ReportID)
+}
+
+// reportTask returns the task that scores a report.
+func reportTask(reportID uuid.UUID) task {
+	payload, _ := json.Marshal(reportPayload{ReportID: reportID})
+	return task{typ: JobScoreReport, id: reportID, payload: payload}
+}
+
+// Drain stops the Runner taking work, for a rolling deploy: the poller stops,
+// workers finish the job they are running and exit, and Enqueue refuses
+// new reports, leaving them to the other replicas' pollers. Start then hands
+// back the claims on queued reports and jobs and returns. It satisfies the
+// Drainer interface. A drained Runner cannot be restarted; the process is
+// meant to exit next.
+func (r *Runner) Drain() {
+	r.drainOnce.Do(func() {
+		close(r.drain)
+		stats := r.QueueStats()
+		r.logger.Info("worker: draining", "running", stats.Running, "queued", stats.Queued)
+	})
+}
+
+// Draining reports whether Drain has been called.
+func (r *Runner) Draining() bool {
+	select {
+	case <-r.drain:
+		return true
+	default:
+		return false
 	}
 }
 
+// QueueStats returns a snapshot of the queue. It satisfies the Backlog
+// interface. Only this replica's queue is counted.
+func (r *Runner) QueueStats() QueueStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	avg := r.avgJob
+	if avg == 0 {
+		avg = defaultJobDuration
+	}
+	return QueueStats{
+		Queued:         len(r.queue),
+		Capacity:       cap(r.queue),
+		Running:        len(r.inFlight),
+		Workers:        r.cfg.Workers,
+		AvgJobDuration: avg,
+	}
+}
+
+// recordDuration folds a successful report's duration into the average,
+// weighting recent reports so the estimate follows a slow provider within a
+// few reports.
+func (r *Runner) recordDuration(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.avgJob == 0 {
+		r.avgJob = d
+		return
+	}
+	r.avgJob = (r.avgJob*4 + d) / 5
+}
+
 // Enqueue pushes a reportID onto the in-process channel. It satisfies the
 // Enqueuer interface. If the channel is full (very unlikely given the buffer
 // sizing) it returns an error rather than blocking the HTTP response.
 func (r *Runner) Enqueue(_ context.Context, reportID uuid.UUID) error {
+	if r.Draining() {
+		return errors.New("worker: draining, report will be picked up by another replica's poller")
+	}
 	select {
-	case r.queue <- reportID:
+	case r.queue <- reportTask(reportID):
 		r.logger.Info("worker: enqueued report", "report_id", reportID)
 		return nil
 	default:
@@ -126,7 +319,7 @@ func (r *Runner) Enqueue(_ context.Context, reportID uuid.UUID) error {
 //
 //	go runner.Start(ctx)
 func (r *Runner) Start(ctx context.Context) {
-	r.logger.Info("worker: starting", "workers", r.cfg.Workers, "poll_interval", r.cfg.PollInterval)
+	r.logger.Info("worker: starting", "workers", r.cfg.Workers, "poll_interval", r.cfg.PollInterval, "job_types", r.tableTypes())
 
 	// Launch worker goroutines.
 	for i := range r.cfg.Workers {
@@ -139,7 +332,18 @@ func (r *Runner) Start(ctx context.Context) {
 	go r.poll(ctx)
 
 	r.wg.Wait()
-	r.logger.Info("worker: stopped")
+
+	// Hand back claims on reports and jobs still queued so another replica
+	// can take them now rather than when the lease runs out.
+	for {
+		select {
+		case t := <-r.queue:
+			r.releaseClaim(t)
+		default:
+			r.logger.Info("worker: stopped")
+			return
+		}
+	}
 }
 
 // work is the inner loop for each worker goroutine.
@@ -149,21 +353,31 @@ func (r *Runner) work(ctx context.Context, id int) {
 	log.Info("worker: goroutine started")
 
 	for {
+		if r.Draining() {
+			log.Info("worker: goroutine drained")
+			return
+		}
 		select {
 		case <-ctx.Done():
 			log.Info("worker: goroutine stopping")
 			return
-		case reportID := <-r.queue:
-			r.runWithRetry(ctx, reportID, log)
+		case <-r.drain:
+			log.Info("worker: goroutine drained")
+			return
+		case t := <-r.queue:
+			r.runWithRetry(ctx, t, log)
 		}
 	}
 }
 
-// poll queries the database on PollInterval for any pending/processing reports
-// that were not delivered via the channel (e.g. reports from before a restart).
+// poll queries the database on PollInterval for any pending reports and jobs
+// that were not delivered via the channel (e.g. ones from before a restart).
+// They are claimed, not just listed, so replicas sharing the database split
+// the backlog instead of all enqueueing the same work.
 func (r *Runner) poll(ctx context.Context) {
 	defer r.wg.Done()
-	ticker := time.NewTicker(r.cfg.PollInterval)
+	interval := r.pollInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Run once immediately on startup to pick up anything from before restart.
@@ -173,66 +387,318 @@ func (r *Runner) poll(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
+		case <-r.drain:
+			return
 		case <-ticker.C:
 			r.pollOnce(ctx)
+			if next := r.pollInterval(); next != interval {
+				r.logger.Info("worker: poll interval changed", "from", interval, "to", next)
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// pollInterval returns the runtime setting when a watcher is configured,
+// falling back to the static RunnerConfig value.
+func (r *Runner) pollInterval() time.Duration {
+	if r.cfg.Settings != nil {
+		if d := r.cfg.Settings.Current().PollInterval; d > 0 {
+			return d
 		}
 	}
+	return r.cfg.PollInterval
 }
 
+// pollOnce claims reports first, then jobs with the room left in the queue.
 func (r *Runner) pollOnce(ctx context.Context) {
-	reports, err := r.q.ListPendingReports(ctx)
+	free := cap(r.queue) - len(r.queue)
+	if free <= 0 || r.Draining() {
+		return
+	}
+	reportSpec, _ := r.spec(JobScoreReport)
+	reports, err := r.q.ClaimPendingReports(ctx, db.ClaimPendingReportsParams{
+		ClaimedBy:    r.cfg.InstanceID,
+		LeaseSeconds: int32(reportSpec.lease().Seconds()),
+		MaxReports:   int32(free),
+	})
 	if err != nil {
 		r.logger.Error("worker: poll failed", "error", err)
 		return
 	}
 	for _, rep := range reports {
-		select {
-		case r.queue <- rep.ID:
-			r.logger.Debug("worker: poller enqueued report", "report_id", rep.ID)
-		default:
-			// Queue full — will be picked up next poll cycle.
+		r.pollerEnqueue(reportTask(rep.ID))
+	}
+
+	free = cap(r.queue) - len(r.queue)
+	if free <= 0 {
+		return
+	}
+	jobs, err := r.q.ClaimPendingJobs(ctx, db.ClaimPendingJobsParams{
+		ClaimedBy:    r.cfg.InstanceID,
+		LeaseSeconds: int32(r.longestLease().Seconds()),
+		Types:        r.tableTypes(),
+		MaxJobs:      int32(free),
+	})
+	if err != nil {
+		r.logger.Error("worker: job poll failed", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		r.pollerEnqueue(task{typ: JobType(job.Type), id: job.ID, payload: job.Payload})
+	}
+}
+
+// pollerEnqueue queues a task the poller claimed.
+func (r *Runner) pollerEnqueue(t task) {
+	select {
+	case r.queue <- t:
+		r.logger.Debug("worker: poller enqueued", "job_type", t.typ, "id", t.id)
+	default:
+		// Queue filled by Enqueue meanwhile — release the claim so the
+		// next poll, here or on another replica, picks it up.
+		r.releaseClaim(t)
+	}
+}
+
+// longestLease is the lease the poller claims a batch of jobs of mixed types
+// with. Each job is claimed again with its own type's lease before it runs.
+func (r *Runner) longestLease() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var longest time.Duration
+	for typ, spec := range r.specs {
+		if typ != JobScoreReport {
+			longest = max(longest, spec.lease())
 		}
 	}
+	return longest
 }
 
-// runWithRetry executes the job up to MaxRetries times. After exhausting
-// retries it calls store.MarkReportFailed so the report is not picked up again.
-func (r *Runner) runWithRetry(ctx context.Context, reportID uuid.UUID, log *slog.Logger) {
-	var lastErr error
+// claim takes the report or job for this replica and marks it in flight. It
+// returns the attempts the job has already used, and false when it is
+// finished, claimed by another replica, or already running here.
+func (r *Runner) claim(ctx context.Context, t task, spec JobSpec, log *slog.Logger) (int, bool) {
+	r.mu.Lock()
+	if r.inFlight[t.id] {
+		r.mu.Unlock()
+		log.DebugContext(ctx, "worker: already running here, skipping")
+		return 0, false
+	}
+	r.inFlight[t.id] = true
+	r.mu.Unlock()
+
+	var attempts int
+	var err error
+	if t.typ == JobScoreReport {
+		_, err = r.q.ClaimReport(ctx, db.ClaimReportParams{
+			ClaimedBy:    r.cfg.InstanceID,
+			LeaseSeconds: int32(spec.lease().Seconds()),
+			ID:           t.id,
+		})
+	} else {
+		var job db.Job
+		job, err = r.q.ClaimJob(ctx, db.ClaimJobParams{
+			ClaimedBy:    r.cfg.InstanceID,
+			LeaseSeconds: int32(spec.lease().Seconds()),
+			ID:           t.id,
+		})
+		attempts = int(job.Attempts)
+	}
+	if err == nil {
+		return attempts, true
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		log.DebugContext(ctx, "worker: finished or claimed by another replica, skipping")
+	} else {
+		log.ErrorContext(ctx, "worker: claim failed, leaving it for the poller", "error", err)
+	}
+	r.done(t.id)
+	return 0, false
+}
+
+// done clears the in-flight mark set by claim.
+func (r *Runner) done(id uuid.UUID) {
+	r.mu.Lock()
+	delete(r.inFlight, id)
+	r.mu.Unlock()
+}
+
+// releaseClaim gives up this replica's claim on a report or job it will not
+// run.
+func (r *Runner) releaseClaim(t task) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var err error
+	if t.typ == JobScoreReport {
+		err = r.q.ReleaseReportClaim(ctx, db.ReleaseReportClaimParams{ID: t.id, ClaimedBy: r.cfg.InstanceID})
+	} else {
+		err = r.q.ReleaseJobClaim(ctx, db.ReleaseJobClaimParams{ID: t.id, ClaimedBy: r.cfg.InstanceID})
+	}
+	if err != nil {
+		r.logger.Warn("worker: release claim failed; it lapses with its lease", "job_type", t.typ, "id", t.id, "error", err)
+	}
+}
 
-	for attempt := 1; attempt <= r.cfg.MaxRetries; attempt++ {
-		jobCtx, cancel := context.WithTimeout(ctx, r.cfg.JobTimeout)
-		lastErr = r.job.Run(jobCtx, reportID)
+// runWithRetry executes a task up to its type's MaxAttempts, counting
+// attempts a job used before a restart. After exhausting them it gives up
+// on it: store.MarkReportFailed for a report, so it is not picked up again,
+// FailJob and the type's Failed hook for a job.
+//
+// Each run gets a trace_id shared by all of its attempts; the job type, the
+// report_id or job_id, attempt and trace_id are attached to the job context
+// with logging.With so every line the job logs can be correlated without
+// explicit .With calls. The trace_id doubles as the request ID forwarded to
+// the store, AI and email calls the job makes (see package requestid).
+func (r *Runner) runWithRetry(ctx context.Context, t task, log *slog.Logger) {
+	traceID := uuid.NewString()
+	if t.typ == JobScoreReport {
+		ctx = logging.With(ctx, "report_id", t.id, "trace_id", traceID)
+	} else {
+		ctx = logging.With(ctx, "job_type", t.typ, "job_id", t.id, "trace_id", traceID)
+	}
+	ctx = requestid.With(ctx, traceID)
+
+	spec, ok := r.spec(t.typ)
+	if !ok {
+		// Only the poller's types are claimed, so this is a job submitted
+		// by a replica running a newer version.
+		log.WarnContext(ctx, "worker: job type not registered here, leaving it")
+		r.releaseClaim(t)
+		return
+	}
+	used, ok := r.claim(ctx, t, spec, log)
+	if !ok {
+		return
+	}
+	defer r.done(t.id)
+
+	var lastErr error
+	start := time.Now()
+	for attempt := used + 1; attempt <= spec.MaxAttempts; attempt++ {
+		attemptCtx := logging.With(ctx, "attempt", attempt)
+		calls := ai.NewCallLog()
+		jobCtx, cancel := context.WithTimeout(ai.WithCallLog(attemptCtx, calls), spec.Timeout)
+		attemptStart := time.Now()
+		lastErr = r.runJob(jobCtx, spec, t)
+		took := time.Since(attemptStart)
 		cancel()
 
 		if lastErr == nil {
-			log.Info("worker: job completed", "report_id", reportID, "attempt", attempt)
+			r.succeeded(attemptCtx, t, time.Since(start), log)
+			return
+		}
+		if errors.Is(lastErr, store.ErrSessionBusy) {
+			// Another job is generating this session's report; it records
+			// the outcome. Give the claim back rather than hold it for the
+			// whole lease, so if that job fails or exits early the next poll
+			// picks the report up.
+			log.InfoContext(attemptCtx, "worker: session locked by another job, skipping")
+			r.releaseClaim(t)
 			return
 		}
 
-		log.Warn("worker: job attempt failed",
-			"report_id", reportID,
-			"attempt", attempt,
-			"max", r.cfg.MaxRetries,
+		log.WarnContext(attemptCtx, "worker: job attempt failed",
+			"max", spec.MaxAttempts,
 			"error", lastErr,
 		)
+		if t.typ == JobScoreReport {
+			r.recordReportAttempt(attemptCtx, t.id, attempt, lastErr, calls, took, log)
+		} else {
+			err := r.q.RecordJobAttempt(ctx, db.RecordJobAttemptParams{
+				ID:    t.id,
+				Error: sql.NullString{String: lastErr.Error(), Valid: true},
+			})
+			if err != nil {
+				log.ErrorContext(attemptCtx, "worker: could not record job attempt", "error", err)
+			}
+		}
 
-		if attempt < r.cfg.MaxRetries {
-			// Exponential back-off: 2s, 4s, 8s …
-			backoff := time.Duration(1<<attempt) * time.Second
+		if attempt < spec.MaxAttempts {
+			// Exponential back-off: 2s, 4s, 8s … by default.
 			select {
 			case <-ctx.Done():
+				r.releaseClaim(t)
 				return
-			case <-time.After(backoff):
+			case <-time.After(spec.backoff(attempt)):
 			}
 		}
 	}
 
-	// All retries exhausted — mark the report permanently failed.
-	log.Error("worker: job permanently failed", "report_id", reportID, "error", lastErr)
+	// Shutting down mid-attempt is not the job's fault.
+	if ctx.Err() != nil {
+		r.releaseClaim(t)
+		return
+	}
+	if lastErr == nil {
+		// Claimed after a restart with no attempts left.
+		lastErr = errors.New("worker: no attempts left")
+	}
+
+	// All attempts exhausted — give up on it.
+	log.ErrorContext(ctx, "worker: job permanently failed", "error", lastErr)
+	r.cfg.ErrorReporter.CaptureError(ctx, lastErr, t.tags())
 	failCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	if _, err := r.store.MarkReportFailed(failCtx, reportID, lastErr.Error()); err != nil {
-		log.Error("worker: failed to mark report as failed", "report_id", reportID, "error", err)
+	if t.typ == JobScoreReport {
+		if _, err := r.store.MarkReportFailed(failCtx, t.id, lastErr.Error()); err != nil {
+			log.ErrorContext(ctx, "worker: failed to mark report as failed", "error", err)
+		}
+		return
+	}
+	if err := r.q.FailJob(failCtx, t.id); err != nil {
+		log.ErrorContext(ctx, "worker: failed to mark job as failed", "error", err)
+	}
+	if spec.Failed != nil {
+		spec.Failed(failCtx, t.payload, lastErr)
+	}
+}
+
+// recordReportAttempt stores a failed attempt at a report in report_attempts,
+// with the AI provider it was calling, for looking at failures in bulk.
+func (r *Runner) recordReportAttempt(ctx context.Context, reportID uuid.UUID, attempt int, attemptErr error, calls *ai.CallLog, took time.Duration, log *slog.Logger) {
+	failedProvider, failure := calls.LastFailure()
+	provider := calls.Provider()
+	if failure != "" {
+		provider = failedProvider
+	}
+	// The attempt's context may have timed out; the record must still land.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	err := r.q.InsertReportAttempt(ctx, db.InsertReportAttemptParams{
+		ReportID:      reportID,
+		Attempt:       int32(attempt),
+		Error:         attemptErr.Error(),
+		Provider:      sql.NullString{String: provider, Valid: provider != ""},
+		ProviderError: sql.NullString{String: failure, Valid: failure != ""},
+		DurationMs:    int32(took.Milliseconds()),
+		WorkerID:      r.cfg.InstanceID,
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "worker: could not record report attempt", "error", err)
+	}
+}
+
+// succeeded records a finished task.
+func (r *Runner) succeeded(ctx context.Context, t task, took time.Duration, log *slog.Logger) {
+	if t.typ == JobScoreReport {
+		r.recordDuration(took)
+	} else if err := r.q.CompleteJob(ctx, t.id); err != nil {
+		// The job ran; at worst it runs again once its claim lapses.
+		log.ErrorContext(ctx, "worker: could not mark job complete", "error", err)
 	}
+	log.InfoContext(ctx, "worker: job completed", "took", took.Round(time.Millisecond))
+}
+
+// runJob runs one attempt, turning a panic into an error so a bug in one
+// job fails that attempt instead of the whole process.
+func (r *Runner) runJob(ctx context.Context, spec JobSpec, t task) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.cfg.ErrorReporter.CapturePanic(ctx, rec, t.tags())
+			err = fmt.Errorf("worker: job panicked: %v", rec)
+		}
+	}()
+	return spec.Run(ctx, t.payload)
 }
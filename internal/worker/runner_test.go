@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/ai"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/store"
+)
+
+func TestQueueStats_BusyOnceAFullRoundIsQueued(t *testing.T) {
+	r := NewRunner(nil, nil, nil, RunnerConfig{Workers: 2}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	stats := r.QueueStats()
+	if stats.Busy() || stats.Capacity != 4 || stats.AvgJobDuration != defaultJobDuration {
+		t.Fatalf("idle runner: got %+v", stats)
+	}
+
+	for range 3 {
+		if err := r.Enqueue(context.Background(), uuid.New()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.inFlight[uuid.New()] = true
+	r.recordDuration(2 * time.Minute)
+
+	stats = r.QueueStats()
+	if !stats.Busy() {
+		t.Fatalf("expected busy with %d queued for %d workers", stats.Queued, stats.Workers)
+	}
+	// Four reports ahead across two workers is two rounds, plus its own.
+	if got, want := stats.Wait(), 6*time.Minute; got != want {
+		t.Errorf("wait: got %v, want %v", got, want)
+	}
+
+	r.recordDuration(7 * time.Minute)
+	if got, want := r.QueueStats().AvgJobDuration, 3*time.Minute; got != want {
+		t.Errorf("average: got %v, want %v", got, want)
+	}
+}
+
+func TestDrain_StopsWorkersAndRefusesReports(t *testing.T) {
+	r := NewRunner(nil, nil, nil, RunnerConfig{Workers: 2, PollInterval: time.Hour}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r.Drain()
+	r.Drain() // idempotent
+	if !r.Draining() {
+		t.Fatal("expected draining after Drain")
+	}
+	if err := r.Enqueue(context.Background(), uuid.New()); err == nil {
+		t.Fatal("expected Enqueue to refuse a report while draining")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.Start(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after Drain")
+	}
+}
+
+// reportAttemptsQuerier records report attempts, failing if the context it
+// is given is already done.
+type reportAttemptsQuerier struct {
+	db.RunnerStore // embedded to panic on unimplemented methods
+	attempts       []db.InsertReportAttemptParams
+}
+
+func (q *reportAttemptsQuerier) InsertReportAttempt(ctx context.Context, arg db.InsertReportAttemptParams) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.attempts = append(q.attempts, arg)
+	return nil
+}
+
+func TestRecordReportAttempt_OutlivesTheAttemptTimeout(t *testing.T) {
+	q := &reportAttemptsQuerier{}
+	r := NewRunner(nil, nil, q, RunnerConfig{Workers: 1, InstanceID: "worker-a"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reportID := uuid.New()
+	r.recordReportAttempt(ctx, reportID, 2, errors.New("job: persist report: timeout"), ai.NewCallLog(), 1500*time.Millisecond, r.logger)
+
+	if len(q.attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(q.attempts))
+	}
+	got := q.attempts[0]
+	if got.ReportID != reportID || got.Attempt != 2 || got.Error != "job: persist report: timeout" || got.DurationMs != 1500 || got.WorkerID != "worker-a" {
+		t.Errorf("recorded %+v", got)
+	}
+	if got.Provider.Valid || got.ProviderError.Valid {
+		t.Errorf("provider = %v, provider error = %v; want both NULL when no provider was called", got.Provider, got.ProviderError)
+	}
+}
+
+// reportClaimsQuerier keeps each report's claim holder.
+type reportClaimsQuerier struct {
+	db.RunnerStore // embedded to panic on unimplemented methods
+	claimedBy      map[uuid.UUID]string
+}
+
+func (q *reportClaimsQuerier) ClaimReport(_ context.Context, arg db.ClaimReportParams) (db.Report, error) {
+	if holder := q.claimedBy[arg.ID]; holder != "" && holder != arg.ClaimedBy {
+		return db.Report{}, sql.ErrNoRows
+	}
+	q.claimedBy[arg.ID] = arg.ClaimedBy
+	return db.Report{ID: arg.ID}, nil
+}
+
+func (q *reportClaimsQuerier) ReleaseReportClaim(_ context.Context, arg db.ReleaseReportClaimParams) error {
+	if q.claimedBy[arg.ID] == arg.ClaimedBy {
+		delete(q.claimedBy, arg.ID)
+	}
+	return nil
+}
+
+func TestRunWithRetry_ReleasesTheClaimWhenTheSessionIsBusy(t *testing.T) {
+	q := &reportClaimsQuerier{claimedBy: make(map[uuid.UUID]string)}
+	r := NewRunner(nil, nil, q, RunnerConfig{Workers: 1, InstanceID: "worker-a"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	runs := 0
+	spec, _ := r.spec(JobScoreReport)
+	spec.Run = func(context.Context, json.RawMessage) error {
+		runs++
+		return fmt.Errorf("job: lock session: %w", store.ErrSessionBusy)
+	}
+	r.specs[JobScoreReport] = spec
+
+	reportID := uuid.New()
+	r.runWithRetry(context.Background(), reportTask(reportID), r.logger)
+
+	if runs != 1 {
+		t.Errorf("runs = %d, want 1: a busy session is not retried", runs)
+	}
+	if holder, ok := q.claimedBy[reportID]; ok {
+		t.Errorf("report still claimed by %q after a busy-lock attempt", holder)
+	}
+	if r.inFlight[reportID] {
+		t.Error("report still marked in flight")
+	}
+}
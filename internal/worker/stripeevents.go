@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// ─── STRIPE EVENTS ────────────────────────────────────────────────────────────
+//
+// The Stripe webhook stores each verified event and answers 200 at once;
+// dispatching it — initialising the report, sending the receipt, mirroring a
+// subscription — is a process_stripe_event job. A slow email provider or a
+// contended row then never makes Stripe time out and redeliver an event whose
+// side effects are still running. A failed event is retried with back-off up
+// to five times; after that it waits for the admin replay endpoint.
+
+// StripeEventHandler runs the side effects of a stored, verified Stripe
+// event. The api package implements it: the handlers live with the webhook.
+type StripeEventHandler interface {
+	HandleStripeEvent(ctx context.Context, event db.StripeEvent) error
+}
+
+// StripeEventQueue is the narrow interface the api package hands stored
+// Stripe events to. The concrete implementation is *Runner.
+type StripeEventQueue interface {
+	// SetHandler sets the handler events are dispatched to.
+	SetHandler(h StripeEventHandler)
+	// EnqueueStripeEvent queues a stored event for processing. An error
+	// means it was not queued and the caller must dispatch it itself.
+	EnqueueStripeEvent(ctx context.Context, eventID string) error
+}
+
+// stripeEventPayload is the payload of a JobProcessStripeEvent job.
+type stripeEventPayload struct {
+	EventID string `json:"event_id"`
+}
+
+// SetHandler sets the handler Stripe events are dispatched to. It satisfies
+// the StripeEventQueue interface.
+func (r *Runner) SetHandler(h StripeEventHandler) {
+	r.mu.Lock()
+	r.stripeHandler = h
+	r.mu.Unlock()
+}
+
+// EnqueueStripeEvent submits a process_stripe_event job for a stored event.
+// It satisfies the StripeEventQueue interface.
+func (r *Runner) EnqueueStripeEvent(ctx context.Context, eventID string) error {
+	return r.Submit(ctx, JobProcessStripeEvent, stripeEventPayload{EventID: eventID}, "stripe_event:"+eventID)
+}
+
+func (r *Runner) stripeEventSpec() JobSpec {
+	return JobSpec{
+		Type:        JobProcessStripeEvent,
+		Run:         r.processStripeEvent,
+		MaxAttempts: 5,
+		Timeout:     time.Minute,
+		Backoff:     15 * time.Second,
+	}
+}
+
+// processStripeEvent dispatches a stored event and records the outcome on it,
+// as the webhook does when it dispatches an event itself.
+func (r *Runner) processStripeEvent(ctx context.Context, payload json.RawMessage) error {
+	var p stripeEventPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("worker: decode stripe event payload: %w", err)
+	}
+	r.mu.Lock()
+	h := r.stripeHandler
+	r.mu.Unlock()
+	if h == nil {
+		return errors.New("worker: no stripe event handler set")
+	}
+
+	event, err := r.q.GetStripeEvent(ctx, p.EventID)
+	if err != nil {
+		return fmt.Errorf("worker: get stripe event %s: %w", p.EventID, err)
+	}
+	if event.Processed {
+		// Replayed by an admin since it was queued.
+		r.logger.DebugContext(ctx, "worker: stripe event already processed", "event_id", event.StripeEventID)
+		return nil
+	}
+
+	if err := h.HandleStripeEvent(ctx, event); err != nil {
+		_, _ = r.q.MarkStripeEventFailed(ctx, db.MarkStripeEventFailedParams{
+			StripeEventID: event.StripeEventID,
+			Error:         sql.NullString{String: err.Error(), Valid: true},
+		})
+		return err
+	}
+	if _, err := r.q.MarkStripeEventProcessed(ctx, event.StripeEventID); err != nil {
+		return fmt.Errorf("worker: mark stripe event %s processed: %w", event.StripeEventID, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/internal/db"
+)
+
+// stripeEventQuerier serves stored events by ID and records their outcomes.
+type stripeEventQuerier struct {
+	*jobsQuerier
+	events    map[string]db.StripeEvent
+	processed []string
+	failed    []db.MarkStripeEventFailedParams
+}
+
+func newStripeEventQuerier(events ...db.StripeEvent) *stripeEventQuerier {
+	q := &stripeEventQuerier{jobsQuerier: newJobsQuerier(), events: make(map[string]db.StripeEvent)}
+	for _, ev := range events {
+		q.events[ev.StripeEventID] = ev
+	}
+	return q
+}
+
+func (q *stripeEventQuerier) GetStripeEvent(_ context.Context, id string) (db.StripeEvent, error) {
+	ev, ok := q.events[id]
+	if !ok {
+		return db.StripeEvent{}, sql.ErrNoRows
+	}
+	return ev, nil
+}
+
+func (q *stripeEventQuerier) MarkStripeEventProcessed(_ context.Context, id string) (db.StripeEvent, error) {
+	q.processed = append(q.processed, id)
+	ev := q.events[id]
+	ev.Processed = true
+	q.events[id] = ev
+	return ev, nil
+}
+
+func (q *stripeEventQuerier) MarkStripeEventFailed(_ context.Context, arg db.MarkStripeEventFailedParams) (db.StripeEvent, error) {
+	q.failed = append(q.failed, arg)
+	return db.StripeEvent{}, nil
+}
+
+// stripeEventRecorder records the events it handles and fails with err.
+type stripeEventRecorder struct {
+	handled []string
+	err     error
+}
+
+func (h *stripeEventRecorder) HandleStripeEvent(_ context.Context, ev db.StripeEvent) error {
+	h.handled = append(h.handled, ev.StripeEventID)
+	return h.err
+}
+
+func TestEnqueueStripeEvent_DispatchesAndMarksProcessed(t *testing.T) {
+	q := newStripeEventQuerier(db.StripeEvent{StripeEventID: "evt_1", Type: "payment_intent.succeeded"})
+	r := newTestRunner(q)
+	h := &stripeEventRecorder{}
+	r.SetHandler(h)
+
+	if err := r.EnqueueStripeEvent(context.Background(), "evt_1"); err != nil {
+		t.Fatal(err)
+	}
+	// Queued once however often the webhook hands it over.
+	if err := r.EnqueueStripeEvent(context.Background(), "evt_1"); err != nil {
+		t.Fatal(err)
+	}
+	runQueued(r)
+
+	if len(h.handled) != 1 || h.handled[0] != "evt_1" {
+		t.Errorf("handled = %v, want [evt_1]", h.handled)
+	}
+	if len(q.processed) != 1 || len(q.failed) != 0 || len(q.completed) != 1 {
+		t.Errorf("processed = %v, failed = %v, jobs completed = %d; want one processed and completed", q.processed, q.failed, len(q.completed))
+	}
+}
+
+func TestEnqueueStripeEvent_RecordsEachFailure(t *testing.T) {
+	q := newStripeEventQuerier(db.StripeEvent{StripeEventID: "evt_1"})
+	r := newTestRunner(q)
+	h := &stripeEventRecorder{err: errors.New("mailer down")}
+	r.SetHandler(h)
+
+	if err := r.EnqueueStripeEvent(context.Background(), "evt_1"); err != nil {
+		t.Fatal(err)
+	}
+	// One attempt left, so the test does not wait out the back-off.
+	for id, job := range q.jobs {
+		job.Attempts = 4
+		q.jobs[id] = job
+	}
+	runQueued(r)
+
+	if len(q.processed) != 0 {
+		t.Errorf("processed = %v, want none", q.processed)
+	}
+	if len(q.failed) != 1 || q.failed[0].Error.String != "mailer down" {
+		t.Fatalf("event failures = %+v, want the handler's error", q.failed)
+	}
+	if len(q.jobsQuerier.failed) != 1 {
+		t.Errorf("jobs failed = %d, want 1 after the last attempt", len(q.jobsQuerier.failed))
+	}
+}
+
+func TestEnqueueStripeEvent_SkipsEventsReplayedMeanwhile(t *testing.T) {
+	q := newStripeEventQuerier(db.StripeEvent{StripeEventID: "evt_1", Processed: true})
+	r := newTestRunner(q)
+	h := &stripeEventRecorder{}
+	r.SetHandler(h)
+
+	if err := r.EnqueueStripeEvent(context.Background(), "evt_1"); err != nil {
+		t.Fatal(err)
+	}
+	runQueued(r)
+
+	if len(h.handled) != 0 || len(q.completed) != 1 {
+		t.Errorf("handled = %v, jobs completed = %d; want the job completed without dispatch", h.handled, len(q.completed))
+	}
+}
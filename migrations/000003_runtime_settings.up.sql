@@ -0,0 +1,11 @@
+-- Operator-tunable values that can change without a restart. Read by the
+-- settings watcher in internal/settings; unknown keys are ignored.
+CREATE TABLE runtime_settings (
+    key             TEXT        PRIMARY KEY,    -- e.g. "poll_interval"
+    value           TEXT        NOT NULL,       -- parsed per key, e.g. "30s"
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER trg_runtime_settings_updated_at
+    BEFORE UPDATE ON runtime_settings
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
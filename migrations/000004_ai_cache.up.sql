@@ -0,0 +1,12 @@
+-- Cached AI hedge generations keyed by a fingerprint of the scored risks and
+-- business context (see ai.Fingerprint). Identical answer patterns reuse a
+-- prior generation instead of paying for a new one.
+CREATE TABLE ai_cache (
+    fingerprint         TEXT        PRIMARY KEY,    -- hex SHA-256
+    hedges              JSONB       NOT NULL,       -- question_id → narrative
+    executive_summary   TEXT        NOT NULL,
+    top_priority_html   TEXT        NOT NULL,
+    hit_count           INT         NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_hit_at         TIMESTAMPTZ
+);
@@ -0,0 +1,3 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS product_sku;
+DROP TABLE IF EXISTS products;
+DROP TYPE IF EXISTS report_type;
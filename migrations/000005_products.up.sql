@@ -0,0 +1,36 @@
+-- Product catalog. Checkout charges the selected product's price, and the
+-- product's report_type controls how detailed the AI narratives are.
+CREATE TYPE report_type AS ENUM ('standard', 'premium');
+
+CREATE TABLE products (
+    sku             TEXT        PRIMARY KEY,    -- e.g. "standard"
+    name            TEXT        NOT NULL,
+    price_cents     INT         NOT NULL CHECK (price_cents >= 50),  -- Stripe minimum
+    currency        TEXT        NOT NULL DEFAULT 'usd',
+    report_type     report_type NOT NULL DEFAULT 'standard',
+    active          BOOLEAN     NOT NULL DEFAULT true,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER trg_products_updated_at
+    BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- The base report keeps its existing price; an operator-set price_cents
+-- runtime setting is carried over so the charge does not change on deploy.
+INSERT INTO products (sku, name, price_cents, report_type)
+VALUES (
+    'standard',
+    'Asymmetric Risk Report',
+    COALESCE((SELECT value::int FROM runtime_settings WHERE key = 'price_cents'), 5900),
+    'standard'
+);
+INSERT INTO products (sku, name, price_cents, report_type)
+VALUES ('premium', 'Premium Deep-Dive Report', 14900, 'premium');
+
+DELETE FROM runtime_settings WHERE key = 'price_cents';
+
+-- The product bought at checkout. NULL for sessions created before the
+-- catalog existed; those are treated as 'standard'.
+ALTER TABLE sessions ADD COLUMN product_sku TEXT REFERENCES products (sku);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS consultation_requests;
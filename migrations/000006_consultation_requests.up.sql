@@ -0,0 +1,11 @@
+-- Customers who asked for a paid consultation after reading their report.
+-- One row per report; repeat requests update the note.
+CREATE TABLE consultation_requests (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id       UUID        NOT NULL UNIQUE REFERENCES reports (id),
+    session_id      UUID        NOT NULL REFERENCES sessions (id),
+    note            TEXT,                       -- optional free text from the customer
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_consultation_requests_created_at ON consultation_requests (created_at);
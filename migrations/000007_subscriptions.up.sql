@@ -0,0 +1,26 @@
+-- Quarterly re-assessment subscriptions, mirrored from Stripe webhooks.
+-- Each billing period entitles the subscriber to one report paid for by the
+-- subscription instead of a PaymentIntent.
+CREATE TABLE subscriptions (
+    id                      UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    stripe_subscription_id  TEXT        NOT NULL UNIQUE,
+    stripe_customer_id      TEXT        NOT NULL,
+    email                   CITEXT,                 -- from invoice.paid; null until the first invoice
+    status                  TEXT        NOT NULL,   -- Stripe subscription status, e.g. active, past_due, canceled
+    current_period_start    TIMESTAMPTZ,
+    current_period_end      TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_subscriptions_email       ON subscriptions (email);
+CREATE INDEX idx_subscriptions_customer_id ON subscriptions (stripe_customer_id);
+
+CREATE TRIGGER trg_subscriptions_updated_at
+    BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Set on sessions paid for by a subscription rather than a PaymentIntent.
+ALTER TABLE sessions ADD COLUMN subscription_id UUID REFERENCES subscriptions (id);
+
+CREATE INDEX idx_sessions_subscription_id ON sessions (subscription_id);
@@ -0,0 +1,5 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS tax_calculation_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS tax_cents;
+ALTER TABLE sessions DROP COLUMN IF EXISTS subtotal_cents;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_postal_code;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_country;
@@ -0,0 +1,8 @@
+-- Stripe Tax: the billing location collected at checkout and what was
+-- charged on top of the product price. Null on sessions checked out before
+-- tax was recorded, and on sessions covered by a subscription.
+ALTER TABLE sessions ADD COLUMN billing_country     TEXT;   -- ISO 3166-1 alpha-2, e.g. "DE"
+ALTER TABLE sessions ADD COLUMN billing_postal_code TEXT;
+ALTER TABLE sessions ADD COLUMN subtotal_cents      INT;    -- product price before tax
+ALTER TABLE sessions ADD COLUMN tax_cents           INT;    -- 0 when Stripe Tax is disabled
+ALTER TABLE sessions ADD COLUMN tax_calculation_id  TEXT;   -- Stripe tax calculation, recorded as a tax transaction on payment
@@ -0,0 +1,7 @@
+DROP SEQUENCE IF EXISTS invoice_number_seq;
+ALTER TABLE sessions DROP COLUMN IF EXISTS invoice_number;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_tax_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_city;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_address_line2;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_address_line1;
+ALTER TABLE sessions DROP COLUMN IF EXISTS billing_name;
@@ -0,0 +1,10 @@
+-- Billing details collected at checkout for the downloadable invoice, and the
+-- invoice number, assigned from a sequence the first time it is downloaded.
+ALTER TABLE sessions ADD COLUMN billing_name          TEXT;   -- legal name on the invoice; biz_name when null
+ALTER TABLE sessions ADD COLUMN billing_address_line1 TEXT;
+ALTER TABLE sessions ADD COLUMN billing_address_line2 TEXT;
+ALTER TABLE sessions ADD COLUMN billing_city          TEXT;
+ALTER TABLE sessions ADD COLUMN billing_tax_id        TEXT;   -- customer VAT/GST number, printed as given
+ALTER TABLE sessions ADD COLUMN invoice_number        BIGINT UNIQUE;
+
+CREATE SEQUENCE invoice_number_seq START 1000;
@@ -0,0 +1,22 @@
+-- Stripe's fee and the net amount for each successful charge, read from the
+-- charge's balance transaction. Amounts are in the settlement currency.
+CREATE TABLE payments (
+    id                            UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    stripe_charge_id              TEXT        NOT NULL UNIQUE,
+    stripe_payment_intent         TEXT,                   -- null for charges not made through a PaymentIntent
+    stripe_balance_transaction_id TEXT        NOT NULL,
+    session_id                    UUID        REFERENCES sessions (id),  -- null for subscription invoices
+    amount_cents                  BIGINT      NOT NULL,   -- gross, before fees
+    fee_cents                     BIGINT      NOT NULL,
+    net_cents                     BIGINT      NOT NULL,   -- amount_cents - fee_cents
+    currency                      TEXT        NOT NULL,   -- settlement currency
+    created_at                    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at                    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payments_payment_intent ON payments (stripe_payment_intent);
+CREATE INDEX idx_payments_created_at     ON payments (created_at);
+
+CREATE TRIGGER trg_payments_updated_at
+    BEFORE UPDATE ON payments
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,2 @@
+-- Indexes behind the checkout velocity checks (internal/fraud).
+CREATE INDEX idx_sessions_ip_hash_created_at ON sessions (ip_hash, created_at);
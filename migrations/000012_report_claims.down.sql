@@ -0,0 +1,2 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS claim_expires_at;
+ALTER TABLE reports DROP COLUMN IF EXISTS claimed_by;
@@ -0,0 +1,4 @@
+-- Worker claims on reports, so several API replicas can poll for pending
+-- reports without all generating the same one.
+ALTER TABLE reports ADD COLUMN claimed_by       TEXT;
+ALTER TABLE reports ADD COLUMN claim_expires_at TIMESTAMPTZ;
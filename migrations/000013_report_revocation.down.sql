@@ -0,0 +1,2 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS revoked_reason;
+ALTER TABLE reports DROP COLUMN IF EXISTS revoked_at;
@@ -0,0 +1,3 @@
+-- Soft deletion of reports: a revoked report's access token stops working.
+ALTER TABLE reports ADD COLUMN revoked_at     TIMESTAMPTZ;
+ALTER TABLE reports ADD COLUMN revoked_reason TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_email_log_created_at;
+DROP INDEX IF EXISTS idx_stripe_events_received_at;
+DROP INDEX IF EXISTS idx_sessions_updated_at;
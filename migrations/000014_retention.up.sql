@@ -0,0 +1,4 @@
+-- Indexes for the age scans of the retention enforcer (internal/retention).
+CREATE INDEX idx_sessions_updated_at       ON sessions (updated_at);
+CREATE INDEX idx_stripe_events_received_at ON stripe_events (received_at);
+CREATE INDEX idx_email_log_created_at      ON email_log (created_at);
@@ -0,0 +1,6 @@
+-- Decrypt first (`armctl reencrypt -decrypt -apply`): encrypted values are
+-- unreadable to the old code.
+DROP INDEX IF EXISTS idx_subscriptions_email_hash;
+DROP INDEX IF EXISTS idx_sessions_email_hash;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS email_hash;
+ALTER TABLE sessions      DROP COLUMN IF EXISTS email_hash;
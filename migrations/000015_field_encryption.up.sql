@@ -0,0 +1,21 @@
+-- Application-level encryption of email addresses and Stripe payloads
+-- (internal/fieldcrypt). Lookups by email move to email_hash.
+ALTER TABLE sessions      ADD COLUMN email_hash TEXT;
+ALTER TABLE subscriptions ADD COLUMN email_hash TEXT;
+
+-- The unkeyed index the API computes while FIELD_INDEX_KEY is unset. With a
+-- key configured, `armctl reencrypt -apply` rewrites these. The triggers are
+-- paused so the backfill does not reset updated_at, which retention reads.
+ALTER TABLE sessions      DISABLE TRIGGER trg_sessions_updated_at;
+ALTER TABLE subscriptions DISABLE TRIGGER trg_subscriptions_updated_at;
+UPDATE sessions
+SET email_hash = encode(sha256(convert_to(lower(btrim(email::text)), 'UTF8')), 'hex')
+WHERE email IS NOT NULL;
+UPDATE subscriptions
+SET email_hash = encode(sha256(convert_to(lower(btrim(email::text)), 'UTF8')), 'hex')
+WHERE email IS NOT NULL;
+ALTER TABLE sessions      ENABLE TRIGGER trg_sessions_updated_at;
+ALTER TABLE subscriptions ENABLE TRIGGER trg_subscriptions_updated_at;
+
+CREATE INDEX idx_sessions_email_hash      ON sessions (email_hash);
+CREATE INDEX idx_subscriptions_email_hash ON subscriptions (email_hash);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_email_log_unopened;
+DROP INDEX IF EXISTS idx_email_log_provider_id;
+
+ALTER TABLE email_log DROP COLUMN IF EXISTS resent_at;
+ALTER TABLE email_log DROP COLUMN IF EXISTS bounced_at;
+ALTER TABLE email_log DROP COLUMN IF EXISTS clicked_at;
@@ -0,0 +1,9 @@
+-- Open/click/bounce tracking from Resend webhooks, and the one-off resend of
+-- unopened report-ready emails.
+ALTER TABLE email_log ADD COLUMN clicked_at TIMESTAMPTZ;
+ALTER TABLE email_log ADD COLUMN bounced_at TIMESTAMPTZ;
+ALTER TABLE email_log ADD COLUMN resent_at  TIMESTAMPTZ;
+
+CREATE INDEX idx_email_log_provider_id ON email_log (provider_id);
+CREATE INDEX idx_email_log_unopened    ON email_log (sent_at)
+    WHERE template = 'report_ready' AND opened_at IS NULL AND resent_at IS NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS duplicate_purchases;
+
+DROP INDEX IF EXISTS idx_sessions_credit_from_session_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS credit_from_session_id;
+ALTER TABLE reports  DROP COLUMN IF EXISTS held_at;
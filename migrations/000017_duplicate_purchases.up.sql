@@ -0,0 +1,20 @@
+-- Duplicate purchase detection: held reports, the duplicates awaiting a
+-- decision, and sessions paid for with a duplicate kept as credit.
+ALTER TABLE reports  ADD COLUMN held_at TIMESTAMPTZ;
+ALTER TABLE sessions ADD COLUMN credit_from_session_id UUID REFERENCES sessions (id);
+
+-- One redemption per credit.
+CREATE UNIQUE INDEX idx_sessions_credit_from_session_id ON sessions (credit_from_session_id);
+
+CREATE TABLE duplicate_purchases (
+    session_id          UUID        PRIMARY KEY REFERENCES sessions (id) ON DELETE CASCADE,
+    original_session_id UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    changed_answers     INT         NOT NULL,
+    resolution          TEXT        CHECK (resolution IN ('refunded', 'credit', 'released')),
+    stripe_refund_id    TEXT,
+    resolved_at         TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_duplicate_purchases_unresolved ON duplicate_purchases (created_at)
+    WHERE resolution IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_email_log_dedupe_key;
+
+ALTER TABLE email_log DROP COLUMN IF EXISTS duplicate_of;
+ALTER TABLE email_log DROP COLUMN IF EXISTS dedupe_key;
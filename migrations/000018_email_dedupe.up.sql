@@ -0,0 +1,6 @@
+-- Emails sent once per report are claimed by dedupe_key before sending;
+-- skipped duplicates point at the claiming row.
+ALTER TABLE email_log ADD COLUMN dedupe_key   TEXT;
+ALTER TABLE email_log ADD COLUMN duplicate_of UUID REFERENCES email_log (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_email_log_dedupe_key ON email_log (dedupe_key) WHERE dedupe_key IS NOT NULL;
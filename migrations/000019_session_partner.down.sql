@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sessions_partner;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS partner;
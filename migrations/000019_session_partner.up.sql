@@ -0,0 +1,4 @@
+-- The partner whose embedded widget a session was started in.
+ALTER TABLE sessions ADD COLUMN partner TEXT;
+
+CREATE INDEX idx_sessions_partner ON sessions (partner) WHERE partner IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Operator-written industry playbook snippets, added to the AI prompt for
+-- sessions in that industry.
+CREATE TABLE playbook_snippets (
+    slug            TEXT        PRIMARY KEY,    -- e.g. "retail-pci"
+    industry        TEXT        NOT NULL,       -- matched case-insensitively
+    title           TEXT        NOT NULL,
+    body            TEXT        NOT NULL,
+    keywords        TEXT[]      NOT NULL DEFAULT '{}',
+    active          BOOLEAN     NOT NULL DEFAULT true,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_playbook_snippets_industry ON playbook_snippets (lower(industry)) WHERE active;
+
+CREATE TRIGGER trg_playbook_snippets_updated_at
+    BEFORE UPDATE ON playbook_snippets
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,3 @@
+ALTER TABLE ai_cache DROP COLUMN IF EXISTS analysis;
+ALTER TABLE reports  DROP COLUMN IF EXISTS ai_narrative;
+ALTER TABLE reports  DROP COLUMN IF EXISTS ai_analysis;
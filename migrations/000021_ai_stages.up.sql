@@ -0,0 +1,5 @@
+-- The output of each AI generation stage, for debugging and for regenerating
+-- a report's narrative from its stored analysis.
+ALTER TABLE reports  ADD COLUMN ai_analysis  JSONB;
+ALTER TABLE reports  ADD COLUMN ai_narrative JSONB;
+ALTER TABLE ai_cache ADD COLUMN analysis     JSONB;
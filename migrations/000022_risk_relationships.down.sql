@@ -0,0 +1,2 @@
+ALTER TABLE ai_cache DROP COLUMN IF EXISTS relationships;
+ALTER TABLE reports  DROP COLUMN IF EXISTS relationships;
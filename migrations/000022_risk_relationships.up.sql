@@ -0,0 +1,3 @@
+-- How a report's risks feed each other, written by the AI with the hedges.
+ALTER TABLE reports  ADD COLUMN relationships JSONB;
+ALTER TABLE ai_cache ADD COLUMN relationships JSONB;
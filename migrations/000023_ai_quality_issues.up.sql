@@ -0,0 +1,2 @@
+-- Why AI output was rejected for a report, recorded by the quality gate.
+ALTER TABLE reports ADD COLUMN ai_quality_issues TEXT;
@@ -0,0 +1,2 @@
+-- What the per-report AI token budget cut, if anything.
+ALTER TABLE reports ADD COLUMN ai_budget_note TEXT;
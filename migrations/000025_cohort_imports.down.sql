@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sessions_cohort;
+
+ALTER TABLE reports  DROP COLUMN IF EXISTS not_before;
+ALTER TABLE sessions DROP COLUMN IF EXISTS cohort;
@@ -0,0 +1,6 @@
+-- Admin bulk imports: the cohort label on imported sessions, and the time
+-- before which the poller leaves an imported report alone.
+ALTER TABLE sessions ADD COLUMN cohort     TEXT;
+ALTER TABLE reports  ADD COLUMN not_before TIMESTAMPTZ;
+
+CREATE INDEX idx_sessions_cohort ON sessions (cohort) WHERE cohort IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Feature flags for soft launches, and the flag state each report was
+-- generated under.
+CREATE TABLE feature_flags (
+    name            TEXT        NOT NULL,
+    environment     TEXT        NOT NULL DEFAULT '',
+    percent         SMALLINT    NOT NULL CHECK (percent BETWEEN 0 AND 100),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (name, environment)
+);
+
+CREATE TRIGGER trg_feature_flags_updated_at
+    BEFORE UPDATE ON feature_flags
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+ALTER TABLE reports ADD COLUMN flags JSONB;
@@ -0,0 +1,10 @@
+-- A/B experiment assignments, one row per session and running experiment.
+CREATE TABLE experiment_assignments (
+    session_id      UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    experiment      TEXT        NOT NULL,
+    variant         TEXT        NOT NULL,
+    assigned_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (session_id, experiment)
+);
+
+CREATE INDEX idx_experiment_assignments_experiment ON experiment_assignments (experiment, variant);
@@ -0,0 +1,13 @@
+-- Raw AI requests and responses per report generation attempt.
+CREATE TABLE ai_transcripts (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id       UUID        NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    exchanges       INT         NOT NULL,
+    storage_key     TEXT,
+    body            JSONB,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((storage_key IS NULL) <> (body IS NULL))
+);
+
+CREATE INDEX idx_ai_transcripts_report_id ON ai_transcripts (report_id, created_at);
+CREATE INDEX idx_ai_transcripts_created_at ON ai_transcripts (created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE reports      DROP COLUMN IF EXISTS executive_summary_edited_at;
+ALTER TABLE risk_results DROP COLUMN IF EXISTS ai_hedge_edited_at;
+DROP TABLE IF EXISTS ai_edits;
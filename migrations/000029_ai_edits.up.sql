@@ -0,0 +1,18 @@
+-- Admin corrections to AI hedges and executive summaries, with provenance.
+CREATE TABLE ai_edits (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id       UUID        NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    field           TEXT        NOT NULL CHECK (field IN ('ai_hedge', 'executive_summary')),
+    question_id     TEXT,
+    original        TEXT,
+    edited          TEXT        NOT NULL,
+    editor          TEXT        NOT NULL,
+    reason          TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((field = 'ai_hedge') = (question_id IS NOT NULL))
+);
+
+CREATE INDEX idx_ai_edits_report_id ON ai_edits (report_id, created_at);
+
+ALTER TABLE risk_results ADD COLUMN ai_hedge_edited_at         TIMESTAMPTZ;
+ALTER TABLE reports      ADD COLUMN executive_summary_edited_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS email_suppressions;
+DROP TABLE IF EXISTS feedback;
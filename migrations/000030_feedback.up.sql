@@ -0,0 +1,20 @@
+-- Rating/testimonial requests sent after delivery, and suppressed addresses.
+CREATE TABLE feedback (
+    report_id       UUID        PRIMARY KEY REFERENCES reports (id) ON DELETE CASCADE,
+    token           TEXT        NOT NULL UNIQUE DEFAULT encode(gen_random_bytes(24), 'base64url'),
+    requested_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rating          SMALLINT    CHECK (rating BETWEEN 0 AND 10),
+    testimonial     TEXT,
+    consent_quote   BOOLEAN     NOT NULL DEFAULT FALSE,
+    consent_name    BOOLEAN     NOT NULL DEFAULT FALSE,
+    responded_at    TIMESTAMPTZ,
+    approved_at     TIMESTAMPTZ
+);
+
+CREATE INDEX idx_feedback_responded_at ON feedback (responded_at) WHERE responded_at IS NOT NULL;
+
+CREATE TABLE email_suppressions (
+    email_hash      TEXT        PRIMARY KEY,
+    reason          TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,15 @@
+-- Overall scores of new reports under a candidate score profile, for
+-- comparison with production before rollout.
+CREATE TABLE shadow_scores (
+    report_id           UUID        NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    profile             TEXT        NOT NULL,
+    production_profile  TEXT        NOT NULL,
+    production_score    SMALLINT    NOT NULL,
+    shadow_score        SMALLINT    NOT NULL,
+    production_band     TEXT        NOT NULL,
+    shadow_band         TEXT        NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (report_id, profile)
+);
+
+CREATE INDEX idx_shadow_scores_created_at ON shadow_scores (created_at);
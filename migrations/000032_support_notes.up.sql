@@ -0,0 +1,12 @@
+-- Notes support staff keep on a session or its report, e.g. a resend request
+-- or a refund reason.
+CREATE TABLE support_notes (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id      UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    report_id       UUID        REFERENCES reports (id) ON DELETE CASCADE,
+    author          TEXT        NOT NULL,
+    body            TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_support_notes_session_id ON support_notes (session_id, created_at);
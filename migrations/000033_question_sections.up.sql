@@ -0,0 +1,21 @@
+-- Sections of the questionnaire: title, description, order and gating.
+-- Titles start out as the ones the questions already carry.
+CREATE TABLE question_sections (
+    id              section_id  PRIMARY KEY,
+    title           TEXT        NOT NULL,
+    description     TEXT        NOT NULL DEFAULT '',
+    display_order   SMALLINT    NOT NULL,
+    gated           BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO question_sections (id, title, display_order)
+SELECT s.id,
+       COALESCE((SELECT min(q.section_title) FROM question_definitions q WHERE q.section_id = s.id),
+                initcap(s.id::text)),
+       s.ord::smallint
+FROM unnest(enum_range(NULL::section_id)) WITH ORDINALITY AS s(id, ord);
+
+CREATE TRIGGER trg_question_sections_updated_at
+    BEFORE UPDATE ON question_sections
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,2 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS skipped_questions;
+ALTER TABLE answers DROP COLUMN IF EXISTS skipped;
@@ -0,0 +1,4 @@
+-- Questions a customer marked as skipped (not applicable), which scoring
+-- leaves out instead of scoring (1,1), and the report's list of them.
+ALTER TABLE answers ADD COLUMN skipped           BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reports ADD COLUMN skipped_questions TEXT[];
@@ -0,0 +1 @@
+ALTER TABLE risk_results DROP COLUMN IF EXISTS explanation;
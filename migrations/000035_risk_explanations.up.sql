@@ -0,0 +1,2 @@
+-- Why each risk scored as it did, captured at scoring time.
+ALTER TABLE risk_results ADD COLUMN explanation JSONB;
@@ -0,0 +1,20 @@
+-- How each risk tier is presented in reports: label, colour, description
+-- and review cadence.
+CREATE TABLE tier_themes (
+    tier            risk_tier   PRIMARY KEY,
+    label           TEXT        NOT NULL,
+    color           TEXT        NOT NULL CHECK (color ~ '^#[0-9a-f]{6}$'),
+    description     TEXT        NOT NULL,
+    cadence         TEXT        NOT NULL DEFAULT '',   -- e.g. "Review weekly"
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO tier_themes (tier, label, color, description, cadence) VALUES
+    ('watch',  'Watch',  '#b91c1c', 'Likely and severe: already on fire, slowly. Act on these first.',        'Review weekly'),
+    ('red',    'Red',    '#ea580c', 'Unlikely but existential if it happens. Hedge now, while it is cheap.', 'Review monthly'),
+    ('manage', 'Manage', '#ca8a04', 'Likely but survivable. Handle operationally.',                           'Review quarterly'),
+    ('ignore', 'Ignore', '#64748b', 'Unlikely and survivable. Not worth attention yet.',                      'Review yearly');
+
+CREATE TRIGGER trg_tier_themes_updated_at
+    BEFORE UPDATE ON tier_themes
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
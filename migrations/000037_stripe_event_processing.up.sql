@@ -0,0 +1,3 @@
+-- Stripe events are dispatched by the worker after the webhook has answered,
+-- as jobs (000038), which carry the claims and attempts. Nothing changes in
+-- stripe_events; this version is kept so the numbering stays unbroken.
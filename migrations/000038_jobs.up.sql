@@ -0,0 +1,24 @@
+-- The worker's durable queue for background work other than scoring a
+-- report, Stripe events among it.
+CREATE TABLE jobs (
+    id               UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    type             TEXT        NOT NULL,
+    payload          JSONB       NOT NULL DEFAULT '{}',
+    dedupe_key       TEXT,
+    status           TEXT        NOT NULL DEFAULT 'pending'
+                                 CHECK (status IN ('pending', 'done', 'failed')),
+    attempts         INT         NOT NULL DEFAULT 0,
+    error            TEXT,
+    claimed_by       TEXT,
+    claim_expires_at TIMESTAMPTZ,
+    finished_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_jobs_dedupe_key ON jobs (dedupe_key);
+CREATE INDEX idx_jobs_pending ON jobs (created_at) WHERE status = 'pending';
+
+CREATE TRIGGER trg_jobs_updated_at
+    BEFORE UPDATE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX idx_jobs_pending ON jobs (created_at) WHERE status = 'pending';
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS run_at;
@@ -0,0 +1,5 @@
+-- Jobs can be queued to run later: a job is not claimed before run_at.
+ALTER TABLE jobs ADD COLUMN run_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+DROP INDEX IF EXISTS idx_jobs_pending;
+CREATE INDEX idx_jobs_pending ON jobs (run_at) WHERE status = 'pending';
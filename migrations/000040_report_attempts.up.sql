@@ -0,0 +1,17 @@
+-- Every failed attempt at scoring a report, with the AI provider it was
+-- calling, so failures can be looked at in bulk rather than one
+-- error_message at a time.
+CREATE TABLE report_attempts (
+    id             UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id      UUID        NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    attempt        INT         NOT NULL,
+    error          TEXT        NOT NULL,
+    provider       TEXT,
+    provider_error TEXT,
+    duration_ms    INT         NOT NULL,
+    worker_id      TEXT        NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_report_attempts_report_id ON report_attempts (report_id, created_at);
+CREATE INDEX idx_report_attempts_created_at ON report_attempts (created_at);
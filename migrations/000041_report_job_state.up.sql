@@ -0,0 +1,3 @@
+-- The worker's checkpoint for a report it has not finished, so a retry does
+-- not call the AI provider again.
+ALTER TABLE reports ADD COLUMN job_state JSONB;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_email_log_created_at_id;
+DROP INDEX IF EXISTS idx_sessions_created_at_id;
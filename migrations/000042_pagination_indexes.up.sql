@@ -0,0 +1,4 @@
+-- Keyset indexes for the admin listings, which page newest first on
+-- (created_at, id).
+CREATE INDEX idx_sessions_created_at_id  ON sessions (created_at, id);
+CREATE INDEX idx_email_log_created_at_id ON email_log (created_at, id);
@@ -0,0 +1,384 @@
+// Package client is a Go client for the Asymmetric Risk Mapper HTTP API,
+// covering the customer flow: create a session, save answers, check out and
+// fetch the report. It is the supported way for integrators and end-to-end
+// tests to call the API; the full contract is served at /api/openapi.json.
+// It calls version 1 of the API (/api/v1), so a later version does not
+// change what it gets back.
+//
+//	c := client.New(client.Config{BaseURL: "https://api.asymmetricrisk.com"})
+//	sess, err := c.CreateSession(ctx, client.CreateSessionRequest{BizName: "Acme"})
+//	_, err = c.UpsertAnswers(ctx, sess, []client.Answer{{QuestionID: "s2_supplier", AnswerText: "One"}})
+//	checkout, err := c.CreateCheckout(ctx, sess, client.CheckoutRequest{Email: "a@example.com", BillingCountry: "ZA"})
+//
+// Requests that fail with a network error, 429 or a 502/503/504 are retried
+// with exponential backoff. Every call here is safe to repeat: answers are
+// upserted, checkout reuses the session's PaymentIntent, and a retried
+// CreateSession at worst leaves an unused anonymous session behind.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config configures a Client. Only BaseURL is required.
+type Config struct {
+	// BaseURL is the API origin, e.g. "https://api.asymmetricrisk.com".
+	BaseURL string
+
+	// HTTPClient defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+
+	// MaxRetries is the number of retries after the first attempt. Zero means
+	// the default of 3; use a negative value to disable retries.
+	MaxRetries int
+
+	// Backoff is the wait before the first retry, doubled for each one after.
+	// Defaults to 500ms. A Retry-After header, when present, takes precedence.
+	Backoff time.Duration
+}
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// New returns a Client for cfg.
+func New(cfg Config) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		httpClient: cfg.HTTPClient,
+		maxRetries: cfg.MaxRetries,
+		backoff:    cfg.Backoff,
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	switch {
+	case c.maxRetries == 0:
+		c.maxRetries = 3
+	case c.maxRetries < 0:
+		c.maxRetries = 0
+	}
+	if c.backoff <= 0 {
+		c.backoff = 500 * time.Millisecond
+	}
+	return c
+}
+
+// ─── ERRORS ───────────────────────────────────────────────────────────────────
+
+// APIError is returned for any response outside 2xx, after retries.
+type APIError struct {
+	StatusCode int
+	// Message is the "error" field of the response body, or the raw body when
+	// it is not the API's JSON error envelope.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// ─── TYPES ────────────────────────────────────────────────────────────────────
+
+// CreateSessionRequest carries the optional context collected before the
+// questionnaire. CaptchaToken is required when the API has captcha enabled.
+type CreateSessionRequest struct {
+	BizName      string `json:"biz_name,omitempty"`
+	Industry     string `json:"industry,omitempty"`
+	Stage        string `json:"stage,omitempty"`
+	Email        string `json:"email,omitempty"`
+	CaptchaToken string `json:"captcha_token,omitempty"`
+	EmbedToken   string `json:"embed_token,omitempty"`
+}
+
+// Session identifies an anonymous assessment. AnonToken authorises every
+// session-scoped call; keep it secret.
+type Session struct {
+	SessionID             string `json:"session_id"`
+	AnonToken             string `json:"anon_token"`
+	ReassessmentAvailable bool   `json:"reassessment_available,omitempty"`
+	Partner               string `json:"partner,omitempty"`
+}
+
+// Answer is one question's answer. For radio and select questions AnswerText
+// is the chosen option's label.
+type Answer struct {
+	QuestionID string `json:"question_id"`
+	AnswerText string `json:"answer_text"`
+}
+
+// Question is one questionnaire question. Options is set for radio questions;
+// answer those with an option's Label.
+type Question struct {
+	ID           string   `json:"id"`
+	SectionID    string   `json:"section_id"`
+	SectionTitle string   `json:"section_title"`
+	DisplayOrder int      `json:"display_order"`
+	Text         string   `json:"text"`
+	Type         string   `json:"type"`
+	Options      []Option `json:"options,omitempty"`
+	Required     bool     `json:"required"`
+	IsScoring    bool     `json:"is_scoring"`
+	SavedAnswer  string   `json:"saved_answer"`
+}
+
+// Option is one choice of a radio question with its preview scores.
+type Option struct {
+	Label  string `json:"label"`
+	PScore int    `json:"p_score"`
+	IScore int    `json:"i_score"`
+}
+
+// CheckoutRequest starts payment for the session's report. SKU defaults to
+// "standard" on the server. BillingCountry is an ISO 3166-1 alpha-2 code.
+type CheckoutRequest struct {
+	Email               string `json:"email"`
+	SKU                 string `json:"sku,omitempty"`
+	BillingCountry      string `json:"billing_country,omitempty"`
+	BillingPostalCode   string `json:"billing_postal_code,omitempty"`
+	BillingName         string `json:"billing_name,omitempty"`
+	BillingAddressLine1 string `json:"billing_address_line1,omitempty"`
+	BillingAddressLine2 string `json:"billing_address_line2,omitempty"`
+	BillingCity         string `json:"billing_city,omitempty"`
+	BillingTaxID        string `json:"billing_tax_id,omitempty"`
+}
+
+// Checkout is the PaymentIntent to confirm with Stripe.js. When
+// CoveredBySubscription or CoveredByCredit is true there is nothing to pay
+// and ClientSecret is empty.
+type Checkout struct {
+	ClientSecret          string `json:"client_secret"`
+	IsExisting            bool   `json:"is_existing,omitempty"`
+	SubtotalCents         int64  `json:"subtotal_cents,omitempty"`
+	TaxCents              int64  `json:"tax_cents,omitempty"`
+	AmountCents           int64  `json:"amount_cents,omitempty"`
+	Currency              string `json:"currency,omitempty"`
+	CoveredBySubscription bool   `json:"covered_by_subscription,omitempty"`
+	CoveredByCredit       bool   `json:"covered_by_credit,omitempty"`
+}
+
+// Report is a paid report. Until it has been generated only Status and the
+// queue estimates are set; check Ready before reading the rest.
+type Report struct {
+	ReportID         string         `json:"report_id"`
+	Status           string         `json:"status"`
+	BizName          string         `json:"biz_name,omitempty"`
+	Industry         string         `json:"industry,omitempty"`
+	Stage            string         `json:"stage,omitempty"`
+	OverallScore     int            `json:"overall_score"`
+	CriticalCount    int            `json:"critical_count"`
+	ExecutiveSummary string         `json:"executive_summary,omitempty"`
+	TopPriorityHTML  string         `json:"top_priority_html,omitempty"`
+	Risks            []Risk         `json:"risks"`
+	Relationships    []Relationship `json:"relationships"`
+	GeneratedAt      string         `json:"generated_at,omitempty"`
+	ConsultationURL  string         `json:"consultation_url,omitempty"`
+
+	// Set while the report is being generated: its place in the queue, 1
+	// being next, and the estimated seconds until it is ready, or minutes
+	// when the service is backed up.
+	QueuePosition  int `json:"queue_position,omitempty"`
+	ETASeconds     int `json:"eta_seconds,omitempty"`
+	ReadyInMinutes int `json:"ready_in_minutes,omitempty"`
+}
+
+// Ready reports whether the report has been generated.
+func (r *Report) Ready() bool { return r.Status == "ready" }
+
+// Risk is one ranked risk in a report.
+type Risk struct {
+	Rank        int    `json:"rank"`
+	QuestionID  string `json:"question_id"`
+	RiskName    string `json:"risk_name"`
+	RiskDesc    string `json:"risk_desc"`
+	Probability int    `json:"probability"`
+	Impact      int    `json:"impact"`
+	Score       int    `json:"score"`
+	Tier        string `json:"tier"`
+	Section     string `json:"section"`
+	Hedge       string `json:"hedge"`
+}
+
+// Relationship is a link between two of a report's risks: the "from" risk
+// makes the "to" risk more likely or more damaging.
+type Relationship struct {
+	FromQuestionID string `json:"from_question_id"`
+	FromRiskName   string `json:"from_risk_name"`
+	ToQuestionID   string `json:"to_question_id"`
+	ToRiskName     string `json:"to_risk_name"`
+	Description    string `json:"description"`
+}
+
+// ─── CALLS ────────────────────────────────────────────────────────────────────
+
+// CreateSession starts an anonymous assessment.
+func (c *Client) CreateSession(ctx context.Context, req CreateSessionRequest) (Session, error) {
+	var sess Session
+	err := c.do(ctx, http.MethodPost, "/api/v1/session", "", req, &sess)
+	return sess, err
+}
+
+// GetQuestions returns the questionnaire in display order, with the
+// session's saved answers filled in.
+func (c *Client) GetQuestions(ctx context.Context, sess Session) ([]Question, error) {
+	var resp struct {
+		Questions []Question `json:"questions"`
+	}
+	err := c.do(ctx, http.MethodGet, sessionPath(sess, "questions"), sess.AnonToken, nil, &resp)
+	return resp.Questions, err
+}
+
+// UpsertAnswers saves answers for the session and returns how many were
+// stored. Sending an answer again overwrites it.
+func (c *Client) UpsertAnswers(ctx context.Context, sess Session, answers []Answer) (int, error) {
+	var resp struct {
+		Upserted int `json:"upserted"`
+	}
+	body := struct {
+		Answers []Answer `json:"answers"`
+	}{answers}
+	err := c.do(ctx, http.MethodPut, sessionPath(sess, "answers"), sess.AnonToken, body, &resp)
+	return resp.Upserted, err
+}
+
+// CreateCheckout creates, or returns the existing, PaymentIntent for the
+// session's report.
+func (c *Client) CreateCheckout(ctx context.Context, sess Session, req CheckoutRequest) (Checkout, error) {
+	var checkout Checkout
+	err := c.do(ctx, http.MethodPost, sessionPath(sess, "checkout"), sess.AnonToken, req, &checkout)
+	return checkout, err
+}
+
+// GetReport fetches the report for an access token from the report email. A
+// report still being generated is returned with Ready() false and no error;
+// an unknown token is an APIError for which IsNotFound is true.
+func (c *Client) GetReport(ctx context.Context, accessToken string) (*Report, error) {
+	var report Report
+	if err := c.do(ctx, http.MethodGet, "/api/v1/report/"+url.PathEscape(accessToken), "", nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func sessionPath(sess Session, resource string) string {
+	return "/api/v1/session/" + url.PathEscape(sess.SessionID) + "/" + resource
+}
+
+// ─── TRANSPORT ────────────────────────────────────────────────────────────────
+
+// do sends the request, retrying transient failures, and decodes a 2xx body
+// into out.
+func (c *Client) do(ctx context.Context, method, path, anonToken string, in, out any) error {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("api: encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		status, retryAfter, err := c.attempt(ctx, method, path, anonToken, payload, out)
+		if err == nil || attempt >= c.maxRetries || !retryable(status, err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := c.backoff << attempt
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path, anonToken string, payload []byte, out any) (int, time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, 0, fmt.Errorf("api: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if anonToken != "" {
+		req.Header.Set("X-Anon-Token", anonToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("api: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return resp.StatusCode, 0, fmt.Errorf("api: read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := strings.TrimSpace(string(raw))
+		var envelope struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(raw, &envelope) == nil && envelope.Error != "" {
+			msg = envelope.Error
+		}
+		return resp.StatusCode, retryAfter(resp.Header), &APIError{StatusCode: resp.StatusCode, Message: msg}
+	}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return resp.StatusCode, 0, fmt.Errorf("api: decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, 0, nil
+}
+
+// retryable reports whether a failed attempt may succeed if repeated: network
+// errors and the statuses a proxy or a busy server returns.
+func retryable(status int, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return status == 0
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
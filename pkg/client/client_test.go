@@ -0,0 +1,118 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nyashahama/asymmetric-risk-mapper-backend/pkg/client"
+)
+
+func newClient(srv *httptest.Server) *client.Client {
+	return client.New(client.Config{BaseURL: srv.URL + "/", Backoff: time.Millisecond})
+}
+
+func TestUpsertAnswers_RetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method != http.MethodPut || r.URL.Path != "/api/v1/session/s1/answers" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("X-Anon-Token"); got != "tok" {
+			t.Errorf("expected X-Anon-Token tok, got %q", got)
+		}
+		var body struct {
+			Answers []client.Answer `json:"answers"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Answers) != 1 {
+			t.Errorf("unexpected body %+v (%v)", body, err)
+		}
+		w.Write([]byte(`{"upserted": 1}`))
+	}))
+	defer srv.Close()
+
+	n, err := newClient(srv).UpsertAnswers(context.Background(), client.Session{SessionID: "s1", AnonToken: "tok"},
+		[]client.Answer{{QuestionID: "q1", AnswerText: "Yes"}})
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 upserted, got %d, %v", n, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestCreateCheckout_ClientErrorIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "billing_country is required"}`))
+	}))
+	defer srv.Close()
+
+	_, err := newClient(srv).CreateCheckout(context.Background(), client.Session{SessionID: "s1", AnonToken: "tok"},
+		client.CheckoutRequest{Email: "a@example.com"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "billing_country is required" {
+		t.Fatalf("expected a 400 APIError, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected one attempt, got %d", calls.Load())
+	}
+}
+
+func TestGetReport_PendingAndNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/report/pending":
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"status": "processing", "message": "report is being generated"}`))
+		case "/api/v1/report/ready":
+			w.Write([]byte(`{"report_id": "r1", "status": "ready", "overall_score": 42, "risks": [{"rank": 1, "tier": "watch"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "report not found"}`))
+		}
+	}))
+	defer srv.Close()
+	c := newClient(srv)
+
+	report, err := c.GetReport(context.Background(), "pending")
+	if err != nil || report.Ready() || report.Status != "processing" {
+		t.Errorf("expected a pending report, got %+v, %v", report, err)
+	}
+	report, err = c.GetReport(context.Background(), "ready")
+	if err != nil || !report.Ready() || report.OverallScore != 42 || len(report.Risks) != 1 {
+		t.Errorf("expected a ready report, got %+v, %v", report, err)
+	}
+	if _, err := c.GetReport(context.Background(), "missing"); !client.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestCreateSession_StopsRetryingWhenContextEnds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := newClient(srv).CreateSession(ctx, client.CreateSessionRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to end the retries, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected Retry-After to be cut short by the context")
+	}
+}
@@ -8,8 +8,15 @@
 -- ---------------------------------------------------------------------------
 
 -- name: CreateSession :one
-INSERT INTO sessions (anon_token, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent)
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO sessions (anon_token, utm_source, utm_medium, utm_campaign, referrer, ip_hash, user_agent, partner)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: CreateCohortSession :one
+-- A session from an admin bulk import: created paid, with its email and
+-- context, for the cohort. Its answers and report are written after it.
+INSERT INTO sessions (anon_token, email, email_hash, biz_name, industry, stage, product_sku, cohort, payment_status, paid_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'paid', now())
 RETURNING *;
 
 -- name: GetSessionByAnonToken :one
@@ -21,6 +28,33 @@ SELECT * FROM sessions WHERE id = $1 LIMIT 1;
 -- name: GetSessionByStripePI :one
 SELECT * FROM sessions WHERE stripe_payment_intent = $1 LIMIT 1;
 
+-- name: CountSessionsByIPHashSince :one
+-- Velocity check at checkout: sessions started from the same (hashed) IP.
+SELECT COUNT(*) FROM sessions
+WHERE ip_hash = sqlc.arg(ip_hash) AND created_at >= sqlc.arg(since)::timestamptz;
+
+-- name: CountFailedPaymentsByEmailSince :one
+-- Sessions whose payment failed for this email, as a card-testing signal.
+-- The store's codec replaces email with its blind index.
+SELECT COUNT(*) FROM sessions
+WHERE email_hash = sqlc.arg(email) AND payment_status = 'failed' AND updated_at >= sqlc.arg(since)::timestamptz;
+
+-- name: ListSessionsByStripePIs :many
+SELECT * FROM sessions WHERE stripe_payment_intent = ANY(sqlc.arg(payment_intents)::text[]);
+
+-- name: ListSessionsPage :many
+-- Sessions newest first, for the admin listing; ties on created_at are broken
+-- by id so every session lands on exactly one page. An empty payment_status
+-- matches every status. With has_cursor, continues after the session at
+-- (cursor_at, cursor_id). Leaves out the email so no ciphertext is read.
+SELECT id, biz_name, industry, stage, payment_status, paid_at, partner, cohort, created_at
+FROM sessions
+WHERE (sqlc.arg(payment_status)::text = '' OR payment_status::text = sqlc.arg(payment_status)::text)
+  AND (NOT sqlc.arg(has_cursor)::boolean
+       OR (created_at, id) < (sqlc.arg(cursor_at)::timestamptz, sqlc.arg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
 -- name: UpdateSessionContext :one
 UPDATE sessions
 SET biz_name = $2,
@@ -33,10 +67,30 @@ RETURNING *;
 UPDATE sessions
 SET stripe_customer_id    = $2,
     stripe_payment_intent = $3,
-    email                 = $4
+    email                 = $4,
+    product_sku           = $5,
+    billing_country       = $6,
+    billing_postal_code   = $7,
+    subtotal_cents        = $8,
+    tax_cents             = $9,
+    tax_calculation_id    = $10,
+    billing_name          = $11,
+    billing_address_line1 = $12,
+    billing_address_line2 = $13,
+    billing_city          = $14,
+    billing_tax_id        = $15,
+    email_hash            = $16
 WHERE id = $1
 RETURNING *;
 
+-- name: AssignInvoiceNumber :one
+-- Returns the session's invoice number, drawing the next one from the
+-- sequence on first use so re-downloads print the same number.
+UPDATE sessions
+SET invoice_number = COALESCE(invoice_number, nextval('invoice_number_seq'))
+WHERE id = $1
+RETURNING invoice_number::bigint;
+
 -- name: MarkSessionPaid :one
 UPDATE sessions
 SET payment_status = 'paid',
@@ -55,13 +109,14 @@ RETURNING *;
 -- ---------------------------------------------------------------------------
 
 -- name: UpsertAnswer :one
-INSERT INTO answers (session_id, question_id, answer_text, client_p, client_i)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO answers (session_id, question_id, answer_text, client_p, client_i, skipped)
+VALUES ($1, $2, $3, $4, $5, $6)
 ON CONFLICT (session_id, question_id)
 DO UPDATE SET
     answer_text = EXCLUDED.answer_text,
     client_p    = EXCLUDED.client_p,
     client_i    = EXCLUDED.client_i,
+    skipped     = EXCLUDED.skipped,
     updated_at  = now()
 RETURNING *;
 
@@ -73,7 +128,7 @@ WHERE a.session_id = $1
 ORDER BY qd.display_order;
 
 -- name: CountAnsweredBySession :one
-SELECT COUNT(*) FROM answers WHERE session_id = $1 AND answer_text != '';
+SELECT COUNT(*) FROM answers WHERE session_id = $1 AND (answer_text != '' OR skipped);
 
 -- ---------------------------------------------------------------------------
 -- QUESTION DEFINITIONS
@@ -91,6 +146,40 @@ ORDER BY section_id, display_order;
 -- name: GetQuestionByID :one
 SELECT * FROM question_definitions WHERE id = $1 LIMIT 1;
 
+-- name: UpsertQuestionDefinition :one
+-- Used by the question seed loader (internal/seed). created_at is kept on
+-- update.
+INSERT INTO question_definitions (
+    id, question_version, section_id, section_title, display_order,
+    text, subtext, type, opts, placeholder, required,
+    risk_name, risk_desc, hedge, scoring_config, is_scoring
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+ON CONFLICT (id) DO UPDATE SET
+    question_version = EXCLUDED.question_version,
+    section_id       = EXCLUDED.section_id,
+    section_title    = EXCLUDED.section_title,
+    display_order    = EXCLUDED.display_order,
+    text             = EXCLUDED.text,
+    subtext          = EXCLUDED.subtext,
+    type             = EXCLUDED.type,
+    opts             = EXCLUDED.opts,
+    placeholder      = EXCLUDED.placeholder,
+    required         = EXCLUDED.required,
+    risk_name        = EXCLUDED.risk_name,
+    risk_desc        = EXCLUDED.risk_desc,
+    hedge            = EXCLUDED.hedge,
+    scoring_config   = EXCLUDED.scoring_config,
+    is_scoring       = EXCLUDED.is_scoring
+RETURNING *;
+
+-- name: ParkQuestionDisplayOrders :exec
+-- Moves questions to unused negative display orders so a reordering can be
+-- written row by row without tripping idx_qdef_section_order.
+UPDATE question_definitions
+SET display_order = -1 - display_order
+WHERE id = ANY(sqlc.arg(ids)::text[]);
+
 -- ---------------------------------------------------------------------------
 -- REPORTS
 -- ---------------------------------------------------------------------------
@@ -100,6 +189,13 @@ INSERT INTO reports (session_id)
 VALUES ($1)
 RETURNING *;
 
+-- name: CreateScheduledReport :one
+-- A draft report the poller leaves alone until not_before; see
+-- GetCohortScheduleEnd.
+INSERT INTO reports (session_id, not_before)
+VALUES ($1, $2)
+RETURNING *;
+
 -- name: GetReportBySessionID :one
 SELECT * FROM reports WHERE session_id = $1 LIMIT 1;
 
@@ -110,6 +206,62 @@ JOIN sessions s ON s.id = r.session_id
 WHERE r.access_token = $1
 LIMIT 1;
 
+-- name: RotateReportAccessToken :one
+-- Gives a report a new access token, so links holding the old one stop
+-- working. Matching the old token makes concurrent rotations issue one new
+-- token; the loser gets no row.
+UPDATE reports
+SET access_token = encode(gen_random_bytes(24), 'base64url')
+WHERE id = $1
+  AND access_token = $2
+RETURNING access_token;
+
+-- name: ListDeliverableReportsByEmail :many
+-- Ready, unrevoked reports of paid sessions for this email, newest first, for
+-- POST /api/report/resend. The store's codec replaces email with its blind
+-- index.
+SELECT r.id, r.session_id, r.access_token, s.biz_name, s.email
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+WHERE s.email_hash = sqlc.arg(email)::text
+  AND s.payment_status = 'paid'
+  AND r.status = 'ready'
+  AND r.revoked_at IS NULL
+ORDER BY r.generated_at DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: GetInvoiceByAccessToken :one
+-- Everything printed on the invoice for a report. product_name and currency
+-- come from the catalog; sessions that predate it are the standard product.
+SELECT
+    r.id                        AS report_id,
+    s.id                        AS session_id,
+    s.payment_status,
+    s.paid_at,
+    s.subscription_id,
+    s.credit_from_session_id,
+    s.stripe_payment_intent,
+    s.email,
+    s.biz_name,
+    s.billing_name,
+    s.billing_address_line1,
+    s.billing_address_line2,
+    s.billing_city,
+    s.billing_postal_code,
+    s.billing_country,
+    s.billing_tax_id,
+    s.invoice_number,
+    r.revoked_at,
+    COALESCE(s.subtotal_cents, p.price_cents)::int AS subtotal_cents,
+    COALESCE(s.tax_cents, 0)::int                  AS tax_cents,
+    p.name                      AS product_name,
+    p.currency
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+JOIN products p ON p.sku = COALESCE(s.product_sku, 'standard')
+WHERE r.access_token = $1
+LIMIT 1;
+
 -- name: GetReportByID :one
 SELECT * FROM reports WHERE id = $1 LIMIT 1;
 
@@ -127,10 +279,34 @@ SET status          = 'ready',
     risks_json      = $4,
     executive_summary = $5,
     top_priority_html = $6,
+    ai_analysis     = $7,
+    ai_narrative    = $8,
+    relationships   = $9,
+    ai_quality_issues = $10,
+    ai_budget_note  = $11,
+    flags           = $12,
+    skipped_questions = $13,
+    executive_summary_edited_at = NULL,
+    job_state       = NULL,
     generated_at    = now()
 WHERE id = $1
 RETURNING *;
 
+-- name: SaveReportJobState :exec
+-- Checkpoints the worker's progress on a report; see reports.job_state.
+UPDATE reports
+SET job_state = $2
+WHERE id = $1;
+
+-- name: TryLockSession :one
+-- Takes the session's advisory lock on this connection without waiting, and
+-- returns whether it was taken. Held until UnlockSession or the connection
+-- closes; see store.LockSession.
+SELECT pg_try_advisory_lock(hashtextextended('session:' || (sqlc.arg(session_id)::uuid)::text, 0))::boolean AS locked;
+
+-- name: UnlockSession :one
+SELECT pg_advisory_unlock(hashtextextended('session:' || (sqlc.arg(session_id)::uuid)::text, 0))::boolean AS unlocked;
+
 -- name: SetReportError :one
 UPDATE reports
 SET status        = 'error',
@@ -139,12 +315,117 @@ WHERE id = $1
 RETURNING *;
 
 -- name: ListPendingReports :many
--- Used by the background worker to pick up unprocessed reports.
+-- Used by the background worker to pick up unprocessed reports. The window is
+-- on updated_at so a report requeued by an operator is picked up again however
+-- old it is, and on not_before so a cohort scheduled far ahead is not dropped.
+-- Imported reports wait for their not_before.
 SELECT * FROM reports
 WHERE status IN ('draft', 'processing')
-  AND created_at > now() - INTERVAL '1 day'
+  AND GREATEST(updated_at, not_before) > now() - INTERVAL '1 day'
+  AND (not_before IS NULL OR not_before <= now())
+  AND revoked_at IS NULL
+  AND held_at IS NULL
 ORDER BY created_at;
 
+-- name: ClaimPendingReports :many
+-- The poller's version of ListPendingReports for several replicas: marks up to
+-- max_reports unclaimed pending reports as owned by claimed_by until the lease
+-- expires and returns them. SKIP LOCKED lets concurrent pollers take disjoint
+-- batches instead of queueing behind each other for the same rows.
+UPDATE reports
+SET claimed_by       = sqlc.arg(claimed_by)::text,
+    claim_expires_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT id FROM reports
+    WHERE status IN ('draft', 'processing')
+      AND GREATEST(updated_at, not_before) > now() - INTERVAL '1 day'
+      AND (not_before IS NULL OR not_before <= now())
+      AND revoked_at IS NULL
+      AND held_at IS NULL
+      AND (claim_expires_at IS NULL OR claim_expires_at < now())
+    ORDER BY created_at
+    LIMIT sqlc.arg(max_reports)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimReport :one
+-- Claims one pending report for claimed_by. Returns no rows when the report is
+-- finished, revoked or held, or another worker holds an unexpired claim on it.
+UPDATE reports
+SET claimed_by       = sqlc.arg(claimed_by)::text,
+    claim_expires_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id = sqlc.arg(id)
+  AND status IN ('draft', 'processing')
+  AND revoked_at IS NULL
+  AND held_at IS NULL
+  AND (claim_expires_at IS NULL OR claim_expires_at < now() OR claimed_by = sqlc.arg(claimed_by)::text)
+RETURNING *;
+
+-- name: ReleaseReportClaim :exec
+-- Gives up claimed_by's claim so another worker can take the report at once.
+UPDATE reports
+SET claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = sqlc.arg(id) AND claimed_by = sqlc.arg(claimed_by)::text;
+
+-- name: CountReportsAhead :one
+-- Pending reports paid for before this one, by the same filter as
+-- ListPendingReports: its position in the queue for GET /api/report.
+SELECT COUNT(*) FROM reports
+WHERE status IN ('draft', 'processing')
+  AND GREATEST(updated_at, not_before) > now() - INTERVAL '1 day'
+  AND (not_before IS NULL OR not_before <= now())
+  AND revoked_at IS NULL
+  AND held_at IS NULL
+  AND created_at < (SELECT r.created_at FROM reports r WHERE r.id = $1);
+
+-- name: GetCohortScheduleEnd :one
+-- The latest not_before of the imported reports still waiting, or now when
+-- none are, so a new import is scheduled after the ones before it.
+SELECT GREATEST(MAX(not_before), now())::timestamptz AS schedule_end
+FROM reports
+WHERE status IN ('draft', 'processing')
+  AND not_before > now();
+
+-- name: RevokeReport :one
+-- Soft-deletes a report: the row and its results stay for accounting and
+-- audit, but the access token stops working and the worker skips it. Returns
+-- no rows when the report does not exist or is already revoked.
+UPDATE reports
+SET revoked_at     = now(),
+    revoked_reason = sqlc.arg(revoked_reason)::text
+WHERE id = sqlc.arg(id) AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RequeueReport :one
+-- Returns a report to draft so the worker's poller generates it again.
+UPDATE reports
+SET status           = 'draft',
+    error_message    = NULL,
+    claimed_by       = NULL,
+    claim_expires_at = NULL,
+    ai_analysis      = NULL,
+    ai_narrative     = NULL,
+    relationships    = NULL,
+    job_state        = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: RequeueReportNarrative :one
+-- Like RequeueReport, but keeps the stored AI analysis so the worker only
+-- runs the narrative stage again.
+UPDATE reports
+SET status           = 'draft',
+    error_message    = NULL,
+    claimed_by       = NULL,
+    claim_expires_at = NULL,
+    ai_narrative     = NULL,
+    relationships    = NULL,
+    job_state        = NULL
+WHERE id = $1
+RETURNING *;
+
 -- ---------------------------------------------------------------------------
 -- RISK RESULTS
 -- ---------------------------------------------------------------------------
@@ -152,9 +433,9 @@ ORDER BY created_at;
 -- name: InsertRiskResult :one
 INSERT INTO risk_results (
     report_id, question_id, rank, risk_name, risk_desc,
-    probability, impact, score, tier, hedge, section
+    probability, impact, score, tier, hedge, section, explanation
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: SetAIHedge :one
@@ -163,11 +444,18 @@ SET ai_hedge = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: GetRiskResultByQuestion :one
+SELECT * FROM risk_results
+WHERE report_id = $1 AND question_id = $2;
+
 -- name: GetRiskResultsByReport :many
 SELECT * FROM risk_results
 WHERE report_id = $1
 ORDER BY rank;
 
+-- name: DeleteRiskResultsByReport :execrows
+DELETE FROM risk_results WHERE report_id = $1;
+
 -- name: GetWatchAndRedRisks :many
 SELECT * FROM risk_results
 WHERE report_id = $1 AND tier IN ('watch', 'red')
@@ -183,6 +471,9 @@ VALUES ($1, $2, $3)
 ON CONFLICT (stripe_event_id) DO NOTHING
 RETURNING *;
 
+-- name: GetStripeEvent :one
+SELECT * FROM stripe_events WHERE stripe_event_id = $1 LIMIT 1;
+
 -- name: MarkStripeEventProcessed :one
 UPDATE stripe_events
 SET processed    = TRUE,
@@ -203,6 +494,46 @@ WHERE processed = FALSE
   AND received_at > now() - INTERVAL '24 hours'
 ORDER BY received_at;
 
+-- name: ListStripeEventsForExport :many
+-- Stored events of the given types received in [received_from, received_to),
+-- oldest first. Used by the accounting export.
+SELECT * FROM stripe_events
+WHERE type = ANY(sqlc.arg(types)::text[])
+  AND received_at >= sqlc.arg(received_from)::timestamptz
+  AND received_at <  sqlc.arg(received_to)::timestamptz
+ORDER BY received_at, stripe_event_id;
+
+-- name: ListReprocessableStripeEvents :many
+-- Failed events oldest first, for the admin bulk reprocess: not processed,
+-- with an error, and with no pending process_stripe_event job that will
+-- retry them, so a replay never runs beside the worker's. An empty
+-- event_type matches every type.
+SELECT * FROM stripe_events
+WHERE NOT processed
+  AND error IS NOT NULL
+  AND (sqlc.arg(event_type)::text = '' OR type = sqlc.arg(event_type)::text)
+  AND NOT EXISTS (SELECT 1 FROM jobs
+                  WHERE jobs.dedupe_key = 'stripe_event:' || stripe_events.stripe_event_id
+                    AND jobs.status = 'pending')
+ORDER BY received_at, stripe_event_id
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListStripeEvents :many
+-- Stored events newest first, for the admin listing, ordered and continued
+-- like ListSessionsPage. status is empty for any, 'failed' (handler errored,
+-- not processed since), 'pending' (not processed, no error) or 'processed';
+-- an empty event_type matches every type.
+SELECT * FROM stripe_events
+WHERE (sqlc.arg(status)::text = ''
+       OR (sqlc.arg(status)::text = 'failed' AND NOT processed AND error IS NOT NULL)
+       OR (sqlc.arg(status)::text = 'pending' AND NOT processed AND error IS NULL)
+       OR (sqlc.arg(status)::text = 'processed' AND processed))
+  AND (sqlc.arg(event_type)::text = '' OR type = sqlc.arg(event_type)::text)
+  AND (NOT sqlc.arg(has_cursor)::boolean
+       OR (received_at, stripe_event_id) < (sqlc.arg(cursor_at)::timestamptz, sqlc.arg(cursor_id)::text))
+ORDER BY received_at DESC, stripe_event_id DESC
+LIMIT sqlc.arg(max_rows);
+
 -- ---------------------------------------------------------------------------
 -- EMAIL LOG
 -- ---------------------------------------------------------------------------
@@ -212,8 +543,206 @@ INSERT INTO email_log (session_id, report_id, to_address, subject, template, pro
 VALUES ($1, $2, $3, $4, $5, $6, now())
 RETURNING *;
 
+-- name: LogEmailFailure :one
+-- Records an email the provider refused. It has no sent_at.
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, error)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ClaimEmail :one
+-- Claims dedupe_key before an email is sent. No row when the key is already
+-- claimed: the email was sent, or is being sent elsewhere. A claim that was
+-- never finished (the process died mid-send) is taken over once it was made
+-- before stale_before.
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, dedupe_key)
+VALUES (sqlc.arg(session_id), sqlc.arg(report_id), sqlc.arg(to_address), '', sqlc.arg(template), sqlc.arg(dedupe_key))
+ON CONFLICT (dedupe_key) WHERE dedupe_key IS NOT NULL DO UPDATE
+SET created_at = now(),
+    to_address = EXCLUDED.to_address
+WHERE email_log.sent_at IS NULL
+  AND email_log.created_at < sqlc.arg(stale_before)::timestamptz
+RETURNING *;
+
+-- name: MarkEmailClaimSent :one
+UPDATE email_log
+SET subject     = sqlc.arg(subject),
+    provider_id = sqlc.arg(provider_id),
+    sent_at     = now()
+WHERE dedupe_key = sqlc.arg(dedupe_key) AND sent_at IS NULL
+RETURNING *;
+
+-- name: ReleaseEmailClaim :one
+-- Records a failed send and frees its key for the next attempt.
+UPDATE email_log
+SET subject    = sqlc.arg(subject),
+    error      = sqlc.arg(error),
+    dedupe_key = NULL
+WHERE dedupe_key = sqlc.arg(dedupe_key) AND sent_at IS NULL
+RETURNING *;
+
+-- name: GetEmailByDedupeKey :one
+SELECT * FROM email_log WHERE dedupe_key = $1;
+
+-- name: LogSkippedEmail :one
+-- Records a send skipped as a duplicate of an earlier email. It has no
+-- sent_at.
+INSERT INTO email_log (session_id, report_id, to_address, subject, template, duplicate_of)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListEmailLogBySession :many
+SELECT * FROM email_log WHERE session_id = $1 ORDER BY created_at;
+
+-- name: ListEmailLogPage :many
+-- Logged emails newest first, for the admin listing, ordered and continued
+-- like ListSessionsPage. An empty template matches every template.
+SELECT * FROM email_log
+WHERE (sqlc.arg(template)::text = '' OR template = sqlc.arg(template)::text)
+  AND (NOT sqlc.arg(has_cursor)::boolean
+       OR (created_at, id) < (sqlc.arg(cursor_at)::timestamptz, sqlc.arg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
 -- name: MarkEmailOpened :one
-UPDATE email_log SET opened_at = now() WHERE provider_id = $1 RETURNING *;
+-- Webhooks are delivered at least once; the first open is kept.
+UPDATE email_log SET opened_at = COALESCE(opened_at, now()) WHERE provider_id = $1 RETURNING *;
+
+-- name: MarkEmailClicked :one
+-- A click implies an open, even when the client blocked the tracking pixel.
+UPDATE email_log
+SET clicked_at = COALESCE(clicked_at, now()),
+    opened_at  = COALESCE(opened_at, now())
+WHERE provider_id = $1
+RETURNING *;
+
+-- name: MarkEmailBounced :one
+UPDATE email_log
+SET bounced_at = COALESCE(bounced_at, now()),
+    error      = sqlc.arg(reason)
+WHERE provider_id = sqlc.arg(provider_id)
+RETURNING *;
+
+-- name: ListUnopenedReportEmails :many
+-- Report-ready emails sent in [sent_after, sent_before) that were not opened,
+-- bounced or resent, for live reports with no other email that is later or
+-- was opened. Oldest first. Feeds the worker's resender; with report_id, only
+-- that report's.
+SELECT l.id, l.session_id, l.report_id, l.to_address, l.sent_at,
+       r.access_token, s.biz_name
+FROM email_log l
+JOIN reports  r ON r.id = l.report_id
+JOIN sessions s ON s.id = l.session_id
+WHERE l.template = 'report_ready'
+  AND l.opened_at  IS NULL
+  AND l.bounced_at IS NULL
+  AND l.resent_at  IS NULL
+  AND l.sent_at >= sqlc.arg(sent_after)::timestamptz
+  AND l.sent_at <  sqlc.arg(sent_before)::timestamptz
+  AND (sqlc.narg(report_id)::uuid IS NULL OR l.report_id = sqlc.narg(report_id)::uuid)
+  AND r.revoked_at IS NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM email_log other
+      WHERE other.report_id = l.report_id
+        AND other.id <> l.id
+        AND other.duplicate_of IS NULL
+        AND (other.created_at > l.created_at OR other.opened_at IS NOT NULL)
+  )
+ORDER BY l.sent_at
+LIMIT sqlc.arg(max_rows);
+
+-- name: MarkEmailResent :execrows
+-- Claims an email for resending; 0 when another replica already has.
+UPDATE email_log SET resent_at = now() WHERE id = $1 AND resent_at IS NULL;
+
+-- ---------------------------------------------------------------------------
+-- FEEDBACK
+-- ---------------------------------------------------------------------------
+
+-- name: ListFeedbackCandidates :many
+-- Live reports whose report-ready email went out in [sent_after, sent_before)
+-- and never bounced, that have not been asked for feedback and whose address
+-- is not suppressed. Oldest first. Feeds the worker's feedback requester;
+-- with report_id, only that report.
+SELECT l.report_id, l.session_id, l.to_address, l.sent_at, s.biz_name
+FROM email_log l
+JOIN reports  r ON r.id = l.report_id
+JOIN sessions s ON s.id = l.session_id
+WHERE l.template = 'report_ready'
+  AND l.dedupe_key IS NOT NULL
+  AND l.sent_at >= sqlc.arg(sent_after)::timestamptz
+  AND l.sent_at <  sqlc.arg(sent_before)::timestamptz
+  AND (sqlc.narg(report_id)::uuid IS NULL OR l.report_id = sqlc.narg(report_id)::uuid)
+  AND r.status = 'ready'
+  AND r.revoked_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM feedback f WHERE f.report_id = l.report_id)
+  AND NOT EXISTS (SELECT 1 FROM email_suppressions es WHERE es.email_hash = s.email_hash)
+  AND NOT EXISTS (
+      SELECT 1 FROM email_log b
+      WHERE b.report_id = l.report_id AND b.bounced_at IS NOT NULL
+  )
+ORDER BY l.sent_at
+LIMIT sqlc.arg(max_rows);
+
+-- name: CreateFeedbackRequest :one
+-- Claims a report for a feedback request; sql.ErrNoRows when another replica
+-- already has.
+INSERT INTO feedback (report_id) VALUES ($1)
+ON CONFLICT (report_id) DO NOTHING
+RETURNING *;
+
+-- name: GetFeedbackByToken :one
+SELECT f.*, s.biz_name, s.email_hash
+FROM feedback f
+JOIN reports  r ON r.id = f.report_id
+JOIN sessions s ON s.id = r.session_id
+WHERE f.token = $1;
+
+-- name: RecordFeedback :one
+-- Stores the customer's answer; they may answer again. An approval only
+-- survives when the testimonial and consent to quote it are unchanged.
+UPDATE feedback
+SET rating        = sqlc.arg(rating),
+    testimonial   = sqlc.arg(testimonial),
+    consent_quote = sqlc.arg(consent_quote),
+    consent_name  = sqlc.arg(consent_name),
+    responded_at  = now(),
+    approved_at   = CASE
+        WHEN sqlc.arg(consent_quote)::bool AND testimonial IS NOT DISTINCT FROM sqlc.arg(testimonial) THEN approved_at
+    END
+WHERE token = sqlc.arg(token)
+RETURNING *;
+
+-- name: ListTestimonials :many
+-- Testimonials their authors agreed to have quoted, approved or awaiting
+-- approval, oldest response first.
+SELECT f.report_id, f.rating, f.testimonial, f.consent_name, f.responded_at, f.approved_at, s.biz_name
+FROM feedback f
+JOIN reports  r ON r.id = f.report_id
+JOIN sessions s ON s.id = r.session_id
+WHERE f.consent_quote
+  AND f.testimonial IS NOT NULL
+  AND (f.approved_at IS NOT NULL) = sqlc.arg(approved)::bool
+ORDER BY f.responded_at;
+
+-- name: ApproveTestimonial :one
+-- sql.ErrNoRows when the report has no testimonial its author agreed to have
+-- quoted.
+UPDATE feedback
+SET approved_at = COALESCE(approved_at, now())
+WHERE report_id = $1 AND consent_quote AND testimonial IS NOT NULL
+RETURNING *;
+
+-- name: SuppressEmail :exec
+-- Stops optional emails (feedback requests) to an address, by its blind
+-- index. The first reason is kept.
+INSERT INTO email_suppressions (email_hash, reason) VALUES ($1, $2)
+ON CONFLICT (email_hash) DO NOTHING;
+
+-- name: SuppressSessionEmail :exec
+INSERT INTO email_suppressions (email_hash, reason)
+SELECT email_hash, sqlc.arg(reason)::text FROM sessions
+WHERE id = sqlc.arg(session_id) AND email_hash IS NOT NULL
+ON CONFLICT (email_hash) DO NOTHING;
 
 -- ---------------------------------------------------------------------------
 -- ANALYTICS
@@ -223,17 +752,58 @@ UPDATE email_log SET opened_at = now() WHERE provider_id = $1 RETURNING *;
 SELECT * FROM public_risk_stats;
 
 -- name: GetDailyRevenue :many
+-- Revenue excluding tax. Sessions checked out before subtotals were recorded
+-- count at the product's current catalog price, or the standard price if they
+-- predate the catalog too. Reports covered by a subscription are excluded; the
+-- subscription's invoices are in Stripe. So are imported cohorts, which are
+-- billed under their B2B deal.
 SELECT
-    DATE(paid_at)       AS day,
+    DATE(s.paid_at)     AS day,
     COUNT(*)            AS sales,
-    COUNT(*) * 59       AS revenue_usd   -- $59 fixed price
-FROM sessions
-WHERE payment_status = 'paid'
-  AND paid_at >= now() - INTERVAL '30 days'
-GROUP BY DATE(paid_at)
+    SUM(COALESCE(s.subtotal_cents, p.price_cents))::bigint AS revenue_cents,
+    SUM(COALESCE(s.tax_cents, 0))::bigint                  AS tax_cents
+FROM sessions s
+JOIN products p ON p.sku = COALESCE(s.product_sku, 'standard')
+WHERE s.payment_status = 'paid'
+  AND s.subscription_id IS NULL
+  AND s.cohort IS NULL
+  AND s.paid_at >= now() - INTERVAL '30 days'
+GROUP BY DATE(s.paid_at)
 ORDER BY day DESC;
 
+-- name: ListResearchReports :many
+-- Delivered reports generated in [generated_from, generated_to), with only
+-- the columns the anonymised research export may publish.
+SELECT
+    r.id,
+    COALESCE(s.industry, '')::text       AS industry,
+    COALESCE(s.stage, '')::text          AS stage,
+    COALESCE(r.overall_score, 0)::smallint AS overall_score
+FROM reports r
+JOIN sessions s ON s.id = r.session_id
+WHERE r.status = 'ready'
+  AND r.revoked_at IS NULL
+  AND r.held_at IS NULL
+  AND r.generated_at >= sqlc.arg(generated_from)::timestamptz
+  AND r.generated_at <  sqlc.arg(generated_to)::timestamptz
+ORDER BY r.generated_at, r.id;
+
+-- name: CountResearchRiskTiers :many
+-- How many of the reports ListResearchReports returns for the same range put
+-- each question in each tier.
+SELECT rr.question_id, rr.tier, COUNT(*)::int AS reports
+FROM risk_results rr
+JOIN reports r ON r.id = rr.report_id
+WHERE r.status = 'ready'
+  AND r.revoked_at IS NULL
+  AND r.held_at IS NULL
+  AND r.generated_at >= sqlc.arg(generated_from)::timestamptz
+  AND r.generated_at <  sqlc.arg(generated_to)::timestamptz
+GROUP BY rr.question_id, rr.tier
+ORDER BY rr.question_id, rr.tier;
+
 -- name: GetCompletionFunnelStats :one
+-- Imported cohorts never went through the funnel and are left out.
 SELECT
     COUNT(*)                                                        AS total_sessions,
     COUNT(*) FILTER (WHERE (SELECT COUNT(*) FROM answers a WHERE a.session_id = s.id) > 0) AS started,
@@ -241,4 +811,737 @@ SELECT
     COUNT(*) FILTER (WHERE payment_status = 'paid' AND EXISTS (
         SELECT 1 FROM reports r WHERE r.session_id = s.id AND r.status = 'ready'
     ))                                                              AS report_delivered
-FROM sessions s;
\ No newline at end of file
+FROM sessions s
+WHERE s.cohort IS NULL;
+
+-- ---------------------------------------------------------------------------
+-- RUNTIME SETTINGS
+-- ---------------------------------------------------------------------------
+
+-- name: ListRuntimeSettings :many
+SELECT * FROM runtime_settings ORDER BY key;
+
+-- name: UpsertRuntimeSetting :one
+INSERT INTO runtime_settings (key, value)
+VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value
+RETURNING *;
+
+-- name: DeleteRuntimeSetting :execrows
+DELETE FROM runtime_settings WHERE key = $1;
+
+-- ---------------------------------------------------------------------------
+-- FEATURE FLAGS
+-- ---------------------------------------------------------------------------
+
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags ORDER BY name, environment;
+
+-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (name, environment, percent)
+VALUES ($1, $2, $3)
+ON CONFLICT (name, environment) DO UPDATE SET percent = EXCLUDED.percent
+RETURNING *;
+
+-- name: DeleteFeatureFlag :execrows
+DELETE FROM feature_flags WHERE name = $1 AND environment = $2;
+
+-- ---------------------------------------------------------------------------
+-- EXPERIMENTS
+-- ---------------------------------------------------------------------------
+
+-- name: AssignExperiment :exec
+-- A session keeps its first assignment.
+INSERT INTO experiment_assignments (session_id, experiment, variant)
+VALUES ($1, $2, $3)
+ON CONFLICT (session_id, experiment) DO NOTHING;
+
+-- name: GetExperimentAssignments :many
+SELECT * FROM experiment_assignments WHERE session_id = $1 ORDER BY experiment;
+
+-- name: GetExperimentStats :many
+-- Conversion and report quality per variant of every experiment, stopped ones
+-- included. A report counts as rejected when the AI quality gate threw its
+-- narrative out.
+SELECT
+    ea.experiment,
+    ea.variant,
+    COUNT(*)                                                          AS sessions,
+    COUNT(*) FILTER (WHERE s.payment_status = 'paid')                 AS paid,
+    COUNT(r.id) FILTER (WHERE r.status = 'ready')                     AS reports,
+    COUNT(r.id) FILTER (WHERE r.status = 'ready'
+                          AND r.ai_quality_issues IS NOT NULL)        AS quality_rejected,
+    COALESCE(AVG(r.overall_score) FILTER (WHERE r.status = 'ready'), 0)::float8 AS mean_overall_score,
+    COUNT(c.id)                                                       AS consultations
+FROM experiment_assignments ea
+JOIN sessions s ON s.id = ea.session_id
+LEFT JOIN reports r ON r.session_id = s.id
+LEFT JOIN consultation_requests c ON c.report_id = r.id
+GROUP BY ea.experiment, ea.variant
+ORDER BY ea.experiment, ea.variant;
+
+-- ---------------------------------------------------------------------------
+-- SHADOW SCORES
+-- ---------------------------------------------------------------------------
+
+-- name: UpsertShadowScore :exec
+-- A regenerated report replaces its earlier shadow score for the profile.
+INSERT INTO shadow_scores (report_id, profile, production_profile, production_score, shadow_score, production_band, shadow_band)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (report_id, profile) DO UPDATE SET
+    production_profile = EXCLUDED.production_profile,
+    production_score   = EXCLUDED.production_score,
+    shadow_score       = EXCLUDED.shadow_score,
+    production_band    = EXCLUDED.production_band,
+    shadow_band        = EXCLUDED.shadow_band,
+    created_at         = now();
+
+-- name: SummarizeShadowScores :many
+-- Shadow scores recorded since the given time, per candidate profile and
+-- pair of bands, with the sums of the score differences (shadow − production).
+SELECT profile, production_band, shadow_band,
+       COUNT(*)                                          AS reports,
+       SUM(shadow_score - production_score)::bigint      AS delta_sum,
+       SUM(ABS(shadow_score - production_score))::bigint AS abs_delta_sum
+FROM shadow_scores
+WHERE created_at >= sqlc.arg(since)::timestamptz
+GROUP BY profile, production_band, shadow_band
+ORDER BY profile, production_band, shadow_band;
+
+-- ---------------------------------------------------------------------------
+-- AI TRANSCRIPTS
+-- ---------------------------------------------------------------------------
+
+-- name: InsertAITranscript :one
+INSERT INTO ai_transcripts (report_id, exchanges, storage_key, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListAITranscriptsByReport :many
+SELECT * FROM ai_transcripts WHERE report_id = $1 ORDER BY created_at;
+
+-- ---------------------------------------------------------------------------
+-- AI EDITS
+-- ---------------------------------------------------------------------------
+
+-- name: EditAIHedge :one
+UPDATE risk_results
+SET ai_hedge           = $2,
+    ai_hedge_edited_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: EditExecutiveSummary :one
+UPDATE reports
+SET executive_summary           = $2,
+    executive_summary_edited_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: InsertAIEdit :one
+INSERT INTO ai_edits (report_id, field, question_id, original, edited, editor, reason)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListAIEditsByReport :many
+SELECT * FROM ai_edits WHERE report_id = $1 ORDER BY created_at;
+
+-- ---------------------------------------------------------------------------
+-- SUPPORT NOTES
+-- ---------------------------------------------------------------------------
+
+-- name: InsertSupportNote :one
+INSERT INTO support_notes (session_id, report_id, author, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListSupportNotesBySession :many
+-- Notes written against the session's report included, oldest first.
+SELECT * FROM support_notes WHERE session_id = $1 ORDER BY created_at;
+
+-- ---------------------------------------------------------------------------
+-- QUESTION SECTIONS
+-- ---------------------------------------------------------------------------
+
+-- name: ListQuestionSections :many
+SELECT * FROM question_sections ORDER BY display_order, id;
+
+-- name: UpdateQuestionSection :one
+UPDATE question_sections
+SET title = $2, description = $3, display_order = $4, gated = $5
+WHERE id = $1
+RETURNING *;
+
+-- ---------------------------------------------------------------------------
+-- AI CACHE
+-- ---------------------------------------------------------------------------
+
+-- name: GetAICacheEntry :one
+-- Returns the entry only if it was generated after not_before, and records
+-- the hit so cache effectiveness can be measured.
+UPDATE ai_cache
+SET hit_count = hit_count + 1, last_hit_at = now()
+WHERE fingerprint = sqlc.arg(fingerprint)
+  AND created_at > sqlc.arg(not_before)::timestamptz
+RETURNING *;
+
+-- name: UpsertAICacheEntry :exec
+-- Replaces an expired entry for the same fingerprint rather than failing.
+INSERT INTO ai_cache (fingerprint, hedges, executive_summary, top_priority_html, analysis, relationships)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (fingerprint) DO UPDATE SET
+    hedges            = EXCLUDED.hedges,
+    executive_summary = EXCLUDED.executive_summary,
+    top_priority_html = EXCLUDED.top_priority_html,
+    analysis          = EXCLUDED.analysis,
+    relationships     = EXCLUDED.relationships,
+    hit_count         = 0,
+    created_at        = now(),
+    last_hit_at       = NULL;
+
+-- ---------------------------------------------------------------------------
+-- PRODUCTS
+-- ---------------------------------------------------------------------------
+
+-- name: ListActiveProducts :many
+SELECT * FROM products WHERE active ORDER BY price_cents, sku;
+
+-- name: ListProducts :many
+SELECT * FROM products ORDER BY price_cents, sku;
+
+-- name: GetProductBySKU :one
+SELECT * FROM products WHERE sku = $1 LIMIT 1;
+
+-- name: UpsertProduct :one
+INSERT INTO products (sku, name, price_cents, currency, report_type, active)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (sku) DO UPDATE SET
+    name        = EXCLUDED.name,
+    price_cents = EXCLUDED.price_cents,
+    currency    = EXCLUDED.currency,
+    report_type = EXCLUDED.report_type,
+    active      = EXCLUDED.active
+RETURNING *;
+
+-- ---------------------------------------------------------------------------
+-- CONSULTATION REQUESTS
+-- ---------------------------------------------------------------------------
+
+-- name: UpsertConsultationRequest :one
+INSERT INTO consultation_requests (report_id, session_id, note)
+VALUES ($1, $2, $3)
+ON CONFLICT (report_id) DO UPDATE
+SET note = COALESCE(EXCLUDED.note, consultation_requests.note)
+RETURNING *;
+
+-- name: GetConsultationStats :one
+-- Conversion from delivered report to consultation request, overall and for
+-- the last 30 days.
+SELECT
+    (SELECT COUNT(*) FROM reports WHERE status = 'ready')::bigint               AS reports_delivered,
+    (SELECT COUNT(*) FROM consultation_requests)::bigint                        AS requests,
+    (SELECT COUNT(*) FROM reports
+      WHERE status = 'ready' AND generated_at >= now() - INTERVAL '30 days')::bigint AS reports_delivered_30d,
+    (SELECT COUNT(*) FROM consultation_requests
+      WHERE created_at >= now() - INTERVAL '30 days')::bigint                    AS requests_30d;
+
+-- ---------------------------------------------------------------------------
+-- SUBSCRIPTIONS
+-- ---------------------------------------------------------------------------
+
+-- name: UpsertSubscription :one
+-- Called for customer.subscription.* and invoice.paid. Null arguments leave
+-- the stored value alone, so each event only overwrites what it carries.
+INSERT INTO subscriptions (stripe_subscription_id, stripe_customer_id, email, email_hash, status, current_period_start, current_period_end)
+VALUES (
+    sqlc.arg(stripe_subscription_id),
+    sqlc.arg(stripe_customer_id),
+    sqlc.narg(email),
+    sqlc.narg(email_hash),
+    COALESCE(sqlc.narg(status)::text, 'active'),
+    sqlc.narg(current_period_start),
+    sqlc.narg(current_period_end)
+)
+ON CONFLICT (stripe_subscription_id) DO UPDATE
+SET stripe_customer_id   = EXCLUDED.stripe_customer_id,
+    email                = COALESCE(EXCLUDED.email, subscriptions.email),
+    email_hash           = COALESCE(EXCLUDED.email_hash, subscriptions.email_hash),
+    status               = COALESCE(sqlc.narg(status)::text, subscriptions.status),
+    current_period_start = COALESCE(EXCLUDED.current_period_start, subscriptions.current_period_start),
+    current_period_end   = COALESCE(EXCLUDED.current_period_end, subscriptions.current_period_end)
+RETURNING *;
+
+-- name: GetEntitledSubscription :one
+-- Returns a live subscription for the email — matched directly or through the
+-- Stripe customer on an earlier session — that has not yet covered a report
+-- in its current billing period. The store's codec replaces email with its
+-- blind index.
+SELECT * FROM subscriptions
+WHERE subscriptions.status IN ('active', 'trialing')
+  AND subscriptions.current_period_end > now()
+  AND (
+        subscriptions.email_hash = sqlc.arg(email)::text
+     OR subscriptions.stripe_customer_id IN (
+            SELECT sessions.stripe_customer_id FROM sessions
+            WHERE sessions.email_hash = sqlc.arg(email)::text
+              AND sessions.stripe_customer_id IS NOT NULL
+        )
+  )
+  AND NOT EXISTS (
+        SELECT 1 FROM sessions
+        WHERE sessions.subscription_id = subscriptions.id
+          AND sessions.paid_at >= subscriptions.current_period_start
+  )
+ORDER BY subscriptions.current_period_end DESC
+LIMIT 1;
+
+-- name: MarkSessionPaidBySubscription :one
+UPDATE sessions
+SET payment_status  = 'paid',
+    paid_at         = now(),
+    email           = $2,
+    subscription_id = $3,
+    product_sku     = $4,
+    email_hash      = $5
+WHERE id = $1
+  AND payment_status <> 'paid'
+RETURNING *;
+
+-- ---------------------------------------------------------------------------
+-- PAYMENTS
+-- ---------------------------------------------------------------------------
+
+-- name: UpsertPayment :one
+-- Called for charge.succeeded and charge.updated. A charge's balance
+-- transaction does not change once it exists, so redelivery rewrites the
+-- same values.
+INSERT INTO payments (
+    stripe_charge_id, stripe_payment_intent, stripe_balance_transaction_id,
+    session_id, amount_cents, fee_cents, net_cents, currency
+)
+VALUES (
+    sqlc.arg(stripe_charge_id),
+    sqlc.narg(stripe_payment_intent),
+    sqlc.arg(stripe_balance_transaction_id),
+    sqlc.narg(session_id),
+    sqlc.arg(amount_cents),
+    sqlc.arg(fee_cents),
+    sqlc.arg(net_cents),
+    sqlc.arg(currency)
+)
+ON CONFLICT (stripe_charge_id) DO UPDATE
+SET stripe_payment_intent         = EXCLUDED.stripe_payment_intent,
+    stripe_balance_transaction_id = EXCLUDED.stripe_balance_transaction_id,
+    session_id                    = COALESCE(EXCLUDED.session_id, payments.session_id),
+    amount_cents                  = EXCLUDED.amount_cents,
+    fee_cents                     = EXCLUDED.fee_cents,
+    net_cents                     = EXCLUDED.net_cents,
+    currency                      = EXCLUDED.currency
+RETURNING *;
+
+-- name: ListPaymentsByStripePIs :many
+SELECT * FROM payments WHERE stripe_payment_intent = ANY(sqlc.arg(payment_intents)::text[]);
+
+-- name: GetPaymentMarginStats :many
+-- Gross, Stripe fees and net per settlement currency, overall and for the
+-- last 30 days.
+SELECT
+    currency,
+    COUNT(*)::bigint                                                                     AS payments,
+    COALESCE(SUM(amount_cents), 0)::bigint                                               AS gross_cents,
+    COALESCE(SUM(fee_cents), 0)::bigint                                                  AS fee_cents,
+    COALESCE(SUM(net_cents), 0)::bigint                                                  AS net_cents,
+    (COUNT(*) FILTER (WHERE created_at >= now() - INTERVAL '30 days'))::bigint            AS payments_30d,
+    COALESCE(SUM(amount_cents) FILTER (WHERE created_at >= now() - INTERVAL '30 days'), 0)::bigint AS gross_cents_30d,
+    COALESCE(SUM(fee_cents) FILTER (WHERE created_at >= now() - INTERVAL '30 days'), 0)::bigint    AS fee_cents_30d,
+    COALESCE(SUM(net_cents) FILTER (WHERE created_at >= now() - INTERVAL '30 days'), 0)::bigint    AS net_cents_30d
+FROM payments
+GROUP BY currency
+ORDER BY currency;
+-- ---------------------------------------------------------------------------
+-- RETENTION
+--   Each data class has a Count query for dry runs and a Delete query that
+--   removes at most batch_size rows, so the enforcer can delete in short
+--   transactions until a batch comes back short.
+-- ---------------------------------------------------------------------------
+
+-- name: CountExpiredAnswers :one
+-- Raw answers of sessions whose session and report are both untouched since
+-- cutoff, so a report being generated or requeued keeps its answers. Reports
+-- keep their scored risk_results, but can no longer be regenerated.
+SELECT COUNT(*) FROM answers a
+JOIN sessions s ON s.id = a.session_id
+WHERE s.updated_at < sqlc.arg(cutoff)::timestamptz
+  AND NOT EXISTS (
+      SELECT 1 FROM reports r
+      WHERE r.session_id = s.id AND r.updated_at >= sqlc.arg(cutoff)::timestamptz
+  );
+
+-- name: DeleteExpiredAnswers :execrows
+DELETE FROM answers
+WHERE id IN (
+    SELECT a.id FROM answers a
+    JOIN sessions s ON s.id = a.session_id
+    WHERE s.updated_at < sqlc.arg(cutoff)::timestamptz
+      AND NOT EXISTS (
+          SELECT 1 FROM reports r
+          WHERE r.session_id = s.id AND r.updated_at >= sqlc.arg(cutoff)::timestamptz
+      )
+    LIMIT sqlc.arg(batch_size)::int
+);
+
+-- name: CountExpiredStripeEvents :one
+-- Processed Stripe events received before cutoff. Unprocessed events are kept
+-- for investigation whatever their age.
+SELECT COUNT(*) FROM stripe_events
+WHERE processed AND received_at < sqlc.arg(cutoff)::timestamptz;
+
+-- name: DeleteExpiredStripeEvents :execrows
+DELETE FROM stripe_events
+WHERE stripe_event_id IN (
+    SELECT stripe_event_id FROM stripe_events
+    WHERE processed AND received_at < sqlc.arg(cutoff)::timestamptz
+    LIMIT sqlc.arg(batch_size)::int
+);
+
+-- name: CountExpiredEmailLog :one
+SELECT COUNT(*) FROM email_log
+WHERE created_at < sqlc.arg(cutoff)::timestamptz;
+
+-- name: DeleteExpiredEmailLog :execrows
+DELETE FROM email_log
+WHERE id IN (
+    SELECT id FROM email_log
+    WHERE created_at < sqlc.arg(cutoff)::timestamptz
+    LIMIT sqlc.arg(batch_size)::int
+);
+
+-- name: CountExpiredAICache :one
+-- AI output not created or reused since cutoff.
+SELECT COUNT(*) FROM ai_cache
+WHERE COALESCE(last_hit_at, created_at) < sqlc.arg(cutoff)::timestamptz;
+
+-- name: DeleteExpiredAICache :execrows
+DELETE FROM ai_cache
+WHERE fingerprint IN (
+    SELECT fingerprint FROM ai_cache
+    WHERE COALESCE(last_hit_at, created_at) < sqlc.arg(cutoff)::timestamptz
+    LIMIT sqlc.arg(batch_size)::int
+);
+
+-- name: CountExpiredAITranscripts :one
+SELECT COUNT(*) FROM ai_transcripts WHERE created_at < sqlc.arg(cutoff)::timestamptz;
+
+-- name: DeleteExpiredAITranscripts :many
+-- Returns the object storage keys of the deleted rows, for removal from the
+-- bucket.
+DELETE FROM ai_transcripts
+WHERE id IN (
+    SELECT id FROM ai_transcripts
+    WHERE created_at < sqlc.arg(cutoff)::timestamptz
+    LIMIT sqlc.arg(batch_size)::int
+)
+RETURNING storage_key;
+
+-- ---------------------------------------------------------------------------
+-- FIELD ENCRYPTION
+--   Keyset-paginated scans and guarded rewrites for `armctl reencrypt`, which
+--   moves encrypted columns to the primary key. Each Set query only writes if
+--   the value is still the one that was read.
+-- ---------------------------------------------------------------------------
+
+-- name: ListSessionEmails :many
+SELECT id, email::text AS email, email_hash FROM sessions
+WHERE email IS NOT NULL AND id > sqlc.arg(after)::uuid
+ORDER BY id
+LIMIT sqlc.arg(max_rows)::int;
+
+-- name: SetSessionEmail :execrows
+UPDATE sessions
+SET email      = sqlc.arg(email)::text,
+    email_hash = sqlc.arg(email_hash)::text
+WHERE id = sqlc.arg(id)::uuid AND email::text = sqlc.arg(old_email)::text;
+
+-- name: ListSubscriptionEmails :many
+SELECT id, email::text AS email, email_hash FROM subscriptions
+WHERE email IS NOT NULL AND id > sqlc.arg(after)::uuid
+ORDER BY id
+LIMIT sqlc.arg(max_rows)::int;
+
+-- name: SetSubscriptionEmail :execrows
+UPDATE subscriptions
+SET email      = sqlc.arg(email)::text,
+    email_hash = sqlc.arg(email_hash)::text
+WHERE id = sqlc.arg(id)::uuid AND email::text = sqlc.arg(old_email)::text;
+
+-- name: ListEmailLogAddresses :many
+SELECT id, to_address::text AS to_address FROM email_log
+WHERE id > sqlc.arg(after)::uuid
+ORDER BY id
+LIMIT sqlc.arg(max_rows)::int;
+
+-- name: SetEmailLogAddress :execrows
+UPDATE email_log
+SET to_address = sqlc.arg(to_address)::text
+WHERE id = sqlc.arg(id)::uuid AND to_address::text = sqlc.arg(old_to_address)::text;
+
+-- name: ListStripeEventPayloads :many
+SELECT stripe_event_id, payload FROM stripe_events
+WHERE stripe_event_id > sqlc.arg(after)::text
+ORDER BY stripe_event_id
+LIMIT sqlc.arg(max_rows)::int;
+
+-- name: SetStripeEventPayload :execrows
+UPDATE stripe_events
+SET payload = sqlc.arg(payload)::jsonb
+WHERE stripe_event_id = sqlc.arg(stripe_event_id)::text AND payload = sqlc.arg(old_payload)::jsonb;
+
+-- ---------------------------------------------------------------------------
+-- DUPLICATE PURCHASES
+-- ---------------------------------------------------------------------------
+
+-- name: GetEarlierCardPurchase :one
+-- The other card-paid session for email_hash paid since `since` whose answers
+-- are closest to session id's, with how many questions were answered
+-- differently or only in one of them. Sessions that are themselves
+-- duplicates are not candidates.
+SELECT s.id,
+       s.paid_at,
+       (
+           SELECT COUNT(DISTINCT d.question_id)
+           FROM (
+               (SELECT a.question_id, a.answer_text FROM answers a WHERE a.session_id = s.id
+                EXCEPT
+                SELECT b.question_id, b.answer_text FROM answers b WHERE b.session_id = sqlc.arg(id)::uuid)
+               UNION ALL
+               (SELECT b.question_id, b.answer_text FROM answers b WHERE b.session_id = sqlc.arg(id)::uuid
+                EXCEPT
+                SELECT a.question_id, a.answer_text FROM answers a WHERE a.session_id = s.id)
+           ) d
+       )::int AS changed_answers
+FROM sessions s
+WHERE s.email_hash = sqlc.arg(email_hash)::text
+  AND s.id <> sqlc.arg(id)::uuid
+  AND s.payment_status = 'paid'
+  AND s.stripe_payment_intent IS NOT NULL
+  AND s.paid_at >= sqlc.arg(since)::timestamptz
+  AND NOT EXISTS (SELECT 1 FROM duplicate_purchases dp WHERE dp.session_id = s.id)
+ORDER BY changed_answers, s.paid_at DESC
+LIMIT 1;
+
+-- name: CreateDuplicatePurchase :one
+INSERT INTO duplicate_purchases (session_id, original_session_id, changed_answers)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: HoldReport :one
+UPDATE reports SET held_at = now() WHERE id = $1 RETURNING *;
+
+-- name: ReleaseReport :one
+-- updated_at is bumped so the poller's one-day window starts again.
+UPDATE reports SET held_at = NULL, updated_at = now() WHERE id = $1 RETURNING *;
+
+-- name: ListUnresolvedDuplicatePurchases :many
+SELECT d.session_id, d.original_session_id, d.changed_answers, d.created_at,
+       s.stripe_payment_intent, s.paid_at, s.product_sku, s.biz_name,
+       r.id AS report_id, o.paid_at AS original_paid_at
+FROM duplicate_purchases d
+JOIN sessions s ON s.id = d.session_id
+JOIN sessions o ON o.id = d.original_session_id
+JOIN reports  r ON r.session_id = d.session_id
+WHERE d.resolution IS NULL
+ORDER BY d.created_at;
+
+-- name: GetDuplicatePurchase :one
+SELECT d.*, s.stripe_payment_intent, r.id AS report_id
+FROM duplicate_purchases d
+JOIN sessions s ON s.id = d.session_id
+JOIN reports  r ON r.session_id = d.session_id
+WHERE d.session_id = $1;
+
+-- name: ResolveDuplicatePurchase :one
+-- Returns no rows when the duplicate is unknown or already resolved.
+UPDATE duplicate_purchases
+SET resolution       = sqlc.arg(resolution)::text,
+    stripe_refund_id = sqlc.narg(stripe_refund_id),
+    resolved_at      = now()
+WHERE session_id = sqlc.arg(session_id) AND resolution IS NULL
+RETURNING *;
+
+-- name: MarkSessionRefunded :one
+UPDATE sessions SET payment_status = 'refunded' WHERE id = $1 RETURNING *;
+
+-- name: GetDuplicateCredit :one
+-- The oldest duplicate purchase kept as credit for this email and product
+-- that has not paid for a session yet. The store's codec replaces email with
+-- its blind index.
+SELECT d.session_id
+FROM duplicate_purchases d
+JOIN sessions s ON s.id = d.session_id
+WHERE d.resolution = 'credit'
+  AND s.email_hash = sqlc.arg(email)::text
+  AND COALESCE(s.product_sku, 'standard') = sqlc.arg(product_sku)::text
+  AND NOT EXISTS (SELECT 1 FROM sessions c WHERE c.credit_from_session_id = d.session_id)
+ORDER BY d.resolved_at
+LIMIT 1;
+
+-- name: MarkSessionPaidByCredit :one
+UPDATE sessions
+SET payment_status         = 'paid',
+    paid_at                = now(),
+    email                  = $2,
+    credit_from_session_id = $3,
+    product_sku            = $4,
+    email_hash             = $5
+WHERE id = $1
+  AND payment_status <> 'paid'
+RETURNING *;
+
+-- ---------------------------------------------------------------------------
+-- INDUSTRY PLAYBOOKS
+-- ---------------------------------------------------------------------------
+
+-- name: ListPlaybookSnippets :many
+SELECT * FROM playbook_snippets ORDER BY lower(industry), slug;
+
+-- name: ListActivePlaybookSnippetsByIndustry :many
+SELECT * FROM playbook_snippets
+WHERE active AND lower(industry) = lower(sqlc.arg(industry)::text)
+ORDER BY slug;
+
+-- name: UpsertPlaybookSnippet :one
+INSERT INTO playbook_snippets (slug, industry, title, body, keywords, active)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (slug) DO UPDATE SET
+    industry = EXCLUDED.industry,
+    title    = EXCLUDED.title,
+    body     = EXCLUDED.body,
+    keywords = EXCLUDED.keywords,
+    active   = EXCLUDED.active
+RETURNING *;
+
+-- name: DeletePlaybookSnippet :execrows
+DELETE FROM playbook_snippets WHERE slug = $1;
+
+-- name: ListTierThemes :many
+SELECT * FROM tier_themes
+ORDER BY tier;
+
+-- name: UpdateTierTheme :one
+UPDATE tier_themes
+SET label       = $2,
+    color       = $3,
+    description = $4,
+    cadence     = $5
+WHERE tier = $1
+RETURNING *;
+
+-- ---------------------------------------------------------------------------
+-- JOBS
+--   Claims work as the report claims do: a claim lasts lease_seconds, and an
+--   expired claim, or claimed_by's own, can be taken again.
+-- ---------------------------------------------------------------------------
+
+-- name: InsertJob :one
+-- Queues a job to run from run_at. Returns no rows when a job with the same
+-- dedupe_key was already queued.
+INSERT INTO jobs (type, payload, dedupe_key, run_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (dedupe_key) DO NOTHING
+RETURNING *;
+
+-- name: ClaimJob :one
+-- Claims one pending job for claimed_by. Returns no rows when the job is
+-- finished, not due yet, or another worker holds an unexpired claim on it.
+UPDATE jobs
+SET claimed_by       = sqlc.arg(claimed_by)::text,
+    claim_expires_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id = sqlc.arg(id)
+  AND status = 'pending'
+  AND run_at <= now()
+  AND (claim_expires_at IS NULL OR claim_expires_at < now() OR claimed_by = sqlc.arg(claimed_by)::text)
+RETURNING *;
+
+-- name: ClaimPendingJobs :many
+-- The poller's version of ClaimJob: claims up to max_jobs unclaimed due jobs
+-- of the given types, longest due first. SKIP LOCKED lets concurrent pollers
+-- take disjoint batches.
+UPDATE jobs
+SET claimed_by       = sqlc.arg(claimed_by)::text,
+    claim_expires_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::int)
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE status = 'pending'
+      AND run_at <= now()
+      AND type = ANY(sqlc.arg(types)::text[])
+      AND (claim_expires_at IS NULL OR claim_expires_at < now())
+    ORDER BY run_at
+    LIMIT sqlc.arg(max_jobs)::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ReleaseJobClaim :exec
+-- Gives up claimed_by's claim so another worker can take the job at once.
+UPDATE jobs
+SET claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = sqlc.arg(id) AND claimed_by = sqlc.arg(claimed_by)::text;
+
+-- name: RecordJobAttempt :exec
+-- Counts a failed attempt and keeps its error.
+UPDATE jobs
+SET attempts = attempts + 1,
+    error    = $2
+WHERE id = $1;
+
+-- name: CompleteJob :exec
+UPDATE jobs
+SET status           = 'done',
+    attempts         = attempts + 1,
+    error            = NULL,
+    finished_at      = now(),
+    claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = $1;
+
+-- name: FailJob :exec
+-- Gives up on a job after its last attempt; RecordJobAttempt has its error.
+UPDATE jobs
+SET status           = 'failed',
+    finished_at      = now(),
+    claimed_by       = NULL,
+    claim_expires_at = NULL
+WHERE id = $1;
+
+-- name: DeleteFinishedJobs :execrows
+DELETE FROM jobs
+WHERE status <> 'pending'
+  AND finished_at < sqlc.arg(cutoff)::timestamptz;
+
+-- ---------------------------------------------------------------------------
+-- REPORT ATTEMPTS
+-- ---------------------------------------------------------------------------
+
+-- name: InsertReportAttempt :exec
+INSERT INTO report_attempts (report_id, attempt, error, provider, provider_error, duration_ms, worker_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListReportAttempts :many
+-- A report's failed attempts, oldest first.
+SELECT * FROM report_attempts
+WHERE report_id = $1
+ORDER BY created_at;
+
+-- name: SummariseReportAttempts :many
+-- Failed attempts since a time, grouped by provider and its last error, most
+-- frequent first: "all failures last night were DeepSeek 429s" at a glance.
+SELECT COALESCE(provider, '')::text       AS provider,
+       COALESCE(provider_error, '')::text AS provider_error,
+       count(*)                           AS attempts,
+       count(DISTINCT report_id)          AS reports,
+       max(created_at)::timestamptz       AS last_seen
+FROM report_attempts
+WHERE created_at >= sqlc.arg(since)::timestamptz
+GROUP BY 1, 2
+ORDER BY attempts DESC, last_seen DESC;
@@ -17,6 +17,7 @@ CREATE TYPE question_type   AS ENUM ('radio', 'text', 'select');
 CREATE TYPE risk_tier       AS ENUM ('watch', 'red', 'manage', 'ignore');
 CREATE TYPE payment_status  AS ENUM ('pending', 'paid', 'failed', 'refunded');
 CREATE TYPE report_status   AS ENUM ('draft', 'processing', 'ready', 'error');
+CREATE TYPE report_type     AS ENUM ('standard', 'premium');
 CREATE TYPE section_id      AS ENUM (
     'snapshot', 'dependency', 'market', 'operational', 'legal', 'blindspots'
 );
@@ -261,6 +262,634 @@ WHERE r.status = 'ready'
 GROUP BY rr.risk_name, rr.tier, rr.section
 ORDER BY avg_score DESC;
 
+-- ---------------------------------------------------------------------------
+-- 9. RUNTIME SETTINGS
+--    Operator-tunable values that can change without a restart.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE runtime_settings (
+    key             TEXT        PRIMARY KEY,    -- e.g. "poll_interval"
+    value           TEXT        NOT NULL,       -- parsed per key, e.g. "30s"
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- ---------------------------------------------------------------------------
+-- 10. AI CACHE
+--     Prior hedge generations keyed by a fingerprint of the scored risks and
+--     business context, so identical answer patterns skip the AI call.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE ai_cache (
+    fingerprint         TEXT        PRIMARY KEY,    -- hex SHA-256
+    hedges              JSONB       NOT NULL,       -- question_id → narrative
+    executive_summary   TEXT        NOT NULL,
+    top_priority_html   TEXT        NOT NULL,
+    hit_count           INT         NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_hit_at         TIMESTAMPTZ
+);
+
+-- ---------------------------------------------------------------------------
+-- 11. PRODUCTS
+--     Catalog of purchasable reports. Checkout charges the selected product's
+--     price; report_type controls how detailed the AI narratives are.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE products (
+    sku             TEXT        PRIMARY KEY,    -- e.g. "standard"
+    name            TEXT        NOT NULL,
+    price_cents     INT         NOT NULL CHECK (price_cents >= 50),  -- Stripe minimum
+    currency        TEXT        NOT NULL DEFAULT 'usd',
+    report_type     report_type NOT NULL DEFAULT 'standard',
+    active          BOOLEAN     NOT NULL DEFAULT true,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- The product bought at checkout. NULL for sessions created before the
+-- catalog existed; those are treated as 'standard'.
+ALTER TABLE sessions ADD COLUMN product_sku TEXT REFERENCES products (sku);
+
+-- ---------------------------------------------------------------------------
+-- 12. CONSULTATION REQUESTS
+--     Customers who asked for a paid consultation after reading their report.
+--     One row per report; repeat requests update the note.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE consultation_requests (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id       UUID        NOT NULL UNIQUE REFERENCES reports (id),
+    session_id      UUID        NOT NULL REFERENCES sessions (id),
+    note            TEXT,                       -- optional free text from the customer
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_consultation_requests_created_at ON consultation_requests (created_at);
+
+-- ---------------------------------------------------------------------------
+-- 13. SUBSCRIPTIONS
+--     Quarterly re-assessment subscriptions, mirrored from Stripe webhooks.
+--     Each billing period entitles the subscriber to one report paid for by
+--     the subscription instead of a PaymentIntent.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE subscriptions (
+    id                      UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    stripe_subscription_id  TEXT        NOT NULL UNIQUE,
+    stripe_customer_id      TEXT        NOT NULL,
+    email                   CITEXT,                 -- from invoice.paid; null until the first invoice
+    status                  TEXT        NOT NULL,   -- Stripe subscription status, e.g. active, past_due, canceled
+    current_period_start    TIMESTAMPTZ,
+    current_period_end      TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_subscriptions_email       ON subscriptions (email);
+CREATE INDEX idx_subscriptions_customer_id ON subscriptions (stripe_customer_id);
+
+-- Set on sessions paid for by a subscription rather than a PaymentIntent.
+ALTER TABLE sessions ADD COLUMN subscription_id UUID REFERENCES subscriptions (id);
+
+CREATE INDEX idx_sessions_subscription_id ON sessions (subscription_id);
+
+-- ---------------------------------------------------------------------------
+-- 14. SESSION TAX
+--     Stripe Tax: the billing location collected at checkout and what was
+--     charged on top of the product price. Null on sessions checked out
+--     before tax was recorded, and on sessions covered by a subscription.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE sessions ADD COLUMN billing_country     TEXT;   -- ISO 3166-1 alpha-2, e.g. "DE"
+ALTER TABLE sessions ADD COLUMN billing_postal_code TEXT;
+ALTER TABLE sessions ADD COLUMN subtotal_cents      INT;    -- product price before tax
+ALTER TABLE sessions ADD COLUMN tax_cents           INT;    -- 0 when Stripe Tax is disabled
+ALTER TABLE sessions ADD COLUMN tax_calculation_id  TEXT;   -- Stripe tax calculation, recorded as a tax transaction on payment
+
+-- ---------------------------------------------------------------------------
+-- 15. INVOICES
+--     Billing details collected at checkout for the downloadable invoice, and
+--     the invoice number, assigned from a sequence the first time it is
+--     downloaded.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE sessions ADD COLUMN billing_name          TEXT;   -- legal name on the invoice; biz_name when null
+ALTER TABLE sessions ADD COLUMN billing_address_line1 TEXT;
+ALTER TABLE sessions ADD COLUMN billing_address_line2 TEXT;
+ALTER TABLE sessions ADD COLUMN billing_city          TEXT;
+ALTER TABLE sessions ADD COLUMN billing_tax_id        TEXT;   -- customer VAT/GST number, printed as given
+ALTER TABLE sessions ADD COLUMN invoice_number        BIGINT UNIQUE;
+
+CREATE SEQUENCE invoice_number_seq START 1000;
+
+-- ---------------------------------------------------------------------------
+-- 16. PAYMENTS
+--     Stripe's fee and the net amount for each successful charge, read from
+--     the charge's balance transaction. Amounts are in the settlement
+--     currency, which can differ from the currency the customer paid in.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE payments (
+    id                            UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    stripe_charge_id              TEXT        NOT NULL UNIQUE,
+    stripe_payment_intent         TEXT,                   -- null for charges not made through a PaymentIntent
+    stripe_balance_transaction_id TEXT        NOT NULL,
+    session_id                    UUID        REFERENCES sessions (id),  -- null for subscription invoices
+    amount_cents                  BIGINT      NOT NULL,   -- gross, before fees
+    fee_cents                     BIGINT      NOT NULL,
+    net_cents                     BIGINT      NOT NULL,   -- amount_cents - fee_cents
+    currency                      TEXT        NOT NULL,   -- settlement currency
+    created_at                    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at                    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payments_payment_intent ON payments (stripe_payment_intent);
+CREATE INDEX idx_payments_created_at     ON payments (created_at);
+
+-- ---------------------------------------------------------------------------
+-- 17. CHECKOUT FRAUD CHECKS
+--     Indexes behind the velocity checks run before a PaymentIntent is
+--     created (internal/fraud).
+-- ---------------------------------------------------------------------------
+
+CREATE INDEX idx_sessions_ip_hash_created_at ON sessions (ip_hash, created_at);
+
+-- ---------------------------------------------------------------------------
+-- 18. REPORT CLAIMS
+--     Which worker is generating a report, so replicas polling the same
+--     database do not all pick it up. A claim lapses at claim_expires_at so a
+--     crashed replica's reports are taken over.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports ADD COLUMN claimed_by       TEXT;
+ALTER TABLE reports ADD COLUMN claim_expires_at TIMESTAMPTZ;
+
+-- ---------------------------------------------------------------------------
+-- 19. REPORT REVOCATION
+--     A revoked report is soft-deleted: its access token stops working, for a
+--     customer's deletion request or a report bought with a fraudulent
+--     payment. The row stays for accounting.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports ADD COLUMN revoked_at     TIMESTAMPTZ;
+ALTER TABLE reports ADD COLUMN revoked_reason TEXT;
+
+-- ---------------------------------------------------------------------------
+-- 20. RETENTION
+--     Indexes for the age scans of the retention enforcer (internal/retention).
+-- ---------------------------------------------------------------------------
+
+CREATE INDEX idx_sessions_updated_at       ON sessions (updated_at);
+CREATE INDEX idx_stripe_events_received_at ON stripe_events (received_at);
+CREATE INDEX idx_email_log_created_at      ON email_log (created_at);
+
+-- ---------------------------------------------------------------------------
+-- 21. FIELD ENCRYPTION
+--     With FIELD_ENCRYPTION_KEYS set, sessions.email, subscriptions.email,
+--     email_log.to_address and stripe_events.payload hold ciphertext written
+--     by the store's codec (internal/fieldcrypt). Email lookups go through
+--     email_hash, a keyed hash of the lower-cased address, instead.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE sessions      ADD COLUMN email_hash TEXT;
+ALTER TABLE subscriptions ADD COLUMN email_hash TEXT;
+
+CREATE INDEX idx_sessions_email_hash      ON sessions (email_hash);
+CREATE INDEX idx_subscriptions_email_hash ON subscriptions (email_hash);
+
+-- ---------------------------------------------------------------------------
+-- 22. EMAIL TRACKING
+--     Opens, clicks and bounces reported by Resend's webhook, matched on
+--     provider_id. A report-ready email nobody opened is sent again once
+--     (resent_at) by the worker's resender.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE email_log ADD COLUMN clicked_at TIMESTAMPTZ;
+ALTER TABLE email_log ADD COLUMN bounced_at TIMESTAMPTZ;
+ALTER TABLE email_log ADD COLUMN resent_at  TIMESTAMPTZ;
+
+CREATE INDEX idx_email_log_provider_id ON email_log (provider_id);
+CREATE INDEX idx_email_log_unopened    ON email_log (sent_at)
+    WHERE template = 'report_ready' AND opened_at IS NULL AND resent_at IS NULL;
+
+-- ---------------------------------------------------------------------------
+-- 23. DUPLICATE PURCHASES
+--     A second card payment from the same email within the duplicate window
+--     whose answers (nearly) match the first. Its report is held (held_at)
+--     and not generated until an operator refunds it, keeps it as credit for
+--     a later re-assessment, or releases it. A session paid for with such a
+--     credit points at it through credit_from_session_id.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports  ADD COLUMN held_at TIMESTAMPTZ;
+ALTER TABLE sessions ADD COLUMN credit_from_session_id UUID REFERENCES sessions (id);
+
+-- One redemption per credit.
+CREATE UNIQUE INDEX idx_sessions_credit_from_session_id ON sessions (credit_from_session_id);
+
+CREATE TABLE duplicate_purchases (
+    session_id          UUID        PRIMARY KEY REFERENCES sessions (id) ON DELETE CASCADE,
+    original_session_id UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    changed_answers     INT         NOT NULL,
+    resolution          TEXT        CHECK (resolution IN ('refunded', 'credit', 'released')),
+    stripe_refund_id    TEXT,
+    resolved_at         TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_duplicate_purchases_unresolved ON duplicate_purchases (created_at)
+    WHERE resolution IS NULL;
+
+-- ---------------------------------------------------------------------------
+-- 24. EMAIL DEDUPLICATION
+--     Emails that must go out once per report (receipt, report-ready) are
+--     claimed by dedupe_key, "<report_id>:<template>", before they are sent.
+--     A failed send releases its claim so a retry can send again. A send
+--     skipped because its key was already claimed is logged with
+--     duplicate_of pointing at the claiming row.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE email_log ADD COLUMN dedupe_key   TEXT;
+ALTER TABLE email_log ADD COLUMN duplicate_of UUID REFERENCES email_log (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_email_log_dedupe_key ON email_log (dedupe_key) WHERE dedupe_key IS NOT NULL;
+
+-- ---------------------------------------------------------------------------
+-- 25. PARTNER ATTRIBUTION
+--     Sessions started in a partner's embedded widget record the partner,
+--     taken from the embed token the widget was loaded with.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE sessions ADD COLUMN partner TEXT;
+
+CREATE INDEX idx_sessions_partner ON sessions (partner) WHERE partner IS NOT NULL;
+
+-- ---------------------------------------------------------------------------
+-- 26. INDUSTRY PLAYBOOKS
+--     Operator-written snippets on an industry's regulations and tactics.
+--     The worker adds the most relevant active snippets for a session's
+--     industry to the AI prompt; keywords are matched against the wording of
+--     the risks being hedged.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE playbook_snippets (
+    slug            TEXT        PRIMARY KEY,    -- e.g. "retail-pci"
+    industry        TEXT        NOT NULL,       -- matched case-insensitively
+    title           TEXT        NOT NULL,
+    body            TEXT        NOT NULL,
+    keywords        TEXT[]      NOT NULL DEFAULT '{}',
+    active          BOOLEAN     NOT NULL DEFAULT true,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_playbook_snippets_industry ON playbook_snippets (lower(industry)) WHERE active;
+
+-- ---------------------------------------------------------------------------
+-- 27. AI GENERATION STAGES
+--     The output of each AI stage: the analysis (priorities and dependencies
+--     between risks) and the narrative written from it. Kept for debugging,
+--     and so a report's narrative can be regenerated from its stored analysis
+--     (armctl requeue-report -narrative). Cache entries keep the analysis
+--     too, so a cache hit stores the same as a fresh generation.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports  ADD COLUMN ai_analysis  JSONB;
+ALTER TABLE reports  ADD COLUMN ai_narrative JSONB;
+ALTER TABLE ai_cache ADD COLUMN analysis     JSONB;
+
+-- ---------------------------------------------------------------------------
+-- 28. RISK RELATIONSHIPS
+--     How a report's risks feed each other ("losing the founder would also
+--     cut the cash runway"), written by the AI with the hedges and shown as a
+--     section of the report: a JSON array of {from, to, description}, keyed
+--     by question_id.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports  ADD COLUMN relationships JSONB;
+ALTER TABLE ai_cache ADD COLUMN relationships JSONB;
+
+-- ---------------------------------------------------------------------------
+-- 29. AI QUALITY GATE
+--     Why AI output was rejected for this report (too short, banned phrases,
+--     unknown question IDs, not English), the issues separated by "; ". NULL
+--     when every reply passed or the AI did not run.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports ADD COLUMN ai_quality_issues TEXT;
+
+-- ---------------------------------------------------------------------------
+-- 30. AI TOKEN BUDGET
+--     What the per-report AI token budget cut (risks left off the prompt,
+--     calls refused once it ran out). NULL when the report stayed within it.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports ADD COLUMN ai_budget_note TEXT;
+
+-- ---------------------------------------------------------------------------
+-- 31. COHORT IMPORTS
+--     Sessions created by an admin bulk import of pre-answered assessments
+--     record the import's cohort label and are left out of sales and funnel
+--     stats. Their reports carry not_before, spaced COHORT_REPORTS_PER_MINUTE
+--     apart, and the poller skips them until then, so a cohort trickles
+--     through the worker instead of queueing ahead of paying customers.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE sessions ADD COLUMN cohort     TEXT;
+ALTER TABLE reports  ADD COLUMN not_before TIMESTAMPTZ;
+
+CREATE INDEX idx_sessions_cohort ON sessions (cohort) WHERE cohort IS NOT NULL;
+
+-- ---------------------------------------------------------------------------
+-- 32. FEATURE FLAGS
+--     Soft launches of risky behaviour. A row sets the share of sessions a
+--     flag is on for, in one environment or (environment '') in all of them;
+--     it overrides FEATURE_FLAGS for the same flag and environment. Reports
+--     record the state of every flag they were generated under.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE feature_flags (
+    name            TEXT        NOT NULL,               -- e.g. "score_profile"
+    environment     TEXT        NOT NULL DEFAULT '',    -- ENV value; '' for all
+    percent         SMALLINT    NOT NULL CHECK (percent BETWEEN 0 AND 100),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (name, environment)
+);
+
+ALTER TABLE reports ADD COLUMN flags JSONB;    -- flag name → on for this report
+
+-- ---------------------------------------------------------------------------
+-- 33. EXPERIMENTS
+--     Which variant of each running A/B experiment a session was assigned to
+--     when it was created. A session with no row for an experiment is not in
+--     it and gets the control. Kept after an experiment stops, for analysis.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE experiment_assignments (
+    session_id      UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    experiment      TEXT        NOT NULL,   -- e.g. "price"
+    variant         TEXT        NOT NULL,   -- "a" (control) or "b"
+    assigned_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (session_id, experiment)
+);
+
+CREATE INDEX idx_experiment_assignments_experiment ON experiment_assignments (experiment, variant);
+
+-- ---------------------------------------------------------------------------
+-- 34. AI TRANSCRIPTS
+--     The exact requests sent to the AI providers for one generation attempt
+--     of a report and their raw responses, for debugging what the AI said.
+--     The JSON lives in body, or in object storage under storage_key when it
+--     is configured. Deleted after RETENTION_AI_TRANSCRIPTS.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE ai_transcripts (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id       UUID        NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    exchanges       INT         NOT NULL,   -- provider calls recorded
+    storage_key     TEXT,                   -- set when body is in object storage
+    body            JSONB,                  -- set otherwise
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((storage_key IS NULL) <> (body IS NULL))
+);
+
+CREATE INDEX idx_ai_transcripts_report_id ON ai_transcripts (report_id, created_at);
+CREATE INDEX idx_ai_transcripts_created_at ON ai_transcripts (created_at);
+
+-- ---------------------------------------------------------------------------
+-- 35. AI EDITS
+--     Corrections an admin made to a generated ai_hedge or executive summary,
+--     with the value each replaced and who made it. The edited field carries
+--     its edit time so the report API can mark it as written by a person.
+--     Regenerating the report replaces the edited text; the history stays.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE ai_edits (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id       UUID        NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    field           TEXT        NOT NULL CHECK (field IN ('ai_hedge', 'executive_summary')),
+    question_id     TEXT,                   -- the risk, for ai_hedge
+    original        TEXT,                   -- value before the edit; NULL if there was none
+    edited          TEXT        NOT NULL,
+    editor          TEXT        NOT NULL,
+    reason          TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((field = 'ai_hedge') = (question_id IS NOT NULL))
+);
+
+CREATE INDEX idx_ai_edits_report_id ON ai_edits (report_id, created_at);
+
+ALTER TABLE risk_results ADD COLUMN ai_hedge_edited_at         TIMESTAMPTZ;
+ALTER TABLE reports      ADD COLUMN executive_summary_edited_at TIMESTAMPTZ;
+
+-- ---------------------------------------------------------------------------
+-- 36. FEEDBACK
+--     One row per report the worker asked for a rating and testimonial,
+--     created when the request email is claimed; token is the secret in the
+--     email's links. A testimonial is only published with consent_quote and
+--     after an admin approved it; consent_name adds the business name.
+--     email_suppressions holds the blind index (sessions.email_hash) of
+--     addresses that unsubscribed or bounced; optional emails skip them.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE feedback (
+    report_id       UUID        PRIMARY KEY REFERENCES reports (id) ON DELETE CASCADE,
+    token           TEXT        NOT NULL UNIQUE DEFAULT encode(gen_random_bytes(24), 'base64url'),
+    requested_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    rating          SMALLINT    CHECK (rating BETWEEN 0 AND 10),
+    testimonial     TEXT,
+    consent_quote   BOOLEAN     NOT NULL DEFAULT FALSE, -- testimonial may be published
+    consent_name    BOOLEAN     NOT NULL DEFAULT FALSE, -- ... with the business name
+    responded_at    TIMESTAMPTZ,
+    approved_at     TIMESTAMPTZ                         -- cleared when the response changes
+);
+
+CREATE INDEX idx_feedback_responded_at ON feedback (responded_at) WHERE responded_at IS NOT NULL;
+
+CREATE TABLE email_suppressions (
+    email_hash      TEXT        PRIMARY KEY,
+    reason          TEXT        NOT NULL,               -- 'unsubscribed' or 'bounced'
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- ---------------------------------------------------------------------------
+-- 37. SHADOW SCORES
+--     While the shadow_score_profile runtime setting names a candidate score
+--     profile, the worker also scores each new report with it and records
+--     both overall scores here, so a scoring change can be compared against
+--     production before rollout. Never shown to customers.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE shadow_scores (
+    report_id           UUID        NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    profile             TEXT        NOT NULL,   -- candidate, e.g. "top_n:5"
+    production_profile  TEXT        NOT NULL,   -- the profile the report was scored with
+    production_score    SMALLINT    NOT NULL,
+    shadow_score        SMALLINT    NOT NULL,
+    production_band     TEXT        NOT NULL,
+    shadow_band         TEXT        NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (report_id, profile)
+);
+
+CREATE INDEX idx_shadow_scores_created_at ON shadow_scores (created_at);
+
+-- ---------------------------------------------------------------------------
+-- 38. SUPPORT NOTES
+--     Free-text notes support staff keep on a session — a resend request, a
+--     refund reason — so the history of a customer's case sits next to their
+--     data. report_id is set on notes written against the session's report.
+--     The admin key is shared, so the author names themselves. Never shown
+--     to customers.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE support_notes (
+    id              UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id      UUID        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    report_id       UUID        REFERENCES reports (id) ON DELETE CASCADE,
+    author          TEXT        NOT NULL,
+    body            TEXT        NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_support_notes_session_id ON support_notes (session_id, created_at);
+
+-- ---------------------------------------------------------------------------
+-- 39. QUESTION SECTIONS
+--     One row per section_id: what the questionnaire shows above the
+--     section's questions, and the order sections are answered in. A gated
+--     section accepts answers only once every required question of the
+--     sections before it is answered, when SECTION_GATING is on.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE question_sections (
+    id              section_id  PRIMARY KEY,
+    title           TEXT        NOT NULL,
+    description     TEXT        NOT NULL DEFAULT '',
+    display_order   SMALLINT    NOT NULL,
+    gated           BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO question_sections (id, title, display_order)
+SELECT s.id, initcap(s.id::text), s.ord::smallint
+FROM unnest(enum_range(NULL::section_id)) WITH ORDINALITY AS s(id, ord);
+
+-- ---------------------------------------------------------------------------
+-- 40. SKIPPED ANSWERS
+--     A customer can mark a question as skipped (not applicable) rather than
+--     leave it blank. Skipped scoring questions are left out of the risks and
+--     the overall score instead of scoring (1,1), and the report lists them.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE answers ADD COLUMN skipped           BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reports ADD COLUMN skipped_questions TEXT[];
+
+-- ---------------------------------------------------------------------------
+-- 41. RISK EXPLANATIONS
+--     Why each risk scored as it did — the answer as scored, the question's
+--     P/I mapping, the tier rule and the risk's weight — captured at scoring
+--     time (scoring.Explanation). NULL for reports scored before it existed.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE risk_results ADD COLUMN explanation JSONB;
+
+-- ---------------------------------------------------------------------------
+-- 42. TIER THEMES
+--     How each risk tier is presented: its label, colour, description and
+--     how often a risk in it should be reviewed. Served with reports and the
+--     scoring metadata, so renaming a tier is a data change rather than a
+--     deploy. The tier boundaries themselves stay in internal/scoring.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE tier_themes (
+    tier            risk_tier   PRIMARY KEY,
+    label           TEXT        NOT NULL,
+    color           TEXT        NOT NULL CHECK (color ~ '^#[0-9a-f]{6}$'),
+    description     TEXT        NOT NULL,
+    cadence         TEXT        NOT NULL DEFAULT '',   -- e.g. "Review weekly"
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO tier_themes (tier, label, color, description, cadence) VALUES
+    ('watch',  'Watch',  '#b91c1c', 'Likely and severe: already on fire, slowly. Act on these first.',        'Review weekly'),
+    ('red',    'Red',    '#ea580c', 'Unlikely but existential if it happens. Hedge now, while it is cheap.', 'Review monthly'),
+    ('manage', 'Manage', '#ca8a04', 'Likely but survivable. Handle operationally.',                           'Review quarterly'),
+    ('ignore', 'Ignore', '#64748b', 'Unlikely and survivable. Not worth attention yet.',                      'Review yearly');
+
+-- ---------------------------------------------------------------------------
+-- 43. JOBS
+--     The worker's durable queue for background work other than scoring a
+--     report, which is queued by its reports row. A job runs once run_at has
+--     passed, so work can be queued for later. A worker claims a job for
+--     claimed_by until claim_expires_at; attempts counts the attempts that
+--     finished. A job with a dedupe_key is only queued once.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE jobs (
+    id               UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    type             TEXT        NOT NULL,   -- e.g. "process_stripe_event"
+    payload          JSONB       NOT NULL DEFAULT '{}',
+    dedupe_key       TEXT,
+    run_at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+    status           TEXT        NOT NULL DEFAULT 'pending'
+                                 CHECK (status IN ('pending', 'done', 'failed')),
+    attempts         INT         NOT NULL DEFAULT 0,
+    error            TEXT,                   -- the last attempt's, if it failed
+    claimed_by       TEXT,
+    claim_expires_at TIMESTAMPTZ,
+    finished_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_jobs_dedupe_key ON jobs (dedupe_key);
+CREATE INDEX idx_jobs_pending ON jobs (run_at) WHERE status = 'pending';
+
+-- ---------------------------------------------------------------------------
+-- 44. REPORT ATTEMPTS
+--     One row per failed attempt at scoring a report, kept alongside the
+--     final error_message on reports so failures can be looked at in bulk.
+--     provider is the AI provider whose call last failed during the attempt,
+--     or else the last one called, and provider_error how that call failed —
+--     a failed AI call falls back to static hedges, so it is rarely the
+--     attempt's own error.
+-- ---------------------------------------------------------------------------
+
+CREATE TABLE report_attempts (
+    id             UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_id      UUID        NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    attempt        INT         NOT NULL,   -- 1-based, within one claim
+    error          TEXT        NOT NULL,
+    provider       TEXT,                   -- e.g. "deepseek"; NULL when none was called
+    provider_error TEXT,
+    duration_ms    INT         NOT NULL,
+    worker_id      TEXT        NOT NULL,   -- the replica that ran it
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_report_attempts_report_id ON report_attempts (report_id, created_at);
+CREATE INDEX idx_report_attempts_created_at ON report_attempts (created_at);
+
+-- ---------------------------------------------------------------------------
+-- 45. JOB STATE
+--     The worker's checkpoint for a report it has not finished: the scored
+--     risks, then the AI output once that stage has succeeded. A retry
+--     resumes from it rather than calling the AI provider again. Cleared when
+--     the report is finalized or requeued.
+-- ---------------------------------------------------------------------------
+
+ALTER TABLE reports ADD COLUMN job_state JSONB;
+
+-- ---------------------------------------------------------------------------
+-- 46. PAGINATION
+--     Keyset indexes for the admin listings, which page newest first on
+--     (created_at, id).
+-- ---------------------------------------------------------------------------
+
+CREATE INDEX idx_sessions_created_at_id  ON sessions (created_at, id);
+CREATE INDEX idx_email_log_created_at_id ON email_log (created_at, id);
+
 -- ---------------------------------------------------------------------------
 -- TRIGGERS — auto-update updated_at
 -- ---------------------------------------------------------------------------
@@ -283,4 +912,40 @@ CREATE TRIGGER trg_answers_updated_at
 
 CREATE TRIGGER trg_reports_updated_at
     BEFORE UPDATE ON reports
-    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
\ No newline at end of file
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_runtime_settings_updated_at
+    BEFORE UPDATE ON runtime_settings
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_products_updated_at
+    BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_subscriptions_updated_at
+    BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_payments_updated_at
+    BEFORE UPDATE ON payments
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_playbook_snippets_updated_at
+    BEFORE UPDATE ON playbook_snippets
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_feature_flags_updated_at
+    BEFORE UPDATE ON feature_flags
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_question_sections_updated_at
+    BEFORE UPDATE ON question_sections
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_tier_themes_updated_at
+    BEFORE UPDATE ON tier_themes
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TRIGGER trg_jobs_updated_at
+    BEFORE UPDATE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();